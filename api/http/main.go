@@ -15,12 +15,15 @@ import (
 
 	"github.com/rexbrahh/lp-indexer/api/http/cache"
 	apitypes "github.com/rexbrahh/lp-indexer/api/http/types"
+	dexcommon "github.com/rexbrahh/lp-indexer/decoder/common"
+	"github.com/rexbrahh/lp-indexer/registry"
 )
 
 // Server bundles dependencies for the HTTP API.
 type Server struct {
 	router  *chi.Mux
 	cache   *cache.Cache
+	pools   *registry.Registry
 	logger  *log.Logger
 	started time.Time
 }
@@ -34,6 +37,7 @@ func NewServer(cacheClient *cache.Cache, logger *log.Logger) *Server {
 	s := &Server{
 		router:  chi.NewRouter(),
 		cache:   cacheClient,
+		pools:   registry.New(nil),
 		logger:  logger,
 		started: time.Now(),
 	}
@@ -42,11 +46,20 @@ func NewServer(cacheClient *cache.Cache, logger *log.Logger) *Server {
 	s.router.Route("/v1", func(r chi.Router) {
 		r.Get("/pool/{id}", s.poolHandler)
 		r.Get("/pool/{id}/candles", s.candlesHandler)
+		r.Get("/pools", s.poolsHandler)
+		r.Get("/pair/{id}", s.pairHandler)
+		r.Get("/pair/{id}/pools", s.pairPoolsHandler)
 	})
 
 	return s
 }
 
+// SetPoolRegistry replaces the empty in-memory registry the server starts
+// with.
+func (s *Server) SetPoolRegistry(reg *registry.Registry) {
+	s.pools = reg
+}
+
 // Handler exposes the underlying router for integration tests.
 func (s *Server) Handler() http.Handler {
 	return s.router
@@ -62,12 +75,84 @@ func (s *Server) healthzHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) poolHandler(w http.ResponseWriter, r *http.Request) {
-	poolID := chi.URLParam(r, "id")
+	pool, err := s.pools.Pool(chi.URLParam(r, "id"))
+	if errors.Is(err, registry.ErrNotFound) {
+		writeJSON(w, http.StatusNotFound, apitypes.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, poolResponse(pool))
+}
+
+// poolsHandler lists pools by ?mint= or ?pair= (either mint order).
+func (s *Server) poolsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	switch {
+	case query.Get("mint") != "":
+		writeJSON(w, http.StatusOK, poolsResponse(s.pools.PoolsByMint(query.Get("mint"))))
+	case query.Get("pair") != "":
+		s.writePairPools(w, query.Get("pair"))
+	default:
+		writeJSON(w, http.StatusBadRequest, apitypes.ErrorResponse{Error: "mint or pair query parameter is required"})
+	}
+}
+
+func (s *Server) pairPoolsHandler(w http.ResponseWriter, r *http.Request) {
+	s.writePairPools(w, chi.URLParam(r, "id"))
+}
+
+func (s *Server) writePairPools(w http.ResponseWriter, pairID string) {
+	pools, err := s.pools.PoolsByPair(pairID)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, apitypes.ErrorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, poolsResponse(pools))
+}
+
+func poolResponse(pool registry.Pool) apitypes.PoolResponse {
 	resp := apitypes.PoolResponse{
-		ID:         poolID,
-		Name:       "stub-pool",
-		BaseAsset:  "SOL",
-		QuoteAsset: "USDC",
+		ID:        pool.Address,
+		DEX:       pool.DEX,
+		ProgramID: pool.ProgramID,
+		BaseMint:  pool.BaseMint,
+		QuoteMint: pool.QuoteMint,
+		PairID:    pool.PairID,
+		FeeBps:    pool.FeeBps,
+		FirstSlot: pool.FirstSlot,
+		LastSlot:  pool.LastSlot,
+	}
+	if pair, err := dexcommon.ResolvePair(pool.BaseMint, pool.QuoteMint); err == nil {
+		resp.Name = pair.Symbol()
+		resp.BaseAsset, resp.QuoteAsset = pair.BaseToken, pair.QuoteToken
+	}
+	return resp
+}
+
+func poolsResponse(pools []registry.Pool) apitypes.PoolsResponse {
+	resp := apitypes.PoolsResponse{Pools: make([]apitypes.PoolResponse, 0, len(pools))}
+	for _, pool := range pools {
+		resp.Pools = append(resp.Pools, poolResponse(pool))
+	}
+	return resp
+}
+
+// pairHandler resolves "<mintA>-<mintB>" in either order to the canonical pair
+// using the same rules the ingestor applies to swap events.
+func (s *Server) pairHandler(w http.ResponseWriter, r *http.Request) {
+	pair, err := dexcommon.ResolvePairID(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, apitypes.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	resp := apitypes.PairResponse{
+		ID:         pair.ID(),
+		BaseMint:   pair.BaseMint,
+		QuoteMint:  pair.QuoteMint,
+		BaseAsset:  pair.BaseToken,
+		QuoteAsset: pair.QuoteToken,
+		Inverted:   pair.Inverted,
 	}
 
 	writeJSON(w, http.StatusOK, resp)
@@ -188,6 +273,35 @@ func main() {
 
 	server := NewServer(cacheClient, logger)
 
+	runCtx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	registryCfg, err := registry.FromEnv()
+	if err != nil {
+		logger.Fatalf("load pool registry config: %v", err)
+	}
+	if registryCfg != nil {
+		if registryCfg.ReloadInterval == 0 {
+			registryCfg.ReloadInterval = registryCfg.FlushInterval
+		}
+		pools, err := registry.Open(runCtx, registryCfg)
+		if err != nil {
+			logger.Fatalf("open pool registry: %v", err)
+		}
+		defer pools.Close()
+		server.SetPoolRegistry(pools)
+		go pools.Run(runCtx, registryCfg.FlushInterval, registryCfg.ReloadInterval)
+	} else {
+		logger.Println("pool registry disabled: REGISTRY_DSN and REGISTRY_PATH not set")
+	}
+	tokenList, err := dexcommon.TokenListWatcherFromEnv()
+	if err != nil {
+		logger.Fatalf("load token list: %v", err)
+	}
+	if tokenList != nil {
+		go tokenList.Run(runCtx, func(err error) { logger.Printf("reload token list: %v", err) })
+	}
+
 	addr := os.Getenv("API_HTTP_ADDR")
 	if addr == "" {
 		addr = ":8080"
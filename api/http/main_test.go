@@ -11,6 +11,8 @@ import (
 
 	"github.com/rexbrahh/lp-indexer/api/http/cache"
 	apitypes "github.com/rexbrahh/lp-indexer/api/http/types"
+	orcawhirlpool "github.com/rexbrahh/lp-indexer/decoder/orca_whirlpool"
+	"github.com/rexbrahh/lp-indexer/registry"
 )
 
 func newTestServer(t *testing.T) *Server {
@@ -51,25 +53,114 @@ func TestHealthzHandler(t *testing.T) {
 }
 
 func TestPoolHandler(t *testing.T) {
+	const (
+		usdc = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+		sol  = "So11111111111111111111111111111111111111112"
+	)
 	srv := newTestServer(t)
+	pools := registry.New(nil)
+	pools.Observe(registry.Pool{Address: "ABCD", ProgramID: orcawhirlpool.WhirlpoolProgramID, MintA: usdc, MintB: sol, FeeBps: 30})
+	srv.SetPoolRegistry(pools)
 
-	req := httptest.NewRequest(http.MethodGet, "/v1/pool/ABCD", nil)
-	rr := httptest.NewRecorder()
+	t.Run("known pool", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/pool/ABCD", nil)
+		rr := httptest.NewRecorder()
 
-	srv.Handler().ServeHTTP(rr, req)
+		srv.Handler().ServeHTTP(rr, req)
 
-	if rr.Code != http.StatusOK {
-		t.Fatalf("expected status 200, got %d", rr.Code)
-	}
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rr.Code)
+		}
 
-	var resp apitypes.PoolResponse
-	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
-		t.Fatalf("decode response: %v", err)
-	}
+		var resp apitypes.PoolResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
 
-	if resp.ID != "ABCD" {
-		t.Fatalf("expected pool id ABCD, got %s", resp.ID)
+		if resp.ID != "ABCD" || resp.Name != "SOL/USDC" || resp.DEX != "orca_whirlpool" || resp.PairID != sol+"-"+usdc || resp.FeeBps != 30 {
+			t.Fatalf("unexpected pool response %+v", resp)
+		}
+	})
+
+	t.Run("unknown pool", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/pool/EFGH", nil)
+		rr := httptest.NewRecorder()
+
+		srv.Handler().ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusNotFound {
+			t.Fatalf("expected status 404, got %d", rr.Code)
+		}
+	})
+
+	for _, target := range []string{"/v1/pools?mint=" + usdc, "/v1/pools?pair=" + usdc + "-" + sol, "/v1/pair/" + sol + "-" + usdc + "/pools"} {
+		t.Run(target, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			rr := httptest.NewRecorder()
+
+			srv.Handler().ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", rr.Code)
+			}
+			var resp apitypes.PoolsResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if len(resp.Pools) != 1 || resp.Pools[0].ID != "ABCD" {
+				t.Fatalf("unexpected pools response %+v", resp)
+			}
+		})
 	}
+
+	t.Run("missing lookup", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/pools", nil)
+		rr := httptest.NewRecorder()
+
+		srv.Handler().ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("expected status 400, got %d", rr.Code)
+		}
+	})
+}
+
+func TestPairHandler(t *testing.T) {
+	const (
+		usdc = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+		sol  = "So11111111111111111111111111111111111111112"
+	)
+	srv := newTestServer(t)
+
+	t.Run("inverted order", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/pair/"+usdc+"-"+sol, nil)
+		rr := httptest.NewRecorder()
+
+		srv.Handler().ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rr.Code)
+		}
+
+		var resp apitypes.PairResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		if resp.ID != sol+"-"+usdc || resp.BaseAsset != "SOL" || resp.QuoteAsset != "USDC" || !resp.Inverted {
+			t.Fatalf("unexpected pair response %+v", resp)
+		}
+	})
+
+	t.Run("malformed id", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/pair/"+sol, nil)
+		rr := httptest.NewRecorder()
+
+		srv.Handler().ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("expected status 400, got %d", rr.Code)
+		}
+	})
 }
 
 func TestCandlesHandler(t *testing.T) {
@@ -7,6 +7,8 @@ type (
 	HealthResponse = apitypes.HealthResponse
 	// PoolResponse aliases the shared pool payload.
 	PoolResponse = apitypes.PoolResponse
+	// PairResponse aliases the canonical pair payload.
+	PairResponse = apitypes.PairResponse
 	// Candle aliases the OHLCV representation.
 	Candle = apitypes.Candle
 	// CandlesResponse aliases the candle response payload.
@@ -18,6 +18,29 @@ type PoolResponse struct {
 	Name       string `json:"name"`
 	BaseAsset  string `json:"base_asset"`
 	QuoteAsset string `json:"quote_asset"`
+	DEX        string `json:"dex"`
+	ProgramID  string `json:"program_id"`
+	BaseMint   string `json:"base_mint"`
+	QuoteMint  string `json:"quote_mint"`
+	PairID     string `json:"pair_id"`
+	FeeBps     uint32 `json:"fee_bps"`
+	FirstSlot  uint64 `json:"first_slot"`
+	LastSlot   uint64 `json:"last_slot"`
+}
+
+// PoolsResponse lists the pools matching a mint or pair lookup.
+type PoolsResponse struct {
+	Pools []PoolResponse `json:"pools"`
+}
+
+// PairResponse describes the canonical ordering of a token pair.
+type PairResponse struct {
+	ID         string `json:"id"`
+	BaseMint   string `json:"base_mint"`
+	QuoteMint  string `json:"quote_mint"`
+	BaseAsset  string `json:"base_asset"`
+	QuoteAsset string `json:"quote_asset"`
+	Inverted   bool   `json:"inverted"`
 }
 
 // Candle represents OHLCV data for a pooled market.
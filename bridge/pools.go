@@ -0,0 +1,56 @@
+package bridge
+
+import (
+	"strconv"
+	"strings"
+
+	nats "github.com/nats-io/nats.go"
+	"google.golang.org/protobuf/proto"
+
+	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+	"github.com/rexbrahh/lp-indexer/registry"
+)
+
+const (
+	poolCandleSubjectPrefix = "dex.sol.candle.pool."
+
+	// Headers stamped on forwarded pool candles when the pool is registered.
+	HeaderPairID = "Dex-Pair-Id"
+	HeaderDEX    = "Dex-Name"
+	HeaderFeeBps = "Dex-Fee-Bps"
+)
+
+// WithPoolRegistry annotates forwarded pool candles with the pool's canonical
+// pair, DEX and fee tier from the registry so legacy consumers do not need
+// their own pool table. Payloads are forwarded unchanged.
+func WithPoolRegistry(reg *registry.Registry) Option {
+	return func(s *Service) {
+		s.pools = reg
+	}
+}
+
+// annotatePool adds registry headers to publish when msg is a candle for a
+// known pool.
+func (s *Service) annotatePool(msg, publish *nats.Msg) {
+	if s.pools == nil || !strings.HasPrefix(msg.Subject, poolCandleSubjectPrefix) {
+		return
+	}
+	var candle dexv1.Candle
+	if err := proto.Unmarshal(msg.Data, &candle); err != nil {
+		return
+	}
+	pool, err := s.pools.Pool(candle.GetPoolId())
+	if err != nil || pool.PairID == "" {
+		return
+	}
+	if publish.Header == nil {
+		publish.Header = nats.Header{}
+	}
+	publish.Header.Set(HeaderPairID, pool.PairID)
+	if pool.DEX != "" {
+		publish.Header.Set(HeaderDEX, pool.DEX)
+	}
+	if pool.FeeBps != 0 {
+		publish.Header.Set(HeaderFeeBps, strconv.FormatUint(uint64(pool.FeeBps), 10))
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/rexbrahh/lp-indexer/observability"
+	"github.com/rexbrahh/lp-indexer/registry"
 )
 
 // SubjectMapper maps canonical subjects to legacy equivalents. Returning ok=false
@@ -41,6 +42,7 @@ type Service struct {
 	customMapper   bool
 	metrics        *serviceMetrics
 	metricsServer  string
+	pools          *registry.Registry
 }
 
 // New creates a Service with validated configuration.
@@ -254,6 +256,7 @@ func (s *Service) forward(ctx context.Context, targetJS nats.JetStreamContext, m
 	if len(msg.Header) > 0 {
 		publish.Header = cloneHeader(msg.Header)
 	}
+	s.annotatePool(msg, publish)
 
 	pubCtx, cancel := s.publishContext(ctx)
 	ack, err := targetJS.PublishMsg(publish, nats.Context(pubCtx), nats.ExpectStream(s.cfg.TargetStream))
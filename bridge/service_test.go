@@ -10,6 +10,11 @@ import (
 
 	server "github.com/nats-io/nats-server/v2/server"
 	nats "github.com/nats-io/nats.go"
+	"google.golang.org/protobuf/proto"
+
+	orcawhirlpool "github.com/rexbrahh/lp-indexer/decoder/orca_whirlpool"
+	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+	"github.com/rexbrahh/lp-indexer/registry"
 )
 
 func TestServiceBridgesMessages(t *testing.T) {
@@ -162,3 +167,34 @@ func waitFor(t *testing.T, timeout time.Duration, fn func() error) {
 	}
 	t.Fatalf("waitFor: %v", lastErr)
 }
+
+func TestAnnotatePoolCandle(t *testing.T) {
+	const (
+		usdc = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+		sol  = "So11111111111111111111111111111111111111112"
+	)
+	pools := registry.New(nil)
+	pools.Observe(registry.Pool{Address: "pool1", ProgramID: orcawhirlpool.WhirlpoolProgramID, MintA: usdc, MintB: sol, FeeBps: 4})
+	svc := &Service{pools: pools}
+
+	data, err := proto.Marshal(&dexv1.Candle{PoolId: "pool1", Timeframe: "1m"})
+	if err != nil {
+		t.Fatalf("marshal candle: %v", err)
+	}
+	msg := &nats.Msg{Subject: "dex.sol.candle.pool.1m", Data: data}
+	publish := &nats.Msg{Subject: "legacy.dex.candle.pool.1m", Data: data}
+	svc.annotatePool(msg, publish)
+	if got := publish.Header.Get(HeaderPairID); got != sol+"-"+usdc {
+		t.Fatalf("%s = %q", HeaderPairID, got)
+	}
+	if publish.Header.Get(HeaderDEX) != "orca_whirlpool" || publish.Header.Get(HeaderFeeBps) != "4" {
+		t.Fatalf("unexpected headers %v", publish.Header)
+	}
+
+	swap := &nats.Msg{Subject: "dex.sol.swap.raydium", Data: data}
+	forwarded := &nats.Msg{Subject: "legacy.swap.raydium", Data: data}
+	svc.annotatePool(swap, forwarded)
+	if forwarded.Header != nil {
+		t.Fatalf("non-candle message annotated: %v", forwarded.Header)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/collectors"
 
 	"github.com/rexbrahh/lp-indexer/bridge"
+	"github.com/rexbrahh/lp-indexer/registry"
 )
 
 func main() {
@@ -21,23 +22,41 @@ func main() {
 		logger.Fatalf("load config: %v", err)
 	}
 
-	registry := prometheus.NewRegistry()
-	registry.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
-	registry.MustRegister(collectors.NewGoCollector())
+	promReg := prometheus.NewRegistry()
+	promReg.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	promReg.MustRegister(collectors.NewGoCollector())
 
-	opts := []bridge.Option{bridge.WithMetricsRegisterer(registry, registry)}
+	opts := []bridge.Option{bridge.WithMetricsRegisterer(promReg, promReg)}
 	if cfg.MetricsAddr != "" {
 		opts = append(opts, bridge.WithMetricsServer(cfg.MetricsAddr))
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	registryCfg, err := registry.FromEnv()
+	if err != nil {
+		logger.Fatalf("load pool registry config: %v", err)
+	}
+	if registryCfg != nil {
+		pools, err := registry.Open(ctx, registryCfg)
+		if err != nil {
+			logger.Fatalf("open pool registry: %v", err)
+		}
+		defer pools.Close()
+		reload := registryCfg.ReloadInterval
+		if reload == 0 {
+			reload = registryCfg.FlushInterval
+		}
+		go pools.Run(ctx, registryCfg.FlushInterval, reload)
+		opts = append(opts, bridge.WithPoolRegistry(pools))
+	}
+
 	svc, err := bridge.New(cfg, opts...)
 	if err != nil {
 		logger.Fatalf("init bridge: %v", err)
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
@@ -15,8 +15,12 @@ import (
 	nats "github.com/nats-io/nats.go"
 	"google.golang.org/protobuf/proto"
 
+	dexcommon "github.com/rexbrahh/lp-indexer/decoder/common"
 	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+	"github.com/rexbrahh/lp-indexer/pricing"
+	"github.com/rexbrahh/lp-indexer/registry"
 	"github.com/rexbrahh/lp-indexer/sinks/clickhouse"
+	"github.com/rexbrahh/lp-indexer/sinks/nats/provision"
 	parquetSink "github.com/rexbrahh/lp-indexer/sinks/parquet"
 )
 
@@ -57,6 +61,10 @@ func main() {
 		logger.Fatalf("jetstream: %v", err)
 	}
 
+	if err := provision.VerifyFromEnv(js, *stream, *durable); err != nil {
+		logger.Fatalf("verify jetstream: %v", err)
+	}
+
 	pullOpts := []nats.SubOpt{nats.BindStream(*stream)}
 	sub, err := js.PullSubscribe(*subject, *durable, pullOpts...)
 	if err != nil {
@@ -74,6 +82,34 @@ func main() {
 		cancel()
 	}()
 
+	tokenList, err := dexcommon.TokenListWatcherFromEnv()
+	if err != nil {
+		logger.Fatalf("load token list: %v", err)
+	}
+	if tokenList != nil {
+		go tokenList.Run(ctx, func(err error) { logger.Printf("reload token list: %v", err) })
+	}
+
+	// The registry is only read here; reloading picks up pools the ingestor
+	// records after start-up.
+	var pools *registry.Registry
+	registryCfg, err := registry.FromEnv()
+	if err != nil {
+		logger.Fatalf("load pool registry config: %v", err)
+	}
+	if registryCfg != nil {
+		pools, err = registry.Open(ctx, registryCfg)
+		if err != nil {
+			logger.Fatalf("open pool registry: %v", err)
+		}
+		defer pools.Close()
+		reload := registryCfg.ReloadInterval
+		if reload == 0 {
+			reload = registryCfg.FlushInterval
+		}
+		go pools.Run(ctx, registryCfg.FlushInterval, reload)
+	}
+
 	writerCfg := clickhouse.Config{
 		DSN:          *clickhouseDSN,
 		Database:     *clickhouseDatabase,
@@ -111,10 +147,17 @@ func main() {
 		defer parquetWriter.Close()
 	}
 
+	priceCfg, err := pricing.FromEnv()
+	if err != nil {
+		logger.Fatalf("load price oracle config: %v", err)
+	}
+
 	bridge := &Bridge{
 		logger:  logger,
 		writer:  writer,
 		parquet: parquetWriter,
+		pools:   pools,
+		prices:  pricing.New(priceCfg),
 	}
 
 	wait := time.Duration(*pullWaitMs) * time.Millisecond
@@ -146,6 +189,8 @@ type Bridge struct {
 	logger  *log.Logger
 	writer  *clickhouse.Writer
 	parquet *parquetSink.Writer
+	pools   *registry.Registry
+	prices  *pricing.Oracle
 }
 
 func (b *Bridge) Process(ctx context.Context, msgs []*nats.Msg) error {
@@ -165,6 +210,9 @@ func (b *Bridge) Process(ctx context.Context, msgs []*nats.Msg) error {
 			continue
 		}
 
+		b.fillPairID(&candle)
+		canonicalizeCandle(&candle)
+		b.valueCandle(&candle)
 		clickhouseRows = append(clickhouseRows, translateCandle(&candle))
 		parquetRows = append(parquetRows, &candle)
 		ackMsgs = append(ackMsgs, msg)
@@ -204,6 +252,77 @@ func (b *Bridge) nakAll(msgs []*nats.Msg) {
 
 const q32Factor = 4294967296.0
 
+// fillPairID stamps candles published without a pair_id with the pool's raw
+// mint order from the registry, so canonicalizeCandle can orient them.
+func (b *Bridge) fillPairID(c *dexv1.Candle) {
+	if c.GetPairId() != "" || b.pools == nil {
+		return
+	}
+	if pool, err := b.pools.Pool(c.GetPoolId()); err == nil && pool.MintA != "" {
+		c.PairId = pool.RawPairID()
+	}
+}
+
+// canonicalizeCandle applies the swap pair ordering rules to candles whose
+// pair_id lists the mints in raw pool order, inverting prices and swapping
+// the base/quote volumes so every sink sees the same orientation.
+func canonicalizeCandle(c *dexv1.Candle) {
+	pair, err := dexcommon.ResolvePairID(c.GetPairId())
+	if err != nil || !pair.Inverted {
+		return
+	}
+	c.PairId = pair.ID()
+	open, high, low, closePx := c.GetOpenPxQ32(), c.GetHighPxQ32(), c.GetLowPxQ32(), c.GetClosePxQ32()
+	c.OpenPxQ32 = dexcommon.InvertPriceQ32(open)
+	c.HighPxQ32 = dexcommon.InvertPriceQ32(low)
+	c.LowPxQ32 = dexcommon.InvertPriceQ32(high)
+	c.ClosePxQ32 = dexcommon.InvertPriceQ32(closePx)
+	c.VolBase, c.VolQuote = c.VolQuote, c.VolBase
+	c.VwapNum, c.VwapDen = c.VwapDen, c.VwapNum
+}
+
+// priceTimeframe is the candle timeframe fed to the price oracle. A single
+// timeframe keeps the volume weights of different pools comparable.
+const priceTimeframe = "1m"
+
+// valueCandle stamps a canonical candle with its USD close and quote volume.
+// Per-pool candles of priceTimeframe also update the oracle, using their
+// quote volume as the pool's weight. Candle time drives staleness so replayed
+// windows are valued against prices of their own era.
+func (b *Bridge) valueCandle(c *dexv1.Candle) {
+	if b.prices == nil {
+		return
+	}
+	base, quote, err := dexcommon.ParsePairID(c.GetPairId())
+	if err != nil {
+		return
+	}
+	at := time.Unix(int64(c.GetWindowStart()), 0).UTC()
+	closePx := priceFromQ32(c.GetClosePxQ32())
+	decimals, haveDecimals := b.prices.Decimals(quote)
+	volQuote := volumeFromU128(c.GetVolQuote()) / math.Pow10(int(decimals))
+
+	if c.GetPoolId() != "" && c.GetTimeframe() == priceTimeframe && haveDecimals {
+		b.prices.Observe(pricing.Quote{
+			PoolID: c.GetPoolId(),
+			Base:   base,
+			Quote:  quote,
+			Price:  closePx,
+			Depth:  volQuote,
+			At:     at,
+		})
+	}
+
+	quoteUSD, ok := b.prices.PriceUSD(quote, at)
+	if !ok {
+		return
+	}
+	c.PriceUsd = closePx * quoteUSD
+	if haveDecimals {
+		c.VolumeUsd = volQuote * quoteUSD
+	}
+}
+
 func translateCandle(c *dexv1.Candle) clickhouse.Candle {
 	return clickhouse.Candle{
 		Timestamp: time.Unix(int64(c.GetWindowStart()), 0).UTC(),
@@ -213,6 +332,8 @@ func translateCandle(c *dexv1.Candle) clickhouse.Candle {
 		Low:       priceFromQ32(c.GetLowPxQ32()),
 		Close:     priceFromQ32(c.GetClosePxQ32()),
 		Volume:    volumeFromU128(c.GetVolQuote()),
+		PriceUSD:  c.GetPriceUsd(),
+		VolumeUSD: c.GetVolumeUsd(),
 	}
 }
 
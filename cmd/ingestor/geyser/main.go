@@ -6,9 +6,17 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	dexcommon "github.com/rexbrahh/lp-indexer/decoder/common"
+	swapdecoder "github.com/rexbrahh/lp-indexer/ingestor/decoder"
 	"github.com/rexbrahh/lp-indexer/ingestor/geyser"
 	"github.com/rexbrahh/lp-indexer/ingestor/helius"
+	"github.com/rexbrahh/lp-indexer/ingestor/mintmeta"
+	"github.com/rexbrahh/lp-indexer/ingestor/rpcpoll"
+	"github.com/rexbrahh/lp-indexer/ingestor/solrpc"
+	"github.com/rexbrahh/lp-indexer/pricing"
+	"github.com/rexbrahh/lp-indexer/registry"
 	natsx "github.com/rexbrahh/lp-indexer/sinks/nats"
 )
 
@@ -28,32 +36,84 @@ func main() {
 
 	metricsAddr := os.Getenv("INGESTOR_METRICS_ADDR")
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	registryCfg, err := registry.FromEnv()
+	if err != nil {
+		logger.Fatalf("load pool registry config: %v", err)
+	}
+	var pools *registry.Registry
+	if registryCfg != nil {
+		pools, err = registry.Open(ctx, registryCfg)
+		if err != nil {
+			logger.Fatalf("open pool registry: %v", err)
+		}
+		defer pools.Close()
+		// Only the Token-2022 mints of indexed pools are streamed; others are
+		// loaded on demand when DECODER_RPC_URL is set.
+		geyserCfg.TokenMints = pools.Mints
+	}
+
 	var service interface {
 		Run(ctx context.Context, startSlot uint64) error
+		SetMintMetadata(provider *mintmeta.Provider)
+		SetAccountLoader(loader swapdecoder.AccountLoader, timeout time.Duration)
+		SetPoolRegistry(reg *registry.Registry)
+		SetPriceOracle(oracle *pricing.Oracle)
+		SetOracleFeeds(feeds []geyser.OracleFeed)
 	}
 
+	var fallbacks []geyser.ClientInterface
 	if os.Getenv("ENABLE_HELIUS_FALLBACK") == "1" {
 		logger.Println("Helius fallback enabled")
-		primaryClient, err := geyser.NewClient(geyserCfg)
-		if err != nil {
-			logger.Fatalf("init geyser client: %v", err)
-		}
-
 		heliusCfg, err := helius.FromEnv()
 		if err != nil {
 			logger.Fatalf("load helius config: %v", err)
 		}
 		heliusCfg.ProgramFilters = geyserCfg.ProgramFilters
+		heliusCfg.OracleAccounts = geyserCfg.OracleAccounts()
+		heliusCfg.TokenMints = geyserCfg.TokenMints
 
-		fallbackClient, err := helius.NewStreamClient(heliusCfg)
+		fallbackClient, err := helius.NewClient(heliusCfg)
 		if err != nil {
 			logger.Fatalf("init helius client: %v", err)
 		}
+		fallbacks = append(fallbacks, fallbackClient)
+	}
+	if os.Getenv("RPC_POLL_URL") != "" {
+		logger.Println("JSON-RPC polling fallback enabled")
+		pollCfg, err := rpcpoll.FromEnv()
+		if err != nil {
+			logger.Fatalf("load rpc poll config: %v", err)
+		}
+		pollCfg.ProgramFilters = geyserCfg.ProgramFilters
+
+		pollClient, err := rpcpoll.NewClient(pollCfg)
+		if err != nil {
+			logger.Fatalf("init rpc poll client: %v", err)
+		}
+		fallbacks = append(fallbacks, pollClient)
+	}
+
+	if len(fallbacks) > 0 {
+		primaryClient, err := geyser.NewClient(geyserCfg)
+		if err != nil {
+			logger.Fatalf("init geyser client: %v", err)
+		}
 
-		service, err = geyser.NewFailoverService(primaryClient, fallbackClient, natsCfg, metricsAddr)
+		failover, err := geyser.NewFailoverService(primaryClient, fallbacks[0], natsCfg, metricsAddr)
 		if err != nil {
 			logger.Fatalf("init failover service: %v", err)
 		}
+		for _, client := range fallbacks[1:] {
+			failover.AddFallback(client)
+		}
+		failover.SetCaptureFailedSwaps(geyserCfg.CaptureFailedSwaps)
+		if err := failover.EnableGapRepair(geyserCfg); err != nil {
+			logger.Fatalf("init gap repair: %v", err)
+		}
+		service = failover
 	} else {
 		svc, err := geyser.NewService(geyserCfg, natsCfg, metricsAddr)
 		if err != nil {
@@ -62,8 +122,47 @@ func main() {
 		service = svc
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	if url := os.Getenv("DECODER_RPC_URL"); url != "" {
+		logger.Println("on-demand account loading enabled")
+		service.SetAccountLoader(solrpc.NewClient(url, swapdecoder.DefaultLoadTimeout), swapdecoder.DefaultLoadTimeout)
+	}
+
+	mintsDone := make(chan struct{})
+	mintCfg, err := mintmeta.FromEnv()
+	if err != nil {
+		logger.Fatalf("load mint metadata config: %v", err)
+	}
+	if mintCfg != nil {
+		provider, err := mintmeta.NewProvider(mintCfg)
+		if err != nil {
+			logger.Fatalf("init mint metadata provider: %v", err)
+		}
+		service.SetMintMetadata(provider)
+		go func() {
+			defer close(mintsDone)
+			provider.Run(ctx)
+		}()
+	} else {
+		close(mintsDone)
+	}
+
+	poolsDone := make(chan struct{})
+	if pools != nil {
+		service.SetPoolRegistry(pools)
+		go func() {
+			defer close(poolsDone)
+			pools.Run(ctx, registryCfg.FlushInterval, registryCfg.ReloadInterval)
+		}()
+	} else {
+		close(poolsDone)
+	}
+
+	priceCfg, err := pricing.FromEnv()
+	if err != nil {
+		logger.Fatalf("load price oracle config: %v", err)
+	}
+	service.SetPriceOracle(pricing.New(priceCfg))
+	service.SetOracleFeeds(geyserCfg.OracleFeeds)
 
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
@@ -73,10 +172,21 @@ func main() {
 		cancel()
 	}()
 
+	tokenList, err := dexcommon.TokenListWatcherFromEnv()
+	if err != nil {
+		logger.Fatalf("load token list: %v", err)
+	}
+	if tokenList != nil {
+		go tokenList.Run(ctx, func(err error) { logger.Printf("reload token list: %v", err) })
+	}
+
 	startSlot := uint64(0)
 	if err := service.Run(ctx, startSlot); err != nil && err != context.Canceled {
 		logger.Fatalf("service run failed: %v", err)
 	}
+	cancel()
+	<-mintsDone
+	<-poolsDone
 
 	logger.Println("service stopped")
 }
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	dexcommon "github.com/rexbrahh/lp-indexer/decoder/common"
+	"github.com/rexbrahh/lp-indexer/ingestor/common"
+	swapdecoder "github.com/rexbrahh/lp-indexer/ingestor/decoder"
+	"github.com/rexbrahh/lp-indexer/ingestor/geyser"
+	"github.com/rexbrahh/lp-indexer/ingestor/helius"
+	"github.com/rexbrahh/lp-indexer/ingestor/solrpc"
+	"github.com/rexbrahh/lp-indexer/pricing"
+	natsx "github.com/rexbrahh/lp-indexer/sinks/nats"
+)
+
+func main() {
+	logger := log.New(os.Stdout, "ingestor-helius-webhook ", log.LstdFlags|log.Lshortfile)
+
+	webhookCfg, err := helius.WebhookConfigFromEnv()
+	if err != nil {
+		logger.Fatalf("load webhook config: %v", err)
+	}
+
+	natsCfg, err := natsx.FromEnv()
+	if err != nil {
+		logger.Fatalf("load nats config: %v", err)
+	}
+	publisher, err := natsx.NewPublisher(natsCfg)
+	if err != nil {
+		logger.Fatalf("init nats publisher: %v", err)
+	}
+	defer publisher.Close()
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	registry.MustRegister(collectors.NewGoCollector())
+	if err := publisher.RegisterMetrics(registry); err != nil {
+		logger.Fatalf("register publisher metrics: %v", err)
+	}
+
+	slotCache, err := common.SlotTimeCacheFromEnv(publisher.JetStream())
+	if err != nil {
+		logger.Fatalf("init slot cache: %v", err)
+	}
+	processor := geyser.NewProcessor(publisher, slotCache, registry)
+	// Webhooks carry no account updates, so pools, AmmConfigs and Token-2022
+	// mints can only be loaded over RPC. Without them Orca swaps are dropped
+	// and Raydium and Token-2022 fees are unknown.
+	rpcURL := os.Getenv("DECODER_RPC_URL")
+	if rpcURL == "" {
+		logger.Fatalf("DECODER_RPC_URL is required: the webhook has no account stream to decode pools from")
+	}
+	processor.SetAccountLoader(solrpc.NewClient(rpcURL, swapdecoder.DefaultLoadTimeout), swapdecoder.DefaultLoadTimeout)
+	if v := os.Getenv("GEYSER_CAPTURE_FAILED_SWAPS"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			logger.Fatalf("invalid GEYSER_CAPTURE_FAILED_SWAPS: %v", err)
+		}
+		processor.SetCaptureFailedSwaps(enabled)
+	}
+	priceCfg, err := pricing.FromEnv()
+	if err != nil {
+		logger.Fatalf("load price oracle config: %v", err)
+	}
+	processor.SetPriceOracle(pricing.New(priceCfg))
+
+	handler, err := helius.NewWebhookHandler(webhookCfg, processor)
+	if err != nil {
+		logger.Fatalf("init webhook handler: %v", err)
+	}
+	handler.SetDeadLetter(publisher)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		logger.Println("shutdown signal received")
+		cancel()
+	}()
+
+	tokenList, err := dexcommon.TokenListWatcherFromEnv()
+	if err != nil {
+		logger.Fatalf("load token list: %v", err)
+	}
+	if tokenList != nil {
+		go tokenList.Run(ctx, func(err error) { logger.Printf("reload token list: %v", err) })
+	}
+
+	logger.Printf("listening for Helius webhooks on %s", webhookCfg.Addr)
+	if err := handler.ListenAndServe(ctx, mux); err != nil && err != context.Canceled {
+		logger.Fatalf("webhook server failed: %v", err)
+	}
+
+	logger.Println("service stopped")
+}
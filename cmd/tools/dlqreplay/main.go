@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	proto "google.golang.org/protobuf/proto"
+
+	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+	"github.com/rexbrahh/lp-indexer/ingestor/common"
+	swapdecoder "github.com/rexbrahh/lp-indexer/ingestor/decoder"
+	"github.com/rexbrahh/lp-indexer/ingestor/solrpc"
+	natsx "github.com/rexbrahh/lp-indexer/sinks/nats"
+)
+
+// dlqreplay re-runs transactions from the decode dead-letter subject through
+// the decoder in this build. By default it only reports which entries now
+// decode; with -publish the recovered swaps are published as final events,
+// preceded by a block head carrying their slot's time so sinks can stamp
+// them. Pool and mint accounts and block times are loaded from -rpc-url.
+func main() {
+	natsURL := flag.String("nats-url", "nats://127.0.0.1:4222", "NATS server URL")
+	stream := flag.String("stream", "DEX", "JetStream stream holding the dead-letter subject")
+	subjectRoot := flag.String("subject-root", "dex.sol", "subject root")
+	publish := flag.Bool("publish", false, "publish recovered swaps instead of only reporting them")
+	limit := flag.Int("limit", 0, "maximum number of dead-letter entries to replay (0 = all)")
+	idleTimeout := flag.Duration("idle-timeout", 5*time.Second, "stop after waiting this long for the next entry")
+	rpcURL := flag.String("rpc-url", os.Getenv("DECODER_RPC_URL"), "Solana JSON-RPC URL used to load pool and mint accounts")
+	loadTimeout := flag.Duration("load-timeout", 10*time.Second, "timeout of a single account load")
+	flag.Parse()
+
+	if *rpcURL == "" {
+		log.Fatal("-rpc-url (or DECODER_RPC_URL) is required to load pool state")
+	}
+
+	nc, err := nats.Connect(*natsURL, nats.Name("dlq-replay"))
+	if err != nil {
+		log.Fatalf("connect to nats: %v", err)
+	}
+	defer nc.Drain()
+
+	js, err := nc.JetStream()
+	if err != nil {
+		log.Fatalf("jetstream context: %v", err)
+	}
+
+	subject := fmt.Sprintf("%s.dlq.decode", *subjectRoot)
+	sub, err := js.SubscribeSync(subject, nats.BindStream(*stream), nats.OrderedConsumer(), nats.DeliverAll())
+	if err != nil {
+		log.Fatalf("subscribe %s: %v", subject, err)
+	}
+	defer sub.Unsubscribe()
+
+	var publisher *natsx.Publisher
+	if *publish {
+		cfg := natsx.DefaultConfig()
+		cfg.URL = *natsURL
+		cfg.Stream = *stream
+		cfg.SubjectRoot = *subjectRoot
+		publisher, err = natsx.NewPublisher(cfg)
+		if err != nil {
+			log.Fatalf("init publisher: %v", err)
+		}
+		defer publisher.Close()
+	}
+
+	// The shared slot cache (SLOT_CACHE_BUCKET) usually no longer holds the
+	// slots being replayed; block times missing from it come from the RPC.
+	slotCache, err := common.SlotTimeCacheFromEnv(js)
+	if err != nil {
+		log.Fatalf("init slot cache: %v", err)
+	}
+	rpc := solrpc.NewClient(*rpcURL, *loadTimeout)
+	decoder := swapdecoder.New(slotCache)
+	decoder.SetAccountLoader(rpc, *loadTimeout)
+	ctx := context.Background()
+	var replayed, recovered, stillFailing, swaps, untimed int
+	for *limit == 0 || replayed < *limit {
+		msg, err := sub.NextMsg(*idleTimeout)
+		if errors.Is(err, nats.ErrTimeout) {
+			break
+		}
+		if err != nil {
+			log.Fatalf("next message: %v", err)
+		}
+		replayed++
+		pending := uint64(1)
+		if meta, err := msg.Metadata(); err == nil {
+			pending = meta.NumPending
+		}
+
+		events, err := replay(decoder, msg.Data)
+		switch {
+		case err != nil:
+			stillFailing++
+			log.Printf("%v", err)
+		case len(events) == 0:
+			// A transaction is only dead-lettered when it touched a DEX, so
+			// decoding no swaps means the pool state is still missing.
+			stillFailing++
+		default:
+			recovered++
+			swaps += len(events)
+			if publisher != nil {
+				slot := events[0].GetSlot()
+				ts, err := blockTime(ctx, slotCache, rpc, slot)
+				if err != nil || ts.IsZero() {
+					// Without a time sinks would bucket the swaps at the epoch.
+					untimed++
+					log.Printf("slot=%d: no block time (%v), not publishing %d swaps", slot, err, len(events))
+					break
+				}
+				head := &dexv1.BlockHead{ChainId: 501, Slot: slot, TsSec: uint64(ts.Unix()), Status: "finalized"}
+				if err := publisher.PublishBlockHead(ctx, head); err != nil {
+					log.Fatalf("publish block head %d: %v", slot, err)
+				}
+				for _, ev := range events {
+					// The original slot has settled long before a replay runs.
+					ev.Provisional = false
+					if err := publisher.PublishSwap(ctx, ev); err != nil {
+						log.Fatalf("publish swap %s/%d: %v", ev.GetSig(), ev.GetIndex(), err)
+					}
+				}
+			}
+		}
+
+		if pending == 0 {
+			break
+		}
+	}
+
+	log.Printf("replayed=%d recovered=%d still_failing=%d swaps=%d untimed=%d", replayed, recovered, stillFailing, swaps, untimed)
+}
+
+// blockTime returns slot's block time from the slot cache, or from the RPC
+// node, caching it. It is zero when neither knows the slot.
+func blockTime(ctx context.Context, cache common.SlotTimeCache, rpc *solrpc.Client, slot uint64) (time.Time, error) {
+	if ts, err := cache.Get(slot); err == nil && !ts.IsZero() {
+		return ts, nil
+	}
+	ts, err := rpc.GetBlockTime(ctx, slot)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("getBlockTime %d: %w", slot, err)
+	}
+	if !ts.IsZero() {
+		cache.Set(slot, ts)
+	}
+	return ts, nil
+}
+
+func replay(decoder *swapdecoder.Decoder, data []byte) ([]*dexv1.SwapEvent, error) {
+	var failure dexv1.DecodeFailure
+	if err := proto.Unmarshal(data, &failure); err != nil {
+		return nil, fmt.Errorf("malformed dead-letter entry: %w", err)
+	}
+	events, err := decoder.ReplayDecodeFailure(&failure)
+	if err != nil {
+		return nil, fmt.Errorf("slot=%d sig=%s still failing: %w", failure.GetSlot(), failure.GetSig(), err)
+	}
+	if len(events) == 0 {
+		log.Printf("slot=%d sig=%s still failing: decoded no swaps (was: %s)", failure.GetSlot(), failure.GetSig(), failure.GetError())
+		return nil, nil
+	}
+	log.Printf("slot=%d sig=%s recovered %d swaps (was: %s)", failure.GetSlot(), failure.GetSig(), len(events), failure.GetError())
+	return events, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/rexbrahh/lp-indexer/sinks/nats/provision"
+)
+
+// jsprovision applies the JetStream streams and consumers defined in
+// ops/jetstream. With -check it only reports drift and exits non-zero when
+// anything is missing or differs from its definition.
+func main() {
+	natsURL := flag.String("nats-url", envOr("NATS_URL", "nats://127.0.0.1:4222"), "NATS server URL")
+	dir := flag.String("dir", "ops/jetstream", "directory of stream and consumer definitions")
+	check := flag.Bool("check", false, "report drift without applying it")
+	flag.Parse()
+
+	defs, err := provision.LoadDir(*dir)
+	if err != nil {
+		log.Fatalf("load definitions: %v", err)
+	}
+
+	nc, err := nats.Connect(*natsURL, nats.Name("jsprovision"))
+	if err != nil {
+		log.Fatalf("connect to nats: %v", err)
+	}
+	defer nc.Close()
+
+	js, err := nc.JetStream()
+	if err != nil {
+		log.Fatalf("jetstream context: %v", err)
+	}
+
+	if *check {
+		drifts, err := provision.Diff(js, defs)
+		if err != nil {
+			log.Fatalf("diff: %v", err)
+		}
+		for _, d := range drifts {
+			log.Printf("drift: %s", d)
+		}
+		if len(drifts) > 0 {
+			nc.Close()
+			os.Exit(1)
+		}
+		log.Printf("%d streams and %d consumers match their definitions", len(defs.Streams), len(defs.Consumers))
+		return
+	}
+
+	applied, err := provision.Apply(js, defs)
+	for _, d := range applied {
+		if d.Missing {
+			log.Printf("created %s %s", d.Kind, d.ID())
+		} else {
+			log.Printf("updated %s", d)
+		}
+	}
+	if err != nil {
+		log.Fatalf("apply: %v", err)
+	}
+	log.Printf("applied %d changes", len(applied))
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -14,6 +14,7 @@ import (
 	proto "google.golang.org/protobuf/proto"
 
 	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+	natsx "github.com/rexbrahh/lp-indexer/sinks/nats"
 )
 
 type event struct {
@@ -59,6 +60,7 @@ func main() {
 	inputPath := flag.String("input", "fixtures/sink_sample.json", "path to event fixture (JSON)")
 	natsURL := flag.String("nats-url", "nats://127.0.0.1:4222", "NATS server URL")
 	subjectRoot := flag.String("subject-root", "dex.sol", "subject root for publishing")
+	programsPath := flag.String("programs", "", "optional programs.yaml providing {name} tokens and subject templates")
 	publishDelay := flag.Int("delay-ms", 0, "delay in milliseconds between events")
 	flag.Parse()
 
@@ -72,6 +74,18 @@ func main() {
 		log.Fatalf("failed to decode fixture: %v", err)
 	}
 
+	var programs, templates map[string]string
+	if *programsPath != "" {
+		programs, templates, err = natsx.LoadProgramsFile(*programsPath)
+		if err != nil {
+			log.Fatalf("load programs: %v", err)
+		}
+	}
+	subjects, err := natsx.NewSubjects(*subjectRoot, programs, templates)
+	if err != nil {
+		log.Fatalf("subject templates: %v", err)
+	}
+
 	nc, err := nats.Connect(*natsURL)
 	if err != nil {
 		log.Fatalf("connect to nats: %v", err)
@@ -90,7 +104,7 @@ func main() {
 		if ctx.Err() != nil {
 			log.Fatalf("context cancelled before event %d", idx)
 		}
-		if err := publishEvent(ctx, js, *subjectRoot, ev); err != nil {
+		if err := publishEvent(ctx, js, subjects, ev); err != nil {
 			log.Fatalf("failed to publish event %d (%s): %v", idx, ev.Type, err)
 		}
 		delay := ev.SleepMillis
@@ -105,7 +119,7 @@ func main() {
 	log.Printf("published %d events", len(events))
 }
 
-func publishEvent(ctx context.Context, js nats.JetStreamContext, root string, ev event) error {
+func publishEvent(ctx context.Context, js nats.JetStreamContext, subjects *natsx.Subjects, ev event) error {
 	chainID := ev.ChainID
 	if chainID == 0 {
 		chainID = 501
@@ -122,7 +136,7 @@ func publishEvent(ctx context.Context, js nats.JetStreamContext, root string, ev
 		if err != nil {
 			return err
 		}
-		return publishProto(ctx, js, fmt.Sprintf("%s.blocks.head", root), data, fmt.Sprintf("%d:%d:head:%s", chainID, ev.Slot, ev.Status))
+		return publishProto(ctx, js, natsx.KindBlockHead, subjects.Fixed(natsx.KindBlockHead), data, fmt.Sprintf("%d:%d:head:%s", chainID, ev.Slot, ev.Status))
 	case "tx_meta":
 		success := true
 		if ev.Success != nil {
@@ -141,7 +155,7 @@ func publishEvent(ctx context.Context, js nats.JetStreamContext, root string, ev
 		if err != nil {
 			return err
 		}
-		return publishProto(ctx, js, fmt.Sprintf("%s.tx.meta", root), data, fmt.Sprintf("%d:%d:%s:meta", chainID, ev.Slot, ev.Signature))
+		return publishProto(ctx, js, natsx.KindTxMeta, subjects.Fixed(natsx.KindTxMeta), data, fmt.Sprintf("%d:%d:%s:meta", chainID, ev.Slot, ev.Signature))
 	case "swap":
 		provisional := true
 		if ev.Provisional != nil {
@@ -172,8 +186,7 @@ func publishEvent(ctx context.Context, js nats.JetStreamContext, root string, ev
 		if err != nil {
 			return err
 		}
-		subject := fmt.Sprintf("%s.%s.swap", root, programSegment(ev.ProgramID))
-		return publishProto(ctx, js, subject, data, fmt.Sprintf("%d:%d:%s:%d:%t:%t", chainID, ev.Slot, ev.Signature, ev.Index, provisional, ev.IsUndo))
+		return publishProto(ctx, js, natsx.KindSwap, subjects.Swap(msg), data, fmt.Sprintf("%d:%d:%s:%d:%t:%t", chainID, ev.Slot, ev.Signature, ev.Index, provisional, ev.IsUndo))
 	case "candle":
 		provisional := false
 		if ev.Provisional != nil {
@@ -212,47 +225,28 @@ func publishEvent(ctx context.Context, js nats.JetStreamContext, root string, ev
 		timeframe := msg.Timeframe
 		if timeframe == "" {
 			timeframe = "unknown"
-			msg.Timeframe = timeframe
 		}
-		subject := fmt.Sprintf("%s.candle.%s.%s", root, subjectScope, strings.ToLower(timeframe))
+		msg.Timeframe = strings.ToLower(timeframe)
+		subject, kind := subjects.Candle(msg)
 		data, err := proto.Marshal(msg)
 		if err != nil {
 			return err
 		}
 		msgID := fmt.Sprintf("%d:%s:%s:%s:%d", msg.ChainId, subjectScope, primaryID, strings.ToLower(timeframe), msg.WindowStart)
-		return publishProto(ctx, js, subject, data, msgID)
+		return publishProto(ctx, js, kind, subject, data, msgID)
 	default:
 		return fmt.Errorf("unsupported event type %q", ev.Type)
 	}
 }
 
-func programSegment(programID string) string {
-	if programID == "" {
-		return "unknown"
-	}
-	switch programID {
-	case "CAMMCzo5YL8w4VFF8KVHrK22GGUsp5VTaW7grrKgrWqK":
-		return "raydium"
-	case "whirLbMiicVdio4qvUfM5KAg6Ct8VwpYzGff3uctyCc":
-		return "orca"
-	case "cpamdpZCGKUy5JxQXB4dcpGPiikHawvSWAd6mEn1sGG":
-		return "meteora"
-	default:
-		cleaned := programID
-		if len(cleaned) > 12 {
-			cleaned = cleaned[:12]
-		}
-		return cleaned
-	}
-}
-
-func publishProto(ctx context.Context, js nats.JetStreamContext, subject string, data []byte, msgID string) error {
+func publishProto(ctx context.Context, js nats.JetStreamContext, kind, subject string, data []byte, msgID string) error {
 	msg := &nats.Msg{Subject: subject, Data: data}
 	msg.Header = nats.Header{}
 	if msgID != "" {
 		msg.Header.Set("Nats-Msg-Id", msgID)
 	}
 	msg.Header.Set("Content-Type", "application/protobuf")
+	msg.Header.Set(natsx.HeaderEventKind, kind)
 	_, err := js.PublishMsgAsync(msg)
 	if err != nil {
 		return err
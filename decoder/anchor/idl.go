@@ -0,0 +1,441 @@
+// Package anchor loads Anchor IDL files and decodes program accounts and
+// instruction data by field name instead of hardcoded byte offsets.
+//
+// Both the legacy IDL format (camelCase names, "publicKey", discriminators
+// derived from names) and the Anchor 0.30 format (snake_case names, "pubkey",
+// explicit discriminators) are accepted. The IDLs the indexer relies on are
+// embedded from the idl directory and trimmed to the accounts and
+// instructions it decodes.
+package anchor
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+//go:embed idl/*.json
+var embedded embed.FS
+
+// IDL is a parsed Anchor interface description.
+type IDL struct {
+	Name         string
+	Version      string
+	Instructions []*Instruction
+	Accounts     []*Layout
+
+	types map[string]*TypeDef
+}
+
+// Instruction describes an instruction's discriminator, account list and
+// argument layout.
+type Instruction struct {
+	Name          string
+	Discriminator [8]byte
+	Accounts      []string
+	Args          *Layout
+}
+
+// TypeDef is a named struct or enum from the IDL "types" section.
+type TypeDef struct {
+	Name     string
+	Kind     string
+	Fields   []FieldDef
+	Variants []VariantDef
+}
+
+// FieldDef is a named field of a struct, instruction or enum variant.
+type FieldDef struct {
+	Name string
+	Type *Type
+}
+
+// VariantDef is an enum variant; unit variants have no fields.
+type VariantDef struct {
+	Name   string
+	Fields []FieldDef
+}
+
+// Type is an IDL type expression. Exactly one of Primitive, Defined, Array,
+// Vec or Option is set.
+type Type struct {
+	Primitive string
+	Defined   string
+	Array     *Type
+	Len       int
+	Vec       *Type
+	Option    *Type
+	// COption marks a 4-byte tagged option (spl-token style).
+	COption bool
+}
+
+// Load parses one of the IDLs embedded in the repository, e.g. "whirlpool".
+func Load(name string) (*IDL, error) {
+	data, err := embedded.ReadFile("idl/" + name + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("load embedded idl %q: %w", name, err)
+	}
+	return Parse(data)
+}
+
+// MustLoad is like Load but panics on error. It is intended for package-level
+// layout variables.
+func MustLoad(name string) *IDL {
+	idl, err := Load(name)
+	if err != nil {
+		panic(err)
+	}
+	return idl
+}
+
+// LoadFile parses an IDL JSON file from disk.
+func LoadFile(path string) (*IDL, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read idl: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes IDL JSON and resolves every account and instruction layout.
+func Parse(data []byte) (*IDL, error) {
+	var raw rawIDL
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("decode idl json: %w", err)
+	}
+
+	idl := &IDL{
+		Name:    raw.Name,
+		Version: raw.Version,
+		types:   make(map[string]*TypeDef),
+	}
+	if raw.Metadata != nil {
+		if idl.Name == "" {
+			idl.Name = raw.Metadata.Name
+		}
+		if idl.Version == "" {
+			idl.Version = raw.Metadata.Version
+		}
+	}
+
+	for _, rt := range raw.Types {
+		def, err := rt.typeDef()
+		if err != nil {
+			return nil, err
+		}
+		idl.types[def.Name] = def
+	}
+
+	for _, ra := range raw.Accounts {
+		def := idl.types[ra.Name]
+		if ra.Type != nil {
+			var err error
+			if def, err = ra.typeDef(); err != nil {
+				return nil, err
+			}
+		}
+		if def == nil || def.Kind != "struct" {
+			return nil, fmt.Errorf("account %s: struct type not found", ra.Name)
+		}
+		disc := explicitDiscriminator(ra.Discriminator, AccountDiscriminator(ra.Name))
+		layout, err := newLayout(idl, ra.Name, disc, def.Fields)
+		if err != nil {
+			return nil, err
+		}
+		idl.Accounts = append(idl.Accounts, layout)
+	}
+
+	for _, ri := range raw.Instructions {
+		disc := explicitDiscriminator(ri.Discriminator, InstructionDiscriminator(ri.Name))
+		fields, err := parseFields(ri.Args)
+		if err != nil {
+			return nil, fmt.Errorf("instruction %s: %w", ri.Name, err)
+		}
+		args, err := newLayout(idl, ri.Name, disc, fields)
+		if err != nil {
+			return nil, err
+		}
+		instr := &Instruction{Name: ri.Name, Discriminator: disc, Args: args}
+		for _, acc := range ri.Accounts {
+			instr.Accounts = append(instr.Accounts, acc.Name)
+		}
+		idl.Instructions = append(idl.Instructions, instr)
+	}
+	return idl, nil
+}
+
+// Account returns the layout of the named account. Names match regardless of
+// camelCase or snake_case spelling.
+func (idl *IDL) Account(name string) (*Layout, error) {
+	for _, layout := range idl.Accounts {
+		if sameName(layout.Name, name) {
+			return layout, nil
+		}
+	}
+	return nil, fmt.Errorf("idl %s: account %q not found", idl.Name, name)
+}
+
+// MustAccount is like Account but panics when the account is missing.
+func (idl *IDL) MustAccount(name string) *Layout {
+	layout, err := idl.Account(name)
+	if err != nil {
+		panic(err)
+	}
+	return layout
+}
+
+// Instruction returns the named instruction.
+func (idl *IDL) Instruction(name string) (*Instruction, error) {
+	for _, instr := range idl.Instructions {
+		if sameName(instr.Name, name) {
+			return instr, nil
+		}
+	}
+	return nil, fmt.Errorf("idl %s: instruction %q not found", idl.Name, name)
+}
+
+// MustInstruction is like Instruction but panics when the instruction is missing.
+func (idl *IDL) MustInstruction(name string) *Instruction {
+	instr, err := idl.Instruction(name)
+	if err != nil {
+		panic(err)
+	}
+	return instr
+}
+
+// Matches reports whether data starts with the instruction discriminator.
+func (i *Instruction) Matches(data []byte) bool {
+	return i.Args.Matches(data)
+}
+
+// Decode parses instruction data after checking its discriminator.
+func (i *Instruction) Decode(data []byte) (*Value, error) {
+	return i.Args.Decode(data)
+}
+
+// AccountIndex returns the position of the named account in the
+// instruction's account list.
+func (i *Instruction) AccountIndex(name string) (int, bool) {
+	for idx, acc := range i.Accounts {
+		if sameName(acc, name) {
+			return idx, true
+		}
+	}
+	return 0, false
+}
+
+// AccountDiscriminator returns the Anchor discriminator of an account type:
+// the first 8 bytes of sha256("account:<Name>").
+func AccountDiscriminator(name string) [8]byte {
+	return discriminator("account:" + name)
+}
+
+// InstructionDiscriminator returns the Anchor discriminator of an instruction:
+// the first 8 bytes of sha256("global:<snake_case_name>").
+func InstructionDiscriminator(name string) [8]byte {
+	return discriminator("global:" + snakeCase(name))
+}
+
+func discriminator(preimage string) [8]byte {
+	sum := sha256.Sum256([]byte(preimage))
+	var out [8]byte
+	copy(out[:], sum[:8])
+	return out
+}
+
+// snakeCase converts legacy camelCase instruction names the way Anchor does
+// (swapV2 -> swap_v2, twoHopSwap -> two_hop_swap).
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && runes[i-1] != '_' {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// sameName compares identifiers ignoring case and underscores so callers can
+// use snake_case field names against legacy camelCase IDLs.
+func sameName(a, b string) bool {
+	return normalizeName(a) == normalizeName(b)
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+// --- JSON decoding ---
+
+type rawIDL struct {
+	Name         string           `json:"name"`
+	Version      string           `json:"version"`
+	Metadata     *rawMetadata     `json:"metadata"`
+	Instructions []rawInstruction `json:"instructions"`
+	Accounts     []rawTypeDef     `json:"accounts"`
+	Types        []rawTypeDef     `json:"types"`
+}
+
+type rawMetadata struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type rawInstruction struct {
+	Name          string          `json:"name"`
+	Discriminator []int           `json:"discriminator"`
+	Accounts      []rawAccountRef `json:"accounts"`
+	Args          []rawField      `json:"args"`
+}
+
+type rawAccountRef struct {
+	Name string `json:"name"`
+}
+
+type rawTypeDef struct {
+	Name          string       `json:"name"`
+	Discriminator []int        `json:"discriminator"`
+	Type          *rawTypeBody `json:"type"`
+}
+
+type rawTypeBody struct {
+	Kind     string       `json:"kind"`
+	Fields   []rawField   `json:"fields"`
+	Variants []rawVariant `json:"variants"`
+}
+
+type rawVariant struct {
+	Name   string     `json:"name"`
+	Fields []rawField `json:"fields"`
+}
+
+type rawField struct {
+	Name string          `json:"name"`
+	Type json.RawMessage `json:"type"`
+}
+
+func (r rawTypeDef) typeDef() (*TypeDef, error) {
+	if r.Type == nil {
+		return nil, fmt.Errorf("type %s: missing body", r.Name)
+	}
+	def := &TypeDef{Name: r.Name, Kind: r.Type.Kind}
+	switch r.Type.Kind {
+	case "struct":
+		fields, err := parseFields(r.Type.Fields)
+		if err != nil {
+			return nil, fmt.Errorf("type %s: %w", r.Name, err)
+		}
+		def.Fields = fields
+	case "enum":
+		for _, rv := range r.Type.Variants {
+			fields, err := parseFields(rv.Fields)
+			if err != nil {
+				return nil, fmt.Errorf("type %s variant %s: %w", r.Name, rv.Name, err)
+			}
+			def.Variants = append(def.Variants, VariantDef{Name: rv.Name, Fields: fields})
+		}
+	default:
+		return nil, fmt.Errorf("type %s: unsupported kind %q", r.Name, r.Type.Kind)
+	}
+	return def, nil
+}
+
+func parseFields(raw []rawField) ([]FieldDef, error) {
+	fields := make([]FieldDef, 0, len(raw))
+	for _, rf := range raw {
+		t, err := parseType(rf.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", rf.Name, err)
+		}
+		fields = append(fields, FieldDef{Name: rf.Name, Type: t})
+	}
+	return fields, nil
+}
+
+func parseType(raw json.RawMessage) (*Type, error) {
+	var prim string
+	if err := json.Unmarshal(raw, &prim); err == nil {
+		if prim == "publicKey" {
+			prim = "pubkey"
+		}
+		if _, ok := primitiveSizes[prim]; !ok && prim != "string" && prim != "bytes" {
+			return nil, fmt.Errorf("unsupported primitive %q", prim)
+		}
+		return &Type{Primitive: prim}, nil
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, fmt.Errorf("invalid type %s", raw)
+	}
+	switch {
+	case obj["defined"] != nil:
+		var name string
+		if err := json.Unmarshal(obj["defined"], &name); err != nil {
+			var named struct {
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal(obj["defined"], &named); err != nil {
+				return nil, fmt.Errorf("invalid defined type %s", obj["defined"])
+			}
+			name = named.Name
+		}
+		return &Type{Defined: name}, nil
+	case obj["array"] != nil:
+		var parts []json.RawMessage
+		if err := json.Unmarshal(obj["array"], &parts); err != nil || len(parts) != 2 {
+			return nil, fmt.Errorf("invalid array type %s", obj["array"])
+		}
+		elem, err := parseType(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		var n int
+		if err := json.Unmarshal(parts[1], &n); err != nil {
+			return nil, fmt.Errorf("unsupported array length %s", parts[1])
+		}
+		return &Type{Array: elem, Len: n}, nil
+	case obj["vec"] != nil:
+		elem, err := parseType(obj["vec"])
+		if err != nil {
+			return nil, err
+		}
+		return &Type{Vec: elem}, nil
+	case obj["option"] != nil:
+		elem, err := parseType(obj["option"])
+		if err != nil {
+			return nil, err
+		}
+		return &Type{Option: elem}, nil
+	case obj["coption"] != nil:
+		elem, err := parseType(obj["coption"])
+		if err != nil {
+			return nil, err
+		}
+		return &Type{Option: elem, COption: true}, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", raw)
+}
+
+// explicitDiscriminator prefers a discriminator spelled out in the IDL over
+// the one derived from the name.
+func explicitDiscriminator(ints []int, derived [8]byte) [8]byte {
+	if len(ints) != 8 {
+		return derived
+	}
+	var out [8]byte
+	for i, v := range ints {
+		out[i] = byte(v)
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+package anchor
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestEmbeddedDiscriminators(t *testing.T) {
+	whirlpool := MustLoad("whirlpool")
+	raydium := MustLoad("raydium_clmm")
+
+	cases := []struct {
+		name string
+		got  [8]byte
+		want string
+	}{
+		{"whirlpool swap", whirlpool.MustInstruction("swap").Discriminator, "f8c69e91e17587c8"},
+		{"whirlpool swap_v2", whirlpool.MustInstruction("swap_v2").Discriminator, "2b04ed0b1ac91e62"},
+		{"whirlpool two_hop_swap", whirlpool.MustInstruction("two_hop_swap").Discriminator, "c360ed6c44a2dbe6"},
+		{"whirlpool account", whirlpool.MustAccount("Whirlpool").Discriminator, "3f95d10ce1806309"},
+		{"raydium swap", raydium.MustInstruction("swap").Discriminator, "f8c69e91e17587c8"},
+		{"raydium amm config", raydium.MustAccount("AmmConfig").Discriminator, "daf42168cbcb2b6f"},
+		{"raydium pool state", raydium.MustAccount("PoolState").Discriminator, "f7ede3f5d7c3de46"},
+	}
+	for _, tc := range cases {
+		if got := hex.EncodeToString(tc.got[:]); got != tc.want {
+			t.Errorf("%s: discriminator %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for in, want := range map[string]string{
+		"swap":       "swap",
+		"swapV2":     "swap_v2",
+		"twoHopSwap": "two_hop_swap",
+		"swap_v2":    "swap_v2",
+	} {
+		if got := snakeCase(in); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseAnchor030Format(t *testing.T) {
+	idl, err := Parse([]byte(`{
+		"address": "Prog111111111111111111111111111111111111111",
+		"metadata": {"name": "example", "version": "0.1.0"},
+		"instructions": [{
+			"name": "deposit",
+			"discriminator": [1, 2, 3, 4, 5, 6, 7, 8],
+			"accounts": [{"name": "user", "signer": true}, {"name": "vault", "writable": true}],
+			"args": [{"name": "amount", "type": "u64"}]
+		}],
+		"accounts": [{"name": "Vault", "discriminator": [9, 9, 9, 9, 9, 9, 9, 9]}],
+		"types": [{
+			"name": "Vault",
+			"type": {"kind": "struct", "fields": [
+				{"name": "owner", "type": "pubkey"},
+				{"name": "state", "type": {"defined": {"name": "State"}}},
+				{"name": "balance", "type": "u64"}
+			]}
+		}, {
+			"name": "State",
+			"type": {"kind": "enum", "variants": [{"name": "Open"}, {"name": "Closed"}]}
+		}]
+	}`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if idl.Name != "example" || idl.Version != "0.1.0" {
+		t.Fatalf("unexpected metadata %s %s", idl.Name, idl.Version)
+	}
+
+	deposit := idl.MustInstruction("deposit")
+	if deposit.Discriminator != [8]byte{1, 2, 3, 4, 5, 6, 7, 8} {
+		t.Fatalf("explicit instruction discriminator ignored: %v", deposit.Discriminator)
+	}
+	if idx, ok := deposit.AccountIndex("vault"); !ok || idx != 1 {
+		t.Fatalf("AccountIndex(vault) = %d, %t", idx, ok)
+	}
+
+	vault := idl.MustAccount("Vault")
+	if vault.Discriminator != [8]byte{9, 9, 9, 9, 9, 9, 9, 9} {
+		t.Fatalf("explicit account discriminator ignored: %v", vault.Discriminator)
+	}
+	if end, ok := vault.End("balance"); !ok || end != 8+32+1+8 {
+		t.Fatalf("End(balance) = %d, %t", end, ok)
+	}
+}
+
+func TestParseRejectsUnknownType(t *testing.T) {
+	_, err := Parse([]byte(`{"name": "bad", "instructions": [], "accounts": [{
+		"name": "Broken",
+		"type": {"kind": "struct", "fields": [{"name": "x", "type": {"defined": "Missing"}}]}
+	}]}`))
+	if err == nil {
+		t.Fatal("expected error for undefined type")
+	}
+}
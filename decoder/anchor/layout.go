@@ -0,0 +1,443 @@
+package anchor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/mr-tron/base58/base58"
+)
+
+// DiscriminatorLen is the length of the Anchor account/instruction prefix.
+const DiscriminatorLen = 8
+
+var errTruncated = errors.New("data truncated")
+
+var primitiveSizes = map[string]int{
+	"bool":   1,
+	"u8":     1,
+	"i8":     1,
+	"u16":    2,
+	"i16":    2,
+	"u32":    4,
+	"i32":    4,
+	"f32":    4,
+	"u64":    8,
+	"i64":    8,
+	"f64":    8,
+	"u128":   16,
+	"i128":   16,
+	"u256":   32,
+	"i256":   32,
+	"pubkey": 32,
+}
+
+// Layout is the borsh layout of an account or instruction: an 8-byte
+// discriminator followed by the fields in declaration order.
+type Layout struct {
+	Name          string
+	Discriminator [8]byte
+	Fields        []Field
+
+	idl *IDL
+}
+
+// Field is a resolved field. Offset is measured from the start of the data,
+// including the discriminator, and is -1 when a variable-size field precedes
+// it. Size is -1 for variable-size fields.
+type Field struct {
+	Name   string
+	Type   *Type
+	Offset int
+	Size   int
+}
+
+func newLayout(idl *IDL, name string, disc [8]byte, defs []FieldDef) (*Layout, error) {
+	layout := &Layout{Name: name, Discriminator: disc, idl: idl}
+	offset := DiscriminatorLen
+	for _, def := range defs {
+		size, err := idl.staticSize(def.Type)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", name, def.Name, err)
+		}
+		layout.Fields = append(layout.Fields, Field{Name: def.Name, Type: def.Type, Offset: offset, Size: size})
+		if offset >= 0 && size >= 0 {
+			offset += size
+		} else {
+			offset = -1
+		}
+	}
+	return layout, nil
+}
+
+// Field returns the named field.
+func (l *Layout) Field(name string) (Field, bool) {
+	for _, f := range l.Fields {
+		if sameName(f.Name, name) {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// Offset returns the fixed byte offset of the named field.
+func (l *Layout) Offset(name string) (int, bool) {
+	f, ok := l.Field(name)
+	if !ok || f.Offset < 0 {
+		return 0, false
+	}
+	return f.Offset, true
+}
+
+// End returns the byte offset just past the named fixed-size field, i.e. the
+// minimum data length needed to read it.
+func (l *Layout) End(name string) (int, bool) {
+	f, ok := l.Field(name)
+	if !ok || f.Offset < 0 || f.Size < 0 {
+		return 0, false
+	}
+	return f.Offset + f.Size, true
+}
+
+// Size returns the total encoded length, including the discriminator, when
+// every field has a fixed size.
+func (l *Layout) Size() (int, bool) {
+	size := DiscriminatorLen
+	for _, f := range l.Fields {
+		if f.Size < 0 {
+			return 0, false
+		}
+		size += f.Size
+	}
+	return size, true
+}
+
+// Matches reports whether data starts with the layout's discriminator.
+func (l *Layout) Matches(data []byte) bool {
+	return len(data) >= DiscriminatorLen && bytes.Equal(data[:DiscriminatorLen], l.Discriminator[:])
+}
+
+// Decode checks the discriminator and returns a view over the fields.
+func (l *Layout) Decode(data []byte) (*Value, error) {
+	if !l.Matches(data) {
+		return nil, fmt.Errorf("%s: discriminator mismatch", l.Name)
+	}
+	return l.View(data)
+}
+
+// View returns a field view without checking the discriminator. Data may be
+// shorter than the full layout; fields past the end are reported as
+// unavailable by the accessors.
+func (l *Layout) View(data []byte) (*Value, error) {
+	if len(data) < DiscriminatorLen {
+		return nil, fmt.Errorf("%s: data too short: %d bytes", l.Name, len(data))
+	}
+	v := &Value{
+		layout:  l,
+		data:    data,
+		offsets: make([]int, len(l.Fields)),
+		sizes:   make([]int, len(l.Fields)),
+	}
+	offset := DiscriminatorLen
+	for i, f := range l.Fields {
+		if offset < 0 {
+			v.offsets[i] = -1
+			continue
+		}
+		size, err := l.idl.encodedSize(f.Type, data, offset)
+		if err != nil {
+			v.offsets[i] = -1
+			offset = -1
+			continue
+		}
+		v.offsets[i], v.sizes[i] = offset, size
+		offset += size
+	}
+	return v, nil
+}
+
+func (idl *IDL) staticSize(t *Type) (int, error) {
+	switch {
+	case t.Primitive != "":
+		if size, ok := primitiveSizes[t.Primitive]; ok {
+			return size, nil
+		}
+		return -1, nil
+	case t.Array != nil:
+		elem, err := idl.staticSize(t.Array)
+		if err != nil || elem < 0 {
+			return elem, err
+		}
+		return elem * t.Len, nil
+	case t.Vec != nil, t.Option != nil:
+		if _, err := idl.staticSize(firstNonNil(t.Vec, t.Option)); err != nil {
+			return 0, err
+		}
+		return -1, nil
+	case t.Defined != "":
+		def, ok := idl.types[t.Defined]
+		if !ok {
+			return 0, fmt.Errorf("undefined type %q", t.Defined)
+		}
+		if def.Kind == "enum" {
+			for _, variant := range def.Variants {
+				if len(variant.Fields) > 0 {
+					return -1, nil
+				}
+			}
+			return 1, nil
+		}
+		total := 0
+		for _, f := range def.Fields {
+			size, err := idl.staticSize(f.Type)
+			if err != nil {
+				return 0, err
+			}
+			if size < 0 {
+				total = -1
+			} else if total >= 0 {
+				total += size
+			}
+		}
+		return total, nil
+	}
+	return 0, errors.New("empty type")
+}
+
+// encodedSize returns the number of bytes the value of type t occupies at
+// offset, reading length prefixes and option tags from data.
+func (idl *IDL) encodedSize(t *Type, data []byte, offset int) (int, error) {
+	if size, err := idl.staticSize(t); err != nil {
+		return 0, err
+	} else if size >= 0 {
+		if offset+size > len(data) {
+			return 0, errTruncated
+		}
+		return size, nil
+	}
+
+	switch {
+	case t.Primitive == "string" || t.Primitive == "bytes":
+		n, err := readLen(data, offset)
+		if err != nil {
+			return 0, err
+		}
+		if offset+4+n > len(data) {
+			return 0, errTruncated
+		}
+		return 4 + n, nil
+	case t.Vec != nil:
+		n, err := readLen(data, offset)
+		if err != nil {
+			return 0, err
+		}
+		return idl.sequenceSize(t.Vec, n, data, offset, 4)
+	case t.Array != nil:
+		return idl.sequenceSize(t.Array, t.Len, data, offset, 0)
+	case t.Option != nil:
+		tagLen := 1
+		if t.COption {
+			tagLen = 4
+		}
+		if offset+tagLen > len(data) {
+			return 0, errTruncated
+		}
+		if data[offset] == 0 {
+			return tagLen, nil
+		}
+		size, err := idl.encodedSize(t.Option, data, offset+tagLen)
+		if err != nil {
+			return 0, err
+		}
+		return tagLen + size, nil
+	case t.Defined != "":
+		def := idl.types[t.Defined]
+		fields := def.Fields
+		prefix := 0
+		if def.Kind == "enum" {
+			if offset >= len(data) {
+				return 0, errTruncated
+			}
+			tag := int(data[offset])
+			if tag >= len(def.Variants) {
+				return 0, fmt.Errorf("%s: invalid variant %d", def.Name, tag)
+			}
+			fields = def.Variants[tag].Fields
+			prefix = 1
+		}
+		total := prefix
+		for _, f := range fields {
+			size, err := idl.encodedSize(f.Type, data, offset+total)
+			if err != nil {
+				return 0, err
+			}
+			total += size
+		}
+		return total, nil
+	}
+	return 0, fmt.Errorf("unsupported type %+v", t)
+}
+
+func (idl *IDL) sequenceSize(elem *Type, n int, data []byte, offset, prefix int) (int, error) {
+	total := prefix
+	for i := 0; i < n; i++ {
+		size, err := idl.encodedSize(elem, data, offset+total)
+		if err != nil {
+			return 0, err
+		}
+		total += size
+	}
+	return total, nil
+}
+
+func readLen(data []byte, offset int) (int, error) {
+	if offset+4 > len(data) {
+		return 0, errTruncated
+	}
+	return int(binary.LittleEndian.Uint32(data[offset : offset+4])), nil
+}
+
+func firstNonNil(types ...*Type) *Type {
+	for _, t := range types {
+		if t != nil {
+			return t
+		}
+	}
+	return nil
+}
+
+// Value is a decoded view over account or instruction data.
+type Value struct {
+	layout  *Layout
+	data    []byte
+	offsets []int
+	sizes   []int
+}
+
+// Has reports whether the named field is present in the data.
+func (v *Value) Has(name string) bool {
+	_, err := v.raw(name, "")
+	return err == nil
+}
+
+// Raw returns the encoded bytes of the named field.
+func (v *Value) Raw(name string) ([]byte, error) {
+	return v.raw(name, "")
+}
+
+// Pubkey returns the named public key field in base58.
+func (v *Value) Pubkey(name string) (string, error) {
+	b, err := v.raw(name, "pubkey")
+	if err != nil {
+		return "", err
+	}
+	return base58.Encode(b), nil
+}
+
+// PubkeyBytes returns the raw 32 bytes of the named public key field.
+func (v *Value) PubkeyBytes(name string) ([]byte, error) {
+	return v.raw(name, "pubkey")
+}
+
+// Bool returns the named bool field.
+func (v *Value) Bool(name string) (bool, error) {
+	b, err := v.raw(name, "bool")
+	if err != nil {
+		return false, err
+	}
+	return b[0] != 0, nil
+}
+
+// U8 returns the named u8 field.
+func (v *Value) U8(name string) (uint8, error) {
+	b, err := v.raw(name, "u8")
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// U16 returns the named u16 field.
+func (v *Value) U16(name string) (uint16, error) {
+	b, err := v.raw(name, "u16")
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint16(b), nil
+}
+
+// U32 returns the named u32 field.
+func (v *Value) U32(name string) (uint32, error) {
+	b, err := v.raw(name, "u32")
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b), nil
+}
+
+// I32 returns the named i32 field.
+func (v *Value) I32(name string) (int32, error) {
+	b, err := v.raw(name, "i32")
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.LittleEndian.Uint32(b)), nil
+}
+
+// U64 returns the named u64 field.
+func (v *Value) U64(name string) (uint64, error) {
+	b, err := v.raw(name, "u64")
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b), nil
+}
+
+// I64 returns the named i64 field.
+func (v *Value) I64(name string) (int64, error) {
+	b, err := v.raw(name, "i64")
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.LittleEndian.Uint64(b)), nil
+}
+
+// U128 returns the named u128 field.
+func (v *Value) U128(name string) (*big.Int, error) {
+	b, err := v.raw(name, "u128")
+	if err != nil {
+		return nil, err
+	}
+	be := make([]byte, len(b))
+	for i := range b {
+		be[len(b)-1-i] = b[i]
+	}
+	return new(big.Int).SetBytes(be), nil
+}
+
+// IsSome reports whether the named option field holds a value.
+func (v *Value) IsSome(name string) (bool, error) {
+	b, err := v.raw(name, "")
+	if err != nil {
+		return false, err
+	}
+	return len(b) > 0 && b[0] != 0, nil
+}
+
+func (v *Value) raw(name, primitive string) ([]byte, error) {
+	for i, f := range v.layout.Fields {
+		if !sameName(f.Name, name) {
+			continue
+		}
+		if primitive != "" && f.Type.Primitive != primitive {
+			return nil, fmt.Errorf("%s.%s: not a %s", v.layout.Name, f.Name, primitive)
+		}
+		if v.offsets[i] < 0 {
+			return nil, fmt.Errorf("%s.%s: %w", v.layout.Name, f.Name, errTruncated)
+		}
+		return v.data[v.offsets[i] : v.offsets[i]+v.sizes[i]], nil
+	}
+	return nil, fmt.Errorf("%s: no field %q", v.layout.Name, name)
+}
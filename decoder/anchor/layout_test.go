@@ -0,0 +1,120 @@
+package anchor
+
+import (
+	"encoding/binary"
+	"math/big"
+	"testing"
+)
+
+func TestAccountLayoutSizes(t *testing.T) {
+	cases := []struct {
+		idl, account string
+		want         int
+	}{
+		{"whirlpool", "Whirlpool", 653},
+		{"raydium_clmm", "AmmConfig", 117},
+		{"raydium_clmm", "PoolState", 1544},
+	}
+	for _, tc := range cases {
+		size, ok := MustLoad(tc.idl).MustAccount(tc.account).Size()
+		if !ok || size != tc.want {
+			t.Errorf("%s.%s size = %d (fixed=%t), want %d", tc.idl, tc.account, size, ok, tc.want)
+		}
+	}
+}
+
+func TestDecodeFixedInstruction(t *testing.T) {
+	swap := MustLoad("whirlpool").MustInstruction("swap")
+
+	data := make([]byte, 8+8+8+16+1+1)
+	copy(data, swap.Discriminator[:])
+	binary.LittleEndian.PutUint64(data[8:], 1_000)
+	binary.LittleEndian.PutUint64(data[16:], 990)
+	binary.LittleEndian.PutUint64(data[24:], 7) // low half of sqrt_price_limit
+	binary.LittleEndian.PutUint64(data[32:], 1) // high half
+	data[40] = 1
+	data[41] = 0
+
+	value, err := swap.Decode(data)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if amount, err := value.U64("amount"); err != nil || amount != 1_000 {
+		t.Fatalf("amount = %d, %v", amount, err)
+	}
+	limit, err := value.U128("sqrt_price_limit")
+	if err != nil {
+		t.Fatalf("sqrt_price_limit: %v", err)
+	}
+	want := new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), 64), big.NewInt(7))
+	if limit.Cmp(want) != 0 {
+		t.Fatalf("sqrt_price_limit = %s, want %s", limit, want)
+	}
+	if exactIn, _ := value.Bool("amountSpecifiedIsInput"); !exactIn {
+		t.Fatal("expected exact-in swap")
+	}
+	if aToB, _ := value.Bool("a_to_b"); aToB {
+		t.Fatal("expected b->a swap")
+	}
+	if _, err := value.U32("amount"); err == nil {
+		t.Fatal("expected type mismatch error")
+	}
+
+	data[0] ^= 0xff
+	if _, err := swap.Decode(data); err == nil {
+		t.Fatal("expected discriminator mismatch")
+	}
+}
+
+func TestDecodeVariableSizeInstruction(t *testing.T) {
+	swapV2 := MustLoad("whirlpool").MustInstruction("swap_v2")
+	if _, ok := swapV2.Args.Size(); ok {
+		t.Fatal("swap_v2 args should be variable size")
+	}
+
+	data := append([]byte{}, swapV2.Discriminator[:]...)
+	data = binary.LittleEndian.AppendUint64(data, 5)
+	data = binary.LittleEndian.AppendUint64(data, 4)
+	data = append(data, make([]byte, 16)...)
+	data = append(data, 1, 1)
+	data = append(data, 1)                           // Some(RemainingAccountsInfo)
+	data = binary.LittleEndian.AppendUint32(data, 2) // two slices
+	data = append(data, 0, 1)                        // TransferHookA x1
+	data = append(data, 6, 3)                        // SupplementalTickArrays x3
+	data = append(data, 0xAA)                        // trailing byte outside the layout
+
+	value, err := swapV2.Decode(data)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if some, err := value.IsSome("remaining_accounts_info"); err != nil || !some {
+		t.Fatalf("remaining_accounts_info some=%t err=%v", some, err)
+	}
+	raw, err := value.Raw("remaining_accounts_info")
+	if err != nil || len(raw) != 1+4+2+2 {
+		t.Fatalf("remaining_accounts_info raw=%x err=%v", raw, err)
+	}
+}
+
+func TestViewTruncatedAccount(t *testing.T) {
+	layout := MustLoad("raydium_clmm").MustAccount("AmmConfig")
+
+	data := make([]byte, 64)
+	copy(data, layout.Discriminator[:])
+	fundOffset, _ := layout.Offset("fund_fee_rate")
+	binary.LittleEndian.PutUint32(data[fundOffset:], 40_000)
+
+	value, err := layout.Decode(data)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if rate, err := value.U32("fund_fee_rate"); err != nil || rate != 40_000 {
+		t.Fatalf("fund_fee_rate = %d, %v", rate, err)
+	}
+	if value.Has("fund_owner") {
+		t.Fatal("fund_owner lies past the truncated data")
+	}
+	if _, err := value.Pubkey("fund_owner"); err == nil {
+		t.Fatal("expected truncation error")
+	}
+}
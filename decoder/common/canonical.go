@@ -0,0 +1,99 @@
+package common
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+)
+
+// pairIDSeparator joins base and quote mints in a pair identifier.
+const pairIDSeparator = "-"
+
+// PairID returns the identifier for a base/quote mint pair ("<base>-<quote>").
+// Callers should pass mints already ordered by ResolvePair.
+func PairID(baseMint, quoteMint string) string {
+	return baseMint + pairIDSeparator + quoteMint
+}
+
+// ID returns the canonical pair identifier.
+func (p *CanonicalPair) ID() string {
+	return PairID(p.BaseMint, p.QuoteMint)
+}
+
+// ParsePairID splits a pair identifier into its two mints without reordering.
+func ParsePairID(pairID string) (mintA, mintB string, err error) {
+	mintA, mintB, ok := strings.Cut(pairID, pairIDSeparator)
+	if !ok || mintA == "" || mintB == "" {
+		return "", "", fmt.Errorf("invalid pair id %q: want <base>%s<quote>", pairID, pairIDSeparator)
+	}
+	return mintA, mintB, nil
+}
+
+// ResolvePairID parses a pair identifier in either mint order and resolves it
+// to the canonical pair. Inverted reports whether the input order was flipped.
+func ResolvePairID(pairID string) (*CanonicalPair, error) {
+	mintA, mintB, err := ParsePairID(pairID)
+	if err != nil {
+		return nil, err
+	}
+	return ResolvePair(mintA, mintB)
+}
+
+// CanonicalizeSwap reorders a swap event so MintBase/MintQuote follow the
+// ResolvePair rules and stamps the canonical pair_id. When the raw pool order
+// is inverted every base/quote field is swapped, so BaseIn still means "base
+// tokens that entered the pool". The inverse of a sqrt price below one does not
+// fit the 64-bit Q64 fields, so the sqrt prices keep the pool's orientation and
+// SqrtPriceInverted is set; CanonicalSqrtPriceQ64 converts them.
+func CanonicalizeSwap(ev *dexv1.SwapEvent) (*CanonicalPair, error) {
+	if ev == nil {
+		return nil, fmt.Errorf("swap event is nil")
+	}
+	pair, err := ResolvePair(ev.GetMintBase(), ev.GetMintQuote())
+	if err != nil {
+		return nil, err
+	}
+
+	if pair.Inverted {
+		ev.MintBase, ev.MintQuote = ev.MintQuote, ev.MintBase
+		ev.DecBase, ev.DecQuote = ev.DecQuote, ev.DecBase
+		ev.BaseIn, ev.QuoteIn = ev.QuoteIn, ev.BaseIn
+		ev.BaseOut, ev.QuoteOut = ev.QuoteOut, ev.BaseOut
+		ev.ReservesBase, ev.ReservesQuote = ev.ReservesQuote, ev.ReservesBase
+		ev.BaseGross, ev.QuoteGross = ev.QuoteGross, ev.BaseGross
+		ev.BaseNet, ev.QuoteNet = ev.QuoteNet, ev.BaseNet
+		ev.BaseTransferFee, ev.QuoteTransferFee = ev.QuoteTransferFee, ev.BaseTransferFee
+		ev.SqrtPriceInverted = !ev.SqrtPriceInverted
+	}
+
+	ev.PairId = pair.ID()
+	return pair, nil
+}
+
+// CanonicalSqrtPriceQ64 returns a Q64.64 sqrt price in canonical orientation:
+// the raw value, or 2^128 / raw when the event's sqrt prices are inverted.
+// Zero stays zero.
+func CanonicalSqrtPriceQ64(raw uint64, inverted bool) *big.Int {
+	sqrt := new(big.Int).SetUint64(raw)
+	if !inverted || raw == 0 {
+		return sqrt
+	}
+	inv := new(big.Int).Lsh(big.NewInt(1), 128)
+	return inv.Quo(inv, sqrt)
+}
+
+// InvertPriceQ32 returns 1/p in Q32.32 for a Q32.32 price. Non-positive inputs
+// and results that overflow int64 yield zero.
+func InvertPriceQ32(price int64) int64 {
+	if price <= 0 {
+		return 0
+	}
+	inv := new(big.Int).Lsh(big.NewInt(1), 64)
+	inv.Quo(inv, big.NewInt(price))
+	if !inv.IsInt64() {
+		return 0
+	}
+	return inv.Int64()
+}
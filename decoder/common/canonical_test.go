@@ -0,0 +1,102 @@
+package common
+
+import (
+	"math/big"
+	"testing"
+
+	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+)
+
+const (
+	testUSDCMint = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+	testSOLMint  = "So11111111111111111111111111111111111111112"
+)
+
+func TestCanonicalizeSwapInverts(t *testing.T) {
+	ev := &dexv1.SwapEvent{
+		MintBase:         testUSDCMint,
+		MintQuote:        testSOLMint,
+		DecBase:          6,
+		DecQuote:         9,
+		BaseIn:           150_000_000,
+		QuoteOut:         1_000_000_000,
+		ReservesBase:     10,
+		ReservesQuote:    20,
+		BaseNet:          150_000_000,
+		QuoteGross:       1_000_000_000,
+		SqrtPriceQ64Pre:  1 << 63,
+		SqrtPriceQ64Post: 1 << 62,
+	}
+
+	pair, err := CanonicalizeSwap(ev)
+	if err != nil {
+		t.Fatalf("CanonicalizeSwap: %v", err)
+	}
+	if !pair.Inverted {
+		t.Fatalf("expected USDC/SOL to be inverted")
+	}
+	if ev.MintBase != testSOLMint || ev.MintQuote != testUSDCMint || ev.DecBase != 9 || ev.DecQuote != 6 {
+		t.Fatalf("unexpected ordering %+v", ev)
+	}
+	if ev.BaseOut != 1_000_000_000 || ev.QuoteIn != 150_000_000 || ev.BaseIn != 0 || ev.QuoteOut != 0 {
+		t.Fatalf("unexpected amounts %+v", ev)
+	}
+	if ev.ReservesBase != 20 || ev.ReservesQuote != 10 {
+		t.Fatalf("unexpected reserves %+v", ev)
+	}
+	if ev.BaseGross != 1_000_000_000 || ev.QuoteNet != 150_000_000 {
+		t.Fatalf("unexpected gross/net %+v", ev)
+	}
+	if ev.SqrtPriceQ64Pre != 1<<63 || ev.SqrtPriceQ64Post != 1<<62 || !ev.SqrtPriceInverted {
+		t.Fatalf("sqrt prices should keep pool orientation and be flagged: %+v", ev)
+	}
+	// sqrt(p) = 0.5 in the pool's order, so 2 canonically; 0.25 becomes 4.
+	if got := CanonicalSqrtPriceQ64(ev.SqrtPriceQ64Pre, ev.SqrtPriceInverted); got.Cmp(new(big.Int).Lsh(big.NewInt(2), 64)) != 0 {
+		t.Fatalf("canonical pre sqrt price = %s", got)
+	}
+	if got := CanonicalSqrtPriceQ64(ev.SqrtPriceQ64Post, ev.SqrtPriceInverted); got.Cmp(new(big.Int).Lsh(big.NewInt(4), 64)) != 0 {
+		t.Fatalf("canonical post sqrt price = %s", got)
+	}
+	if ev.PairId != testSOLMint+"-"+testUSDCMint {
+		t.Fatalf("unexpected pair id %s", ev.PairId)
+	}
+}
+
+func TestCanonicalizeSwapKeepsCanonicalOrder(t *testing.T) {
+	ev := &dexv1.SwapEvent{MintBase: testSOLMint, MintQuote: testUSDCMint, BaseIn: 5, QuoteOut: 7}
+	pair, err := CanonicalizeSwap(ev)
+	if err != nil {
+		t.Fatalf("CanonicalizeSwap: %v", err)
+	}
+	if pair.Inverted || ev.BaseIn != 5 || ev.QuoteOut != 7 || ev.SqrtPriceInverted {
+		t.Fatalf("canonical event should be untouched: %+v", ev)
+	}
+	if ev.PairId != PairID(testSOLMint, testUSDCMint) {
+		t.Fatalf("unexpected pair id %s", ev.PairId)
+	}
+}
+
+func TestResolvePairID(t *testing.T) {
+	pair, err := ResolvePairID(testUSDCMint + "-" + testSOLMint)
+	if err != nil {
+		t.Fatalf("ResolvePairID: %v", err)
+	}
+	if !pair.Inverted || pair.ID() != testSOLMint+"-"+testUSDCMint {
+		t.Fatalf("unexpected pair %+v", pair)
+	}
+	if _, err := ResolvePairID(testSOLMint); err == nil {
+		t.Fatalf("expected error for pair id without separator")
+	}
+}
+
+func TestInvertPriceQ32(t *testing.T) {
+	if got := InvertPriceQ32(2 << 32); got != 1<<31 {
+		t.Fatalf("InvertPriceQ32(2.0) = %d, want %d", got, int64(1)<<31)
+	}
+	if got := InvertPriceQ32(0); got != 0 {
+		t.Fatalf("InvertPriceQ32(0) = %d, want 0", got)
+	}
+	if got := InvertPriceQ32(1); got != 0 {
+		t.Fatalf("expected overflow to yield zero, got %d", got)
+	}
+}
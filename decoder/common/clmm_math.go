@@ -0,0 +1,323 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Exact concentrated-liquidity math. Orca Whirlpool and Raydium CLMM share the
+// tick range and the Q64.64 sqrt price representation but compute
+// sqrt(1.0001)^tick from different constant tables, so their tick_math
+// results differ in the low bits. Both are ported here operation for
+// operation (same constants, shifts and truncation) so results are
+// bit-identical to the programs.
+
+const (
+	// MinTickIndex and MaxTickIndex bound the tick range of both programs.
+	MinTickIndex int32 = -443636
+	MaxTickIndex int32 = 443636
+)
+
+var (
+	// MinSqrtPriceX64 is the sqrt price at MinTickIndex in both programs.
+	MinSqrtPriceX64 = big.NewInt(4295048016)
+	// WhirlpoolMaxSqrtPriceX64 is the Whirlpool sqrt price at MaxTickIndex.
+	WhirlpoolMaxSqrtPriceX64 = mustBigInt("79226673515401279992447579055")
+	// RaydiumMaxSqrtPriceX64 is the Raydium CLMM sqrt price at MaxTickIndex.
+	RaydiumMaxSqrtPriceX64 = mustBigInt("79226673521066979257578248091")
+
+	// ErrTickOutOfRange reports a tick outside [MinTickIndex, MaxTickIndex].
+	ErrTickOutOfRange = errors.New("tick index out of range")
+	// ErrSqrtPriceOutOfRange reports a sqrt price outside the program bounds.
+	ErrSqrtPriceOutOfRange = errors.New("sqrt price out of range")
+	// ErrAmountOverflow reports an amount delta that does not fit a u64.
+	ErrAmountOverflow = errors.New("amount delta overflows u64")
+)
+
+// whirlpoolPositiveRatios[i] is sqrt(1.0001)^(2^i) in Q96.96 (floor), used for
+// non-negative ticks.
+var whirlpoolPositiveRatios = mustBigInts(
+	"79232123823359799118286999567",
+	"79236085330515764027303304731",
+	"79244008939048815603706035061",
+	"79259858533276714757314932305",
+	"79291567232598584799939703904",
+	"79355022692464371645785046466",
+	"79482085999252804386437311141",
+	"79736823300114093921829183326",
+	"80248749790819932309965073892",
+	"81282483887344747381513967011",
+	"83390072131320151908154831281",
+	"87770609709833776024991924138",
+	"97234110755111693312479820773",
+	"119332217159966728226237229890",
+	"179736315981702064433883588727",
+	"407748233172238350107850275304",
+	"2098478828474011932436660412517",
+	"55581415166113811149459800483533",
+	"38992368544603139932233054999993551",
+)
+
+// whirlpoolNegativeRatios[i] is sqrt(1.0001)^-(2^i) in Q64.64 (floor), used
+// for negative ticks.
+var whirlpoolNegativeRatios = []uint64{
+	18445821805675392311,
+	18444899583751176498,
+	18443055278223354162,
+	18439367220385604838,
+	18431993317065449817,
+	18417254355718160513,
+	18387811781193591352,
+	18329067761203520168,
+	18212142134806087854,
+	17980523815641551639,
+	17526086738831147013,
+	16651378430235024244,
+	15030750278693429944,
+	12247334978882834399,
+	8131365268884726200,
+	3584323654723342297,
+	696457651847595233,
+	26294789957452057,
+	37481735321082,
+}
+
+// raydiumRatios[i] is sqrt(1.0001)^-(2^i) in Q64.64 as tabulated by the
+// Raydium CLMM program.
+var raydiumRatios = []uint64{
+	0xfffcb933bd6fb800,
+	0xfff97272373d4000,
+	0xfff2e50f5f657000,
+	0xffe5caca7e10f000,
+	0xffcb9843d60f7000,
+	0xff973b41fa98e800,
+	0xff2ea16466c9b000,
+	0xfe5dee046a9a3800,
+	0xfcbe86c7900bb000,
+	0xf987a7253ac65800,
+	0xf3392b0822bb6000,
+	0xe7159475a2caf000,
+	0xd097f3bdfd2f2000,
+	0xa9f746462d9f8000,
+	0x70d869a156f31c00,
+	0x31be135f97ed3200,
+	0x9aa508b5b85a500,
+	0x5d6af8dedc582c,
+	0x2216e584f5fa,
+}
+
+var (
+	q96One  = new(big.Int).Lsh(big.NewInt(1), 96)
+	u128Max = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+	u64Max  = new(big.Int).SetUint64(^uint64(0))
+)
+
+// WhirlpoolSqrtPriceAtTick ports Whirlpool's sqrt_price_from_tick_index.
+func WhirlpoolSqrtPriceAtTick(tick int32) (*big.Int, error) {
+	if tick < MinTickIndex || tick > MaxTickIndex {
+		return nil, fmt.Errorf("%w: %d", ErrTickOutOfRange, tick)
+	}
+	if tick >= 0 {
+		ratio := new(big.Int).Set(q96One)
+		if tick&1 != 0 {
+			ratio.Set(whirlpoolPositiveRatios[0])
+		}
+		for i := 1; i < len(whirlpoolPositiveRatios); i++ {
+			if tick&(1<<i) != 0 {
+				ratio.Mul(ratio, whirlpoolPositiveRatios[i])
+				ratio.Rsh(ratio, 96)
+			}
+		}
+		return ratio.Rsh(ratio, 32), nil
+	}
+
+	abs := -tick
+	ratio := new(big.Int).Set(Q64One)
+	if abs&1 != 0 {
+		ratio.SetUint64(whirlpoolNegativeRatios[0])
+	}
+	factor := new(big.Int)
+	for i := 1; i < len(whirlpoolNegativeRatios); i++ {
+		if abs&(1<<i) != 0 {
+			ratio.Mul(ratio, factor.SetUint64(whirlpoolNegativeRatios[i]))
+			ratio.Rsh(ratio, 64)
+		}
+	}
+	return ratio, nil
+}
+
+// RaydiumSqrtPriceAtTick ports Raydium CLMM's get_sqrt_price_at_tick.
+func RaydiumSqrtPriceAtTick(tick int32) (*big.Int, error) {
+	if tick < MinTickIndex || tick > MaxTickIndex {
+		return nil, fmt.Errorf("%w: %d", ErrTickOutOfRange, tick)
+	}
+	abs := tick
+	if abs < 0 {
+		abs = -abs
+	}
+	ratio := new(big.Int).Set(Q64One)
+	if abs&1 != 0 {
+		ratio.SetUint64(raydiumRatios[0])
+	}
+	factor := new(big.Int)
+	for i := 1; i < len(raydiumRatios); i++ {
+		if abs&(1<<i) != 0 {
+			ratio.Mul(ratio, factor.SetUint64(raydiumRatios[i]))
+			ratio.Rsh(ratio, 64)
+		}
+	}
+	if tick > 0 {
+		ratio.Quo(u128Max, ratio)
+	}
+	return ratio, nil
+}
+
+// WhirlpoolTickAtSqrtPrice ports Whirlpool's tick_index_from_sqrt_price: the
+// greatest tick whose sqrt price is <= sqrtPriceX64.
+func WhirlpoolTickAtSqrtPrice(sqrtPriceX64 *big.Int) (int32, error) {
+	if sqrtPriceX64 == nil || sqrtPriceX64.Cmp(MinSqrtPriceX64) < 0 || sqrtPriceX64.Cmp(WhirlpoolMaxSqrtPriceX64) > 0 {
+		return 0, fmt.Errorf("%w: %v", ErrSqrtPriceOutOfRange, sqrtPriceX64)
+	}
+	return tickAtSqrtPrice(sqrtPriceX64, 14, WhirlpoolSqrtPriceAtTick), nil
+}
+
+// RaydiumTickAtSqrtPrice ports Raydium CLMM's get_tick_at_sqrt_price, which
+// accepts [MinSqrtPriceX64, RaydiumMaxSqrtPriceX64).
+func RaydiumTickAtSqrtPrice(sqrtPriceX64 *big.Int) (int32, error) {
+	if sqrtPriceX64 == nil || sqrtPriceX64.Cmp(MinSqrtPriceX64) < 0 || sqrtPriceX64.Cmp(RaydiumMaxSqrtPriceX64) >= 0 {
+		return 0, fmt.Errorf("%w: %v", ErrSqrtPriceOutOfRange, sqrtPriceX64)
+	}
+	return tickAtSqrtPrice(sqrtPriceX64, 16, RaydiumSqrtPriceAtTick), nil
+}
+
+var (
+	// log_sqrt(1.0001)(2) in Q32.32.
+	logB2X32 = big.NewInt(59543866431248)
+	// 0.01 in Q64.64.
+	logBPErrMarginLowerX64 = big.NewInt(184467440737095516)
+	// 2^-14 / log2(sqrt(1.0001)) + 0.01 in Q64.64.
+	logBPErrMarginUpperX64 = new(big.Int).SetUint64(15793534762490258745)
+)
+
+// tickAtSqrtPrice is the shared log2 estimate of both programs: an integer
+// log2 from the msb, precisionBits iterations of the fractional part, a change
+// of base, and a sqrt price lookup to settle between the two candidate ticks.
+func tickAtSqrtPrice(sqrtPriceX64 *big.Int, precisionBits int, sqrtPriceAtTick func(int32) (*big.Int, error)) int32 {
+	msb := sqrtPriceX64.BitLen() - 1
+	log2pIntegerX32 := new(big.Int).Lsh(big.NewInt(int64(msb-64)), 32)
+
+	r := new(big.Int)
+	if msb >= 64 {
+		r.Rsh(sqrtPriceX64, uint(msb-63))
+	} else {
+		r.Lsh(sqrtPriceX64, uint(63-msb))
+	}
+
+	var log2pFractionX64 uint64
+	bit := uint64(1) << 63
+	for precision := 0; bit > 0 && precision < precisionBits; precision++ {
+		r.Mul(r, r)
+		moreThanTwo := r.Bit(127)
+		r.Rsh(r, 63+moreThanTwo)
+		if moreThanTwo == 1 {
+			log2pFractionX64 += bit
+		}
+		bit >>= 1
+	}
+
+	log2pX32 := log2pIntegerX32.Add(log2pIntegerX32, new(big.Int).SetUint64(log2pFractionX64>>32))
+	logbpX64 := log2pX32.Mul(log2pX32, logB2X32)
+
+	// Arithmetic shifts: Rsh on a negative big.Int rounds toward -inf like
+	// Rust's >> on i128.
+	tickLow := int32(new(big.Int).Rsh(new(big.Int).Sub(logbpX64, logBPErrMarginLowerX64), 64).Int64())
+	tickHigh := int32(new(big.Int).Rsh(new(big.Int).Add(logbpX64, logBPErrMarginUpperX64), 64).Int64())
+	if tickLow == tickHigh {
+		return tickLow
+	}
+	if high, err := sqrtPriceAtTick(tickHigh); err == nil && high.Cmp(sqrtPriceX64) <= 0 {
+		return tickHigh
+	}
+	return tickLow
+}
+
+// SqrtPriceX64ToPrice returns the exact price of token B in token A units,
+// adjusted for decimals: (sqrtPrice / 2^64)^2 * 10^(decimalsA - decimalsB).
+func SqrtPriceX64ToPrice(sqrtPriceX64 *big.Int, decimalsA, decimalsB uint8) *big.Rat {
+	num := new(big.Int).Mul(sqrtPriceX64, sqrtPriceX64)
+	num.Mul(num, pow10(decimalsA))
+	den := new(big.Int).Lsh(pow10(decimalsB), 2*Q64Shift)
+	return new(big.Rat).SetFrac(num, den)
+}
+
+// SqrtPriceX64ToPriceQ32 returns SqrtPriceX64ToPrice as a Q32.32 value
+// truncated toward zero, the representation used by candles.
+func SqrtPriceX64ToPriceQ32(sqrtPriceX64 *big.Int, decimalsA, decimalsB uint8) (int64, error) {
+	price := SqrtPriceX64ToPrice(sqrtPriceX64, decimalsA, decimalsB)
+	q32 := new(big.Int).Lsh(price.Num(), Q32FractionalBits)
+	q32.Quo(q32, price.Denom())
+	if !q32.IsInt64() {
+		return 0, fmt.Errorf("price overflows Q32.32: sqrt price %s", sqrtPriceX64)
+	}
+	return q32.Int64(), nil
+}
+
+// AmountDeltaA is the token A amount between two sqrt prices for a given
+// liquidity, liquidity * (upper - lower) * 2^64 / (upper * lower), rounded up
+// when roundUp is set (get_amount_delta_a / get_delta_amount_0_unsigned).
+func AmountDeltaA(sqrtPrice0, sqrtPrice1, liquidity *big.Int, roundUp bool) (uint64, error) {
+	lower, upper := orderedSqrtPrices(sqrtPrice0, sqrtPrice1)
+	if lower.Sign() <= 0 {
+		return 0, fmt.Errorf("%w: %v", ErrSqrtPriceOutOfRange, lower)
+	}
+	num := new(big.Int).Sub(upper, lower)
+	num.Mul(num, liquidity)
+	num.Lsh(num, Q64Shift)
+	den := new(big.Int).Mul(upper, lower)
+	return quoU64(num, den, roundUp)
+}
+
+// AmountDeltaB is the token B amount between two sqrt prices for a given
+// liquidity, liquidity * (upper - lower) / 2^64, rounded up when roundUp is
+// set (get_amount_delta_b / get_delta_amount_1_unsigned).
+func AmountDeltaB(sqrtPrice0, sqrtPrice1, liquidity *big.Int, roundUp bool) (uint64, error) {
+	lower, upper := orderedSqrtPrices(sqrtPrice0, sqrtPrice1)
+	num := new(big.Int).Sub(upper, lower)
+	num.Mul(num, liquidity)
+	return quoU64(num, Q64One, roundUp)
+}
+
+func orderedSqrtPrices(a, b *big.Int) (lower, upper *big.Int) {
+	if a.Cmp(b) > 0 {
+		return b, a
+	}
+	return a, b
+}
+
+func quoU64(num, den *big.Int, roundUp bool) (uint64, error) {
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if roundUp && rem.Sign() != 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if q.Cmp(u64Max) > 0 {
+		return 0, ErrAmountOverflow
+	}
+	return q.Uint64(), nil
+}
+
+func mustBigInt(s string) *big.Int {
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("invalid big integer constant " + s)
+	}
+	return v
+}
+
+func mustBigInts(values ...string) []*big.Int {
+	out := make([]*big.Int, len(values))
+	for i, s := range values {
+		out[i] = mustBigInt(s)
+	}
+	return out
+}
@@ -0,0 +1,230 @@
+package common
+
+import (
+	"errors"
+	"math/big"
+	"slices"
+	"testing"
+)
+
+func TestSqrtPriceAtTickMatchesProgramConstants(t *testing.T) {
+	// Boundary values are the MIN/MAX_SQRT_PRICE_X64 constants declared by
+	// each program; tick ±1 values are from Whirlpool's tick_math tests.
+	tests := []struct {
+		name string
+		fn   func(int32) (*big.Int, error)
+		tick int32
+		want string
+	}{
+		{"whirlpool_min", WhirlpoolSqrtPriceAtTick, MinTickIndex, "4295048016"},
+		{"whirlpool_max", WhirlpoolSqrtPriceAtTick, MaxTickIndex, "79226673515401279992447579055"},
+		{"whirlpool_zero", WhirlpoolSqrtPriceAtTick, 0, "18446744073709551616"},
+		{"whirlpool_plus_one", WhirlpoolSqrtPriceAtTick, 1, "18447666387855959850"},
+		{"whirlpool_minus_one", WhirlpoolSqrtPriceAtTick, -1, "18445821805675392311"},
+		{"raydium_min", RaydiumSqrtPriceAtTick, MinTickIndex, "4295048016"},
+		{"raydium_max", RaydiumSqrtPriceAtTick, MaxTickIndex, "79226673521066979257578248091"},
+		{"raydium_zero", RaydiumSqrtPriceAtTick, 0, "18446744073709551616"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fn(tt.tick)
+			if err != nil {
+				t.Fatalf("sqrt price at %d: %v", tt.tick, err)
+			}
+			if got.String() != tt.want {
+				t.Fatalf("sqrt price at %d = %s, want %s", tt.tick, got, tt.want)
+			}
+		})
+	}
+
+	for _, fn := range []func(int32) (*big.Int, error){WhirlpoolSqrtPriceAtTick, RaydiumSqrtPriceAtTick} {
+		for _, tick := range []int32{MinTickIndex - 1, MaxTickIndex + 1} {
+			if _, err := fn(tick); !errors.Is(err, ErrTickOutOfRange) {
+				t.Fatalf("tick %d: err = %v, want ErrTickOutOfRange", tick, err)
+			}
+		}
+	}
+}
+
+func TestSqrtPriceAtTickFixtures(t *testing.T) {
+	// Interior ticks across the range, generated with a separate big-integer
+	// implementation of Whirlpool's sqrt_price_from_tick_index and Raydium
+	// CLMM's tick_math::get_sqrt_price_at_tick (same constant tables, shifts
+	// and truncation). The generator reproduces the MIN/MAX_SQRT_PRICE_X64
+	// constants above and each value is within 1e-9 of sqrt(1.0001)^tick.
+	fixtures := []struct {
+		tick      int32
+		whirlpool string
+		raydium   string
+	}{
+		{-443635, "4295262763", "4295262763"},
+		{-400000, "38059611423", "38059611423"},
+		{-345678, "575430154721", "575430154722"},
+		{-300000, "5647135299341", "5647135299345"},
+		{-222222, "275849094704622", "275849094705601"},
+		{-150000, "10206432266789888", "10206432266814347"},
+		{-100000, "124324258982887573", "124324258983086206"},
+		{-65536, "696457651847595233", "696457651848324352"},
+		{-50001, "1514314522403994010", "1514314522405204008"},
+		{-32768, "3584323654723342297", "3584323654725218816"},
+		{-12345, "9950957148631419635", "9950957148633381772"},
+		{-10000, "11188795550323325955", "11188795550325113405"},
+		{-4097, "15029998797540112118", "15029998797541096663"},
+		{-1000, "17547129613991598777", "17547129613991882732"},
+		{-100, "18354745142194483561", "18354745142194513203"},
+		{-64, "18387811781193591352", "18387811781193609216"},
+		{-2, "18444899583751176498", "18444899583751176192"},
+		{2, "18448588748116922571", "18448588748116922877"},
+		{64, "18505865242158250041", "18505865242158232063"},
+		{100, "18539204128674405812", "18539204128674375874"},
+		{1000, "19392480388906836277", "19392480388906522465"},
+		{4097, "22640212517956478336", "22640212517954995284"},
+		{10000, "30412779051191548722", "30412779051186690180"},
+		{12345, "34195943348800206620", "34195943348793463849"},
+		{32768, "94936283578220370716", "94936283578170668259"},
+		{50001, "224710495664226859312", "224710495664047306798"},
+		{65536, "488590176327622479860", "488590176327110977113"},
+		{100000, "2737055259406582257880", "2737055259402209284734"},
+		{150000, "33339991686239204854474", "33339991686159311376918"},
+		{222222, "1233581597522773159111557", "1233581597518395527783742"},
+		{300000, "60257519765924248467716150", "60257519765890351327341632"},
+		{345678, "591353032386092212360566359", "591353032385545742674115373"},
+		{400000, "8940773544377188876727933130", "8940773544400946562007010836"},
+		{443635, "79222712478800779441888593664", "79222712485061176096288712065"},
+	}
+	for _, f := range fixtures {
+		whirlpool, err := WhirlpoolSqrtPriceAtTick(f.tick)
+		if err != nil {
+			t.Fatalf("whirlpool sqrt price at %d: %v", f.tick, err)
+		}
+		if whirlpool.String() != f.whirlpool {
+			t.Errorf("whirlpool sqrt price at %d = %s, want %s", f.tick, whirlpool, f.whirlpool)
+		}
+		raydium, err := RaydiumSqrtPriceAtTick(f.tick)
+		if err != nil {
+			t.Fatalf("raydium sqrt price at %d: %v", f.tick, err)
+		}
+		if raydium.String() != f.raydium {
+			t.Errorf("raydium sqrt price at %d = %s, want %s", f.tick, raydium, f.raydium)
+		}
+	}
+}
+
+func TestTickAtSqrtPriceInvertsSqrtPriceAtTick(t *testing.T) {
+	programs := []struct {
+		name        string
+		sqrtAtTick  func(int32) (*big.Int, error)
+		tickAtSqrt  func(*big.Int) (int32, error)
+		maxSqrtTick int32
+	}{
+		{"whirlpool", WhirlpoolSqrtPriceAtTick, WhirlpoolTickAtSqrtPrice, MaxTickIndex},
+		// Raydium rejects sqrt prices >= its MAX_SQRT_PRICE_X64.
+		{"raydium", RaydiumSqrtPriceAtTick, RaydiumTickAtSqrtPrice, MaxTickIndex - 1},
+	}
+	one := big.NewInt(1)
+	for _, p := range programs {
+		t.Run(p.name, func(t *testing.T) {
+			ticks := []int32{MinTickIndex, MinTickIndex + 1, -1, 0, 1, p.maxSqrtTick}
+			for tick := MinTickIndex + 7; tick < MaxTickIndex; tick += 997 {
+				ticks = append(ticks, tick)
+			}
+			slices.Sort(ticks)
+			var prev *big.Int
+			for _, tick := range ticks {
+				sqrtPrice, err := p.sqrtAtTick(tick)
+				if err != nil {
+					t.Fatalf("sqrt price at %d: %v", tick, err)
+				}
+				if prev != nil && sqrtPrice.Cmp(prev) <= 0 {
+					t.Fatalf("sqrt price not increasing at tick %d", tick)
+				}
+				prev = sqrtPrice
+				if tick > p.maxSqrtTick {
+					continue
+				}
+				got, err := p.tickAtSqrt(sqrtPrice)
+				if err != nil || got != tick {
+					t.Fatalf("tick at sqrt price of %d = %d, %v", tick, got, err)
+				}
+				if tick == MinTickIndex {
+					continue
+				}
+				below := new(big.Int).Sub(sqrtPrice, one)
+				if got, err := p.tickAtSqrt(below); err != nil || got != tick-1 {
+					t.Fatalf("tick just below %d = %d, %v", tick, got, err)
+				}
+			}
+		})
+	}
+
+	if _, err := WhirlpoolTickAtSqrtPrice(new(big.Int).Sub(MinSqrtPriceX64, one)); !errors.Is(err, ErrSqrtPriceOutOfRange) {
+		t.Fatalf("below min: err = %v", err)
+	}
+	if _, err := RaydiumTickAtSqrtPrice(RaydiumMaxSqrtPriceX64); !errors.Is(err, ErrSqrtPriceOutOfRange) {
+		t.Fatalf("raydium max: err = %v", err)
+	}
+}
+
+func TestSqrtPriceX64ToPrice(t *testing.T) {
+	// A raw price of 1 between a 9-decimal and a 6-decimal token is 1000
+	// token-B units per token A.
+	price := SqrtPriceX64ToPrice(Q64One, 9, 6)
+	if price.Cmp(big.NewRat(1000, 1)) != 0 {
+		t.Fatalf("price = %s, want 1000", price.RatString())
+	}
+	// sqrt price 1.5 -> raw price 2.25.
+	sqrtPrice := new(big.Int).Add(Q64One, new(big.Int).Rsh(Q64One, 1))
+	if price := SqrtPriceX64ToPrice(sqrtPrice, 6, 6); price.Cmp(big.NewRat(9, 4)) != 0 {
+		t.Fatalf("price = %s, want 9/4", price.RatString())
+	}
+	q32, err := SqrtPriceX64ToPriceQ32(sqrtPrice, 6, 6)
+	if err != nil || q32 != 9<<30 {
+		t.Fatalf("SqrtPriceX64ToPriceQ32 = %d, %v; want %d", q32, err, int64(9<<30))
+	}
+	if _, err := SqrtPriceX64ToPriceQ32(WhirlpoolMaxSqrtPriceX64, 0, 0); err == nil {
+		t.Fatal("expected Q32.32 overflow at the max sqrt price")
+	}
+}
+
+func TestAmountDeltas(t *testing.T) {
+	lower := new(big.Int).Set(Q64One)    // price 1
+	upper := new(big.Int).Lsh(Q64One, 1) // price 4
+	liquidity := big.NewInt(1_000_000_000)
+	nudged := new(big.Int).Add(Q64One, big.NewInt(1)) // one ulp above price 1
+
+	tests := []struct {
+		name    string
+		fn      func(a, b, l *big.Int, roundUp bool) (uint64, error)
+		a, b, l *big.Int
+		roundUp bool
+		want    uint64
+	}{
+		{"a_exact", AmountDeltaA, lower, upper, liquidity, false, 500_000_000},
+		{"a_order_independent", AmountDeltaA, upper, lower, liquidity, true, 500_000_000},
+		{"b_exact", AmountDeltaB, lower, upper, liquidity, false, 1_000_000_000},
+		{"b_floor", AmountDeltaB, lower, nudged, big.NewInt(1), false, 0},
+		{"b_ceil", AmountDeltaB, lower, nudged, big.NewInt(1), true, 1},
+		{"a_floor", AmountDeltaA, lower, nudged, big.NewInt(3), false, 0},
+		{"a_ceil", AmountDeltaA, lower, nudged, big.NewInt(3), true, 1},
+		{"empty_range", AmountDeltaB, lower, lower, liquidity, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fn(tt.a, tt.b, tt.l, tt.roundUp)
+			if err != nil {
+				t.Fatalf("amount delta: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("amount delta = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	huge := new(big.Int).Lsh(big.NewInt(1), 100)
+	if _, err := AmountDeltaB(lower, upper, huge, false); !errors.Is(err, ErrAmountOverflow) {
+		t.Fatalf("AmountDeltaB overflow err = %v", err)
+	}
+	if _, err := AmountDeltaA(lower, upper, huge, false); !errors.Is(err, ErrAmountOverflow) {
+		t.Fatalf("AmountDeltaA overflow err = %v", err)
+	}
+}
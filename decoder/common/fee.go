@@ -0,0 +1,66 @@
+package common
+
+import (
+	"math"
+	"math/bits"
+)
+
+// FeeSplit is the swap fee charged on the input leg, in input token units.
+type FeeSplit struct {
+	LP       uint64
+	Protocol uint64
+}
+
+// Total returns the full fee charged to the trader.
+func (f FeeSplit) Total() uint64 {
+	return f.LP + f.Protocol
+}
+
+// SplitSwapFee charges feeRate/feeDenominator of amountIn, rounding up as the
+// CLMM programs do, and carves protocolRate/protocolDenominator of that fee
+// out for the protocol. The remainder accrues to liquidity providers.
+func SplitSwapFee(amountIn, feeRate, feeDenominator, protocolRate, protocolDenominator uint64) FeeSplit {
+	if amountIn == 0 || feeRate == 0 || feeDenominator == 0 {
+		return FeeSplit{}
+	}
+	total := mulDivCeil(amountIn, feeRate, feeDenominator)
+	return SplitFeeAmount(total, protocolRate, protocolDenominator)
+}
+
+// SplitFeeAmount divides an already-charged fee between the protocol
+// (protocolRate/protocolDenominator, rounded down) and liquidity providers.
+func SplitFeeAmount(total, protocolRate, protocolDenominator uint64) FeeSplit {
+	if protocolRate == 0 || protocolDenominator == 0 {
+		return FeeSplit{LP: total}
+	}
+	protocol := mulDivFloor(total, protocolRate, protocolDenominator)
+	if protocol > total {
+		protocol = total
+	}
+	return FeeSplit{LP: total - protocol, Protocol: protocol}
+}
+
+// mulDivCeil computes ceil(a*b/d) with a 128-bit intermediate, saturating at
+// MaxUint64 when the quotient does not fit.
+func mulDivCeil(a, b, d uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	if hi >= d {
+		return math.MaxUint64
+	}
+	quo, rem := bits.Div64(hi, lo, d)
+	if rem > 0 && quo < math.MaxUint64 {
+		quo++
+	}
+	return quo
+}
+
+// mulDivFloor computes floor(a*b/d) with a 128-bit intermediate, saturating at
+// MaxUint64 when the quotient does not fit.
+func mulDivFloor(a, b, d uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	if hi >= d {
+		return math.MaxUint64
+	}
+	quo, _ := bits.Div64(hi, lo, d)
+	return quo
+}
@@ -0,0 +1,36 @@
+package common
+
+import "testing"
+
+func TestSplitSwapFee(t *testing.T) {
+	tests := []struct {
+		name         string
+		amountIn     uint64
+		feeRate      uint64
+		feeDenom     uint64
+		protocolRate uint64
+		protoDenom   uint64
+		want         FeeSplit
+	}{
+		{name: "whirlpool_30bps", amountIn: 1_000_000_000, feeRate: 3000, feeDenom: 1_000_000, protocolRate: 1300, protoDenom: 10_000, want: FeeSplit{LP: 2_610_000, Protocol: 390_000}},
+		{name: "rounds_fee_up", amountIn: 1_001, feeRate: 2500, feeDenom: 1_000_000, want: FeeSplit{LP: 3}},
+		{name: "raydium_protocol", amountIn: 10_000, feeRate: 2500, feeDenom: 1_000_000, protocolRate: 120_000, protoDenom: 1_000_000, want: FeeSplit{LP: 22, Protocol: 3}},
+		{name: "zero_rate", amountIn: 10_000, feeDenom: 1_000_000, want: FeeSplit{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitSwapFee(tt.amountIn, tt.feeRate, tt.feeDenom, tt.protocolRate, tt.protoDenom)
+			if got != tt.want {
+				t.Fatalf("SplitSwapFee = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitFeeAmount(t *testing.T) {
+	got := SplitFeeAmount(1_000, 2_000, 10_000)
+	if got.LP != 800 || got.Protocol != 200 || got.Total() != 1_000 {
+		t.Fatalf("unexpected split %+v", got)
+	}
+}
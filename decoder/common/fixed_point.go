@@ -18,37 +18,28 @@ var (
 
 // SqrtPriceQ64ToFloat converts a Q64.64 sqrt price to a float64 price
 // Formula: price = (sqrt_price / 2^64)^2
+// The price is computed exactly and rounded once; see SqrtPriceX64ToPrice for
+// the decimal-adjusted exact value.
 func SqrtPriceQ64ToFloat(sqrtPriceQ64Str string) (float64, error) {
-	sqrtPrice := new(big.Int)
-	_, ok := sqrtPrice.SetString(sqrtPriceQ64Str, 10)
-	if !ok {
-		return 0, fmt.Errorf("invalid sqrt price string: %s", sqrtPriceQ64Str)
+	sqrtPrice, err := parseSqrtPrice(sqrtPriceQ64Str)
+	if err != nil {
+		return 0, err
 	}
-
-	// Convert to big.Float for precision
-	sqrtPriceFloat := new(big.Float).SetInt(sqrtPrice)
-
-	// Divide by 2^64
-	divisor := new(big.Float).SetInt(Q64One)
-	sqrtPriceFloat.Quo(sqrtPriceFloat, divisor)
-
-	// Square to get the actual price
-	sqrtPriceFloat.Mul(sqrtPriceFloat, sqrtPriceFloat)
-
-	// Convert to float64
-	price, _ := sqrtPriceFloat.Float64()
+	price, _ := SqrtPriceX64ToPrice(sqrtPrice, 0, 0).Float64()
 	return price, nil
 }
 
 // FloatToSqrtPriceQ64 converts a float64 price to a Q64.64 sqrt price string
 // Formula: sqrt_price_q64 = sqrt(price) * 2^64
 func FloatToSqrtPriceQ64(price float64) string {
-	sqrtPrice := math.Sqrt(price)
-	sqrtPriceQ64 := new(big.Float).SetFloat64(sqrtPrice)
+	if price <= 0 || math.IsNaN(price) || math.IsInf(price, 0) {
+		return "0"
+	}
+	sqrtPriceQ64 := new(big.Float).SetPrec(256).SetFloat64(price)
+	sqrtPriceQ64.Sqrt(sqrtPriceQ64)
 
 	// Multiply by 2^64
-	multiplier := new(big.Float).SetInt(Q64One)
-	sqrtPriceQ64.Mul(sqrtPriceQ64, multiplier)
+	sqrtPriceQ64.SetMantExp(sqrtPriceQ64, Q64Shift)
 
 	// Convert to big.Int
 	result := new(big.Int)
@@ -57,49 +48,32 @@ func FloatToSqrtPriceQ64(price float64) string {
 	return result.String()
 }
 
-// TickIndexToSqrtPrice converts a tick index to a sqrt price
-// Formula: sqrt_price = 1.0001^(tick_index / 2) * 2^64
+// TickIndexToSqrtPrice converts a tick index to a Whirlpool Q64.64 sqrt price
+// (sqrt_price = 1.0001^(tick_index / 2) * 2^64), bit-identical to the
+// program's tick math. Ticks outside [MinTickIndex, MaxTickIndex] are
+// clamped.
 func TickIndexToSqrtPrice(tickIndex int32) string {
-	// Base for tick calculation: 1.0001
-	base := 1.0001
-
-	// Calculate sqrt price
-	exponent := float64(tickIndex) / 2.0
-	sqrtPrice := math.Pow(base, exponent)
-
-	// Convert to Q64.64
-	sqrtPriceQ64 := new(big.Float).SetFloat64(sqrtPrice)
-	multiplier := new(big.Float).SetInt(Q64One)
-	sqrtPriceQ64.Mul(sqrtPriceQ64, multiplier)
-
-	result := new(big.Int)
-	sqrtPriceQ64.Int(result)
-
-	return result.String()
+	tickIndex = max(MinTickIndex, min(tickIndex, MaxTickIndex))
+	sqrtPrice, _ := WhirlpoolSqrtPriceAtTick(tickIndex)
+	return sqrtPrice.String()
 }
 
-// SqrtPriceToTickIndex converts a sqrt price to the nearest tick index
-// Formula: tick_index = floor(log_1.0001(sqrt_price / 2^64) * 2)
+// SqrtPriceToTickIndex converts a Whirlpool Q64.64 sqrt price to the greatest
+// tick whose sqrt price does not exceed it, as the program does.
 func SqrtPriceToTickIndex(sqrtPriceQ64Str string) (int32, error) {
-	sqrtPrice := new(big.Int)
-	_, ok := sqrtPrice.SetString(sqrtPriceQ64Str, 10)
-	if !ok {
-		return 0, fmt.Errorf("invalid sqrt price string: %s", sqrtPriceQ64Str)
+	sqrtPrice, err := parseSqrtPrice(sqrtPriceQ64Str)
+	if err != nil {
+		return 0, err
 	}
+	return WhirlpoolTickAtSqrtPrice(sqrtPrice)
+}
 
-	// Convert to float64
-	sqrtPriceFloat := new(big.Float).SetInt(sqrtPrice)
-	divisor := new(big.Float).SetInt(Q64One)
-	sqrtPriceFloat.Quo(sqrtPriceFloat, divisor)
-
-	price, _ := sqrtPriceFloat.Float64()
-
-	// Calculate tick index: log_1.0001(price) * 2
-	// log_1.0001(x) = ln(x) / ln(1.0001)
-	logBase := math.Log(1.0001)
-	tickIndex := math.Floor(math.Log(price) / logBase * 2.0)
-
-	return int32(tickIndex), nil
+func parseSqrtPrice(s string) (*big.Int, error) {
+	sqrtPrice, ok := new(big.Int).SetString(s, 10)
+	if !ok || sqrtPrice.Sign() < 0 {
+		return nil, fmt.Errorf("invalid sqrt price string: %s", s)
+	}
+	return sqrtPrice, nil
 }
 
 // ScaleAmount scales a raw token amount by its decimal places
@@ -126,3 +100,43 @@ func CalculatePriceFromAmounts(amountA, amountB uint64, decimalsA, decimalsB uin
 	// Price is quoted as B/A (quote/base)
 	return scaledB / scaledA
 }
+
+// Q32FractionalBits matches FRACTIONAL_BITS in the C++ candle engine's
+// Q32.32 FixedPoint type.
+const Q32FractionalBits = 32
+
+// ExecutionPriceQ32 returns the decimal-adjusted quote-per-base price of a
+// trade as a Q32.32 fixed-point value:
+//
+//	floor(quote * 10^baseDecimals * 2^32 / (base * 10^quoteDecimals))
+//
+// The computation is exact, so it equals the C++ fp_divide of the two
+// decimal-adjusted amounts (truncation toward zero) without the intermediate
+// rounding of converting each amount to Q32.32 first.
+func ExecutionPriceQ32(baseAmount, quoteAmount uint64, baseDecimals, quoteDecimals uint8) (int64, error) {
+	if baseAmount == 0 {
+		return 0, fmt.Errorf("base amount is zero")
+	}
+
+	num := new(big.Int).SetUint64(quoteAmount)
+	num.Mul(num, pow10(baseDecimals))
+	num.Lsh(num, Q32FractionalBits)
+
+	den := new(big.Int).SetUint64(baseAmount)
+	den.Mul(den, pow10(quoteDecimals))
+
+	price := num.Quo(num, den)
+	if !price.IsInt64() {
+		return 0, fmt.Errorf("price overflows Q32.32: quote=%d base=%d", quoteAmount, baseAmount)
+	}
+	return price.Int64(), nil
+}
+
+// Q32ToFloat converts a Q32.32 fixed-point value to float64.
+func Q32ToFloat(value int64) float64 {
+	return float64(value) / (1 << Q32FractionalBits)
+}
+
+func pow10(exp uint8) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)
+}
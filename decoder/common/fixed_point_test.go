@@ -249,3 +249,48 @@ func abs32(x int32) int32 {
 	}
 	return x
 }
+
+func TestExecutionPriceQ32(t *testing.T) {
+	tests := []struct {
+		name        string
+		base, quote uint64
+		decB, decQ  uint8
+		want        int64
+		wantErr     bool
+	}{
+		// 10 SOL for 1000 USDC -> 100.0
+		{name: "sol_usdc", base: 10_000_000_000, quote: 1_000_000_000, decB: 9, decQ: 6, want: 100 << 32},
+		// 3 SOL for 306 USDC -> 102.0
+		{name: "whole_price", base: 3_000_000_000, quote: 306_000_000, decB: 9, decQ: 6, want: 102 << 32},
+		// 1 base for 1 quote unit -> 1/3 truncated toward zero
+		{name: "truncates", base: 3, quote: 1, want: 1431655765},
+		{name: "zero_base", base: 0, quote: 1, wantErr: true},
+		{name: "overflow", base: 1, quote: math.MaxUint64, decB: 9, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExecutionPriceQ32(tt.base, tt.quote, tt.decB, tt.decQ)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %d", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExecutionPriceQ32: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("ExecutionPriceQ32 = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	price, err := ExecutionPriceQ32(5_000_000_000, 507_500_000, 9, 6)
+	if err != nil {
+		t.Fatalf("ExecutionPriceQ32: %v", err)
+	}
+	if got := Q32ToFloat(price); math.Abs(got-101.5) > 1e-9 {
+		t.Fatalf("Q32ToFloat = %f, want 101.5", got)
+	}
+}
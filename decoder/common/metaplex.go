@@ -0,0 +1,119 @@
+package common
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/mr-tron/base58/base58"
+)
+
+// MetaplexMetadataProgramID is the Metaplex Token Metadata program.
+const MetaplexMetadataProgramID = "metaqbxxUerdq28cj1RbAWkYQm3ybzjb6a8bt518x1s"
+
+const (
+	metaplexKeyMetadataV1 = 4
+	metaplexHeaderLen     = 1 + 32 + 32
+	// Borsh strings are length-prefixed; cap them well above the program's
+	// own limits so corrupt data cannot trigger huge allocations.
+	maxMetadataStringLen = 1024
+)
+
+// TokenMetadata is the descriptive metadata of a mint, taken from a Metaplex
+// metadata account or the Token-2022 token-metadata extension.
+type TokenMetadata struct {
+	UpdateAuthority string
+	Mint            string
+	Name            string
+	Symbol          string
+	URI             string
+}
+
+// MetaplexMetadataAddress derives the metadata PDA of mint:
+// ["metadata", program, mint] under the Token Metadata program.
+func MetaplexMetadataAddress(mint string) (string, error) {
+	mintKey, err := base58.Decode(mint)
+	if err != nil {
+		return "", fmt.Errorf("decode mint %s: %w", mint, err)
+	}
+	program, err := base58.Decode(MetaplexMetadataProgramID)
+	if err != nil {
+		return "", fmt.Errorf("decode metadata program id: %w", err)
+	}
+	addr, _, err := FindProgramAddress([][]byte{[]byte("metadata"), program, mintKey}, program)
+	if err != nil {
+		return "", err
+	}
+	return base58.Encode(addr), nil
+}
+
+// DecodeMetaplexMetadata parses the leading fields of a Metaplex metadata
+// account. Name, symbol and URI are stored null-padded on chain; the padding
+// is trimmed.
+func DecodeMetaplexMetadata(data []byte) (*TokenMetadata, error) {
+	if len(data) < metaplexHeaderLen {
+		return nil, fmt.Errorf("metadata account too short: %d bytes", len(data))
+	}
+	if data[0] != metaplexKeyMetadataV1 {
+		return nil, fmt.Errorf("unexpected metadata key %d", data[0])
+	}
+	md := &TokenMetadata{
+		UpdateAuthority: base58.Encode(data[1:33]),
+		Mint:            base58.Encode(data[33:65]),
+	}
+	r := borshReader{data: data, offset: metaplexHeaderLen}
+	var err error
+	if md.Name, err = r.string(); err != nil {
+		return nil, fmt.Errorf("metadata name: %w", err)
+	}
+	if md.Symbol, err = r.string(); err != nil {
+		return nil, fmt.Errorf("metadata symbol: %w", err)
+	}
+	if md.URI, err = r.string(); err != nil {
+		return nil, fmt.Errorf("metadata uri: %w", err)
+	}
+	return md, nil
+}
+
+// decodeTokenMetadataExtension parses the Token-2022 TokenMetadata extension.
+// Additional metadata key/value pairs are ignored.
+func decodeTokenMetadataExtension(value []byte) (*TokenMetadata, error) {
+	if len(value) < 64 {
+		return nil, fmt.Errorf("token metadata extension too short: %d bytes", len(value))
+	}
+	md := &TokenMetadata{
+		UpdateAuthority: decodeOptionalNonZeroPubkey(value[0:32]),
+		Mint:            base58.Encode(value[32:64]),
+	}
+	r := borshReader{data: value, offset: 64}
+	var err error
+	if md.Name, err = r.string(); err != nil {
+		return nil, fmt.Errorf("token metadata name: %w", err)
+	}
+	if md.Symbol, err = r.string(); err != nil {
+		return nil, fmt.Errorf("token metadata symbol: %w", err)
+	}
+	if md.URI, err = r.string(); err != nil {
+		return nil, fmt.Errorf("token metadata uri: %w", err)
+	}
+	return md, nil
+}
+
+type borshReader struct {
+	data   []byte
+	offset int
+}
+
+func (r *borshReader) string() (string, error) {
+	if r.offset+4 > len(r.data) {
+		return "", fmt.Errorf("length prefix at %d overruns %d bytes", r.offset, len(r.data))
+	}
+	n := int(binary.LittleEndian.Uint32(r.data[r.offset:]))
+	r.offset += 4
+	if n > maxMetadataStringLen || r.offset+n > len(r.data) {
+		return "", fmt.Errorf("string of %d bytes at %d overruns %d bytes", n, r.offset, len(r.data))
+	}
+	s := string(r.data[r.offset : r.offset+n])
+	r.offset += n
+	return strings.TrimRight(s, "\x00"), nil
+}
@@ -0,0 +1,91 @@
+package common
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/mr-tron/base58/base58"
+)
+
+func TestDecodeMetaplexMetadata(t *testing.T) {
+	authority := make([]byte, 32)
+	authority[0] = 7
+	mint, _ := base58.Decode("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+
+	data := []byte{metaplexKeyMetadataV1}
+	data = append(data, authority...)
+	data = append(data, mint...)
+	data = appendBorshString(data, padded("USD Coin", 32))
+	data = appendBorshString(data, padded("USDC", 10))
+	data = appendBorshString(data, padded("https://example.com/usdc.json", 200))
+	data = append(data, 0, 0) // seller fee basis points
+
+	md, err := DecodeMetaplexMetadata(data)
+	if err != nil {
+		t.Fatalf("DecodeMetaplexMetadata: %v", err)
+	}
+	if md.Mint != "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v" {
+		t.Fatalf("mint=%s", md.Mint)
+	}
+	if md.UpdateAuthority != base58.Encode(authority) {
+		t.Fatalf("update authority=%s", md.UpdateAuthority)
+	}
+	if md.Name != "USD Coin" || md.Symbol != "USDC" || md.URI != "https://example.com/usdc.json" {
+		t.Fatalf("unexpected metadata %+v", md)
+	}
+
+	if _, err := DecodeMetaplexMetadata(data[:80]); err == nil {
+		t.Fatal("expected error for truncated name")
+	}
+	data[0] = 1
+	if _, err := DecodeMetaplexMetadata(data); err == nil {
+		t.Fatal("expected error for non-metadata key")
+	}
+}
+
+func TestDecodeTokenMintMetadataExtension(t *testing.T) {
+	mintKey := make([]byte, 32)
+	mintKey[31] = 9
+
+	value := make([]byte, 32) // no update authority
+	value = append(value, mintKey...)
+	value = appendBorshString(value, "Token Twenty Two")
+	value = appendBorshString(value, "T22")
+	value = appendBorshString(value, "https://example.com/t22.json")
+	value = append(value, 0, 0, 0, 0) // no additional metadata
+
+	data := make([]byte, MintAccountTypeOffset+1)
+	data[44] = 6
+	data[45] = 1
+	data[MintAccountTypeOffset] = accountTypeMint
+	header := make([]byte, tlvHeaderLen)
+	binary.LittleEndian.PutUint16(header[0:2], extensionTokenMetadata)
+	binary.LittleEndian.PutUint16(header[2:4], uint16(len(value)))
+	data = append(data, header...)
+	data = append(data, value...)
+
+	mint, err := DecodeTokenMint(data)
+	if err != nil {
+		t.Fatalf("DecodeTokenMint: %v", err)
+	}
+	if mint.Metadata == nil {
+		t.Fatal("expected token metadata extension")
+	}
+	if mint.Metadata.Symbol != "T22" || mint.Metadata.Name != "Token Twenty Two" {
+		t.Fatalf("unexpected metadata %+v", mint.Metadata)
+	}
+	if mint.Metadata.UpdateAuthority != "" || mint.Metadata.Mint != base58.Encode(mintKey) {
+		t.Fatalf("unexpected keys %+v", mint.Metadata)
+	}
+}
+
+func appendBorshString(dst []byte, s string) []byte {
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(len(s)))
+	return append(dst, s...)
+}
+
+func padded(s string, n int) string {
+	out := make([]byte, n)
+	copy(out, s)
+	return string(out)
+}
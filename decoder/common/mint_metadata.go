@@ -11,10 +11,18 @@ type MintMetadata struct {
 	Symbol   string `json:"symbol"`
 	Decimals uint8  `json:"decimals"`
 	Name     string `json:"name"`
+
+	// On-chain fields, populated by providers that read mint accounts.
+	URI             string `json:"uri,omitempty"`
+	Supply          uint64 `json:"supply,omitempty"`
+	MintAuthority   string `json:"mint_authority,omitempty"`
+	FreezeAuthority string `json:"freeze_authority,omitempty"`
+	TokenProgram    string `json:"token_program,omitempty"`
+	// Slot is the slot of the account data the entry was built from.
+	Slot uint64 `json:"slot,omitempty"`
 }
 
-// MintMetadataProvider is the interface that Engineer B will implement
-// to provide mint metadata lookup functionality
+// MintMetadataProvider provides mint metadata lookup functionality
 type MintMetadataProvider interface {
 	// GetMintMetadata retrieves metadata for a given mint address
 	GetMintMetadata(mintAddress string) (*MintMetadata, error)
@@ -26,8 +34,9 @@ type MintMetadataProvider interface {
 	CacheMintMetadata(mintAddresses []string) error
 }
 
-// InMemoryMintMetadataProvider is a simple in-memory implementation
-// This is a stub/mock for testing until Engineer B provides the real implementation
+// InMemoryMintMetadataProvider is a simple in-memory implementation with a
+// fixed set of well-known mints, used in tests and as a static fallback. The
+// on-chain provider lives in ingestor/mintmeta.
 type InMemoryMintMetadataProvider struct {
 	mu       sync.RWMutex
 	metadata map[string]*MintMetadata
@@ -99,8 +108,7 @@ func (p *InMemoryMintMetadataProvider) GetDecimals(mintAddress string) (uint8, e
 
 // CacheMintMetadata pre-loads metadata for a batch of mints
 func (p *InMemoryMintMetadataProvider) CacheMintMetadata(mintAddresses []string) error {
-	// In the stub implementation, this is a no-op since we pre-populate common mints
-	// Engineer B's implementation will fetch from an external source
+	// No-op: the static provider has nothing to fetch
 	return nil
 }
 
@@ -112,15 +120,8 @@ func (p *InMemoryMintMetadataProvider) AddMintMetadata(metadata *MintMetadata) {
 	p.metadata[metadata.Address] = metadata
 }
 
-var canonicalPriority = map[string]int{
-	"USDC":    100,
-	"USDT":    90,
-	"SOL":     80,
-	"default": 0,
-}
-
 // DetermineBaseQuote determines which mint should be the base and which should be quote
-// based on canonical ordering rules (USDC > USDT > SOL > others)
+// based on the same quote priority rules as ResolvePair (USDC > USDT > SOL > others)
 func DetermineBaseQuote(mintA, mintB string, provider MintMetadataProvider) (base, quote string, err error) {
 	metadataA, err := provider.GetMintMetadata(mintA)
 	if err != nil {
@@ -133,8 +134,8 @@ func DetermineBaseQuote(mintA, mintB string, provider MintMetadataProvider) (bas
 	}
 
 	// Find priority for each symbol
-	priorityA := getPriority(metadataA.Symbol)
-	priorityB := getPriority(metadataB.Symbol)
+	priorityA := quotePriorityFor(mintA, metadataA.Symbol)
+	priorityB := quotePriorityFor(mintB, metadataB.Symbol)
 
 	switch {
 	case priorityA == priorityB:
@@ -148,10 +149,3 @@ func DetermineBaseQuote(mintA, mintB string, provider MintMetadataProvider) (bas
 		return mintA, mintB, nil
 	}
 }
-
-func getPriority(symbol string) int {
-	if priority, ok := canonicalPriority[symbol]; ok {
-		return priority
-	}
-	return canonicalPriority["default"]
-}
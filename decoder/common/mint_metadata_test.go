@@ -194,7 +194,7 @@ func TestPriorityMap(t *testing.T) {
 	}
 
 	for symbol, expected := range cases {
-		if got := getPriority(symbol); got != expected {
+		if got := getQuotePriority(symbol); got != expected {
 			t.Errorf("priority for %s: got %d, want %d", symbol, got, expected)
 		}
 	}
@@ -227,3 +227,22 @@ func TestAddMintMetadata(t *testing.T) {
 		t.Errorf("Decimals mismatch: got %d, want %d", metadata.Decimals, customMint.Decimals)
 	}
 }
+
+func TestDetermineBaseQuoteIgnoresSpoofedQuoteSymbol(t *testing.T) {
+	provider := NewInMemoryMintMetadataProvider()
+	provider.AddMintMetadata(&MintMetadata{
+		Address:  "1111111111111111111111111111111FakeUSDC",
+		Symbol:   "USDC",
+		Decimals: 6,
+		Name:     "Not USD Coin",
+	})
+
+	const sol = "So11111111111111111111111111111111111111112"
+	base, quote, err := DetermineBaseQuote("1111111111111111111111111111111FakeUSDC", sol, provider)
+	if err != nil {
+		t.Fatalf("DetermineBaseQuote failed: %v", err)
+	}
+	if quote != sol || base != "1111111111111111111111111111111FakeUSDC" {
+		t.Fatalf("spoofed USDC must not outrank SOL: base=%s quote=%s", base, quote)
+	}
+}
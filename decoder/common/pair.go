@@ -4,28 +4,6 @@ import (
 	"fmt"
 )
 
-// Priority order for canonical pair determination
-// Higher priority tokens should be the quote (second token in pair)
-var quotePriority = map[string]int{
-	"USDC":    100, // Highest priority quote
-	"USDT":    90,
-	"SOL":     80,
-	"WSOL":    75,
-	"ETH":     70,
-	"WETH":    65,
-	"BTC":     60,
-	"WBTC":    55,
-	"default": 0,
-}
-
-// Well-known token addresses on Solana
-var knownTokens = map[string]string{
-	"EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v": "USDC",
-	"Es9vMFrzaCERmJfrF4H2FYD4KCoNkY11McCe8BenwNYB": "USDT",
-	"So11111111111111111111111111111111111111112":  "SOL",
-	"So11111111111111111111111111111111111111111":  "WSOL",
-}
-
 // CanonicalPair represents a normalized trading pair
 type CanonicalPair struct {
 	// BaseToken is the first token (lower priority)
@@ -53,8 +31,8 @@ func ResolvePair(mintA, mintB string) (*CanonicalPair, error) {
 	symbolB := resolveTokenSymbol(mintB)
 
 	// Get priorities
-	priorityA := getQuotePriority(symbolA)
-	priorityB := getQuotePriority(symbolB)
+	priorityA := quotePriorityFor(mintA, symbolA)
+	priorityB := quotePriorityFor(mintB, symbolB)
 
 	pair := &CanonicalPair{}
 
@@ -98,7 +76,7 @@ func ResolvePair(mintA, mintB string) (*CanonicalPair, error) {
 
 // resolveTokenSymbol returns the symbol for a known token mint, or the mint address if unknown
 func resolveTokenSymbol(mint string) string {
-	if symbol, ok := knownTokens[mint]; ok {
+	if symbol, ok := tokens.symbol(mint); ok {
 		return symbol
 	}
 	// For unknown tokens, return the first 8 characters of the mint as identifier
@@ -110,10 +88,7 @@ func resolveTokenSymbol(mint string) string {
 
 // getQuotePriority returns the priority value for a token symbol
 func getQuotePriority(symbol string) int {
-	if priority, ok := quotePriority[symbol]; ok {
-		return priority
-	}
-	return quotePriority["default"]
+	return tokens.symbolPriority(symbol)
 }
 
 // Symbol returns the canonical pair symbol (e.g., "SOL/USDC")
@@ -137,11 +112,11 @@ func (p *CanonicalPair) IsStablecoinPair() bool {
 // RegisterToken adds or updates a token in the known tokens registry
 // This allows dynamic token registration at runtime
 func RegisterToken(mint, symbol string) {
-	knownTokens[mint] = symbol
+	tokens.register(mint, symbol)
 }
 
 // SetQuotePriority sets the priority for a token symbol
 // Higher values indicate higher priority as a quote token
 func SetQuotePriority(symbol string, priority int) {
-	quotePriority[symbol] = priority
+	tokens.setPriority(symbol, priority)
 }
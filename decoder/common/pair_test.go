@@ -176,7 +176,7 @@ func TestRegisterToken(t *testing.T) {
 	}
 
 	// Clean up
-	delete(knownTokens, testMint)
+	resetTokenRegistry()
 }
 
 func TestSetQuotePriority(t *testing.T) {
@@ -193,7 +193,7 @@ func TestSetQuotePriority(t *testing.T) {
 	}
 
 	// Clean up
-	delete(quotePriority, testSymbol)
+	resetTokenRegistry()
 }
 
 // Benchmark for pair resolution
@@ -0,0 +1,116 @@
+package common
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/mr-tron/base58/base58"
+)
+
+const (
+	pdaMarker     = "ProgramDerivedAddress"
+	maxSeeds      = 16
+	maxSeedLength = 32
+)
+
+// ErrNoProgramAddress is returned when no bump seed yields an off-curve
+// address.
+var ErrNoProgramAddress = errors.New("unable to find a viable program address bump seed")
+
+var (
+	// curveP is the field prime 2^255 - 19.
+	curveP = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+	// curveD is the edwards25519 constant -121665/121666 mod p.
+	curveD = new(big.Int).Mod(
+		new(big.Int).Mul(big.NewInt(-121665), new(big.Int).ModInverse(big.NewInt(121666), curveP)),
+		curveP,
+	)
+	curveLegendreExp = new(big.Int).Rsh(new(big.Int).Sub(curveP, big.NewInt(1)), 1)
+)
+
+// CreateProgramAddress mirrors Pubkey::create_program_address: it hashes the
+// seeds with the program ID and rejects results that lie on the ed25519 curve.
+func CreateProgramAddress(seeds [][]byte, programID []byte) ([]byte, error) {
+	if len(seeds) > maxSeeds {
+		return nil, fmt.Errorf("too many seeds: %d", len(seeds))
+	}
+	h := sha256.New()
+	for _, seed := range seeds {
+		if len(seed) > maxSeedLength {
+			return nil, fmt.Errorf("seed too long: %d bytes", len(seed))
+		}
+		h.Write(seed)
+	}
+	h.Write(programID)
+	h.Write([]byte(pdaMarker))
+	addr := h.Sum(nil)
+	if isOnCurve(addr) {
+		return nil, errors.New("address is on the ed25519 curve")
+	}
+	return addr, nil
+}
+
+// FindProgramAddress mirrors Pubkey::find_program_address, searching bump
+// seeds from 255 downwards.
+func FindProgramAddress(seeds [][]byte, programID []byte) ([]byte, uint8, error) {
+	withBump := make([][]byte, len(seeds)+1)
+	copy(withBump, seeds)
+	for bump := 255; bump >= 0; bump-- {
+		withBump[len(seeds)] = []byte{byte(bump)}
+		if addr, err := CreateProgramAddress(withBump, programID); err == nil {
+			return addr, uint8(bump), nil
+		}
+	}
+	return nil, 0, ErrNoProgramAddress
+}
+
+// FindProgramAddressBase58 is FindProgramAddress for a base58 program ID,
+// returning the address in base58.
+func FindProgramAddressBase58(seeds [][]byte, programID string) (string, error) {
+	program, err := base58.Decode(programID)
+	if err != nil {
+		return "", fmt.Errorf("decode program id: %w", err)
+	}
+	addr, _, err := FindProgramAddress(seeds, program)
+	if err != nil {
+		return "", err
+	}
+	return base58.Encode(addr), nil
+}
+
+// isOnCurve reports whether the 32-byte compressed Edwards point decompresses,
+// i.e. whether (y^2 - 1) / (d*y^2 + 1) is a square modulo p.
+func isOnCurve(point []byte) bool {
+	if len(point) != 32 {
+		return false
+	}
+	le := make([]byte, 32)
+	copy(le, point)
+	le[31] &= 0x7f
+	for i, j := 0, len(le)-1; i < j; i, j = i+1, j-1 {
+		le[i], le[j] = le[j], le[i]
+	}
+	y := new(big.Int).SetBytes(le)
+	y.Mod(y, curveP)
+
+	y2 := new(big.Int).Mul(y, y)
+	y2.Mod(y2, curveP)
+	u := new(big.Int).Sub(y2, big.NewInt(1))
+	u.Mod(u, curveP)
+	v := new(big.Int).Mul(curveD, y2)
+	v.Add(v, big.NewInt(1))
+	v.Mod(v, curveP)
+
+	vInv := new(big.Int).ModInverse(v, curveP)
+	if vInv == nil {
+		return false
+	}
+	x2 := new(big.Int).Mul(u, vInv)
+	x2.Mod(x2, curveP)
+	if x2.Sign() == 0 {
+		return true
+	}
+	return new(big.Int).Exp(x2, curveLegendreExp, curveP).Cmp(big.NewInt(1)) == 0
+}
@@ -0,0 +1,73 @@
+package common
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/mr-tron/base58/base58"
+)
+
+func TestIsOnCurveAcceptsPublicKeys(t *testing.T) {
+	for i := 0; i < 32; i++ {
+		seed := sha256.Sum256([]byte{byte(i)})
+		pub := ed25519.NewKeyFromSeed(seed[:]).Public().(ed25519.PublicKey)
+		if !isOnCurve(pub) {
+			t.Fatalf("public key %d reported off curve", i)
+		}
+	}
+}
+
+func TestIsOnCurveRejectsSomeHashes(t *testing.T) {
+	off := 0
+	for i := 0; i < 64; i++ {
+		h := sha256.Sum256([]byte{byte(i), 0xff})
+		if !isOnCurve(h[:]) {
+			off++
+		}
+	}
+	// Roughly half of all 32-byte strings decompress.
+	if off < 16 || off > 48 {
+		t.Fatalf("unexpected off-curve count %d/64", off)
+	}
+}
+
+func TestFindProgramAddress(t *testing.T) {
+	program, _ := base58.Decode(MetaplexMetadataProgramID)
+	mint, _ := base58.Decode("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+	seeds := [][]byte{[]byte("metadata"), program, mint}
+
+	addr, bump, err := FindProgramAddress(seeds, program)
+	if err != nil {
+		t.Fatalf("FindProgramAddress: %v", err)
+	}
+	if isOnCurve(addr) {
+		t.Fatal("derived address must be off curve")
+	}
+	again, err := CreateProgramAddress(append(seeds, []byte{bump}), program)
+	if err != nil || !bytes.Equal(again, addr) {
+		t.Fatalf("CreateProgramAddress mismatch: %v", err)
+	}
+	for b := 255; b > int(bump); b-- {
+		if _, err := CreateProgramAddress(append(seeds, []byte{byte(b)}), program); err == nil {
+			t.Fatalf("bump %d is viable but %d was chosen", b, bump)
+		}
+	}
+
+	// USDC's metadata account on mainnet.
+	const want = "5x38Kp4hvdomTCnCrAny4UtMUt5rQBdB6px2K1Ui45Wq"
+	if base58.Encode(addr) != want {
+		t.Fatalf("address=%s want %s", base58.Encode(addr), want)
+	}
+	viaMint, err := MetaplexMetadataAddress("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+	if err != nil || viaMint != want {
+		t.Fatalf("MetaplexMetadataAddress=%s err=%v", viaMint, err)
+	}
+}
+
+func TestCreateProgramAddressRejectsLongSeed(t *testing.T) {
+	if _, err := CreateProgramAddress([][]byte{make([]byte, 33)}, make([]byte, 32)); err == nil {
+		t.Fatal("expected error for seed longer than 32 bytes")
+	}
+}
@@ -0,0 +1,245 @@
+package common
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/mr-tron/base58/base58"
+)
+
+// SPL token program identifiers.
+const (
+	TokenProgramID     = "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA"
+	Token2022ProgramID = "TokenzQdBNbLqP5VEhdkAS6EPFLC1PQnBqHGnNM2Mk8"
+)
+
+// SlotsPerEpoch is the mainnet-beta epoch length used to select the active
+// transfer fee for a slot.
+const SlotsPerEpoch = 432_000
+
+const (
+	// MintBaseLen is the size of a classic SPL mint account.
+	MintBaseLen = 82
+	// TokenAccountLen is the size of a classic SPL token account. Token-2022
+	// pads mints to this length before the account-type byte.
+	TokenAccountLen = 165
+	// MintAccountTypeOffset locates the Token-2022 account-type discriminator.
+	MintAccountTypeOffset = TokenAccountLen
+
+	accountTypeMint = 1
+	tlvStartOffset  = MintAccountTypeOffset + 1
+	tlvHeaderLen    = 4
+
+	extensionTransferFeeConfig     = 1
+	extensionInterestBearingConfig = 10
+	extensionTokenMetadata         = 19
+
+	transferFeeConfigLen     = 32 + 32 + 8 + transferFeeLen*2
+	transferFeeLen           = 8 + 8 + 2
+	interestBearingConfigLen = 32 + 8 + 2 + 8 + 2
+
+	oneInBasisPoints = 10_000
+	secondsPerYear   = 60 * 60 * 24 * 365.24
+)
+
+// TransferFee mirrors the spl-token-2022 TransferFee struct.
+type TransferFee struct {
+	Epoch       uint64
+	MaximumFee  uint64
+	BasisPoints uint16
+}
+
+// TransferFeeConfig mirrors the spl-token-2022 TransferFeeConfig extension.
+type TransferFeeConfig struct {
+	ConfigAuthority   string
+	WithdrawAuthority string
+	WithheldAmount    uint64
+	Older             TransferFee
+	Newer             TransferFee
+}
+
+// InterestBearingConfig mirrors the spl-token-2022 InterestBearingConfig extension.
+type InterestBearingConfig struct {
+	RateAuthority           string
+	InitializationTimestamp int64
+	PreUpdateAverageRate    int16
+	LastUpdateTimestamp     int64
+	CurrentRate             int16
+}
+
+// TokenMint is the decoded form of an SPL Token or Token-2022 mint account.
+type TokenMint struct {
+	MintAuthority   string
+	Supply          uint64
+	Decimals        uint8
+	IsInitialized   bool
+	FreezeAuthority string
+
+	TransferFee     *TransferFeeConfig
+	InterestBearing *InterestBearingConfig
+	// Metadata is set when the mint carries the token-metadata extension.
+	Metadata *TokenMetadata
+}
+
+// DecodeTokenMint parses a mint account. Extension data is only inspected when
+// the account carries the Token-2022 mint account-type byte.
+func DecodeTokenMint(data []byte) (*TokenMint, error) {
+	if len(data) < MintBaseLen {
+		return nil, fmt.Errorf("mint account too short: have %d want >= %d", len(data), MintBaseLen)
+	}
+
+	mint := &TokenMint{
+		MintAuthority:   decodeCOptionPubkey(data[0:36]),
+		Supply:          binary.LittleEndian.Uint64(data[36:44]),
+		Decimals:        data[44],
+		IsInitialized:   data[45] != 0,
+		FreezeAuthority: decodeCOptionPubkey(data[46:82]),
+	}
+
+	if len(data) <= MintAccountTypeOffset {
+		return mint, nil
+	}
+	if data[MintAccountTypeOffset] != accountTypeMint {
+		return nil, fmt.Errorf("unexpected token-2022 account type %d", data[MintAccountTypeOffset])
+	}
+
+	offset := tlvStartOffset
+	for offset+tlvHeaderLen <= len(data) {
+		extType := binary.LittleEndian.Uint16(data[offset : offset+2])
+		extLen := int(binary.LittleEndian.Uint16(data[offset+2 : offset+4]))
+		offset += tlvHeaderLen
+		if extType == 0 && extLen == 0 {
+			break
+		}
+		if offset+extLen > len(data) {
+			return nil, fmt.Errorf("extension %d overruns account: offset %d len %d", extType, offset, extLen)
+		}
+		value := data[offset : offset+extLen]
+		switch extType {
+		case extensionTransferFeeConfig:
+			cfg, err := decodeTransferFeeConfig(value)
+			if err != nil {
+				return nil, err
+			}
+			mint.TransferFee = cfg
+		case extensionInterestBearingConfig:
+			cfg, err := decodeInterestBearingConfig(value)
+			if err != nil {
+				return nil, err
+			}
+			mint.InterestBearing = cfg
+		case extensionTokenMetadata:
+			md, err := decodeTokenMetadataExtension(value)
+			if err != nil {
+				return nil, err
+			}
+			mint.Metadata = md
+		}
+		offset += extLen
+	}
+
+	return mint, nil
+}
+
+func decodeTransferFeeConfig(value []byte) (*TransferFeeConfig, error) {
+	if len(value) < transferFeeConfigLen {
+		return nil, fmt.Errorf("transfer fee config too short: have %d want %d", len(value), transferFeeConfigLen)
+	}
+	return &TransferFeeConfig{
+		ConfigAuthority:   decodeOptionalNonZeroPubkey(value[0:32]),
+		WithdrawAuthority: decodeOptionalNonZeroPubkey(value[32:64]),
+		WithheldAmount:    binary.LittleEndian.Uint64(value[64:72]),
+		Older:             decodeTransferFee(value[72 : 72+transferFeeLen]),
+		Newer:             decodeTransferFee(value[72+transferFeeLen : 72+2*transferFeeLen]),
+	}, nil
+}
+
+func decodeTransferFee(value []byte) TransferFee {
+	return TransferFee{
+		Epoch:       binary.LittleEndian.Uint64(value[0:8]),
+		MaximumFee:  binary.LittleEndian.Uint64(value[8:16]),
+		BasisPoints: binary.LittleEndian.Uint16(value[16:18]),
+	}
+}
+
+func decodeInterestBearingConfig(value []byte) (*InterestBearingConfig, error) {
+	if len(value) < interestBearingConfigLen {
+		return nil, fmt.Errorf("interest bearing config too short: have %d want %d", len(value), interestBearingConfigLen)
+	}
+	return &InterestBearingConfig{
+		RateAuthority:           decodeOptionalNonZeroPubkey(value[0:32]),
+		InitializationTimestamp: int64(binary.LittleEndian.Uint64(value[32:40])),
+		PreUpdateAverageRate:    int16(binary.LittleEndian.Uint16(value[40:42])),
+		LastUpdateTimestamp:     int64(binary.LittleEndian.Uint64(value[42:50])),
+		CurrentRate:             int16(binary.LittleEndian.Uint16(value[50:52])),
+	}, nil
+}
+
+func decodeCOptionPubkey(data []byte) string {
+	if binary.LittleEndian.Uint32(data[0:4]) == 0 {
+		return ""
+	}
+	return base58.Encode(data[4:36])
+}
+
+func decodeOptionalNonZeroPubkey(data []byte) string {
+	for _, b := range data {
+		if b != 0 {
+			return base58.Encode(data)
+		}
+	}
+	return ""
+}
+
+// EpochForSlot returns the epoch containing slot.
+func EpochForSlot(slot uint64) uint64 {
+	return slot / SlotsPerEpoch
+}
+
+// EpochFee returns the transfer fee active during epoch.
+func (c *TransferFeeConfig) EpochFee(epoch uint64) TransferFee {
+	if epoch >= c.Newer.Epoch {
+		return c.Newer
+	}
+	return c.Older
+}
+
+// Fee calculates the fee withheld from a transfer of preFeeAmount.
+func (f TransferFee) Fee(preFeeAmount uint64) uint64 {
+	if f.BasisPoints == 0 || preFeeAmount == 0 {
+		return 0
+	}
+	fee := mulDivCeil(preFeeAmount, uint64(f.BasisPoints), oneInBasisPoints)
+	if fee > f.MaximumFee {
+		return f.MaximumFee
+	}
+	return fee
+}
+
+// PreFeeAmount inverts Fee: it returns the amount a sender must transfer so the
+// recipient is credited postFeeAmount.
+func (f TransferFee) PreFeeAmount(postFeeAmount uint64) uint64 {
+	switch {
+	case f.BasisPoints == 0:
+		return postFeeAmount
+	case postFeeAmount == 0:
+		return 0
+	case f.BasisPoints >= oneInBasisPoints:
+		return postFeeAmount + f.MaximumFee
+	}
+	preFee := mulDivCeil(postFeeAmount, oneInBasisPoints, uint64(oneInBasisPoints-f.BasisPoints))
+	if preFee-postFeeAmount >= f.MaximumFee {
+		return postFeeAmount + f.MaximumFee
+	}
+	return preFee
+}
+
+// ScaleFactor returns the multiplier applied to raw amounts when rendering UI
+// amounts at unixTimestamp, following the spl-token-2022 continuous
+// compounding formula.
+func (c *InterestBearingConfig) ScaleFactor(unixTimestamp int64) float64 {
+	preUpdate := float64(c.PreUpdateAverageRate) * float64(c.LastUpdateTimestamp-c.InitializationTimestamp) / secondsPerYear / oneInBasisPoints
+	postUpdate := float64(c.CurrentRate) * float64(unixTimestamp-c.LastUpdateTimestamp) / secondsPerYear / oneInBasisPoints
+	return math.Exp(preUpdate) * math.Exp(postUpdate)
+}
@@ -0,0 +1,143 @@
+package common
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func buildToken2022Mint(extensions ...[]byte) []byte {
+	data := make([]byte, TokenAccountLen+1)
+	binary.LittleEndian.PutUint64(data[36:44], 1_000_000)
+	data[44] = 6
+	data[45] = 1
+	data[MintAccountTypeOffset] = accountTypeMint
+	for _, ext := range extensions {
+		data = append(data, ext...)
+	}
+	return data
+}
+
+func tlv(extType uint16, value []byte) []byte {
+	out := make([]byte, tlvHeaderLen+len(value))
+	binary.LittleEndian.PutUint16(out[0:2], extType)
+	binary.LittleEndian.PutUint16(out[2:4], uint16(len(value)))
+	copy(out[tlvHeaderLen:], value)
+	return out
+}
+
+func transferFeeConfigValue(older, newer TransferFee, withheld uint64) []byte {
+	value := make([]byte, transferFeeConfigLen)
+	binary.LittleEndian.PutUint64(value[64:72], withheld)
+	putFee := func(dst []byte, fee TransferFee) {
+		binary.LittleEndian.PutUint64(dst[0:8], fee.Epoch)
+		binary.LittleEndian.PutUint64(dst[8:16], fee.MaximumFee)
+		binary.LittleEndian.PutUint16(dst[16:18], fee.BasisPoints)
+	}
+	putFee(value[72:72+transferFeeLen], older)
+	putFee(value[72+transferFeeLen:], newer)
+	return value
+}
+
+func TestDecodeTokenMintClassic(t *testing.T) {
+	data := make([]byte, MintBaseLen)
+	data[44] = 9
+	data[45] = 1
+
+	mint, err := DecodeTokenMint(data)
+	if err != nil {
+		t.Fatalf("DecodeTokenMint: %v", err)
+	}
+	if mint.Decimals != 9 || !mint.IsInitialized {
+		t.Fatalf("unexpected mint %+v", mint)
+	}
+	if mint.TransferFee != nil || mint.InterestBearing != nil {
+		t.Fatalf("classic mint should not carry extensions")
+	}
+}
+
+func TestDecodeTokenMintExtensions(t *testing.T) {
+	older := TransferFee{Epoch: 500, MaximumFee: 1_000, BasisPoints: 50}
+	newer := TransferFee{Epoch: 600, MaximumFee: 5_000, BasisPoints: 100}
+
+	interest := make([]byte, interestBearingConfigLen)
+	binary.LittleEndian.PutUint64(interest[32:40], 1_700_000_000)
+	binary.LittleEndian.PutUint16(interest[40:42], 250)
+	binary.LittleEndian.PutUint64(interest[42:50], 1_700_000_000)
+	binary.LittleEndian.PutUint16(interest[50:52], 500)
+
+	data := buildToken2022Mint(
+		tlv(extensionTransferFeeConfig, transferFeeConfigValue(older, newer, 42)),
+		tlv(extensionInterestBearingConfig, interest),
+	)
+
+	mint, err := DecodeTokenMint(data)
+	if err != nil {
+		t.Fatalf("DecodeTokenMint: %v", err)
+	}
+	if mint.Supply != 1_000_000 || mint.Decimals != 6 {
+		t.Fatalf("unexpected base mint fields %+v", mint)
+	}
+	if mint.TransferFee == nil {
+		t.Fatalf("expected transfer fee config")
+	}
+	if mint.TransferFee.Older != older || mint.TransferFee.Newer != newer || mint.TransferFee.WithheldAmount != 42 {
+		t.Fatalf("unexpected transfer fee config %+v", mint.TransferFee)
+	}
+	if got := mint.TransferFee.EpochFee(599); got != older {
+		t.Fatalf("epoch 599 fee = %+v, want older", got)
+	}
+	if got := mint.TransferFee.EpochFee(600); got != newer {
+		t.Fatalf("epoch 600 fee = %+v, want newer", got)
+	}
+
+	if mint.InterestBearing == nil || mint.InterestBearing.CurrentRate != 500 {
+		t.Fatalf("unexpected interest bearing config %+v", mint.InterestBearing)
+	}
+	oneYear := int64(secondsPerYear)
+	scale := mint.InterestBearing.ScaleFactor(1_700_000_000 + oneYear)
+	if math.Abs(scale-math.Exp(0.05)) > 1e-6 {
+		t.Fatalf("scale factor = %f, want %f", scale, math.Exp(0.05))
+	}
+}
+
+func TestDecodeTokenMintRejectsTruncatedExtension(t *testing.T) {
+	data := buildToken2022Mint(tlv(extensionTransferFeeConfig, make([]byte, 8)))
+	if _, err := DecodeTokenMint(data); err == nil {
+		t.Fatalf("expected error for truncated transfer fee config")
+	}
+
+	data = buildToken2022Mint()
+	data[MintAccountTypeOffset] = 2
+	if _, err := DecodeTokenMint(data); err == nil {
+		t.Fatalf("expected error for token account type")
+	}
+}
+
+func TestTransferFeeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		fee     TransferFee
+		gross   uint64
+		wantFee uint64
+	}{
+		{name: "zero_bps", fee: TransferFee{MaximumFee: 100, BasisPoints: 0}, gross: 1_000, wantFee: 0},
+		{name: "rounds_up", fee: TransferFee{MaximumFee: math.MaxUint64, BasisPoints: 100}, gross: 1_001, wantFee: 11},
+		{name: "exact", fee: TransferFee{MaximumFee: math.MaxUint64, BasisPoints: 250}, gross: 10_000, wantFee: 250},
+		{name: "capped", fee: TransferFee{MaximumFee: 5, BasisPoints: 1_000}, gross: 1_000_000, wantFee: 5},
+		{name: "large_amount", fee: TransferFee{MaximumFee: math.MaxUint64, BasisPoints: 30}, gross: math.MaxUint64 / 2, wantFee: 27670116110564328},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fee.Fee(tt.gross); got != tt.wantFee {
+				t.Fatalf("Fee(%d) = %d, want %d", tt.gross, got, tt.wantFee)
+			}
+			net := tt.gross - tt.wantFee
+			pre := tt.fee.PreFeeAmount(net)
+			if pre-tt.fee.Fee(pre) != net {
+				t.Fatalf("PreFeeAmount(%d) = %d does not credit net amount", net, pre)
+			}
+		})
+	}
+}
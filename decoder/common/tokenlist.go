@@ -0,0 +1,209 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mr-tron/base58/base58"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// solanaMainnetChainID is the chainId used by the Solana token-list format.
+	solanaMainnetChainID = 101
+
+	envTokenListPath         = "TOKEN_LIST_PATH"
+	envTokenListReloadMS     = "TOKEN_LIST_RELOAD_MS"
+	defaultTokenListInterval = 30 * time.Second
+)
+
+// tokenListEntry accepts the field names of the supported formats: the
+// Solana token-list ("address", "chainId"), Jupiter ("address" or "id") and
+// our YAML ("mint", "quote_priority").
+type tokenListEntry struct {
+	Mint          string   `json:"mint" yaml:"mint"`
+	Address       string   `json:"address" yaml:"address"`
+	ID            string   `json:"id" yaml:"id"`
+	ChainID       int      `json:"chainId" yaml:"chain_id"`
+	Symbol        string   `json:"symbol" yaml:"symbol"`
+	Name          string   `json:"name" yaml:"name"`
+	Decimals      uint8    `json:"decimals" yaml:"decimals"`
+	Tags          []string `json:"tags" yaml:"tags"`
+	QuotePriority *int     `json:"quote_priority" yaml:"quote_priority"`
+}
+
+type tokenListDocument struct {
+	Tokens []tokenListEntry `json:"tokens" yaml:"tokens"`
+}
+
+// ParseTokenList decodes a token list. YAML is used for .yaml/.yml files;
+// otherwise the data is JSON, either a Solana token-list document
+// ({"tokens": [...]}) or a Jupiter-style array. Entries for other chains are
+// dropped.
+func ParseTokenList(name string, data []byte) ([]TokenInfo, error) {
+	var entries []tokenListEntry
+	switch ext := strings.ToLower(filepath.Ext(name)); {
+	case ext == ".yaml" || ext == ".yml":
+		var doc tokenListDocument
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("decode token list %s: %w", name, err)
+		}
+		entries = doc.Tokens
+	case bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")):
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("decode token list %s: %w", name, err)
+		}
+	default:
+		var doc tokenListDocument
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("decode token list %s: %w", name, err)
+		}
+		entries = doc.Tokens
+	}
+
+	out := make([]TokenInfo, 0, len(entries))
+	for i, entry := range entries {
+		if entry.ChainID != 0 && entry.ChainID != solanaMainnetChainID {
+			continue
+		}
+		mint := firstNonEmpty(entry.Mint, entry.Address, entry.ID)
+		if key, err := base58.Decode(mint); err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("token list %s: entry %d: invalid mint %q", name, i, mint)
+		}
+		out = append(out, TokenInfo{
+			Mint:          mint,
+			Symbol:        entry.Symbol,
+			Name:          entry.Name,
+			Decimals:      entry.Decimals,
+			Tags:          entry.Tags,
+			QuotePriority: entry.QuotePriority,
+		})
+	}
+	return out, nil
+}
+
+// LoadTokenList reads and parses the token list at path.
+func LoadTokenList(path string) ([]TokenInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read token list: %w", err)
+	}
+	return ParseTokenList(path, data)
+}
+
+// ApplyTokenList replaces the loaded token list consulted by ResolvePair,
+// DetermineBaseQuote and LookupToken. Built-in tokens and runtime
+// registrations are kept.
+func ApplyTokenList(list []TokenInfo) {
+	tokens.replaceList(list)
+}
+
+// TokenListWatcher reloads a token list file whenever its content changes.
+type TokenListWatcher struct {
+	path     string
+	interval time.Duration
+	sum      [sha256.Size]byte
+	modTime  time.Time
+	size     int64
+}
+
+// NewTokenListWatcher polls path every interval.
+func NewTokenListWatcher(path string, interval time.Duration) (*TokenListWatcher, error) {
+	if path == "" {
+		return nil, errors.New("token list path is required")
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid token list reload interval: %s", interval)
+	}
+	return &TokenListWatcher{path: path, interval: interval}, nil
+}
+
+// Reload applies the file if it changed since the last successful load. It
+// reports whether a new list was applied; on error the previous list stays
+// active.
+func (w *TokenListWatcher) Reload() (bool, error) {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return false, fmt.Errorf("stat token list: %w", err)
+	}
+	if info.ModTime().Equal(w.modTime) && info.Size() == w.size {
+		return false, nil
+	}
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return false, fmt.Errorf("read token list: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	if sum == w.sum {
+		w.modTime, w.size = info.ModTime(), info.Size()
+		return false, nil
+	}
+	list, err := ParseTokenList(w.path, data)
+	if err != nil {
+		return false, err
+	}
+	ApplyTokenList(list)
+	w.sum, w.modTime, w.size = sum, info.ModTime(), info.Size()
+	return true, nil
+}
+
+// Run reloads the list every interval until ctx is cancelled. Errors are
+// passed to onError (which may be nil) and do not stop the watcher.
+func (w *TokenListWatcher) Run(ctx context.Context, onError func(error)) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := w.Reload(); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// TokenListWatcherFromEnv builds a watcher for TOKEN_LIST_PATH (polled every
+// TOKEN_LIST_RELOAD_MS) and applies the list once so callers start with it.
+// It returns nil when no path is configured.
+func TokenListWatcherFromEnv() (*TokenListWatcher, error) {
+	path := os.Getenv(envTokenListPath)
+	if path == "" {
+		return nil, nil
+	}
+	interval := defaultTokenListInterval
+	if v := os.Getenv(envTokenListReloadMS); v != "" {
+		ms, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", envTokenListReloadMS, err)
+		}
+		interval = time.Duration(ms) * time.Millisecond
+	}
+	w, err := NewTokenListWatcher(path, interval)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Reload(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
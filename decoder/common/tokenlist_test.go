@@ -0,0 +1,178 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testUSDC = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+	testSOL  = "So11111111111111111111111111111111111111112"
+	testBONK = "DezXAZ8z7PnrnRJjz3wXBoRgixCa6xjnB7YaB1pPB263"
+	testJUP  = "JUPyiwrYJFskUPiHa7hkeR8VUtAeFoSYbKedZNsDvCN"
+)
+
+func resetTokenRegistry() {
+	tokens = newTokenRegistry()
+}
+
+func TestParseTokenListFormats(t *testing.T) {
+	solanaList := `{"name":"Solana Token List","tokens":[
+		{"chainId":101,"address":"` + testBONK + `","symbol":"Bonk","name":"Bonk","decimals":5,"tags":["meme"]},
+		{"chainId":103,"address":"` + testJUP + `","symbol":"JUP","name":"Devnet JUP","decimals":6}
+	]}`
+	list, err := ParseTokenList("tokens.json", []byte(solanaList))
+	if err != nil {
+		t.Fatalf("solana token list: %v", err)
+	}
+	if len(list) != 1 || list[0].Mint != testBONK || list[0].Decimals != 5 || list[0].Tags[0] != "meme" {
+		t.Fatalf("unexpected solana list %+v", list)
+	}
+
+	jupiter := `[{"address":"` + testJUP + `","symbol":"JUP","name":"Jupiter","decimals":6,"tags":["verified"]},
+		{"id":"` + testBONK + `","symbol":"Bonk","decimals":5}]`
+	list, err = ParseTokenList("strict.json", []byte(jupiter))
+	if err != nil {
+		t.Fatalf("jupiter list: %v", err)
+	}
+	if len(list) != 2 || list[0].Symbol != "JUP" || list[1].Mint != testBONK {
+		t.Fatalf("unexpected jupiter list %+v", list)
+	}
+
+	yamlList := "tokens:\n" +
+		"  - mint: " + testJUP + "\n" +
+		"    symbol: JUP\n" +
+		"    decimals: 6\n" +
+		"    tags: [governance]\n" +
+		"    quote_priority: 85\n"
+	list, err = ParseTokenList("tokens.yaml", []byte(yamlList))
+	if err != nil {
+		t.Fatalf("yaml list: %v", err)
+	}
+	if len(list) != 1 || list[0].QuotePriority == nil || *list[0].QuotePriority != 85 {
+		t.Fatalf("unexpected yaml list %+v", list)
+	}
+
+	if _, err := ParseTokenList("bad.json", []byte(`[{"address":"not-a-mint","symbol":"X"}]`)); err == nil {
+		t.Fatal("expected error for invalid mint")
+	}
+}
+
+func TestApplyTokenListDrivesResolvePair(t *testing.T) {
+	defer resetTokenRegistry()
+
+	priority := 95
+	ApplyTokenList([]TokenInfo{
+		{Mint: testJUP, Symbol: "JUP", Decimals: 6, QuotePriority: &priority},
+		{Mint: testBONK, Symbol: "BONK", Decimals: 5},
+		// A list entry cannot claim USDC's priority for another mint.
+		{Mint: "Fake1111111111111111111111111111111111111111", Symbol: "USDC", Decimals: 6},
+	})
+
+	pair, err := ResolvePair(testJUP, testSOL)
+	if err != nil {
+		t.Fatalf("ResolvePair: %v", err)
+	}
+	if pair.BaseMint != testSOL || pair.QuoteToken != "JUP" {
+		t.Fatalf("JUP priority 95 should outrank SOL: %+v", pair)
+	}
+	pair, _ = ResolvePair(testBONK, testUSDC)
+	if pair.BaseToken != "BONK" || pair.QuoteToken != "USDC" {
+		t.Fatalf("unexpected pair %+v", pair)
+	}
+	pair, _ = ResolvePair("Fake1111111111111111111111111111111111111111", testSOL)
+	if pair.QuoteMint != testSOL {
+		t.Fatalf("spoofed USDC must not outrank SOL: %+v", pair)
+	}
+
+	info, ok := LookupToken(testBONK)
+	if !ok || info.Decimals != 5 {
+		t.Fatalf("LookupToken=%+v ok=%v", info, ok)
+	}
+
+	ApplyTokenList(nil)
+	if _, ok := LookupToken(testBONK); ok {
+		t.Fatal("replaced list must drop old entries")
+	}
+	if pair, _ := ResolvePair(testJUP, testSOL); pair.QuoteMint != testSOL {
+		t.Fatalf("JUP priority should be gone after reload: %+v", pair)
+	}
+}
+
+func TestTokenListWatcherReloads(t *testing.T) {
+	defer resetTokenRegistry()
+
+	path := filepath.Join(t.TempDir(), "tokens.yaml")
+	write := func(symbol string, mod time.Time) {
+		t.Helper()
+		data := "tokens:\n  - mint: " + testBONK + "\n    symbol: " + symbol + "\n    decimals: 5\n"
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if err := os.Chtimes(path, mod, mod); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+
+	base := time.Now().Add(-time.Hour)
+	write("BONK", base)
+	w, err := NewTokenListWatcher(path, time.Second)
+	if err != nil {
+		t.Fatalf("NewTokenListWatcher: %v", err)
+	}
+	if changed, err := w.Reload(); err != nil || !changed {
+		t.Fatalf("initial reload changed=%v err=%v", changed, err)
+	}
+	if changed, _ := w.Reload(); changed {
+		t.Fatal("unchanged file must not reload")
+	}
+
+	write("BONK2", base.Add(time.Minute))
+	if changed, err := w.Reload(); err != nil || !changed {
+		t.Fatalf("reload changed=%v err=%v", changed, err)
+	}
+	if symbol := resolveTokenSymbol(testBONK); symbol != "BONK2" {
+		t.Fatalf("symbol=%s want BONK2", symbol)
+	}
+
+	if err := os.WriteFile(path, []byte("tokens: ["), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.Chtimes(path, base.Add(2*time.Minute), base.Add(2*time.Minute)); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	if _, err := w.Reload(); err == nil {
+		t.Fatal("expected parse error")
+	}
+	if symbol := resolveTokenSymbol(testBONK); symbol != "BONK2" {
+		t.Fatalf("broken file must keep previous list, symbol=%s", symbol)
+	}
+}
+
+func TestTokenRegistryConcurrentAccess(t *testing.T) {
+	defer resetTokenRegistry()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				ApplyTokenList([]TokenInfo{{Mint: testBONK, Symbol: "BONK"}})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := ResolvePair(testBONK, testUSDC); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,194 @@
+package common
+
+import (
+	"strings"
+	"sync"
+)
+
+// Built-in quote priorities. Higher priority tokens become the quote (second
+// token in the pair).
+var builtinQuotePriority = map[string]int{
+	"USDC":    100, // Highest priority quote
+	"USDT":    90,
+	"SOL":     80,
+	"WSOL":    75,
+	"ETH":     70,
+	"WETH":    65,
+	"BTC":     60,
+	"WBTC":    55,
+	"default": 0,
+}
+
+// Built-in well-known token addresses on Solana.
+var builtinTokens = map[string]string{
+	"EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v": "USDC",
+	"Es9vMFrzaCERmJfrF4H2FYD4KCoNkY11McCe8BenwNYB": "USDT",
+	"So11111111111111111111111111111111111111112":  "SOL",
+	"So11111111111111111111111111111111111111111":  "WSOL",
+}
+
+// TokenInfo describes a token from a token list.
+type TokenInfo struct {
+	Mint     string
+	Symbol   string
+	Name     string
+	Decimals uint8
+	Tags     []string
+	// QuotePriority, when set, overrides the symbol priority for this mint.
+	QuotePriority *int
+}
+
+// tokenRegistry holds the symbol and quote priority tables consulted by
+// ResolvePair and DetermineBaseQuote. Three layers are merged, later ones
+// winning: built-ins, the loaded token list, and runtime registrations.
+type tokenRegistry struct {
+	mu sync.RWMutex
+
+	list           map[string]TokenInfo
+	manualSymbols  map[string]string
+	manualPriority map[string]int
+
+	// Derived tables, rebuilt on every change.
+	symbols      map[string]string
+	priorities   map[string]int
+	mintPriority map[string]int
+	// quoteMints maps an upper-cased symbol with a quote priority to the
+	// mint entitled to it, so other mints declaring the same symbol do not
+	// inherit the priority.
+	quoteMints map[string]string
+}
+
+var tokens = newTokenRegistry()
+
+func newTokenRegistry() *tokenRegistry {
+	r := &tokenRegistry{
+		list:           make(map[string]TokenInfo),
+		manualSymbols:  make(map[string]string),
+		manualPriority: make(map[string]int),
+	}
+	r.rebuildLocked()
+	return r
+}
+
+func (r *tokenRegistry) rebuildLocked() {
+	r.symbols = make(map[string]string, len(builtinTokens)+len(r.list)+len(r.manualSymbols))
+	r.priorities = make(map[string]int, len(builtinQuotePriority)+len(r.manualPriority))
+	r.mintPriority = make(map[string]int)
+	r.quoteMints = make(map[string]string)
+
+	for symbol, priority := range builtinQuotePriority {
+		r.priorities[symbol] = priority
+	}
+	for symbol, priority := range r.manualPriority {
+		r.priorities[symbol] = priority
+	}
+
+	claim := func(mint, symbol string) {
+		key := strings.ToUpper(symbol)
+		if _, ok := r.priorities[symbol]; !ok {
+			return
+		}
+		if _, taken := r.quoteMints[key]; !taken {
+			r.quoteMints[key] = mint
+		}
+	}
+	for mint, symbol := range builtinTokens {
+		r.symbols[mint] = symbol
+		claim(mint, symbol)
+	}
+	for mint, info := range r.list {
+		if info.Symbol != "" {
+			r.symbols[mint] = info.Symbol
+		}
+		if info.QuotePriority != nil {
+			r.mintPriority[mint] = *info.QuotePriority
+			r.quoteMints[strings.ToUpper(info.Symbol)] = mint
+		}
+	}
+	for mint, symbol := range r.manualSymbols {
+		r.symbols[mint] = symbol
+		claim(mint, symbol)
+	}
+}
+
+func (r *tokenRegistry) symbol(mint string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	symbol, ok := r.symbols[mint]
+	return symbol, ok
+}
+
+func (r *tokenRegistry) symbolPriority(symbol string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if priority, ok := r.priorities[symbol]; ok {
+		return priority
+	}
+	return r.priorities["default"]
+}
+
+// priorityFor returns the quote priority of mint. A registered symbol takes
+// precedence over the caller's (possibly self-declared) symbol, and a
+// symbol's priority only applies to the mint that owns it.
+func (r *tokenRegistry) priorityFor(mint, symbol string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if priority, ok := r.mintPriority[mint]; ok {
+		return priority
+	}
+	if registered, ok := r.symbols[mint]; ok {
+		symbol = registered
+	}
+	priority, ok := r.priorities[symbol]
+	if !ok {
+		return r.priorities["default"]
+	}
+	if owner, claimed := r.quoteMints[strings.ToUpper(symbol)]; claimed && owner != mint {
+		return r.priorities["default"]
+	}
+	return priority
+}
+
+func (r *tokenRegistry) lookup(mint string) (TokenInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	info, ok := r.list[mint]
+	return info, ok
+}
+
+func (r *tokenRegistry) register(mint, symbol string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.manualSymbols[mint] = symbol
+	r.rebuildLocked()
+}
+
+func (r *tokenRegistry) setPriority(symbol string, priority int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.manualPriority[symbol] = priority
+	r.rebuildLocked()
+}
+
+func (r *tokenRegistry) replaceList(entries []TokenInfo) {
+	list := make(map[string]TokenInfo, len(entries))
+	for _, info := range entries {
+		list[info.Mint] = info
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.list = list
+	r.rebuildLocked()
+}
+
+// quotePriorityFor returns the quote priority of mint given the symbol its
+// metadata declares.
+func quotePriorityFor(mint, symbol string) int {
+	return tokens.priorityFor(mint, symbol)
+}
+
+// LookupToken returns the token list entry for mint, if the loaded list has
+// one.
+func LookupToken(mint string) (TokenInfo, bool) {
+	return tokens.lookup(mint)
+}
@@ -276,10 +276,16 @@ func decimalsForMint(ctx *InstructionContext, mint string, deltas ...*tokenBalan
 }
 
 func applyLogMetadata(event *SwapEvent, ctx *InstructionContext) {
-	if event == nil || ctx == nil || len(ctx.Logs) == 0 {
+	if event == nil || ctx == nil {
 		return
 	}
 
+	var (
+		totalFee    uint64
+		protocolFee uint64
+		feeLogged   bool
+	)
+
 	for _, line := range ctx.Logs {
 		tokens := tokenize(line)
 		if len(tokens) == 0 {
@@ -289,6 +295,12 @@ func applyLogMetadata(event *SwapEvent, ctx *InstructionContext) {
 		if fee, ok := extractUint(tokens, "fee_bps"); ok {
 			event.FeeBps = uint32(fee)
 		}
+		if fee, ok := extractUint(tokens, "fee"); ok {
+			totalFee, feeLogged = fee, true
+		}
+		if fee, ok := extractUint(tokens, "protocol_fee"); ok {
+			protocolFee = fee
+		}
 
 		switch ctx.Kind {
 		case PoolKindDLMM:
@@ -303,6 +315,26 @@ func applyLogMetadata(event *SwapEvent, ctx *InstructionContext) {
 			}
 		}
 	}
+
+	applyFees(event, totalFee, feeLogged, protocolFee)
+}
+
+// applyFees populates the LP/protocol fee split. Meteora logs the total fee and
+// protocol share in input token units; older or CPMM pools that only report
+// fee_bps fall back to charging that rate on the input amount.
+func applyFees(event *SwapEvent, totalFee uint64, feeLogged bool, protocolFee uint64) {
+	if !feeLogged {
+		amountIn := event.BaseAmount
+		if event.BaseDecreased {
+			amountIn = event.QuoteAmount
+		}
+		totalFee = common.SplitSwapFee(amountIn, uint64(event.FeeBps), 10_000, 0, 0).Total()
+	}
+	if protocolFee > totalFee {
+		protocolFee = 0
+	}
+	event.LPFee = totalFee - protocolFee
+	event.ProtocolFee = protocolFee
 }
 
 func tokenize(line string) []string {
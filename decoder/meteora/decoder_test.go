@@ -79,6 +79,9 @@ func TestDecodeSwapEvent_BaseSold(t *testing.T) {
 	if event.FeeBps != 25 {
 		t.Fatalf("unexpected fee bps %d", event.FeeBps)
 	}
+	if event.LPFee != 1_250_000 || event.ProtocolFee != 0 {
+		t.Fatalf("unexpected fee split lp=%d protocol=%d", event.LPFee, event.ProtocolFee)
+	}
 }
 
 func TestDecodeSwapEvent_BaseBought(t *testing.T) {
@@ -110,6 +113,7 @@ func TestDecodeSwapEvent_BaseBought(t *testing.T) {
 		Logs: []string{
 			"Program log: virtual_reserves base=2500000 quote=780000000",
 			"Program log: fee_bps=30",
+			"Program log: fee=4500 protocol_fee=900",
 		},
 		PreTokenBalances: []*pb.TokenBalance{
 			tokenBalance(inputIdx, "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", "2000000", 6),
@@ -146,6 +150,9 @@ func TestDecodeSwapEvent_BaseBought(t *testing.T) {
 	if event.FeeBps != 30 {
 		t.Fatalf("unexpected fee bps %d", event.FeeBps)
 	}
+	if event.LPFee != 3600 || event.ProtocolFee != 900 {
+		t.Fatalf("unexpected fee split lp=%d protocol=%d", event.LPFee, event.ProtocolFee)
+	}
 }
 
 func tokenBalance(index uint32, mint string, amount string, decimals uint32) *pb.TokenBalance {
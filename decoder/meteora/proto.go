@@ -28,6 +28,8 @@ func (e *SwapEvent) ToProto() *dexv1.SwapEvent {
 		DecBase:     e.DecBase,
 		DecQuote:    e.DecQuote,
 		FeeBps:      e.FeeBps,
+		LpFee:       e.LPFee,
+		ProtocolFee: e.ProtocolFee,
 		Provisional: true,
 	}
 
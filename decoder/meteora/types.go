@@ -55,6 +55,12 @@ type SwapEvent struct {
 
 	FeeBps uint32
 
+	// Fee charged on the input leg, split between liquidity providers and the
+	// protocol. Taken from the dynamic fee logs when present, otherwise derived
+	// from FeeBps.
+	LPFee       uint64
+	ProtocolFee uint64
+
 	// DLMM specific fields populated when available.
 	VirtualReservesBase  uint64
 	VirtualReservesQuote uint64
@@ -38,9 +38,8 @@ func (d *Decoder) DecodeSwapTransaction(
 		return nil, fmt.Errorf("instruction data too short")
 	}
 
-	discriminator := binary.LittleEndian.Uint64(instructionData[0:8])
-	if discriminator != SwapInstructionDiscriminator {
-		return nil, fmt.Errorf("not a swap instruction: discriminator %x", discriminator)
+	if !swapIDL.Matches(instructionData) {
+		return nil, fmt.Errorf("not a swap instruction: discriminator %x", instructionData[0:8])
 	}
 
 	// Decode swap instruction
@@ -132,8 +131,7 @@ func (d *Decoder) DecodeSwapTransaction(
 	}
 
 	// Calculate fees
-	feeAmount := calculateFeeAmount(amountIn, poolStatePre.FeeRate)
-	protocolFee := calculateProtocolFee(feeAmount, poolStatePre.ProtocolFeeRate)
+	fees := SwapFee(amountIn, poolStatePre.FeeRate, poolStatePre.ProtocolFeeRate)
 
 	return &SwapEvent{
 		Signature:        signature,
@@ -151,8 +149,8 @@ func (d *Decoder) DecodeSwapTransaction(
 		TickIndexPost:    poolStatePost.TickCurrentIndex,
 		LiquidityPre:     poolStatePre.Liquidity,
 		LiquidityPost:    poolStatePost.Liquidity,
-		FeeAmount:        feeAmount,
-		ProtocolFee:      protocolFee,
+		FeeAmount:        fees.Total(),
+		ProtocolFee:      fees.Protocol,
 		Price:            price,
 		VolumeBase:       volumeBase,
 		VolumeQuote:      volumeQuote,
@@ -240,18 +238,11 @@ func (d *Decoder) calculateAmounts(
 	return amountIn, amountOut, nil
 }
 
-// calculateFeeAmount calculates the fee from the input amount and fee rate
-// feeRate is in basis points (e.g., 30 = 0.3%)
-func calculateFeeAmount(amountIn uint64, feeRate uint16) uint64 {
-	// Fee = (amountIn * feeRate) / 10000
-	return (amountIn * uint64(feeRate)) / 10000
-}
-
-// calculateProtocolFee calculates the protocol fee from the total fee
-// protocolFeeRate is in basis points
-func calculateProtocolFee(feeAmount uint64, protocolFeeRate uint16) uint64 {
-	// Protocol fee = (feeAmount * protocolFeeRate) / 10000
-	return (feeAmount * uint64(protocolFeeRate)) / 10000
+// SwapFee splits the fee charged on amountIn between liquidity providers and
+// the protocol. feeRate is in hundredths of a basis point (3000 = 0.3%) and
+// protocolFeeRate is in basis points of the fee, matching the Whirlpool account.
+func SwapFee(amountIn uint64, feeRate, protocolFeeRate uint16) common.FeeSplit {
+	return common.SplitSwapFee(amountIn, uint64(feeRate), FeeRateDenominator, uint64(protocolFeeRate), ProtocolFeeRateDenominator)
 }
 
 // bytesToU128String converts a 16-byte little-endian byte array to a string representation of u128
@@ -236,7 +236,7 @@ func TestDecoder_FeeCalculation(t *testing.T) {
 		{
 			name:                "0.3%_fee_1_SOL",
 			amountIn:            1000000000, // 1 SOL
-			feeRate:             30,         // 0.3%
+			feeRate:             3000,       // 0.3%
 			expectedFeeAmount:   3000000,    // 0.003 SOL
 			protocolFeeRate:     200,        // 2% of fee
 			expectedProtocolFee: 60000,      // 2% of 0.003
@@ -244,7 +244,7 @@ func TestDecoder_FeeCalculation(t *testing.T) {
 		{
 			name:                "0.1%_fee_1000_USDC",
 			amountIn:            1000000000,
-			feeRate:             10,
+			feeRate:             1000,
 			expectedFeeAmount:   1000000,
 			protocolFeeRate:     100,
 			expectedProtocolFee: 10000,
@@ -253,14 +253,13 @@ func TestDecoder_FeeCalculation(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			feeAmount := calculateFeeAmount(tt.amountIn, tt.feeRate)
-			if feeAmount != tt.expectedFeeAmount {
-				t.Errorf("Fee amount mismatch: got %d, want %d", feeAmount, tt.expectedFeeAmount)
+			fees := SwapFee(tt.amountIn, tt.feeRate, tt.protocolFeeRate)
+			if fees.Total() != tt.expectedFeeAmount {
+				t.Errorf("Fee amount mismatch: got %d, want %d", fees.Total(), tt.expectedFeeAmount)
 			}
 
-			protocolFee := calculateProtocolFee(feeAmount, tt.protocolFeeRate)
-			if protocolFee != tt.expectedProtocolFee {
-				t.Errorf("Protocol fee mismatch: got %d, want %d", protocolFee, tt.expectedProtocolFee)
+			if fees.Protocol != tt.expectedProtocolFee {
+				t.Errorf("Protocol fee mismatch: got %d, want %d", fees.Protocol, tt.expectedProtocolFee)
 			}
 		})
 	}
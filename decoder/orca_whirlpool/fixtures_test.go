@@ -71,8 +71,8 @@ func GetTestFixtures() []TestFixture {
 				SqrtPrice:        common.FloatToSqrtPriceQ64(180.0), // 180 USDC per SOL
 				TickCurrentIndex: 53215,
 				Liquidity:        "5000000000000",
-				FeeRate:          30,  // 0.3%
-				ProtocolFeeRate:  200, // 2% of fee
+				FeeRate:          3000, // 0.3%
+				ProtocolFeeRate:  200,  // 2% of fee
 			},
 			PoolStatePost: &WhirlpoolState{
 				WhirlpoolAddress: "HJPjoWUrhoZzkNfRpHuieeFk9WcZWjwy6PBjZ81ngndJ",
@@ -83,7 +83,7 @@ func GetTestFixtures() []TestFixture {
 				SqrtPrice:        common.FloatToSqrtPriceQ64(179.5), // slight price movement
 				TickCurrentIndex: 53210,
 				Liquidity:        "5000000000000",
-				FeeRate:          30,
+				FeeRate:          3000,
 				ProtocolFeeRate:  200,
 			},
 			ExpectedEvent: &SwapEvent{
@@ -157,7 +157,7 @@ func GetTestFixtures() []TestFixture {
 				SqrtPrice:        common.FloatToSqrtPriceQ64(0.999),
 				TickCurrentIndex: 10,
 				Liquidity:        "10000000000000",
-				FeeRate:          10, // 0.1% for stablecoin pairs
+				FeeRate:          1000, // 0.1% for stablecoin pairs
 				ProtocolFeeRate:  100,
 			},
 			PoolStatePost: &WhirlpoolState{
@@ -169,7 +169,7 @@ func GetTestFixtures() []TestFixture {
 				SqrtPrice:        common.FloatToSqrtPriceQ64(0.9985),
 				TickCurrentIndex: 9,
 				Liquidity:        "10000000000000",
-				FeeRate:          10,
+				FeeRate:          1000,
 				ProtocolFeeRate:  100,
 			},
 			ExpectedEvent: &SwapEvent{
@@ -242,7 +242,7 @@ func GetTestFixtures() []TestFixture {
 				SqrtPrice:        common.FloatToSqrtPriceQ64(1000.0),
 				TickCurrentIndex: 60000,
 				Liquidity:        "7500000000000",
-				FeeRate:          30,
+				FeeRate:          3000,
 				ProtocolFeeRate:  200,
 			},
 			PoolStatePost: &WhirlpoolState{
@@ -254,7 +254,7 @@ func GetTestFixtures() []TestFixture {
 				SqrtPrice:        common.FloatToSqrtPriceQ64(1001.0),
 				TickCurrentIndex: 60005,
 				Liquidity:        "7500000000000",
-				FeeRate:          30,
+				FeeRate:          3000,
 				ProtocolFeeRate:  200,
 			},
 			ExpectedEvent: &SwapEvent{
@@ -295,17 +295,8 @@ func buildSwapInstructionData(
 ) []byte {
 	data := make([]byte, 0, 49)
 
-	// Discriminator (8 bytes) - 0xf8c69e91e17587c8
-	discriminatorBytes := make([]byte, 8)
-	discriminatorBytes[0] = 0xc8
-	discriminatorBytes[1] = 0x87
-	discriminatorBytes[2] = 0x75
-	discriminatorBytes[3] = 0xe1
-	discriminatorBytes[4] = 0x91
-	discriminatorBytes[5] = 0x9e
-	discriminatorBytes[6] = 0xc6
-	discriminatorBytes[7] = 0xf8
-	data = append(data, discriminatorBytes...)
+	// Discriminator (8 bytes) - sha256("global:swap")[:8]
+	data = append(data, 0xf8, 0xc6, 0x9e, 0x91, 0xe1, 0x75, 0x87, 0xc8)
 
 	// Amount (8 bytes)
 	amountBytes := make([]byte, 8)
@@ -26,6 +26,8 @@ func (e *SwapEvent) ToProto() *dexv1.SwapEvent {
 		BaseOut:     0,
 		QuoteIn:     0,
 		QuoteOut:    0,
+		LpFee:       e.FeeAmount - e.ProtocolFee,
+		ProtocolFee: e.ProtocolFee,
 		Provisional: true,
 	}
 
@@ -2,16 +2,41 @@ package orca_whirlpool
 
 import (
 	"time"
+
+	"github.com/rexbrahh/lp-indexer/decoder/anchor"
 )
 
 const (
 	// WhirlpoolProgramID is the Orca Whirlpools program address on Solana
 	WhirlpoolProgramID = "whirLbMiicVdio4qvUfM5KAg6Ct8VwpYzGff3uctyCc"
 
-	// SwapInstructionDiscriminator is the 8-byte discriminator for swap instructions
-	SwapInstructionDiscriminator = uint64(0xf8c69e91e17587c8)
+	// FeeRateDenominator scales Whirlpool fee_rate (hundredths of a basis point)
+	FeeRateDenominator = 1_000_000
+
+	// ProtocolFeeRateDenominator scales Whirlpool protocol_fee_rate (basis points of the fee)
+	ProtocolFeeRateDenominator = 10_000
 )
 
+// swapIDL is the Whirlpool swap instruction from the embedded IDL; its
+// discriminator prefixes swap instruction data.
+var swapIDL = anchor.MustLoad("whirlpool").MustInstruction("swap")
+
+// twoHopSwapIDL trades through whirlpool_one and then whirlpool_two.
+var twoHopSwapIDL = anchor.MustLoad("whirlpool").MustInstruction("twoHopSwap")
+
+// SwapPoolAccounts returns the positions in a Whirlpool instruction's account
+// list of the pools it trades against, in hop order: whirlpool_one and
+// whirlpool_two for two_hop_swap, and the swap whirlpool account otherwise.
+func SwapPoolAccounts(data []byte) []int {
+	if twoHopSwapIDL.Matches(data) {
+		one, _ := twoHopSwapIDL.AccountIndex("whirlpoolOne")
+		two, _ := twoHopSwapIDL.AccountIndex("whirlpoolTwo")
+		return []int{one, two}
+	}
+	pool, _ := swapIDL.AccountIndex("whirlpool")
+	return []int{pool}
+}
+
 // SwapEvent represents a normalized swap event from Orca Whirlpools CLMM
 type SwapEvent struct {
 	// Transaction metadata
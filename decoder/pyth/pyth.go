@@ -0,0 +1,177 @@
+// Package pyth decodes Pyth price accounts: legacy push-oracle price accounts
+// (magic 0xa1b2c3d4) and the PriceUpdateV2 accounts written by the pull
+// oracle receiver and the sponsored price feed program.
+package pyth
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/mr-tron/base58/base58"
+
+	"github.com/rexbrahh/lp-indexer/decoder/anchor"
+)
+
+// Program IDs owning Pyth price accounts on mainnet.
+const (
+	OracleProgramID   = "FsJ3A3u2vn5cTVofAjvy6y5kwABJAqYWpe4975bi2epH"
+	ReceiverProgramID = "rec5EKMGg6MxZYaMdyBfgwp4d5rB9T1VQH5pJv5LtFJ"
+	PushProgramID     = "pythWSnswVUd12oZpeFP8e9CVaEqJg25g1Vtc2biRsT"
+)
+
+// Sources reported in Price.Source.
+const (
+	SourceLegacy = "pyth"
+	SourcePull   = "pyth_pull"
+)
+
+// Price statuses. Only StatusTrading prices should be relied on.
+const (
+	StatusUnknown = "unknown"
+	StatusTrading = "trading"
+	StatusHalted  = "halted"
+	StatusAuction = "auction"
+	StatusIgnored = "ignored"
+	// StatusPartial marks pull updates verified by fewer than all Wormhole
+	// guardians.
+	StatusPartial = "partial"
+)
+
+// ErrNotPriceAccount is returned for data that is neither layout.
+var ErrNotPriceAccount = errors.New("not a pyth price account")
+
+const (
+	legacyMagic     = 0xa1b2c3d4
+	legacyTypePrice = 3
+
+	// Legacy price account offsets (pyth-client oracle.h, pc_price_t).
+	legacyExpoOffset      = 20
+	legacyTimestampOffset = 96
+	legacyProductOffset   = 112
+	legacyAggOffset       = 208
+	legacyLen             = 240
+
+	// PriceUpdateV2: discriminator, write authority, verification level.
+	pullHeaderLen = 8 + 32
+	// PriceFeedMessage: feed id, price, conf, exponent, publish time, previous
+	// publish time, EMA price, EMA conf; followed by the posted slot.
+	pullMessageLen = 32 + 8 + 8 + 4 + 8 + 8 + 8 + 8
+)
+
+var priceUpdateDiscriminator = anchor.AccountDiscriminator("PriceUpdateV2")
+
+// Price is one decoded price observation. The value is Price * 10^Expo.
+type Price struct {
+	Source string
+	// FeedID is the hex price feed ID for pull updates and the product
+	// account for legacy price accounts.
+	FeedID      string
+	Price       int64
+	Conf        uint64
+	Expo        int32
+	PublishTime int64
+	// Slot is the aggregate publish slot (legacy) or the posted slot (pull).
+	Slot   uint64
+	Status string
+}
+
+// Value returns the price as a float.
+func (p *Price) Value() float64 {
+	return float64(p.Price) * math.Pow10(int(p.Expo))
+}
+
+// Confidence returns the confidence interval as a float.
+func (p *Price) Confidence() float64 {
+	return float64(p.Conf) * math.Pow10(int(p.Expo))
+}
+
+// Decode parses either price account layout.
+func Decode(data []byte) (*Price, error) {
+	if len(data) >= 8 && bytes.Equal(data[:8], priceUpdateDiscriminator[:]) {
+		return DecodePriceUpdate(data)
+	}
+	if len(data) >= 4 && binary.LittleEndian.Uint32(data) == legacyMagic {
+		return DecodeLegacyPrice(data)
+	}
+	return nil, ErrNotPriceAccount
+}
+
+// DecodeLegacyPrice parses a push-oracle price account.
+func DecodeLegacyPrice(data []byte) (*Price, error) {
+	if len(data) < legacyLen {
+		return nil, fmt.Errorf("pyth price account too short: %d bytes", len(data))
+	}
+	if binary.LittleEndian.Uint32(data[0:]) != legacyMagic {
+		return nil, ErrNotPriceAccount
+	}
+	if atype := binary.LittleEndian.Uint32(data[8:]); atype != legacyTypePrice {
+		return nil, fmt.Errorf("pyth account type %d is not a price account", atype)
+	}
+	agg := data[legacyAggOffset:]
+	return &Price{
+		Source:      SourceLegacy,
+		FeedID:      base58.Encode(data[legacyProductOffset : legacyProductOffset+32]),
+		Price:       int64(binary.LittleEndian.Uint64(agg[0:])),
+		Conf:        binary.LittleEndian.Uint64(agg[8:]),
+		Expo:        int32(binary.LittleEndian.Uint32(data[legacyExpoOffset:])),
+		PublishTime: int64(binary.LittleEndian.Uint64(data[legacyTimestampOffset:])),
+		Slot:        binary.LittleEndian.Uint64(agg[24:]),
+		Status:      legacyStatus(binary.LittleEndian.Uint32(agg[16:])),
+	}, nil
+}
+
+// DecodePriceUpdate parses a pull-oracle PriceUpdateV2 account.
+func DecodePriceUpdate(data []byte) (*Price, error) {
+	if len(data) < 8 || !bytes.Equal(data[:8], priceUpdateDiscriminator[:]) {
+		return nil, ErrNotPriceAccount
+	}
+	if len(data) < pullHeaderLen+1 {
+		return nil, fmt.Errorf("pyth price update too short: %d bytes", len(data))
+	}
+	// VerificationLevel is a Borsh enum: Partial { num_signatures: u8 } or
+	// Full.
+	offset := pullHeaderLen
+	status := StatusTrading
+	switch data[offset] {
+	case 0:
+		status = StatusPartial
+		offset += 2
+	case 1:
+		offset++
+	default:
+		return nil, fmt.Errorf("pyth price update: unknown verification level %d", data[offset])
+	}
+	if len(data) < offset+pullMessageLen+8 {
+		return nil, fmt.Errorf("pyth price update too short: %d bytes", len(data))
+	}
+	msg := data[offset:]
+	return &Price{
+		Source:      SourcePull,
+		FeedID:      hex.EncodeToString(msg[0:32]),
+		Price:       int64(binary.LittleEndian.Uint64(msg[32:])),
+		Conf:        binary.LittleEndian.Uint64(msg[40:]),
+		Expo:        int32(binary.LittleEndian.Uint32(msg[48:])),
+		PublishTime: int64(binary.LittleEndian.Uint64(msg[52:])),
+		Slot:        binary.LittleEndian.Uint64(msg[pullMessageLen:]),
+		Status:      status,
+	}, nil
+}
+
+func legacyStatus(status uint32) string {
+	switch status {
+	case 1:
+		return StatusTrading
+	case 2:
+		return StatusHalted
+	case 3:
+		return StatusAuction
+	case 4:
+		return StatusIgnored
+	default:
+		return StatusUnknown
+	}
+}
@@ -0,0 +1,115 @@
+package pyth
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestPriceUpdateDiscriminatorMatchesIDL(t *testing.T) {
+	// From the pyth-solana-receiver IDL.
+	want := [8]byte{34, 241, 35, 99, 157, 126, 244, 205}
+	if priceUpdateDiscriminator != want {
+		t.Fatalf("discriminator = %v, want %v", priceUpdateDiscriminator, want)
+	}
+}
+
+func legacyAccount(price int64, conf uint64, expo int32, status uint32) []byte {
+	data := make([]byte, 3312)
+	binary.LittleEndian.PutUint32(data[0:], legacyMagic)
+	binary.LittleEndian.PutUint32(data[4:], 2)
+	binary.LittleEndian.PutUint32(data[8:], legacyTypePrice)
+	binary.LittleEndian.PutUint32(data[legacyExpoOffset:], uint32(expo))
+	binary.LittleEndian.PutUint64(data[legacyTimestampOffset:], 1_700_000_000)
+	data[legacyProductOffset] = 1
+	binary.LittleEndian.PutUint64(data[legacyAggOffset:], uint64(price))
+	binary.LittleEndian.PutUint64(data[legacyAggOffset+8:], conf)
+	binary.LittleEndian.PutUint32(data[legacyAggOffset+16:], status)
+	binary.LittleEndian.PutUint64(data[legacyAggOffset+24:], 250_000_000)
+	return data
+}
+
+func pullAccount(level []byte, price int64, conf uint64, expo int32) []byte {
+	data := append([]byte{}, priceUpdateDiscriminator[:]...)
+	data = append(data, make([]byte, 32)...) // write authority
+	data = append(data, level...)
+	feed := make([]byte, 32)
+	feed[0], feed[31] = 0xef, 0x0d
+	data = append(data, feed...)
+	data = binary.LittleEndian.AppendUint64(data, uint64(price))
+	data = binary.LittleEndian.AppendUint64(data, conf)
+	data = binary.LittleEndian.AppendUint32(data, uint32(expo))
+	data = binary.LittleEndian.AppendUint64(data, 1_700_000_123) // publish time
+	data = binary.LittleEndian.AppendUint64(data, 1_700_000_122) // prev publish time
+	data = binary.LittleEndian.AppendUint64(data, uint64(price)) // ema price
+	data = binary.LittleEndian.AppendUint64(data, conf)          // ema conf
+	data = binary.LittleEndian.AppendUint64(data, 250_000_042)   // posted slot
+	return data
+}
+
+func TestDecodeLegacyPrice(t *testing.T) {
+	got, err := Decode(legacyAccount(15_012_345_678, 7_500_000, -8, 1))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if got.Source != SourceLegacy || got.Status != StatusTrading || got.Slot != 250_000_000 || got.PublishTime != 1_700_000_000 {
+		t.Fatalf("unexpected price %+v", got)
+	}
+	if math.Abs(got.Value()-150.12345678) > 1e-9 || math.Abs(got.Confidence()-0.075) > 1e-12 {
+		t.Fatalf("value = %g ± %g", got.Value(), got.Confidence())
+	}
+	if got.FeedID == "" {
+		t.Fatal("missing product account")
+	}
+
+	halted, err := Decode(legacyAccount(1, 1, -8, 2))
+	if err != nil || halted.Status != StatusHalted {
+		t.Fatalf("halted = %+v, %v", halted, err)
+	}
+	if _, err := Decode(legacyAccount(1, 1, -8, 1)[:100]); err == nil {
+		t.Fatal("expected short account error")
+	}
+}
+
+func TestDecodePriceUpdate(t *testing.T) {
+	tests := []struct {
+		name   string
+		level  []byte
+		status string
+	}{
+		{"full", []byte{1}, StatusTrading},
+		{"partial", []byte{0, 5}, StatusPartial},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(pullAccount(tt.level, 14_998_000_000, 3_000_000, -8))
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if got.Source != SourcePull || got.Status != tt.status {
+				t.Fatalf("unexpected price %+v", got)
+			}
+			if got.Price != 14_998_000_000 || got.Conf != 3_000_000 || got.Expo != -8 {
+				t.Fatalf("unexpected price %+v", got)
+			}
+			if got.PublishTime != 1_700_000_123 || got.Slot != 250_000_042 {
+				t.Fatalf("unexpected timing %+v", got)
+			}
+			if !strings.HasPrefix(got.FeedID, "ef") || !strings.HasSuffix(got.FeedID, "0d") || len(got.FeedID) != 64 {
+				t.Fatalf("feed id = %s", got.FeedID)
+			}
+		})
+	}
+
+	if _, err := Decode(pullAccount([]byte{7}, 1, 1, -8)); err == nil {
+		t.Fatal("expected unknown verification level error")
+	}
+	if _, err := Decode(pullAccount([]byte{1}, 1, 1, -8)[:80]); err == nil {
+		t.Fatal("expected short update error")
+	}
+	if _, err := Decode(make([]byte, 300)); !errors.Is(err, ErrNotPriceAccount) {
+		t.Fatalf("zeroed data err = %v", err)
+	}
+}
@@ -256,8 +256,43 @@ type SwapEvent struct {
 	FeeBps           uint32                 `protobuf:"varint,19,opt,name=fee_bps,json=feeBps,proto3" json:"fee_bps,omitempty"`
 	Provisional      bool                   `protobuf:"varint,20,opt,name=provisional,proto3" json:"provisional,omitempty"`
 	IsUndo           bool                   `protobuf:"varint,21,opt,name=is_undo,json=isUndo,proto3" json:"is_undo,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+	BaseGross        uint64                 `protobuf:"varint,22,opt,name=base_gross,json=baseGross,proto3" json:"base_gross,omitempty"`
+	BaseNet          uint64                 `protobuf:"varint,23,opt,name=base_net,json=baseNet,proto3" json:"base_net,omitempty"`
+	BaseTransferFee  uint64                 `protobuf:"varint,24,opt,name=base_transfer_fee,json=baseTransferFee,proto3" json:"base_transfer_fee,omitempty"`
+	QuoteGross       uint64                 `protobuf:"varint,25,opt,name=quote_gross,json=quoteGross,proto3" json:"quote_gross,omitempty"`
+	QuoteNet         uint64                 `protobuf:"varint,26,opt,name=quote_net,json=quoteNet,proto3" json:"quote_net,omitempty"`
+	QuoteTransferFee uint64                 `protobuf:"varint,27,opt,name=quote_transfer_fee,json=quoteTransferFee,proto3" json:"quote_transfer_fee,omitempty"`
+	LpFee            uint64                 `protobuf:"varint,28,opt,name=lp_fee,json=lpFee,proto3" json:"lp_fee,omitempty"`
+	ProtocolFee      uint64                 `protobuf:"varint,29,opt,name=protocol_fee,json=protocolFee,proto3" json:"protocol_fee,omitempty"`
+	PairId           string                 `protobuf:"bytes,30,opt,name=pair_id,json=pairId,proto3" json:"pair_id,omitempty"`
+	PriceQ32         int64                  `protobuf:"varint,31,opt,name=price_q32,json=priceQ32,proto3" json:"price_q32,omitempty"`
+	Trader           string                 `protobuf:"bytes,32,opt,name=trader,proto3" json:"trader,omitempty"`
+	FeePayer         string                 `protobuf:"bytes,33,opt,name=fee_payer,json=feePayer,proto3" json:"fee_payer,omitempty"`
+	MevVictim        bool                   `protobuf:"varint,34,opt,name=mev_victim,json=mevVictim,proto3" json:"mev_victim,omitempty"`
+	// Set when the swap was recovered by slot gap repair rather than streamed.
+	Repaired bool `protobuf:"varint,35,opt,name=repaired,proto3" json:"repaired,omitempty"`
+	// USD execution price of the base token and USD volume from the routing
+	// price oracle; zero when neither mint could be priced.
+	PriceUsd  float64 `protobuf:"fixed64,36,opt,name=price_usd,json=priceUsd,proto3" json:"price_usd,omitempty"`
+	VolumeUsd float64 `protobuf:"fixed64,37,opt,name=volume_usd,json=volumeUsd,proto3" json:"volume_usd,omitempty"`
+	// Set when the mint is a Token-2022 mint whose account has not been loaded,
+	// so the gross/net/transfer fee split of that side is unknown and left zero.
+	BaseTransferFeeUnknown  bool `protobuf:"varint,38,opt,name=base_transfer_fee_unknown,json=baseTransferFeeUnknown,proto3" json:"base_transfer_fee_unknown,omitempty"`
+	QuoteTransferFeeUnknown bool `protobuf:"varint,39,opt,name=quote_transfer_fee_unknown,json=quoteTransferFeeUnknown,proto3" json:"quote_transfer_fee_unknown,omitempty"`
+	// Set when canonical ordering swapped the pool's mints. The sqrt price
+	// fields keep the pool's own orientation; the canonical value is
+	// 2^128 / sqrt_price_q64 (see decoder/common.CanonicalSqrtPriceQ64).
+	SqrtPriceInverted bool `protobuf:"varint,40,opt,name=sqrt_price_inverted,json=sqrtPriceInverted,proto3" json:"sqrt_price_inverted,omitempty"`
+	// Position of the swap within the transaction. inner is set when the swap
+	// was reached through CPI; instruction_index is then the outer instruction
+	// and inner_instruction_index its position among that instruction's CPIs.
+	// hop numbers the legs of a multi-pool instruction (Orca two_hop_swap).
+	InstructionIndex      uint32 `protobuf:"varint,41,opt,name=instruction_index,json=instructionIndex,proto3" json:"instruction_index,omitempty"`
+	Inner                 bool   `protobuf:"varint,42,opt,name=inner,proto3" json:"inner,omitempty"`
+	InnerInstructionIndex uint32 `protobuf:"varint,43,opt,name=inner_instruction_index,json=innerInstructionIndex,proto3" json:"inner_instruction_index,omitempty"`
+	Hop                   uint32 `protobuf:"varint,44,opt,name=hop,proto3" json:"hop,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
 }
 
 func (x *SwapEvent) Reset() {
@@ -437,6 +472,622 @@ func (x *SwapEvent) GetIsUndo() bool {
 	return false
 }
 
+func (x *SwapEvent) GetBaseGross() uint64 {
+	if x != nil {
+		return x.BaseGross
+	}
+	return 0
+}
+
+func (x *SwapEvent) GetBaseNet() uint64 {
+	if x != nil {
+		return x.BaseNet
+	}
+	return 0
+}
+
+func (x *SwapEvent) GetBaseTransferFee() uint64 {
+	if x != nil {
+		return x.BaseTransferFee
+	}
+	return 0
+}
+
+func (x *SwapEvent) GetQuoteGross() uint64 {
+	if x != nil {
+		return x.QuoteGross
+	}
+	return 0
+}
+
+func (x *SwapEvent) GetQuoteNet() uint64 {
+	if x != nil {
+		return x.QuoteNet
+	}
+	return 0
+}
+
+func (x *SwapEvent) GetQuoteTransferFee() uint64 {
+	if x != nil {
+		return x.QuoteTransferFee
+	}
+	return 0
+}
+
+func (x *SwapEvent) GetLpFee() uint64 {
+	if x != nil {
+		return x.LpFee
+	}
+	return 0
+}
+
+func (x *SwapEvent) GetProtocolFee() uint64 {
+	if x != nil {
+		return x.ProtocolFee
+	}
+	return 0
+}
+
+func (x *SwapEvent) GetPairId() string {
+	if x != nil {
+		return x.PairId
+	}
+	return ""
+}
+
+func (x *SwapEvent) GetPriceQ32() int64 {
+	if x != nil {
+		return x.PriceQ32
+	}
+	return 0
+}
+
+func (x *SwapEvent) GetTrader() string {
+	if x != nil {
+		return x.Trader
+	}
+	return ""
+}
+
+func (x *SwapEvent) GetFeePayer() string {
+	if x != nil {
+		return x.FeePayer
+	}
+	return ""
+}
+
+func (x *SwapEvent) GetMevVictim() bool {
+	if x != nil {
+		return x.MevVictim
+	}
+	return false
+}
+
+func (x *SwapEvent) GetRepaired() bool {
+	if x != nil {
+		return x.Repaired
+	}
+	return false
+}
+
+func (x *SwapEvent) GetPriceUsd() float64 {
+	if x != nil {
+		return x.PriceUsd
+	}
+	return 0
+}
+
+func (x *SwapEvent) GetVolumeUsd() float64 {
+	if x != nil {
+		return x.VolumeUsd
+	}
+	return 0
+}
+
+func (x *SwapEvent) GetBaseTransferFeeUnknown() bool {
+	if x != nil {
+		return x.BaseTransferFeeUnknown
+	}
+	return false
+}
+
+func (x *SwapEvent) GetQuoteTransferFeeUnknown() bool {
+	if x != nil {
+		return x.QuoteTransferFeeUnknown
+	}
+	return false
+}
+
+func (x *SwapEvent) GetSqrtPriceInverted() bool {
+	if x != nil {
+		return x.SqrtPriceInverted
+	}
+	return false
+}
+
+func (x *SwapEvent) GetInstructionIndex() uint32 {
+	if x != nil {
+		return x.InstructionIndex
+	}
+	return 0
+}
+
+func (x *SwapEvent) GetInner() bool {
+	if x != nil {
+		return x.Inner
+	}
+	return false
+}
+
+func (x *SwapEvent) GetInnerInstructionIndex() uint32 {
+	if x != nil {
+		return x.InnerInstructionIndex
+	}
+	return 0
+}
+
+func (x *SwapEvent) GetHop() uint32 {
+	if x != nil {
+		return x.Hop
+	}
+	return 0
+}
+
+type MevEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChainId       uint64                 `protobuf:"varint,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	Slot          uint64                 `protobuf:"varint,2,opt,name=slot,proto3" json:"slot,omitempty"`
+	Kind          string                 `protobuf:"bytes,3,opt,name=kind,proto3" json:"kind,omitempty"`
+	Attacker      string                 `protobuf:"bytes,4,opt,name=attacker,proto3" json:"attacker,omitempty"`
+	PoolId        string                 `protobuf:"bytes,5,opt,name=pool_id,json=poolId,proto3" json:"pool_id,omitempty"`
+	FrontSig      string                 `protobuf:"bytes,6,opt,name=front_sig,json=frontSig,proto3" json:"front_sig,omitempty"`
+	FrontIndex    uint32                 `protobuf:"varint,7,opt,name=front_index,json=frontIndex,proto3" json:"front_index,omitempty"`
+	BackSig       string                 `protobuf:"bytes,8,opt,name=back_sig,json=backSig,proto3" json:"back_sig,omitempty"`
+	BackIndex     uint32                 `protobuf:"varint,9,opt,name=back_index,json=backIndex,proto3" json:"back_index,omitempty"`
+	VictimSigs    []string               `protobuf:"bytes,10,rep,name=victim_sigs,json=victimSigs,proto3" json:"victim_sigs,omitempty"`
+	VictimTraders []string               `protobuf:"bytes,11,rep,name=victim_traders,json=victimTraders,proto3" json:"victim_traders,omitempty"`
+	ProfitMint    string                 `protobuf:"bytes,12,opt,name=profit_mint,json=profitMint,proto3" json:"profit_mint,omitempty"`
+	ProfitAmount  int64                  `protobuf:"varint,13,opt,name=profit_amount,json=profitAmount,proto3" json:"profit_amount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MevEvent) Reset() {
+	*x = MevEvent{}
+	mi := &file_dex_sol_v1_core_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MevEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MevEvent) ProtoMessage() {}
+
+func (x *MevEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_dex_sol_v1_core_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MevEvent.ProtoReflect.Descriptor instead.
+func (*MevEvent) Descriptor() ([]byte, []int) {
+	return file_dex_sol_v1_core_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *MevEvent) GetChainId() uint64 {
+	if x != nil {
+		return x.ChainId
+	}
+	return 0
+}
+
+func (x *MevEvent) GetSlot() uint64 {
+	if x != nil {
+		return x.Slot
+	}
+	return 0
+}
+
+func (x *MevEvent) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *MevEvent) GetAttacker() string {
+	if x != nil {
+		return x.Attacker
+	}
+	return ""
+}
+
+func (x *MevEvent) GetPoolId() string {
+	if x != nil {
+		return x.PoolId
+	}
+	return ""
+}
+
+func (x *MevEvent) GetFrontSig() string {
+	if x != nil {
+		return x.FrontSig
+	}
+	return ""
+}
+
+func (x *MevEvent) GetFrontIndex() uint32 {
+	if x != nil {
+		return x.FrontIndex
+	}
+	return 0
+}
+
+func (x *MevEvent) GetBackSig() string {
+	if x != nil {
+		return x.BackSig
+	}
+	return ""
+}
+
+func (x *MevEvent) GetBackIndex() uint32 {
+	if x != nil {
+		return x.BackIndex
+	}
+	return 0
+}
+
+func (x *MevEvent) GetVictimSigs() []string {
+	if x != nil {
+		return x.VictimSigs
+	}
+	return nil
+}
+
+func (x *MevEvent) GetVictimTraders() []string {
+	if x != nil {
+		return x.VictimTraders
+	}
+	return nil
+}
+
+func (x *MevEvent) GetProfitMint() string {
+	if x != nil {
+		return x.ProfitMint
+	}
+	return ""
+}
+
+func (x *MevEvent) GetProfitAmount() int64 {
+	if x != nil {
+		return x.ProfitAmount
+	}
+	return 0
+}
+
+type Route struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	ChainId             uint64                 `protobuf:"varint,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	Slot                uint64                 `protobuf:"varint,2,opt,name=slot,proto3" json:"slot,omitempty"`
+	Sig                 string                 `protobuf:"bytes,3,opt,name=sig,proto3" json:"sig,omitempty"`
+	Index               uint32                 `protobuf:"varint,4,opt,name=index,proto3" json:"index,omitempty"`
+	AggregatorProgramId string                 `protobuf:"bytes,5,opt,name=aggregator_program_id,json=aggregatorProgramId,proto3" json:"aggregator_program_id,omitempty"`
+	Trader              string                 `protobuf:"bytes,6,opt,name=trader,proto3" json:"trader,omitempty"`
+	InputMint           string                 `protobuf:"bytes,7,opt,name=input_mint,json=inputMint,proto3" json:"input_mint,omitempty"`
+	InputAmount         uint64                 `protobuf:"varint,8,opt,name=input_amount,json=inputAmount,proto3" json:"input_amount,omitempty"`
+	OutputMint          string                 `protobuf:"bytes,9,opt,name=output_mint,json=outputMint,proto3" json:"output_mint,omitempty"`
+	OutputAmount        uint64                 `protobuf:"varint,10,opt,name=output_amount,json=outputAmount,proto3" json:"output_amount,omitempty"`
+	Circular            bool                   `protobuf:"varint,11,opt,name=circular,proto3" json:"circular,omitempty"`
+	Legs                []*SwapEvent           `protobuf:"bytes,12,rep,name=legs,proto3" json:"legs,omitempty"`
+	Provisional         bool                   `protobuf:"varint,13,opt,name=provisional,proto3" json:"provisional,omitempty"`
+	IsUndo              bool                   `protobuf:"varint,14,opt,name=is_undo,json=isUndo,proto3" json:"is_undo,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *Route) Reset() {
+	*x = Route{}
+	mi := &file_dex_sol_v1_core_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Route) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Route) ProtoMessage() {}
+
+func (x *Route) ProtoReflect() protoreflect.Message {
+	mi := &file_dex_sol_v1_core_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Route.ProtoReflect.Descriptor instead.
+func (*Route) Descriptor() ([]byte, []int) {
+	return file_dex_sol_v1_core_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Route) GetChainId() uint64 {
+	if x != nil {
+		return x.ChainId
+	}
+	return 0
+}
+
+func (x *Route) GetSlot() uint64 {
+	if x != nil {
+		return x.Slot
+	}
+	return 0
+}
+
+func (x *Route) GetSig() string {
+	if x != nil {
+		return x.Sig
+	}
+	return ""
+}
+
+func (x *Route) GetIndex() uint32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *Route) GetAggregatorProgramId() string {
+	if x != nil {
+		return x.AggregatorProgramId
+	}
+	return ""
+}
+
+func (x *Route) GetTrader() string {
+	if x != nil {
+		return x.Trader
+	}
+	return ""
+}
+
+func (x *Route) GetInputMint() string {
+	if x != nil {
+		return x.InputMint
+	}
+	return ""
+}
+
+func (x *Route) GetInputAmount() uint64 {
+	if x != nil {
+		return x.InputAmount
+	}
+	return 0
+}
+
+func (x *Route) GetOutputMint() string {
+	if x != nil {
+		return x.OutputMint
+	}
+	return ""
+}
+
+func (x *Route) GetOutputAmount() uint64 {
+	if x != nil {
+		return x.OutputAmount
+	}
+	return 0
+}
+
+func (x *Route) GetCircular() bool {
+	if x != nil {
+		return x.Circular
+	}
+	return false
+}
+
+func (x *Route) GetLegs() []*SwapEvent {
+	if x != nil {
+		return x.Legs
+	}
+	return nil
+}
+
+func (x *Route) GetProvisional() bool {
+	if x != nil {
+		return x.Provisional
+	}
+	return false
+}
+
+func (x *Route) GetIsUndo() bool {
+	if x != nil {
+		return x.IsUndo
+	}
+	return false
+}
+
+type FailedSwapAttempt struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	ChainId              uint64                 `protobuf:"varint,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	Slot                 uint64                 `protobuf:"varint,2,opt,name=slot,proto3" json:"slot,omitempty"`
+	Sig                  string                 `protobuf:"bytes,3,opt,name=sig,proto3" json:"sig,omitempty"`
+	Index                uint32                 `protobuf:"varint,4,opt,name=index,proto3" json:"index,omitempty"`
+	ProgramId            string                 `protobuf:"bytes,5,opt,name=program_id,json=programId,proto3" json:"program_id,omitempty"`
+	PoolId               string                 `protobuf:"bytes,6,opt,name=pool_id,json=poolId,proto3" json:"pool_id,omitempty"`
+	InstructionIndex     uint32                 `protobuf:"varint,7,opt,name=instruction_index,json=instructionIndex,proto3" json:"instruction_index,omitempty"`
+	ErrorKind            string                 `protobuf:"bytes,8,opt,name=error_kind,json=errorKind,proto3" json:"error_kind,omitempty"`
+	HasFailedInstruction bool                   `protobuf:"varint,9,opt,name=has_failed_instruction,json=hasFailedInstruction,proto3" json:"has_failed_instruction,omitempty"`
+	FailedInstruction    uint32                 `protobuf:"varint,10,opt,name=failed_instruction,json=failedInstruction,proto3" json:"failed_instruction,omitempty"`
+	ErrorCode            uint32                 `protobuf:"varint,11,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`
+	ErrorName            string                 `protobuf:"bytes,12,opt,name=error_name,json=errorName,proto3" json:"error_name,omitempty"`
+	FeePayer             string                 `protobuf:"bytes,13,opt,name=fee_payer,json=feePayer,proto3" json:"fee_payer,omitempty"`
+	CuUsed               uint64                 `protobuf:"varint,14,opt,name=cu_used,json=cuUsed,proto3" json:"cu_used,omitempty"`
+	// Set when the attempt was reached through CPI (e.g. an aggregator). The
+	// instruction_index is then the outer instruction and
+	// inner_instruction_index its position among that instruction's CPIs.
+	Inner                 bool   `protobuf:"varint,15,opt,name=inner,proto3" json:"inner,omitempty"`
+	InnerInstructionIndex uint32 `protobuf:"varint,16,opt,name=inner_instruction_index,json=innerInstructionIndex,proto3" json:"inner_instruction_index,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *FailedSwapAttempt) Reset() {
+	*x = FailedSwapAttempt{}
+	mi := &file_dex_sol_v1_core_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FailedSwapAttempt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FailedSwapAttempt) ProtoMessage() {}
+
+func (x *FailedSwapAttempt) ProtoReflect() protoreflect.Message {
+	mi := &file_dex_sol_v1_core_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FailedSwapAttempt.ProtoReflect.Descriptor instead.
+func (*FailedSwapAttempt) Descriptor() ([]byte, []int) {
+	return file_dex_sol_v1_core_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *FailedSwapAttempt) GetChainId() uint64 {
+	if x != nil {
+		return x.ChainId
+	}
+	return 0
+}
+
+func (x *FailedSwapAttempt) GetSlot() uint64 {
+	if x != nil {
+		return x.Slot
+	}
+	return 0
+}
+
+func (x *FailedSwapAttempt) GetSig() string {
+	if x != nil {
+		return x.Sig
+	}
+	return ""
+}
+
+func (x *FailedSwapAttempt) GetIndex() uint32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *FailedSwapAttempt) GetProgramId() string {
+	if x != nil {
+		return x.ProgramId
+	}
+	return ""
+}
+
+func (x *FailedSwapAttempt) GetPoolId() string {
+	if x != nil {
+		return x.PoolId
+	}
+	return ""
+}
+
+func (x *FailedSwapAttempt) GetInstructionIndex() uint32 {
+	if x != nil {
+		return x.InstructionIndex
+	}
+	return 0
+}
+
+func (x *FailedSwapAttempt) GetErrorKind() string {
+	if x != nil {
+		return x.ErrorKind
+	}
+	return ""
+}
+
+func (x *FailedSwapAttempt) GetHasFailedInstruction() bool {
+	if x != nil {
+		return x.HasFailedInstruction
+	}
+	return false
+}
+
+func (x *FailedSwapAttempt) GetFailedInstruction() uint32 {
+	if x != nil {
+		return x.FailedInstruction
+	}
+	return 0
+}
+
+func (x *FailedSwapAttempt) GetErrorCode() uint32 {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return 0
+}
+
+func (x *FailedSwapAttempt) GetErrorName() string {
+	if x != nil {
+		return x.ErrorName
+	}
+	return ""
+}
+
+func (x *FailedSwapAttempt) GetFeePayer() string {
+	if x != nil {
+		return x.FeePayer
+	}
+	return ""
+}
+
+func (x *FailedSwapAttempt) GetCuUsed() uint64 {
+	if x != nil {
+		return x.CuUsed
+	}
+	return 0
+}
+
+func (x *FailedSwapAttempt) GetInner() bool {
+	if x != nil {
+		return x.Inner
+	}
+	return false
+}
+
+func (x *FailedSwapAttempt) GetInnerInstructionIndex() uint32 {
+	if x != nil {
+		return x.InnerInstructionIndex
+	}
+	return 0
+}
+
 type PoolSnapshot struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ChainId       uint64                 `protobuf:"varint,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
@@ -455,7 +1106,7 @@ type PoolSnapshot struct {
 
 func (x *PoolSnapshot) Reset() {
 	*x = PoolSnapshot{}
-	mi := &file_dex_sol_v1_core_proto_msgTypes[4]
+	mi := &file_dex_sol_v1_core_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -467,7 +1118,7 @@ func (x *PoolSnapshot) String() string {
 func (*PoolSnapshot) ProtoMessage() {}
 
 func (x *PoolSnapshot) ProtoReflect() protoreflect.Message {
-	mi := &file_dex_sol_v1_core_proto_msgTypes[4]
+	mi := &file_dex_sol_v1_core_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -480,7 +1131,7 @@ func (x *PoolSnapshot) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PoolSnapshot.ProtoReflect.Descriptor instead.
 func (*PoolSnapshot) Descriptor() ([]byte, []int) {
-	return file_dex_sol_v1_core_proto_rawDescGZIP(), []int{4}
+	return file_dex_sol_v1_core_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *PoolSnapshot) GetChainId() uint64 {
@@ -554,30 +1205,34 @@ func (x *PoolSnapshot) GetLiquidity() uint64 {
 }
 
 type Candle struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ChainId       uint64                 `protobuf:"varint,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
-	PairId        string                 `protobuf:"bytes,2,opt,name=pair_id,json=pairId,proto3" json:"pair_id,omitempty"`
-	PoolId        string                 `protobuf:"bytes,3,opt,name=pool_id,json=poolId,proto3" json:"pool_id,omitempty"`
-	Timeframe     string                 `protobuf:"bytes,4,opt,name=timeframe,proto3" json:"timeframe,omitempty"`
-	WindowStart   uint64                 `protobuf:"varint,5,opt,name=window_start,json=windowStart,proto3" json:"window_start,omitempty"`
-	Provisional   bool                   `protobuf:"varint,6,opt,name=provisional,proto3" json:"provisional,omitempty"`
-	IsCorrection  bool                   `protobuf:"varint,7,opt,name=is_correction,json=isCorrection,proto3" json:"is_correction,omitempty"`
-	OpenPxQ32     int64                  `protobuf:"varint,10,opt,name=open_px_q32,json=openPxQ32,proto3" json:"open_px_q32,omitempty"`
-	HighPxQ32     int64                  `protobuf:"varint,11,opt,name=high_px_q32,json=highPxQ32,proto3" json:"high_px_q32,omitempty"`
-	LowPxQ32      int64                  `protobuf:"varint,12,opt,name=low_px_q32,json=lowPxQ32,proto3" json:"low_px_q32,omitempty"`
-	ClosePxQ32    int64                  `protobuf:"varint,13,opt,name=close_px_q32,json=closePxQ32,proto3" json:"close_px_q32,omitempty"`
-	VwapNum       *U128                  `protobuf:"bytes,14,opt,name=vwap_num,json=vwapNum,proto3" json:"vwap_num,omitempty"`
-	VwapDen       *U128                  `protobuf:"bytes,15,opt,name=vwap_den,json=vwapDen,proto3" json:"vwap_den,omitempty"`
-	VolBase       *U128                  `protobuf:"bytes,16,opt,name=vol_base,json=volBase,proto3" json:"vol_base,omitempty"`
-	VolQuote      *U128                  `protobuf:"bytes,17,opt,name=vol_quote,json=volQuote,proto3" json:"vol_quote,omitempty"`
-	Trades        uint32                 `protobuf:"varint,18,opt,name=trades,proto3" json:"trades,omitempty"`
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	ChainId      uint64                 `protobuf:"varint,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	PairId       string                 `protobuf:"bytes,2,opt,name=pair_id,json=pairId,proto3" json:"pair_id,omitempty"`
+	PoolId       string                 `protobuf:"bytes,3,opt,name=pool_id,json=poolId,proto3" json:"pool_id,omitempty"`
+	Timeframe    string                 `protobuf:"bytes,4,opt,name=timeframe,proto3" json:"timeframe,omitempty"`
+	WindowStart  uint64                 `protobuf:"varint,5,opt,name=window_start,json=windowStart,proto3" json:"window_start,omitempty"`
+	Provisional  bool                   `protobuf:"varint,6,opt,name=provisional,proto3" json:"provisional,omitempty"`
+	IsCorrection bool                   `protobuf:"varint,7,opt,name=is_correction,json=isCorrection,proto3" json:"is_correction,omitempty"`
+	OpenPxQ32    int64                  `protobuf:"varint,10,opt,name=open_px_q32,json=openPxQ32,proto3" json:"open_px_q32,omitempty"`
+	HighPxQ32    int64                  `protobuf:"varint,11,opt,name=high_px_q32,json=highPxQ32,proto3" json:"high_px_q32,omitempty"`
+	LowPxQ32     int64                  `protobuf:"varint,12,opt,name=low_px_q32,json=lowPxQ32,proto3" json:"low_px_q32,omitempty"`
+	ClosePxQ32   int64                  `protobuf:"varint,13,opt,name=close_px_q32,json=closePxQ32,proto3" json:"close_px_q32,omitempty"`
+	VwapNum      *U128                  `protobuf:"bytes,14,opt,name=vwap_num,json=vwapNum,proto3" json:"vwap_num,omitempty"`
+	VwapDen      *U128                  `protobuf:"bytes,15,opt,name=vwap_den,json=vwapDen,proto3" json:"vwap_den,omitempty"`
+	VolBase      *U128                  `protobuf:"bytes,16,opt,name=vol_base,json=volBase,proto3" json:"vol_base,omitempty"`
+	VolQuote     *U128                  `protobuf:"bytes,17,opt,name=vol_quote,json=volQuote,proto3" json:"vol_quote,omitempty"`
+	Trades       uint32                 `protobuf:"varint,18,opt,name=trades,proto3" json:"trades,omitempty"`
+	// USD close price and quote volume; zero when the quote mint could not be
+	// priced.
+	PriceUsd      float64 `protobuf:"fixed64,19,opt,name=price_usd,json=priceUsd,proto3" json:"price_usd,omitempty"`
+	VolumeUsd     float64 `protobuf:"fixed64,20,opt,name=volume_usd,json=volumeUsd,proto3" json:"volume_usd,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Candle) Reset() {
 	*x = Candle{}
-	mi := &file_dex_sol_v1_core_proto_msgTypes[5]
+	mi := &file_dex_sol_v1_core_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -589,7 +1244,7 @@ func (x *Candle) String() string {
 func (*Candle) ProtoMessage() {}
 
 func (x *Candle) ProtoReflect() protoreflect.Message {
-	mi := &file_dex_sol_v1_core_proto_msgTypes[5]
+	mi := &file_dex_sol_v1_core_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -602,7 +1257,7 @@ func (x *Candle) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Candle.ProtoReflect.Descriptor instead.
 func (*Candle) Descriptor() ([]byte, []int) {
-	return file_dex_sol_v1_core_proto_rawDescGZIP(), []int{5}
+	return file_dex_sol_v1_core_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *Candle) GetChainId() uint64 {
@@ -717,6 +1372,20 @@ func (x *Candle) GetTrades() uint32 {
 	return 0
 }
 
+func (x *Candle) GetPriceUsd() float64 {
+	if x != nil {
+		return x.PriceUsd
+	}
+	return 0
+}
+
+func (x *Candle) GetVolumeUsd() float64 {
+	if x != nil {
+		return x.VolumeUsd
+	}
+	return 0
+}
+
 type WalletHeuristics struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ChainId       uint64                 `protobuf:"varint,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
@@ -733,7 +1402,7 @@ type WalletHeuristics struct {
 
 func (x *WalletHeuristics) Reset() {
 	*x = WalletHeuristics{}
-	mi := &file_dex_sol_v1_core_proto_msgTypes[6]
+	mi := &file_dex_sol_v1_core_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -745,7 +1414,7 @@ func (x *WalletHeuristics) String() string {
 func (*WalletHeuristics) ProtoMessage() {}
 
 func (x *WalletHeuristics) ProtoReflect() protoreflect.Message {
-	mi := &file_dex_sol_v1_core_proto_msgTypes[6]
+	mi := &file_dex_sol_v1_core_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -758,7 +1427,7 @@ func (x *WalletHeuristics) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WalletHeuristics.ProtoReflect.Descriptor instead.
 func (*WalletHeuristics) Descriptor() ([]byte, []int) {
-	return file_dex_sol_v1_core_proto_rawDescGZIP(), []int{6}
+	return file_dex_sol_v1_core_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *WalletHeuristics) GetChainId() uint64 {
@@ -817,6 +1486,258 @@ func (x *WalletHeuristics) GetBundledPct() float32 {
 	return 0
 }
 
+type DecodeFailure struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	ChainId      uint64                 `protobuf:"varint,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	Slot         uint64                 `protobuf:"varint,2,opt,name=slot,proto3" json:"slot,omitempty"`
+	Sig          string                 `protobuf:"bytes,3,opt,name=sig,proto3" json:"sig,omitempty"`
+	ProgramId    string                 `protobuf:"bytes,4,opt,name=program_id,json=programId,proto3" json:"program_id,omitempty"`
+	Error        string                 `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	RawTx        []byte                 `protobuf:"bytes,6,opt,name=raw_tx,json=rawTx,proto3" json:"raw_tx,omitempty"`
+	ObservedAtMs uint64                 `protobuf:"varint,7,opt,name=observed_at_ms,json=observedAtMs,proto3" json:"observed_at_ms,omitempty"`
+	// Set when raw_tx holds the source JSON transaction (e.g. a Helius webhook
+	// delivery) because it could not be converted to a SubscribeUpdateTransaction.
+	RawTxJson     bool `protobuf:"varint,8,opt,name=raw_tx_json,json=rawTxJson,proto3" json:"raw_tx_json,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DecodeFailure) Reset() {
+	*x = DecodeFailure{}
+	mi := &file_dex_sol_v1_core_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DecodeFailure) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DecodeFailure) ProtoMessage() {}
+
+func (x *DecodeFailure) ProtoReflect() protoreflect.Message {
+	mi := &file_dex_sol_v1_core_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DecodeFailure.ProtoReflect.Descriptor instead.
+func (*DecodeFailure) Descriptor() ([]byte, []int) {
+	return file_dex_sol_v1_core_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DecodeFailure) GetChainId() uint64 {
+	if x != nil {
+		return x.ChainId
+	}
+	return 0
+}
+
+func (x *DecodeFailure) GetSlot() uint64 {
+	if x != nil {
+		return x.Slot
+	}
+	return 0
+}
+
+func (x *DecodeFailure) GetSig() string {
+	if x != nil {
+		return x.Sig
+	}
+	return ""
+}
+
+func (x *DecodeFailure) GetProgramId() string {
+	if x != nil {
+		return x.ProgramId
+	}
+	return ""
+}
+
+func (x *DecodeFailure) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *DecodeFailure) GetRawTx() []byte {
+	if x != nil {
+		return x.RawTx
+	}
+	return nil
+}
+
+func (x *DecodeFailure) GetObservedAtMs() uint64 {
+	if x != nil {
+		return x.ObservedAtMs
+	}
+	return 0
+}
+
+func (x *DecodeFailure) GetRawTxJson() bool {
+	if x != nil {
+		return x.RawTxJson
+	}
+	return false
+}
+
+// ReferencePrice is an off-DEX oracle price (Pyth) for one configured feed.
+// The price is price * 10^expo in the feed's quote currency (USD).
+type ReferencePrice struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChainId       uint64                 `protobuf:"varint,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	Slot          uint64                 `protobuf:"varint,2,opt,name=slot,proto3" json:"slot,omitempty"`
+	Source        string                 `protobuf:"bytes,3,opt,name=source,proto3" json:"source,omitempty"`
+	Account       string                 `protobuf:"bytes,4,opt,name=account,proto3" json:"account,omitempty"`
+	Feed          string                 `protobuf:"bytes,5,opt,name=feed,proto3" json:"feed,omitempty"`
+	FeedId        string                 `protobuf:"bytes,6,opt,name=feed_id,json=feedId,proto3" json:"feed_id,omitempty"`
+	Mint          string                 `protobuf:"bytes,7,opt,name=mint,proto3" json:"mint,omitempty"`
+	Price         int64                  `protobuf:"varint,8,opt,name=price,proto3" json:"price,omitempty"`
+	Conf          uint64                 `protobuf:"varint,9,opt,name=conf,proto3" json:"conf,omitempty"`
+	Expo          int32                  `protobuf:"varint,10,opt,name=expo,proto3" json:"expo,omitempty"`
+	PublishTime   int64                  `protobuf:"varint,11,opt,name=publish_time,json=publishTime,proto3" json:"publish_time,omitempty"`
+	Status        string                 `protobuf:"bytes,12,opt,name=status,proto3" json:"status,omitempty"`
+	PriceUsd      float64                `protobuf:"fixed64,13,opt,name=price_usd,json=priceUsd,proto3" json:"price_usd,omitempty"`
+	ConfUsd       float64                `protobuf:"fixed64,14,opt,name=conf_usd,json=confUsd,proto3" json:"conf_usd,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReferencePrice) Reset() {
+	*x = ReferencePrice{}
+	mi := &file_dex_sol_v1_core_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReferencePrice) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReferencePrice) ProtoMessage() {}
+
+func (x *ReferencePrice) ProtoReflect() protoreflect.Message {
+	mi := &file_dex_sol_v1_core_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReferencePrice.ProtoReflect.Descriptor instead.
+func (*ReferencePrice) Descriptor() ([]byte, []int) {
+	return file_dex_sol_v1_core_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ReferencePrice) GetChainId() uint64 {
+	if x != nil {
+		return x.ChainId
+	}
+	return 0
+}
+
+func (x *ReferencePrice) GetSlot() uint64 {
+	if x != nil {
+		return x.Slot
+	}
+	return 0
+}
+
+func (x *ReferencePrice) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *ReferencePrice) GetAccount() string {
+	if x != nil {
+		return x.Account
+	}
+	return ""
+}
+
+func (x *ReferencePrice) GetFeed() string {
+	if x != nil {
+		return x.Feed
+	}
+	return ""
+}
+
+func (x *ReferencePrice) GetFeedId() string {
+	if x != nil {
+		return x.FeedId
+	}
+	return ""
+}
+
+func (x *ReferencePrice) GetMint() string {
+	if x != nil {
+		return x.Mint
+	}
+	return ""
+}
+
+func (x *ReferencePrice) GetPrice() int64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *ReferencePrice) GetConf() uint64 {
+	if x != nil {
+		return x.Conf
+	}
+	return 0
+}
+
+func (x *ReferencePrice) GetExpo() int32 {
+	if x != nil {
+		return x.Expo
+	}
+	return 0
+}
+
+func (x *ReferencePrice) GetPublishTime() int64 {
+	if x != nil {
+		return x.PublishTime
+	}
+	return 0
+}
+
+func (x *ReferencePrice) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ReferencePrice) GetPriceUsd() float64 {
+	if x != nil {
+		return x.PriceUsd
+	}
+	return 0
+}
+
+func (x *ReferencePrice) GetConfUsd() float64 {
+	if x != nil {
+		return x.ConfUsd
+	}
+	return 0
+}
+
 var File_dex_sol_v1_core_proto protoreflect.FileDescriptor
 
 const file_dex_sol_v1_core_proto_rawDesc = "" +
@@ -838,7 +1759,7 @@ const file_dex_sol_v1_core_proto_rawDesc = "" +
 	"\asuccess\x18\x04 \x01(\bR\asuccess\x12\x17\n" +
 	"\acu_used\x18\x05 \x01(\x04R\x06cuUsed\x12\x19\n" +
 	"\bcu_price\x18\x06 \x01(\x04R\acuPrice\x12\x19\n" +
-	"\blog_msgs\x18\a \x03(\tR\alogMsgs\"\xf6\x04\n" +
+	"\blog_msgs\x18\a \x03(\tR\alogMsgs\"\x99\v\n" +
 	"\tSwapEvent\x12\x19\n" +
 	"\bchain_id\x18\x01 \x01(\x04R\achainId\x12\x12\n" +
 	"\x04slot\x18\x02 \x01(\x04R\x04slot\x12\x10\n" +
@@ -863,7 +1784,93 @@ const file_dex_sol_v1_core_proto_rawDesc = "" +
 	"\x0ereserves_quote\x18\x12 \x01(\x04R\rreservesQuote\x12\x17\n" +
 	"\afee_bps\x18\x13 \x01(\rR\x06feeBps\x12 \n" +
 	"\vprovisional\x18\x14 \x01(\bR\vprovisional\x12\x17\n" +
-	"\ais_undo\x18\x15 \x01(\bR\x06isUndo\"\xbb\x02\n" +
+	"\ais_undo\x18\x15 \x01(\bR\x06isUndo\x12\x1d\n" +
+	"\n" +
+	"base_gross\x18\x16 \x01(\x04R\tbaseGross\x12\x19\n" +
+	"\bbase_net\x18\x17 \x01(\x04R\abaseNet\x12*\n" +
+	"\x11base_transfer_fee\x18\x18 \x01(\x04R\x0fbaseTransferFee\x12\x1f\n" +
+	"\vquote_gross\x18\x19 \x01(\x04R\n" +
+	"quoteGross\x12\x1b\n" +
+	"\tquote_net\x18\x1a \x01(\x04R\bquoteNet\x12,\n" +
+	"\x12quote_transfer_fee\x18\x1b \x01(\x04R\x10quoteTransferFee\x12\x15\n" +
+	"\x06lp_fee\x18\x1c \x01(\x04R\x05lpFee\x12!\n" +
+	"\fprotocol_fee\x18\x1d \x01(\x04R\vprotocolFee\x12\x17\n" +
+	"\apair_id\x18\x1e \x01(\tR\x06pairId\x12\x1b\n" +
+	"\tprice_q32\x18\x1f \x01(\x03R\bpriceQ32\x12\x16\n" +
+	"\x06trader\x18  \x01(\tR\x06trader\x12\x1b\n" +
+	"\tfee_payer\x18! \x01(\tR\bfeePayer\x12\x1d\n" +
+	"\n" +
+	"mev_victim\x18\" \x01(\bR\tmevVictim\x12\x1a\n" +
+	"\brepaired\x18# \x01(\bR\brepaired\x12\x1b\n" +
+	"\tprice_usd\x18$ \x01(\x01R\bpriceUsd\x12\x1d\n" +
+	"\n" +
+	"volume_usd\x18% \x01(\x01R\tvolumeUsd\x129\n" +
+	"\x19base_transfer_fee_unknown\x18& \x01(\bR\x16baseTransferFeeUnknown\x12;\n" +
+	"\x1aquote_transfer_fee_unknown\x18' \x01(\bR\x17quoteTransferFeeUnknown\x12.\n" +
+	"\x13sqrt_price_inverted\x18( \x01(\bR\x11sqrtPriceInverted\x12+\n" +
+	"\x11instruction_index\x18) \x01(\rR\x10instructionIndex\x12\x14\n" +
+	"\x05inner\x18* \x01(\bR\x05inner\x126\n" +
+	"\x17inner_instruction_index\x18+ \x01(\rR\x15innerInstructionIndex\x12\x10\n" +
+	"\x03hop\x18, \x01(\rR\x03hop\"\x88\x03\n" +
+	"\bMevEvent\x12\x19\n" +
+	"\bchain_id\x18\x01 \x01(\x04R\achainId\x12\x12\n" +
+	"\x04slot\x18\x02 \x01(\x04R\x04slot\x12\x12\n" +
+	"\x04kind\x18\x03 \x01(\tR\x04kind\x12\x1a\n" +
+	"\battacker\x18\x04 \x01(\tR\battacker\x12\x17\n" +
+	"\apool_id\x18\x05 \x01(\tR\x06poolId\x12\x1b\n" +
+	"\tfront_sig\x18\x06 \x01(\tR\bfrontSig\x12\x1f\n" +
+	"\vfront_index\x18\a \x01(\rR\n" +
+	"frontIndex\x12\x19\n" +
+	"\bback_sig\x18\b \x01(\tR\abackSig\x12\x1d\n" +
+	"\n" +
+	"back_index\x18\t \x01(\rR\tbackIndex\x12\x1f\n" +
+	"\vvictim_sigs\x18\n" +
+	" \x03(\tR\n" +
+	"victimSigs\x12%\n" +
+	"\x0evictim_traders\x18\v \x03(\tR\rvictimTraders\x12\x1f\n" +
+	"\vprofit_mint\x18\f \x01(\tR\n" +
+	"profitMint\x12#\n" +
+	"\rprofit_amount\x18\r \x01(\x03R\fprofitAmount\"\xb4\x03\n" +
+	"\x05Route\x12\x19\n" +
+	"\bchain_id\x18\x01 \x01(\x04R\achainId\x12\x12\n" +
+	"\x04slot\x18\x02 \x01(\x04R\x04slot\x12\x10\n" +
+	"\x03sig\x18\x03 \x01(\tR\x03sig\x12\x14\n" +
+	"\x05index\x18\x04 \x01(\rR\x05index\x122\n" +
+	"\x15aggregator_program_id\x18\x05 \x01(\tR\x13aggregatorProgramId\x12\x16\n" +
+	"\x06trader\x18\x06 \x01(\tR\x06trader\x12\x1d\n" +
+	"\n" +
+	"input_mint\x18\a \x01(\tR\tinputMint\x12!\n" +
+	"\finput_amount\x18\b \x01(\x04R\vinputAmount\x12\x1f\n" +
+	"\voutput_mint\x18\t \x01(\tR\n" +
+	"outputMint\x12#\n" +
+	"\routput_amount\x18\n" +
+	" \x01(\x04R\foutputAmount\x12\x1a\n" +
+	"\bcircular\x18\v \x01(\bR\bcircular\x12)\n" +
+	"\x04legs\x18\f \x03(\v2\x15.dex.sol.v1.SwapEventR\x04legs\x12 \n" +
+	"\vprovisional\x18\r \x01(\bR\vprovisional\x12\x17\n" +
+	"\ais_undo\x18\x0e \x01(\bR\x06isUndo\"\x95\x04\n" +
+	"\x11FailedSwapAttempt\x12\x19\n" +
+	"\bchain_id\x18\x01 \x01(\x04R\achainId\x12\x12\n" +
+	"\x04slot\x18\x02 \x01(\x04R\x04slot\x12\x10\n" +
+	"\x03sig\x18\x03 \x01(\tR\x03sig\x12\x14\n" +
+	"\x05index\x18\x04 \x01(\rR\x05index\x12\x1d\n" +
+	"\n" +
+	"program_id\x18\x05 \x01(\tR\tprogramId\x12\x17\n" +
+	"\apool_id\x18\x06 \x01(\tR\x06poolId\x12+\n" +
+	"\x11instruction_index\x18\a \x01(\rR\x10instructionIndex\x12\x1d\n" +
+	"\n" +
+	"error_kind\x18\b \x01(\tR\terrorKind\x124\n" +
+	"\x16has_failed_instruction\x18\t \x01(\bR\x14hasFailedInstruction\x12-\n" +
+	"\x12failed_instruction\x18\n" +
+	" \x01(\rR\x11failedInstruction\x12\x1d\n" +
+	"\n" +
+	"error_code\x18\v \x01(\rR\terrorCode\x12\x1d\n" +
+	"\n" +
+	"error_name\x18\f \x01(\tR\terrorName\x12\x1b\n" +
+	"\tfee_payer\x18\r \x01(\tR\bfeePayer\x12\x17\n" +
+	"\acu_used\x18\x0e \x01(\x04R\x06cuUsed\x12\x14\n" +
+	"\x05inner\x18\x0f \x01(\bR\x05inner\x126\n" +
+	"\x17inner_instruction_index\x18\x10 \x01(\rR\x15innerInstructionIndex\"\xbb\x02\n" +
 	"\fPoolSnapshot\x12\x19\n" +
 	"\bchain_id\x18\x01 \x01(\x04R\achainId\x12\x12\n" +
 	"\x04slot\x18\x02 \x01(\x04R\x04slot\x12\x17\n" +
@@ -876,7 +1883,7 @@ const file_dex_sol_v1_core_proto_rawDesc = "" +
 	"\x0ereserves_quote\x18\b \x01(\x04R\rreservesQuote\x12\x17\n" +
 	"\afee_bps\x18\t \x01(\rR\x06feeBps\x12\x1c\n" +
 	"\tliquidity\x18\n" +
-	" \x01(\x04R\tliquidity\"\xab\x04\n" +
+	" \x01(\x04R\tliquidity\"\xe7\x04\n" +
 	"\x06Candle\x12\x19\n" +
 	"\bchain_id\x18\x01 \x01(\x04R\achainId\x12\x17\n" +
 	"\apair_id\x18\x02 \x01(\tR\x06pairId\x12\x17\n" +
@@ -896,7 +1903,10 @@ const file_dex_sol_v1_core_proto_rawDesc = "" +
 	"\bvwap_den\x18\x0f \x01(\v2\x10.dex.sol.v1.U128R\avwapDen\x12+\n" +
 	"\bvol_base\x18\x10 \x01(\v2\x10.dex.sol.v1.U128R\avolBase\x12-\n" +
 	"\tvol_quote\x18\x11 \x01(\v2\x10.dex.sol.v1.U128R\bvolQuote\x12\x16\n" +
-	"\x06trades\x18\x12 \x01(\rR\x06trades\"\xfe\x01\n" +
+	"\x06trades\x18\x12 \x01(\rR\x06trades\x12\x1b\n" +
+	"\tprice_usd\x18\x13 \x01(\x01R\bpriceUsd\x12\x1d\n" +
+	"\n" +
+	"volume_usd\x18\x14 \x01(\x01R\tvolumeUsd\"\xfe\x01\n" +
 	"\x10WalletHeuristics\x12\x19\n" +
 	"\bchain_id\x18\x01 \x01(\x04R\achainId\x12\x16\n" +
 	"\x06wallet\x18\x02 \x01(\tR\x06wallet\x12&\n" +
@@ -906,7 +1916,33 @@ const file_dex_sol_v1_core_proto_rawDesc = "" +
 	"\bis_fresh\x18\x06 \x01(\bR\aisFresh\x12\x1b\n" +
 	"\tis_sniper\x18\a \x01(\bR\bisSniper\x12\x1f\n" +
 	"\vbundled_pct\x18\b \x01(\x02R\n" +
-	"bundledPctB;Z9github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1;dexsolv1b\x06proto3"
+	"bundledPct\"\xe2\x01\n" +
+	"\rDecodeFailure\x12\x19\n" +
+	"\bchain_id\x18\x01 \x01(\x04R\achainId\x12\x12\n" +
+	"\x04slot\x18\x02 \x01(\x04R\x04slot\x12\x10\n" +
+	"\x03sig\x18\x03 \x01(\tR\x03sig\x12\x1d\n" +
+	"\n" +
+	"program_id\x18\x04 \x01(\tR\tprogramId\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\x12\x15\n" +
+	"\x06raw_tx\x18\x06 \x01(\fR\x05rawTx\x12$\n" +
+	"\x0eobserved_at_ms\x18\a \x01(\x04R\fobservedAtMs\x12\x1e\n" +
+	"\vraw_tx_json\x18\b \x01(\bR\trawTxJson\"\xe3\x02\n" +
+	"\x0eReferencePrice\x12\x19\n" +
+	"\bchain_id\x18\x01 \x01(\x04R\achainId\x12\x12\n" +
+	"\x04slot\x18\x02 \x01(\x04R\x04slot\x12\x16\n" +
+	"\x06source\x18\x03 \x01(\tR\x06source\x12\x18\n" +
+	"\aaccount\x18\x04 \x01(\tR\aaccount\x12\x12\n" +
+	"\x04feed\x18\x05 \x01(\tR\x04feed\x12\x17\n" +
+	"\afeed_id\x18\x06 \x01(\tR\x06feedId\x12\x12\n" +
+	"\x04mint\x18\a \x01(\tR\x04mint\x12\x14\n" +
+	"\x05price\x18\b \x01(\x03R\x05price\x12\x12\n" +
+	"\x04conf\x18\t \x01(\x04R\x04conf\x12\x12\n" +
+	"\x04expo\x18\n" +
+	" \x01(\x05R\x04expo\x12!\n" +
+	"\fpublish_time\x18\v \x01(\x03R\vpublishTime\x12\x16\n" +
+	"\x06status\x18\f \x01(\tR\x06status\x12\x1b\n" +
+	"\tprice_usd\x18\r \x01(\x01R\bpriceUsd\x12\x19\n" +
+	"\bconf_usd\x18\x0e \x01(\x01R\aconfUsdB;Z9github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1;dexsolv1b\x06proto3"
 
 var (
 	file_dex_sol_v1_core_proto_rawDescOnce sync.Once
@@ -920,26 +1956,32 @@ func file_dex_sol_v1_core_proto_rawDescGZIP() []byte {
 	return file_dex_sol_v1_core_proto_rawDescData
 }
 
-var file_dex_sol_v1_core_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_dex_sol_v1_core_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
 var file_dex_sol_v1_core_proto_goTypes = []any{
-	(*U128)(nil),             // 0: dex.sol.v1.U128
-	(*BlockHead)(nil),        // 1: dex.sol.v1.BlockHead
-	(*TxMeta)(nil),           // 2: dex.sol.v1.TxMeta
-	(*SwapEvent)(nil),        // 3: dex.sol.v1.SwapEvent
-	(*PoolSnapshot)(nil),     // 4: dex.sol.v1.PoolSnapshot
-	(*Candle)(nil),           // 5: dex.sol.v1.Candle
-	(*WalletHeuristics)(nil), // 6: dex.sol.v1.WalletHeuristics
+	(*U128)(nil),              // 0: dex.sol.v1.U128
+	(*BlockHead)(nil),         // 1: dex.sol.v1.BlockHead
+	(*TxMeta)(nil),            // 2: dex.sol.v1.TxMeta
+	(*SwapEvent)(nil),         // 3: dex.sol.v1.SwapEvent
+	(*MevEvent)(nil),          // 4: dex.sol.v1.MevEvent
+	(*Route)(nil),             // 5: dex.sol.v1.Route
+	(*FailedSwapAttempt)(nil), // 6: dex.sol.v1.FailedSwapAttempt
+	(*PoolSnapshot)(nil),      // 7: dex.sol.v1.PoolSnapshot
+	(*Candle)(nil),            // 8: dex.sol.v1.Candle
+	(*WalletHeuristics)(nil),  // 9: dex.sol.v1.WalletHeuristics
+	(*DecodeFailure)(nil),     // 10: dex.sol.v1.DecodeFailure
+	(*ReferencePrice)(nil),    // 11: dex.sol.v1.ReferencePrice
 }
 var file_dex_sol_v1_core_proto_depIdxs = []int32{
-	0, // 0: dex.sol.v1.Candle.vwap_num:type_name -> dex.sol.v1.U128
-	0, // 1: dex.sol.v1.Candle.vwap_den:type_name -> dex.sol.v1.U128
-	0, // 2: dex.sol.v1.Candle.vol_base:type_name -> dex.sol.v1.U128
-	0, // 3: dex.sol.v1.Candle.vol_quote:type_name -> dex.sol.v1.U128
-	4, // [4:4] is the sub-list for method output_type
-	4, // [4:4] is the sub-list for method input_type
-	4, // [4:4] is the sub-list for extension type_name
-	4, // [4:4] is the sub-list for extension extendee
-	0, // [0:4] is the sub-list for field type_name
+	3, // 0: dex.sol.v1.Route.legs:type_name -> dex.sol.v1.SwapEvent
+	0, // 1: dex.sol.v1.Candle.vwap_num:type_name -> dex.sol.v1.U128
+	0, // 2: dex.sol.v1.Candle.vwap_den:type_name -> dex.sol.v1.U128
+	0, // 3: dex.sol.v1.Candle.vol_base:type_name -> dex.sol.v1.U128
+	0, // 4: dex.sol.v1.Candle.vol_quote:type_name -> dex.sol.v1.U128
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
 }
 
 func init() { file_dex_sol_v1_core_proto_init() }
@@ -953,7 +1995,7 @@ func file_dex_sol_v1_core_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_dex_sol_v1_core_proto_rawDesc), len(file_dex_sol_v1_core_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   7,
+			NumMessages:   12,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,22 @@
+package common
+
+import (
+	pb "github.com/rpcpool/yellowstone-grpc/examples/golang/proto"
+)
+
+// OracleFilterName is the account subscription key used for reference price
+// accounts.
+const OracleFilterName = "oracle_prices"
+
+// OracleAccountFilter subscribes to the given price accounts. It returns nil
+// when no accounts are configured.
+func OracleAccountFilter(accounts []string) *pb.SubscribeRequestFilterAccounts {
+	if len(accounts) == 0 {
+		return nil
+	}
+	return &pb.SubscribeRequestFilterAccounts{
+		Account: accounts,
+		Owner:   []string{},
+		Filters: []*pb.SubscribeRequestFilterAccountsFilter{},
+	}
+}
@@ -16,10 +16,18 @@ type SlotTimeCache interface {
 	// Set stores a slot-to-timestamp mapping
 	Set(slot uint64, timestamp time.Time)
 
+	// Delete removes the mapping for a slot, e.g. once it is known to be dead
+	Delete(slot uint64)
+
 	// GetRange retrieves timestamps for a range of slots [startSlot, endSlot]
 	// Returns a map of slot -> timestamp for slots found in the cache
 	GetRange(startSlot, endSlot uint64) map[uint64]time.Time
 
+	// Estimate returns the timestamp for a slot, interpolating or extrapolating
+	// from nearby cached slots when its own block time is unknown
+	// estimated reports whether the timestamp is such an estimate
+	Estimate(slot uint64) (ts time.Time, estimated bool, err error)
+
 	// SetReplayMarker marks a slot as the replay boundary
 	// This indicates that slots >= this value are being replayed/reprocessed
 	SetReplayMarker(slot uint64)
@@ -74,12 +82,31 @@ func (c *MemorySlotTimeCache) Set(slot uint64, timestamp time.Time) {
 	c.slots[slot] = timestamp
 }
 
+// Delete removes the mapping for a slot
+func (c *MemorySlotTimeCache) Delete(slot uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.slots, slot)
+}
+
 // GetRange retrieves timestamps for a range of slots
 func (c *MemorySlotTimeCache) GetRange(startSlot, endSlot uint64) map[uint64]time.Time {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
 	result := make(map[uint64]time.Time)
+	if endSlot < startSlot {
+		return result
+	}
+	// Walk whichever is smaller: the requested range or the cache itself.
+	if endSlot-startSlot >= uint64(len(c.slots)) {
+		for slot, ts := range c.slots {
+			if slot >= startSlot && slot <= endSlot {
+				result[slot] = ts
+			}
+		}
+		return result
+	}
 	for slot := startSlot; slot <= endSlot; slot++ {
 		if ts, ok := c.slots[slot]; ok {
 			result[slot] = ts
@@ -88,6 +115,11 @@ func (c *MemorySlotTimeCache) GetRange(startSlot, endSlot uint64) map[uint64]tim
 	return result
 }
 
+// Estimate returns the cached or estimated timestamp for a slot
+func (c *MemorySlotTimeCache) Estimate(slot uint64) (time.Time, bool, error) {
+	return estimateSlotTime(c, slot)
+}
+
 // SetReplayMarker marks a slot as the replay boundary
 func (c *MemorySlotTimeCache) SetReplayMarker(slot uint64) {
 	c.mu.Lock()
@@ -138,3 +170,61 @@ func (c *MemorySlotTimeCache) PruneBeforeSlot(slot uint64) int {
 	}
 	return pruned
 }
+
+const (
+	// DefaultSlotDuration is the nominal slot time, used to extrapolate when
+	// the cache holds a single nearby slot
+	DefaultSlotDuration = 400 * time.Millisecond
+
+	// EstimateWindowSlots bounds how far from a slot Estimate looks for known
+	// block times on either side
+	EstimateWindowSlots = 2048
+)
+
+// estimateSlotTime implements Estimate for any SlotTimeCache. A slot between
+// two known slots is interpolated linearly; otherwise it is extrapolated from
+// the nearest known slot at the slot duration observed across the window
+func estimateSlotTime(c SlotTimeCache, slot uint64) (time.Time, bool, error) {
+	if ts, err := c.Get(slot); err == nil {
+		return ts, false, nil
+	}
+	start := slot - min(slot, EstimateWindowSlots)
+	end := slot + EstimateWindowSlots
+	if end < slot {
+		end = ^uint64(0)
+	}
+	known := c.GetRange(start, end)
+	if len(known) == 0 {
+		return time.Time{}, false, fmt.Errorf("no block times within %d slots of %d", EstimateWindowSlots, slot)
+	}
+
+	var below, above, first, last uint64
+	var haveBelow, haveAbove bool
+	first, last = ^uint64(0), 0
+	for s := range known {
+		first, last = min(first, s), max(last, s)
+		if s < slot && (!haveBelow || s > below) {
+			below, haveBelow = s, true
+		}
+		if s > slot && (!haveAbove || s < above) {
+			above, haveAbove = s, true
+		}
+	}
+
+	if haveBelow && haveAbove {
+		span := known[above].Sub(known[below])
+		offset := time.Duration(int64(span) * int64(slot-below) / int64(above-below))
+		return known[below].Add(offset).UTC(), true, nil
+	}
+
+	perSlot := DefaultSlotDuration
+	if last > first {
+		if observed := known[last].Sub(known[first]) / time.Duration(last-first); observed > 0 {
+			perSlot = observed
+		}
+	}
+	if haveBelow {
+		return known[below].Add(perSlot * time.Duration(slot-below)).UTC(), true, nil
+	}
+	return known[above].Add(-perSlot * time.Duration(above-slot)).UTC(), true, nil
+}
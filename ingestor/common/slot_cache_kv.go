@@ -0,0 +1,428 @@
+package common
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	envSlotCacheBucket   = "SLOT_CACHE_BUCKET"
+	envSlotCacheMaxSlots = "SLOT_CACHE_MAX_SLOTS"
+	envSlotCacheReplicas = "SLOT_CACHE_REPLICAS"
+
+	// Roughly two days of slots.
+	defaultSlotCacheMaxSlots = 432_000
+
+	replayMarkerKey = "replay_marker"
+)
+
+// KVSlotCacheConfig configures a KVSlotTimeCache.
+type KVSlotCacheConfig struct {
+	// Bucket is the JetStream key-value bucket, created on first use.
+	Bucket string
+	// MaxSlots is the number of most recent slots retained. Every process
+	// sharing a bucket should use the same value.
+	MaxSlots int
+	// Replicas is the bucket replication factor when it is created.
+	Replicas int
+}
+
+// DefaultKVSlotCacheConfig returns the defaults for optional fields.
+func DefaultKVSlotCacheConfig() KVSlotCacheConfig {
+	return KVSlotCacheConfig{
+		MaxSlots: defaultSlotCacheMaxSlots,
+		Replicas: 1,
+	}
+}
+
+// Validate ensures the configuration is usable.
+func (c KVSlotCacheConfig) Validate() error {
+	if c.Bucket == "" {
+		return errors.New("slot cache bucket is required")
+	}
+	if c.MaxSlots <= 0 {
+		return errors.New("slot cache max slots must be positive")
+	}
+	if c.Replicas <= 0 {
+		return errors.New("slot cache replicas must be positive")
+	}
+	return nil
+}
+
+// KVSlotCacheConfigFromEnv loads the shared slot cache configuration. It
+// returns nil when SLOT_CACHE_BUCKET is unset.
+func KVSlotCacheConfigFromEnv() (*KVSlotCacheConfig, error) {
+	bucket := os.Getenv(envSlotCacheBucket)
+	if bucket == "" {
+		return nil, nil
+	}
+	cfg := DefaultKVSlotCacheConfig()
+	cfg.Bucket = bucket
+	if v := os.Getenv(envSlotCacheMaxSlots); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", envSlotCacheMaxSlots, err)
+		}
+		cfg.MaxSlots = n
+	}
+	if v := os.Getenv(envSlotCacheReplicas); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", envSlotCacheReplicas, err)
+		}
+		cfg.Replicas = n
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// SlotTimeCacheFromEnv opens the shared cache configured by SLOT_CACHE_BUCKET
+// on js, or returns a process-local MemorySlotTimeCache when it is unset.
+func SlotTimeCacheFromEnv(js nats.JetStreamContext) (SlotTimeCache, error) {
+	cfg, err := KVSlotCacheConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		return NewMemorySlotTimeCache(), nil
+	}
+	return NewKVSlotTimeCache(js, *cfg)
+}
+
+// KVSlotTimeCache is a SlotTimeCache stored in a JetStream key-value bucket,
+// so slot timestamps survive restarts and every process opening the bucket
+// sees the same values. Each process mirrors the bucket into a sorted
+// in-memory index through a watcher: reads never leave the process and range
+// scans only visit slots that are present.
+//
+// Retention is by count. Once more than MaxSlots slots are held the oldest are
+// dropped locally and the bucket's stream is purged up to the oldest revision
+// still referenced, which removes expired values without delete markers.
+type KVSlotTimeCache struct {
+	cfg     KVSlotCacheConfig
+	js      nats.JetStreamContext
+	kv      nats.KeyValue
+	watcher nats.KeyWatcher
+	done    chan struct{}
+
+	mu           sync.RWMutex
+	entries      map[uint64]slotEntry
+	order        []uint64
+	replayMarker uint64
+	markerRev    uint64
+}
+
+type slotEntry struct {
+	ts time.Time
+	// rev is the bucket revision holding the value; zero when the write
+	// failed and the entry only exists locally.
+	rev uint64
+}
+
+// NewKVSlotTimeCache opens (creating if needed) the bucket and loads its
+// current contents before returning.
+func NewKVSlotTimeCache(js nats.JetStreamContext, cfg KVSlotCacheConfig) (*KVSlotTimeCache, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	kv, err := js.KeyValue(cfg.Bucket)
+	if errors.Is(err, nats.ErrBucketNotFound) {
+		kv, err = js.CreateKeyValue(&nats.KeyValueConfig{
+			Bucket:      cfg.Bucket,
+			Description: "Solana slot timestamps",
+			History:     1,
+			Storage:     nats.FileStorage,
+			Replicas:    cfg.Replicas,
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open slot cache bucket %s: %w", cfg.Bucket, err)
+	}
+	watcher, err := kv.WatchAll()
+	if err != nil {
+		return nil, fmt.Errorf("watch slot cache bucket %s: %w", cfg.Bucket, err)
+	}
+
+	c := &KVSlotTimeCache{
+		cfg:     cfg,
+		js:      js,
+		kv:      kv,
+		watcher: watcher,
+		done:    make(chan struct{}),
+		entries: make(map[uint64]slotEntry),
+	}
+	// The watcher replays every current value, then sends nil.
+	for entry := range watcher.Updates() {
+		if entry == nil {
+			c.mu.Lock()
+			c.trimLocked(0)
+			c.mu.Unlock()
+			go c.watch()
+			return c, nil
+		}
+		c.mu.Lock()
+		c.applyLocked(entry)
+		c.mu.Unlock()
+	}
+	return nil, fmt.Errorf("watch slot cache bucket %s: updates closed during load", cfg.Bucket)
+}
+
+// Close stops following the bucket.
+func (c *KVSlotTimeCache) Close() error {
+	err := c.watcher.Stop()
+	<-c.done
+	return err
+}
+
+// Get retrieves the timestamp for a given slot
+func (c *KVSlotTimeCache) Get(slot uint64) (time.Time, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	e, ok := c.entries[slot]
+	if !ok {
+		return time.Time{}, fmt.Errorf("slot %d not found in cache", slot)
+	}
+	return e.ts, nil
+}
+
+// Set stores a slot-to-timestamp mapping. A failed bucket write is logged and
+// the value is still kept locally.
+func (c *KVSlotTimeCache) Set(slot uint64, timestamp time.Time) {
+	rev, err := c.kv.Put(slotKey(slot), encodeSlotTime(timestamp))
+	if err != nil {
+		log.Printf("slot cache: put slot %d: %v", slot, err)
+	}
+	c.mu.Lock()
+	c.putLocked(slot, slotEntry{ts: timestamp.UTC(), rev: rev}, true)
+	compact := c.trimLocked(c.cfg.MaxSlots / 16)
+	c.mu.Unlock()
+	if compact {
+		c.compact()
+	}
+}
+
+// Delete removes the mapping for a slot from the bucket
+func (c *KVSlotTimeCache) Delete(slot uint64) {
+	if err := c.kv.Delete(slotKey(slot)); err != nil {
+		log.Printf("slot cache: delete slot %d: %v", slot, err)
+	}
+	c.mu.Lock()
+	c.removeLocked(slot)
+	c.mu.Unlock()
+}
+
+// GetRange retrieves timestamps for a range of slots
+func (c *KVSlotTimeCache) GetRange(startSlot, endSlot uint64) map[uint64]time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make(map[uint64]time.Time)
+	i, _ := slices.BinarySearch(c.order, startSlot)
+	for ; i < len(c.order) && c.order[i] <= endSlot; i++ {
+		result[c.order[i]] = c.entries[c.order[i]].ts
+	}
+	return result
+}
+
+// Estimate returns the cached or estimated timestamp for a slot
+func (c *KVSlotTimeCache) Estimate(slot uint64) (time.Time, bool, error) {
+	return estimateSlotTime(c, slot)
+}
+
+// SetReplayMarker marks a slot as the replay boundary
+func (c *KVSlotTimeCache) SetReplayMarker(slot uint64) {
+	rev, err := c.kv.Put(replayMarkerKey, binary.BigEndian.AppendUint64(nil, slot))
+	if err != nil {
+		log.Printf("slot cache: put replay marker: %v", err)
+	}
+	c.mu.Lock()
+	c.replayMarker, c.markerRev = slot, rev
+	c.mu.Unlock()
+}
+
+// GetReplayMarker returns the current replay marker slot
+func (c *KVSlotTimeCache) GetReplayMarker() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.replayMarker
+}
+
+// IsReplaySlot returns true if the given slot is at or after the replay marker
+func (c *KVSlotTimeCache) IsReplaySlot(slot uint64) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.replayMarker > 0 && slot >= c.replayMarker
+}
+
+// Clear removes all entries and the replay marker from the bucket
+func (c *KVSlotTimeCache) Clear() {
+	c.mu.Lock()
+	slots := c.order
+	c.entries = make(map[uint64]slotEntry)
+	c.order = nil
+	c.replayMarker, c.markerRev = 0, 0
+	c.mu.Unlock()
+
+	for _, slot := range slots {
+		if err := c.kv.Delete(slotKey(slot)); err != nil {
+			log.Printf("slot cache: delete slot %d: %v", slot, err)
+		}
+	}
+	if err := c.kv.Delete(replayMarkerKey); err != nil {
+		log.Printf("slot cache: delete replay marker: %v", err)
+	}
+}
+
+// Size returns the number of entries in the cache
+func (c *KVSlotTimeCache) Size() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.order)
+}
+
+// PruneBeforeSlot removes all entries with slot numbers less than the given
+// slot from the bucket
+func (c *KVSlotTimeCache) PruneBeforeSlot(slot uint64) int {
+	c.mu.Lock()
+	n, _ := slices.BinarySearch(c.order, slot)
+	pruned := slices.Clone(c.order[:n])
+	for _, s := range pruned {
+		delete(c.entries, s)
+	}
+	c.order = slices.Delete(c.order, 0, n)
+	c.mu.Unlock()
+
+	for _, s := range pruned {
+		if err := c.kv.Delete(slotKey(s)); err != nil {
+			log.Printf("slot cache: delete slot %d: %v", s, err)
+		}
+	}
+	return len(pruned)
+}
+
+func (c *KVSlotTimeCache) watch() {
+	defer close(c.done)
+	for entry := range c.watcher.Updates() {
+		if entry == nil {
+			continue
+		}
+		c.mu.Lock()
+		c.applyLocked(entry)
+		compact := c.trimLocked(c.cfg.MaxSlots / 16)
+		c.mu.Unlock()
+		if compact {
+			c.compact()
+		}
+	}
+}
+
+func (c *KVSlotTimeCache) applyLocked(entry nats.KeyValueEntry) {
+	put := entry.Operation() == nats.KeyValuePut
+	if entry.Key() == replayMarkerKey {
+		if entry.Revision() < c.markerRev {
+			return
+		}
+		c.replayMarker, c.markerRev = 0, entry.Revision()
+		if put && len(entry.Value()) == 8 {
+			c.replayMarker = binary.BigEndian.Uint64(entry.Value())
+		}
+		return
+	}
+	slot, err := strconv.ParseUint(entry.Key(), 10, 64)
+	if err != nil {
+		return
+	}
+	if !put {
+		if e, ok := c.entries[slot]; ok && e.rev < entry.Revision() {
+			c.removeLocked(slot)
+		}
+		return
+	}
+	if len(entry.Value()) != 8 {
+		return
+	}
+	ts := time.Unix(0, int64(binary.BigEndian.Uint64(entry.Value()))).UTC()
+	c.putLocked(slot, slotEntry{ts: ts, rev: entry.Revision()}, false)
+}
+
+// putLocked stores e unless a newer revision of the slot is already held.
+// Local writes always win.
+func (c *KVSlotTimeCache) putLocked(slot uint64, e slotEntry, local bool) {
+	if prev, ok := c.entries[slot]; ok {
+		if !local && prev.rev > e.rev {
+			return
+		}
+		c.entries[slot] = e
+		return
+	}
+	c.entries[slot] = e
+	// Slots mostly arrive in order, making this an append.
+	i, _ := slices.BinarySearch(c.order, slot)
+	c.order = slices.Insert(c.order, i, slot)
+}
+
+func (c *KVSlotTimeCache) removeLocked(slot uint64) {
+	if _, ok := c.entries[slot]; !ok {
+		return
+	}
+	delete(c.entries, slot)
+	if i, ok := slices.BinarySearch(c.order, slot); ok {
+		c.order = slices.Delete(c.order, i, i+1)
+	}
+}
+
+// trimLocked drops the oldest slots once the cache exceeds MaxSlots by more
+// than slack, reporting whether anything was dropped. The slack batches the
+// work so a full cache does not shift its index on every write.
+func (c *KVSlotTimeCache) trimLocked(slack int) bool {
+	if len(c.order) <= c.cfg.MaxSlots+slack {
+		return false
+	}
+	n := len(c.order) - c.cfg.MaxSlots
+	for _, slot := range c.order[:n] {
+		delete(c.entries, slot)
+	}
+	c.order = slices.Delete(c.order, 0, n)
+	return true
+}
+
+// compact purges every message older than the oldest revision still held.
+// Those are values of dropped slots, superseded values and delete markers;
+// watchers are not notified, so other processes are unaffected.
+func (c *KVSlotTimeCache) compact() {
+	c.mu.RLock()
+	oldest := c.markerRev
+	for _, e := range c.entries {
+		if e.rev != 0 && (oldest == 0 || e.rev < oldest) {
+			oldest = e.rev
+		}
+	}
+	c.mu.RUnlock()
+	if oldest <= 1 {
+		return
+	}
+	if err := c.js.PurgeStream("KV_"+c.cfg.Bucket, &nats.StreamPurgeRequest{Sequence: oldest}); err != nil {
+		log.Printf("slot cache: compact bucket %s: %v", c.cfg.Bucket, err)
+	}
+}
+
+// slotKey zero-pads the slot so keys sort numerically.
+func slotKey(slot uint64) string {
+	return fmt.Sprintf("%020d", slot)
+}
+
+func encodeSlotTime(ts time.Time) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(ts.UnixNano()))
+}
@@ -0,0 +1,131 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+func runJetStream(t *testing.T) string {
+	t.Helper()
+	opts := &server.Options{JetStream: true, Host: "127.0.0.1", Port: -1, StoreDir: t.TempDir()}
+	srv, err := server.NewServer(opts)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	go srv.Start()
+	if !srv.ReadyForConnections(10 * time.Second) {
+		srv.Shutdown()
+		t.Skip("nats-server not ready in sandbox")
+	}
+	t.Cleanup(srv.Shutdown)
+	return srv.ClientURL()
+}
+
+func openKVCache(t *testing.T, url string, maxSlots int) *KVSlotTimeCache {
+	t.Helper()
+	nc, err := nats.Connect(url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(nc.Close)
+	js, err := nc.JetStream()
+	if err != nil {
+		t.Fatalf("jetstream: %v", err)
+	}
+	cfg := DefaultKVSlotCacheConfig()
+	cfg.Bucket = "slot_times"
+	cfg.MaxSlots = maxSlots
+	cache, err := NewKVSlotTimeCache(js, cfg)
+	if err != nil {
+		t.Fatalf("open cache: %v", err)
+	}
+	t.Cleanup(func() { _ = cache.Close() })
+	return cache
+}
+
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestKVSlotTimeCache_SharedAcrossProcesses(t *testing.T) {
+	url := runJetStream(t)
+	a := openKVCache(t, url, 1000)
+	b := openKVCache(t, url, 1000)
+
+	base := time.Unix(1_700_000_000, 0).UTC()
+	a.Set(100, base)
+	a.Set(105, base.Add(2*time.Second))
+	b.Set(102, base.Add(time.Second))
+	a.SetReplayMarker(101)
+
+	for name, c := range map[string]*KVSlotTimeCache{"a": a, "b": b} {
+		eventually(t, name+" to see every write", func() bool {
+			return c.Size() == 3 && c.GetReplayMarker() == 101
+		})
+		got := c.GetRange(101, 200)
+		if len(got) != 2 || !got[102].Equal(base.Add(time.Second)) || !got[105].Equal(base.Add(2*time.Second)) {
+			t.Fatalf("%s: GetRange = %v", name, got)
+		}
+		if !c.IsReplaySlot(102) || c.IsReplaySlot(100) {
+			t.Fatalf("%s: replay marker not applied", name)
+		}
+	}
+
+	b.Delete(100)
+	eventually(t, "delete to propagate", func() bool {
+		_, err := a.Get(100)
+		return err != nil
+	})
+
+	// A restarted process loads the bucket's contents.
+	c := openKVCache(t, url, 1000)
+	if ts, err := c.Get(105); err != nil || !ts.Equal(base.Add(2*time.Second)) {
+		t.Fatalf("Get(105) after restart = %v, %v", ts, err)
+	}
+	if c.Size() != 2 || c.GetReplayMarker() != 101 {
+		t.Fatalf("restarted cache size = %d, marker = %d", c.Size(), c.GetReplayMarker())
+	}
+}
+
+func TestKVSlotTimeCache_Retention(t *testing.T) {
+	url := runJetStream(t)
+	cache := openKVCache(t, url, 32)
+
+	base := time.Unix(1_700_000_000, 0).UTC()
+	for slot := uint64(1); slot <= 100; slot++ {
+		cache.Set(slot, base.Add(time.Duration(slot)*time.Second))
+	}
+	// Trimming is batched, so up to MaxSlots/16 extra slots are held.
+	if size := cache.Size(); size < 32 || size > 34 {
+		t.Fatalf("size = %d, want 32..34", size)
+	}
+	if _, err := cache.Get(100); err != nil {
+		t.Fatalf("newest slot dropped: %v", err)
+	}
+	if _, err := cache.Get(50); err == nil {
+		t.Fatal("old slot retained")
+	}
+
+	// Compaction removed the dropped slots from the bucket itself.
+	restarted := openKVCache(t, url, 32)
+	if size := restarted.Size(); size < 32 || size > 34 {
+		t.Fatalf("restarted size = %d, want 32..34", size)
+	}
+
+	if pruned := cache.PruneBeforeSlot(90); pruned == 0 {
+		t.Fatal("PruneBeforeSlot removed nothing")
+	}
+	if got := cache.GetRange(0, 100); len(got) != 11 {
+		t.Fatalf("GetRange after prune = %d entries, want 11", len(got))
+	}
+}
@@ -221,3 +221,49 @@ func TestMemorySlotTimeCache_Concurrent(t *testing.T) {
 
 	// If we reach here without data races, test passes
 }
+
+func TestMemorySlotTimeCache_Estimate(t *testing.T) {
+	cache := NewMemorySlotTimeCache()
+
+	if _, _, err := cache.Estimate(100); err == nil {
+		t.Fatal("Expected error when no nearby block times are cached")
+	}
+
+	base := time.Unix(1_700_000_000, 0).UTC()
+	cache.Set(100, base)
+	cache.Set(110, base.Add(5*time.Second))
+
+	tests := []struct {
+		name      string
+		slot      uint64
+		want      time.Time
+		estimated bool
+	}{
+		{"known", 100, base, false},
+		{"interpolated", 104, base.Add(2 * time.Second), true},
+		// Extrapolated at the observed 500ms per slot.
+		{"after", 120, base.Add(10 * time.Second), true},
+		{"before", 96, base.Add(-2 * time.Second), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, estimated, err := cache.Estimate(tt.slot)
+			if err != nil {
+				t.Fatalf("Estimate(%d) error: %v", tt.slot, err)
+			}
+			if !ts.Equal(tt.want) || estimated != tt.estimated {
+				t.Errorf("Estimate(%d) = %v, %v; want %v, %v", tt.slot, ts, estimated, tt.want, tt.estimated)
+			}
+		})
+	}
+
+	// A single known slot extrapolates at the nominal slot duration.
+	single := NewMemorySlotTimeCache()
+	single.Set(100, base)
+	if ts, _, err := single.Estimate(105); err != nil || !ts.Equal(base.Add(5*DefaultSlotDuration)) {
+		t.Errorf("Estimate from a single slot = %v, %v", ts, err)
+	}
+	if _, _, err := single.Estimate(100 + EstimateWindowSlots + 1); err == nil {
+		t.Error("Expected error outside the estimate window")
+	}
+}
@@ -0,0 +1,35 @@
+package common
+
+import (
+	dexcommon "github.com/rexbrahh/lp-indexer/decoder/common"
+
+	pb "github.com/rpcpool/yellowstone-grpc/examples/golang/proto"
+)
+
+// Token2022MintFilterName is the account subscription key used for Token-2022
+// mint updates.
+const Token2022MintFilterName = "token2022_mints"
+
+// Token2022MintFilter subscribes to the given mints when they are Token-2022
+// mint accounts, so the decoder can track their transfer fee extension. It
+// returns nil when mints is empty: filtering on the owner alone would stream
+// every Token-2022 account.
+func Token2022MintFilter(mints []string) *pb.SubscribeRequestFilterAccounts {
+	if len(mints) == 0 {
+		return nil
+	}
+	return &pb.SubscribeRequestFilterAccounts{
+		Account: mints,
+		Owner:   []string{dexcommon.Token2022ProgramID},
+		Filters: []*pb.SubscribeRequestFilterAccountsFilter{
+			{
+				Filter: &pb.SubscribeRequestFilterAccountsFilter_Memcmp{
+					Memcmp: &pb.SubscribeRequestFilterAccountsFilterMemcmp{
+						Offset: dexcommon.MintAccountTypeOffset,
+						Data:   &pb.SubscribeRequestFilterAccountsFilterMemcmp_Bytes{Bytes: []byte{1}},
+					},
+				},
+			},
+		},
+	}
+}
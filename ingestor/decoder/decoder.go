@@ -7,6 +7,7 @@ import (
 
 	"github.com/mr-tron/base58/base58"
 
+	dexcommon "github.com/rexbrahh/lp-indexer/decoder/common"
 	meteora "github.com/rexbrahh/lp-indexer/decoder/meteora"
 	orcawhirlpool "github.com/rexbrahh/lp-indexer/decoder/orca_whirlpool"
 	ray "github.com/rexbrahh/lp-indexer/decoder/raydium"
@@ -24,9 +25,15 @@ const chainIDSolana = 501
 type Decoder struct {
 	slotCache  common.SlotTimeCache
 	poolConfig map[string]string
-	poolFees   map[string]uint16
-	configFees map[string]uint16
+	poolFees   map[string]*poolmeta.AmmConfig
+	configFees map[string]*poolmeta.AmmConfig
 	orcaPools  map[string]*poolmeta.OrcaPoolInfo
+	tokenMints map[string]*dexcommon.TokenMint
+
+	// On-demand account loading; see loader.go.
+	loader      AccountLoader
+	loadTimeout time.Duration
+	loadFailed  map[string]time.Time
 }
 
 // New constructs a decoder using the provided slot cache. When cache is nil a
@@ -38,9 +45,11 @@ func New(cache common.SlotTimeCache) *Decoder {
 	return &Decoder{
 		slotCache:  cache,
 		poolConfig: make(map[string]string),
-		poolFees:   make(map[string]uint16),
-		configFees: make(map[string]uint16),
+		poolFees:   make(map[string]*poolmeta.AmmConfig),
+		configFees: make(map[string]*poolmeta.AmmConfig),
 		orcaPools:  make(map[string]*poolmeta.OrcaPoolInfo),
+		tokenMints: make(map[string]*dexcommon.TokenMint),
+		loadFailed: make(map[string]time.Time),
 	}
 }
 
@@ -61,16 +70,16 @@ func (d *Decoder) HandleBlockMeta(meta *pb.SubscribeUpdateBlockMeta) {
 }
 
 // HandleAccount indexes account data used to enrich swap decoding (e.g. pool
-// configuration, fee rates, and Orca pool metadata).
+// configuration, fee rates, Orca pool metadata, and Token-2022 mint extensions).
 func (d *Decoder) HandleAccount(account *pb.SubscribeUpdateAccount) {
 	if account == nil || account.Account == nil {
 		return
 	}
 	info := account.Account
-	owner := base58.Encode(info.GetOwner())
-	pubkey := base58.Encode(info.GetPubkey())
-	data := info.GetData()
+	d.applyAccount(base58.Encode(info.GetPubkey()), base58.Encode(info.GetOwner()), info.GetData())
+}
 
+func (d *Decoder) applyAccount(pubkey, owner string, data []byte) {
 	switch owner {
 	case ray.ProgramID:
 		d.handleRaydiumAccount(pubkey, data)
@@ -78,12 +87,17 @@ func (d *Decoder) HandleAccount(account *pb.SubscribeUpdateAccount) {
 		if poolInfo, err := poolmeta.DecodeOrcaPool(data); err == nil {
 			d.orcaPools[pubkey] = poolInfo
 		}
+	case dexcommon.Token2022ProgramID:
+		if mint, err := dexcommon.DecodeTokenMint(data); err == nil {
+			d.tokenMints[pubkey] = mint
+		}
 	}
 }
 
 // DecodeTransaction inspects the provided transaction update and returns any
-// decoded swap events (Raydium, Orca Whirlpool, Meteora). When decoding fails
-// for a recognised program a *DecodeError is returned.
+// decoded swap events (Raydium, Orca Whirlpool, Meteora). Failed transactions
+// yield no events (see DecodeFailedAttempts). When decoding fails for a
+// recognised program a *DecodeError is returned.
 func (d *Decoder) DecodeTransaction(tx *pb.SubscribeUpdateTransaction) ([]*dexv1.SwapEvent, error) {
 	if tx == nil {
 		return nil, nil
@@ -95,7 +109,7 @@ func (d *Decoder) DecodeTransaction(tx *pb.SubscribeUpdateTransaction) ([]*dexv1
 	}
 
 	meta := info.GetMeta()
-	if meta == nil {
+	if meta == nil || meta.GetErr() != nil {
 		return nil, nil
 	}
 
@@ -109,59 +123,156 @@ func (d *Decoder) DecodeTransaction(tx *pb.SubscribeUpdateTransaction) ([]*dexv1
 		return nil, nil
 	}
 
-	accountStrs := make([]string, len(message.GetAccountKeys()))
-	for i, key := range message.GetAccountKeys() {
-		accountStrs[i] = base58.Encode(key)
+	tc := &txContext{
+		signature:   encodeSignature(txMsg.GetSignatures()),
+		slot:        tx.GetSlot(),
+		index:       info.GetIndex(),
+		accountStrs: transactionAccountKeys(message, meta),
+		numSigners:  message.GetHeader().GetNumRequiredSignatures(),
+		meta:        meta,
+		vaults:      extractVaultBalances(meta),
+		token2022:   token2022Mints(meta),
 	}
+	tc.timestamp = lookupSlotTimestamp(d.slotCache, tc.slot)
 
-	vaults := extractVaultBalances(meta)
-	signature := encodeSignature(txMsg.GetSignatures())
-	slot := tx.GetSlot()
-	timestamp := lookupSlotTimestamp(d.slotCache, slot)
-	index := info.GetIndex()
+	inner := make(map[uint32][]*pb.InnerInstruction, len(meta.GetInnerInstructions()))
+	for _, group := range meta.GetInnerInstructions() {
+		inner[group.GetIndex()] = append(inner[group.GetIndex()], group.GetInstructions()...)
+	}
 
 	var events []*dexv1.SwapEvent
 
-	for _, instr := range message.GetInstructions() {
-		programIdx := int(instr.GetProgramIdIndex())
-		if programIdx >= len(accountStrs) {
-			continue
+	for i, instr := range message.GetInstructions() {
+		evs, err := d.decodeInstruction(tc, instr)
+		if err != nil {
+			return nil, err
 		}
-		programID := accountStrs[programIdx]
-
-		switch programID {
-		case ray.ProgramID:
-			ev, err := d.buildRaydiumSwap(signature, slot, timestamp, index, instr, accountStrs, vaults)
+		for _, ev := range evs {
+			ev.InstructionIndex = uint32(i)
+		}
+		events = append(events, evs...)
+
+		// Aggregators (e.g. Jupiter) reach the pools through CPI, so their legs
+		// only appear as inner instructions. A DEX calling back into itself is
+		// already covered by the top-level decode.
+		topProgram := tc.programID(instr.GetProgramIdIndex())
+		for j, innerInstr := range inner[uint32(i)] {
+			if tc.programID(innerInstr.GetProgramIdIndex()) == topProgram {
+				continue
+			}
+			evs, err := d.decodeInstruction(tc, &pb.CompiledInstruction{
+				ProgramIdIndex: innerInstr.GetProgramIdIndex(),
+				Accounts:       innerInstr.GetAccounts(),
+				Data:           innerInstr.GetData(),
+			})
 			if err != nil {
-				return nil, &DecodeError{Program: ray.ProgramID, Err: err}
+				return nil, err
 			}
-			if ev != nil {
-				events = append(events, ev)
+			for _, ev := range evs {
+				ev.InstructionIndex = uint32(i)
+				ev.Inner = true
+				ev.InnerInstructionIndex = uint32(j)
 			}
-		case orcawhirlpool.WhirlpoolProgramID:
-			ev, err := d.buildOrcaSwap(signature, slot, timestamp, index, instr, accountStrs, vaults)
+			events = append(events, evs...)
+		}
+	}
+
+	return events, nil
+}
+
+// txContext carries the per-transaction state shared by instruction decoders.
+type txContext struct {
+	signature   string
+	slot        uint64
+	timestamp   int64
+	index       uint64
+	accountStrs []string
+	numSigners  uint32
+	meta        *pb.TransactionStatusMeta
+	vaults      map[string][]*tokenBalance
+	// token2022 holds the mints the token balances report as Token-2022.
+	token2022 map[string]bool
+}
+
+func (tc *txContext) programID(idx uint32) string {
+	if int(idx) >= len(tc.accountStrs) {
+		return ""
+	}
+	return tc.accountStrs[idx]
+}
+
+// decodeInstruction decodes a single swap instruction into one event per pool
+// it traded against, returning nil when the program is not a supported DEX or
+// the instruction moved no pool balances.
+func (d *Decoder) decodeInstruction(tc *txContext, instr *pb.CompiledInstruction) ([]*dexv1.SwapEvent, error) {
+	programID := tc.programID(instr.GetProgramIdIndex())
+
+	var (
+		events []*dexv1.SwapEvent
+		err    error
+	)
+	switch programID {
+	case "":
+		return nil, nil
+	case ray.ProgramID:
+		var ev *dexv1.SwapEvent
+		ev, err = d.buildRaydiumSwap(tc.signature, tc.slot, tc.timestamp, tc.index, instr, tc.accountStrs, tc.vaults)
+		if ev != nil {
+			events = append(events, ev)
+		}
+	case orcawhirlpool.WhirlpoolProgramID:
+		for hop, pos := range orcawhirlpool.SwapPoolAccounts(instr.GetData()) {
+			var ev *dexv1.SwapEvent
+			ev, err = d.buildOrcaSwap(tc.signature, tc.slot, tc.timestamp, tc.index, instr, pos, tc.accountStrs, tc.vaults)
 			if err != nil {
-				return nil, &DecodeError{Program: orcawhirlpool.WhirlpoolProgramID, Err: err}
+				break
 			}
 			if ev != nil {
+				ev.Hop = uint32(hop)
 				events = append(events, ev)
 			}
-		default:
-			if kind, ok := meteora.ProgramKindForID(programID); ok {
-				ev, err := d.buildMeteoraSwap(signature, slot, timestamp, index, instr, accountStrs, meta, programID, kind)
-				if err != nil {
-					return nil, &DecodeError{Program: programID, Err: err}
-				}
-				if ev != nil {
-					events = append(events, ev)
-				}
-			}
 		}
+	default:
+		kind, ok := meteora.ProgramKindForID(programID)
+		if !ok {
+			return nil, nil
+		}
+		var ev *dexv1.SwapEvent
+		ev, err = d.buildMeteoraSwap(tc.signature, tc.slot, tc.timestamp, tc.index, instr, tc.accountStrs, tc.meta, programID, kind)
+		if ev != nil {
+			events = append(events, ev)
+		}
+	}
+	if err != nil {
+		return nil, &DecodeError{Program: programID, Err: err}
 	}
 
+	for _, ev := range events {
+		attributeTrader(ev, instr, tc.accountStrs, tc.numSigners, tc.vaults)
+		if err := d.normalizeSwap(ev, tc.token2022); err != nil {
+			return nil, &DecodeError{Program: programID, Err: err}
+		}
+	}
 	return events, nil
 }
 
+// transactionAccountKeys returns the full account list of a transaction: the
+// static message keys followed by writable and readonly keys loaded from
+// address lookup tables.
+func transactionAccountKeys(message *pb.Message, meta *pb.TransactionStatusMeta) []string {
+	keys := make([]string, 0, len(message.GetAccountKeys())+len(meta.GetLoadedWritableAddresses())+len(meta.GetLoadedReadonlyAddresses()))
+	for _, key := range message.GetAccountKeys() {
+		keys = append(keys, base58.Encode(key))
+	}
+	for _, key := range meta.GetLoadedWritableAddresses() {
+		keys = append(keys, base58.Encode(key))
+	}
+	for _, key := range meta.GetLoadedReadonlyAddresses() {
+		keys = append(keys, base58.Encode(key))
+	}
+	return keys
+}
+
 // DecodeError annotates decode failures with the program identifier.
 type DecodeError struct {
 	Program string
@@ -184,6 +295,24 @@ func (e *DecodeError) Unwrap() error {
 
 // --- internal helpers ---
 
+// normalizeSwap applies the steps shared by every DEX: canonical base/quote
+// ordering (with pair_id), Token-2022 transfer fee accounting and the Q32.32
+// execution price.
+func (d *Decoder) normalizeSwap(ev *dexv1.SwapEvent, token2022 map[string]bool) error {
+	if _, err := dexcommon.CanonicalizeSwap(ev); err != nil {
+		return fmt.Errorf("canonicalize pair: %w", err)
+	}
+	d.applyTransferFees(ev, token2022)
+
+	// Only one side of each leg is populated, so the sums are the traded amounts.
+	base := ev.GetBaseIn() + ev.GetBaseOut()
+	quote := ev.GetQuoteIn() + ev.GetQuoteOut()
+	if price, err := dexcommon.ExecutionPriceQ32(base, quote, uint8(ev.GetDecBase()), uint8(ev.GetDecQuote())); err == nil {
+		ev.PriceQ32 = price
+	}
+	return nil
+}
+
 func (d *Decoder) handleRaydiumAccount(pubkey string, data []byte) {
 	if poolmeta.HasPoolDiscriminator(data) {
 		if cfg, err := poolmeta.DecodeRaydiumPool(data); err == nil {
@@ -197,12 +326,11 @@ func (d *Decoder) handleRaydiumAccount(pubkey string, data []byte) {
 	}
 
 	if poolmeta.HasAmmConfigDiscriminator(data) {
-		if tradeRate, err := poolmeta.DecodeAmmConfig(data); err == nil {
-			feeBps := uint16(tradeRate / 100)
-			d.configFees[pubkey] = feeBps
+		if ammConfig, err := poolmeta.DecodeAmmConfig(data); err == nil {
+			d.configFees[pubkey] = ammConfig
 			for pool, cfg := range d.poolConfig {
 				if cfg == pubkey {
-					d.poolFees[pool] = feeBps
+					d.poolFees[pool] = ammConfig
 				}
 			}
 			return
@@ -220,12 +348,11 @@ func (d *Decoder) handleRaydiumAccount(pubkey string, data []byte) {
 		}
 	}
 
-	if tradeRate, err := poolmeta.DecodeAmmConfig(data); err == nil {
-		feeBps := uint16(tradeRate / 100)
-		d.configFees[pubkey] = feeBps
+	if ammConfig, err := poolmeta.DecodeAmmConfig(data); err == nil {
+		d.configFees[pubkey] = ammConfig
 		for pool, cfg := range d.poolConfig {
 			if cfg == pubkey {
-				d.poolFees[pool] = feeBps
+				d.poolFees[pool] = ammConfig
 			}
 		}
 		return
@@ -240,6 +367,23 @@ func (d *Decoder) handleRaydiumAccount(pubkey string, data []byte) {
 	}
 }
 
+// raydiumFees returns the AmmConfig of pool, loading the pool and its config
+// on demand when they have not been streamed yet.
+func (d *Decoder) raydiumFees(pool string) *poolmeta.AmmConfig {
+	if fee, ok := d.poolFees[pool]; ok {
+		return fee
+	}
+	if _, ok := d.poolConfig[pool]; !ok {
+		d.loadOnDemand(pool)
+	}
+	if configKey, ok := d.poolConfig[pool]; ok {
+		if _, ok := d.configFees[configKey]; !ok {
+			d.loadOnDemand(configKey)
+		}
+	}
+	return d.poolFees[pool]
+}
+
 func (d *Decoder) buildRaydiumSwap(signature string, slot uint64, timestamp int64, index uint64, instr *pb.CompiledInstruction, accountStrs []string, vaults map[string][]*tokenBalance) (*dexv1.SwapEvent, error) {
 	data := instr.GetData()
 	if len(data) == 0 {
@@ -279,23 +423,41 @@ func (d *Decoder) buildRaydiumSwap(signature string, slot uint64, timestamp int6
 		return nil, fmt.Errorf("parse swap event: %w", err)
 	}
 
-	feeBps := d.poolFees[pool]
-	return convertRaydiumSwap(event, slot, timestamp, signature, index, feeBps), nil
+	var feeBps uint16
+	ammConfig := d.raydiumFees(pool)
+	if ammConfig != nil {
+		feeBps = ammConfig.FeeBps()
+	}
+	msg := convertRaydiumSwap(event, slot, timestamp, signature, index, feeBps)
+	if ammConfig != nil {
+		// Fund fees leave the pool alongside protocol fees, so both count as
+		// protocol revenue rather than LP revenue.
+		fees := dexcommon.SplitSwapFee(event.AmountIn, uint64(ammConfig.TradeFeeRate), poolmeta.RaydiumFeeRateDenominator,
+			uint64(ammConfig.ProtocolFeeRate)+uint64(ammConfig.FundFeeRate), poolmeta.RaydiumFeeRateDenominator)
+		msg.LpFee = fees.LP
+		msg.ProtocolFee = fees.Protocol
+	}
+	return msg, nil
 }
 
-func (d *Decoder) buildOrcaSwap(signature string, slot uint64, timestamp int64, index uint64, instr *pb.CompiledInstruction, accountStrs []string, vaults map[string][]*tokenBalance) (*dexv1.SwapEvent, error) {
+// buildOrcaSwap decodes the leg against the whirlpool at position poolPos of
+// the instruction's accounts.
+func (d *Decoder) buildOrcaSwap(signature string, slot uint64, timestamp int64, index uint64, instr *pb.CompiledInstruction, poolPos int, accountStrs []string, vaults map[string][]*tokenBalance) (*dexv1.SwapEvent, error) {
 	accounts := instr.GetAccounts()
-	if len(accounts) < 3 {
+	if len(accounts) <= poolPos {
 		return nil, nil
 	}
-	poolIdx := int(accounts[2])
+	poolIdx := int(accounts[poolPos])
 	if poolIdx >= len(accountStrs) {
 		return nil, nil
 	}
 	poolID := accountStrs[poolIdx]
 	poolInfo, ok := d.orcaPools[poolID]
 	if !ok {
-		return nil, nil
+		d.loadOnDemand(poolID)
+		if poolInfo, ok = d.orcaPools[poolID]; !ok {
+			return nil, nil
+		}
 	}
 
 	balances := vaults[poolID]
@@ -348,6 +510,10 @@ func (d *Decoder) buildOrcaSwap(signature string, slot uint64, timestamp int64,
 		}
 	}
 
+	fees := orcawhirlpool.SwapFee(event.BaseIn+event.QuoteIn, poolInfo.FeeRate, poolInfo.ProtocolFee)
+	event.LpFee = fees.LP
+	event.ProtocolFee = fees.Protocol
+
 	return event, nil
 }
 
@@ -387,6 +553,8 @@ func (d *Decoder) buildMeteoraSwap(signature string, slot uint64, timestamp int6
 		DecBase:     event.DecBase,
 		DecQuote:    event.DecQuote,
 		FeeBps:      event.FeeBps,
+		LpFee:       event.LPFee,
+		ProtocolFee: event.ProtocolFee,
 		Provisional: true,
 	}
 
@@ -495,6 +663,20 @@ func extractVaultBalances(meta *pb.TransactionStatusMeta) map[string][]*tokenBal
 	return owners
 }
 
+// token2022Mints returns the mints whose token balances are held in
+// Token-2022 accounts.
+func token2022Mints(meta *pb.TransactionStatusMeta) map[string]bool {
+	mints := map[string]bool{}
+	for _, balances := range [][]*pb.TokenBalance{meta.GetPreTokenBalances(), meta.GetPostTokenBalances()} {
+		for _, bal := range balances {
+			if bal.GetProgramId() == dexcommon.Token2022ProgramID {
+				mints[bal.GetMint()] = true
+			}
+		}
+	}
+	return mints
+}
+
 func resolvePool(instr *pb.CompiledInstruction, accountStrs []string, vaults map[string][]*tokenBalance) (string, *tokenBalance, *tokenBalance) {
 	var pool string
 	var ordered []*tokenBalance
@@ -557,11 +739,14 @@ func encodeSignature(signatures [][]byte) string {
 	return base58.Encode(signatures[0])
 }
 
+// lookupSlotTimestamp returns the block time of slot, estimated from nearby
+// slots when its block meta has not arrived yet. It is 0 when neither is
+// possible.
 func lookupSlotTimestamp(cache common.SlotTimeCache, slot uint64) int64 {
 	if cache == nil {
 		return 0
 	}
-	ts, err := cache.Get(slot)
+	ts, _, err := cache.Estimate(slot)
 	if err != nil || ts.IsZero() {
 		return 0
 	}
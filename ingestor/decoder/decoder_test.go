@@ -1,6 +1,7 @@
 package decoder
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/binary"
 	"encoding/hex"
@@ -14,7 +15,10 @@ import (
 	"time"
 
 	"github.com/mr-tron/base58/base58"
+	"google.golang.org/protobuf/proto"
 
+	"github.com/rexbrahh/lp-indexer/decoder/anchor"
+	dexcommon "github.com/rexbrahh/lp-indexer/decoder/common"
 	orcawhirlpool "github.com/rexbrahh/lp-indexer/decoder/orca_whirlpool"
 	ray "github.com/rexbrahh/lp-indexer/decoder/raydium"
 	"github.com/rexbrahh/lp-indexer/ingestor/common"
@@ -32,6 +36,8 @@ func TestDecoder_DecodeTransaction_Raydium(t *testing.T) {
 	tradeRate := uint32(3000)
 	configKey := generateAddress(0xAA)
 	configData := buildConfigData(tradeRate)
+	binary.LittleEndian.PutUint32(configData[8+1+2+32:], 120_000)      // protocol: 12% of fee
+	binary.LittleEndian.PutUint32(configData[8+1+2+32+4+4+2:], 40_000) // fund: 4% of fee
 	dec.HandleAccount(&pb.SubscribeUpdateAccount{
 		Account: &pb.SubscribeUpdateAccountInfo{
 			Pubkey: configKey,
@@ -82,6 +88,9 @@ func TestDecoder_DecodeTransaction_Raydium(t *testing.T) {
 	if ev.FeeBps != expectedFee {
 		t.Fatalf("fee_bps=%d want %d", ev.FeeBps, expectedFee)
 	}
+	if ev.LpFee != 2_520_000 || ev.ProtocolFee != 480_000 {
+		t.Fatalf("unexpected fee split lp=%d protocol=%d", ev.LpFee, ev.ProtocolFee)
+	}
 	if ev.GetSlot() != fixture.Slot {
 		t.Fatalf("unexpected slot %d", ev.GetSlot())
 	}
@@ -207,12 +216,494 @@ func TestDecoder_DecodeTransaction_Orca(t *testing.T) {
 	if ev.FeeBps != uint32(feeRate/100) {
 		t.Fatalf("fee_bps=%d want %d", ev.FeeBps, feeRate/100)
 	}
+	if ev.LpFee != 1_750 || ev.ProtocolFee != 0 {
+		t.Fatalf("unexpected fee split lp=%d protocol=%d", ev.LpFee, ev.ProtocolFee)
+	}
 	if !ev.Provisional {
 		t.Fatal("expected provisional flag")
 	}
 	if ev.DecBase != 6 || ev.DecQuote != 6 {
 		t.Fatalf("unexpected decimals base=%d quote=%d", ev.DecBase, ev.DecQuote)
 	}
+	feePayer := base58.Encode(generateAddress(0x01))
+	if ev.FeePayer != feePayer || ev.Trader != feePayer {
+		t.Fatalf("expected trader to fall back to fee payer %s, got trader=%s fee_payer=%s", feePayer, ev.Trader, ev.FeePayer)
+	}
+}
+
+func TestDecoder_DecodeTransaction_OrcaAttributesTrader(t *testing.T) {
+	cache := common.NewMemorySlotTimeCache()
+	slot := uint64(987655)
+	cache.Set(slot, time.Unix(1_700_000_600, 0))
+
+	dec := New(cache)
+
+	poolKey := generateAddress(0x77)
+	mintA := generateAddress(0x22)
+	mintB := generateAddress(0x33)
+	vaultA := generateAddress(0x44)
+	vaultB := generateAddress(0x55)
+
+	dec.HandleAccount(&pb.SubscribeUpdateAccount{
+		Account: &pb.SubscribeUpdateAccountInfo{
+			Pubkey: poolKey,
+			Owner:  mustDecodeBase58(t, orcawhirlpool.WhirlpoolProgramID),
+			Data:   buildOrcaPoolData(t, mintA, mintB, vaultA, vaultB, 3000),
+		},
+	})
+
+	// The fee payer relays the swap for a separate signing wallet that owns
+	// the source and destination token accounts.
+	wallet := generateAddress(0x66)
+	walletStr := base58.Encode(wallet)
+	userA := generateAddress(0x67)
+	userB := generateAddress(0x68)
+
+	tx := buildOrcaTransaction(t, slot, poolKey, mintA, mintB, vaultA, vaultB, 3000)
+	msg := tx.Transaction.Transaction.Message
+	msg.Header = &pb.MessageHeader{NumRequiredSignatures: 2}
+	msg.AccountKeys = append([][]byte{msg.AccountKeys[0], wallet}, append(msg.AccountKeys[1:], userA, userB)...)
+	instr := msg.Instructions[0]
+	instr.ProgramIdIndex = 6
+	instr.Accounts = []byte{1, 2, 3, 4, 5, 7, 8}
+	meta := tx.Transaction.Meta
+	for _, bal := range append(meta.PreTokenBalances, meta.PostTokenBalances...) {
+		bal.AccountIndex++
+	}
+
+	userBalance := func(idx uint32, mint []byte, amount string) *pb.TokenBalance {
+		return &pb.TokenBalance{
+			AccountIndex:  idx,
+			Mint:          base58.Encode(mint),
+			Owner:         walletStr,
+			UiTokenAmount: &pb.UiTokenAmount{Amount: amount, Decimals: 6},
+		}
+	}
+	meta.PreTokenBalances = append(meta.PreTokenBalances, userBalance(7, mintA, "0"), userBalance(8, mintB, "900000"))
+	meta.PostTokenBalances = append(meta.PostTokenBalances, userBalance(7, mintA, "500000"), userBalance(8, mintB, "200000"))
+
+	events, err := dec.DecodeTransaction(tx)
+	if err != nil {
+		t.Fatalf("DecodeTransaction returned error: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected 1 swap event, got %d", len(events))
+	}
+	ev := events[0]
+	if ev.FeePayer != base58.Encode(generateAddress(0x01)) {
+		t.Fatalf("unexpected fee payer %s", ev.FeePayer)
+	}
+	if ev.Trader != walletStr {
+		t.Fatalf("trader=%s want %s", ev.Trader, walletStr)
+	}
+}
+
+func TestDecoder_DecodeTransaction_FailedOrcaSwap(t *testing.T) {
+	cache := common.NewMemorySlotTimeCache()
+	slot := uint64(987656)
+	cache.Set(slot, time.Unix(1_700_000_700, 0))
+
+	dec := New(cache)
+
+	poolKey := generateAddress(0x77)
+	mintA := generateAddress(0x22)
+	mintB := generateAddress(0x33)
+	vaultA := generateAddress(0x44)
+	vaultB := generateAddress(0x55)
+
+	dec.HandleAccount(&pb.SubscribeUpdateAccount{
+		Account: &pb.SubscribeUpdateAccountInfo{
+			Pubkey: poolKey,
+			Owner:  mustDecodeBase58(t, orcawhirlpool.WhirlpoolProgramID),
+			Data:   buildOrcaPoolData(t, mintA, mintB, vaultA, vaultB, 3000),
+		},
+	})
+
+	tx := buildOrcaTransaction(t, slot, poolKey, mintA, mintB, vaultA, vaultB, 3000)
+	meta := tx.Transaction.Meta
+	meta.Err = &pb.TransactionError{Err: encodeInstructionError(0, instructionErrorCustom, 6018)}
+	meta.ComputeUnitsConsumed = proto.Uint64(42_000)
+	meta.LogMessages = []string{
+		"Program log: AnchorError occurred. Error Code: AmountOutBelowMinimum. Error Number: 6018. Error Message: Amount out below minimum threshold.",
+	}
+
+	events, err := dec.DecodeTransaction(tx)
+	if err != nil {
+		t.Fatalf("DecodeTransaction returned error: %v", err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("expected failed transaction to yield no swaps, got %d", len(events))
+	}
+
+	attempts, err := dec.DecodeFailedAttempts(tx)
+	if err != nil {
+		t.Fatalf("DecodeFailedAttempts returned error: %v", err)
+	}
+	if len(attempts) != 1 {
+		t.Fatalf("expected 1 failed attempt, got %d", len(attempts))
+	}
+	attempt := attempts[0]
+	if attempt.PoolId != base58.Encode(poolKey) || attempt.ProgramId != orcawhirlpool.WhirlpoolProgramID {
+		t.Fatalf("unexpected attempt target %+v", attempt)
+	}
+	if attempt.ErrorKind != TxErrorKindCustom || attempt.ErrorCode != 6018 || attempt.ErrorName != "AmountOutBelowMinimum" {
+		t.Fatalf("unexpected attempt error %+v", attempt)
+	}
+	if !attempt.HasFailedInstruction || attempt.FailedInstruction != 0 || attempt.CuUsed != 42_000 {
+		t.Fatalf("unexpected attempt details %+v", attempt)
+	}
+	if attempt.FeePayer != base58.Encode(generateAddress(0x01)) {
+		t.Fatalf("unexpected fee payer %s", attempt.FeePayer)
+	}
+
+	ok := buildOrcaTransaction(t, slot, poolKey, mintA, mintB, vaultA, vaultB, 3000)
+	if attempts, err := dec.DecodeFailedAttempts(ok); err != nil || len(attempts) != 0 {
+		t.Fatalf("expected no attempts for successful transaction, got %d (%v)", len(attempts), err)
+	}
+}
+
+func TestDecoder_DecodeFailedAttempts_InnerInstruction(t *testing.T) {
+	slot := uint64(987658)
+	dec := New(nil)
+
+	poolKey := generateAddress(0x77)
+	mintA := generateAddress(0x22)
+	mintB := generateAddress(0x33)
+	vaultA := generateAddress(0x44)
+	vaultB := generateAddress(0x55)
+
+	// The Whirlpool swap is reached through an aggregator, so it is only
+	// visible as an inner instruction of the reverted transaction.
+	aggregator := generateAddress(0x99)
+	tx := buildOrcaTransaction(t, slot, poolKey, mintA, mintB, vaultA, vaultB, 3000)
+	msg := tx.Transaction.Transaction.Message
+	msg.AccountKeys = append(msg.AccountKeys, aggregator)
+	orcaInstr := msg.Instructions[0]
+	msg.Instructions = []*pb.CompiledInstruction{
+		{ProgramIdIndex: 0},
+		{ProgramIdIndex: 6, Accounts: []byte{0, 2, 3, 4}},
+	}
+	meta := tx.Transaction.Meta
+	meta.InnerInstructions = []*pb.InnerInstructions{{
+		Index: 1,
+		Instructions: []*pb.InnerInstruction{
+			{ProgramIdIndex: 6},
+			{ProgramIdIndex: orcaInstr.ProgramIdIndex, Accounts: orcaInstr.Accounts, Data: orcaInstr.Data},
+		},
+	}}
+	meta.Err = &pb.TransactionError{Err: encodeInstructionError(1, instructionErrorCustom, 6018)}
+
+	attempts, err := dec.DecodeFailedAttempts(tx)
+	if err != nil {
+		t.Fatalf("DecodeFailedAttempts returned error: %v", err)
+	}
+	if len(attempts) != 1 {
+		t.Fatalf("expected 1 failed attempt, got %d", len(attempts))
+	}
+	attempt := attempts[0]
+	if attempt.ProgramId != orcawhirlpool.WhirlpoolProgramID || attempt.PoolId != base58.Encode(poolKey) {
+		t.Fatalf("unexpected attempt target %+v", attempt)
+	}
+	if !attempt.Inner || attempt.InstructionIndex != 1 || attempt.InnerInstructionIndex != 1 {
+		t.Fatalf("unexpected attempt position %+v", attempt)
+	}
+}
+
+func TestDecoder_DecodeTransaction_OrcaTwoHopSwap(t *testing.T) {
+	slot := uint64(987659)
+	dec := New(nil)
+
+	poolOne, poolTwo := generateAddress(0x71), generateAddress(0x72)
+	mintA, mintB, mintC := generateAddress(0x22), generateAddress(0x33), generateAddress(0x34)
+	vaultOneA, vaultOneB := generateAddress(0x44), generateAddress(0x45)
+	vaultTwoB, vaultTwoC := generateAddress(0x46), generateAddress(0x47)
+	for _, pool := range []struct{ key, mintA, mintB, vaultA, vaultB []byte }{
+		{poolOne, mintA, mintB, vaultOneA, vaultOneB},
+		{poolTwo, mintB, mintC, vaultTwoB, vaultTwoC},
+	} {
+		dec.HandleAccount(&pb.SubscribeUpdateAccount{
+			Account: &pb.SubscribeUpdateAccountInfo{
+				Pubkey: pool.key,
+				Owner:  mustDecodeBase58(t, orcawhirlpool.WhirlpoolProgramID),
+				Data:   buildOrcaPoolData(t, pool.mintA, pool.mintB, pool.vaultA, pool.vaultB, 3000),
+			},
+		})
+	}
+
+	balance := func(idx uint32, mint, owner []byte, amount string) *pb.TokenBalance {
+		return &pb.TokenBalance{
+			AccountIndex:  idx,
+			Mint:          base58.Encode(mint),
+			Owner:         base58.Encode(owner),
+			UiTokenAmount: &pb.UiTokenAmount{Amount: amount, Decimals: 6},
+		}
+	}
+	disc := anchor.InstructionDiscriminator("twoHopSwap")
+	tx := &pb.SubscribeUpdateTransaction{
+		Slot: slot,
+		Transaction: &pb.SubscribeUpdateTransactionInfo{
+			Transaction: &pb.Transaction{
+				Signatures: [][]byte{generateSignature(0x9B)},
+				Message: &pb.Message{
+					AccountKeys: [][]byte{
+						generateAddress(0x01), generateAddress(0x02), poolOne, poolTwo,
+						vaultOneA, vaultOneB, vaultTwoB, vaultTwoC,
+						mustDecodeBase58(t, orcawhirlpool.WhirlpoolProgramID),
+					},
+					Instructions: []*pb.CompiledInstruction{{
+						ProgramIdIndex: 8,
+						Accounts:       []byte{0, 1, 2, 3, 4, 5, 6, 7},
+						Data:           append(disc[:], make([]byte, 32)...),
+					}},
+				},
+			},
+			Meta: &pb.TransactionStatusMeta{
+				PreTokenBalances: []*pb.TokenBalance{
+					balance(4, mintA, poolOne, "1000000"), balance(5, mintB, poolOne, "1000000"),
+					balance(6, mintB, poolTwo, "1000000"), balance(7, mintC, poolTwo, "1000000"),
+				},
+				PostTokenBalances: []*pb.TokenBalance{
+					balance(4, mintA, poolOne, "1500000"), balance(5, mintB, poolOne, "700000"),
+					balance(6, mintB, poolTwo, "1300000"), balance(7, mintC, poolTwo, "800000"),
+				},
+			},
+		},
+	}
+
+	events, err := dec.DecodeTransaction(tx)
+	if err != nil {
+		t.Fatalf("DecodeTransaction returned error: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected one leg per whirlpool, got %d", len(events))
+	}
+	for hop, want := range []string{base58.Encode(poolOne), base58.Encode(poolTwo)} {
+		ev := events[hop]
+		if ev.PoolId != want || ev.Hop != uint32(hop) || ev.InstructionIndex != 0 || ev.Inner {
+			t.Fatalf("leg %d: unexpected event %+v", hop, ev)
+		}
+	}
+	_, inOne, _, outOne := LegFlows(events[0])
+	_, inTwo, _, outTwo := LegFlows(events[1])
+	if inOne != 500_000 || outOne != 300_000 || inTwo != 300_000 || outTwo != 200_000 {
+		t.Fatalf("unexpected leg amounts %d->%d, %d->%d", inOne, outOne, inTwo, outTwo)
+	}
+}
+
+func TestDecoder_DecodeTransaction_OrcaCanonicalizesPair(t *testing.T) {
+	const (
+		usdcMint = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+		solMint  = "So11111111111111111111111111111111111111112"
+	)
+	slot := uint64(987655)
+	dec := New(nil)
+
+	poolKey := generateAddress(0x79)
+	mintA := mustDecodeBase58(t, usdcMint)
+	mintB := mustDecodeBase58(t, solMint)
+	vaultA := generateAddress(0x46)
+	vaultB := generateAddress(0x57)
+	feeRate := uint16(2500)
+
+	dec.HandleAccount(&pb.SubscribeUpdateAccount{
+		Account: &pb.SubscribeUpdateAccountInfo{
+			Pubkey: poolKey,
+			Owner:  mustDecodeBase58(t, orcawhirlpool.WhirlpoolProgramID),
+			Data:   buildOrcaPoolData(t, mintA, mintB, vaultA, vaultB, feeRate),
+		},
+	})
+
+	events, err := dec.DecodeTransaction(buildOrcaTransaction(t, slot, poolKey, mintA, mintB, vaultA, vaultB, feeRate))
+	if err != nil {
+		t.Fatalf("DecodeTransaction returned error: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected 1 swap event, got %d", len(events))
+	}
+	ev := events[0]
+
+	// Pool order is USDC/SOL; the canonical pair is SOL/USDC.
+	if ev.MintBase != solMint || ev.MintQuote != usdcMint {
+		t.Fatalf("unexpected mints %s/%s", ev.MintBase, ev.MintQuote)
+	}
+	if ev.PairId != solMint+"-"+usdcMint {
+		t.Fatalf("unexpected pair id %s", ev.PairId)
+	}
+	if ev.BaseIn != 700_000 || ev.QuoteOut != 500_000 || ev.BaseOut != 0 || ev.QuoteIn != 0 {
+		t.Fatalf("unexpected amounts base_in=%d base_out=%d quote_in=%d quote_out=%d", ev.BaseIn, ev.BaseOut, ev.QuoteIn, ev.QuoteOut)
+	}
+	// 500_000 quote for 700_000 base at equal decimals -> floor(5/7 * 2^32).
+	if ev.PriceQ32 != 3067833782 {
+		t.Fatalf("price_q32=%d want 3067833782", ev.PriceQ32)
+	}
+}
+
+func TestDecoder_DecodeTransaction_OrcaToken2022TransferFee(t *testing.T) {
+	slot := uint64(987654)
+	dec := New(nil)
+
+	poolKey := generateAddress(0x78)
+	mintA := generateAddress(0x23)
+	mintB := generateAddress(0x34)
+	vaultA := generateAddress(0x45)
+	vaultB := generateAddress(0x56)
+	feeRate := uint16(2500)
+
+	dec.HandleAccount(&pb.SubscribeUpdateAccount{
+		Account: &pb.SubscribeUpdateAccountInfo{
+			Pubkey: poolKey,
+			Owner:  mustDecodeBase58(t, orcawhirlpool.WhirlpoolProgramID),
+			Data:   buildOrcaPoolData(t, mintA, mintB, vaultA, vaultB, feeRate),
+		},
+	})
+	epoch := dexcommon.EpochForSlot(slot)
+	for _, mint := range []struct {
+		key []byte
+		bps uint16
+	}{{mintA, 50}, {mintB, 100}} {
+		dec.HandleAccount(&pb.SubscribeUpdateAccount{
+			Account: &pb.SubscribeUpdateAccountInfo{
+				Pubkey: mint.key,
+				Owner:  mustDecodeBase58(t, dexcommon.Token2022ProgramID),
+				Data:   buildToken2022MintData(epoch, mint.bps, 1_000_000),
+			},
+		})
+	}
+
+	tx := buildOrcaTransaction(t, slot, poolKey, mintA, mintB, vaultA, vaultB, feeRate)
+	events, err := dec.DecodeTransaction(tx)
+	if err != nil {
+		t.Fatalf("DecodeTransaction returned error: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected 1 swap event, got %d", len(events))
+	}
+	ev := events[0]
+
+	// The vault paid out 500_000 base; 50 bps is withheld from the trader.
+	if ev.BaseGross != 500_000 || ev.BaseNet != 497_500 || ev.BaseTransferFee != 2_500 {
+		t.Fatalf("unexpected base amounts gross=%d net=%d fee=%d", ev.BaseGross, ev.BaseNet, ev.BaseTransferFee)
+	}
+	// The vault was credited 700_000 quote after a 100 bps fee on the trader's transfer.
+	if ev.QuoteGross != 707_071 || ev.QuoteNet != 700_000 || ev.QuoteTransferFee != 7_071 {
+		t.Fatalf("unexpected quote amounts gross=%d net=%d fee=%d", ev.QuoteGross, ev.QuoteNet, ev.QuoteTransferFee)
+	}
+	if ev.BaseOut != 500_000 || ev.QuoteIn != 700_000 {
+		t.Fatalf("vault deltas changed: base_out=%d quote_in=%d", ev.BaseOut, ev.QuoteIn)
+	}
+}
+
+type fakeAccountLoader struct {
+	accounts map[string]*Account
+	calls    int
+}
+
+func (l *fakeAccountLoader) LoadAccounts(_ context.Context, keys []string) ([]*Account, error) {
+	l.calls++
+	out := make([]*Account, len(keys))
+	for i, key := range keys {
+		out[i] = l.accounts[key]
+	}
+	return out, nil
+}
+
+func TestDecoder_DecodeTransaction_LoadsUnseenOrcaPool(t *testing.T) {
+	slot := uint64(987660)
+	poolKey := generateAddress(0x79)
+	mintA := generateAddress(0x22)
+	mintB := generateAddress(0x33)
+	vaultA := generateAddress(0x44)
+	vaultB := generateAddress(0x55)
+
+	dec := New(nil)
+	tx := buildOrcaTransaction(t, slot, poolKey, mintA, mintB, vaultA, vaultB, 3000)
+	if events, err := dec.DecodeTransaction(tx); err != nil || len(events) != 0 {
+		t.Fatalf("expected no swaps without pool state, got %d (%v)", len(events), err)
+	}
+
+	loader := &fakeAccountLoader{accounts: map[string]*Account{
+		base58.Encode(poolKey): {
+			Owner: orcawhirlpool.WhirlpoolProgramID,
+			Data:  buildOrcaPoolData(t, mintA, mintB, vaultA, vaultB, 3000),
+		},
+	}}
+	dec.SetAccountLoader(loader, time.Second)
+
+	events, err := dec.DecodeTransaction(tx)
+	if err != nil {
+		t.Fatalf("DecodeTransaction returned error: %v", err)
+	}
+	if len(events) != 1 || events[0].PoolId != base58.Encode(poolKey) || events[0].FeeBps != 30 {
+		t.Fatalf("expected swap decoded with loaded pool state, got %+v", events)
+	}
+	if _, err := dec.DecodeTransaction(tx); err != nil || loader.calls != 1 {
+		t.Fatalf("expected the pool to be loaded once, got %d loads (%v)", loader.calls, err)
+	}
+}
+
+func TestDecoder_DecodeTransaction_UnseenToken2022Mint(t *testing.T) {
+	slot := uint64(987654)
+	poolKey := generateAddress(0x78)
+	mintA := generateAddress(0x23)
+	mintB := generateAddress(0x34)
+	vaultA := generateAddress(0x45)
+	vaultB := generateAddress(0x56)
+	feeRate := uint16(2500)
+
+	newDecoder := func() *Decoder {
+		dec := New(nil)
+		dec.HandleAccount(&pb.SubscribeUpdateAccount{
+			Account: &pb.SubscribeUpdateAccountInfo{
+				Pubkey: poolKey,
+				Owner:  mustDecodeBase58(t, orcawhirlpool.WhirlpoolProgramID),
+				Data:   buildOrcaPoolData(t, mintA, mintB, vaultA, vaultB, feeRate),
+			},
+		})
+		return dec
+	}
+	// The balances report mint A as Token-2022; its mint account has not been
+	// streamed.
+	tx := buildOrcaTransaction(t, slot, poolKey, mintA, mintB, vaultA, vaultB, feeRate)
+	for _, balances := range [][]*pb.TokenBalance{tx.Transaction.Meta.PreTokenBalances, tx.Transaction.Meta.PostTokenBalances} {
+		for _, bal := range balances {
+			bal.ProgramId = dexcommon.TokenProgramID
+			if bal.Mint == base58.Encode(mintA) {
+				bal.ProgramId = dexcommon.Token2022ProgramID
+			}
+		}
+	}
+
+	events, err := newDecoder().DecodeTransaction(tx)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("DecodeTransaction() = %v, %v", events, err)
+	}
+	ev := events[0]
+	if !ev.BaseTransferFeeUnknown || ev.BaseGross != 0 || ev.BaseNet != 0 {
+		t.Fatalf("unloaded Token-2022 side: unknown=%v gross=%d net=%d", ev.BaseTransferFeeUnknown, ev.BaseGross, ev.BaseNet)
+	}
+	if ev.QuoteTransferFeeUnknown || ev.QuoteNet != 700_000 {
+		t.Fatalf("classic side: unknown=%v net=%d", ev.QuoteTransferFeeUnknown, ev.QuoteNet)
+	}
+
+	// With a loader the mint is fetched once and its fee applied.
+	dec := newDecoder()
+	loader := &fakeAccountLoader{accounts: map[string]*Account{
+		base58.Encode(mintA): {Owner: dexcommon.Token2022ProgramID, Data: buildToken2022MintData(dexcommon.EpochForSlot(slot), 50, 1_000_000)},
+	}}
+	dec.SetAccountLoader(loader, time.Second)
+	for i := 0; i < 2; i++ {
+		events, err = dec.DecodeTransaction(tx)
+		if err != nil || len(events) != 1 {
+			t.Fatalf("DecodeTransaction() = %v, %v", events, err)
+		}
+	}
+	ev = events[0]
+	if ev.BaseTransferFeeUnknown || ev.BaseGross != 500_000 || ev.BaseNet != 497_500 || ev.BaseTransferFee != 2_500 {
+		t.Fatalf("loaded Token-2022 side: unknown=%v gross=%d net=%d fee=%d", ev.BaseTransferFeeUnknown, ev.BaseGross, ev.BaseNet, ev.BaseTransferFee)
+	}
+	if loader.calls != 1 {
+		t.Fatalf("loader called %d times, want 1", loader.calls)
+	}
 }
 
 func TestDecoder_DecodeTransaction_OrcaMissingPoolMetadata(t *testing.T) {
@@ -769,6 +1260,23 @@ func buildOrcaPoolData(t *testing.T, mintA, mintB, vaultA, vaultB []byte, feeRat
 	return data
 }
 
+func buildToken2022MintData(epoch uint64, basisPoints uint16, maximumFee uint64) []byte {
+	const feeConfigLen = 32 + 32 + 8 + 2*18
+	data := make([]byte, dexcommon.TokenAccountLen+1+4+feeConfigLen)
+	data[44] = 6
+	data[45] = 1
+	data[dexcommon.MintAccountTypeOffset] = 1
+
+	ext := data[dexcommon.MintAccountTypeOffset+1:]
+	binary.LittleEndian.PutUint16(ext[0:2], 1)
+	binary.LittleEndian.PutUint16(ext[2:4], feeConfigLen)
+	newer := ext[4+72+18:]
+	binary.LittleEndian.PutUint64(newer[0:8], epoch)
+	binary.LittleEndian.PutUint64(newer[8:16], maximumFee)
+	binary.LittleEndian.PutUint16(newer[16:18], basisPoints)
+	return data
+}
+
 func generateAddress(seed byte) []byte {
 	buf := make([]byte, 32)
 	for i := range buf {
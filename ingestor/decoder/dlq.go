@@ -0,0 +1,45 @@
+package decoder
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mr-tron/base58/base58"
+	"google.golang.org/protobuf/proto"
+
+	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+
+	pb "github.com/rpcpool/yellowstone-grpc/examples/golang/proto"
+)
+
+// NewDecodeFailure captures a transaction the decoder rejected, together with
+// the raw update, so it can be dead-lettered and replayed later.
+func NewDecodeFailure(tx *pb.SubscribeUpdateTransaction, decodeErr *DecodeError, observedAt time.Time) (*dexv1.DecodeFailure, error) {
+	raw, err := proto.Marshal(tx)
+	if err != nil {
+		return nil, fmt.Errorf("marshal transaction: %w", err)
+	}
+	return &dexv1.DecodeFailure{
+		ChainId:      chainIDSolana,
+		Slot:         tx.GetSlot(),
+		Sig:          base58.Encode(tx.GetTransaction().GetSignature()),
+		ProgramId:    decodeErr.Program,
+		Error:        decodeErr.Error(),
+		RawTx:        raw,
+		ObservedAtMs: uint64(observedAt.UnixMilli()),
+	}, nil
+}
+
+// ReplayDecodeFailure decodes a dead-lettered transaction again, returning the
+// swap events the current decoder produces for it.
+func (d *Decoder) ReplayDecodeFailure(failure *dexv1.DecodeFailure) ([]*dexv1.SwapEvent, error) {
+	if failure.GetRawTxJson() {
+		return nil, errors.New("dead-lettered before conversion; raw_tx holds the source JSON")
+	}
+	var tx pb.SubscribeUpdateTransaction
+	if err := proto.Unmarshal(failure.GetRawTx(), &tx); err != nil {
+		return nil, fmt.Errorf("unmarshal dead-letter transaction: %w", err)
+	}
+	return d.DecodeTransaction(&tx)
+}
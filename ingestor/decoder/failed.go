@@ -0,0 +1,123 @@
+package decoder
+
+import (
+	"fmt"
+
+	meteora "github.com/rexbrahh/lp-indexer/decoder/meteora"
+	orcawhirlpool "github.com/rexbrahh/lp-indexer/decoder/orca_whirlpool"
+	ray "github.com/rexbrahh/lp-indexer/decoder/raydium"
+	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+
+	pb "github.com/rpcpool/yellowstone-grpc/examples/golang/proto"
+)
+
+// DecodeFailedAttempts returns one FailedSwapAttempt per supported DEX
+// instruction, top-level or CPI, in a transaction that failed on chain. Successful transactions
+// yield no attempts; their swaps come from DecodeTransaction instead.
+func (d *Decoder) DecodeFailedAttempts(tx *pb.SubscribeUpdateTransaction) ([]*dexv1.FailedSwapAttempt, error) {
+	info := tx.GetTransaction()
+	meta := info.GetMeta()
+	if meta.GetErr() == nil {
+		return nil, nil
+	}
+	message := info.GetTransaction().GetMessage()
+	if message == nil {
+		return nil, nil
+	}
+
+	txErr, err := DecodeTxError(meta.GetErr().GetErr())
+	if err != nil {
+		return nil, fmt.Errorf("decode transaction error: %w", err)
+	}
+	txErr.resolveCustomErrorName(meta.GetLogMessages())
+
+	accountStrs := transactionAccountKeys(message, meta)
+	feePayer := ""
+	if len(accountStrs) > 0 {
+		feePayer = accountStrs[0]
+	}
+
+	vaults := extractVaultBalances(meta)
+	signature := encodeSignature(info.GetTransaction().GetSignatures())
+
+	inner := make(map[uint32][]*pb.InnerInstruction, len(meta.GetInnerInstructions()))
+	for _, group := range meta.GetInnerInstructions() {
+		inner[group.GetIndex()] = append(inner[group.GetIndex()], group.GetInstructions()...)
+	}
+
+	programID := func(idx uint32) string {
+		if int(idx) >= len(accountStrs) {
+			return ""
+		}
+		return accountStrs[idx]
+	}
+	newAttempt := func(programID string, instr *pb.CompiledInstruction, outer uint32) *dexv1.FailedSwapAttempt {
+		return &dexv1.FailedSwapAttempt{
+			ChainId:              chainIDSolana,
+			Slot:                 tx.GetSlot(),
+			Sig:                  signature,
+			Index:                uint32(info.GetIndex()),
+			ProgramId:            programID,
+			PoolId:               attemptPoolID(programID, instr, accountStrs, vaults),
+			InstructionIndex:     outer,
+			ErrorKind:            txErr.Kind,
+			HasFailedInstruction: txErr.HasInstruction,
+			FailedInstruction:    uint32(txErr.InstructionIndex),
+			ErrorCode:            txErr.Code,
+			ErrorName:            txErr.Name,
+			FeePayer:             feePayer,
+			CuUsed:               meta.GetComputeUnitsConsumed(),
+		}
+	}
+
+	var attempts []*dexv1.FailedSwapAttempt
+	for i, instr := range message.GetInstructions() {
+		topProgram := programID(instr.GetProgramIdIndex())
+		if isSwapProgram(topProgram) {
+			attempts = append(attempts, newAttempt(topProgram, instr, uint32(i)))
+		}
+
+		// Swaps routed through an aggregator only appear as inner
+		// instructions; a DEX calling back into itself is covered above.
+		for j, innerInstr := range inner[uint32(i)] {
+			innerProgram := programID(innerInstr.GetProgramIdIndex())
+			if innerProgram == topProgram || !isSwapProgram(innerProgram) {
+				continue
+			}
+			attempt := newAttempt(innerProgram, &pb.CompiledInstruction{
+				ProgramIdIndex: innerInstr.GetProgramIdIndex(),
+				Accounts:       innerInstr.GetAccounts(),
+				Data:           innerInstr.GetData(),
+			}, uint32(i))
+			attempt.Inner = true
+			attempt.InnerInstructionIndex = uint32(j)
+			attempts = append(attempts, attempt)
+		}
+	}
+	return attempts, nil
+}
+
+func isSwapProgram(programID string) bool {
+	switch programID {
+	case ray.ProgramID, orcawhirlpool.WhirlpoolProgramID:
+		return true
+	}
+	_, ok := meteora.ProgramKindForID(programID)
+	return ok
+}
+
+// attemptPoolID identifies the pool targeted by a failed swap instruction. The
+// vaults are untouched by a reverted transaction, so the pool is taken from the
+// instruction accounts (Orca) or the owner of the referenced token accounts.
+func attemptPoolID(programID string, instr *pb.CompiledInstruction, accountStrs []string, vaults map[string][]*tokenBalance) string {
+	if programID == orcawhirlpool.WhirlpoolProgramID {
+		accounts := instr.GetAccounts()
+		pos := orcawhirlpool.SwapPoolAccounts(instr.GetData())[0]
+		if pos < len(accounts) && int(accounts[pos]) < len(accountStrs) {
+			return accountStrs[accounts[pos]]
+		}
+		return ""
+	}
+	pool, _, _ := resolvePool(instr, accountStrs, vaults)
+	return pool
+}
@@ -0,0 +1,103 @@
+package decoder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	// DefaultLoadTimeout bounds a single on-demand account load.
+	DefaultLoadTimeout = 2 * time.Second
+	// loadRetryInterval keeps an account that could not be loaded from
+	// stalling every transaction that touches it.
+	loadRetryInterval = time.Minute
+)
+
+// Account is an account returned by an AccountLoader.
+type Account struct {
+	Owner string
+	Data  []byte
+}
+
+// AccountLoader fetches accounts by address. The result is index-aligned with
+// keys; missing accounts are nil. *solrpc.Client implements it.
+type AccountLoader interface {
+	LoadAccounts(ctx context.Context, keys []string) ([]*Account, error)
+}
+
+// SetAccountLoader enables on-demand loading of the accounts a decode needs
+// but the stream has not delivered yet: Raydium pools and their AmmConfig,
+// Orca whirlpools and Token-2022 mints. Accounts are loaded at their current
+// state, not as of the transaction's slot. A decode blocks for at most timeout
+// per load, and an account that fails to load is not retried for a minute.
+func (d *Decoder) SetAccountLoader(loader AccountLoader, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultLoadTimeout
+	}
+	d.loader = loader
+	d.loadTimeout = timeout
+}
+
+// LoadAccounts fetches keys with the configured loader and applies them like
+// streamed account updates.
+func (d *Decoder) LoadAccounts(ctx context.Context, keys []string) error {
+	_, err := d.loadAccounts(ctx, keys)
+	return err
+}
+
+// loadAccounts applies the accounts that exist and returns the keys that do
+// not.
+func (d *Decoder) loadAccounts(ctx context.Context, keys []string) ([]string, error) {
+	if d.loader == nil {
+		return nil, errors.New("no account loader configured")
+	}
+	accounts, err := d.loader.LoadAccounts(ctx, keys)
+	if err != nil {
+		return nil, fmt.Errorf("load accounts: %w", err)
+	}
+	var missing []string
+	for i, account := range accounts {
+		if account == nil {
+			missing = append(missing, keys[i])
+			continue
+		}
+		d.applyAccount(keys[i], account.Owner, account.Data)
+	}
+	return missing, nil
+}
+
+// loadOnDemand loads keys when a loader is configured, skipping keys whose
+// last load failed within loadRetryInterval.
+func (d *Decoder) loadOnDemand(keys ...string) {
+	if d.loader == nil || len(keys) == 0 {
+		return
+	}
+	now := time.Now()
+	want := keys[:0:0]
+	for _, key := range keys {
+		if failed, ok := d.loadFailed[key]; ok && now.Sub(failed) < loadRetryInterval {
+			continue
+		}
+		want = append(want, key)
+	}
+	if len(want) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.loadTimeout)
+	defer cancel()
+	missing, err := d.loadAccounts(ctx, want)
+	if err != nil {
+		log.Printf("decoder: %v", err)
+		missing = want
+	}
+	for _, key := range want {
+		delete(d.loadFailed, key)
+	}
+	for _, key := range missing {
+		d.loadFailed[key] = now
+	}
+}
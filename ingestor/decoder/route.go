@@ -0,0 +1,121 @@
+package decoder
+
+import (
+	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+
+	pb "github.com/rpcpool/yellowstone-grpc/examples/golang/proto"
+)
+
+// BuildRoute groups the swap legs decoded from one transaction into a Route
+// carrying the trader's net input and output. Transactions with fewer than two
+// legs are not routes and yield nil.
+func BuildRoute(tx *pb.SubscribeUpdateTransaction, legs []*dexv1.SwapEvent) *dexv1.Route {
+	if len(legs) < 2 {
+		return nil
+	}
+	first := legs[0]
+
+	route := &dexv1.Route{
+		ChainId:             first.GetChainId(),
+		Slot:                first.GetSlot(),
+		Sig:                 first.GetSig(),
+		Index:               first.GetIndex(),
+		AggregatorProgramId: aggregatorProgram(tx),
+		Trader:              first.GetTrader(),
+		Legs:                legs,
+		Provisional:         first.GetProvisional(),
+		IsUndo:              first.GetIsUndo(),
+	}
+
+	var order []string
+	paid := make(map[string]uint64)
+	received := make(map[string]uint64)
+	track := func(mint string) {
+		if _, ok := paid[mint]; !ok {
+			order = append(order, mint)
+			paid[mint] = 0
+		}
+	}
+	for _, leg := range legs {
+		inMint, inAmount, outMint, outAmount := LegFlows(leg)
+		track(inMint)
+		track(outMint)
+		paid[inMint] += inAmount
+		received[outMint] += outAmount
+	}
+
+	firstIn, _, _, _ := LegFlows(first)
+	_, _, lastOut, _ := LegFlows(legs[len(legs)-1])
+	if firstIn == lastOut {
+		route.Circular = true
+		route.InputMint, route.InputAmount = firstIn, paid[firstIn]
+		route.OutputMint, route.OutputAmount = lastOut, received[lastOut]
+		return route
+	}
+
+	// Intermediate mints net out; the input is the mint the trader spent the
+	// most of on balance and the output the one they gained the most of.
+	for _, mint := range order {
+		if spent := paid[mint]; spent > received[mint] && spent-received[mint] > route.InputAmount {
+			route.InputMint, route.InputAmount = mint, spent-received[mint]
+		}
+		if gained := received[mint]; gained > paid[mint] && gained-paid[mint] > route.OutputAmount {
+			route.OutputMint, route.OutputAmount = mint, gained-paid[mint]
+		}
+	}
+	return route
+}
+
+// LegFlows reports what the trader sent into and took out of a pool. The
+// trader is debited the gross input and credited the net output after any
+// Token-2022 transfer fees.
+func LegFlows(leg *dexv1.SwapEvent) (inMint string, inAmount uint64, outMint string, outAmount uint64) {
+	if leg.GetBaseIn() > 0 {
+		return leg.GetMintBase(), orAmount(leg.GetBaseGross(), leg.GetBaseIn()),
+			leg.GetMintQuote(), orAmount(leg.GetQuoteNet(), leg.GetQuoteOut())
+	}
+	return leg.GetMintQuote(), orAmount(leg.GetQuoteGross(), leg.GetQuoteIn()),
+		leg.GetMintBase(), orAmount(leg.GetBaseNet(), leg.GetBaseOut())
+}
+
+func orAmount(preferred, fallback uint64) uint64 {
+	if preferred != 0 {
+		return preferred
+	}
+	return fallback
+}
+
+// aggregatorProgram returns the first top-level program that reached a
+// supported DEX through CPI, or "" when the legs were invoked directly.
+func aggregatorProgram(tx *pb.SubscribeUpdateTransaction) string {
+	info := tx.GetTransaction()
+	meta := info.GetMeta()
+	message := info.GetTransaction().GetMessage()
+	if message == nil {
+		return ""
+	}
+	accountStrs := transactionAccountKeys(message, meta)
+	programAt := func(idx uint32) string {
+		if int(idx) >= len(accountStrs) {
+			return ""
+		}
+		return accountStrs[idx]
+	}
+
+	instructions := message.GetInstructions()
+	for _, group := range meta.GetInnerInstructions() {
+		if int(group.GetIndex()) >= len(instructions) {
+			continue
+		}
+		top := programAt(instructions[group.GetIndex()].GetProgramIdIndex())
+		if top == "" || isSwapProgram(top) {
+			continue
+		}
+		for _, inner := range group.GetInstructions() {
+			if isSwapProgram(programAt(inner.GetProgramIdIndex())) {
+				return top
+			}
+		}
+	}
+	return ""
+}
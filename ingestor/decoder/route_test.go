@@ -0,0 +1,114 @@
+package decoder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mr-tron/base58/base58"
+
+	orcawhirlpool "github.com/rexbrahh/lp-indexer/decoder/orca_whirlpool"
+	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+	"github.com/rexbrahh/lp-indexer/ingestor/common"
+
+	pb "github.com/rpcpool/yellowstone-grpc/examples/golang/proto"
+)
+
+func TestBuildRouteLinear(t *testing.T) {
+	legs := []*dexv1.SwapEvent{
+		// 1_000 A in, 500 B out.
+		{Slot: 7, Sig: "sig", MintBase: "A", MintQuote: "B", BaseIn: 1_000, QuoteOut: 500, Trader: "wallet", Provisional: true},
+		// 500 B in, 250 C out with a 5 unit transfer fee withheld on C.
+		{Slot: 7, Sig: "sig", MintBase: "C", MintQuote: "B", QuoteIn: 500, BaseOut: 250, BaseGross: 250, BaseNet: 245, Provisional: true},
+	}
+
+	route := BuildRoute(nil, legs)
+	if route == nil {
+		t.Fatal("expected route")
+	}
+	if route.Circular {
+		t.Fatal("linear route flagged circular")
+	}
+	if route.InputMint != "A" || route.InputAmount != 1_000 {
+		t.Fatalf("unexpected input %s/%d", route.InputMint, route.InputAmount)
+	}
+	if route.OutputMint != "C" || route.OutputAmount != 245 {
+		t.Fatalf("unexpected output %s/%d", route.OutputMint, route.OutputAmount)
+	}
+	if route.Trader != "wallet" || len(route.Legs) != 2 || !route.Provisional {
+		t.Fatalf("unexpected route %+v", route)
+	}
+
+	if BuildRoute(nil, legs[:1]) != nil {
+		t.Fatal("single leg should not form a route")
+	}
+}
+
+func TestBuildRouteCircular(t *testing.T) {
+	legs := []*dexv1.SwapEvent{
+		{MintBase: "SOL", MintQuote: "USDC", BaseIn: 1_000, QuoteOut: 150_000},
+		{MintBase: "BONK", MintQuote: "USDC", QuoteIn: 150_000, BaseOut: 9_000},
+		{MintBase: "SOL", MintQuote: "BONK", QuoteIn: 9_000, BaseOut: 1_010},
+	}
+
+	route := BuildRoute(nil, legs)
+	if !route.Circular {
+		t.Fatal("expected circular route")
+	}
+	if route.InputMint != "SOL" || route.OutputMint != "SOL" {
+		t.Fatalf("unexpected mints %s -> %s", route.InputMint, route.OutputMint)
+	}
+	if route.InputAmount != 1_000 || route.OutputAmount != 1_010 {
+		t.Fatalf("unexpected amounts %d -> %d", route.InputAmount, route.OutputAmount)
+	}
+}
+
+func TestDecodeTransactionAggregatorInnerLegs(t *testing.T) {
+	cache := common.NewMemorySlotTimeCache()
+	slot := uint64(987657)
+	cache.Set(slot, time.Unix(1_700_000_800, 0))
+
+	dec := New(cache)
+
+	poolKey := generateAddress(0x77)
+	mintA := generateAddress(0x22)
+	mintB := generateAddress(0x33)
+	vaultA := generateAddress(0x44)
+	vaultB := generateAddress(0x55)
+
+	dec.HandleAccount(&pb.SubscribeUpdateAccount{
+		Account: &pb.SubscribeUpdateAccountInfo{
+			Pubkey: poolKey,
+			Owner:  mustDecodeBase58(t, orcawhirlpool.WhirlpoolProgramID),
+			Data:   buildOrcaPoolData(t, mintA, mintB, vaultA, vaultB, 3000),
+		},
+	})
+
+	// Move the Whirlpool swap behind an aggregator instruction so it is only
+	// visible as an inner instruction.
+	aggregator := generateAddress(0x99)
+	tx := buildOrcaTransaction(t, slot, poolKey, mintA, mintB, vaultA, vaultB, 3000)
+	msg := tx.Transaction.Transaction.Message
+	msg.AccountKeys = append(msg.AccountKeys, aggregator)
+	orcaInstr := msg.Instructions[0]
+	msg.Instructions = []*pb.CompiledInstruction{{ProgramIdIndex: 6, Accounts: []byte{0, 2, 3, 4}}}
+	tx.Transaction.Meta.InnerInstructions = []*pb.InnerInstructions{{
+		Index: 0,
+		Instructions: []*pb.InnerInstruction{{
+			ProgramIdIndex: orcaInstr.ProgramIdIndex,
+			Accounts:       orcaInstr.Accounts,
+			Data:           orcaInstr.Data,
+		}},
+	}}
+
+	events, err := dec.DecodeTransaction(tx)
+	if err != nil {
+		t.Fatalf("DecodeTransaction returned error: %v", err)
+	}
+	if len(events) != 1 || events[0].PoolId != base58.Encode(poolKey) {
+		t.Fatalf("expected inner Whirlpool leg, got %+v", events)
+	}
+
+	if got := aggregatorProgram(tx); got != base58.Encode(aggregator) {
+		t.Fatalf("aggregator=%s want %s", got, base58.Encode(aggregator))
+	}
+}
@@ -0,0 +1,62 @@
+package decoder
+
+import (
+	"sort"
+
+	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+
+	pb "github.com/rpcpool/yellowstone-grpc/examples/golang/proto"
+)
+
+// attributeTrader stamps the fee payer and trader wallet on a swap event. The
+// trader is the owner of the user token accounts the swap instruction moved
+// (preferring a transaction signer when several owners qualify); when no such
+// account is visible the fee payer is used.
+func attributeTrader(ev *dexv1.SwapEvent, instr *pb.CompiledInstruction, accountStrs []string, numSigners uint32, vaults map[string][]*tokenBalance) {
+	feePayer := ""
+	if len(accountStrs) > 0 {
+		feePayer = accountStrs[0]
+	}
+	ev.FeePayer = feePayer
+	ev.Trader = feePayer
+
+	touched := make(map[uint32]struct{}, len(instr.GetAccounts()))
+	for _, idx := range instr.GetAccounts() {
+		touched[uint32(idx)] = struct{}{}
+	}
+
+	var candidates []*tokenBalance
+	for owner, balances := range vaults {
+		if owner == ev.GetPoolId() {
+			continue
+		}
+		for _, tb := range balances {
+			if _, ok := touched[tb.accountIndex]; !ok {
+				continue
+			}
+			if tb.pre == tb.post || (tb.mint != ev.GetMintBase() && tb.mint != ev.GetMintQuote()) {
+				continue
+			}
+			candidates = append(candidates, tb)
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].accountIndex < candidates[j].accountIndex
+	})
+
+	signers := make(map[string]struct{}, numSigners)
+	for i := 0; i < int(numSigners) && i < len(accountStrs); i++ {
+		signers[accountStrs[i]] = struct{}{}
+	}
+	for _, tb := range candidates {
+		if _, ok := signers[tb.owner]; ok {
+			ev.Trader = tb.owner
+			return
+		}
+	}
+	ev.Trader = candidates[0].owner
+}
@@ -0,0 +1,47 @@
+package decoder
+
+import (
+	dexcommon "github.com/rexbrahh/lp-indexer/decoder/common"
+	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+)
+
+// applyTransferFees fills the gross/net/withheld amounts on a swap event. Swap
+// amounts are observed from the pool vaults: an inbound leg is what the vault
+// was credited (net of any Token-2022 transfer fee) and an outbound leg is what
+// the vault sent (gross, before the fee is withheld from the trader).
+//
+// token2022 lists the mints the transaction's token balances attribute to
+// Token-2022. Those whose mint account is unknown are loaded on demand; when
+// that is not possible the side is flagged unknown instead of assuming no fee.
+func (d *Decoder) applyTransferFees(ev *dexv1.SwapEvent, token2022 map[string]bool) {
+	var missing []string
+	for _, mint := range []string{ev.GetMintBase(), ev.GetMintQuote()} {
+		if _, ok := d.tokenMints[mint]; !ok && token2022[mint] {
+			missing = append(missing, mint)
+		}
+	}
+	d.loadOnDemand(missing...)
+
+	epoch := dexcommon.EpochForSlot(ev.GetSlot())
+	ev.BaseGross, ev.BaseNet, ev.BaseTransferFee, ev.BaseTransferFeeUnknown = d.splitTransferFee(ev.GetMintBase(), token2022, epoch, ev.GetBaseIn(), ev.GetBaseOut())
+	ev.QuoteGross, ev.QuoteNet, ev.QuoteTransferFee, ev.QuoteTransferFeeUnknown = d.splitTransferFee(ev.GetMintQuote(), token2022, epoch, ev.GetQuoteIn(), ev.GetQuoteOut())
+}
+
+func (d *Decoder) splitTransferFee(mint string, token2022 map[string]bool, epoch, amountIn, amountOut uint64) (gross, net, fee uint64, unknown bool) {
+	tm, ok := d.tokenMints[mint]
+	if !ok && token2022[mint] {
+		return 0, 0, 0, true
+	}
+	if !ok || tm.TransferFee == nil {
+		amount := amountIn + amountOut
+		return amount, amount, 0, false
+	}
+	schedule := tm.TransferFee.EpochFee(epoch)
+
+	if amountIn > 0 {
+		gross = schedule.PreFeeAmount(amountIn)
+		return gross, amountIn, gross - amountIn, false
+	}
+	fee = schedule.Fee(amountOut)
+	return amountOut, amountOut - fee, fee, false
+}
@@ -0,0 +1,290 @@
+package decoder
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Transaction error kinds reported on failed swap attempts.
+const (
+	TxErrorKindCustom      = "custom"
+	TxErrorKindInstruction = "instruction"
+	TxErrorKindTransaction = "transaction"
+)
+
+const (
+	txErrorInstructionVariant = 8
+	instructionErrorCustom    = 25
+)
+
+// transactionErrorNames mirrors the variant order of solana_sdk::TransactionError.
+var transactionErrorNames = []string{
+	"AccountInUse",
+	"AccountLoadedTwice",
+	"AccountNotFound",
+	"ProgramAccountNotFound",
+	"InsufficientFundsForFee",
+	"InvalidAccountForFee",
+	"AlreadyProcessed",
+	"BlockhashNotFound",
+	"InstructionError",
+	"CallChainTooDeep",
+	"MissingSignatureForFee",
+	"InvalidAccountIndex",
+	"SignatureFailure",
+	"InvalidProgramForExecution",
+	"SanitizeFailure",
+	"ClusterMaintenance",
+	"AccountBorrowOutstanding",
+	"WouldExceedMaxBlockCostLimit",
+	"UnsupportedVersion",
+	"InvalidWritableAccount",
+	"WouldExceedMaxAccountCostLimit",
+	"WouldExceedAccountDataBlockLimit",
+	"TooManyAccountLocks",
+	"AddressLookupTableNotFound",
+	"InvalidAddressLookupTableOwner",
+	"InvalidAddressLookupTableData",
+	"InvalidAddressLookupTableIndex",
+	"InvalidRentPayingAccount",
+	"WouldExceedMaxVoteCostLimit",
+	"WouldExceedAccountDataTotalLimit",
+	"DuplicateInstruction",
+	"InsufficientFundsForRent",
+	"MaxLoadedAccountsDataSizeExceeded",
+	"InvalidLoadedAccountsDataSizeLimit",
+	"ResanitizationNeeded",
+	"ProgramExecutionTemporarilyRestricted",
+	"UnbalancedTransaction",
+}
+
+// instructionErrorNames mirrors the variant order of solana_program::InstructionError.
+var instructionErrorNames = []string{
+	"GenericError",
+	"InvalidArgument",
+	"InvalidInstructionData",
+	"InvalidAccountData",
+	"AccountDataTooSmall",
+	"InsufficientFunds",
+	"IncorrectProgramId",
+	"MissingRequiredSignature",
+	"AccountAlreadyInitialized",
+	"UninitializedAccount",
+	"UnbalancedInstruction",
+	"ModifiedProgramId",
+	"ExternalAccountLamportSpend",
+	"ExternalAccountDataModified",
+	"ReadonlyLamportChange",
+	"ReadonlyDataModified",
+	"DuplicateAccountIndex",
+	"ExecutableModified",
+	"RentEpochModified",
+	"NotEnoughAccountKeys",
+	"AccountDataSizeChanged",
+	"AccountNotExecutable",
+	"AccountBorrowFailed",
+	"AccountBorrowOutstanding",
+	"DuplicateAccountOutOfSync",
+	"Custom",
+	"InvalidError",
+	"ExecutableDataModified",
+	"ExecutableLamportChange",
+	"ExecutableAccountNotRentExempt",
+	"UnsupportedProgramId",
+	"CallDepth",
+	"MissingAccount",
+	"ReentrancyNotAllowed",
+	"MaxSeedLengthExceeded",
+	"InvalidSeeds",
+	"InvalidRealloc",
+	"ComputationalBudgetExceeded",
+	"PrivilegeEscalation",
+	"ProgramEnvironmentSetupFailure",
+	"ProgramFailedToComplete",
+	"ProgramFailedToCompile",
+	"Immutable",
+	"IncorrectAuthority",
+	"BorshIoError",
+	"AccountNotRentExempt",
+	"InvalidAccountOwner",
+	"ArithmeticOverflow",
+	"UnsupportedSysvar",
+	"IllegalOwner",
+	"MaxAccountsDataAllocationsExceeded",
+	"MaxAccountsExceeded",
+	"MaxInstructionTraceLengthExceeded",
+	"BuiltinProgramsMustConsumeComputeUnits",
+}
+
+// anchorErrorPattern matches the error line Anchor programs log before failing.
+var anchorErrorPattern = regexp.MustCompile(`Error Code: (\w+)\. Error Number: (\d+)\.`)
+
+// TxError is a decoded Solana transaction error. For custom program errors Code
+// is the program-defined code; otherwise it is the runtime variant index.
+type TxError struct {
+	Kind             string
+	Name             string
+	Code             uint32
+	HasInstruction   bool
+	InstructionIndex uint8
+}
+
+// DecodeTxError parses the bincode-encoded TransactionError carried by
+// Yellowstone transaction metadata.
+func DecodeTxError(raw []byte) (*TxError, error) {
+	if len(raw) < 4 {
+		return nil, errors.New("transaction error too short")
+	}
+	variant := binary.LittleEndian.Uint32(raw[0:4])
+	if variant != txErrorInstructionVariant {
+		return &TxError{
+			Kind: TxErrorKindTransaction,
+			Name: variantName(transactionErrorNames, variant, "TransactionError"),
+			Code: variant,
+		}, nil
+	}
+
+	if len(raw) < 9 {
+		return nil, fmt.Errorf("instruction error too short: %d bytes", len(raw))
+	}
+	txErr := &TxError{
+		Kind:             TxErrorKindInstruction,
+		HasInstruction:   true,
+		InstructionIndex: raw[4],
+	}
+	instrVariant := binary.LittleEndian.Uint32(raw[5:9])
+	if instrVariant != instructionErrorCustom {
+		txErr.Name = variantName(instructionErrorNames, instrVariant, "InstructionError")
+		txErr.Code = instrVariant
+		return txErr, nil
+	}
+
+	if len(raw) < 13 {
+		return nil, fmt.Errorf("custom instruction error too short: %d bytes", len(raw))
+	}
+	txErr.Kind = TxErrorKindCustom
+	txErr.Code = binary.LittleEndian.Uint32(raw[9:13])
+	return txErr, nil
+}
+
+// resolveCustomErrorName fills the error name for custom program errors from
+// the Anchor error line in the transaction logs, when one is present.
+func (e *TxError) resolveCustomErrorName(logs []string) {
+	if e == nil || e.Kind != TxErrorKindCustom || e.Name != "" {
+		return
+	}
+	for i := len(logs) - 1; i >= 0; i-- {
+		match := anchorErrorPattern.FindStringSubmatch(logs[i])
+		if match == nil {
+			continue
+		}
+		code, err := strconv.ParseUint(match[2], 10, 32)
+		if err != nil || uint32(code) != e.Code {
+			continue
+		}
+		e.Name = match[1]
+		return
+	}
+}
+
+func variantName(names []string, variant uint32, fallback string) string {
+	if int(variant) < len(names) {
+		return names[variant]
+	}
+	return fmt.Sprintf("%s(%d)", fallback, variant)
+}
+
+// EncodeTxErrorJSON converts the JSON form of a TransactionError returned by
+// the Solana JSON-RPC API (e.g. {"InstructionError":[0,{"Custom":6001}]}) into
+// the bincode encoding carried by Yellowstone transaction metadata.
+func EncodeTxErrorJSON(raw json.RawMessage) ([]byte, error) {
+	name, payload, err := splitJSONVariant(raw)
+	if err != nil {
+		return nil, fmt.Errorf("transaction error: %w", err)
+	}
+	variant, ok := variantIndex(transactionErrorNames, name)
+	if !ok {
+		return nil, fmt.Errorf("unknown transaction error %q", name)
+	}
+	out := binary.LittleEndian.AppendUint32(nil, variant)
+
+	switch name {
+	case "InstructionError":
+		var parts []json.RawMessage
+		if err := json.Unmarshal(payload, &parts); err != nil || len(parts) != 2 {
+			return nil, fmt.Errorf("invalid InstructionError payload %s", payload)
+		}
+		var index uint8
+		if err := json.Unmarshal(parts[0], &index); err != nil {
+			return nil, fmt.Errorf("invalid instruction index %s", parts[0])
+		}
+		instrName, instrPayload, err := splitJSONVariant(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("instruction error: %w", err)
+		}
+		instrVariant, ok := variantIndex(instructionErrorNames, instrName)
+		if !ok {
+			return nil, fmt.Errorf("unknown instruction error %q", instrName)
+		}
+		out = append(out, index)
+		out = binary.LittleEndian.AppendUint32(out, instrVariant)
+		switch instrName {
+		case "Custom":
+			var code uint32
+			if err := json.Unmarshal(instrPayload, &code); err != nil {
+				return nil, fmt.Errorf("invalid custom error code %s", instrPayload)
+			}
+			out = binary.LittleEndian.AppendUint32(out, code)
+		case "BorshIoError":
+			var msg string
+			_ = json.Unmarshal(instrPayload, &msg)
+			out = binary.LittleEndian.AppendUint64(out, uint64(len(msg)))
+			out = append(out, msg...)
+		}
+	case "DuplicateInstruction":
+		var index uint8
+		if err := json.Unmarshal(payload, &index); err != nil {
+			return nil, fmt.Errorf("invalid DuplicateInstruction payload %s", payload)
+		}
+		out = append(out, index)
+	case "InsufficientFundsForRent", "ProgramExecutionTemporarilyRestricted":
+		var body struct {
+			AccountIndex uint8 `json:"account_index"`
+		}
+		if err := json.Unmarshal(payload, &body); err != nil {
+			return nil, fmt.Errorf("invalid %s payload %s", name, payload)
+		}
+		out = append(out, body.AccountIndex)
+	}
+	return out, nil
+}
+
+// splitJSONVariant splits a serde-encoded enum into its variant name and
+// payload: unit variants are bare strings, others single-key objects.
+func splitJSONVariant(raw json.RawMessage) (string, json.RawMessage, error) {
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		return name, nil, nil
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &obj); err != nil || len(obj) != 1 {
+		return "", nil, fmt.Errorf("invalid enum value %s", raw)
+	}
+	for key, payload := range obj {
+		return key, payload, nil
+	}
+	return "", nil, nil
+}
+
+func variantIndex(names []string, name string) (uint32, bool) {
+	for i, candidate := range names {
+		if candidate == name {
+			return uint32(i), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,118 @@
+package decoder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func encodeInstructionError(instrIdx uint8, variant uint32, custom ...uint32) []byte {
+	out := binary.LittleEndian.AppendUint32(nil, txErrorInstructionVariant)
+	out = append(out, instrIdx)
+	out = binary.LittleEndian.AppendUint32(out, variant)
+	for _, code := range custom {
+		out = binary.LittleEndian.AppendUint32(out, code)
+	}
+	return out
+}
+
+func TestDecodeTxError(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      []byte
+		kind     string
+		errName  string
+		code     uint32
+		hasInstr bool
+		instrIdx uint8
+	}{
+		{
+			name:     "custom",
+			raw:      encodeInstructionError(2, instructionErrorCustom, 6001),
+			kind:     TxErrorKindCustom,
+			code:     6001,
+			hasInstr: true,
+			instrIdx: 2,
+		},
+		{
+			name:     "builtin_instruction",
+			raw:      encodeInstructionError(1, 37),
+			kind:     TxErrorKindInstruction,
+			errName:  "ComputationalBudgetExceeded",
+			code:     37,
+			hasInstr: true,
+			instrIdx: 1,
+		},
+		{
+			name:    "transaction",
+			raw:     binary.LittleEndian.AppendUint32(nil, 7),
+			kind:    TxErrorKindTransaction,
+			errName: "BlockhashNotFound",
+			code:    7,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeTxError(tt.raw)
+			if err != nil {
+				t.Fatalf("DecodeTxError: %v", err)
+			}
+			if got.Kind != tt.kind || got.Name != tt.errName || got.Code != tt.code {
+				t.Fatalf("unexpected error %+v", got)
+			}
+			if got.HasInstruction != tt.hasInstr || got.InstructionIndex != tt.instrIdx {
+				t.Fatalf("unexpected instruction %+v", got)
+			}
+		})
+	}
+
+	if _, err := DecodeTxError(encodeInstructionError(0, instructionErrorCustom)); err == nil {
+		t.Fatal("expected error for truncated custom code")
+	}
+}
+
+func TestResolveCustomErrorName(t *testing.T) {
+	txErr, err := DecodeTxError(encodeInstructionError(0, instructionErrorCustom, 6001))
+	if err != nil {
+		t.Fatalf("DecodeTxError: %v", err)
+	}
+	txErr.resolveCustomErrorName([]string{
+		"Program log: Instruction: Swap",
+		"Program log: AnchorError occurred. Error Code: SlippageExceeded. Error Number: 6001. Error Message: slippage exceeded.",
+	})
+	if txErr.Name != "SlippageExceeded" {
+		t.Fatalf("name=%q want SlippageExceeded", txErr.Name)
+	}
+}
+
+func TestEncodeTxErrorJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want []byte
+	}{
+		{"custom", `{"InstructionError":[2,{"Custom":6001}]}`, encodeInstructionError(2, instructionErrorCustom, 6001)},
+		{"builtin_instruction", `{"InstructionError":[1,"ComputationalBudgetExceeded"]}`, encodeInstructionError(1, 37)},
+		{"unit", `"BlockhashNotFound"`, binary.LittleEndian.AppendUint32(nil, 7)},
+		{"duplicate", `{"DuplicateInstruction":3}`, append(binary.LittleEndian.AppendUint32(nil, 30), 3)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EncodeTxErrorJSON([]byte(tt.json))
+			if err != nil {
+				t.Fatalf("EncodeTxErrorJSON: %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("EncodeTxErrorJSON = %x, want %x", got, tt.want)
+			}
+			if _, err := DecodeTxError(got); err != nil {
+				t.Fatalf("round trip: %v", err)
+			}
+		})
+	}
+
+	if _, err := EncodeTxErrorJSON([]byte(`"NotARealError"`)); err == nil {
+		t.Fatal("expected error for unknown variant")
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"log"
 	"time"
 
+	"github.com/rexbrahh/lp-indexer/ingestor/common"
+
 	pb "github.com/rpcpool/yellowstone-grpc/examples/golang/proto"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
@@ -173,6 +175,14 @@ func (c *Client) buildSubscribeRequest(startSlot uint64) *pb.SubscribeRequest {
 			Filters: []*pb.SubscribeRequestFilterAccountsFilter{},
 		}
 	}
+	if c.cfg.TokenMints != nil {
+		if filter := common.Token2022MintFilter(c.cfg.TokenMints()); filter != nil {
+			accounts[common.Token2022MintFilterName] = filter
+		}
+	}
+	if filter := common.OracleAccountFilter(c.cfg.OracleAccounts()); filter != nil {
+		accounts[common.OracleFilterName] = filter
+	}
 
 	commitment := pb.CommitmentLevel_CONFIRMED
 
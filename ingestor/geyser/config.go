@@ -3,6 +3,8 @@ package geyser
 import (
 	"fmt"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -18,6 +20,37 @@ type Config struct {
 
 	// ProgramFilters maps friendly names to Solana program IDs to filter
 	ProgramFilters map[string]string `yaml:"program_filters"`
+
+	// CaptureFailedSwaps publishes failed swap attempts from reverted
+	// transactions on the <root>.<program>.swap.failed subjects
+	CaptureFailedSwaps bool `yaml:"capture_failed_swaps"`
+
+	// OracleFeeds lists price accounts subscribed as reference prices
+	OracleFeeds []OracleFeed `yaml:"-"`
+
+	// TokenMints returns the mints of the indexed pools, whose Token-2022 mint
+	// accounts are subscribed; it is called on every (re)subscribe
+	TokenMints func() []string `yaml:"-"`
+
+	// Repair enables slot gap repair over JSON-RPC when non-nil
+	Repair *RepairConfig `yaml:"-"`
+}
+
+// OracleFeed is a Pyth price account streamed as a reference price. Mint, when
+// set, is the token the feed prices in USD.
+type OracleFeed struct {
+	Name    string `yaml:"-"`
+	Account string `yaml:"account"`
+	Mint    string `yaml:"mint"`
+}
+
+// OracleAccounts returns the price account of every configured feed.
+func (c *Config) OracleAccounts() []string {
+	accounts := make([]string, 0, len(c.OracleFeeds))
+	for _, feed := range c.OracleFeeds {
+		accounts = append(accounts, feed.Account)
+	}
+	return accounts
 }
 
 // LoadConfig loads configuration from environment variables and programs.yaml
@@ -28,34 +61,57 @@ func LoadConfig(programsYAMLPath string) (*Config, error) {
 		ProgramFilters: make(map[string]string),
 	}
 
-	// Load program filters from YAML
+	if v := os.Getenv("GEYSER_CAPTURE_FAILED_SWAPS"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GEYSER_CAPTURE_FAILED_SWAPS: %w", err)
+		}
+		cfg.CaptureFailedSwaps = enabled
+	}
+
+	repair, err := RepairConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Repair = repair
+
+	// Load program filters and oracle feeds from YAML
 	if programsYAMLPath != "" {
-		filters, err := loadProgramFilters(programsYAMLPath)
+		filters, feeds, err := loadProgramsFile(programsYAMLPath)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load program filters: %w", err)
 		}
 		cfg.ProgramFilters = filters
+		cfg.OracleFeeds = feeds
 	}
 
 	return cfg, nil
 }
 
-// loadProgramFilters reads program IDs from a YAML file
-func loadProgramFilters(path string) (map[string]string, error) {
+// loadProgramsFile reads program IDs and oracle feeds from a YAML file
+func loadProgramsFile(path string) (map[string]string, []OracleFeed, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read programs file: %w", err)
+		return nil, nil, fmt.Errorf("failed to read programs file: %w", err)
 	}
 
 	var config struct {
-		Programs map[string]string `yaml:"programs"`
+		Programs map[string]string     `yaml:"programs"`
+		Oracles  map[string]OracleFeed `yaml:"oracles"`
 	}
 
 	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse programs YAML: %w", err)
+		return nil, nil, fmt.Errorf("failed to parse programs YAML: %w", err)
 	}
 
-	return config.Programs, nil
+	feeds := make([]OracleFeed, 0, len(config.Oracles))
+	for name, feed := range config.Oracles {
+		feed.Name = name
+		feeds = append(feeds, feed)
+	}
+	sort.Slice(feeds, func(i, j int) bool { return feeds[i].Name < feeds[j].Name })
+
+	return config.Programs, feeds, nil
 }
 
 // Validate checks that required configuration fields are set
@@ -85,6 +141,12 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	for _, feed := range c.OracleFeeds {
+		if len(feed.Account) < 32 || len(feed.Account) > 44 {
+			errors = append(errors, fmt.Sprintf("oracle feed '%s' has invalid account: %q", feed.Name, feed.Account))
+		}
+	}
+
 	if len(errors) > 0 {
 		return fmt.Errorf("configuration validation failed:\n  - %s", strings.Join(errors, "\n  - "))
 	}
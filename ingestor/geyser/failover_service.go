@@ -11,15 +11,21 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 
+	swapdecoder "github.com/rexbrahh/lp-indexer/ingestor/decoder"
+	"github.com/rexbrahh/lp-indexer/ingestor/mintmeta"
+	"github.com/rexbrahh/lp-indexer/pricing"
+	"github.com/rexbrahh/lp-indexer/registry"
 	natsx "github.com/rexbrahh/lp-indexer/sinks/nats"
 )
 
-// FailoverService coordinates a primary/fallback client pair and feeds updates
-// through a shared processor. When the primary stream exits with an error, the
-// service attempts the fallback and periodically retries the primary.
+// FailoverService coordinates a primary client and an ordered list of
+// fallbacks and feeds updates through a shared processor. When the active
+// stream exits with an error, the service moves on to the next source and
+// periodically retries the primary.
 type FailoverService struct {
 	primary   ClientInterface
 	fallback  ClientInterface
+	extra     []ClientInterface
 	processor *Processor
 	metrics   *failoverMetrics
 
@@ -37,12 +43,12 @@ func NewFailoverService(primary ClientInterface, fallback ClientInterface, natsC
 		return nil, errors.New("primary client is required")
 	}
 
-	processor, registry, server, stopCh, err := setupPipeline(natsCfg, metricsAddr)
+	processor, promReg, server, stopCh, err := setupPipeline(natsCfg, metricsAddr)
 	if err != nil {
 		return nil, err
 	}
 
-	metrics := newFailoverMetrics(registry)
+	metrics := newFailoverMetrics(promReg)
 
 	return &FailoverService{
 		primary:            primary,
@@ -56,6 +62,54 @@ func NewFailoverService(primary ClientInterface, fallback ClientInterface, natsC
 	}, nil
 }
 
+// AddFallback appends a further fallback source, tried after the primary and
+// the fallback passed to NewFailoverService.
+func (s *FailoverService) AddFallback(client ClientInterface) {
+	if client != nil {
+		s.extra = append(s.extra, client)
+	}
+}
+
+// EnableGapRepair turns on slot gap detection and JSON-RPC repair when cfg
+// carries a repair configuration.
+func (s *FailoverService) EnableGapRepair(cfg *Config) error {
+	return enableGapRepair(s.processor, cfg)
+}
+
+// SetMintMetadata attaches an on-chain mint metadata provider to the
+// processor.
+func (s *FailoverService) SetMintMetadata(provider *mintmeta.Provider) {
+	s.processor.SetMintMetadata(provider)
+}
+
+// SetAccountLoader lets the processor's decoder fetch accounts the stream
+// has not delivered yet.
+func (s *FailoverService) SetAccountLoader(loader swapdecoder.AccountLoader, timeout time.Duration) {
+	s.processor.SetAccountLoader(loader, timeout)
+}
+
+// SetPoolRegistry attaches the pool registry to the processor.
+func (s *FailoverService) SetPoolRegistry(reg *registry.Registry) {
+	s.processor.SetPoolRegistry(reg)
+}
+
+// SetPriceOracle attaches the USD price oracle to the processor.
+func (s *FailoverService) SetPriceOracle(oracle *pricing.Oracle) {
+	s.processor.SetPriceOracle(oracle)
+}
+
+// SetOracleFeeds configures the reference price accounts the processor
+// decodes.
+func (s *FailoverService) SetOracleFeeds(feeds []OracleFeed) {
+	s.processor.SetOracleFeeds(feeds)
+}
+
+// SetCaptureFailedSwaps toggles publishing of failed swap attempts on the
+// shared processor.
+func (s *FailoverService) SetCaptureFailedSwaps(enabled bool) {
+	s.processor.SetCaptureFailedSwaps(enabled)
+}
+
 // Run executes the failover loop until the context is cancelled. startSlot is
 // forwarded to both clients (each is responsible for replaying recent slots).
 func (s *FailoverService) Run(ctx context.Context, startSlot uint64) error {
@@ -67,6 +121,7 @@ func (s *FailoverService) Run(ctx context.Context, startSlot uint64) error {
 	if s.fallback != nil {
 		clients = append(clients, s.fallback)
 	}
+	clients = append(clients, s.extra...)
 
 	if s.metricsServer != nil {
 		go func() {
@@ -77,10 +132,12 @@ func (s *FailoverService) Run(ctx context.Context, startSlot uint64) error {
 		}()
 	}
 
+	go s.processor.RunRepairs(ctx)
+
 	current := 0
 	for {
 		client := clients[current]
-		s.metrics.setActive(client.Name())
+		s.metrics.setActive(current)
 
 		start := time.Now()
 		err := s.runClient(ctx, client, startSlot)
@@ -98,7 +155,7 @@ func (s *FailoverService) Run(ctx context.Context, startSlot uint64) error {
 			continue
 		}
 
-		// Toggle between primary and fallback.
+		// Rotate through the primary and fallbacks in order.
 		current = (current + 1) % len(clients)
 		if current == 0 {
 			time.Sleep(s.primaryRetryDelay)
@@ -131,6 +188,10 @@ func (s *FailoverService) runClient(ctx context.Context, client ClientInterface,
 			if err := s.processor.HandleUpdate(ctx, update); err != nil {
 				return err
 			}
+		case result := <-s.processor.RepairResults():
+			if err := s.processor.ApplyRepair(ctx, result); err != nil {
+				return err
+			}
 		}
 	}
 }
@@ -159,7 +220,7 @@ func newFailoverMetrics(reg prometheus.Registerer) *failoverMetrics {
 			Namespace: "dex",
 			Subsystem: "ingestor",
 			Name:      "active_source",
-			Help:      "Indicates which ingest source is currently active (1=primary, 2=fallback)",
+			Help:      "Indicates which ingest source is currently active (1=primary, 2=fallback, 3+=further fallbacks)",
 		}),
 		failures: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 			Namespace: "dex",
@@ -170,19 +231,11 @@ func newFailoverMetrics(reg prometheus.Registerer) *failoverMetrics {
 	}
 }
 
-func (m *failoverMetrics) setActive(source string) {
+func (m *failoverMetrics) setActive(index int) {
 	if m == nil {
 		return
 	}
-	if source == "" {
-		m.activeSource.Set(0)
-		return
-	}
-	if source == "geyser" {
-		m.activeSource.Set(1)
-	} else {
-		m.activeSource.Set(2)
-	}
+	m.activeSource.Set(float64(index + 1))
 }
 
 func (m *failoverMetrics) recordFailure(source string) {
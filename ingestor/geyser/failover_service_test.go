@@ -63,6 +63,22 @@ func (p *failoverStubPublisher) PublishSwap(_ context.Context, ev *dexv1.SwapEve
 	return nil
 }
 
+func (p *failoverStubPublisher) PublishRoute(context.Context, *dexv1.Route) error {
+	return nil
+}
+
+func (p *failoverStubPublisher) PublishMev(context.Context, *dexv1.MevEvent) error {
+	return nil
+}
+
+func (p *failoverStubPublisher) PublishFailedSwap(context.Context, *dexv1.FailedSwapAttempt) error {
+	return nil
+}
+
+func (p *failoverStubPublisher) PublishDecodeFailure(context.Context, *dexv1.DecodeFailure) error {
+	return nil
+}
+
 func (p *failoverStubPublisher) PublishBlockHead(context.Context, *dexv1.BlockHead) error {
 	return nil
 }
@@ -71,6 +87,10 @@ func (p *failoverStubPublisher) PublishTxMeta(context.Context, *dexv1.TxMeta) er
 	return nil
 }
 
+func (p *failoverStubPublisher) PublishReferencePrice(context.Context, *dexv1.ReferencePrice) error {
+	return nil
+}
+
 func TestFailoverServiceSwitchesToFallback(t *testing.T) {
 	var fallbackInvoked sync.WaitGroup
 	fallbackInvoked.Add(1)
@@ -153,3 +173,51 @@ func TestFailoverServiceSwitchesToFallback(t *testing.T) {
 		t.Fatal("fallback client was never connected")
 	}
 }
+
+func TestFailoverServiceReachesLastResortSource(t *testing.T) {
+	reached := make(chan struct{})
+	var once sync.Once
+
+	failing := func(name string) *stubClient {
+		return &stubClient{name: name, connectErr: errors.New(name + " unavailable")}
+	}
+	primary := failing("geyser")
+	fallback := failing("helius")
+	rpc := &stubClient{
+		name: "rpc",
+		subscribeFn: func(uint64) (<-chan *pb.SubscribeUpdate, <-chan error) {
+			once.Do(func() { close(reached) })
+			return make(chan *pb.SubscribeUpdate), make(chan error)
+		},
+	}
+
+	svc := &FailoverService{
+		primary:            primary,
+		fallback:           fallback,
+		processor:          NewProcessor(&failoverStubPublisher{}, nil, prometheus.NewRegistry()),
+		metrics:            newFailoverMetrics(prometheus.NewRegistry()),
+		primaryRetryDelay:  5 * time.Millisecond,
+		fallbackRetryDelay: 5 * time.Millisecond,
+	}
+	svc.AddFallback(rpc)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- svc.Run(ctx, 0)
+	}()
+
+	select {
+	case <-reached:
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("last-resort source was not reached")
+	}
+	cancel()
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context cancellation, got %v", err)
+	}
+	if primary.connectCount == 0 || fallback.connectCount == 0 {
+		t.Fatalf("expected primary and fallback attempts, got %d/%d", primary.connectCount, fallback.connectCount)
+	}
+}
@@ -0,0 +1,554 @@
+package geyser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/rexbrahh/lp-indexer/ingestor/solrpc"
+
+	pb "github.com/rpcpool/yellowstone-grpc/examples/golang/proto"
+)
+
+const (
+	defaultRepairDelay    = 5 * time.Second
+	defaultRepairMaxSlots = 512
+	defaultRepairTimeout  = 10 * time.Second
+	repairQueueSize       = 256
+	repairCommitment      = "confirmed"
+	// finalityCheckInterval is how often repaired slots that were not yet
+	// finalized are re-checked.
+	finalityCheckInterval = 2 * time.Second
+)
+
+// Gap outcomes reported on the slot gap metric.
+const (
+	gapOutcomeRepaired = "repaired"
+	gapOutcomeSkipped  = "skipped"
+	gapOutcomeLate     = "late"
+	gapOutcomeFailed   = "failed"
+	gapOutcomeDropped  = "dropped"
+)
+
+// SlotRange is an inclusive range of slots.
+type SlotRange struct {
+	Start uint64
+	End   uint64
+}
+
+// Len returns the number of slots in the range.
+func (r SlotRange) Len() uint64 {
+	if r.End < r.Start {
+		return 0
+	}
+	return r.End - r.Start + 1
+}
+
+// RepairConfig configures slot gap repair over JSON-RPC.
+type RepairConfig struct {
+	// RPCEndpoint is the JSON-RPC URL used for getBlocks/getBlock.
+	RPCEndpoint string
+	// Delay is how long a gap is left open before repair, giving late
+	// block metas a chance to arrive through the stream.
+	Delay time.Duration
+	// MaxSlots caps the size of a single gap; older slots of a larger hole
+	// are counted as dropped.
+	MaxSlots uint64
+	// RequestTimeout bounds each RPC call.
+	RequestTimeout time.Duration
+}
+
+// RepairConfigFromEnv reads GAP_REPAIR_* variables. It returns nil when
+// GAP_REPAIR_RPC_URL is unset, leaving gap repair disabled.
+func RepairConfigFromEnv() (*RepairConfig, error) {
+	endpoint := os.Getenv("GAP_REPAIR_RPC_URL")
+	if endpoint == "" {
+		return nil, nil
+	}
+	cfg := &RepairConfig{
+		RPCEndpoint:    endpoint,
+		Delay:          defaultRepairDelay,
+		MaxSlots:       defaultRepairMaxSlots,
+		RequestTimeout: defaultRepairTimeout,
+	}
+	if v := os.Getenv("GAP_REPAIR_DELAY_MS"); v != "" {
+		ms, err := strconv.Atoi(v)
+		if err != nil || ms < 0 {
+			return nil, fmt.Errorf("invalid GAP_REPAIR_DELAY_MS: %q", v)
+		}
+		cfg.Delay = time.Duration(ms) * time.Millisecond
+	}
+	if v := os.Getenv("GAP_REPAIR_MAX_SLOTS"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || n == 0 {
+			return nil, fmt.Errorf("invalid GAP_REPAIR_MAX_SLOTS: %q", v)
+		}
+		cfg.MaxSlots = n
+	}
+	return cfg, nil
+}
+
+// gapTracker records observed block-meta slots and reports holes. It is
+// owned by the processor goroutine.
+type gapTracker struct {
+	highest  uint64
+	maxSlots uint64
+	missing  map[uint64]struct{}
+	// unfinalized holds repaired slots applied before they were finalized.
+	unfinalized map[uint64]struct{}
+}
+
+func newGapTracker(maxSlots uint64) *gapTracker {
+	return &gapTracker{
+		maxSlots:    maxSlots,
+		missing:     make(map[uint64]struct{}),
+		unfinalized: make(map[uint64]struct{}),
+	}
+}
+
+// observe records slot. When it jumps past the highest slot seen so far the
+// skipped range is marked missing and returned, together with the number of
+// older slots dropped because the hole exceeded maxSlots.
+func (g *gapTracker) observe(slot uint64) (SlotRange, uint64, bool) {
+	if g.highest == 0 {
+		g.highest = slot
+		return SlotRange{}, 0, false
+	}
+	if slot <= g.highest {
+		delete(g.missing, slot)
+		return SlotRange{}, 0, false
+	}
+	if slot == g.highest+1 {
+		g.highest = slot
+		return SlotRange{}, 0, false
+	}
+	gap := SlotRange{Start: g.highest + 1, End: slot - 1}
+	g.highest = slot
+	var dropped uint64
+	if gap.Len() > g.maxSlots {
+		dropped = gap.Len() - g.maxSlots
+		gap.Start = gap.End - g.maxSlots + 1
+	}
+	for s := gap.Start; s <= gap.End; s++ {
+		g.missing[s] = struct{}{}
+	}
+	return gap, dropped, true
+}
+
+// resolve clears slot and reports whether it was still missing.
+func (g *gapTracker) resolve(slot uint64) bool {
+	if _, ok := g.missing[slot]; !ok {
+		return false
+	}
+	delete(g.missing, slot)
+	return true
+}
+
+func (g *gapTracker) isMissing(slot uint64) bool {
+	_, ok := g.missing[slot]
+	return ok
+}
+
+// settle clears an unfinalized repaired slot and reports whether it was
+// still awaiting finality.
+func (g *gapTracker) settle(slot uint64) bool {
+	if _, ok := g.unfinalized[slot]; !ok {
+		return false
+	}
+	delete(g.unfinalized, slot)
+	return true
+}
+
+// RepairedBlock holds the updates reconstructed for one missing slot.
+type RepairedBlock struct {
+	Slot    uint64
+	Updates []*pb.SubscribeUpdate
+	// Final is set when Updates already carry the finalized status.
+	Final bool
+}
+
+// RepairResult is the outcome of repairing one gap, or of a later finality
+// check. Finalized and Dead list repaired slots that were only confirmed when
+// repaired and have since been rooted or abandoned.
+type RepairResult struct {
+	Range     SlotRange
+	Blocks    []RepairedBlock
+	Skipped   []uint64
+	Failed    []uint64
+	Finalized []uint64
+	Dead      []uint64
+}
+
+type repairJob struct {
+	gap SlotRange
+	due time.Time
+}
+
+// SlotRepairer fetches the blocks of slot gaps over JSON-RPC. Results are
+// handed back to the processor goroutine, which applies them with
+// Processor.ApplyRepair.
+type SlotRepairer struct {
+	cfg      RepairConfig
+	rpc      *solrpc.Client
+	programs map[string]struct{}
+	jobs     chan repairJob
+	results  chan RepairResult
+
+	// unfinalized holds repaired slots awaiting finality; it is owned by
+	// the Run goroutine.
+	unfinalized   map[uint64]struct{}
+	finalityEvery time.Duration
+}
+
+// NewSlotRepairer builds a repairer that keeps transactions referencing the
+// given programs.
+func NewSlotRepairer(cfg RepairConfig, programFilters map[string]string) (*SlotRepairer, error) {
+	if cfg.RPCEndpoint == "" {
+		return nil, errors.New("repair RPCEndpoint is required")
+	}
+	if cfg.MaxSlots == 0 {
+		cfg.MaxSlots = defaultRepairMaxSlots
+	}
+	if cfg.RequestTimeout <= 0 {
+		cfg.RequestTimeout = defaultRepairTimeout
+	}
+	programs := make(map[string]struct{}, len(programFilters))
+	for _, programID := range programFilters {
+		programs[programID] = struct{}{}
+	}
+	return &SlotRepairer{
+		cfg:      cfg,
+		rpc:      solrpc.NewClient(cfg.RPCEndpoint, cfg.RequestTimeout),
+		programs: programs,
+		jobs:     make(chan repairJob, repairQueueSize),
+		results:  make(chan RepairResult, repairQueueSize),
+
+		unfinalized:   make(map[uint64]struct{}),
+		finalityEvery: finalityCheckInterval,
+	}, nil
+}
+
+// Results returns the channel of completed repairs.
+func (r *SlotRepairer) Results() <-chan RepairResult {
+	if r == nil {
+		return nil
+	}
+	return r.results
+}
+
+// enqueue schedules gap for repair without blocking; it reports false when
+// the queue is full.
+func (r *SlotRepairer) enqueue(gap SlotRange) bool {
+	select {
+	case r.jobs <- repairJob{gap: gap, due: time.Now().Add(r.cfg.Delay)}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Run processes repair jobs in order until ctx is cancelled. Between jobs it
+// re-checks repaired slots that were not finalized yet.
+func (r *SlotRepairer) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.finalityEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, ok := r.checkFinality(ctx)
+			if !ok {
+				continue
+			}
+			select {
+			case r.results <- result:
+			case <-ctx.Done():
+				return
+			}
+		case job := <-r.jobs:
+			if wait := time.Until(job.due); wait > 0 {
+				select {
+				case <-time.After(wait):
+				case <-ctx.Done():
+					return
+				}
+			}
+			result := r.repair(ctx, job.gap)
+			for _, block := range result.Blocks {
+				if !block.Final {
+					r.unfinalized[block.Slot] = struct{}{}
+				}
+			}
+			select {
+			case r.results <- result:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// repair fetches every slot of gap. Slots absent from getBlocks, or reported
+// skipped by getBlock, were leader slots that produced no block.
+func (r *SlotRepairer) repair(ctx context.Context, gap SlotRange) RepairResult {
+	result := RepairResult{Range: gap}
+	produced, err := r.rpc.GetBlocks(ctx, gap.Start, gap.End, repairCommitment)
+	if err != nil {
+		log.Printf("slot repair %d-%d: %v", gap.Start, gap.End, err)
+		for s := gap.Start; s <= gap.End; s++ {
+			result.Failed = append(result.Failed, s)
+		}
+		return result
+	}
+	finalized, err := r.rpc.GetSlot(ctx, "finalized")
+	if err != nil {
+		finalized = 0
+	}
+
+	producedSet := make(map[uint64]struct{}, len(produced))
+	for _, s := range produced {
+		producedSet[s] = struct{}{}
+	}
+	for s := gap.Start; s <= gap.End; s++ {
+		if _, ok := producedSet[s]; !ok {
+			result.Skipped = append(result.Skipped, s)
+			continue
+		}
+		block, err := r.rpc.GetBlock(ctx, s, repairCommitment)
+		switch {
+		case solrpc.IsSlotSkipped(err), err == nil && block == nil:
+			result.Skipped = append(result.Skipped, s)
+			continue
+		case err != nil:
+			log.Printf("slot repair %d: %v", s, err)
+			result.Failed = append(result.Failed, s)
+			continue
+		}
+		updates, err := blockUpdates(block, r.programs, s <= finalized)
+		if err != nil {
+			log.Printf("slot repair %d: %v", s, err)
+			result.Failed = append(result.Failed, s)
+			continue
+		}
+		result.Blocks = append(result.Blocks, RepairedBlock{Slot: s, Updates: updates, Final: s <= finalized})
+	}
+	return result
+}
+
+// checkFinality resolves repaired slots the finalized slot has passed: those
+// on the finalized chain are reported finalized, the rest dead. It reports
+// false when there is nothing to settle yet.
+func (r *SlotRepairer) checkFinality(ctx context.Context) (RepairResult, bool) {
+	if len(r.unfinalized) == 0 {
+		return RepairResult{}, false
+	}
+	finalized, err := r.rpc.GetSlot(ctx, "finalized")
+	if err != nil {
+		log.Printf("slot repair finality check: %v", err)
+		return RepairResult{}, false
+	}
+	var settled []uint64
+	for s := range r.unfinalized {
+		if s <= finalized {
+			settled = append(settled, s)
+		}
+	}
+	if len(settled) == 0 {
+		return RepairResult{}, false
+	}
+	sort.Slice(settled, func(i, j int) bool { return settled[i] < settled[j] })
+	rooted, err := r.rpc.GetBlocks(ctx, settled[0], settled[len(settled)-1], "finalized")
+	if err != nil {
+		log.Printf("slot repair finality check %d-%d: %v", settled[0], settled[len(settled)-1], err)
+		return RepairResult{}, false
+	}
+	rootedSet := make(map[uint64]struct{}, len(rooted))
+	for _, s := range rooted {
+		rootedSet[s] = struct{}{}
+	}
+	var result RepairResult
+	for _, s := range settled {
+		delete(r.unfinalized, s)
+		if _, ok := rootedSet[s]; ok {
+			result.Finalized = append(result.Finalized, s)
+		} else {
+			result.Dead = append(result.Dead, s)
+		}
+	}
+	return result, true
+}
+
+// blockUpdates converts a block into the update sequence the stream would
+// have delivered: block meta, matching transactions, then slot statuses.
+func blockUpdates(block *solrpc.Block, programs map[string]struct{}, finalized bool) ([]*pb.SubscribeUpdate, error) {
+	txs, err := block.TransactionUpdates(programs)
+	if err != nil {
+		return nil, err
+	}
+	updates := make([]*pb.SubscribeUpdate, 0, len(txs)+3)
+	updates = append(updates, &pb.SubscribeUpdate{UpdateOneof: &pb.SubscribeUpdate_BlockMeta{BlockMeta: block.BlockMeta()}})
+	for _, tx := range txs {
+		updates = append(updates, &pb.SubscribeUpdate{UpdateOneof: &pb.SubscribeUpdate_Transaction{Transaction: tx}})
+	}
+	statuses := []pb.SlotStatus{pb.SlotStatus_SLOT_CONFIRMED}
+	if finalized {
+		statuses = append(statuses, pb.SlotStatus_SLOT_FINALIZED)
+	}
+	parent := block.ParentSlot
+	for _, status := range statuses {
+		updates = append(updates, &pb.SubscribeUpdate{UpdateOneof: &pb.SubscribeUpdate_Slot{
+			Slot: &pb.SubscribeUpdateSlot{Slot: block.Slot, Parent: &parent, Status: status},
+		}})
+	}
+	return updates, nil
+}
+
+// EnableGapRepair turns on slot gap tracking, handing detected holes to
+// repairer.
+func (p *Processor) EnableGapRepair(repairer *SlotRepairer) {
+	if repairer == nil {
+		return
+	}
+	p.repairer = repairer
+	p.gaps = newGapTracker(repairer.cfg.MaxSlots)
+}
+
+// RepairResults returns the repairer's result channel, or nil when gap
+// repair is disabled so that selecting on it blocks forever.
+func (p *Processor) RepairResults() <-chan RepairResult {
+	return p.repairer.Results()
+}
+
+// RunRepairs runs the repair worker until ctx is cancelled; it returns
+// immediately when gap repair is disabled.
+func (p *Processor) RunRepairs(ctx context.Context) {
+	if p.repairer != nil {
+		p.repairer.Run(ctx)
+	}
+}
+
+// observeSlot feeds a streamed block-meta slot to the gap tracker.
+func (p *Processor) observeSlot(slot uint64) {
+	if p.gaps == nil || p.repairing {
+		return
+	}
+	gap, dropped, ok := p.gaps.observe(slot)
+	if !ok {
+		p.metrics.setGapOpen(len(p.gaps.missing))
+		return
+	}
+	p.metrics.recordGap(dropped)
+	if !p.repairer.enqueue(gap) {
+		for s := gap.Start; s <= gap.End; s++ {
+			p.gaps.resolve(s)
+		}
+		p.metrics.recordGapSlots(gapOutcomeDropped, gap.Len())
+		log.Printf("slot repair queue full, dropping gap %d-%d", gap.Start, gap.End)
+	}
+	p.metrics.setGapOpen(len(p.gaps.missing))
+}
+
+// ApplyRepair runs repaired blocks through the normal decode path with the
+// repaired marker set. Slots that arrived through the stream in the meantime
+// are left alone. Repaired slots that were not finalized yet get their
+// finalized or dead status once a later result settles them.
+func (p *Processor) ApplyRepair(ctx context.Context, result RepairResult) error {
+	if p.gaps == nil {
+		return nil
+	}
+	defer func() { p.metrics.setGapOpen(len(p.gaps.missing)) }()
+
+	for _, slot := range result.Skipped {
+		if p.gaps.resolve(slot) {
+			p.metrics.recordGapSlots(gapOutcomeSkipped, 1)
+		}
+	}
+	for _, slot := range result.Failed {
+		if p.gaps.resolve(slot) {
+			p.metrics.recordGapSlots(gapOutcomeFailed, 1)
+		}
+	}
+	for _, block := range result.Blocks {
+		if !p.gaps.isMissing(block.Slot) {
+			p.metrics.recordGapSlots(gapOutcomeLate, 1)
+			continue
+		}
+		p.repairing = true
+		for _, update := range block.Updates {
+			if err := p.HandleUpdate(ctx, update); err != nil {
+				p.repairing = false
+				return fmt.Errorf("apply repaired slot %d: %w", block.Slot, err)
+			}
+		}
+		p.repairing = false
+		p.gaps.resolve(block.Slot)
+		if !block.Final {
+			p.gaps.unfinalized[block.Slot] = struct{}{}
+		}
+		p.metrics.recordGapSlots(gapOutcomeRepaired, 1)
+	}
+	for _, slot := range result.Finalized {
+		if err := p.settleRepaired(ctx, slot, pb.SlotStatus_SLOT_FINALIZED); err != nil {
+			return err
+		}
+	}
+	for _, slot := range result.Dead {
+		if err := p.settleRepaired(ctx, slot, pb.SlotStatus_SLOT_DEAD); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// settledSlot stops waiting on a repaired slot whose final status arrived
+// through the stream.
+func (p *Processor) settledSlot(slot uint64) {
+	if p.gaps != nil {
+		delete(p.gaps.unfinalized, slot)
+	}
+}
+
+func (p *Processor) settleRepaired(ctx context.Context, slot uint64, status pb.SlotStatus) error {
+	if !p.gaps.settle(slot) {
+		return nil
+	}
+	update := &pb.SubscribeUpdate{UpdateOneof: &pb.SubscribeUpdate_Slot{
+		Slot: &pb.SubscribeUpdateSlot{Slot: slot, Status: status},
+	}}
+	p.repairing = true
+	defer func() { p.repairing = false }()
+	if err := p.HandleUpdate(ctx, update); err != nil {
+		return fmt.Errorf("settle repaired slot %d: %w", slot, err)
+	}
+	return nil
+}
+
+func (m *processorMetrics) recordGap(dropped uint64) {
+	if m == nil {
+		return
+	}
+	m.slotGaps.Inc()
+	if dropped > 0 {
+		m.recordGapSlots(gapOutcomeDropped, dropped)
+	}
+}
+
+func (m *processorMetrics) recordGapSlots(outcome string, n uint64) {
+	if m == nil {
+		return
+	}
+	m.slotGapSlots.WithLabelValues(outcome).Add(float64(n))
+}
+
+func (m *processorMetrics) setGapOpen(n int) {
+	if m == nil {
+		return
+	}
+	m.slotGapOpen.Set(float64(n))
+}
@@ -0,0 +1,257 @@
+package geyser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rexbrahh/lp-indexer/ingestor/common"
+
+	pb "github.com/rpcpool/yellowstone-grpc/examples/golang/proto"
+)
+
+func TestGapTrackerDetectsHoles(t *testing.T) {
+	g := newGapTracker(4)
+
+	if _, _, ok := g.observe(100); ok {
+		t.Fatal("first slot must not open a gap")
+	}
+	if _, _, ok := g.observe(101); ok {
+		t.Fatal("contiguous slot must not open a gap")
+	}
+	gap, dropped, ok := g.observe(104)
+	if !ok || gap != (SlotRange{Start: 102, End: 103}) || dropped != 0 {
+		t.Fatalf("gap=%+v dropped=%d ok=%v", gap, dropped, ok)
+	}
+	if !g.isMissing(102) || !g.isMissing(103) {
+		t.Fatal("expected 102 and 103 to be missing")
+	}
+
+	// A late stream delivery fills its slot.
+	if _, _, ok := g.observe(102); ok {
+		t.Fatal("late slot must not open a gap")
+	}
+	if g.isMissing(102) {
+		t.Fatal("late slot should no longer be missing")
+	}
+
+	gap, dropped, ok = g.observe(115)
+	if !ok || gap != (SlotRange{Start: 111, End: 114}) || dropped != 6 {
+		t.Fatalf("gap=%+v dropped=%d ok=%v", gap, dropped, ok)
+	}
+	if !g.resolve(111) || g.resolve(111) {
+		t.Fatal("resolve should report a missing slot exactly once")
+	}
+}
+
+func TestProcessorAppliesRepairedBlock(t *testing.T) {
+	fixture := loadRaydiumFixture(t, "swap_tx_1.json")
+	pub := &stubPublisher{}
+	processor := NewProcessor(pub, common.NewMemorySlotTimeCache(), nil)
+
+	repairer, err := NewSlotRepairer(RepairConfig{RPCEndpoint: "http://127.0.0.1:0"}, nil)
+	if err != nil {
+		t.Fatalf("NewSlotRepairer: %v", err)
+	}
+	processor.EnableGapRepair(repairer)
+
+	ctx := context.Background()
+	for _, slot := range []uint64{fixture.Slot - 2, fixture.Slot + 2} {
+		if err := processor.HandleUpdate(ctx, blockMetaUpdate(slot, fixture.Timestamp)); err != nil {
+			t.Fatalf("HandleUpdate block meta %d: %v", slot, err)
+		}
+	}
+
+	select {
+	case job := <-repairer.jobs:
+		if job.gap != (SlotRange{Start: fixture.Slot - 1, End: fixture.Slot + 1}) {
+			t.Fatalf("unexpected repair job %+v", job.gap)
+		}
+	default:
+		t.Fatal("expected a repair job to be enqueued")
+	}
+
+	// The stream delivers slot+1 late; the repaired copy must be ignored.
+	if err := processor.HandleUpdate(ctx, blockMetaUpdate(fixture.Slot+1, fixture.Timestamp)); err != nil {
+		t.Fatalf("HandleUpdate late block meta: %v", err)
+	}
+
+	result := RepairResult{
+		Range:   SlotRange{Start: fixture.Slot - 1, End: fixture.Slot + 1},
+		Skipped: []uint64{fixture.Slot - 1},
+		Blocks: []RepairedBlock{
+			{
+				Slot: fixture.Slot,
+				Updates: []*pb.SubscribeUpdate{
+					blockMetaUpdate(fixture.Slot, fixture.Timestamp),
+					buildRaydiumUpdate(t, fixture),
+				},
+			},
+			{
+				Slot:    fixture.Slot + 1,
+				Updates: []*pb.SubscribeUpdate{buildRaydiumUpdate(t, fixture)},
+			},
+		},
+	}
+	if err := processor.ApplyRepair(ctx, result); err != nil {
+		t.Fatalf("ApplyRepair: %v", err)
+	}
+
+	if len(pub.events) != 1 {
+		t.Fatalf("expected 1 repaired swap, got %d", len(pub.events))
+	}
+	ev := pub.events[0]
+	if !ev.GetRepaired() {
+		t.Fatal("expected repaired flag on swap")
+	}
+	if ev.GetSlot() != fixture.Slot {
+		t.Fatalf("slot=%d want %d", ev.GetSlot(), fixture.Slot)
+	}
+	if len(processor.gaps.missing) != 0 {
+		t.Fatalf("expected no open gap slots, got %d", len(processor.gaps.missing))
+	}
+
+	// Streamed swaps after the repair are not marked.
+	if err := processor.HandleUpdate(ctx, buildRaydiumUpdate(t, fixture)); err != nil {
+		t.Fatalf("HandleUpdate transaction: %v", err)
+	}
+	if pub.events[len(pub.events)-1].GetRepaired() {
+		t.Fatal("streamed swap must not be marked repaired")
+	}
+}
+
+func TestProcessorFinalizesRepairedSlotLater(t *testing.T) {
+	fixture := loadRaydiumFixture(t, "swap_tx_1.json")
+	pub := &stubPublisher{}
+	processor := NewProcessor(pub, common.NewMemorySlotTimeCache(), nil)
+
+	repairer, err := NewSlotRepairer(RepairConfig{RPCEndpoint: "http://127.0.0.1:0"}, nil)
+	if err != nil {
+		t.Fatalf("NewSlotRepairer: %v", err)
+	}
+	processor.EnableGapRepair(repairer)
+
+	ctx := context.Background()
+	for _, slot := range []uint64{fixture.Slot - 1, fixture.Slot + 1} {
+		if err := processor.HandleUpdate(ctx, blockMetaUpdate(slot, fixture.Timestamp)); err != nil {
+			t.Fatalf("HandleUpdate block meta %d: %v", slot, err)
+		}
+	}
+
+	// Repaired while only confirmed: the swap stays provisional.
+	result := RepairResult{
+		Range: SlotRange{Start: fixture.Slot, End: fixture.Slot},
+		Blocks: []RepairedBlock{{
+			Slot: fixture.Slot,
+			Updates: []*pb.SubscribeUpdate{
+				blockMetaUpdate(fixture.Slot, fixture.Timestamp),
+				buildRaydiumUpdate(t, fixture),
+				slotStatusUpdate(fixture.Slot, pb.SlotStatus_SLOT_CONFIRMED),
+			},
+		}},
+	}
+	if err := processor.ApplyRepair(ctx, result); err != nil {
+		t.Fatalf("ApplyRepair: %v", err)
+	}
+	if len(pub.events) != 1 || !pub.events[0].GetProvisional() {
+		t.Fatalf("expected one provisional swap, got %v", pub.events)
+	}
+	if len(processor.pending[fixture.Slot]) != 1 {
+		t.Fatalf("expected the repaired swap to be pending")
+	}
+
+	if err := processor.ApplyRepair(ctx, RepairResult{Finalized: []uint64{fixture.Slot}}); err != nil {
+		t.Fatalf("ApplyRepair finality: %v", err)
+	}
+	if len(pub.events) != 2 || pub.events[1].GetProvisional() {
+		t.Fatalf("expected a finalized swap, got %v", pub.events)
+	}
+	if _, ok := processor.pending[fixture.Slot]; ok {
+		t.Fatal("pending swaps leaked after finalization")
+	}
+
+	// A repeated finality result is ignored.
+	if err := processor.ApplyRepair(ctx, RepairResult{Finalized: []uint64{fixture.Slot}}); err != nil {
+		t.Fatalf("ApplyRepair finality: %v", err)
+	}
+	if len(pub.events) != 2 {
+		t.Fatalf("expected no further swaps, got %d", len(pub.events))
+	}
+}
+
+func TestSlotRepairerChecksFinality(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     int    `json:"id"`
+			Method string `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		switch req.Method {
+		case "getSlot":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":105}`, req.ID)
+		case "getBlocks":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":[100,102]}`, req.ID)
+		default:
+			http.Error(w, "unexpected method", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	repairer, err := NewSlotRepairer(RepairConfig{RPCEndpoint: server.URL}, nil)
+	if err != nil {
+		t.Fatalf("NewSlotRepairer: %v", err)
+	}
+	for _, slot := range []uint64{100, 101, 102, 110} {
+		repairer.unfinalized[slot] = struct{}{}
+	}
+
+	result, ok := repairer.checkFinality(context.Background())
+	if !ok {
+		t.Fatal("expected a finality result")
+	}
+	if fmt.Sprint(result.Finalized) != "[100 102]" || fmt.Sprint(result.Dead) != "[101]" {
+		t.Fatalf("finalized=%v dead=%v", result.Finalized, result.Dead)
+	}
+	if _, ok := repairer.unfinalized[110]; !ok || len(repairer.unfinalized) != 1 {
+		t.Fatalf("expected only slot 110 to remain, got %v", repairer.unfinalized)
+	}
+}
+
+func TestProcessorRepairDisabled(t *testing.T) {
+	processor := NewProcessor(&stubPublisher{}, common.NewMemorySlotTimeCache(), nil)
+	if processor.RepairResults() != nil {
+		t.Fatal("expected nil result channel without a repairer")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	processor.RunRepairs(ctx)
+	if err := processor.ApplyRepair(ctx, RepairResult{}); err != nil {
+		t.Fatalf("ApplyRepair: %v", err)
+	}
+}
+
+func blockMetaUpdate(slot uint64, ts int64) *pb.SubscribeUpdate {
+	return &pb.SubscribeUpdate{
+		UpdateOneof: &pb.SubscribeUpdate_BlockMeta{
+			BlockMeta: &pb.SubscribeUpdateBlockMeta{
+				Slot:      slot,
+				BlockTime: &pb.UnixTimestamp{Timestamp: ts},
+			},
+		},
+	}
+}
+
+func slotStatusUpdate(slot uint64, status pb.SlotStatus) *pb.SubscribeUpdate {
+	return &pb.SubscribeUpdate{
+		UpdateOneof: &pb.SubscribeUpdate_Slot{
+			Slot: &pb.SubscribeUpdateSlot{Slot: slot, Status: status},
+		},
+	}
+}
@@ -0,0 +1,77 @@
+package geyser
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mr-tron/base58/base58"
+
+	"github.com/rexbrahh/lp-indexer/decoder/pyth"
+	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+
+	pb "github.com/rpcpool/yellowstone-grpc/examples/golang/proto"
+)
+
+// SetOracleFeeds configures the Pyth price accounts decoded from the account
+// stream. Each update is published as a ReferencePrice; trading prices of
+// feeds with a mint also feed the USD price oracle and the DEX deviation
+// metric.
+func (p *Processor) SetOracleFeeds(feeds []OracleFeed) {
+	p.oracleFeeds = make(map[string]OracleFeed, len(feeds))
+	for _, feed := range feeds {
+		p.oracleFeeds[feed.Account] = feed
+	}
+}
+
+func (p *Processor) handleOracleAccount(ctx context.Context, account *pb.SubscribeUpdateAccount) error {
+	info := account.GetAccount()
+	if info == nil {
+		return nil
+	}
+	address := base58.Encode(info.GetPubkey())
+	feed, ok := p.oracleFeeds[address]
+	if !ok {
+		return nil
+	}
+	price, err := pyth.Decode(info.GetData())
+	if err != nil {
+		// Feeds are configured by hand; a wrong account should not stop the
+		// stream.
+		return nil
+	}
+
+	ref := &dexv1.ReferencePrice{
+		ChainId:     chainIDSolana,
+		Slot:        account.GetSlot(),
+		Source:      price.Source,
+		Account:     address,
+		Feed:        feed.Name,
+		FeedId:      price.FeedID,
+		Mint:        feed.Mint,
+		Price:       price.Price,
+		Conf:        price.Conf,
+		Expo:        price.Expo,
+		PublishTime: price.PublishTime,
+		Status:      price.Status,
+		PriceUsd:    price.Value(),
+		ConfUsd:     price.Confidence(),
+	}
+	if price.Status == pyth.StatusTrading && feed.Mint != "" && p.prices != nil {
+		p.prices.ObserveReference(feed.Mint, ref.PriceUsd, time.Unix(price.PublishTime, 0))
+		if dex, ok := p.prices.DEXPriceUSD(feed.Mint, time.Now()); ok {
+			p.metrics.setOracleDeviation(feed.Name, (dex-ref.PriceUsd)/ref.PriceUsd*10_000)
+		}
+	}
+	if err := p.publisher.PublishReferencePrice(ctx, ref); err != nil {
+		return fmt.Errorf("publish reference price: %w", err)
+	}
+	return nil
+}
+
+func (m *processorMetrics) setOracleDeviation(feed string, bps float64) {
+	if m == nil {
+		return
+	}
+	m.oracleDev.WithLabelValues(feed).Set(bps)
+}
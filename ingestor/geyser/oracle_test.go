@@ -0,0 +1,84 @@
+package geyser
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mr-tron/base58/base58"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/rexbrahh/lp-indexer/decoder/pyth"
+	"github.com/rexbrahh/lp-indexer/ingestor/common"
+	"github.com/rexbrahh/lp-indexer/pricing"
+
+	pb "github.com/rpcpool/yellowstone-grpc/examples/golang/proto"
+)
+
+// buildLegacyPythPrice lays out a push-oracle price account with a trading
+// aggregate price of price * 10^-8.
+func buildLegacyPythPrice(price int64, publishTime int64) []byte {
+	expo := int32(-8)
+	data := make([]byte, 3312)
+	binary.LittleEndian.PutUint32(data[0:], 0xa1b2c3d4)
+	binary.LittleEndian.PutUint32(data[4:], 2)
+	binary.LittleEndian.PutUint32(data[8:], 3)
+	binary.LittleEndian.PutUint32(data[20:], uint32(expo))
+	binary.LittleEndian.PutUint64(data[96:], uint64(publishTime))
+	binary.LittleEndian.PutUint64(data[208:], uint64(price))
+	binary.LittleEndian.PutUint64(data[216:], 5_000_000)
+	binary.LittleEndian.PutUint32(data[224:], 1)
+	return data
+}
+
+func TestProcessorPublishesReferencePrice(t *testing.T) {
+	pub := &stubPublisher{}
+	processor := NewProcessor(pub, common.NewMemorySlotTimeCache(), nil)
+	oracle := pricing.New(nil)
+	processor.SetPriceOracle(oracle)
+
+	feedKey := generateAddress(0x42)
+	processor.SetOracleFeeds([]OracleFeed{{Name: "sol_usd", Account: base58.Encode(feedKey), Mint: pricing.SOLMint}})
+
+	now := time.Now()
+	oracle.Observe(pricing.Quote{PoolID: "sol-usdc", Base: pricing.SOLMint, Quote: pricing.USDCMint, Price: 152, Depth: 1_000_000, At: now})
+
+	update := &pb.SubscribeUpdate{UpdateOneof: &pb.SubscribeUpdate_Account{Account: &pb.SubscribeUpdateAccount{
+		Slot: 250_000_000,
+		Account: &pb.SubscribeUpdateAccountInfo{
+			Pubkey: feedKey,
+			Owner:  mustDecodeBase58(t, pyth.OracleProgramID),
+			Data:   buildLegacyPythPrice(16_000_000_000, now.Unix()),
+		},
+	}}}
+	if err := processor.HandleUpdate(context.Background(), update); err != nil {
+		t.Fatalf("HandleUpdate: %v", err)
+	}
+
+	if len(pub.refs) != 1 {
+		t.Fatalf("expected 1 reference price, got %d", len(pub.refs))
+	}
+	ref := pub.refs[0]
+	if ref.GetFeed() != "sol_usd" || ref.GetMint() != pricing.SOLMint || ref.GetSource() != pyth.SourceLegacy || ref.GetStatus() != pyth.StatusTrading {
+		t.Fatalf("unexpected reference price %+v", ref)
+	}
+	if ref.GetSlot() != 250_000_000 || ref.GetPriceUsd() != 160 || ref.GetConfUsd() != 0.05 {
+		t.Fatalf("unexpected reference price %+v", ref)
+	}
+
+	if price, _ := oracle.PriceUSD(pricing.SOLMint, now); price != 160 {
+		t.Fatalf("oracle SOL = %g, want reference 160", price)
+	}
+	deviation := testutil.ToFloat64(processor.metrics.oracleDev.WithLabelValues("sol_usd"))
+	if math.Abs(deviation-(-500)) > 1e-6 {
+		t.Fatalf("deviation = %g bps, want -500", deviation)
+	}
+
+	// Accounts that are not configured feeds are ignored.
+	update.GetAccount().Account.Pubkey = generateAddress(0x43)
+	if err := processor.HandleUpdate(context.Background(), update); err != nil || len(pub.refs) != 1 {
+		t.Fatalf("unconfigured account published: refs=%d err=%v", len(pub.refs), err)
+	}
+}
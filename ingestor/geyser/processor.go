@@ -4,7 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
+	"time"
 
+	"github.com/mr-tron/base58/base58"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	proto "google.golang.org/protobuf/proto"
@@ -15,7 +18,12 @@ import (
 	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
 	"github.com/rexbrahh/lp-indexer/ingestor/common"
 	swapdecoder "github.com/rexbrahh/lp-indexer/ingestor/decoder"
+	poolmeta "github.com/rexbrahh/lp-indexer/ingestor/internal/pools"
+	"github.com/rexbrahh/lp-indexer/ingestor/mev"
+	"github.com/rexbrahh/lp-indexer/ingestor/mintmeta"
 	"github.com/rexbrahh/lp-indexer/observability"
+	"github.com/rexbrahh/lp-indexer/pricing"
+	"github.com/rexbrahh/lp-indexer/registry"
 
 	pb "github.com/rpcpool/yellowstone-grpc/examples/golang/proto"
 )
@@ -25,8 +33,13 @@ const chainIDSolana = 501
 // SwapPublisher publishes canonical swap events.
 type SwapPublisher interface {
 	PublishSwap(ctx context.Context, event *dexv1.SwapEvent) error
+	PublishRoute(ctx context.Context, route *dexv1.Route) error
+	PublishMev(ctx context.Context, event *dexv1.MevEvent) error
+	PublishFailedSwap(ctx context.Context, attempt *dexv1.FailedSwapAttempt) error
+	PublishDecodeFailure(ctx context.Context, failure *dexv1.DecodeFailure) error
 	PublishBlockHead(ctx context.Context, head *dexv1.BlockHead) error
 	PublishTxMeta(ctx context.Context, meta *dexv1.TxMeta) error
+	PublishReferencePrice(ctx context.Context, price *dexv1.ReferencePrice) error
 }
 
 // Processor consumes geyser updates and emits canonical swap events.
@@ -35,7 +48,20 @@ type Processor struct {
 	decoder    *swapdecoder.Decoder
 	metrics    *processorMetrics
 	pending    map[uint64][]*dexv1.SwapEvent
+	routes     map[uint64][]*dexv1.Route
 	blockHeads map[uint64]*dexv1.BlockHead
+
+	captureFailedSwaps bool
+	mints              *mintmeta.Provider
+	pools              *registry.Registry
+	prices             *pricing.Oracle
+	oracleFeeds        map[string]OracleFeed
+
+	// Slot gap repair; see gaps.go. repairing is set while a repaired block
+	// is replayed so its swaps carry the repaired marker.
+	repairer  *SlotRepairer
+	gaps      *gapTracker
+	repairing bool
 }
 
 // NewProcessor initialises a Processor with optional metrics registration.
@@ -45,10 +71,43 @@ func NewProcessor(publisher SwapPublisher, cache common.SlotTimeCache, reg prome
 		decoder:    swapdecoder.New(cache),
 		metrics:    newProcessorMetrics(reg),
 		pending:    make(map[uint64][]*dexv1.SwapEvent),
+		routes:     make(map[uint64][]*dexv1.Route),
 		blockHeads: make(map[uint64]*dexv1.BlockHead),
 	}
 }
 
+// SetCaptureFailedSwaps toggles publishing of failed swap attempts. Failed
+// transactions never produce swap events; with capture enabled each attempted
+// DEX instruction is published as a FailedSwapAttempt instead.
+func (p *Processor) SetCaptureFailedSwaps(enabled bool) {
+	p.captureFailedSwaps = enabled
+}
+
+// SetMintMetadata attaches an on-chain mint metadata provider. Streamed
+// account updates are forwarded to it and the mints of published swaps are
+// queued for loading when unknown.
+func (p *Processor) SetMintMetadata(provider *mintmeta.Provider) {
+	p.mints = provider
+}
+
+// SetAccountLoader lets the decoder fetch accounts the stream has not
+// delivered yet over JSON-RPC.
+func (p *Processor) SetAccountLoader(loader swapdecoder.AccountLoader, timeout time.Duration) {
+	p.decoder.SetAccountLoader(loader, timeout)
+}
+
+// SetPoolRegistry attaches the pool registry. Decoded Raydium and Orca pool
+// accounts and every published swap are recorded in it.
+func (p *Processor) SetPoolRegistry(reg *registry.Registry) {
+	p.pools = reg
+}
+
+// SetPriceOracle attaches the USD price oracle. Every published swap updates
+// it and is stamped with its USD price and volume.
+func (p *Processor) SetPriceOracle(oracle *pricing.Oracle) {
+	p.prices = oracle
+}
+
 // HandleUpdate inspects an incoming geyser update and routes it to the decoder.
 func (p *Processor) HandleUpdate(ctx context.Context, update *pb.SubscribeUpdate) error {
 	if update == nil {
@@ -61,7 +120,7 @@ func (p *Processor) HandleUpdate(ctx context.Context, update *pb.SubscribeUpdate
 	case *pb.SubscribeUpdate_BlockMeta:
 		return p.handleBlockMeta(ctx, u.BlockMeta)
 	case *pb.SubscribeUpdate_Account:
-		p.handleAccount(u.Account)
+		return p.handleAccount(ctx, u.Account)
 	case *pb.SubscribeUpdate_Slot:
 		return p.handleSlot(ctx, u.Slot)
 	}
@@ -76,10 +135,13 @@ func (p *Processor) handleTransaction(ctx context.Context, tx *pb.SubscribeUpdat
 	events, err := p.decoder.DecodeTransaction(tx)
 	if err != nil {
 		var decodeErr *swapdecoder.DecodeError
-		if errors.As(err, &decodeErr) {
-			p.metrics.recordError(decodeErr.Program)
+		if !errors.As(err, &decodeErr) {
+			return fmt.Errorf("decode transaction: %w", err)
 		}
-		return fmt.Errorf("decode transaction: %w", err)
+		// A transaction the decoder cannot handle is dead-lettered rather than
+		// failing the stream; it can be replayed once the decoder is fixed.
+		p.metrics.recordError(decodeErr.Program)
+		return p.deadLetter(ctx, tx, decodeErr)
 	}
 
 	if meta := common.ConvertTxMeta(tx); meta != nil {
@@ -88,7 +150,23 @@ func (p *Processor) handleTransaction(ctx context.Context, tx *pb.SubscribeUpdat
 		}
 	}
 
+	if p.captureFailedSwaps {
+		p.publishFailedAttempts(ctx, tx)
+	}
+
 	for _, ev := range events {
+		ev.Repaired = p.repairing
+		if p.mints != nil {
+			p.mints.Want(ev.GetMintBase(), ev.GetMintQuote())
+		}
+		if p.pools != nil {
+			p.pools.ObserveSwap(ev)
+		}
+		if p.prices != nil {
+			now := time.Now()
+			p.prices.ObserveSwap(ev, now)
+			ev.PriceUsd, ev.VolumeUsd, _ = p.prices.ValueSwap(ev, now)
+		}
 		p.metrics.recordSwap(ev.GetProgramId())
 		if err := p.publisher.PublishSwap(ctx, ev); err != nil {
 			p.metrics.recordError(ev.GetProgramId())
@@ -96,14 +174,51 @@ func (p *Processor) handleTransaction(ctx context.Context, tx *pb.SubscribeUpdat
 		}
 		p.appendPending(ev.GetSlot(), ev)
 	}
+
+	if route := swapdecoder.BuildRoute(tx, events); route != nil {
+		if err := p.publisher.PublishRoute(ctx, route); err != nil {
+			return fmt.Errorf("publish route: %w", err)
+		}
+		p.routes[route.GetSlot()] = append(p.routes[route.GetSlot()], proto.Clone(route).(*dexv1.Route))
+	}
 	return nil
 }
 
+func (p *Processor) deadLetter(ctx context.Context, tx *pb.SubscribeUpdateTransaction, decodeErr *swapdecoder.DecodeError) error {
+	failure, err := swapdecoder.NewDecodeFailure(tx, decodeErr, time.Now())
+	if err != nil {
+		return fmt.Errorf("build decode failure: %w", err)
+	}
+	p.metrics.recordDeadLetter(decodeErr.Program)
+	if err := p.publisher.PublishDecodeFailure(ctx, failure); err != nil {
+		return fmt.Errorf("publish decode failure: %w", err)
+	}
+	return nil
+}
+
+// publishFailedAttempts is best effort: failed attempts are analytics only, so
+// decode and publish errors are logged rather than tearing down the stream.
+func (p *Processor) publishFailedAttempts(ctx context.Context, tx *pb.SubscribeUpdateTransaction) {
+	attempts, err := p.decoder.DecodeFailedAttempts(tx)
+	if err != nil {
+		log.Printf("decode failed attempts of slot %d: %v", tx.GetSlot(), err)
+		return
+	}
+	for _, attempt := range attempts {
+		p.metrics.recordFailedAttempt(attempt.GetProgramId())
+		if err := p.publisher.PublishFailedSwap(ctx, attempt); err != nil {
+			p.metrics.recordError(attempt.GetProgramId())
+			log.Printf("publish failed swap %s: %v", attempt.GetSig(), err)
+		}
+	}
+}
+
 func (p *Processor) handleBlockMeta(ctx context.Context, meta *pb.SubscribeUpdateBlockMeta) error {
 	p.decoder.HandleBlockMeta(meta)
 	if meta == nil {
 		return nil
 	}
+	p.observeSlot(meta.GetSlot())
 
 	head := &dexv1.BlockHead{
 		ChainId: chainIDSolana,
@@ -117,8 +232,54 @@ func (p *Processor) handleBlockMeta(ctx context.Context, meta *pb.SubscribeUpdat
 	return p.publisher.PublishBlockHead(ctx, proto.Clone(head).(*dexv1.BlockHead))
 }
 
-func (p *Processor) handleAccount(account *pb.SubscribeUpdateAccount) {
+func (p *Processor) handleAccount(ctx context.Context, account *pb.SubscribeUpdateAccount) error {
 	p.decoder.HandleAccount(account)
+	if p.mints != nil {
+		p.mints.HandleAccount(account)
+	}
+	if p.pools != nil {
+		observePoolAccount(p.pools, account)
+	}
+	if len(p.oracleFeeds) > 0 {
+		return p.handleOracleAccount(ctx, account)
+	}
+	return nil
+}
+
+// observePoolAccount records pool accounts in the registry. Raydium fee tiers
+// live in the AmmConfig account and arrive with the pool's swaps instead.
+func observePoolAccount(reg *registry.Registry, account *pb.SubscribeUpdateAccount) {
+	info := account.GetAccount()
+	if info == nil {
+		return
+	}
+	pool := registry.Pool{
+		Address:   base58.Encode(info.GetPubkey()),
+		ProgramID: base58.Encode(info.GetOwner()),
+		FirstSlot: account.GetSlot(),
+		LastSlot:  account.GetSlot(),
+	}
+	switch pool.ProgramID {
+	case ray.ProgramID:
+		if !poolmeta.HasPoolDiscriminator(info.GetData()) {
+			return
+		}
+		state, err := poolmeta.DecodeRaydiumPoolInfo(info.GetData())
+		if err != nil {
+			return
+		}
+		pool.MintA, pool.MintB = state.TokenMint0, state.TokenMint1
+	case orcawhirlpool.WhirlpoolProgramID:
+		state, err := poolmeta.DecodeOrcaPool(info.GetData())
+		if err != nil {
+			return
+		}
+		pool.MintA, pool.MintB = state.TokenMintA, state.TokenMintB
+		pool.FeeBps = uint32(state.FeeRate / 100)
+	default:
+		return
+	}
+	reg.Observe(pool)
 }
 
 func (p *Processor) handleSlot(ctx context.Context, update *pb.SubscribeUpdateSlot) error {
@@ -127,12 +288,16 @@ func (p *Processor) handleSlot(ctx context.Context, update *pb.SubscribeUpdateSl
 	}
 	slot := update.GetSlot()
 	switch update.GetStatus() {
+	case pb.SlotStatus_SLOT_CONFIRMED:
+		return p.analyzeSlot(ctx, slot)
 	case pb.SlotStatus_SLOT_FINALIZED:
+		p.settledSlot(slot)
 		if err := p.finalizeSlot(ctx, slot); err != nil {
 			return err
 		}
 		return p.publishBlockHeadStatus(ctx, slot, "finalized")
 	case pb.SlotStatus_SLOT_DEAD:
+		p.settledSlot(slot)
 		if err := p.undoSlot(ctx, slot); err != nil {
 			return err
 		}
@@ -142,7 +307,23 @@ func (p *Processor) handleSlot(ctx context.Context, update *pb.SubscribeUpdateSl
 	}
 }
 
+// analyzeSlot runs MEV detection over the slot's pending swaps once the slot
+// is confirmed. Victim flags land on the pending copies, so the finalized
+// swaps carry them.
+func (p *Processor) analyzeSlot(ctx context.Context, slot uint64) error {
+	for _, ev := range mev.Analyze(p.pending[slot]) {
+		p.metrics.recordMev(ev.GetKind())
+		if err := p.publisher.PublishMev(ctx, ev); err != nil {
+			return fmt.Errorf("publish mev event: %w", err)
+		}
+	}
+	return nil
+}
+
 func (p *Processor) finalizeSlot(ctx context.Context, slot uint64) error {
+	if err := p.settleRoutes(ctx, slot, false); err != nil {
+		return err
+	}
 	events := p.pending[slot]
 	if len(events) == 0 {
 		delete(p.pending, slot)
@@ -162,6 +343,9 @@ func (p *Processor) finalizeSlot(ctx context.Context, slot uint64) error {
 }
 
 func (p *Processor) undoSlot(ctx context.Context, slot uint64) error {
+	if err := p.settleRoutes(ctx, slot, true); err != nil {
+		return err
+	}
 	events := p.pending[slot]
 	if len(events) == 0 {
 		delete(p.pending, slot)
@@ -180,6 +364,25 @@ func (p *Processor) undoSlot(ctx context.Context, slot uint64) error {
 	return nil
 }
 
+// settleRoutes republishes the slot's routes once it is finalized or dead,
+// mirroring the flags applied to the individual swaps.
+func (p *Processor) settleRoutes(ctx context.Context, slot uint64, isUndo bool) error {
+	routes := p.routes[slot]
+	delete(p.routes, slot)
+	for _, route := range routes {
+		route.Provisional = false
+		route.IsUndo = isUndo
+		for _, leg := range route.GetLegs() {
+			leg.Provisional = false
+			leg.IsUndo = isUndo
+		}
+		if err := p.publisher.PublishRoute(ctx, route); err != nil {
+			return fmt.Errorf("publish settled route: %w", err)
+		}
+	}
+	return nil
+}
+
 func (p *Processor) publishBlockHeadStatus(ctx context.Context, slot uint64, status string) error {
 	head, ok := p.blockHeads[slot]
 	if !ok {
@@ -212,6 +415,13 @@ type processorMetrics struct {
 	orcaErrors    prometheus.Counter
 	meteoraSwaps  prometheus.Counter
 	meteoraErrors prometheus.Counter
+	failedSwaps   *prometheus.CounterVec
+	mevEvents     *prometheus.CounterVec
+	deadLetters   *prometheus.CounterVec
+	slotGaps      prometheus.Counter
+	slotGapSlots  *prometheus.CounterVec
+	slotGapOpen   prometheus.Gauge
+	oracleDev     *prometheus.GaugeVec
 }
 
 func newProcessorMetrics(reg prometheus.Registerer) *processorMetrics {
@@ -255,6 +465,48 @@ func newProcessorMetrics(reg prometheus.Registerer) *processorMetrics {
 			Name:      observability.MetricMeteoraDecodeErrors,
 			Help:      "Meteora swap decode or publish errors.",
 		}),
+		failedSwaps: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "dex",
+			Subsystem: "geyser",
+			Name:      observability.MetricFailedSwapAttemptsTotal,
+			Help:      "Failed swap attempts observed in reverted transactions.",
+		}, []string{"program"}),
+		mevEvents: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "dex",
+			Subsystem: "geyser",
+			Name:      observability.MetricMevEventsTotal,
+			Help:      "MEV patterns detected in confirmed slots.",
+		}, []string{"kind"}),
+		deadLetters: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "dex",
+			Subsystem: "geyser",
+			Name:      observability.MetricDecodeDLQTotal,
+			Help:      "Transactions routed to the decode dead-letter subject.",
+		}, []string{"program"}),
+		slotGaps: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Namespace: "dex",
+			Subsystem: "geyser",
+			Name:      observability.MetricSlotGapsTotal,
+			Help:      "Holes detected in the sequence of observed block-meta slots.",
+		}),
+		slotGapSlots: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "dex",
+			Subsystem: "geyser",
+			Name:      observability.MetricSlotGapSlotsTotal,
+			Help:      "Missing slots by repair outcome (repaired, skipped, late, failed, dropped).",
+		}, []string{"outcome"}),
+		slotGapOpen: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Namespace: "dex",
+			Subsystem: "geyser",
+			Name:      observability.MetricSlotGapOpenSlots,
+			Help:      "Missing slots awaiting repair.",
+		}),
+		oracleDev: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "dex",
+			Subsystem: "geyser",
+			Name:      observability.MetricOracleDeviationBps,
+			Help:      "Deviation of the DEX-routed USD price from the reference oracle price, in basis points.",
+		}, []string{"feed"}),
 	}
 }
 
@@ -274,6 +526,27 @@ func (m *processorMetrics) recordSwap(programID string) {
 	}
 }
 
+func (m *processorMetrics) recordFailedAttempt(programID string) {
+	if m == nil {
+		return
+	}
+	m.failedSwaps.WithLabelValues(programID).Inc()
+}
+
+func (m *processorMetrics) recordMev(kind string) {
+	if m == nil {
+		return
+	}
+	m.mevEvents.WithLabelValues(kind).Inc()
+}
+
+func (m *processorMetrics) recordDeadLetter(programID string) {
+	if m == nil {
+		return
+	}
+	m.deadLetters.WithLabelValues(programID).Inc()
+}
+
 func (m *processorMetrics) recordError(programID string) {
 	if m == nil {
 		return
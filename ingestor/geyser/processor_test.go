@@ -7,6 +7,7 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -20,7 +21,10 @@ import (
 	ray "github.com/rexbrahh/lp-indexer/decoder/raydium"
 	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
 	"github.com/rexbrahh/lp-indexer/ingestor/common"
+	swapdecoder "github.com/rexbrahh/lp-indexer/ingestor/decoder"
 	poolmeta "github.com/rexbrahh/lp-indexer/ingestor/internal/pools"
+	"github.com/rexbrahh/lp-indexer/ingestor/mev"
+	"github.com/rexbrahh/lp-indexer/registry"
 
 	pb "github.com/rpcpool/yellowstone-grpc/examples/golang/proto"
 )
@@ -49,14 +53,16 @@ func TestProcessorPublishesRaydiumSwap(t *testing.T) {
 	cache := common.NewMemorySlotTimeCache()
 	cache.Set(fixture.Slot, time.Unix(fixture.Timestamp, 0))
 	processor := NewProcessor(pub, cache, nil)
+	pools := registry.New(nil)
+	processor.SetPoolRegistry(pools)
 
 	tradeRate := uint32(3000)
 	configKey := generateAddress(0xAA)
 	configData := buildConfigData(tradeRate)
-	if rate, err := poolmeta.DecodeAmmConfig(configData); err != nil || rate != tradeRate {
-		t.Fatalf("DecodeAmmConfig mismatch: rate=%d err=%v", rate, err)
+	if cfg, err := poolmeta.DecodeAmmConfig(configData); err != nil || cfg.TradeFeeRate != tradeRate {
+		t.Fatalf("DecodeAmmConfig mismatch: cfg=%+v err=%v", cfg, err)
 	}
-	processor.handleAccount(&pb.SubscribeUpdateAccount{
+	processor.handleAccount(context.Background(), &pb.SubscribeUpdateAccount{
 		Account: &pb.SubscribeUpdateAccountInfo{
 			Pubkey: configKey,
 			Owner:  mustDecodeBase58(t, ray.ProgramID),
@@ -67,7 +73,7 @@ func TestProcessorPublishesRaydiumSwap(t *testing.T) {
 	if decoded, err := poolmeta.DecodeRaydiumPool(poolData); err != nil || !bytes.Equal(decoded, configKey) {
 		t.Fatalf("DecodeRaydiumPool mismatch err=%v", err)
 	}
-	processor.handleAccount(&pb.SubscribeUpdateAccount{
+	processor.handleAccount(context.Background(), &pb.SubscribeUpdateAccount{
 		Account: &pb.SubscribeUpdateAccountInfo{
 			Pubkey: mustDecodeBase58(t, fixture.PoolAddress),
 			Owner:  mustDecodeBase58(t, ray.ProgramID),
@@ -122,6 +128,14 @@ func TestProcessorPublishesRaydiumSwap(t *testing.T) {
 		t.Fatalf("fee_bps=%d want %d", event.FeeBps, expectedFee)
 	}
 
+	pool, err := pools.Pool(fixture.PoolAddress)
+	if err != nil {
+		t.Fatalf("pool not registered: %v", err)
+	}
+	if pool.DEX != "raydium_clmm" || pool.FeeBps != expectedFee || pool.PairID != event.PairId || pool.FirstSlot != fixture.Slot {
+		t.Fatalf("unexpected registry entry %+v", pool)
+	}
+
 	if len(pub.txMetas) != 1 {
 		t.Fatalf("expected tx meta to be published")
 	}
@@ -146,6 +160,11 @@ type stubPublisher struct {
 	events     []*dexv1.SwapEvent
 	blockHeads []*dexv1.BlockHead
 	txMetas    []*dexv1.TxMeta
+	failed     []*dexv1.FailedSwapAttempt
+	routes     []*dexv1.Route
+	mev        []*dexv1.MevEvent
+	dlq        []*dexv1.DecodeFailure
+	refs       []*dexv1.ReferencePrice
 }
 
 func (s *stubPublisher) PublishSwap(_ context.Context, ev *dexv1.SwapEvent) error {
@@ -154,6 +173,30 @@ func (s *stubPublisher) PublishSwap(_ context.Context, ev *dexv1.SwapEvent) erro
 	return nil
 }
 
+func (s *stubPublisher) PublishRoute(_ context.Context, route *dexv1.Route) error {
+	clone := proto.Clone(route).(*dexv1.Route)
+	s.routes = append(s.routes, clone)
+	return nil
+}
+
+func (s *stubPublisher) PublishMev(_ context.Context, event *dexv1.MevEvent) error {
+	clone := proto.Clone(event).(*dexv1.MevEvent)
+	s.mev = append(s.mev, clone)
+	return nil
+}
+
+func (s *stubPublisher) PublishFailedSwap(_ context.Context, attempt *dexv1.FailedSwapAttempt) error {
+	clone := proto.Clone(attempt).(*dexv1.FailedSwapAttempt)
+	s.failed = append(s.failed, clone)
+	return nil
+}
+
+func (s *stubPublisher) PublishDecodeFailure(_ context.Context, failure *dexv1.DecodeFailure) error {
+	clone := proto.Clone(failure).(*dexv1.DecodeFailure)
+	s.dlq = append(s.dlq, clone)
+	return nil
+}
+
 func (s *stubPublisher) PublishBlockHead(_ context.Context, head *dexv1.BlockHead) error {
 	clone := proto.Clone(head).(*dexv1.BlockHead)
 	s.blockHeads = append(s.blockHeads, clone)
@@ -166,6 +209,12 @@ func (s *stubPublisher) PublishTxMeta(_ context.Context, meta *dexv1.TxMeta) err
 	return nil
 }
 
+func (s *stubPublisher) PublishReferencePrice(_ context.Context, price *dexv1.ReferencePrice) error {
+	clone := proto.Clone(price).(*dexv1.ReferencePrice)
+	s.refs = append(s.refs, clone)
+	return nil
+}
+
 func TestProcessorFinalizesSlotPublishesNonProvisional(t *testing.T) {
 	fixture := loadRaydiumFixture(t, "swap_tx_1.json")
 	pub := &stubPublisher{}
@@ -175,14 +224,14 @@ func TestProcessorFinalizesSlotPublishesNonProvisional(t *testing.T) {
 	ctx := context.Background()
 
 	configKey := generateAddress(0xAA)
-	processor.handleAccount(&pb.SubscribeUpdateAccount{
+	processor.handleAccount(context.Background(), &pb.SubscribeUpdateAccount{
 		Account: &pb.SubscribeUpdateAccountInfo{
 			Pubkey: configKey,
 			Owner:  mustDecodeBase58(t, ray.ProgramID),
 			Data:   buildConfigData(3000),
 		},
 	})
-	processor.handleAccount(&pb.SubscribeUpdateAccount{
+	processor.handleAccount(context.Background(), &pb.SubscribeUpdateAccount{
 		Account: &pb.SubscribeUpdateAccountInfo{
 			Pubkey: mustDecodeBase58(t, fixture.PoolAddress),
 			Owner:  mustDecodeBase58(t, ray.ProgramID),
@@ -243,14 +292,14 @@ func TestProcessorEmitsUndoOnDeadSlot(t *testing.T) {
 	ctx := context.Background()
 
 	configKey := generateAddress(0xAA)
-	processor.handleAccount(&pb.SubscribeUpdateAccount{
+	processor.handleAccount(context.Background(), &pb.SubscribeUpdateAccount{
 		Account: &pb.SubscribeUpdateAccountInfo{
 			Pubkey: configKey,
 			Owner:  mustDecodeBase58(t, ray.ProgramID),
 			Data:   buildConfigData(3000),
 		},
 	})
-	processor.handleAccount(&pb.SubscribeUpdateAccount{
+	processor.handleAccount(context.Background(), &pb.SubscribeUpdateAccount{
 		Account: &pb.SubscribeUpdateAccountInfo{
 			Pubkey: mustDecodeBase58(t, fixture.PoolAddress),
 			Owner:  mustDecodeBase58(t, ray.ProgramID),
@@ -298,6 +347,161 @@ func TestProcessorEmitsUndoOnDeadSlot(t *testing.T) {
 	}
 }
 
+func TestProcessorCapturesFailedSwapAttempts(t *testing.T) {
+	fixture := loadRaydiumFixture(t, "swap_tx_1.json")
+	ctx := context.Background()
+
+	failedUpdate := func() *pb.SubscribeUpdate {
+		update := buildRaydiumUpdate(t, fixture)
+		tx := update.GetTransaction()
+		// InstructionError(0, Custom(30)) encoded with bincode.
+		tx.Transaction.Meta.Err = &pb.TransactionError{Err: []byte{8, 0, 0, 0, 0, 25, 0, 0, 0, 30, 0, 0, 0}}
+		return update
+	}
+
+	for _, capture := range []bool{false, true} {
+		pub := &stubPublisher{}
+		processor := NewProcessor(pub, common.NewMemorySlotTimeCache(), nil)
+		processor.SetCaptureFailedSwaps(capture)
+
+		if err := processor.HandleUpdate(ctx, failedUpdate()); err != nil {
+			t.Fatalf("HandleUpdate failed transaction: %v", err)
+		}
+		if len(pub.events) != 0 {
+			t.Fatalf("capture=%t: expected no swaps for failed transaction, got %d", capture, len(pub.events))
+		}
+		if len(pub.txMetas) != 1 || pub.txMetas[0].GetSuccess() {
+			t.Fatalf("capture=%t: expected one unsuccessful tx meta, got %+v", capture, pub.txMetas)
+		}
+		if !capture {
+			if len(pub.failed) != 0 {
+				t.Fatalf("expected no failed attempts without capture, got %d", len(pub.failed))
+			}
+			continue
+		}
+		if len(pub.failed) != 1 {
+			t.Fatalf("expected 1 failed attempt, got %d", len(pub.failed))
+		}
+		attempt := pub.failed[0]
+		if attempt.GetProgramId() != ray.ProgramID || attempt.GetPoolId() != fixture.PoolAddress {
+			t.Fatalf("unexpected attempt target %+v", attempt)
+		}
+		if attempt.GetErrorKind() != swapdecoder.TxErrorKindCustom || attempt.GetErrorCode() != 30 {
+			t.Fatalf("unexpected attempt error %+v", attempt)
+		}
+	}
+}
+
+type failingAttemptPublisher struct {
+	*stubPublisher
+}
+
+func (failingAttemptPublisher) PublishFailedSwap(context.Context, *dexv1.FailedSwapAttempt) error {
+	return errors.New("nats unavailable")
+}
+
+func TestProcessorFailedAttemptPublishErrorKeepsStream(t *testing.T) {
+	fixture := loadRaydiumFixture(t, "swap_tx_1.json")
+	update := buildRaydiumUpdate(t, fixture)
+	update.GetTransaction().Transaction.Meta.Err = &pb.TransactionError{Err: []byte{8, 0, 0, 0, 0, 25, 0, 0, 0, 30, 0, 0, 0}}
+
+	pub := failingAttemptPublisher{&stubPublisher{}}
+	processor := NewProcessor(pub, common.NewMemorySlotTimeCache(), nil)
+	processor.SetCaptureFailedSwaps(true)
+
+	if err := processor.HandleUpdate(context.Background(), update); err != nil {
+		t.Fatalf("failed attempt publish error must not fail the update: %v", err)
+	}
+	if len(pub.txMetas) != 1 {
+		t.Fatalf("expected tx meta to be published, got %d", len(pub.txMetas))
+	}
+}
+
+func TestProcessorDeadLettersUndecodableTransaction(t *testing.T) {
+	fixture := loadRaydiumFixture(t, "swap_tx_1.json")
+	pub := &stubPublisher{}
+	processor := NewProcessor(pub, common.NewMemorySlotTimeCache(), nil)
+	ctx := context.Background()
+
+	update := buildRaydiumUpdate(t, fixture)
+	instr := update.GetTransaction().GetTransaction().GetTransaction().GetMessage().GetInstructions()[0]
+	instr.Data = instr.Data[:10]
+
+	if err := processor.HandleUpdate(ctx, update); err != nil {
+		t.Fatalf("HandleUpdate should not fail on decode errors: %v", err)
+	}
+	if len(pub.events) != 0 || len(pub.txMetas) != 0 {
+		t.Fatalf("expected nothing published for undecodable tx, got %d swaps %d metas", len(pub.events), len(pub.txMetas))
+	}
+	if len(pub.dlq) != 1 {
+		t.Fatalf("expected 1 dead-lettered transaction, got %d", len(pub.dlq))
+	}
+	failure := pub.dlq[0]
+	if failure.GetProgramId() != ray.ProgramID || failure.GetSig() != fixture.Signature || failure.GetError() == "" {
+		t.Fatalf("unexpected decode failure %+v", failure)
+	}
+
+	var raw pb.SubscribeUpdateTransaction
+	if err := proto.Unmarshal(failure.GetRawTx(), &raw); err != nil {
+		t.Fatalf("unmarshal raw tx: %v", err)
+	}
+	if !proto.Equal(&raw, update.GetTransaction()) {
+		t.Fatal("raw tx does not round-trip")
+	}
+
+	// The stream keeps going: the next transaction decodes normally.
+	if err := processor.HandleUpdate(ctx, buildRaydiumUpdate(t, fixture)); err != nil {
+		t.Fatalf("HandleUpdate after dead-letter: %v", err)
+	}
+	if len(pub.events) != 1 {
+		t.Fatalf("expected 1 swap after dead-letter, got %d", len(pub.events))
+	}
+}
+
+func TestProcessorPublishesMevOnConfirmedSlot(t *testing.T) {
+	pub := &stubPublisher{}
+	processor := NewProcessor(pub, common.NewMemorySlotTimeCache(), nil)
+	ctx := context.Background()
+
+	slot := uint64(55)
+	swap := func(sig string, index uint32, trader string, baseIn, quoteIn uint64) *dexv1.SwapEvent {
+		ev := &dexv1.SwapEvent{Slot: slot, Sig: sig, Index: index, Trader: trader, PoolId: "pool",
+			MintBase: "SOL", MintQuote: "USDC", BaseIn: baseIn, QuoteIn: quoteIn, Provisional: true}
+		if baseIn > 0 {
+			ev.QuoteOut = baseIn * 100
+		} else {
+			ev.BaseOut = quoteIn / 100
+		}
+		return ev
+	}
+	for _, ev := range []*dexv1.SwapEvent{
+		swap("front", 1, "bot", 0, 1_000),
+		swap("victim", 2, "user", 0, 5_000),
+		swap("back", 3, "bot", 10, 0),
+	} {
+		processor.appendPending(slot, ev)
+	}
+
+	for _, status := range []pb.SlotStatus{pb.SlotStatus_SLOT_CONFIRMED, pb.SlotStatus_SLOT_FINALIZED} {
+		update := &pb.SubscribeUpdate{UpdateOneof: &pb.SubscribeUpdate_Slot{Slot: &pb.SubscribeUpdateSlot{Slot: slot, Status: status}}}
+		if err := processor.HandleUpdate(ctx, update); err != nil {
+			t.Fatalf("HandleUpdate %s: %v", status, err)
+		}
+	}
+
+	if len(pub.mev) != 1 || pub.mev[0].GetKind() != mev.KindSandwich {
+		t.Fatalf("expected one sandwich event, got %+v", pub.mev)
+	}
+	if len(pub.events) != 3 {
+		t.Fatalf("expected 3 finalized swaps, got %d", len(pub.events))
+	}
+	for _, ev := range pub.events {
+		if ev.GetMevVictim() != (ev.GetSig() == "victim") {
+			t.Fatalf("unexpected victim flag on %s", ev.GetSig())
+		}
+	}
+}
+
 func loadRaydiumFixture(t *testing.T, filename string) *raydiumFixture {
 	t.Helper()
 	_, file, _, ok := runtime.Caller(0)
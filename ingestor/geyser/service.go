@@ -12,6 +12,10 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/rexbrahh/lp-indexer/ingestor/common"
+	swapdecoder "github.com/rexbrahh/lp-indexer/ingestor/decoder"
+	"github.com/rexbrahh/lp-indexer/ingestor/mintmeta"
+	"github.com/rexbrahh/lp-indexer/pricing"
+	"github.com/rexbrahh/lp-indexer/registry"
 	natsx "github.com/rexbrahh/lp-indexer/sinks/nats"
 
 	pb "github.com/rpcpool/yellowstone-grpc/examples/golang/proto"
@@ -50,6 +54,10 @@ func NewService(geyserCfg *Config, natsCfg natsx.Config, metricsAddr string) (*S
 	if err != nil {
 		return nil, err
 	}
+	processor.SetCaptureFailedSwaps(geyserCfg.CaptureFailedSwaps)
+	if err := enableGapRepair(processor, geyserCfg); err != nil {
+		return nil, err
+	}
 
 	return &Service{
 		client:        client,
@@ -60,6 +68,34 @@ func NewService(geyserCfg *Config, natsCfg natsx.Config, metricsAddr string) (*S
 	}, nil
 }
 
+// SetMintMetadata attaches an on-chain mint metadata provider to the
+// processor.
+func (s *Service) SetMintMetadata(provider *mintmeta.Provider) {
+	s.processor.SetMintMetadata(provider)
+}
+
+// SetAccountLoader lets the processor's decoder fetch accounts the stream
+// has not delivered yet.
+func (s *Service) SetAccountLoader(loader swapdecoder.AccountLoader, timeout time.Duration) {
+	s.processor.SetAccountLoader(loader, timeout)
+}
+
+// SetPoolRegistry attaches the pool registry to the processor.
+func (s *Service) SetPoolRegistry(reg *registry.Registry) {
+	s.processor.SetPoolRegistry(reg)
+}
+
+// SetPriceOracle attaches the USD price oracle to the processor.
+func (s *Service) SetPriceOracle(oracle *pricing.Oracle) {
+	s.processor.SetPriceOracle(oracle)
+}
+
+// SetOracleFeeds configures the reference price accounts the processor
+// decodes.
+func (s *Service) SetOracleFeeds(feeds []OracleFeed) {
+	s.processor.SetOracleFeeds(feeds)
+}
+
 // Run connects to geyser, processes updates, and blocks until the context is
 // cancelled or an unrecoverable error occurs.
 func (s *Service) Run(ctx context.Context, startSlot uint64) error {
@@ -73,6 +109,7 @@ func (s *Service) Run(ctx context.Context, startSlot uint64) error {
 	defer s.client.Close()
 
 	updates, errs := s.client.Subscribe(startSlot)
+	go s.processor.RunRepairs(ctx)
 
 	if s.metricsServer != nil {
 		go func() {
@@ -102,10 +139,26 @@ func (s *Service) Run(ctx context.Context, startSlot uint64) error {
 			if err := s.processor.HandleUpdate(ctx, update); err != nil {
 				return err
 			}
+		case result := <-s.processor.RepairResults():
+			if err := s.processor.ApplyRepair(ctx, result); err != nil {
+				return err
+			}
 		}
 	}
 }
 
+func enableGapRepair(processor *Processor, cfg *Config) error {
+	if cfg == nil || cfg.Repair == nil {
+		return nil
+	}
+	repairer, err := NewSlotRepairer(*cfg.Repair, cfg.ProgramFilters)
+	if err != nil {
+		return fmt.Errorf("init slot repairer: %w", err)
+	}
+	processor.EnableGapRepair(repairer)
+	return nil
+}
+
 func (s *Service) shutdownMetrics() {
 	if s.metricsServer == nil {
 		return
@@ -133,14 +186,22 @@ func setupPipeline(natsCfg natsx.Config, metricsAddr string) (*Processor, promet
 		return nil, nil, nil, nil, fmt.Errorf("init nats publisher: %w", err)
 	}
 
-	registry := prometheus.NewRegistry()
-	registry.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
-	registry.MustRegister(collectors.NewGoCollector())
+	promReg := prometheus.NewRegistry()
+	promReg.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	promReg.MustRegister(collectors.NewGoCollector())
+	if err := publisher.RegisterMetrics(promReg); err != nil {
+		publisher.Close()
+		return nil, nil, nil, nil, fmt.Errorf("register publisher metrics: %w", err)
+	}
 
-	slotCache := common.NewMemorySlotTimeCache()
-	processor := NewProcessor(publisher, slotCache, registry)
+	slotCache, err := common.SlotTimeCacheFromEnv(publisher.JetStream())
+	if err != nil {
+		publisher.Close()
+		return nil, nil, nil, nil, fmt.Errorf("init slot cache: %w", err)
+	}
+	processor := NewProcessor(publisher, slotCache, promReg)
 
-	server := buildMetricsServer(metricsAddr, registry)
+	server := buildMetricsServer(metricsAddr, promReg)
 	stopCh := make(chan struct{})
-	return processor, registry, server, stopCh, nil
+	return processor, promReg, server, stopCh, nil
 }
@@ -0,0 +1,134 @@
+package geyser
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mr-tron/base58/base58"
+
+	orcawhirlpool "github.com/rexbrahh/lp-indexer/decoder/orca_whirlpool"
+	"github.com/rexbrahh/lp-indexer/ingestor/common"
+	swapdecoder "github.com/rexbrahh/lp-indexer/ingestor/decoder"
+	"github.com/rexbrahh/lp-indexer/ingestor/helius"
+	"github.com/rexbrahh/lp-indexer/ingestor/solrpc"
+)
+
+// buildWhirlpoolData lays out the whirlpool fields the decoder reads.
+func buildWhirlpoolData(mintA, mintB, vaultA, vaultB []byte, feeRate uint16) []byte {
+	const (
+		feeRateOffset = 8 + 32 + 1 + 2 + 2
+		mintAOffset   = feeRateOffset + 2 + 2 + 16 + 16 + 4 + 8 + 8
+		vaultAOffset  = mintAOffset + 32
+		mintBOffset   = vaultAOffset + 32 + 16
+		vaultBOffset  = mintBOffset + 32
+	)
+	data := make([]byte, vaultBOffset+32)
+	binary.LittleEndian.PutUint16(data[feeRateOffset:], feeRate)
+	copy(data[mintAOffset:], mintA)
+	copy(data[vaultAOffset:], vaultA)
+	copy(data[mintBOffset:], mintB)
+	copy(data[vaultBOffset:], vaultB)
+	return data
+}
+
+// TestWebhookPublishesOrcaSwapWithLoadedPool wires the webhook receiver to a
+// processor the way cmd/ingestor/helius-webhook does. The webhook has no
+// account stream, so the whirlpool must come from the RPC account loader.
+func TestWebhookPublishesOrcaSwapWithLoadedPool(t *testing.T) {
+	pool := base58.Encode(generateAddress(0x79))
+	mintA, mintB := generateAddress(0x22), generateAddress(0x33)
+	vaultA, vaultB := generateAddress(0x44), generateAddress(0x55)
+	poolData := buildWhirlpoolData(mintA, mintB, vaultA, vaultB, 3000)
+
+	var loads int
+	rpc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     int               `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Method != "getMultipleAccounts" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		var keys []string
+		_ = json.Unmarshal(req.Params[0], &keys)
+		loads++
+		values := make([]string, len(keys))
+		for i, key := range keys {
+			values[i] = "null"
+			if key == pool {
+				values[i] = fmt.Sprintf(`{"lamports": 1, "owner": %q, "data": [%q, "base64"], "executable": false}`,
+					orcawhirlpool.WhirlpoolProgramID, base64.StdEncoding.EncodeToString(poolData))
+			}
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{"context":{"slot":1},"value":[%s]}}`, req.ID, strings.Join(values, ","))
+	}))
+	defer rpc.Close()
+
+	pub := &stubPublisher{}
+	processor := NewProcessor(pub, common.NewMemorySlotTimeCache(), nil)
+	processor.SetAccountLoader(solrpc.NewClient(rpc.URL, swapdecoder.DefaultLoadTimeout), swapdecoder.DefaultLoadTimeout)
+
+	cfg := helius.DefaultWebhookConfig()
+	cfg.AuthHeader = "Bearer secret"
+	handler, err := helius.NewWebhookHandler(cfg, processor)
+	if err != nil {
+		t.Fatalf("NewWebhookHandler() error = %v", err)
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	balance := func(index int, mint []byte, amount string) string {
+		return fmt.Sprintf(`{"accountIndex": %d, "mint": %q, "owner": %q, "uiTokenAmount": {"amount": %q, "decimals": 6}}`,
+			index, base58.Encode(mint), pool, amount)
+	}
+	keys := []string{
+		base58.Encode(generateAddress(0x01)),
+		base58.Encode(generateAddress(0x02)),
+		pool,
+		base58.Encode(vaultA),
+		base58.Encode(vaultB),
+		orcawhirlpool.WhirlpoolProgramID,
+	}
+	body := fmt.Sprintf(`[{"slot": 987660, "blockTime": 1700000000, "indexWithinBlock": 0,
+	  "meta": {"err": null, "fee": 5000, "preTokenBalances": [%s, %s], "postTokenBalances": [%s, %s]},
+	  "transaction": {"signatures": [%q], "message": {"header": {"numRequiredSignatures": 1}, "accountKeys": ["%s"],
+	  "instructions": [{"programIdIndex": 5, "accounts": [0, 1, 2, 3, 4], "data": "1"}]}}}]`,
+		balance(3, mintA, "1000000"), balance(4, mintB, "500000"),
+		balance(3, mintA, "500000"), balance(4, mintB, "1200000"),
+		base58.Encode(bytes.Repeat([]byte{0x9A}, 64)), strings.Join(keys, `", "`))
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post webhook: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("webhook status = %d", resp.StatusCode)
+	}
+
+	if len(pub.events) != 1 {
+		t.Fatalf("expected 1 swap event, got %d", len(pub.events))
+	}
+	ev := pub.events[0]
+	if ev.PoolId != pool || ev.FeeBps != 30 || ev.BaseOut != 500_000 || ev.QuoteIn != 700_000 {
+		t.Fatalf("unexpected swap %+v", ev)
+	}
+	if loads == 0 {
+		t.Fatal("expected the whirlpool to be loaded over RPC")
+	}
+}
@@ -2,7 +2,9 @@ package helius
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"sync"
 	"time"
 
@@ -19,6 +21,9 @@ type Update struct {
 	BlockHead *dexv1.BlockHead
 	TxMeta    *dexv1.TxMeta
 	Swap      *dexv1.SwapEvent
+	// DecodeFailure carries a transaction the decoder rejected; the stream
+	// keeps running and the caller dead-letters it.
+	DecodeFailure *dexv1.DecodeFailure
 }
 
 // HealthSnapshot captures the coarse health signals consumed by the failover
@@ -46,8 +51,13 @@ type Client struct {
 
 	cancel    context.CancelFunc
 	newStream func(*Config) (streamClient, error)
+	newWS     func(*Config) (streamClient, error)
 }
 
+// errUnavailable marks the error reported when neither LaserStream nor the
+// WebSocket fallback is serving.
+var errUnavailable = errors.New("helius unavailable")
+
 type streamClient interface {
 	Connect() error
 	Subscribe(startSlot uint64) (<-chan *pb.SubscribeUpdate, <-chan error)
@@ -68,6 +78,9 @@ func NewClient(cfg *Config) (*Client, error) {
 		newStream: func(cfg *Config) (streamClient, error) {
 			return NewStreamClient(cfg)
 		},
+		newWS: func(cfg *Config) (streamClient, error) {
+			return NewWSClient(cfg)
+		},
 	}, nil
 }
 
@@ -80,21 +93,72 @@ func (c *Client) Start(ctx context.Context, startSlot uint64) (<-chan Update, <-
 	runCtx, cancel := context.WithCancel(ctx)
 	c.setCancel(cancel)
 
-	go c.run(runCtx, startSlot, updates, errs)
+	go func() {
+		defer close(updates)
+		defer close(errs)
+		c.run(runCtx, startSlot, func(name string, update *pb.SubscribeUpdate) {
+			c.observe(name, update)
+			if err := c.handleSubscribeUpdate(runCtx, update, updates); err != nil {
+				c.reportError(errs, err)
+			}
+		}, func(err error) {
+			c.reportError(errs, err)
+		})
+	}()
+	return updates, errs
+}
+
+// Connect is a no-op: transports are dialled by Subscribe so the client can
+// be used as a geyser.ClientInterface in the failover chain.
+func (c *Client) Connect() error { return nil }
+
+// Subscribe streams raw Yellowstone updates from LaserStream, or from the
+// WebSocket fallback while LaserStream is down. Errors from a single
+// transport are logged because the other one takes over; an error is only
+// delivered once neither transport is serving, which lets the failover
+// controller move on to the next source.
+func (c *Client) Subscribe(startSlot uint64) (<-chan *pb.SubscribeUpdate, <-chan error) {
+	updates := make(chan *pb.SubscribeUpdate, 128)
+	errs := make(chan error, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.setCancel(cancel)
+
+	go func() {
+		defer close(updates)
+		defer close(errs)
+		c.run(ctx, startSlot, func(name string, update *pb.SubscribeUpdate) {
+			c.observe(name, update)
+			select {
+			case updates <- update:
+			case <-ctx.Done():
+			}
+		}, func(err error) {
+			if errors.Is(err, errUnavailable) {
+				c.reportError(errs, err)
+				return
+			}
+			log.Printf("helius: %v", err)
+		})
+	}()
 	return updates, errs
 }
 
 // Close stops the client and releases resources. It is safe to call multiple
 // times.
-func (c *Client) Close() {
+func (c *Client) Close() error {
 	c.mu.Lock()
 	if c.cancel != nil {
 		c.cancel()
 		c.cancel = nil
 	}
 	c.mu.Unlock()
+	return nil
 }
 
+// Name identifies the client in failover logs and metrics.
+func (c *Client) Name() string { return "helius" }
+
 // Health returns a copy of the current health snapshot.
 func (c *Client) Health() HealthSnapshot {
 	c.mu.RLock()
@@ -115,78 +179,205 @@ func (c *Client) setCancel(cancel context.CancelFunc) {
 }
 
 // run manages the lifecycle of the LaserStream and WebSocket connections.
-func (c *Client) run(ctx context.Context, startSlot uint64, updates chan<- Update, errs chan<- error) {
-	defer close(updates)
-	defer close(errs)
+// LaserStream is preferred; when it fails to connect, reports an error, closes
+// or goes silent for RequestTimeout, the WebSocket fallback is opened and
+// serves updates until LaserStream delivers again. Errors wrapping
+// errUnavailable are reported when neither transport could be opened.
+func (c *Client) run(ctx context.Context, startSlot uint64, deliver func(name string, update *pb.SubscribeUpdate), report func(error)) {
+	var primary, fallback *source
+	defer func() {
+		primary.close()
+		fallback.close()
+	}()
 
-	stream, err := c.newStream(c.cfg)
+	primary, err := openSource(c.newStream, c.cfg, startSlot)
 	if err != nil {
-		select {
-		case errs <- fmt.Errorf("init helius stream client: %w", err):
-		case <-ctx.Done():
-		}
-		return
+		report(fmt.Errorf("helius stream: %w", err))
 	}
-	defer stream.Close()
+	primaryDown := primary == nil
 
-	if err := stream.Connect(); err != nil {
-		select {
-		case errs <- fmt.Errorf("connect helius stream: %w", err):
-		case <-ctx.Done():
-		}
-		return
+	stall := time.NewTimer(c.cfg.RequestTimeout)
+	defer stall.Stop()
+	retry := time.NewTimer(0)
+	defer retry.Stop()
+	if primaryDown {
+		stall.Stop()
+	} else {
+		retry.Stop()
 	}
 
-	updateCh, errCh := stream.Subscribe(startSlot)
-
-	c.setHealth(func(s *HealthSnapshot) {
-		s.Healthy = true
-		s.Source = "grpc"
-		s.LastHeartbeat = time.Now()
-	})
+	failPrimary := func(err error) {
+		if err != nil {
+			report(err)
+		}
+		if primaryDown {
+			return
+		}
+		primaryDown = true
+		c.setHealth(func(s *HealthSnapshot) {
+			s.Healthy = fallback != nil
+		})
+		if fallback == nil {
+			retry.Reset(0)
+		}
+	}
+	failFallback := func(err error) {
+		if err != nil {
+			report(err)
+		}
+		fallback.close()
+		fallback = nil
+		if primaryDown {
+			c.setHealth(func(s *HealthSnapshot) {
+				s.Healthy = false
+			})
+			retry.Reset(c.cfg.ReconnectBackoff)
+		}
+	}
 
 	for {
+		var primaryUpdates, fallbackUpdates <-chan *pb.SubscribeUpdate
+		var primaryErrs, fallbackErrs <-chan error
+		if primary != nil {
+			primaryUpdates, primaryErrs = primary.updates, primary.errs
+		}
+		if fallback != nil {
+			fallbackUpdates, fallbackErrs = fallback.updates, fallback.errs
+		}
+
 		select {
 		case <-ctx.Done():
 			c.setHealth(func(s *HealthSnapshot) {
 				s.Healthy = false
 			})
 			return
-		case err, ok := <-errCh:
-			if !ok || err == nil {
+		case <-stall.C:
+			failPrimary(nil)
+		case err, ok := <-primaryErrs:
+			if !ok {
+				primary.errs = nil
 				continue
 			}
-			c.setHealth(func(s *HealthSnapshot) {
-				s.Healthy = false
-				s.Source = "grpc"
-			})
-			select {
-			case errs <- err:
-			default:
+			if err != nil {
+				failPrimary(err)
 			}
-		case update, ok := <-updateCh:
+		case update, ok := <-primaryUpdates:
 			if !ok {
-				c.setHealth(func(s *HealthSnapshot) {
-					s.Healthy = false
-				})
-				return
+				primary.close()
+				primary = nil
+				stall.Stop()
+				failPrimary(errors.New("helius stream closed"))
+				if fallback != nil {
+					retry.Reset(c.cfg.ReconnectBackoff)
+				}
+				continue
 			}
-			slot := slotFromUpdate(update)
-			c.setHealth(func(s *HealthSnapshot) {
-				s.Healthy = true
-				s.Source = "grpc"
-				s.LastHeartbeat = time.Now()
-				if slot > 0 {
-					s.LastSlot = slot
+			stall.Reset(c.cfg.RequestTimeout)
+			if primaryDown {
+				primaryDown = false
+				fallback.close()
+				fallback = nil
+				retry.Stop()
+			}
+			deliver("grpc", update)
+		case err, ok := <-fallbackErrs:
+			if !ok {
+				fallback.errs = nil
+				continue
+			}
+			if err != nil {
+				report(err)
+			}
+		case update, ok := <-fallbackUpdates:
+			if !ok {
+				failFallback(errors.New("helius websocket closed"))
+				continue
+			}
+			if primaryDown {
+				deliver("websocket", update)
+			}
+		case <-retry.C:
+			reopened := false
+			if primary == nil {
+				if primary, err = openSource(c.newStream, c.cfg, c.resumeSlot(startSlot)); err != nil {
+					report(fmt.Errorf("helius stream: %w", err))
+				} else {
+					reopened = true
+					stall.Reset(c.cfg.RequestTimeout)
 				}
-			})
-			if err := c.handleSubscribeUpdate(ctx, update, updates); err != nil {
-				select {
-				case errs <- err:
-				default:
+			}
+			if primaryDown && fallback == nil && c.newWS != nil {
+				if fallback, err = openSource(c.newWS, c.cfg, c.resumeSlot(startSlot)); err != nil {
+					report(fmt.Errorf("helius websocket: %w", err))
+				} else {
+					c.setHealth(func(s *HealthSnapshot) {
+						s.Healthy = true
+						s.Source = "websocket"
+					})
 				}
 			}
+			if primaryDown && fallback == nil && !reopened {
+				report(fmt.Errorf("%w: laserstream and websocket are both down", errUnavailable))
+			}
+			if primary == nil || (primaryDown && fallback == nil) {
+				retry.Reset(c.cfg.ReconnectBackoff)
+			}
+		}
+	}
+}
+
+// resumeSlot is the slot to resubscribe from: the latest slot seen so far, or
+// the original start slot before anything arrived.
+func (c *Client) resumeSlot(startSlot uint64) uint64 {
+	if last := c.Health().LastSlot; last > startSlot {
+		return last
+	}
+	return startSlot
+}
+
+// source is an open transport and its subscription channels.
+type source struct {
+	stream  streamClient
+	updates <-chan *pb.SubscribeUpdate
+	errs    <-chan error
+}
+
+func openSource(newStream func(*Config) (streamClient, error), cfg *Config, startSlot uint64) (*source, error) {
+	stream, err := newStream(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("init: %w", err)
+	}
+	if err := stream.Connect(); err != nil {
+		stream.Close()
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+	updates, errs := stream.Subscribe(startSlot)
+	return &source{stream: stream, updates: updates, errs: errs}, nil
+}
+
+func (s *source) close() {
+	if s != nil {
+		s.stream.Close()
+	}
+}
+
+// observe records a delivered update in the health snapshot.
+func (c *Client) observe(name string, update *pb.SubscribeUpdate) {
+	slot := slotFromUpdate(update)
+	c.setHealth(func(s *HealthSnapshot) {
+		s.Healthy = true
+		s.Source = name
+		s.LastHeartbeat = time.Now()
+		if slot > s.LastSlot {
+			s.LastSlot = slot
 		}
+	})
+}
+
+func (c *Client) reportError(errs chan<- error, err error) {
+	select {
+	case errs <- err:
+	default:
 	}
 }
 
@@ -215,7 +406,16 @@ func (c *Client) handleSubscribeUpdate(ctx context.Context, in *pb.SubscribeUpda
 		}
 		events, err := c.decoder.DecodeTransaction(u.Transaction)
 		if err != nil {
-			return fmt.Errorf("decode transaction: %w", err)
+			var decodeErr *swapdecoder.DecodeError
+			if !errors.As(err, &decodeErr) {
+				return fmt.Errorf("decode transaction: %w", err)
+			}
+			failure, err := swapdecoder.NewDecodeFailure(u.Transaction, decodeErr, time.Now())
+			if err != nil {
+				return fmt.Errorf("build decode failure: %w", err)
+			}
+			c.sendUpdate(ctx, out, Update{DecodeFailure: failure})
+			return nil
 		}
 		for _, ev := range events {
 			c.sendUpdate(ctx, out, Update{Swap: ev})
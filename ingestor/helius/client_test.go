@@ -6,6 +6,7 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -237,6 +238,103 @@ func TestClientEmitsTxMetaAndSwap(t *testing.T) {
 	}
 }
 
+func TestClientSubscribeKeepsStreamingThroughTransportErrors(t *testing.T) {
+	client, err := NewClient(&Config{
+		GRPCEndpoint:     "grpc.example.com:443",
+		WSEndpoint:       "wss://example.com",
+		APIKey:           "secret",
+		RequestTimeout:   time.Second,
+		ReconnectBackoff: 50 * time.Millisecond,
+		ReplaySlots:      64,
+		ProgramFilters: map[string]string{
+			"raydium": "CAMMCzo5YL8w4VFF8KVHrK22GGUsp5VTaW7grrKgrWqK",
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	grpc, ws := newFakeStreamClient(), newFakeStreamClient()
+	client.newStream = func(*Config) (streamClient, error) { return grpc, nil }
+	client.newWS = func(*Config) (streamClient, error) { return ws, nil }
+
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer client.Close()
+	updates, errs := client.Subscribe(0)
+
+	grpc.updates <- &pb.SubscribeUpdate{
+		UpdateOneof: &pb.SubscribeUpdate_Slot{
+			Slot: &pb.SubscribeUpdateSlot{Slot: 10, Status: pb.SlotStatus_SLOT_FINALIZED},
+		},
+	}
+	expectRawUpdate(t, updates, errs, func(u *pb.SubscribeUpdate) bool {
+		return u.GetSlot().GetSlot() == 10 && u.GetSlot().GetStatus() == pb.SlotStatus_SLOT_FINALIZED
+	})
+
+	grpc.errs <- errors.New("laserstream recv failed")
+	waitFor(t, func() bool { return client.Health().Source == "websocket" })
+	ws.updates <- &pb.SubscribeUpdate{
+		UpdateOneof: &pb.SubscribeUpdate_Slot{
+			Slot: &pb.SubscribeUpdateSlot{Slot: 11, Status: pb.SlotStatus_SLOT_PROCESSED},
+		},
+	}
+	expectRawUpdate(t, updates, errs, func(u *pb.SubscribeUpdate) bool {
+		return u.GetSlot().GetSlot() == 11
+	})
+}
+
+func TestClientSubscribeReportsWhenNoTransportServes(t *testing.T) {
+	client, err := NewClient(&Config{
+		GRPCEndpoint:     "grpc.example.com:443",
+		WSEndpoint:       "wss://example.com",
+		APIKey:           "secret",
+		RequestTimeout:   time.Second,
+		ReconnectBackoff: 50 * time.Millisecond,
+		ReplaySlots:      64,
+		ProgramFilters: map[string]string{
+			"raydium": "CAMMCzo5YL8w4VFF8KVHrK22GGUsp5VTaW7grrKgrWqK",
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	client.newStream = func(*Config) (streamClient, error) { return nil, errors.New("laserstream unavailable") }
+	client.newWS = func(*Config) (streamClient, error) { return nil, errors.New("websocket unavailable") }
+
+	defer client.Close()
+	_, errs := client.Subscribe(0)
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, errUnavailable) {
+			t.Fatalf("expected errUnavailable, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for unavailable error")
+	}
+}
+
+func expectRawUpdate(t *testing.T, updates <-chan *pb.SubscribeUpdate, errs <-chan error, predicate func(*pb.SubscribeUpdate) bool) {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case u, ok := <-updates:
+			if !ok {
+				t.Fatal("updates channel closed")
+			}
+			if predicate(u) {
+				return
+			}
+		case err := <-errs:
+			t.Fatalf("unexpected error: %v", err)
+		case <-timeout:
+			t.Fatal("timed out waiting for update")
+		}
+	}
+}
+
 type fakeStreamClient struct {
 	updates      chan *pb.SubscribeUpdate
 	errs         chan error
@@ -3,8 +3,10 @@ package helius
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -14,6 +16,7 @@ const (
 	defaultReplayWindow  = 64
 	envHeliusGRPC        = "HELIUS_GRPC"
 	envHeliusWS          = "HELIUS_WS"
+	envHeliusRPC         = "HELIUS_RPC"
 	envHeliusAPIKey      = "HELIUS_API_KEY"
 	envHeliusTimeoutMS   = "HELIUS_TIMEOUT_MS"
 	envHeliusBackoffMS   = "HELIUS_BACKOFF_MS"
@@ -25,12 +28,21 @@ const (
 type Config struct {
 	GRPCEndpoint string
 	WSEndpoint   string
-	APIKey       string
+	// RPCEndpoint is the JSON-RPC endpoint used by the WebSocket fallback to
+	// fetch transactions. When empty it is derived from WSEndpoint.
+	RPCEndpoint string
+	APIKey      string
 
 	RequestTimeout   time.Duration
 	ReconnectBackoff time.Duration
 	ReplaySlots      uint64
 	ProgramFilters   map[string]string
+	// OracleAccounts are reference price accounts streamed alongside the
+	// program accounts.
+	OracleAccounts []string
+	// TokenMints returns the mints of the indexed pools, whose Token-2022
+	// mint accounts are streamed; it is called on every (re)subscribe.
+	TokenMints func() []string
 }
 
 // DefaultConfig returns a Config populated with sensible defaults. Endpoints
@@ -82,6 +94,7 @@ func FromEnv() (*Config, error) {
 	cfg := DefaultConfig()
 	cfg.GRPCEndpoint = os.Getenv(envHeliusGRPC)
 	cfg.WSEndpoint = os.Getenv(envHeliusWS)
+	cfg.RPCEndpoint = os.Getenv(envHeliusRPC)
 	cfg.APIKey = os.Getenv(envHeliusAPIKey)
 
 	if v := os.Getenv(envHeliusTimeoutMS); v != "" {
@@ -110,3 +123,42 @@ func FromEnv() (*Config, error) {
 
 	return cfg, cfg.Validate()
 }
+
+// WebSocketURL returns WSEndpoint with the api-key query parameter applied.
+func (c *Config) WebSocketURL() (string, error) {
+	return withAPIKey(c.WSEndpoint, c.APIKey)
+}
+
+// RPCURL returns the JSON-RPC endpoint, deriving it from WSEndpoint
+// (ws→http, wss→https) when RPCEndpoint is unset.
+func (c *Config) RPCURL() (string, error) {
+	if c.RPCEndpoint != "" {
+		return withAPIKey(c.RPCEndpoint, c.APIKey)
+	}
+	endpoint := c.WSEndpoint
+	switch {
+	case strings.HasPrefix(endpoint, "wss://"):
+		endpoint = "https://" + strings.TrimPrefix(endpoint, "wss://")
+	case strings.HasPrefix(endpoint, "ws://"):
+		endpoint = "http://" + strings.TrimPrefix(endpoint, "ws://")
+	default:
+		return "", fmt.Errorf("cannot derive RPC endpoint from %q", c.WSEndpoint)
+	}
+	return withAPIKey(endpoint, c.APIKey)
+}
+
+func withAPIKey(endpoint, key string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("parse endpoint %q: %w", endpoint, err)
+	}
+	if key == "" {
+		return u.String(), nil
+	}
+	query := u.Query()
+	if query.Get("api-key") == "" {
+		query.Set("api-key", key)
+		u.RawQuery = query.Encode()
+	}
+	return u.String(), nil
+}
@@ -8,6 +8,8 @@ import (
 	"log"
 	"time"
 
+	"github.com/rexbrahh/lp-indexer/ingestor/common"
+
 	pb "github.com/rpcpool/yellowstone-grpc/examples/golang/proto"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
@@ -143,6 +145,14 @@ func (c *StreamClient) buildSubscribeRequest(startSlot uint64) *pb.SubscribeRequ
 			Filters: []*pb.SubscribeRequestFilterAccountsFilter{},
 		}
 	}
+	if c.cfg.TokenMints != nil {
+		if filter := common.Token2022MintFilter(c.cfg.TokenMints()); filter != nil {
+			accounts[common.Token2022MintFilterName] = filter
+		}
+	}
+	if filter := common.OracleAccountFilter(c.cfg.OracleAccounts); filter != nil {
+		accounts[common.OracleFilterName] = filter
+	}
 
 	programIDs := make([]string, 0, len(c.cfg.ProgramFilters))
 	for _, programID := range c.cfg.ProgramFilters {
@@ -0,0 +1,359 @@
+package helius
+
+import (
+	"container/list"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+	"github.com/rexbrahh/lp-indexer/ingestor/solrpc"
+
+	pb "github.com/rpcpool/yellowstone-grpc/examples/golang/proto"
+)
+
+const (
+	defaultWebhookAddr          = ":8090"
+	defaultWebhookDedupeSize    = 100_000
+	defaultWebhookFinalityLag   = 32
+	defaultWebhookMaxBodyBytes  = 16 << 20
+	envHeliusWebhookAddr        = "HELIUS_WEBHOOK_ADDR"
+	envHeliusWebhookAuth        = "HELIUS_WEBHOOK_AUTH"
+	envHeliusWebhookDedupeSize  = "HELIUS_WEBHOOK_DEDUPE_SIZE"
+	envHeliusWebhookFinalityLag = "HELIUS_WEBHOOK_FINALITY_SLOTS"
+)
+
+// WebhookConfig configures the raw-transaction webhook receiver.
+type WebhookConfig struct {
+	// Addr is the listen address of the HTTP receiver.
+	Addr string
+	// AuthHeader is the exact Authorization header value configured on the
+	// Helius webhook.
+	AuthHeader string
+	// DedupeSize bounds the number of recently seen signatures kept for
+	// idempotency across webhook retries.
+	DedupeSize int
+	// FinalityLag is the number of slots after which a slot is treated as
+	// confirmed and finalized. Webhooks carry no slot status, so pending swaps
+	// are settled once newer slots have been observed.
+	FinalityLag uint64
+	// MaxBodyBytes caps the request body size.
+	MaxBodyBytes int64
+}
+
+// DefaultWebhookConfig returns a WebhookConfig with defaults applied. The auth
+// header remains empty because it is deployment-specific.
+func DefaultWebhookConfig() *WebhookConfig {
+	return &WebhookConfig{
+		Addr:         defaultWebhookAddr,
+		DedupeSize:   defaultWebhookDedupeSize,
+		FinalityLag:  defaultWebhookFinalityLag,
+		MaxBodyBytes: defaultWebhookMaxBodyBytes,
+	}
+}
+
+// Validate ensures the receiver is authenticated and bounded.
+func (c *WebhookConfig) Validate() error {
+	if c == nil {
+		return errors.New("webhook config is nil")
+	}
+	if c.Addr == "" {
+		return errors.New("webhook Addr is required")
+	}
+	if c.AuthHeader == "" {
+		return errors.New("webhook AuthHeader is required")
+	}
+	if c.DedupeSize <= 0 {
+		return fmt.Errorf("invalid DedupeSize: %d", c.DedupeSize)
+	}
+	if c.FinalityLag == 0 {
+		return errors.New("FinalityLag must be >= 1")
+	}
+	if c.MaxBodyBytes <= 0 {
+		return fmt.Errorf("invalid MaxBodyBytes: %d", c.MaxBodyBytes)
+	}
+	return nil
+}
+
+// WebhookConfigFromEnv builds a WebhookConfig from the environment.
+func WebhookConfigFromEnv() (*WebhookConfig, error) {
+	cfg := DefaultWebhookConfig()
+	if v := os.Getenv(envHeliusWebhookAddr); v != "" {
+		cfg.Addr = v
+	}
+	cfg.AuthHeader = os.Getenv(envHeliusWebhookAuth)
+
+	if v := os.Getenv(envHeliusWebhookDedupeSize); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", envHeliusWebhookDedupeSize, err)
+		}
+		cfg.DedupeSize = n
+	}
+	if v := os.Getenv(envHeliusWebhookFinalityLag); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", envHeliusWebhookFinalityLag, err)
+		}
+		cfg.FinalityLag = n
+	}
+	return cfg, cfg.Validate()
+}
+
+// UpdateHandler consumes Yellowstone-shaped updates; geyser.Processor
+// satisfies it.
+type UpdateHandler interface {
+	HandleUpdate(ctx context.Context, update *pb.SubscribeUpdate) error
+}
+
+// DeadLetterPublisher receives webhook transactions that cannot be processed;
+// *natsx.Publisher satisfies it.
+type DeadLetterPublisher interface {
+	PublishDecodeFailure(ctx context.Context, failure *dexv1.DecodeFailure) error
+}
+
+// errMissingIndex rejects transactions without their position in the block,
+// which ordering-sensitive consumers (MEV detection) rely on.
+var errMissingIndex = errors.New("missing indexWithinBlock")
+
+// WebhookHandler receives Helius raw-transaction webhooks, converts each
+// transaction into the Yellowstone update shape and feeds it to the wrapped
+// handler. Requests are serialised because the handler is not thread-safe.
+type WebhookHandler struct {
+	cfg  *WebhookConfig
+	sink UpdateHandler
+	dlq  DeadLetterPublisher
+
+	mu      sync.Mutex
+	seen    map[string]*list.Element
+	order   *list.List
+	open    map[uint64]struct{}
+	highest uint64
+}
+
+// NewWebhookHandler validates cfg and wraps sink.
+func NewWebhookHandler(cfg *WebhookConfig, sink UpdateHandler) (*WebhookHandler, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if sink == nil {
+		return nil, errors.New("update handler is required")
+	}
+	return &WebhookHandler{
+		cfg:   cfg,
+		sink:  sink,
+		seen:  make(map[string]*list.Element),
+		order: list.New(),
+		open:  make(map[uint64]struct{}),
+	}, nil
+}
+
+// SetDeadLetter publishes transactions that cannot be converted to dlq in
+// addition to logging them.
+func (h *WebhookHandler) SetDeadLetter(dlq DeadLetterPublisher) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.dlq = dlq
+}
+
+// ServeHTTP implements http.Handler. A processing failure answers 500 so
+// Helius retries the delivery; signatures already processed are skipped.
+// Malformed transactions are dead-lettered rather than failing the delivery,
+// since a retry would fail the same way.
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(h.cfg.AuthHeader)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.cfg.MaxBodyBytes))
+	if err != nil {
+		http.Error(w, "read body", http.StatusRequestEntityTooLarge)
+		return
+	}
+	var txs []solrpc.Transaction
+	if err := json.Unmarshal(body, &txs); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Handle(r.Context(), txs); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Handle processes one webhook delivery.
+func (h *WebhookHandler) Handle(ctx context.Context, txs []solrpc.Transaction) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i := range txs {
+		tx := &txs[i]
+		sig := tx.Signature()
+		if _, ok := h.seen[sig]; ok && sig != "" {
+			continue
+		}
+		update, err := convertWebhookTx(tx)
+		if err != nil {
+			if err := h.deadLetter(ctx, tx, sig, err); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := h.observeSlot(ctx, tx); err != nil {
+			return err
+		}
+		if err := h.sink.HandleUpdate(ctx, &pb.SubscribeUpdate{
+			UpdateOneof: &pb.SubscribeUpdate_Transaction{Transaction: update},
+		}); err != nil {
+			return fmt.Errorf("handle %s: %w", sig, err)
+		}
+		h.markSeen(sig)
+	}
+	return h.settle(ctx)
+}
+
+func convertWebhookTx(tx *solrpc.Transaction) (*pb.SubscribeUpdateTransaction, error) {
+	if tx.Signature() == "" {
+		return nil, errors.New("missing signature")
+	}
+	if tx.IndexWithinBlock == nil {
+		return nil, errMissingIndex
+	}
+	return tx.ToUpdate()
+}
+
+// deadLetter logs a transaction that cannot be converted and publishes it with
+// its source JSON to the dead-letter subject. Only a failure to publish fails
+// the delivery.
+func (h *WebhookHandler) deadLetter(ctx context.Context, tx *solrpc.Transaction, sig string, convertErr error) error {
+	log.Printf("helius webhook: dropping transaction %q in slot %d: %v", sig, tx.Slot, convertErr)
+	if h.dlq != nil {
+		raw, err := json.Marshal(tx)
+		if err != nil {
+			return fmt.Errorf("marshal dead-letter %q: %w", sig, err)
+		}
+		if err := h.dlq.PublishDecodeFailure(ctx, &dexv1.DecodeFailure{
+			ChainId:      501,
+			Slot:         tx.Slot,
+			Sig:          sig,
+			Error:        fmt.Sprintf("convert webhook transaction: %v", convertErr),
+			RawTx:        raw,
+			ObservedAtMs: uint64(time.Now().UnixMilli()),
+			RawTxJson:    true,
+		}); err != nil {
+			return fmt.Errorf("dead-letter %q: %w", sig, err)
+		}
+	}
+	if sig != "" {
+		h.markSeen(sig)
+	}
+	return nil
+}
+
+// observeSlot emits a block meta the first time a slot is opened so the
+// processor can timestamp its swaps. A late delivery for an already settled
+// slot reopens it and is settled again at the end of the request.
+func (h *WebhookHandler) observeSlot(ctx context.Context, tx *solrpc.Transaction) error {
+	if _, ok := h.open[tx.Slot]; ok {
+		return nil
+	}
+	meta := &pb.SubscribeUpdateBlockMeta{Slot: tx.Slot}
+	if tx.BlockTime != nil {
+		meta.BlockTime = &pb.UnixTimestamp{Timestamp: *tx.BlockTime}
+	}
+	if err := h.sink.HandleUpdate(ctx, &pb.SubscribeUpdate{
+		UpdateOneof: &pb.SubscribeUpdate_BlockMeta{BlockMeta: meta},
+	}); err != nil {
+		return fmt.Errorf("block meta %d: %w", tx.Slot, err)
+	}
+	h.open[tx.Slot] = struct{}{}
+	if tx.Slot > h.highest {
+		h.highest = tx.Slot
+	}
+	return nil
+}
+
+// settle confirms and finalizes open slots that trail the highest observed
+// slot by at least FinalityLag.
+func (h *WebhookHandler) settle(ctx context.Context) error {
+	if h.highest < h.cfg.FinalityLag {
+		return nil
+	}
+	cutoff := h.highest - h.cfg.FinalityLag
+	var due []uint64
+	for slot := range h.open {
+		if slot <= cutoff {
+			due = append(due, slot)
+		}
+	}
+	slices.Sort(due)
+	for _, slot := range due {
+		for _, status := range []pb.SlotStatus{pb.SlotStatus_SLOT_CONFIRMED, pb.SlotStatus_SLOT_FINALIZED} {
+			if err := h.sink.HandleUpdate(ctx, &pb.SubscribeUpdate{
+				UpdateOneof: &pb.SubscribeUpdate_Slot{Slot: &pb.SubscribeUpdateSlot{Slot: slot, Status: status}},
+			}); err != nil {
+				return fmt.Errorf("settle slot %d: %w", slot, err)
+			}
+		}
+		delete(h.open, slot)
+	}
+	return nil
+}
+
+func (h *WebhookHandler) markSeen(sig string) {
+	h.seen[sig] = h.order.PushBack(sig)
+	for h.order.Len() > h.cfg.DedupeSize {
+		oldest := h.order.Front()
+		h.order.Remove(oldest)
+		delete(h.seen, oldest.Value.(string))
+	}
+}
+
+// ListenAndServe serves the webhook handler on cfg.Addr (plus any extra
+// routes on mux) until ctx is cancelled.
+func (h *WebhookHandler) ListenAndServe(ctx context.Context, mux *http.ServeMux) error {
+	if mux == nil {
+		mux = http.NewServeMux()
+	}
+	mux.Handle("/", h)
+	server := &http.Server{
+		Addr:              h.cfg.Addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.ListenAndServe() }()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("shutdown webhook server: %w", err)
+		}
+		return ctx.Err()
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return fmt.Errorf("webhook server: %w", err)
+	}
+}
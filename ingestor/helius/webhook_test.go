@@ -0,0 +1,229 @@
+package helius
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+
+	pb "github.com/rpcpool/yellowstone-grpc/examples/golang/proto"
+)
+
+type recordingHandler struct {
+	updates []*pb.SubscribeUpdate
+	fail    bool
+}
+
+func (r *recordingHandler) HandleUpdate(_ context.Context, update *pb.SubscribeUpdate) error {
+	if r.fail {
+		return errors.New("publisher down")
+	}
+	r.updates = append(r.updates, update)
+	return nil
+}
+
+func (r *recordingHandler) count() (txs, metas int, statuses []string) {
+	for _, u := range r.updates {
+		switch v := u.GetUpdateOneof().(type) {
+		case *pb.SubscribeUpdate_Transaction:
+			txs++
+		case *pb.SubscribeUpdate_BlockMeta:
+			metas++
+		case *pb.SubscribeUpdate_Slot:
+			statuses = append(statuses, fmt.Sprintf("%d:%s", v.Slot.GetSlot(), v.Slot.GetStatus()))
+		}
+	}
+	return txs, metas, statuses
+}
+
+func webhookPayload(slot uint64, sigs ...string) string {
+	var items []string
+	for i, sig := range sigs {
+		items = append(items, fmt.Sprintf(`{"slot": %d, "blockTime": 1700000000, "indexWithinBlock": %d, "meta": {"err": null, "fee": 5000},
+		  "transaction": {"signatures": [%q], "message": {"header": {}, "accountKeys": ["11111111111111111111111111111111"], "instructions": []}}}`, slot, i, sig))
+	}
+	return "[" + strings.Join(items, ",") + "]"
+}
+
+func newTestWebhook(t *testing.T, sink UpdateHandler) *WebhookHandler {
+	t.Helper()
+	cfg := DefaultWebhookConfig()
+	cfg.AuthHeader = "Bearer secret"
+	cfg.FinalityLag = 2
+	handler, err := NewWebhookHandler(cfg, sink)
+	if err != nil {
+		t.Fatalf("NewWebhookHandler() error = %v", err)
+	}
+	return handler
+}
+
+func postWebhook(t *testing.T, url, auth, body string) int {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	req.Header.Set("Authorization", auth)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post webhook: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestWebhookRejectsBadAuth(t *testing.T) {
+	sink := &recordingHandler{}
+	server := httptest.NewServer(newTestWebhook(t, sink))
+	defer server.Close()
+
+	if code := postWebhook(t, server.URL, "Bearer wrong", webhookPayload(10, "5VERv8NMvzbJ")); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", code)
+	}
+	if code := postWebhook(t, server.URL, "Bearer secret", "{not json"); code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", code)
+	}
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", resp.StatusCode)
+	}
+	if len(sink.updates) != 0 {
+		t.Fatalf("expected no updates, got %d", len(sink.updates))
+	}
+}
+
+func TestWebhookDeduplicatesRetriesAndSettlesSlots(t *testing.T) {
+	sink := &recordingHandler{}
+	server := httptest.NewServer(newTestWebhook(t, sink))
+	defer server.Close()
+
+	if code := postWebhook(t, server.URL, "Bearer secret", webhookPayload(10, "5VERv8NMvzbJ", "4ZkQUW")); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	// A retried delivery must not feed the processor again.
+	if code := postWebhook(t, server.URL, "Bearer secret", webhookPayload(10, "5VERv8NMvzbJ")); code != http.StatusOK {
+		t.Fatalf("expected 200 on retry, got %d", code)
+	}
+	txs, metas, statuses := sink.count()
+	if txs != 2 || metas != 1 || len(statuses) != 0 {
+		t.Fatalf("unexpected updates txs=%d metas=%d statuses=%v", txs, metas, statuses)
+	}
+
+	if code := postWebhook(t, server.URL, "Bearer secret", webhookPayload(12, "3Bxs4Bc3")); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	txs, metas, statuses = sink.count()
+	if txs != 3 || metas != 2 {
+		t.Fatalf("unexpected updates txs=%d metas=%d", txs, metas)
+	}
+	want := []string{"10:SLOT_CONFIRMED", "10:SLOT_FINALIZED"}
+	if strings.Join(statuses, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected slot statuses %v", statuses)
+	}
+}
+
+func TestWebhookFailureAllowsRetry(t *testing.T) {
+	sink := &recordingHandler{fail: true}
+	server := httptest.NewServer(newTestWebhook(t, sink))
+	defer server.Close()
+
+	if code := postWebhook(t, server.URL, "Bearer secret", webhookPayload(10, "5VERv8NMvzbJ")); code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", code)
+	}
+	sink.fail = false
+	if code := postWebhook(t, server.URL, "Bearer secret", webhookPayload(10, "5VERv8NMvzbJ")); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if txs, _, _ := sink.count(); txs != 1 {
+		t.Fatalf("expected retried transaction to be processed once, got %d", txs)
+	}
+}
+
+type recordingDeadLetter struct {
+	failures []*dexv1.DecodeFailure
+}
+
+func (r *recordingDeadLetter) PublishDecodeFailure(_ context.Context, failure *dexv1.DecodeFailure) error {
+	r.failures = append(r.failures, failure)
+	return nil
+}
+
+func TestWebhookDeadLettersMalformedTransactions(t *testing.T) {
+	sink := &recordingHandler{}
+	dlq := &recordingDeadLetter{}
+	handler := newTestWebhook(t, sink)
+	handler.SetDeadLetter(dlq)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	body := `[
+	  {"slot": 10, "indexWithinBlock": 0, "meta": {"err": null},
+	   "transaction": {"signatures": ["not-base58-0OIl"], "message": {"header": {}, "accountKeys": [], "instructions": []}}},
+	  {"slot": 10, "meta": {"err": null},
+	   "transaction": {"signatures": ["3Bxs4Bc3"], "message": {"header": {}, "accountKeys": [], "instructions": []}}},
+	  {"slot": 10, "indexWithinBlock": 2, "meta": {"err": null},
+	   "transaction": {"signatures": ["5VERv8NMvzbJ"], "message": {"header": {}, "accountKeys": [], "instructions": []}}}
+	]`
+	if code := postWebhook(t, server.URL, "Bearer secret", body); code != http.StatusOK {
+		t.Fatalf("expected 200 so Helius stops redelivering, got %d", code)
+	}
+	if txs, _, _ := sink.count(); txs != 1 {
+		t.Fatalf("expected the valid transaction to be processed, got %d", txs)
+	}
+	if len(dlq.failures) != 2 {
+		t.Fatalf("expected 2 dead-lettered transactions, got %d", len(dlq.failures))
+	}
+	missing := dlq.failures[1]
+	if missing.GetSig() != "3Bxs4Bc3" || !strings.Contains(missing.GetError(), "indexWithinBlock") || !missing.GetRawTxJson() {
+		t.Fatalf("unexpected dead letter %+v", missing)
+	}
+
+	// A redelivery does not dead-letter the same transactions again.
+	if code := postWebhook(t, server.URL, "Bearer secret", body); code != http.StatusOK {
+		t.Fatalf("expected 200 on retry, got %d", code)
+	}
+	if len(dlq.failures) != 2 {
+		t.Fatalf("expected no new dead letters on retry, got %d", len(dlq.failures))
+	}
+}
+
+func TestWebhookDedupeEviction(t *testing.T) {
+	sink := &recordingHandler{}
+	handler := newTestWebhook(t, sink)
+	handler.cfg.DedupeSize = 1
+	handler.markSeen("a")
+	handler.markSeen("b")
+	if _, ok := handler.seen["a"]; ok {
+		t.Fatal("expected oldest signature to be evicted")
+	}
+	if _, ok := handler.seen["b"]; !ok {
+		t.Fatal("expected newest signature to be retained")
+	}
+}
+
+func TestWebhookConfigFromEnv(t *testing.T) {
+	t.Setenv(envHeliusWebhookAuth, "")
+	if _, err := WebhookConfigFromEnv(); err == nil {
+		t.Fatal("expected error when auth header is missing")
+	}
+	t.Setenv(envHeliusWebhookAuth, "Bearer secret")
+	t.Setenv(envHeliusWebhookAddr, ":9999")
+	t.Setenv(envHeliusWebhookDedupeSize, "10")
+	t.Setenv(envHeliusWebhookFinalityLag, "4")
+	cfg, err := WebhookConfigFromEnv()
+	if err != nil {
+		t.Fatalf("WebhookConfigFromEnv() error = %v", err)
+	}
+	if cfg.Addr != ":9999" || cfg.DedupeSize != 10 || cfg.FinalityLag != 4 {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+}
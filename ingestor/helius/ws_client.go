@@ -0,0 +1,417 @@
+package helius
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/rexbrahh/lp-indexer/ingestor/solrpc"
+
+	pb "github.com/rpcpool/yellowstone-grpc/examples/golang/proto"
+)
+
+const (
+	wsCommitment      = "confirmed"
+	wsOrigin          = "http://localhost"
+	wsSeenSignatures  = 50_000
+	wsFetchQueue      = 1024
+	wsFetchAttempts   = 5
+	wsFetchRetryDelay = 250 * time.Millisecond
+)
+
+// WSClient is the WebSocket fallback transport. It subscribes to program logs
+// via logsSubscribe, fetches each matching transaction over JSON-RPC and
+// emits it in the same Yellowstone update shape as LaserStream. Slot statuses
+// come from slotSubscribe (processed) and rootSubscribe (finalized); a slot is
+// reported confirmed once transactions from a later slot arrive, or when it is
+// rooted. The WebSocket API cannot replay, so the start slot passed to
+// Subscribe is ignored and account updates are not available.
+type WSClient struct {
+	cfg *Config
+	rpc *solrpc.Client
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu   sync.Mutex
+	conn *websocket.Conn
+}
+
+// NewWSClient validates the configuration and prepares the WebSocket client.
+func NewWSClient(cfg *Config) (*WSClient, error) {
+	if cfg == nil {
+		return nil, errors.New("config is nil")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid helius config: %w", err)
+	}
+	if len(cfg.ProgramFilters) == 0 {
+		return nil, errors.New("ProgramFilters must contain at least one entry")
+	}
+	rpcURL, err := cfg.RPCURL()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WSClient{
+		cfg:    cfg,
+		rpc:    solrpc.NewClient(rpcURL, cfg.RequestTimeout),
+		ctx:    ctx,
+		cancel: cancel,
+	}, nil
+}
+
+// Connect dials the WebSocket endpoint.
+func (c *WSClient) Connect() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != nil {
+		return nil
+	}
+	wsURL, err := c.cfg.WebSocketURL()
+	if err != nil {
+		return err
+	}
+	wsCfg, err := websocket.NewConfig(wsURL, wsOrigin)
+	if err != nil {
+		return fmt.Errorf("helius websocket config: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(c.ctx, c.cfg.RequestTimeout)
+	defer cancel()
+	conn, err := wsCfg.DialContext(ctx)
+	if err != nil {
+		return fmt.Errorf("dial helius websocket: %w", err)
+	}
+	c.conn = conn
+	return nil
+}
+
+// Subscribe issues one logsSubscribe per configured program plus slotSubscribe
+// and rootSubscribe, and streams the resulting transactions and slot statuses. Both channels close when the connection drops.
+func (c *WSClient) Subscribe(uint64) (<-chan *pb.SubscribeUpdate, <-chan error) {
+	updates := make(chan *pb.SubscribeUpdate, 128)
+	errs := make(chan error, 1)
+
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	if conn == nil {
+		errs <- errors.New("helius websocket not connected")
+		close(errs)
+		close(updates)
+		return updates, errs
+	}
+
+	sigs := make(chan wsNotification, wsFetchQueue)
+	go c.readLoop(conn, sigs, errs)
+	go c.fetchLoop(sigs, updates, errs)
+	return updates, errs
+}
+
+// Close tears down the WebSocket connection and stops the fetch loop.
+func (c *WSClient) Close() error {
+	c.cancel()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// Name identifies the transport.
+func (c *WSClient) Name() string { return "helius-ws" }
+
+type wsMessage struct {
+	ID     *uint64         `json:"id"`
+	Method string          `json:"method"`
+	Result json.RawMessage `json:"result"`
+	Error  *solrpc.Error   `json:"error"`
+	Params *struct {
+		Result       json.RawMessage `json:"result"`
+		Subscription uint64          `json:"subscription"`
+	} `json:"params"`
+}
+
+type logsResult struct {
+	Context struct {
+		Slot uint64 `json:"slot"`
+	} `json:"context"`
+	Value struct {
+		Signature string          `json:"signature"`
+		Err       json.RawMessage `json:"err"`
+	} `json:"value"`
+}
+
+type slotResult struct {
+	Slot   uint64 `json:"slot"`
+	Parent uint64 `json:"parent"`
+	Root   uint64 `json:"root"`
+}
+
+type wsNotificationKind int
+
+const (
+	notifyLogs wsNotificationKind = iota
+	notifySlot
+	notifyRoot
+)
+
+// wsNotification is a subscription event. Slot and root events share the
+// transaction queue so statuses are emitted after the transactions notified
+// before them.
+type wsNotification struct {
+	kind      wsNotificationKind
+	slot      uint64
+	signature string
+}
+
+func (c *WSClient) readLoop(conn *websocket.Conn, sigs chan<- wsNotification, errs chan<- error) {
+	defer close(sigs)
+
+	programs := make([]string, 0, len(c.cfg.ProgramFilters))
+	for _, programID := range c.cfg.ProgramFilters {
+		programs = append(programs, programID)
+	}
+	sort.Strings(programs)
+	for i, programID := range programs {
+		req := map[string]any{
+			"jsonrpc": "2.0",
+			"id":      i + 1,
+			"method":  "logsSubscribe",
+			"params": []any{
+				map[string]any{"mentions": []string{programID}},
+				map[string]any{"commitment": wsCommitment},
+			},
+		}
+		if err := websocket.JSON.Send(conn, req); err != nil {
+			c.sendErr(errs, fmt.Errorf("helius logsSubscribe %s: %w", programID, err))
+			return
+		}
+	}
+	for i, method := range []string{"slotSubscribe", "rootSubscribe"} {
+		req := map[string]any{
+			"jsonrpc": "2.0",
+			"id":      len(programs) + i + 1,
+			"method":  method,
+		}
+		if err := websocket.JSON.Send(conn, req); err != nil {
+			c.sendErr(errs, fmt.Errorf("helius %s: %w", method, err))
+			return
+		}
+	}
+
+	for {
+		var msg wsMessage
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			if c.ctx.Err() == nil {
+				c.sendErr(errs, fmt.Errorf("helius websocket recv failed: %w", err))
+			}
+			return
+		}
+		if msg.Error != nil {
+			c.sendErr(errs, fmt.Errorf("helius websocket: %w", msg.Error))
+			return
+		}
+		if msg.Params == nil {
+			continue
+		}
+		var note wsNotification
+		switch msg.Method {
+		case "logsNotification":
+			var result logsResult
+			if err := json.Unmarshal(msg.Params.Result, &result); err != nil || result.Value.Signature == "" {
+				continue
+			}
+			note = wsNotification{kind: notifyLogs, slot: result.Context.Slot, signature: result.Value.Signature}
+		case "slotNotification":
+			var result slotResult
+			if err := json.Unmarshal(msg.Params.Result, &result); err != nil {
+				continue
+			}
+			note = wsNotification{kind: notifySlot, slot: result.Slot}
+		case "rootNotification":
+			var root uint64
+			if err := json.Unmarshal(msg.Params.Result, &root); err != nil {
+				continue
+			}
+			note = wsNotification{kind: notifyRoot, slot: root}
+		default:
+			continue
+		}
+		select {
+		case sigs <- note:
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// fetchLoop resolves notified signatures into transactions and turns slot and
+// root notifications into slot statuses. A transaction touching several
+// programs is notified once per subscription, so signatures are deduplicated.
+func (c *WSClient) fetchLoop(sigs <-chan wsNotification, updates chan<- *pb.SubscribeUpdate, errs chan<- error) {
+	defer close(updates)
+	defer close(errs)
+
+	seen := make(map[string]struct{})
+	order := list.New()
+	var lastSlot, lastRoot uint64
+	lastConfirmed := true
+	// unrooted holds slots that delivered transactions and still need a
+	// finalized status.
+	unrooted := make(map[uint64]struct{})
+
+	for note := range sigs {
+		switch note.kind {
+		case notifySlot:
+			if !c.emit(updates, slotStatus(note.slot, pb.SlotStatus_SLOT_PROCESSED)) {
+				return
+			}
+			continue
+		case notifyRoot:
+			if note.slot <= lastRoot {
+				continue
+			}
+			lastRoot = note.slot
+			if !lastConfirmed && lastSlot <= lastRoot {
+				lastConfirmed = true
+				if !c.emit(updates, slotStatus(lastSlot, pb.SlotStatus_SLOT_CONFIRMED)) {
+					return
+				}
+			}
+			slots := make([]uint64, 0, len(unrooted)+1)
+			for slot := range unrooted {
+				if slot < lastRoot {
+					slots = append(slots, slot)
+					delete(unrooted, slot)
+				}
+			}
+			delete(unrooted, lastRoot)
+			sort.Slice(slots, func(i, j int) bool { return slots[i] < slots[j] })
+			for _, slot := range append(slots, lastRoot) {
+				if !c.emit(updates, slotStatus(slot, pb.SlotStatus_SLOT_FINALIZED)) {
+					return
+				}
+			}
+			continue
+		}
+
+		if _, ok := seen[note.signature]; ok {
+			continue
+		}
+		seen[note.signature] = struct{}{}
+		order.PushBack(note.signature)
+		if order.Len() > wsSeenSignatures {
+			delete(seen, order.Remove(order.Front()).(string))
+		}
+
+		tx, err := c.fetchTransaction(note.signature)
+		if err != nil {
+			c.sendErr(errs, err)
+			continue
+		}
+		if tx == nil {
+			c.sendErr(errs, fmt.Errorf("helius getTransaction %s: not found", note.signature))
+			continue
+		}
+		update, err := tx.ToUpdate()
+		if err != nil {
+			c.sendErr(errs, fmt.Errorf("convert %s: %w", note.signature, err))
+			continue
+		}
+
+		if tx.Slot > lastSlot {
+			// Logs are notified at confirmed commitment, so a transaction
+			// from a later slot means the previous slot is complete.
+			if !lastConfirmed {
+				if !c.emit(updates, slotStatus(lastSlot, pb.SlotStatus_SLOT_CONFIRMED)) {
+					return
+				}
+			}
+			lastSlot = tx.Slot
+			lastConfirmed = false
+			meta := &pb.SubscribeUpdateBlockMeta{Slot: tx.Slot}
+			if tx.BlockTime != nil {
+				meta.BlockTime = &pb.UnixTimestamp{Timestamp: *tx.BlockTime}
+			}
+			if !c.emit(updates, &pb.SubscribeUpdate{UpdateOneof: &pb.SubscribeUpdate_BlockMeta{BlockMeta: meta}}) {
+				return
+			}
+		}
+		if !c.emit(updates, &pb.SubscribeUpdate{UpdateOneof: &pb.SubscribeUpdate_Transaction{Transaction: update}}) {
+			return
+		}
+		if tx.Slot > lastRoot {
+			unrooted[tx.Slot] = struct{}{}
+			continue
+		}
+		// The slot was rooted before its transaction was fetched; settle it
+		// straight away so its swaps are not left provisional.
+		if !c.emit(updates, slotStatus(tx.Slot, pb.SlotStatus_SLOT_CONFIRMED)) ||
+			!c.emit(updates, slotStatus(tx.Slot, pb.SlotStatus_SLOT_FINALIZED)) {
+			return
+		}
+		if tx.Slot == lastSlot {
+			lastConfirmed = true
+		}
+	}
+}
+
+func slotStatus(slot uint64, status pb.SlotStatus) *pb.SubscribeUpdate {
+	return &pb.SubscribeUpdate{
+		UpdateOneof: &pb.SubscribeUpdate_Slot{
+			Slot: &pb.SubscribeUpdateSlot{Slot: slot, Status: status},
+		},
+	}
+}
+
+// fetchTransaction retries briefly because a signature notified at confirmed
+// commitment may not be served by getTransaction yet.
+func (c *WSClient) fetchTransaction(signature string) (*solrpc.Transaction, error) {
+	var lastErr error
+	for attempt := 0; attempt < wsFetchAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(time.Duration(attempt) * wsFetchRetryDelay):
+			case <-c.ctx.Done():
+				return nil, c.ctx.Err()
+			}
+		}
+		tx, err := c.rpc.GetTransaction(c.ctx, signature, wsCommitment)
+		if err == nil && tx != nil {
+			return tx, nil
+		}
+		lastErr = err
+	}
+	if lastErr != nil {
+		return nil, fmt.Errorf("helius getTransaction %s: %w", signature, lastErr)
+	}
+	return nil, nil
+}
+
+func (c *WSClient) emit(updates chan<- *pb.SubscribeUpdate, update *pb.SubscribeUpdate) bool {
+	select {
+	case updates <- update:
+		return true
+	case <-c.ctx.Done():
+		return false
+	}
+}
+
+func (c *WSClient) sendErr(errs chan<- error, err error) {
+	select {
+	case errs <- err:
+	default:
+	}
+}
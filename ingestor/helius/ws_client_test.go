@@ -0,0 +1,346 @@
+package helius
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	pb "github.com/rpcpool/yellowstone-grpc/examples/golang/proto"
+)
+
+const testSignature = "5VERv8NMvzbJMEkV8xnrLkEaWRtSz9CosKDYjCJjBRnbJLgp8uirBgmQpjKhoR4tjF3ZpRzrFmBV6UjKdiSZkQUW"
+
+// rpcStandIn serves the Solana WebSocket subscription API and JSON-RPC over
+// HTTP on the same address, like a Helius endpoint.
+type rpcStandIn struct {
+	server *httptest.Server
+	notify chan string
+	// frames are written to the WebSocket verbatim.
+	frames chan string
+
+	mu         sync.Mutex
+	subscribed []string
+	fetched    []string
+	apiKeys    []string
+}
+
+func newRPCStandIn(t *testing.T) *rpcStandIn {
+	t.Helper()
+	s := &rpcStandIn{notify: make(chan string, 8), frames: make(chan string, 8)}
+	ws := websocket.Handler(s.serveWS)
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.apiKeys = append(s.apiKeys, r.URL.Query().Get("api-key"))
+		s.mu.Unlock()
+		if r.Method == http.MethodPost {
+			s.serveRPC(w, r)
+			return
+		}
+		ws.ServeHTTP(w, r)
+	}))
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+func (s *rpcStandIn) wsURL() string {
+	return "ws" + strings.TrimPrefix(s.server.URL, "http")
+}
+
+func (s *rpcStandIn) serveWS(conn *websocket.Conn) {
+	go func() {
+		for {
+			var req struct {
+				ID     int               `json:"id"`
+				Method string            `json:"method"`
+				Params []json.RawMessage `json:"params"`
+			}
+			if err := websocket.JSON.Receive(conn, &req); err != nil {
+				return
+			}
+			var filter struct {
+				Mentions []string `json:"mentions"`
+			}
+			sub := req.Method
+			if len(req.Params) > 0 {
+				_ = json.Unmarshal(req.Params[0], &filter)
+				sub += ":" + strings.Join(filter.Mentions, ",")
+			}
+			s.mu.Lock()
+			s.subscribed = append(s.subscribed, sub)
+			s.mu.Unlock()
+			_ = websocket.JSON.Send(conn, map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": req.ID})
+		}
+	}()
+	for {
+		select {
+		case sig := <-s.notify:
+			// Each signature is notified twice, as it would be for a
+			// transaction mentioning two subscribed programs.
+			for sub := 1; sub <= 2; sub++ {
+				msg := fmt.Sprintf(`{"jsonrpc":"2.0","method":"logsNotification","params":{"result":{"context":{"slot":77},"value":{"signature":%q,"err":null,"logs":[]}},"subscription":%d}}`, sig, sub)
+				if _, err := conn.Write([]byte(msg)); err != nil {
+					return
+				}
+			}
+		case frame := <-s.frames:
+			if _, err := conn.Write([]byte(frame)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *rpcStandIn) serveRPC(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     int               `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Method != "getTransaction" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	var sig string
+	_ = json.Unmarshal(req.Params[0], &sig)
+	s.mu.Lock()
+	s.fetched = append(s.fetched, sig)
+	s.mu.Unlock()
+
+	tx := fmt.Sprintf(`{"slot": 77, "blockTime": 1700000077, "version": "legacy",
+	  "meta": {"err": null, "fee": 5000, "preBalances": [10000], "postBalances": [5000]},
+	  "transaction": {"signatures": [%q], "message": {"header": {"numRequiredSignatures": 1},
+	  "accountKeys": ["11111111111111111111111111111111"], "instructions": []}}}`, sig)
+	fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":%s}`, req.ID, tx)
+}
+
+func (s *rpcStandIn) snapshot() (subscribed, fetched, keys []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.subscribed...), append([]string(nil), s.fetched...), append([]string(nil), s.apiKeys...)
+}
+
+func standInConfig(s *rpcStandIn) *Config {
+	return &Config{
+		GRPCEndpoint:     "grpc.example.com:443",
+		WSEndpoint:       s.wsURL(),
+		APIKey:           "secret",
+		RequestTimeout:   time.Second,
+		ReconnectBackoff: 50 * time.Millisecond,
+		ReplaySlots:      64,
+		ProgramFilters: map[string]string{
+			"raydium": "CAMMCzo5YL8w4VFF8KVHrK22GGUsp5VTaW7grrKgrWqK",
+			"orca":    "whirLbMiicVdio4qvUfM5KAg6Ct8VwpYzGff3uctyCc",
+		},
+	}
+}
+
+func TestWSClientStreamsTransactions(t *testing.T) {
+	standIn := newRPCStandIn(t)
+	ws, err := NewWSClient(standInConfig(standIn))
+	if err != nil {
+		t.Fatalf("NewWSClient() error = %v", err)
+	}
+	defer ws.Close()
+	if err := ws.Connect(); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	updates, errs := ws.Subscribe(0)
+	standIn.notify <- testSignature
+
+	var got []*pb.SubscribeUpdate
+	timeout := time.After(2 * time.Second)
+	for len(got) < 2 {
+		select {
+		case u := <-updates:
+			got = append(got, u)
+		case err := <-errs:
+			t.Fatalf("unexpected error: %v", err)
+		case <-timeout:
+			t.Fatalf("timed out, got %d updates", len(got))
+		}
+	}
+	meta := got[0].GetBlockMeta()
+	if meta == nil || meta.GetSlot() != 77 || meta.GetBlockTime().GetTimestamp() != 1700000077 {
+		t.Fatalf("expected block meta first, got %v", got[0])
+	}
+	tx := got[1].GetTransaction()
+	if tx == nil || tx.GetSlot() != 77 || tx.GetTransaction().GetMeta().GetFee() != 5000 {
+		t.Fatalf("unexpected transaction update %v", got[1])
+	}
+
+	// The duplicate notification must not trigger a second fetch.
+	select {
+	case u := <-updates:
+		t.Fatalf("unexpected extra update %v", u)
+	case <-time.After(100 * time.Millisecond):
+	}
+	subscribed, fetched, keys := standIn.snapshot()
+	want := []string{
+		"logsSubscribe:CAMMCzo5YL8w4VFF8KVHrK22GGUsp5VTaW7grrKgrWqK",
+		"logsSubscribe:whirLbMiicVdio4qvUfM5KAg6Ct8VwpYzGff3uctyCc",
+		"slotSubscribe",
+		"rootSubscribe",
+	}
+	if strings.Join(subscribed, " ") != strings.Join(want, " ") {
+		t.Fatalf("unexpected subscriptions %v", subscribed)
+	}
+	if len(fetched) != 1 || fetched[0] != testSignature {
+		t.Fatalf("unexpected fetches %v", fetched)
+	}
+	for _, key := range keys {
+		if key != "secret" {
+			t.Fatalf("expected api-key on every request, got %v", keys)
+		}
+	}
+}
+
+func TestWSClientEmitsSlotStatuses(t *testing.T) {
+	standIn := newRPCStandIn(t)
+	ws, err := NewWSClient(standInConfig(standIn))
+	if err != nil {
+		t.Fatalf("NewWSClient() error = %v", err)
+	}
+	defer ws.Close()
+	if err := ws.Connect(); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	updates, errs := ws.Subscribe(0)
+
+	next := func() *pb.SubscribeUpdate {
+		t.Helper()
+		select {
+		case u := <-updates:
+			return u
+		case err := <-errs:
+			t.Fatalf("unexpected error: %v", err)
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for update")
+		}
+		return nil
+	}
+	expectStatus := func(u *pb.SubscribeUpdate, slot uint64, status pb.SlotStatus) {
+		t.Helper()
+		if u.GetSlot() == nil || u.GetSlot().GetSlot() != slot || u.GetSlot().GetStatus() != status {
+			t.Fatalf("expected slot %d %v, got %v", slot, status, u)
+		}
+	}
+
+	standIn.frames <- `{"jsonrpc":"2.0","method":"slotNotification","params":{"result":{"parent":76,"root":40,"slot":77},"subscription":3}}`
+	expectStatus(next(), 77, pb.SlotStatus_SLOT_PROCESSED)
+
+	standIn.notify <- testSignature
+	if next().GetBlockMeta() == nil {
+		t.Fatal("expected block meta")
+	}
+	if next().GetTransaction() == nil {
+		t.Fatal("expected transaction")
+	}
+
+	// Rooting the slot confirms it before finalizing it.
+	standIn.frames <- `{"jsonrpc":"2.0","method":"rootNotification","params":{"result":77,"subscription":4}}`
+	expectStatus(next(), 77, pb.SlotStatus_SLOT_CONFIRMED)
+	expectStatus(next(), 77, pb.SlotStatus_SLOT_FINALIZED)
+}
+
+func TestClientFallsBackToWebSocket(t *testing.T) {
+	standIn := newRPCStandIn(t)
+	client, err := NewClient(standInConfig(standIn))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	client.newStream = func(*Config) (streamClient, error) {
+		return nil, errors.New("laserstream unavailable")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates, errs := client.Start(ctx, 0)
+
+	select {
+	case err := <-errs:
+		if err == nil || !strings.Contains(err.Error(), "laserstream unavailable") {
+			t.Fatalf("expected laserstream error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for laserstream error")
+	}
+
+	standIn.notify <- testSignature
+	head := expectUpdate(t, updates, func(u Update) bool { return u.BlockHead != nil })
+	if head.BlockHead.GetSlot() != 77 || head.BlockHead.GetTsSec() != 1700000077 {
+		t.Fatalf("unexpected block head %v", head.BlockHead)
+	}
+	meta := expectUpdate(t, updates, func(u Update) bool { return u.TxMeta != nil })
+	if meta.TxMeta.GetSig() != testSignature {
+		t.Fatalf("unexpected tx meta signature %s", meta.TxMeta.GetSig())
+	}
+
+	health := client.Health()
+	if !health.Healthy || health.Source != "websocket" || health.LastSlot != 77 {
+		t.Fatalf("unexpected health %+v", health)
+	}
+}
+
+func TestClientReturnsToLaserStream(t *testing.T) {
+	standIn := newRPCStandIn(t)
+	client, err := NewClient(standInConfig(standIn))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	fake := newFakeStreamClient()
+	client.newStream = func(*Config) (streamClient, error) {
+		return fake, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates, _ := client.Start(ctx, 0)
+
+	fake.errs <- errors.New("laserstream recv failed")
+	waitFor(t, func() bool { return client.Health().Source == "websocket" && client.Health().Healthy })
+
+	fake.updates <- &pb.SubscribeUpdate{
+		UpdateOneof: &pb.SubscribeUpdate_BlockMeta{
+			BlockMeta: &pb.SubscribeUpdateBlockMeta{Slot: 90},
+		},
+	}
+	expectUpdate(t, updates, func(u Update) bool { return u.BlockHead != nil && u.BlockHead.GetSlot() == 90 })
+	if health := client.Health(); health.Source != "grpc" || !health.Healthy {
+		t.Fatalf("expected grpc source after recovery, got %+v", health)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before deadline")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestConfigRPCURL(t *testing.T) {
+	cfg := &Config{WSEndpoint: "wss://mainnet.helius-rpc.com/", APIKey: "key"}
+	got, err := cfg.RPCURL()
+	if err != nil {
+		t.Fatalf("RPCURL() error = %v", err)
+	}
+	if got != "https://mainnet.helius-rpc.com/?api-key=key" {
+		t.Fatalf("unexpected rpc url %s", got)
+	}
+	cfg.RPCEndpoint = "https://rpc.example.com/?api-key=other"
+	if got, _ := cfg.RPCURL(); got != "https://rpc.example.com/?api-key=other" {
+		t.Fatalf("explicit api-key should be kept, got %s", got)
+	}
+}
@@ -0,0 +1,47 @@
+package pools
+
+import (
+	"fmt"
+
+	"github.com/rexbrahh/lp-indexer/decoder/anchor"
+)
+
+// mustEnd returns the minimum account length needed to read field, failing
+// at init when the embedded IDL no longer has it at a fixed offset.
+func mustEnd(layout *anchor.Layout, field string) int {
+	end, ok := layout.End(field)
+	if !ok {
+		panic(fmt.Sprintf("pools: %s.%s has no fixed offset", layout.Name, field))
+	}
+	return end
+}
+
+// fieldErrors keeps the first error from a run of field reads so decoders can
+// read several fields before checking.
+type fieldErrors struct {
+	err error
+}
+
+func (e *fieldErrors) pubkey(v *anchor.Value, name string) string {
+	s, err := v.Pubkey(name)
+	e.record(err)
+	return s
+}
+
+func (e *fieldErrors) u16(v *anchor.Value, name string) uint16 {
+	n, err := v.U16(name)
+	e.record(err)
+	return n
+}
+
+func (e *fieldErrors) u32(v *anchor.Value, name string) uint32 {
+	n, err := v.U32(name)
+	e.record(err)
+	return n
+}
+
+func (e *fieldErrors) record(err error) {
+	if e.err == nil {
+		e.err = err
+	}
+}
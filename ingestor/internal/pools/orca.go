@@ -1,28 +1,15 @@
 package pools
 
 import (
-	"encoding/binary"
 	"fmt"
 
-	"github.com/mr-tron/base58/base58"
+	"github.com/rexbrahh/lp-indexer/decoder/anchor"
 )
 
-const (
-	orcaDiscriminatorLen    = 8
-	orcaConfigOffset        = orcaDiscriminatorLen
-	orcaFeeRateOffset       = orcaConfigOffset + 32 + 1 + 2 + 2
-	orcaProtocolFeeOffset   = orcaFeeRateOffset + 2
-	orcaLiquidityOffset     = orcaProtocolFeeOffset + 2
-	orcaSqrtPriceOffset     = orcaLiquidityOffset + 16
-	orcaTickOffset          = orcaSqrtPriceOffset + 16
-	orcaProtocolFeeAOOffset = orcaTickOffset + 4
-	orcaProtocolFeeBOOffset = orcaProtocolFeeAOOffset + 8
-	orcaTokenMintAOffset    = orcaProtocolFeeBOOffset + 8
-	orcaTokenVaultAOffset   = orcaTokenMintAOffset + 32
-	orcaFeeGrowthAOffset    = orcaTokenVaultAOffset + 32
-	orcaTokenMintBOffset    = orcaFeeGrowthAOffset + 16
-	orcaTokenVaultBOffset   = orcaTokenMintBOffset + 32
-	orcaRequiredLength      = orcaTokenVaultBOffset + 32
+var (
+	whirlpoolLayout = anchor.MustLoad("whirlpool").MustAccount("Whirlpool")
+	// orcaRequiredLength covers every field DecodeOrcaPool reads.
+	orcaRequiredLength = mustEnd(whirlpoolLayout, "token_vault_b")
 )
 
 // OrcaPoolInfo captures metadata required for swap decoding.
@@ -41,14 +28,21 @@ func DecodeOrcaPool(data []byte) (*OrcaPoolInfo, error) {
 	if len(data) < orcaRequiredLength {
 		return nil, fmt.Errorf("orca pool account too short: have %d want >= %d", len(data), orcaRequiredLength)
 	}
-	info := &OrcaPoolInfo{
-		Config:      base58.Encode(data[orcaConfigOffset : orcaConfigOffset+32]),
-		FeeRate:     binary.LittleEndian.Uint16(data[orcaFeeRateOffset : orcaFeeRateOffset+2]),
-		ProtocolFee: binary.LittleEndian.Uint16(data[orcaProtocolFeeOffset : orcaProtocolFeeOffset+2]),
-		TokenMintA:  base58.Encode(data[orcaTokenMintAOffset : orcaTokenMintAOffset+32]),
-		TokenVaultA: base58.Encode(data[orcaTokenVaultAOffset : orcaTokenVaultAOffset+32]),
-		TokenMintB:  base58.Encode(data[orcaTokenMintBOffset : orcaTokenMintBOffset+32]),
-		TokenVaultB: base58.Encode(data[orcaTokenVaultBOffset : orcaTokenVaultBOffset+32]),
+	pool, err := whirlpoolLayout.View(data)
+	if err != nil {
+		return nil, err
+	}
+	info := &OrcaPoolInfo{}
+	var errs fieldErrors
+	info.Config = errs.pubkey(pool, "whirlpools_config")
+	info.FeeRate = errs.u16(pool, "fee_rate")
+	info.ProtocolFee = errs.u16(pool, "protocol_fee_rate")
+	info.TokenMintA = errs.pubkey(pool, "token_mint_a")
+	info.TokenVaultA = errs.pubkey(pool, "token_vault_a")
+	info.TokenMintB = errs.pubkey(pool, "token_mint_b")
+	info.TokenVaultB = errs.pubkey(pool, "token_vault_b")
+	if errs.err != nil {
+		return nil, fmt.Errorf("decode orca pool: %w", errs.err)
 	}
 	return info, nil
 }
@@ -0,0 +1,140 @@
+package pools
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/mr-tron/base58/base58"
+
+	"github.com/rexbrahh/lp-indexer/decoder/anchor"
+)
+
+// The expected offsets are the published on-chain layouts; a mismatch means the
+// embedded IDL drifted from the program the decoders were written against.
+func TestIDLOffsets(t *testing.T) {
+	cases := []struct {
+		layout *anchor.Layout
+		field  string
+		want   int
+	}{
+		{whirlpoolLayout, "whirlpools_config", 8},
+		{whirlpoolLayout, "fee_rate", 45},
+		{whirlpoolLayout, "protocol_fee_rate", 47},
+		{whirlpoolLayout, "liquidity", 49},
+		{whirlpoolLayout, "sqrt_price", 65},
+		{whirlpoolLayout, "tick_current_index", 81},
+		{whirlpoolLayout, "token_mint_a", 101},
+		{whirlpoolLayout, "token_vault_a", 133},
+		{whirlpoolLayout, "token_mint_b", 181},
+		{whirlpoolLayout, "token_vault_b", 213},
+		{poolStateLayout, "amm_config", 9},
+		{poolStateLayout, "token_mint_0", 73},
+		{poolStateLayout, "sqrt_price_x64", 253},
+		{poolStateLayout, "tick_current", 269},
+		{ammConfigLayout, "protocol_fee_rate", 43},
+		{ammConfigLayout, "trade_fee_rate", 47},
+		{ammConfigLayout, "fund_fee_rate", 53},
+	}
+	for _, tc := range cases {
+		got, ok := tc.layout.Offset(tc.field)
+		if !ok || got != tc.want {
+			t.Errorf("%s.%s offset = %d (ok=%t), want %d", tc.layout.Name, tc.field, got, ok, tc.want)
+		}
+	}
+}
+
+func TestDecodeOrcaPoolAtIDLOffsets(t *testing.T) {
+	config, mintA, vaultA, mintB, vaultB := key(1), key(2), key(3), key(4), key(5)
+
+	data := make([]byte, orcaRequiredLength)
+	putAt(t, whirlpoolLayout, data, "whirlpools_config", config)
+	putAt(t, whirlpoolLayout, data, "fee_rate", binary.LittleEndian.AppendUint16(nil, 3000))
+	putAt(t, whirlpoolLayout, data, "protocol_fee_rate", binary.LittleEndian.AppendUint16(nil, 1300))
+	putAt(t, whirlpoolLayout, data, "token_mint_a", mintA)
+	putAt(t, whirlpoolLayout, data, "token_vault_a", vaultA)
+	putAt(t, whirlpoolLayout, data, "token_mint_b", mintB)
+	putAt(t, whirlpoolLayout, data, "token_vault_b", vaultB)
+
+	info, err := DecodeOrcaPool(data)
+	if err != nil {
+		t.Fatalf("DecodeOrcaPool: %v", err)
+	}
+	want := OrcaPoolInfo{
+		Config:      base58.Encode(config),
+		FeeRate:     3000,
+		ProtocolFee: 1300,
+		TokenMintA:  base58.Encode(mintA),
+		TokenVaultA: base58.Encode(vaultA),
+		TokenMintB:  base58.Encode(mintB),
+		TokenVaultB: base58.Encode(vaultB),
+	}
+	if *info != want {
+		t.Fatalf("DecodeOrcaPool = %+v, want %+v", *info, want)
+	}
+
+	if _, err := DecodeOrcaPool(data[:orcaRequiredLength-1]); err == nil {
+		t.Fatal("expected error for truncated whirlpool")
+	}
+}
+
+func TestDecodeRaydiumAccountsAtIDLOffsets(t *testing.T) {
+	configKey := key(7)
+	pool := make([]byte, 1544)
+	copy(pool, poolStateLayout.Discriminator[:])
+	putAt(t, poolStateLayout, pool, "amm_config", configKey)
+	if !HasPoolDiscriminator(pool) || HasAmmConfigDiscriminator(pool) {
+		t.Fatal("pool discriminator not recognised")
+	}
+	got, err := DecodeRaydiumPool(pool)
+	if err != nil || !bytes.Equal(got, configKey) {
+		t.Fatalf("DecodeRaydiumPool = %x, %v", got, err)
+	}
+	putAt(t, poolStateLayout, pool, "token_mint_0", key(8))
+	putAt(t, poolStateLayout, pool, "token_mint_1", key(9))
+	info, err := DecodeRaydiumPoolInfo(pool)
+	if err != nil {
+		t.Fatalf("DecodeRaydiumPoolInfo: %v", err)
+	}
+	if info.Config != base58.Encode(configKey) || info.TokenMint0 != base58.Encode(key(8)) || info.TokenMint1 != base58.Encode(key(9)) {
+		t.Fatalf("DecodeRaydiumPoolInfo = %+v", info)
+	}
+
+	config := make([]byte, 117)
+	copy(config, ammConfigLayout.Discriminator[:])
+	putAt(t, ammConfigLayout, config, "protocol_fee_rate", binary.LittleEndian.AppendUint32(nil, 120_000))
+	putAt(t, ammConfigLayout, config, "trade_fee_rate", binary.LittleEndian.AppendUint32(nil, 2_500))
+	putAt(t, ammConfigLayout, config, "fund_fee_rate", binary.LittleEndian.AppendUint32(nil, 40_000))
+	if !HasAmmConfigDiscriminator(config) {
+		t.Fatal("amm config discriminator not recognised")
+	}
+	cfg, err := DecodeAmmConfig(config)
+	if err != nil {
+		t.Fatalf("DecodeAmmConfig: %v", err)
+	}
+	if *cfg != (AmmConfig{TradeFeeRate: 2_500, ProtocolFeeRate: 120_000, FundFeeRate: 40_000}) {
+		t.Fatalf("DecodeAmmConfig = %+v", *cfg)
+	}
+	if cfg.FeeBps() != 25 {
+		t.Fatalf("FeeBps = %d", cfg.FeeBps())
+	}
+
+	// Accounts cut off before fund_fee_rate still yield the trade fee.
+	cfg, err = DecodeAmmConfig(config[:ammRequiredLength])
+	if err != nil || cfg.FundFeeRate != 0 || cfg.TradeFeeRate != 2_500 {
+		t.Fatalf("truncated DecodeAmmConfig = %+v, %v", cfg, err)
+	}
+}
+
+func putAt(t *testing.T, layout *anchor.Layout, data []byte, field string, value []byte) {
+	t.Helper()
+	offset, ok := layout.Offset(field)
+	if !ok {
+		t.Fatalf("%s.%s has no fixed offset", layout.Name, field)
+	}
+	copy(data[offset:], value)
+}
+
+func key(seed byte) []byte {
+	return bytes.Repeat([]byte{seed}, 32)
+}
@@ -1,43 +1,33 @@
 package pools
 
 import (
-	"encoding/binary"
 	"fmt"
+
+	"github.com/rexbrahh/lp-indexer/decoder/anchor"
 )
 
 const (
-	poolHeaderLen          = 8 // anchor discriminator
-	poolConfigOffset       = poolHeaderLen + 1
-	poolConfigEnd          = poolConfigOffset + 32
-	ammHeaderLen           = 8
-	ammTradeFeeOffset      = ammHeaderLen + 1 + 2 + 32 + 4
-	ammRequiredLength      = ammTradeFeeOffset + 4
-	ApproxConfigAccountMax = 256
+	// RaydiumFeeRateDenominator scales every AmmConfig rate (1e-6 units).
+	RaydiumFeeRateDenominator = 1_000_000
+	ApproxConfigAccountMax    = 256
 )
 
 var (
-	ammConfigDiscriminator = [8]byte{218, 244, 33, 104, 203, 203, 43, 111}
-	poolStateDiscriminator = [8]byte{247, 237, 227, 245, 215, 195, 222, 70}
+	raydiumIDL      = anchor.MustLoad("raydium_clmm")
+	poolStateLayout = raydiumIDL.MustAccount("PoolState")
+	ammConfigLayout = raydiumIDL.MustAccount("AmmConfig")
+
+	poolConfigEnd     = mustEnd(poolStateLayout, "amm_config")
+	poolMintsEnd      = mustEnd(poolStateLayout, "token_mint_1")
+	ammRequiredLength = mustEnd(ammConfigLayout, "trade_fee_rate")
 )
 
 func HasAmmConfigDiscriminator(data []byte) bool {
-	return len(data) >= 8 && equalDiscriminator(data[:8], ammConfigDiscriminator[:])
+	return ammConfigLayout.Matches(data)
 }
 
 func HasPoolDiscriminator(data []byte) bool {
-	return len(data) >= 8 && equalDiscriminator(data[:8], poolStateDiscriminator[:])
-}
-
-func equalDiscriminator(a, b []byte) bool {
-	if len(a) != len(b) {
-		return false
-	}
-	for i := range a {
-		if a[i] != b[i] {
-			return false
-		}
-	}
-	return true
+	return poolStateLayout.Matches(data)
 }
 
 // DecodeRaydiumPool extracts the amm_config pubkey from a Raydium CLMM pool account.
@@ -45,14 +35,73 @@ func DecodeRaydiumPool(data []byte) ([]byte, error) {
 	if len(data) < poolConfigEnd {
 		return nil, fmt.Errorf("raydium pool account too short: have %d want >= %d", len(data), poolConfigEnd)
 	}
-	return data[poolConfigOffset:poolConfigEnd], nil
+	pool, err := poolStateLayout.View(data)
+	if err != nil {
+		return nil, err
+	}
+	return pool.PubkeyBytes("amm_config")
+}
+
+// AmmConfig holds the Raydium CLMM fee rates expressed in the on-chain
+// denominator (1e-6 units). Protocol and fund rates are fractions of the trade fee.
+type AmmConfig struct {
+	TradeFeeRate    uint32
+	ProtocolFeeRate uint32
+	FundFeeRate     uint32
 }
 
-// DecodeAmmConfig parses the Raydium `AmmConfig` account and returns the trade fee rate
-// expressed in the on-chain denominator (1e-6 units).
-func DecodeAmmConfig(data []byte) (uint32, error) {
+// DecodeAmmConfig parses the Raydium `AmmConfig` account fee rates. The fund
+// fee rate is left at zero when the account is truncated before it.
+func DecodeAmmConfig(data []byte) (*AmmConfig, error) {
 	if len(data) < ammRequiredLength {
-		return 0, fmt.Errorf("amm config account too short: have %d want >= %d", len(data), ammRequiredLength)
+		return nil, fmt.Errorf("amm config account too short: have %d want >= %d", len(data), ammRequiredLength)
+	}
+	config, err := ammConfigLayout.View(data)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &AmmConfig{}
+	var errs fieldErrors
+	cfg.TradeFeeRate = errs.u32(config, "trade_fee_rate")
+	cfg.ProtocolFeeRate = errs.u32(config, "protocol_fee_rate")
+	if errs.err != nil {
+		return nil, fmt.Errorf("decode amm config: %w", errs.err)
+	}
+	if rate, err := config.U32("fund_fee_rate"); err == nil {
+		cfg.FundFeeRate = rate
+	}
+	return cfg, nil
+}
+
+// FeeBps returns the trade fee rate in basis points.
+func (c *AmmConfig) FeeBps() uint16 {
+	return uint16(c.TradeFeeRate / 100)
+}
+
+// RaydiumPoolInfo identifies a Raydium CLMM pool's config and mints.
+type RaydiumPoolInfo struct {
+	Config     string
+	TokenMint0 string
+	TokenMint1 string
+}
+
+// DecodeRaydiumPoolInfo extracts the amm_config and token mints from a
+// Raydium CLMM pool account.
+func DecodeRaydiumPoolInfo(data []byte) (*RaydiumPoolInfo, error) {
+	if len(data) < poolMintsEnd {
+		return nil, fmt.Errorf("raydium pool account too short: have %d want >= %d", len(data), poolMintsEnd)
+	}
+	pool, err := poolStateLayout.View(data)
+	if err != nil {
+		return nil, err
+	}
+	info := &RaydiumPoolInfo{}
+	var errs fieldErrors
+	info.Config = errs.pubkey(pool, "amm_config")
+	info.TokenMint0 = errs.pubkey(pool, "token_mint_0")
+	info.TokenMint1 = errs.pubkey(pool, "token_mint_1")
+	if errs.err != nil {
+		return nil, fmt.Errorf("decode raydium pool: %w", errs.err)
 	}
-	return binary.LittleEndian.Uint32(data[ammTradeFeeOffset : ammTradeFeeOffset+4]), nil
+	return info, nil
 }
@@ -0,0 +1,186 @@
+package mev
+
+import (
+	"sort"
+
+	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+	swapdecoder "github.com/rexbrahh/lp-indexer/ingestor/decoder"
+)
+
+// MEV event kinds.
+const (
+	KindSandwich  = "sandwich"
+	KindBackrun   = "backrun"
+	KindAtomicArb = "atomic_arb"
+)
+
+const chainIDSolana = 501
+
+// Analyze inspects the swaps of a single slot and returns the MEV patterns it
+// finds:
+//
+//   - sandwich: the same wallet trades a pool, one or more other wallets trade
+//     it in the same direction, then the wallet reverses its trade. Victim
+//     swaps are flagged with MevVictim.
+//   - backrun: an atomic arbitrage whose leg on a pool reverses the swap that
+//     immediately preceded it there.
+//   - atomic_arb: a transaction whose legs start and end in the same mint.
+//
+// The attacker's transaction is reported in back_sig for back-runs and
+// arbitrage. Undo events are ignored.
+func Analyze(swaps []*dexv1.SwapEvent) []*dexv1.MevEvent {
+	ordered := make([]*dexv1.SwapEvent, 0, len(swaps))
+	for _, ev := range swaps {
+		if ev != nil && !ev.GetIsUndo() {
+			ordered = append(ordered, ev)
+		}
+	}
+	if len(ordered) < 2 {
+		return nil
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].GetIndex() < ordered[j].GetIndex()
+	})
+
+	slot := ordered[0].GetSlot()
+	events := detectSandwiches(slot, ordered)
+	return append(events, detectArbitrage(slot, ordered)...)
+}
+
+func detectSandwiches(slot uint64, ordered []*dexv1.SwapEvent) []*dexv1.MevEvent {
+	byPool := make(map[string][]*dexv1.SwapEvent)
+	var pools []string
+	for _, ev := range ordered {
+		pool := ev.GetPoolId()
+		if _, ok := byPool[pool]; !ok {
+			pools = append(pools, pool)
+		}
+		byPool[pool] = append(byPool[pool], ev)
+	}
+
+	var events []*dexv1.MevEvent
+	for _, pool := range pools {
+		poolSwaps := byPool[pool]
+		used := make([]bool, len(poolSwaps))
+		for i, front := range poolSwaps {
+			if used[i] || front.GetTrader() == "" {
+				continue
+			}
+			frontIn, frontAmount, frontOut, _ := swapdecoder.LegFlows(front)
+
+			var victims []*dexv1.SwapEvent
+			for j := i + 1; j < len(poolSwaps); j++ {
+				next := poolSwaps[j]
+				if next.GetSig() == front.GetSig() {
+					continue
+				}
+				nextIn, _, nextOut, backAmount := swapdecoder.LegFlows(next)
+				if next.GetTrader() != front.GetTrader() {
+					if nextIn == frontIn {
+						victims = append(victims, next)
+					}
+					continue
+				}
+				if nextIn != frontOut || nextOut != frontIn {
+					// The attacker added to the position; keep scanning for the exit.
+					continue
+				}
+				if len(victims) > 0 {
+					used[i], used[j] = true, true
+					events = append(events, sandwichEvent(slot, front, next, victims, frontIn, int64(backAmount)-int64(frontAmount)))
+				}
+				break
+			}
+		}
+	}
+	return events
+}
+
+func sandwichEvent(slot uint64, front, back *dexv1.SwapEvent, victims []*dexv1.SwapEvent, profitMint string, profit int64) *dexv1.MevEvent {
+	ev := &dexv1.MevEvent{
+		ChainId:      chainIDSolana,
+		Slot:         slot,
+		Kind:         KindSandwich,
+		Attacker:     front.GetTrader(),
+		PoolId:       front.GetPoolId(),
+		FrontSig:     front.GetSig(),
+		FrontIndex:   front.GetIndex(),
+		BackSig:      back.GetSig(),
+		BackIndex:    back.GetIndex(),
+		ProfitMint:   profitMint,
+		ProfitAmount: profit,
+	}
+	for _, victim := range victims {
+		victim.MevVictim = true
+		ev.VictimSigs = append(ev.VictimSigs, victim.GetSig())
+		ev.VictimTraders = append(ev.VictimTraders, victim.GetTrader())
+	}
+	return ev
+}
+
+func detectArbitrage(slot uint64, ordered []*dexv1.SwapEvent) []*dexv1.MevEvent {
+	var sigs []string
+	legsBySig := make(map[string][]*dexv1.SwapEvent)
+	for _, ev := range ordered {
+		if _, ok := legsBySig[ev.GetSig()]; !ok {
+			sigs = append(sigs, ev.GetSig())
+		}
+		legsBySig[ev.GetSig()] = append(legsBySig[ev.GetSig()], ev)
+	}
+
+	var events []*dexv1.MevEvent
+	for _, sig := range sigs {
+		legs := legsBySig[sig]
+		route := swapdecoder.BuildRoute(nil, legs)
+		if route == nil || !route.GetCircular() {
+			continue
+		}
+
+		ev := &dexv1.MevEvent{
+			ChainId:      chainIDSolana,
+			Slot:         slot,
+			Kind:         KindAtomicArb,
+			Attacker:     route.GetTrader(),
+			BackSig:      sig,
+			BackIndex:    route.GetIndex(),
+			ProfitMint:   route.GetInputMint(),
+			ProfitAmount: int64(route.GetOutputAmount()) - int64(route.GetInputAmount()),
+		}
+		if trigger, leg := backrunTrigger(ordered, legs); trigger != nil {
+			ev.Kind = KindBackrun
+			ev.PoolId = leg.GetPoolId()
+			ev.VictimSigs = []string{trigger.GetSig()}
+			ev.VictimTraders = []string{trigger.GetTrader()}
+		}
+		events = append(events, ev)
+	}
+	return events
+}
+
+// backrunTrigger returns the swap an arbitrage reacted to: the last swap from
+// another transaction on one of the arbitrage pools, traded in the direction
+// the arbitrage leg reverses.
+func backrunTrigger(ordered, legs []*dexv1.SwapEvent) (*dexv1.SwapEvent, *dexv1.SwapEvent) {
+	arbSig := legs[0].GetSig()
+	arbIndex := legs[0].GetIndex()
+	for _, leg := range legs {
+		var prev *dexv1.SwapEvent
+		for _, ev := range ordered {
+			if ev.GetIndex() >= arbIndex {
+				break
+			}
+			if ev.GetPoolId() == leg.GetPoolId() && ev.GetSig() != arbSig {
+				prev = ev
+			}
+		}
+		if prev == nil || prev.GetTrader() == legs[0].GetTrader() {
+			continue
+		}
+		prevIn, _, prevOut, _ := swapdecoder.LegFlows(prev)
+		legIn, _, legOut, _ := swapdecoder.LegFlows(leg)
+		if legIn == prevOut && legOut == prevIn {
+			return prev, leg
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,92 @@
+package mev
+
+import (
+	"testing"
+
+	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+)
+
+// buy swaps quote for base on pool; sell swaps base for quote.
+func buy(sig string, index uint32, trader, pool string, quoteIn, baseOut uint64) *dexv1.SwapEvent {
+	return &dexv1.SwapEvent{Slot: 10, Sig: sig, Index: index, Trader: trader, PoolId: pool,
+		MintBase: "SOL", MintQuote: "USDC", QuoteIn: quoteIn, BaseOut: baseOut}
+}
+
+func sell(sig string, index uint32, trader, pool string, baseIn, quoteOut uint64) *dexv1.SwapEvent {
+	return &dexv1.SwapEvent{Slot: 10, Sig: sig, Index: index, Trader: trader, PoolId: pool,
+		MintBase: "SOL", MintQuote: "USDC", BaseIn: baseIn, QuoteOut: quoteOut}
+}
+
+func TestAnalyzeSandwich(t *testing.T) {
+	front := buy("front", 1, "bot", "pool", 1_000, 10)
+	victim := buy("victim", 2, "user", "pool", 5_000, 45)
+	other := buy("other", 3, "user2", "pool-b", 100, 1)
+	back := sell("back", 4, "bot", "pool", 10, 1_080)
+
+	// Deliberately out of order: the analyzer sorts by transaction index.
+	events := Analyze([]*dexv1.SwapEvent{back, other, victim, front})
+	if len(events) != 1 {
+		t.Fatalf("expected 1 mev event, got %d: %+v", len(events), events)
+	}
+	ev := events[0]
+	if ev.Kind != KindSandwich || ev.Attacker != "bot" || ev.PoolId != "pool" {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+	if ev.FrontSig != "front" || ev.BackSig != "back" {
+		t.Fatalf("unexpected legs front=%s back=%s", ev.FrontSig, ev.BackSig)
+	}
+	if len(ev.VictimSigs) != 1 || ev.VictimSigs[0] != "victim" || ev.VictimTraders[0] != "user" {
+		t.Fatalf("unexpected victims %v/%v", ev.VictimSigs, ev.VictimTraders)
+	}
+	if ev.ProfitMint != "USDC" || ev.ProfitAmount != 80 {
+		t.Fatalf("unexpected profit %s/%d", ev.ProfitMint, ev.ProfitAmount)
+	}
+	if !victim.MevVictim || front.MevVictim || other.MevVictim {
+		t.Fatal("expected only the victim swap to be flagged")
+	}
+}
+
+func TestAnalyzeIgnoresRoundTripWithoutVictim(t *testing.T) {
+	events := Analyze([]*dexv1.SwapEvent{
+		buy("a", 1, "bot", "pool", 1_000, 10),
+		sell("b", 2, "user", "pool", 5, 480),
+		sell("c", 3, "bot", "pool", 10, 990),
+	})
+	if len(events) != 0 {
+		t.Fatalf("expected no events, got %+v", events)
+	}
+}
+
+func TestAnalyzeBackrunAndAtomicArb(t *testing.T) {
+	trigger := buy("whale", 1, "whale", "pool-a", 50_000, 480)
+	// The arbitrage sells SOL back into pool-a and rebuys it on pool-b.
+	arbA := sell("arb", 2, "searcher", "pool-a", 10, 1_060)
+	arbB := buy("arb", 2, "searcher", "pool-b", 1_000, 11)
+	// A standalone arb on unrelated pools.
+	loopA := sell("loop", 3, "searcher2", "pool-c", 5, 500)
+	loopB := buy("loop", 3, "searcher2", "pool-d", 500, 6)
+
+	events := Analyze([]*dexv1.SwapEvent{trigger, arbA, arbB, loopA, loopB})
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d: %+v", len(events), events)
+	}
+
+	backrun := events[0]
+	if backrun.Kind != KindBackrun || backrun.BackSig != "arb" || backrun.PoolId != "pool-a" {
+		t.Fatalf("unexpected backrun %+v", backrun)
+	}
+	if len(backrun.VictimSigs) != 1 || backrun.VictimSigs[0] != "whale" {
+		t.Fatalf("unexpected backrun trigger %v", backrun.VictimSigs)
+	}
+	if backrun.ProfitMint != "SOL" || backrun.ProfitAmount != 1 {
+		t.Fatalf("unexpected backrun profit %s/%d", backrun.ProfitMint, backrun.ProfitAmount)
+	}
+	if trigger.MevVictim {
+		t.Fatal("back-run triggers are not flagged as victims")
+	}
+
+	arb := events[1]
+	if arb.Kind != KindAtomicArb || arb.Attacker != "searcher2" || arb.ProfitAmount != 1 {
+		t.Fatalf("unexpected arb %+v", arb)
+	}
+}
@@ -0,0 +1,83 @@
+package mintmeta
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRequestTimeout = 10 * time.Second
+	defaultFlushInterval  = 30 * time.Second
+	defaultLoadInterval   = time.Second
+	envMintMetadataPath   = "MINT_METADATA_PATH"
+	envMintMetadataRPC    = "MINT_METADATA_RPC_URL"
+	envMintMetadataFlush  = "MINT_METADATA_FLUSH_MS"
+)
+
+// Config captures the parameters of the on-chain mint metadata provider.
+type Config struct {
+	// Path is the local JSON snapshot the provider loads on start and
+	// flushes to. Empty disables persistence.
+	Path string
+	// RPCEndpoint is the JSON-RPC URL used to load unknown mints with
+	// getMultipleAccounts. Empty limits the provider to the account stream
+	// and the snapshot.
+	RPCEndpoint string
+	// RequestTimeout bounds each RPC call.
+	RequestTimeout time.Duration
+	// FlushInterval is how often a changed cache is written to Path.
+	FlushInterval time.Duration
+	// LoadInterval is how often mints requested via Want are fetched.
+	LoadInterval time.Duration
+}
+
+// DefaultConfig returns a Config with defaults applied. Path and endpoint stay
+// empty because they are deployment-specific.
+func DefaultConfig() *Config {
+	return &Config{
+		RequestTimeout: defaultRequestTimeout,
+		FlushInterval:  defaultFlushInterval,
+		LoadInterval:   defaultLoadInterval,
+	}
+}
+
+// Validate ensures intervals are positive.
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("config is nil")
+	}
+	if c.RequestTimeout <= 0 {
+		return fmt.Errorf("invalid RequestTimeout: %s", c.RequestTimeout)
+	}
+	if c.FlushInterval <= 0 {
+		return fmt.Errorf("invalid FlushInterval: %s", c.FlushInterval)
+	}
+	if c.LoadInterval <= 0 {
+		return fmt.Errorf("invalid LoadInterval: %s", c.LoadInterval)
+	}
+	return nil
+}
+
+// FromEnv builds a Config from the environment. It returns nil when neither a
+// snapshot path nor an RPC endpoint is configured.
+func FromEnv() (*Config, error) {
+	path := os.Getenv(envMintMetadataPath)
+	endpoint := os.Getenv(envMintMetadataRPC)
+	if path == "" && endpoint == "" {
+		return nil, nil
+	}
+	cfg := DefaultConfig()
+	cfg.Path = path
+	cfg.RPCEndpoint = endpoint
+	if v := os.Getenv(envMintMetadataFlush); v != "" {
+		ms, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", envMintMetadataFlush, err)
+		}
+		cfg.FlushInterval = time.Duration(ms) * time.Millisecond
+	}
+	return cfg, cfg.Validate()
+}
@@ -0,0 +1,328 @@
+package mintmeta
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mr-tron/base58/base58"
+
+	dexcommon "github.com/rexbrahh/lp-indexer/decoder/common"
+	"github.com/rexbrahh/lp-indexer/ingestor/solrpc"
+
+	pb "github.com/rpcpool/yellowstone-grpc/examples/golang/proto"
+)
+
+const (
+	loadCommitment = "confirmed"
+	// Each mint is fetched together with its metadata PDA, so a batch of 50
+	// mints fills one getMultipleAccounts request.
+	mintsPerRequest = solrpc.MaxMultipleAccounts / 2
+)
+
+var _ dexcommon.MintMetadataProvider = (*Provider)(nil)
+
+// Provider serves mint metadata decoded from SPL Token and Token-2022 mint
+// accounts and Metaplex metadata accounts. Entries arrive from the account
+// stream (HandleAccount) or are loaded over JSON-RPC (Load, Want), and are
+// persisted to a local snapshot. It is safe for concurrent use.
+type Provider struct {
+	cfg *Config
+	rpc *solrpc.Client
+
+	mu      sync.RWMutex
+	entries map[string]*dexcommon.MintMetadata
+	wanted  map[string]struct{}
+	dirty   bool
+}
+
+// NewProvider builds a provider and restores the snapshot at cfg.Path when it
+// exists.
+func NewProvider(cfg *Config) (*Provider, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	p := &Provider{
+		cfg:     cfg,
+		entries: make(map[string]*dexcommon.MintMetadata),
+		wanted:  make(map[string]struct{}),
+	}
+	if cfg.RPCEndpoint != "" {
+		p.rpc = solrpc.NewClient(cfg.RPCEndpoint, cfg.RequestTimeout)
+	}
+	if cfg.Path != "" {
+		entries, err := readSnapshot(cfg.Path)
+		if err != nil {
+			return nil, err
+		}
+		for _, md := range entries {
+			p.entries[md.Address] = md
+		}
+	}
+	return p, nil
+}
+
+// GetMintMetadata returns the metadata of a mint whose account has been
+// decoded or that appears in the loaded token list. The curated token list
+// wins for symbol and name; on-chain data wins for decimals. Mints only known
+// through their Metaplex metadata are reported missing because their decimals
+// are unknown.
+func (p *Provider) GetMintMetadata(mintAddress string) (*dexcommon.MintMetadata, error) {
+	p.mu.RLock()
+	md, ok := p.entries[mintAddress]
+	var out dexcommon.MintMetadata
+	if ok {
+		out = *md
+	}
+	p.mu.RUnlock()
+
+	info, listed := dexcommon.LookupToken(mintAddress)
+	if !listed && (!ok || out.TokenProgram == "") {
+		return nil, fmt.Errorf("mint metadata not found for address: %s", mintAddress)
+	}
+	out.Address = mintAddress
+	if listed {
+		if out.TokenProgram == "" {
+			out.Decimals = info.Decimals
+		}
+		if info.Symbol != "" {
+			out.Symbol = info.Symbol
+		}
+		if info.Name != "" {
+			out.Name = info.Name
+		}
+	}
+	return &out, nil
+}
+
+// GetDecimals returns just the decimal places for a mint.
+func (p *Provider) GetDecimals(mintAddress string) (uint8, error) {
+	md, err := p.GetMintMetadata(mintAddress)
+	if err != nil {
+		return 0, err
+	}
+	return md.Decimals, nil
+}
+
+// CacheMintMetadata loads the given mints over JSON-RPC, skipping those
+// already known.
+func (p *Provider) CacheMintMetadata(mintAddresses []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 4*p.cfg.RequestTimeout)
+	defer cancel()
+	return p.Load(ctx, p.missing(mintAddresses))
+}
+
+// Want queues mints for the background loader started by Run. Known mints
+// are ignored.
+func (p *Provider) Want(mintAddresses ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, mint := range mintAddresses {
+		if md, ok := p.entries[mint]; ok && md.TokenProgram != "" {
+			continue
+		}
+		p.wanted[mint] = struct{}{}
+	}
+}
+
+// HandleAccount applies a streamed account update. Mint accounts owned by the
+// token programs and Metaplex metadata accounts are decoded; everything else
+// is ignored.
+func (p *Provider) HandleAccount(update *pb.SubscribeUpdateAccount) {
+	info := update.GetAccount()
+	if info == nil {
+		return
+	}
+	p.apply(base58.Encode(info.GetPubkey()), base58.Encode(info.GetOwner()), info.GetData(), update.GetSlot())
+}
+
+// Load fetches mints and their Metaplex metadata accounts with
+// getMultipleAccounts.
+func (p *Provider) Load(ctx context.Context, mints []string) error {
+	if len(mints) == 0 {
+		return nil
+	}
+	if p.rpc == nil {
+		return fmt.Errorf("mint metadata: no RPC endpoint configured to load %d mints", len(mints))
+	}
+	for start := 0; start < len(mints); start += mintsPerRequest {
+		end := min(start+mintsPerRequest, len(mints))
+		if err := p.loadBatch(ctx, mints[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Provider) loadBatch(ctx context.Context, mints []string) error {
+	keys := make([]string, 0, 2*len(mints))
+	for _, mint := range mints {
+		pda, err := dexcommon.MetaplexMetadataAddress(mint)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, mint, pda)
+	}
+	accounts, slot, err := p.rpc.GetMultipleAccounts(ctx, keys, loadCommitment)
+	if err != nil {
+		return fmt.Errorf("load mint metadata: %w", err)
+	}
+	for i, account := range accounts {
+		if account != nil {
+			p.apply(keys[i], account.Owner, account.Data, slot)
+		}
+	}
+	return nil
+}
+
+// Run loads wanted mints every LoadInterval and flushes the snapshot every
+// FlushInterval until ctx is cancelled, then flushes once more.
+func (p *Provider) Run(ctx context.Context) {
+	load := time.NewTicker(p.cfg.LoadInterval)
+	defer load.Stop()
+	flush := time.NewTicker(p.cfg.FlushInterval)
+	defer flush.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := p.Flush(); err != nil {
+				log.Printf("mint metadata flush: %v", err)
+			}
+			return
+		case <-load.C:
+			if p.rpc == nil {
+				continue
+			}
+			mints := p.takeWanted()
+			if err := p.Load(ctx, mints); err != nil {
+				log.Printf("mint metadata load: %v", err)
+				p.Want(mints...)
+			}
+		case <-flush.C:
+			if err := p.Flush(); err != nil {
+				log.Printf("mint metadata flush: %v", err)
+			}
+		}
+	}
+}
+
+// Flush writes the snapshot when entries changed since the last flush.
+func (p *Provider) Flush() error {
+	if p.cfg.Path == "" {
+		return nil
+	}
+	p.mu.Lock()
+	if !p.dirty {
+		p.mu.Unlock()
+		return nil
+	}
+	entries := make([]*dexcommon.MintMetadata, 0, len(p.entries))
+	for _, md := range p.entries {
+		clone := *md
+		entries = append(entries, &clone)
+	}
+	p.dirty = false
+	p.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Address < entries[j].Address })
+	if err := writeSnapshot(p.cfg.Path, entries); err != nil {
+		p.mu.Lock()
+		p.dirty = true
+		p.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (p *Provider) apply(address, owner string, data []byte, slot uint64) {
+	switch owner {
+	case dexcommon.TokenProgramID, dexcommon.Token2022ProgramID:
+		if !isMintAccount(data) {
+			return
+		}
+		mint, err := dexcommon.DecodeTokenMint(data)
+		if err != nil || !mint.IsInitialized {
+			return
+		}
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		md := p.entry(address)
+		if md.TokenProgram != "" && slot < md.Slot {
+			return
+		}
+		md.Decimals = mint.Decimals
+		md.Supply = mint.Supply
+		md.MintAuthority = mint.MintAuthority
+		md.FreezeAuthority = mint.FreezeAuthority
+		md.TokenProgram = owner
+		md.Slot = slot
+		if mint.Metadata != nil && md.Symbol == "" {
+			setDescriptive(md, mint.Metadata)
+		}
+		delete(p.wanted, address)
+		p.dirty = true
+	case dexcommon.MetaplexMetadataProgramID:
+		meta, err := dexcommon.DecodeMetaplexMetadata(data)
+		if err != nil {
+			return
+		}
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		setDescriptive(p.entry(meta.Mint), meta)
+		p.dirty = true
+	}
+}
+
+// entry returns the cache entry for address, creating it. Callers hold mu.
+func (p *Provider) entry(address string) *dexcommon.MintMetadata {
+	md, ok := p.entries[address]
+	if !ok {
+		md = &dexcommon.MintMetadata{Address: address}
+		p.entries[address] = md
+	}
+	return md
+}
+
+func (p *Provider) missing(mints []string) []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	var out []string
+	for _, mint := range mints {
+		if md, ok := p.entries[mint]; !ok || md.TokenProgram == "" {
+			out = append(out, mint)
+		}
+	}
+	return out
+}
+
+func (p *Provider) takeWanted() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	mints := make([]string, 0, len(p.wanted))
+	for mint := range p.wanted {
+		mints = append(mints, mint)
+	}
+	clear(p.wanted)
+	sort.Strings(mints)
+	return mints
+}
+
+func setDescriptive(md *dexcommon.MintMetadata, meta *dexcommon.TokenMetadata) {
+	md.Name = meta.Name
+	md.Symbol = meta.Symbol
+	md.URI = meta.URI
+}
+
+// isMintAccount distinguishes mints from token accounts, which share owners:
+// classic mints are exactly MintBaseLen bytes, Token-2022 mints with
+// extensions carry the mint account-type byte.
+func isMintAccount(data []byte) bool {
+	if len(data) == dexcommon.MintBaseLen {
+		return true
+	}
+	return len(data) > dexcommon.MintAccountTypeOffset && data[dexcommon.MintAccountTypeOffset] == 1
+}
@@ -0,0 +1,222 @@
+package mintmeta
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/mr-tron/base58/base58"
+
+	dexcommon "github.com/rexbrahh/lp-indexer/decoder/common"
+
+	pb "github.com/rpcpool/yellowstone-grpc/examples/golang/proto"
+)
+
+const (
+	usdcMint = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+	// An arbitrary mint that is not in the well-known token table.
+	bonkMint = "DezXAZ8z7PnrnRJjz3wXBoRgixCa6xjnB7YaB1pPB263"
+)
+
+func TestProviderDecodesStreamedAccounts(t *testing.T) {
+	p, err := NewProvider(DefaultConfig())
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+
+	p.HandleAccount(accountUpdate(t, bonkMint, dexcommon.TokenProgramID, mintData(5, 1_000_000), 10))
+	// Token accounts share the owner and must be ignored.
+	p.HandleAccount(accountUpdate(t, usdcMint, dexcommon.TokenProgramID, make([]byte, dexcommon.TokenAccountLen), 10))
+
+	if _, err := p.GetMintMetadata(usdcMint); err == nil {
+		t.Fatal("token account must not be decoded as a mint")
+	}
+	md, err := p.GetMintMetadata(bonkMint)
+	if err != nil {
+		t.Fatalf("GetMintMetadata: %v", err)
+	}
+	if md.Decimals != 5 || md.Supply != 1_000_000 || md.TokenProgram != dexcommon.TokenProgramID {
+		t.Fatalf("unexpected mint metadata %+v", md)
+	}
+
+	pda, err := dexcommon.MetaplexMetadataAddress(bonkMint)
+	if err != nil {
+		t.Fatalf("MetaplexMetadataAddress: %v", err)
+	}
+	p.HandleAccount(accountUpdate(t, pda, dexcommon.MetaplexMetadataProgramID, metaplexData(t, bonkMint, "Bonk", "BONK"), 11))
+	md, _ = p.GetMintMetadata(bonkMint)
+	if md.Symbol != "BONK" || md.Name != "Bonk" {
+		t.Fatalf("metaplex metadata not applied: %+v", md)
+	}
+
+	// Older account data does not overwrite newer data.
+	p.HandleAccount(accountUpdate(t, bonkMint, dexcommon.TokenProgramID, mintData(9, 1), 5))
+	if d, _ := p.GetDecimals(bonkMint); d != 5 {
+		t.Fatalf("stale update applied: decimals=%d", d)
+	}
+}
+
+func TestProviderLoadsOverRPCAndPersists(t *testing.T) {
+	usdcPDA, _ := dexcommon.MetaplexMetadataAddress(usdcMint)
+	accounts := map[string]any{
+		usdcMint: rpcAccount(dexcommon.TokenProgramID, mintData(6, 42)),
+		usdcPDA:  rpcAccount(dexcommon.MetaplexMetadataProgramID, metaplexData(t, usdcMint, "USD Coin", "USDC")),
+		bonkMint: rpcAccount(dexcommon.Token2022ProgramID, mintData(5, 7)),
+	}
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Method != "getMultipleAccounts" {
+			t.Errorf("unexpected request %s: %v", req.Method, err)
+			return
+		}
+		requests++
+		var keys []string
+		_ = json.Unmarshal(req.Params[0], &keys)
+		value := make([]any, len(keys))
+		for i, key := range keys {
+			value[i] = accounts[key]
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"result":  map[string]any{"context": map[string]any{"slot": 99}, "value": value},
+		})
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig()
+	cfg.RPCEndpoint = server.URL
+	cfg.Path = filepath.Join(t.TempDir(), "mints.json")
+	p, err := NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+	if err := p.CacheMintMetadata([]string{usdcMint, bonkMint}); err != nil {
+		t.Fatalf("CacheMintMetadata: %v", err)
+	}
+	if requests != 1 {
+		t.Fatalf("expected one batched request, got %d", requests)
+	}
+	if err := p.CacheMintMetadata([]string{usdcMint, bonkMint}); err != nil || requests != 1 {
+		t.Fatalf("known mints must not be refetched: requests=%d err=%v", requests, err)
+	}
+
+	base, quote, err := dexcommon.DetermineBaseQuote(usdcMint, bonkMint, p)
+	if err != nil {
+		t.Fatalf("DetermineBaseQuote: %v", err)
+	}
+	if base != bonkMint || quote != usdcMint {
+		t.Fatalf("base=%s quote=%s", base, quote)
+	}
+
+	if err := p.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	restored, err := NewProvider(&Config{
+		Path:           cfg.Path,
+		RequestTimeout: cfg.RequestTimeout,
+		FlushInterval:  cfg.FlushInterval,
+		LoadInterval:   cfg.LoadInterval,
+	})
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	md, err := restored.GetMintMetadata(usdcMint)
+	if err != nil {
+		t.Fatalf("restored GetMintMetadata: %v", err)
+	}
+	if md.Symbol != "USDC" || md.Decimals != 6 || md.Slot != 99 {
+		t.Fatalf("unexpected restored metadata %+v", md)
+	}
+	if d, _ := restored.GetDecimals(bonkMint); d != 5 {
+		t.Fatalf("restored decimals=%d", d)
+	}
+}
+
+func TestProviderWantSkipsKnownMints(t *testing.T) {
+	p, _ := NewProvider(DefaultConfig())
+	p.HandleAccount(accountUpdate(t, bonkMint, dexcommon.TokenProgramID, mintData(5, 1), 1))
+	p.Want(bonkMint, usdcMint, usdcMint)
+	if got := p.takeWanted(); len(got) != 1 || got[0] != usdcMint {
+		t.Fatalf("wanted=%v", got)
+	}
+}
+
+func mintData(decimals uint8, supply uint64) []byte {
+	data := make([]byte, dexcommon.MintBaseLen)
+	binary.LittleEndian.PutUint64(data[36:44], supply)
+	data[44] = decimals
+	data[45] = 1
+	return data
+}
+
+func metaplexData(t *testing.T, mint, name, symbol string) []byte {
+	t.Helper()
+	mintKey, err := base58.Decode(mint)
+	if err != nil {
+		t.Fatalf("decode mint: %v", err)
+	}
+	data := []byte{4}
+	data = append(data, make([]byte, 32)...)
+	data = append(data, mintKey...)
+	for _, s := range []string{name, symbol, "https://example.com"} {
+		data = binary.LittleEndian.AppendUint32(data, uint32(len(s)))
+		data = append(data, s...)
+	}
+	return data
+}
+
+func accountUpdate(t *testing.T, pubkey, owner string, data []byte, slot uint64) *pb.SubscribeUpdateAccount {
+	t.Helper()
+	key, err := base58.Decode(pubkey)
+	if err != nil {
+		t.Fatalf("decode pubkey: %v", err)
+	}
+	ownerKey, err := base58.Decode(owner)
+	if err != nil {
+		t.Fatalf("decode owner: %v", err)
+	}
+	return &pb.SubscribeUpdateAccount{
+		Slot:    slot,
+		Account: &pb.SubscribeUpdateAccountInfo{Pubkey: key, Owner: ownerKey, Data: data},
+	}
+}
+
+func rpcAccount(owner string, data []byte) map[string]any {
+	return map[string]any{
+		"lamports":   1,
+		"owner":      owner,
+		"data":       []string{base64.StdEncoding.EncodeToString(data), "base64"},
+		"executable": false,
+	}
+}
+
+func TestProviderConsultsTokenList(t *testing.T) {
+	dexcommon.ApplyTokenList([]dexcommon.TokenInfo{{Mint: bonkMint, Symbol: "BONK", Name: "Bonk", Decimals: 5}})
+	defer dexcommon.ApplyTokenList(nil)
+
+	p, _ := NewProvider(DefaultConfig())
+	md, err := p.GetMintMetadata(bonkMint)
+	if err != nil {
+		t.Fatalf("listed mint: %v", err)
+	}
+	if md.Symbol != "BONK" || md.Decimals != 5 || md.TokenProgram != "" {
+		t.Fatalf("unexpected list-only metadata %+v", md)
+	}
+
+	pda, _ := dexcommon.MetaplexMetadataAddress(bonkMint)
+	p.HandleAccount(accountUpdate(t, bonkMint, dexcommon.TokenProgramID, mintData(5, 10), 1))
+	p.HandleAccount(accountUpdate(t, pda, dexcommon.MetaplexMetadataProgramID, metaplexData(t, bonkMint, "Bonk Inu", "bonk"), 1))
+	md, _ = p.GetMintMetadata(bonkMint)
+	if md.Symbol != "BONK" || md.Name != "Bonk" || md.Supply != 10 {
+		t.Fatalf("token list should win for symbol and name: %+v", md)
+	}
+}
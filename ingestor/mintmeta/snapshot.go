@@ -0,0 +1,67 @@
+package mintmeta
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	dexcommon "github.com/rexbrahh/lp-indexer/decoder/common"
+)
+
+const snapshotVersion = 1
+
+type snapshot struct {
+	Version int                       `json:"version"`
+	Mints   []*dexcommon.MintMetadata `json:"mints"`
+}
+
+// readSnapshot loads the entries stored at path. A missing file yields no
+// entries.
+func readSnapshot(path string) ([]*dexcommon.MintMetadata, error) {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read mint metadata snapshot: %w", err)
+	}
+	var snap snapshot
+	if err := json.Unmarshal(raw, &snap); err != nil {
+		return nil, fmt.Errorf("decode mint metadata snapshot %s: %w", path, err)
+	}
+	if snap.Version != snapshotVersion {
+		return nil, fmt.Errorf("mint metadata snapshot %s: unsupported version %d", path, snap.Version)
+	}
+	return snap.Mints, nil
+}
+
+// writeSnapshot replaces the file at path atomically.
+func writeSnapshot(path string, entries []*dexcommon.MintMetadata) error {
+	raw, err := json.MarshalIndent(snapshot{Version: snapshotVersion, Mints: entries}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode mint metadata snapshot: %w", err)
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create snapshot dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create snapshot temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("replace snapshot: %w", err)
+	}
+	return nil
+}
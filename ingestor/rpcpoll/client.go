@@ -0,0 +1,233 @@
+// Package rpcpoll implements a last-resort ingest source that needs nothing
+// but a standard Solana JSON-RPC URL. It polls getSlot/getBlocks/getBlock and
+// converts blocks into the Yellowstone updates the geyser processor consumes.
+package rpcpoll
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/rexbrahh/lp-indexer/ingestor/solrpc"
+
+	pb "github.com/rpcpool/yellowstone-grpc/examples/golang/proto"
+)
+
+const (
+	commitmentConfirmed = "confirmed"
+	commitmentFinalized = "finalized"
+)
+
+// Client polls a JSON-RPC node and satisfies geyser.ClientInterface.
+type Client struct {
+	cfg      *Config
+	rpc      *solrpc.Client
+	programs map[string]struct{}
+
+	// cancel stops the current stream. Each Connect and Subscribe starts a
+	// new one, so the client can be reused after Close when failover rotates
+	// back to it.
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+// NewClient validates the configuration and prepares the polling client.
+func NewClient(cfg *Config) (*Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid rpc poll config: %w", err)
+	}
+	programs := make(map[string]struct{}, len(cfg.ProgramFilters))
+	for _, programID := range cfg.ProgramFilters {
+		programs[programID] = struct{}{}
+	}
+	return &Client{
+		cfg:      cfg,
+		rpc:      solrpc.NewClient(cfg.Endpoint, cfg.RequestTimeout),
+		programs: programs,
+	}, nil
+}
+
+// stream cancels the previous stream's context and returns a fresh one.
+func (c *Client) stream() context.Context {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancel != nil {
+		c.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	return ctx
+}
+
+// Connect checks that the node answers getSlot.
+func (c *Client) Connect() error {
+	if _, err := c.rpc.GetSlot(c.stream(), commitmentConfirmed); err != nil {
+		return fmt.Errorf("rpc poll connect: %w", err)
+	}
+	return nil
+}
+
+// Subscribe polls from startSlot (or the current confirmed tip when zero)
+// and streams block meta, filtered transactions and slot statuses. Both
+// channels close once the client is closed or polling keeps failing.
+func (c *Client) Subscribe(startSlot uint64) (<-chan *pb.SubscribeUpdate, <-chan error) {
+	updates := make(chan *pb.SubscribeUpdate, 256)
+	errs := make(chan error, 1)
+	go c.pollLoop(c.stream(), startSlot, updates, errs)
+	return updates, errs
+}
+
+// Close stops the current stream. A later Connect or Subscribe starts over.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancel != nil {
+		c.cancel()
+		c.cancel = nil
+	}
+	return nil
+}
+
+// Name identifies the source for failover metrics and logs.
+func (c *Client) Name() string { return "rpc" }
+
+type pollState struct {
+	next        uint64
+	unfinalized []uint64
+}
+
+func (c *Client) pollLoop(ctx context.Context, startSlot uint64, updates chan<- *pb.SubscribeUpdate, errs chan<- error) {
+	defer close(updates)
+	defer close(errs)
+
+	state := &pollState{next: startSlot}
+	failures := 0
+	for {
+		caughtUp, err := c.poll(ctx, state, updates)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			failures++
+			log.Printf("rpc poll failed (%d/%d): %v", failures, c.cfg.MaxConsecutiveErrors, err)
+			if failures >= c.cfg.MaxConsecutiveErrors {
+				errs <- fmt.Errorf("rpc poll: %w", err)
+				return
+			}
+		} else {
+			failures = 0
+		}
+		if caughtUp || err != nil {
+			select {
+			case <-time.After(c.cfg.PollInterval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// poll fetches up to MaxSlotsPerPoll confirmed slots and settles finalized
+// ones. It reports whether the confirmed tip has been reached.
+func (c *Client) poll(ctx context.Context, state *pollState, updates chan<- *pb.SubscribeUpdate) (bool, error) {
+	tip, err := c.rpc.GetSlot(ctx, commitmentConfirmed)
+	if err != nil {
+		return false, err
+	}
+	if state.next == 0 {
+		state.next = tip
+	}
+
+	caughtUp := true
+	if state.next <= tip {
+		end := tip
+		if end-state.next+1 > c.cfg.MaxSlotsPerPoll {
+			end = state.next + c.cfg.MaxSlotsPerPoll - 1
+			caughtUp = false
+		}
+		slots, err := c.rpc.GetBlocks(ctx, state.next, end, commitmentConfirmed)
+		if err != nil {
+			return false, err
+		}
+		for _, slot := range slots {
+			if err := c.emitBlock(ctx, slot, updates); err != nil {
+				return false, err
+			}
+			state.unfinalized = append(state.unfinalized, slot)
+			// Keep partial progress so a failed block is retried alone.
+			state.next = slot + 1
+		}
+		state.next = end + 1
+	}
+
+	if len(state.unfinalized) == 0 {
+		return caughtUp, nil
+	}
+	finalized, err := c.rpc.GetSlot(ctx, commitmentFinalized)
+	if err != nil {
+		return false, err
+	}
+	settled := 0
+	for _, slot := range state.unfinalized {
+		if slot > finalized {
+			break
+		}
+		if !c.send(ctx, updates, slotUpdate(slot, nil, pb.SlotStatus_SLOT_FINALIZED)) {
+			return false, ctx.Err()
+		}
+		settled++
+	}
+	state.unfinalized = state.unfinalized[settled:]
+	return caughtUp, nil
+}
+
+// emitBlock sends the block meta, the block's matching transactions and a
+// confirmed slot status, in that order, so swaps are timestamped before the
+// processor analyses the slot.
+func (c *Client) emitBlock(ctx context.Context, slot uint64, updates chan<- *pb.SubscribeUpdate) error {
+	block, err := c.rpc.GetBlock(ctx, slot, commitmentConfirmed)
+	if err != nil {
+		if solrpc.IsSlotSkipped(err) {
+			return nil
+		}
+		return fmt.Errorf("get block %d: %w", slot, err)
+	}
+	if block == nil {
+		return nil
+	}
+	txs, err := block.TransactionUpdates(c.programs)
+	if err != nil {
+		return err
+	}
+
+	msgs := make([]*pb.SubscribeUpdate, 0, len(txs)+2)
+	msgs = append(msgs, &pb.SubscribeUpdate{UpdateOneof: &pb.SubscribeUpdate_BlockMeta{BlockMeta: block.BlockMeta()}})
+	for _, tx := range txs {
+		msgs = append(msgs, &pb.SubscribeUpdate{UpdateOneof: &pb.SubscribeUpdate_Transaction{Transaction: tx}})
+	}
+	parent := block.ParentSlot
+	msgs = append(msgs, slotUpdate(slot, &parent, pb.SlotStatus_SLOT_CONFIRMED))
+	for _, msg := range msgs {
+		if !c.send(ctx, updates, msg) {
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (c *Client) send(ctx context.Context, updates chan<- *pb.SubscribeUpdate, update *pb.SubscribeUpdate) bool {
+	select {
+	case updates <- update:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func slotUpdate(slot uint64, parent *uint64, status pb.SlotStatus) *pb.SubscribeUpdate {
+	return &pb.SubscribeUpdate{
+		UpdateOneof: &pb.SubscribeUpdate_Slot{Slot: &pb.SubscribeUpdateSlot{Slot: slot, Parent: parent, Status: status}},
+	}
+}
@@ -0,0 +1,226 @@
+package rpcpoll
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/rpcpool/yellowstone-grpc/examples/golang/proto"
+)
+
+const (
+	testProgram = "whirLbMiicVdio4qvUfM5KAg6Ct8VwpYzGff3uctyCc"
+	testSig     = "5VERv8NMvzbJMEkV8xnrLkEaWRtSz9CosKDYjCJjBRnbJLgp8uirBgmQpjKhoR4tjF3ZpRzrFmBV6UjKdiSZkQUW"
+)
+
+func blockTx(sig string, keys ...string) string {
+	quoted := make([]string, len(keys))
+	for i, k := range keys {
+		quoted[i] = fmt.Sprintf("%q", k)
+	}
+	return fmt.Sprintf(`{"meta": {"err": null, "fee": 5000}, "version": 0,
+	  "transaction": {"signatures": [%q], "message": {"header": {"numRequiredSignatures": 1},
+	  "accountKeys": [%s], "instructions": [{"programIdIndex": 1, "accounts": [0], "data": ""}]}}}`,
+		sig, strings.Join(quoted, ","))
+}
+
+func newRPCServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     int               `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		reply := func(result string) {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":%s}`, req.ID, result)
+		}
+		switch req.Method {
+		case "getSlot":
+			if strings.Contains(string(req.Params[0]), "finalized") {
+				reply("100")
+				return
+			}
+			reply("102")
+		case "getBlocks":
+			// Slot 101 was a skipped leader slot.
+			reply("[100, 102]")
+		case "getBlock":
+			var slot uint64
+			_ = json.Unmarshal(req.Params[0], &slot)
+			if slot == 102 {
+				fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32007,"message":"Slot 102 was skipped"}}`, req.ID)
+				return
+			}
+			txs := strings.Join([]string{
+				blockTx("3Bxs4Bc3VYuGVB19", "9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM", "Vote111111111111111111111111111111111111111"),
+				blockTx(testSig, "9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM", testProgram),
+				blockTx("4ZkQUW", "9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM", "11111111111111111111111111111111"),
+			}, ",")
+			reply(fmt.Sprintf(`{"blockhash": "EkSnNWid2cvwEVnVx9aBqawnmiCNiDgp3gUdkDPTKN1N", "previousBlockhash": "11111111111111111111111111111111",
+			  "parentSlot": 99, "blockTime": 1700000100, "blockHeight": 90, "transactions": [%s]}`, txs))
+		default:
+			http.Error(w, "unknown method", http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClientPollsBlocks(t *testing.T) {
+	server := newRPCServer(t)
+	cfg := DefaultConfig()
+	cfg.Endpoint = server.URL
+	cfg.PollInterval = 10 * time.Millisecond
+	cfg.ProgramFilters = map[string]string{"orca": testProgram}
+
+	client, err := NewClient(cfg)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	if client.Name() != "rpc" {
+		t.Fatalf("unexpected name %s", client.Name())
+	}
+
+	updates, errs := client.Subscribe(100)
+	var got []*pb.SubscribeUpdate
+	timeout := time.After(2 * time.Second)
+	for len(got) < 4 {
+		select {
+		case u := <-updates:
+			got = append(got, u)
+		case err := <-errs:
+			t.Fatalf("unexpected error: %v", err)
+		case <-timeout:
+			t.Fatalf("timed out after %d updates", len(got))
+		}
+	}
+
+	meta := got[0].GetBlockMeta()
+	if meta == nil || meta.GetSlot() != 100 || meta.GetParentSlot() != 99 || meta.GetBlockTime().GetTimestamp() != 1700000100 {
+		t.Fatalf("expected block meta for slot 100, got %v", got[0])
+	}
+	tx := got[1].GetTransaction()
+	if tx == nil || tx.GetSlot() != 100 || tx.GetTransaction().GetIndex() != 1 {
+		t.Fatalf("expected program transaction at index 1, got %v", got[1])
+	}
+	if !tx.GetTransaction().GetTransaction().GetMessage().GetVersioned() {
+		t.Fatal("expected versioned message")
+	}
+	if s := got[2].GetSlot(); s == nil || s.GetSlot() != 100 || s.GetStatus() != pb.SlotStatus_SLOT_CONFIRMED || s.GetParent() != 99 {
+		t.Fatalf("expected confirmed status, got %v", got[2])
+	}
+	if s := got[3].GetSlot(); s == nil || s.GetSlot() != 100 || s.GetStatus() != pb.SlotStatus_SLOT_FINALIZED {
+		t.Fatalf("expected finalized status, got %v", got[3])
+	}
+
+	// Nothing is emitted for the skipped slots, and slot 100 is finalized once.
+	select {
+	case u := <-updates:
+		t.Fatalf("unexpected extra update %v", u)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestClientReconnectsAfterClose(t *testing.T) {
+	server := newRPCServer(t)
+	cfg := DefaultConfig()
+	cfg.Endpoint = server.URL
+	cfg.PollInterval = 10 * time.Millisecond
+	cfg.ProgramFilters = map[string]string{"orca": testProgram}
+
+	client, err := NewClient(cfg)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	first, _ := client.Subscribe(100)
+	<-first
+
+	// Failover closes the client when it rotates away from it.
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	for range first {
+	}
+
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect() after Close error = %v", err)
+	}
+	updates, errs := client.Subscribe(100)
+	select {
+	case u, ok := <-updates:
+		if !ok || u.GetBlockMeta().GetSlot() != 100 {
+			t.Fatalf("expected block meta for slot 100 after reconnect, got %v", u)
+		}
+	case err := <-errs:
+		t.Fatalf("unexpected error: %v", err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for updates after reconnect")
+	}
+}
+
+func TestClientReportsPersistentFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig()
+	cfg.Endpoint = server.URL
+	cfg.PollInterval = time.Millisecond
+	cfg.MaxConsecutiveErrors = 2
+	cfg.ProgramFilters = map[string]string{"orca": testProgram}
+	client, err := NewClient(cfg)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+	if err := client.Connect(); err == nil {
+		t.Fatal("expected connect error")
+	}
+
+	updates, errs := client.Subscribe(0)
+	select {
+	case err := <-errs:
+		if err == nil || !strings.Contains(err.Error(), "503") {
+			t.Fatalf("unexpected error %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for error")
+	}
+	if _, ok := <-updates; ok {
+		t.Fatal("expected updates channel to close")
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv(envRPCPollURL, "https://rpc.example.com")
+	t.Setenv(envRPCPollIntervalMS, "250")
+	t.Setenv(envRPCPollMaxSlots, "8")
+	cfg, err := FromEnv()
+	if err != nil {
+		t.Fatalf("FromEnv() error = %v", err)
+	}
+	if cfg.Endpoint != "https://rpc.example.com" || cfg.PollInterval != 250*time.Millisecond || cfg.MaxSlotsPerPoll != 8 {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected validation error without program filters")
+	}
+}
@@ -0,0 +1,106 @@
+package rpcpoll
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultPollInterval   = 400 * time.Millisecond
+	defaultRequestTimeout = 10 * time.Second
+	defaultMaxSlots       = 32
+	defaultMaxErrors      = 5
+	envRPCPollURL         = "RPC_POLL_URL"
+	envRPCPollIntervalMS  = "RPC_POLL_INTERVAL_MS"
+	envRPCPollTimeoutMS   = "RPC_POLL_TIMEOUT_MS"
+	envRPCPollMaxSlots    = "RPC_POLL_MAX_SLOTS"
+)
+
+// Config captures the parameters of the JSON-RPC polling source.
+type Config struct {
+	// Endpoint is a standard Solana JSON-RPC URL.
+	Endpoint string
+	// PollInterval is the delay between getSlot polls once caught up.
+	PollInterval time.Duration
+	// RequestTimeout bounds each RPC call.
+	RequestTimeout time.Duration
+	// MaxSlotsPerPoll bounds how many slots are fetched per poll while
+	// catching up.
+	MaxSlotsPerPoll uint64
+	// MaxConsecutiveErrors is the number of failed polls tolerated before
+	// the stream reports an error and ends.
+	MaxConsecutiveErrors int
+	// ProgramFilters restricts emitted transactions to those referencing the
+	// listed program IDs.
+	ProgramFilters map[string]string
+}
+
+// DefaultConfig returns a Config populated with defaults. The endpoint stays
+// empty because it is environment-specific.
+func DefaultConfig() *Config {
+	return &Config{
+		PollInterval:         defaultPollInterval,
+		RequestTimeout:       defaultRequestTimeout,
+		MaxSlotsPerPoll:      defaultMaxSlots,
+		MaxConsecutiveErrors: defaultMaxErrors,
+	}
+}
+
+// Validate ensures required fields are present and bounds are sane.
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("config is nil")
+	}
+	if c.Endpoint == "" {
+		return errors.New("rpc poll Endpoint is required")
+	}
+	if c.PollInterval <= 0 {
+		return fmt.Errorf("invalid PollInterval: %s", c.PollInterval)
+	}
+	if c.RequestTimeout <= 0 {
+		return fmt.Errorf("invalid RequestTimeout: %s", c.RequestTimeout)
+	}
+	if c.MaxSlotsPerPoll == 0 {
+		return errors.New("MaxSlotsPerPoll must be >= 1")
+	}
+	if c.MaxConsecutiveErrors <= 0 {
+		return errors.New("MaxConsecutiveErrors must be >= 1")
+	}
+	if len(c.ProgramFilters) == 0 {
+		return errors.New("ProgramFilters must contain at least one entry")
+	}
+	return nil
+}
+
+// FromEnv builds a Config from the environment. Program filters are not read
+// here; callers copy them from the geyser program config before validating.
+func FromEnv() (*Config, error) {
+	cfg := DefaultConfig()
+	cfg.Endpoint = os.Getenv(envRPCPollURL)
+
+	if v := os.Getenv(envRPCPollIntervalMS); v != "" {
+		ms, err := strconv.Atoi(v)
+		if err != nil || ms <= 0 {
+			return nil, fmt.Errorf("invalid %s: %q", envRPCPollIntervalMS, v)
+		}
+		cfg.PollInterval = time.Duration(ms) * time.Millisecond
+	}
+	if v := os.Getenv(envRPCPollTimeoutMS); v != "" {
+		ms, err := strconv.Atoi(v)
+		if err != nil || ms <= 0 {
+			return nil, fmt.Errorf("invalid %s: %q", envRPCPollTimeoutMS, v)
+		}
+		cfg.RequestTimeout = time.Duration(ms) * time.Millisecond
+	}
+	if v := os.Getenv(envRPCPollMaxSlots); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || n == 0 {
+			return nil, fmt.Errorf("invalid %s: %q", envRPCPollMaxSlots, v)
+		}
+		cfg.MaxSlotsPerPoll = n
+	}
+	return cfg, nil
+}
@@ -0,0 +1,87 @@
+package solrpc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	swapdecoder "github.com/rexbrahh/lp-indexer/ingestor/decoder"
+)
+
+// MaxMultipleAccounts is the per-request key limit of getMultipleAccounts.
+const MaxMultipleAccounts = 100
+
+var _ swapdecoder.AccountLoader = (*Client)(nil)
+
+// Account is an account returned by getMultipleAccounts with "base64"
+// encoding.
+type Account struct {
+	Lamports   uint64      `json:"lamports"`
+	Owner      string      `json:"owner"`
+	Data       AccountData `json:"data"`
+	Executable bool        `json:"executable"`
+}
+
+// AccountData decodes the ["<base64>", "base64"] data tuple.
+type AccountData []byte
+
+func (d *AccountData) UnmarshalJSON(raw []byte) error {
+	var tuple []string
+	if err := json.Unmarshal(raw, &tuple); err != nil {
+		return fmt.Errorf("account data: %w", err)
+	}
+	if len(tuple) != 2 || tuple[1] != "base64" {
+		return fmt.Errorf("account data: unexpected encoding %v", tuple)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(tuple[0])
+	if err != nil {
+		return fmt.Errorf("account data: %w", err)
+	}
+	*d = decoded
+	return nil
+}
+
+// GetMultipleAccounts fetches up to MaxMultipleAccounts accounts. The result
+// is index-aligned with keys; missing accounts are nil. The returned slot is
+// the context slot the node served the request at.
+func (c *Client) GetMultipleAccounts(ctx context.Context, keys []string, commitment string) ([]*Account, uint64, error) {
+	if len(keys) > MaxMultipleAccounts {
+		return nil, 0, fmt.Errorf("getMultipleAccounts: %d keys exceeds limit %d", len(keys), MaxMultipleAccounts)
+	}
+	var out struct {
+		Context struct {
+			Slot uint64 `json:"slot"`
+		} `json:"context"`
+		Value []*Account `json:"value"`
+	}
+	params := []any{keys, map[string]any{"encoding": "base64", "commitment": commitment}}
+	if err := c.Call(ctx, "getMultipleAccounts", params, &out); err != nil {
+		return nil, 0, err
+	}
+	if len(out.Value) != len(keys) {
+		return nil, 0, fmt.Errorf("getMultipleAccounts: got %d accounts for %d keys", len(out.Value), len(keys))
+	}
+	return out.Value, out.Context.Slot, nil
+}
+
+// LoadAccounts implements decoder.AccountLoader with confirmed
+// getMultipleAccounts requests.
+func (c *Client) LoadAccounts(ctx context.Context, keys []string) ([]*swapdecoder.Account, error) {
+	out := make([]*swapdecoder.Account, 0, len(keys))
+	for start := 0; start < len(keys); start += MaxMultipleAccounts {
+		end := min(start+MaxMultipleAccounts, len(keys))
+		accounts, _, err := c.GetMultipleAccounts(ctx, keys[start:end], "confirmed")
+		if err != nil {
+			return nil, err
+		}
+		for _, account := range accounts {
+			if account == nil {
+				out = append(out, nil)
+				continue
+			}
+			out = append(out, &swapdecoder.Account{Owner: account.Owner, Data: account.Data})
+		}
+	}
+	return out, nil
+}
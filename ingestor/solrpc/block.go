@@ -0,0 +1,124 @@
+package solrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	pb "github.com/rpcpool/yellowstone-grpc/examples/golang/proto"
+)
+
+// Skipped-slot error codes returned by getBlock.
+const (
+	codeSlotSkipped                = -32007
+	codeLongTermStorageSlotSkipped = -32009
+)
+
+// IsSlotSkipped reports whether err is the RPC error for a leader slot that
+// produced no block.
+func IsSlotSkipped(err error) bool {
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	return rpcErr.Code == codeSlotSkipped || rpcErr.Code == codeLongTermStorageSlotSkipped
+}
+
+// Block is a confirmed block as returned by getBlock with full transaction
+// details. Slot is filled in by GetBlock because the response omits it.
+type Block struct {
+	Slot              uint64             `json:"-"`
+	Blockhash         string             `json:"blockhash"`
+	PreviousBlockhash string             `json:"previousBlockhash"`
+	ParentSlot        uint64             `json:"parentSlot"`
+	BlockTime         *int64             `json:"blockTime"`
+	BlockHeight       *uint64            `json:"blockHeight"`
+	Transactions      []BlockTransaction `json:"transactions"`
+}
+
+// BlockTransaction is one entry of Block.Transactions.
+type BlockTransaction struct {
+	Transaction TransactionBody `json:"transaction"`
+	Meta        *Meta           `json:"meta"`
+	Version     json.RawMessage `json:"version"`
+}
+
+// BlockMeta converts the block header into a Yellowstone block meta update.
+func (b *Block) BlockMeta() *pb.SubscribeUpdateBlockMeta {
+	meta := &pb.SubscribeUpdateBlockMeta{
+		Slot:                     b.Slot,
+		Blockhash:                b.Blockhash,
+		ParentSlot:               b.ParentSlot,
+		ParentBlockhash:          b.PreviousBlockhash,
+		ExecutedTransactionCount: uint64(len(b.Transactions)),
+	}
+	if b.BlockTime != nil {
+		meta.BlockTime = &pb.UnixTimestamp{Timestamp: *b.BlockTime}
+	}
+	if b.BlockHeight != nil {
+		meta.BlockHeight = &pb.BlockHeight{BlockHeight: *b.BlockHeight}
+	}
+	return meta
+}
+
+// TransactionUpdates converts the block's transactions, keeping their
+// position in the block as the transaction index. When programs is non-empty
+// only transactions referencing one of those program IDs (directly or via a
+// lookup table) are returned; vote transactions are always dropped.
+func (b *Block) TransactionUpdates(programs map[string]struct{}) ([]*pb.SubscribeUpdateTransaction, error) {
+	var out []*pb.SubscribeUpdateTransaction
+	for i := range b.Transactions {
+		entry := &b.Transactions[i]
+		if isVote(entry) || (len(programs) > 0 && !entry.mentions(programs)) {
+			continue
+		}
+		index := uint64(i)
+		tx := Transaction{
+			Slot:             b.Slot,
+			BlockTime:        b.BlockTime,
+			IndexWithinBlock: &index,
+			Version:          entry.Version,
+			Transaction:      entry.Transaction,
+			Meta:             entry.Meta,
+		}
+		update, err := tx.ToUpdate()
+		if err != nil {
+			return nil, fmt.Errorf("slot %d tx %d: %w", b.Slot, i, err)
+		}
+		out = append(out, update)
+	}
+	return out, nil
+}
+
+const voteProgramID = "Vote111111111111111111111111111111111111111"
+
+func isVote(entry *BlockTransaction) bool {
+	keys := entry.Transaction.Message.AccountKeys
+	for _, instr := range entry.Transaction.Message.Instructions {
+		if idx := int(instr.ProgramIDIndex); idx < len(keys) && keys[idx] == voteProgramID {
+			return true
+		}
+	}
+	return false
+}
+
+func (entry *BlockTransaction) mentions(programs map[string]struct{}) bool {
+	for _, key := range entry.Transaction.Message.AccountKeys {
+		if _, ok := programs[key]; ok {
+			return true
+		}
+	}
+	if entry.Meta != nil && entry.Meta.LoadedAddresses != nil {
+		for _, key := range entry.Meta.LoadedAddresses.Readonly {
+			if _, ok := programs[key]; ok {
+				return true
+			}
+		}
+		for _, key := range entry.Meta.LoadedAddresses.Writable {
+			if _, ok := programs[key]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
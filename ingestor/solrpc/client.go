@@ -0,0 +1,156 @@
+package solrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Client is a minimal Solana JSON-RPC client over HTTP.
+type Client struct {
+	endpoint string
+	http     *http.Client
+	nextID   atomic.Uint64
+}
+
+// NewClient returns a client for endpoint with a per-request timeout.
+func NewClient(endpoint string, timeout time.Duration) *Client {
+	return &Client{
+		endpoint: endpoint,
+		http:     &http.Client{Timeout: timeout},
+	}
+}
+
+// Error is a JSON-RPC error object returned by the node.
+type Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+type request struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      uint64 `json:"id"`
+	Method  string `json:"method"`
+	Params  []any  `json:"params,omitempty"`
+}
+
+type response struct {
+	Result json.RawMessage `json:"result"`
+	Error  *Error          `json:"error"`
+}
+
+// Call invokes method with params and decodes the result into result. A null
+// result leaves result untouched.
+func (c *Client) Call(ctx context.Context, method string, params []any, result any) error {
+	body, err := json.Marshal(request{JSONRPC: "2.0", ID: c.nextID.Add(1), Method: method, Params: params})
+	if err != nil {
+		return fmt.Errorf("encode %s request: %w", method, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build %s request: %w", method, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: http %d: %s", method, resp.StatusCode, bytes.TrimSpace(snippet))
+	}
+
+	var out response
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return fmt.Errorf("decode %s response: %w", method, err)
+	}
+	if out.Error != nil {
+		return fmt.Errorf("%s: %w", method, out.Error)
+	}
+	if result == nil || len(out.Result) == 0 || string(out.Result) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(out.Result, result); err != nil {
+		return fmt.Errorf("decode %s result: %w", method, err)
+	}
+	return nil
+}
+
+// GetTransaction fetches a transaction with "json" encoding. It returns nil
+// without error when the node does not (yet) know the signature.
+func (c *Client) GetTransaction(ctx context.Context, signature, commitment string) (*Transaction, error) {
+	var tx *Transaction
+	params := []any{signature, map[string]any{
+		"encoding":                       "json",
+		"commitment":                     commitment,
+		"maxSupportedTransactionVersion": 0,
+	}}
+	if err := c.Call(ctx, "getTransaction", params, &tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// GetSlot returns the latest slot at the given commitment.
+func (c *Client) GetSlot(ctx context.Context, commitment string) (uint64, error) {
+	var slot uint64
+	if err := c.Call(ctx, "getSlot", []any{map[string]any{"commitment": commitment}}, &slot); err != nil {
+		return 0, err
+	}
+	return slot, nil
+}
+
+// GetBlocks returns the slots in [start, end] that produced a block; leader
+// slots that were skipped are absent.
+func (c *Client) GetBlocks(ctx context.Context, start, end uint64, commitment string) ([]uint64, error) {
+	var slots []uint64
+	if err := c.Call(ctx, "getBlocks", []any{start, end, map[string]any{"commitment": commitment}}, &slots); err != nil {
+		return nil, err
+	}
+	return slots, nil
+}
+
+// GetBlock fetches a block with full transaction details in "json" encoding.
+// It returns nil without error when the node reports no block for the slot.
+func (c *Client) GetBlock(ctx context.Context, slot uint64, commitment string) (*Block, error) {
+	var block *Block
+	params := []any{slot, map[string]any{
+		"encoding":                       "json",
+		"transactionDetails":             "full",
+		"rewards":                        false,
+		"commitment":                     commitment,
+		"maxSupportedTransactionVersion": 0,
+	}}
+	if err := c.Call(ctx, "getBlock", params, &block); err != nil {
+		return nil, err
+	}
+	if block != nil {
+		block.Slot = slot
+	}
+	return block, nil
+}
+
+// GetBlockTime returns the estimated production time of slot's block. It
+// returns the zero time without error when the node has no time for it.
+func (c *Client) GetBlockTime(ctx context.Context, slot uint64) (time.Time, error) {
+	var ts *int64
+	if err := c.Call(ctx, "getBlockTime", []any{slot}, &ts); err != nil {
+		return time.Time{}, err
+	}
+	if ts == nil {
+		return time.Time{}, nil
+	}
+	return time.Unix(*ts, 0).UTC(), nil
+}
@@ -0,0 +1,306 @@
+// Package solrpc converts Solana JSON-RPC transaction payloads (getTransaction
+// and getBlock with "json" encoding, and Helius raw webhooks which share the
+// shape) into the Yellowstone protobuf updates the decoder consumes.
+package solrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/mr-tron/base58/base58"
+
+	swapdecoder "github.com/rexbrahh/lp-indexer/ingestor/decoder"
+
+	pb "github.com/rpcpool/yellowstone-grpc/examples/golang/proto"
+)
+
+// Transaction is a transaction with status metadata as returned by
+// getTransaction. IndexWithinBlock is a Helius webhook extension; getBlock
+// callers fill it from the transaction's position in the block.
+type Transaction struct {
+	Slot             uint64          `json:"slot"`
+	BlockTime        *int64          `json:"blockTime"`
+	IndexWithinBlock *uint64         `json:"indexWithinBlock"`
+	Version          json.RawMessage `json:"version"`
+	Transaction      TransactionBody `json:"transaction"`
+	Meta             *Meta           `json:"meta"`
+}
+
+// TransactionBody holds the signatures and message.
+type TransactionBody struct {
+	Signatures []string `json:"signatures"`
+	Message    Message  `json:"message"`
+}
+
+// Message is the JSON-encoded transaction message.
+type Message struct {
+	Header              Header               `json:"header"`
+	AccountKeys         []string             `json:"accountKeys"`
+	RecentBlockhash     string               `json:"recentBlockhash"`
+	Instructions        []Instruction        `json:"instructions"`
+	AddressTableLookups []AddressTableLookup `json:"addressTableLookups"`
+}
+
+// Header mirrors the message header counts.
+type Header struct {
+	NumRequiredSignatures       uint32 `json:"numRequiredSignatures"`
+	NumReadonlySignedAccounts   uint32 `json:"numReadonlySignedAccounts"`
+	NumReadonlyUnsignedAccounts uint32 `json:"numReadonlyUnsignedAccounts"`
+}
+
+// Instruction is a compiled instruction with base58 data.
+type Instruction struct {
+	ProgramIDIndex uint32  `json:"programIdIndex"`
+	Accounts       []int   `json:"accounts"`
+	Data           string  `json:"data"`
+	StackHeight    *uint32 `json:"stackHeight"`
+}
+
+// AddressTableLookup references accounts loaded from an address lookup table.
+type AddressTableLookup struct {
+	AccountKey      string `json:"accountKey"`
+	WritableIndexes []int  `json:"writableIndexes"`
+	ReadonlyIndexes []int  `json:"readonlyIndexes"`
+}
+
+// Meta is the transaction status metadata.
+type Meta struct {
+	Err                  json.RawMessage     `json:"err"`
+	Fee                  uint64              `json:"fee"`
+	PreBalances          []uint64            `json:"preBalances"`
+	PostBalances         []uint64            `json:"postBalances"`
+	InnerInstructions    []InnerInstructions `json:"innerInstructions"`
+	LogMessages          []string            `json:"logMessages"`
+	PreTokenBalances     []TokenBalance      `json:"preTokenBalances"`
+	PostTokenBalances    []TokenBalance      `json:"postTokenBalances"`
+	LoadedAddresses      *LoadedAddresses    `json:"loadedAddresses"`
+	ComputeUnitsConsumed *uint64             `json:"computeUnitsConsumed"`
+}
+
+// InnerInstructions groups the CPIs made by one top-level instruction.
+type InnerInstructions struct {
+	Index        uint32        `json:"index"`
+	Instructions []Instruction `json:"instructions"`
+}
+
+// TokenBalance is an SPL token balance snapshot.
+type TokenBalance struct {
+	AccountIndex  uint32        `json:"accountIndex"`
+	Mint          string        `json:"mint"`
+	Owner         string        `json:"owner"`
+	ProgramID     string        `json:"programId"`
+	UITokenAmount UITokenAmount `json:"uiTokenAmount"`
+}
+
+// UITokenAmount carries the raw amount string and decimals.
+type UITokenAmount struct {
+	Amount         string   `json:"amount"`
+	Decimals       uint32   `json:"decimals"`
+	UIAmount       *float64 `json:"uiAmount"`
+	UIAmountString string   `json:"uiAmountString"`
+}
+
+// LoadedAddresses lists accounts loaded through address lookup tables.
+type LoadedAddresses struct {
+	Writable []string `json:"writable"`
+	Readonly []string `json:"readonly"`
+}
+
+// Signature returns the transaction's first signature, its identifier.
+func (t *Transaction) Signature() string {
+	if len(t.Transaction.Signatures) == 0 {
+		return ""
+	}
+	return t.Transaction.Signatures[0]
+}
+
+// ToUpdate converts the transaction into the Yellowstone update shape.
+func (t *Transaction) ToUpdate() (*pb.SubscribeUpdateTransaction, error) {
+	signatures, err := decodeKeys(t.Transaction.Signatures)
+	if err != nil {
+		return nil, fmt.Errorf("signatures: %w", err)
+	}
+	if len(signatures) == 0 {
+		return nil, fmt.Errorf("transaction has no signatures")
+	}
+
+	msg := t.Transaction.Message
+	accountKeys, err := decodeKeys(msg.AccountKeys)
+	if err != nil {
+		return nil, fmt.Errorf("account keys: %w", err)
+	}
+	var blockhash []byte
+	if msg.RecentBlockhash != "" {
+		if blockhash, err = base58.Decode(msg.RecentBlockhash); err != nil {
+			return nil, fmt.Errorf("recent blockhash: %w", err)
+		}
+	}
+	instructions := make([]*pb.CompiledInstruction, 0, len(msg.Instructions))
+	for i, instr := range msg.Instructions {
+		data, accounts, err := instr.decode()
+		if err != nil {
+			return nil, fmt.Errorf("instruction %d: %w", i, err)
+		}
+		instructions = append(instructions, &pb.CompiledInstruction{
+			ProgramIdIndex: instr.ProgramIDIndex,
+			Accounts:       accounts,
+			Data:           data,
+		})
+	}
+	lookups := make([]*pb.MessageAddressTableLookup, 0, len(msg.AddressTableLookups))
+	for _, lookup := range msg.AddressTableLookups {
+		key, err := base58.Decode(lookup.AccountKey)
+		if err != nil {
+			return nil, fmt.Errorf("lookup table key: %w", err)
+		}
+		lookups = append(lookups, &pb.MessageAddressTableLookup{
+			AccountKey:      key,
+			WritableIndexes: indexBytes(lookup.WritableIndexes),
+			ReadonlyIndexes: indexBytes(lookup.ReadonlyIndexes),
+		})
+	}
+
+	info := &pb.SubscribeUpdateTransactionInfo{
+		Signature: signatures[0],
+		Transaction: &pb.Transaction{
+			Signatures: signatures,
+			Message: &pb.Message{
+				Header: &pb.MessageHeader{
+					NumRequiredSignatures:       msg.Header.NumRequiredSignatures,
+					NumReadonlySignedAccounts:   msg.Header.NumReadonlySignedAccounts,
+					NumReadonlyUnsignedAccounts: msg.Header.NumReadonlyUnsignedAccounts,
+				},
+				AccountKeys:         accountKeys,
+				RecentBlockhash:     blockhash,
+				Instructions:        instructions,
+				Versioned:           t.versioned(),
+				AddressTableLookups: lookups,
+			},
+		},
+	}
+	if t.IndexWithinBlock != nil {
+		info.Index = *t.IndexWithinBlock
+	}
+	if t.Meta != nil {
+		meta, err := t.Meta.toProto()
+		if err != nil {
+			return nil, fmt.Errorf("meta: %w", err)
+		}
+		info.Meta = meta
+	}
+	return &pb.SubscribeUpdateTransaction{Slot: t.Slot, Transaction: info}, nil
+}
+
+// versioned reports whether the message is a v0 message; legacy messages
+// report version "legacy" (or omit it).
+func (t *Transaction) versioned() bool {
+	if len(t.Version) == 0 {
+		return false
+	}
+	var label string
+	if err := json.Unmarshal(t.Version, &label); err == nil {
+		return label != "legacy"
+	}
+	return string(t.Version) != "null"
+}
+
+func (m *Meta) toProto() (*pb.TransactionStatusMeta, error) {
+	meta := &pb.TransactionStatusMeta{
+		Fee:                  m.Fee,
+		PreBalances:          m.PreBalances,
+		PostBalances:         m.PostBalances,
+		LogMessages:          m.LogMessages,
+		ComputeUnitsConsumed: m.ComputeUnitsConsumed,
+	}
+	if len(m.Err) > 0 && string(m.Err) != "null" {
+		raw, err := swapdecoder.EncodeTxErrorJSON(m.Err)
+		if err != nil {
+			return nil, err
+		}
+		meta.Err = &pb.TransactionError{Err: raw}
+	}
+	for _, group := range m.InnerInstructions {
+		inner := &pb.InnerInstructions{Index: group.Index}
+		for i, instr := range group.Instructions {
+			data, accounts, err := instr.decode()
+			if err != nil {
+				return nil, fmt.Errorf("inner instruction %d.%d: %w", group.Index, i, err)
+			}
+			inner.Instructions = append(inner.Instructions, &pb.InnerInstruction{
+				ProgramIdIndex: instr.ProgramIDIndex,
+				Accounts:       accounts,
+				Data:           data,
+				StackHeight:    instr.StackHeight,
+			})
+		}
+		meta.InnerInstructions = append(meta.InnerInstructions, inner)
+	}
+	meta.PreTokenBalances = tokenBalances(m.PreTokenBalances)
+	meta.PostTokenBalances = tokenBalances(m.PostTokenBalances)
+	if m.LoadedAddresses != nil {
+		var err error
+		if meta.LoadedWritableAddresses, err = decodeKeys(m.LoadedAddresses.Writable); err != nil {
+			return nil, fmt.Errorf("loaded writable addresses: %w", err)
+		}
+		if meta.LoadedReadonlyAddresses, err = decodeKeys(m.LoadedAddresses.Readonly); err != nil {
+			return nil, fmt.Errorf("loaded readonly addresses: %w", err)
+		}
+	}
+	return meta, nil
+}
+
+func (i Instruction) decode() ([]byte, []byte, error) {
+	if i.Data == "" {
+		return []byte{}, indexBytes(i.Accounts), nil
+	}
+	data, err := base58.Decode(i.Data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("instruction data: %w", err)
+	}
+	return data, indexBytes(i.Accounts), nil
+}
+
+func tokenBalances(in []TokenBalance) []*pb.TokenBalance {
+	out := make([]*pb.TokenBalance, 0, len(in))
+	for _, tb := range in {
+		ui := &pb.UiTokenAmount{
+			Amount:         tb.UITokenAmount.Amount,
+			Decimals:       tb.UITokenAmount.Decimals,
+			UiAmountString: tb.UITokenAmount.UIAmountString,
+		}
+		if tb.UITokenAmount.UIAmount != nil {
+			ui.UiAmount = *tb.UITokenAmount.UIAmount
+		} else if v, err := strconv.ParseFloat(tb.UITokenAmount.UIAmountString, 64); err == nil {
+			ui.UiAmount = v
+		}
+		out = append(out, &pb.TokenBalance{
+			AccountIndex:  tb.AccountIndex,
+			Mint:          tb.Mint,
+			Owner:         tb.Owner,
+			ProgramId:     tb.ProgramID,
+			UiTokenAmount: ui,
+		})
+	}
+	return out
+}
+
+func decodeKeys(keys []string) ([][]byte, error) {
+	out := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		b, err := base58.Decode(key)
+		if err != nil {
+			return nil, fmt.Errorf("decode %q: %w", key, err)
+		}
+		out = append(out, b)
+	}
+	return out, nil
+}
+
+func indexBytes(indexes []int) []byte {
+	out := make([]byte, len(indexes))
+	for i, idx := range indexes {
+		out[i] = byte(idx)
+	}
+	return out
+}
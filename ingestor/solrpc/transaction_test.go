@@ -0,0 +1,135 @@
+package solrpc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mr-tron/base58/base58"
+
+	swapdecoder "github.com/rexbrahh/lp-indexer/ingestor/decoder"
+)
+
+const sampleTransaction = `{
+  "slot": 250000001,
+  "blockTime": 1700000000,
+  "indexWithinBlock": 7,
+  "version": 0,
+  "meta": {
+    "err": {"InstructionError": [1, {"Custom": 6001}]},
+    "fee": 5000,
+    "preBalances": [1000000, 0, 1],
+    "postBalances": [995000, 0, 1],
+    "innerInstructions": [
+      {"index": 1, "instructions": [
+        {"programIdIndex": 2, "accounts": [0, 1], "data": "3Bxs4Bc3VYuGVB19", "stackHeight": 2}
+      ]}
+    ],
+    "logMessages": ["Program whirLbMiicVdio4qvUfM5KAg6Ct8VwpYzGff3uctyCc invoke [1]"],
+    "preTokenBalances": [
+      {"accountIndex": 1, "mint": "So11111111111111111111111111111111111111112", "owner": "11111111111111111111111111111111",
+       "programId": "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA",
+       "uiTokenAmount": {"amount": "1500000000", "decimals": 9, "uiAmount": null, "uiAmountString": "1.5"}}
+    ],
+    "postTokenBalances": [],
+    "loadedAddresses": {"writable": ["TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA"], "readonly": []},
+    "computeUnitsConsumed": 42000
+  },
+  "transaction": {
+    "signatures": ["5VERv8NMvzbJMEkV8xnrLkEaWRtSz9CosKDYjCJjBRnbJLgp8uirBgmQpjKhoR4tjF3ZpRzrFmBV6UjKdiSZkQUW"],
+    "message": {
+      "header": {"numRequiredSignatures": 1, "numReadonlySignedAccounts": 0, "numReadonlyUnsignedAccounts": 1},
+      "accountKeys": [
+        "9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM",
+        "So11111111111111111111111111111111111111112",
+        "whirLbMiicVdio4qvUfM5KAg6Ct8VwpYzGff3uctyCc"
+      ],
+      "recentBlockhash": "EkSnNWid2cvwEVnVx9aBqawnmiCNiDgp3gUdkDPTKN1N",
+      "instructions": [
+        {"programIdIndex": 2, "accounts": [0, 1, 3], "data": "3Bxs4Bc3VYuGVB19", "stackHeight": null}
+      ],
+      "addressTableLookups": [
+        {"accountKey": "EkSnNWid2cvwEVnVx9aBqawnmiCNiDgp3gUdkDPTKN1N", "writableIndexes": [4], "readonlyIndexes": []}
+      ]
+    }
+  }
+}`
+
+func TestTransactionToUpdate(t *testing.T) {
+	var tx Transaction
+	if err := json.Unmarshal([]byte(sampleTransaction), &tx); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	update, err := tx.ToUpdate()
+	if err != nil {
+		t.Fatalf("ToUpdate: %v", err)
+	}
+	if update.GetSlot() != 250000001 {
+		t.Fatalf("unexpected slot %d", update.GetSlot())
+	}
+	info := update.GetTransaction()
+	if base58.Encode(info.GetSignature()) != tx.Signature() || info.GetIndex() != 7 {
+		t.Fatalf("unexpected signature/index: %x %d", info.GetSignature(), info.GetIndex())
+	}
+	msg := info.GetTransaction().GetMessage()
+	if !msg.GetVersioned() || len(msg.GetAccountKeys()) != 3 || len(msg.GetAddressTableLookups()) != 1 {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+	instr := msg.GetInstructions()[0]
+	if instr.GetProgramIdIndex() != 2 || string(instr.GetAccounts()) != "\x00\x01\x03" {
+		t.Fatalf("unexpected instruction: %+v", instr)
+	}
+	if len(instr.GetData()) != 12 || instr.GetData()[0] != 2 {
+		t.Fatalf("unexpected instruction data %q", instr.GetData())
+	}
+
+	meta := info.GetMeta()
+	if meta.GetFee() != 5000 || meta.GetComputeUnitsConsumed() != 42000 {
+		t.Fatalf("unexpected fee/cu: %+v", meta)
+	}
+	inner := meta.GetInnerInstructions()[0].GetInstructions()[0]
+	if inner.GetStackHeight() != 2 || string(inner.GetAccounts()) != "\x00\x01" {
+		t.Fatalf("unexpected inner instruction: %+v", inner)
+	}
+	balance := meta.GetPreTokenBalances()[0]
+	if balance.GetUiTokenAmount().GetAmount() != "1500000000" || balance.GetUiTokenAmount().GetUiAmount() != 1.5 {
+		t.Fatalf("unexpected token balance: %+v", balance)
+	}
+	if len(meta.GetLoadedWritableAddresses()) != 1 {
+		t.Fatalf("expected one loaded writable address")
+	}
+
+	txErr, err := swapdecoder.DecodeTxError(meta.GetErr().GetErr())
+	if err != nil {
+		t.Fatalf("decode tx error: %v", err)
+	}
+	if txErr.Kind != swapdecoder.TxErrorKindCustom || txErr.InstructionIndex != 1 || txErr.Code != 6001 {
+		t.Fatalf("unexpected tx error: %+v", txErr)
+	}
+}
+
+func TestTransactionLegacyWithoutError(t *testing.T) {
+	var tx Transaction
+	raw := `{"slot": 1, "version": "legacy", "meta": {"err": null, "fee": 0},
+	  "transaction": {"signatures": ["5VERv8NMvzbJMEkV8xnrLkEaWRtSz9CosKDYjCJjBRnbJLgp8uirBgmQpjKhoR4tjF3ZpRzrFmBV6UjKdiSZkQUW"],
+	  "message": {"header": {}, "accountKeys": [], "instructions": []}}}`
+	if err := json.Unmarshal([]byte(raw), &tx); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	update, err := tx.ToUpdate()
+	if err != nil {
+		t.Fatalf("ToUpdate: %v", err)
+	}
+	if update.GetTransaction().GetTransaction().GetMessage().GetVersioned() {
+		t.Fatal("legacy message reported as versioned")
+	}
+	if update.GetTransaction().GetMeta().GetErr() != nil {
+		t.Fatal("expected no transaction error")
+	}
+}
+
+func TestTransactionRejectsBadBase58(t *testing.T) {
+	tx := Transaction{Transaction: TransactionBody{Signatures: []string{"0OIl"}}}
+	if _, err := tx.ToUpdate(); err == nil {
+		t.Fatal("expected error for invalid base58 signature")
+	}
+}
@@ -5,6 +5,13 @@ const (
 	MetricPublisherNATSacksTotal = "publisher_nats_acks_total"
 	MetricPublisherNATSErrors    = "publisher_nats_errors_total"
 
+	MetricPublisherOutboxDepth   = "publisher_outbox_depth"
+	MetricPublisherOutboxBytes   = "publisher_outbox_bytes"
+	MetricPublisherOutboxAge     = "publisher_outbox_oldest_age_seconds"
+	MetricPublisherOutboxSpooled = "publisher_outbox_spooled_total"
+	MetricPublisherOutboxDrained = "publisher_outbox_drained_total"
+	MetricPublisherOutboxDropped = "publisher_outbox_dropped_total"
+
 	MetricBridgeForwardTotal    = "bridge_forward_total"
 	MetricBridgeDroppedTotal    = "bridge_dropped_total"
 	MetricBridgePublishErrors   = "bridge_publish_errors_total"
@@ -16,4 +23,14 @@ const (
 	MetricOrcaDecodeErrors    = "ingestor_orca_decode_errors_total"
 	MetricMeteoraSwapsTotal   = "ingestor_meteora_swaps_total"
 	MetricMeteoraDecodeErrors = "ingestor_meteora_decode_errors_total"
+
+	MetricFailedSwapAttemptsTotal = "ingestor_failed_swap_attempts_total"
+	MetricMevEventsTotal          = "ingestor_mev_events_total"
+	MetricDecodeDLQTotal          = "ingestor_decode_dlq_total"
+
+	MetricSlotGapsTotal     = "ingestor_slot_gaps_total"
+	MetricSlotGapSlotsTotal = "ingestor_slot_gap_slots_total"
+	MetricSlotGapOpenSlots  = "ingestor_slot_gap_open_slots"
+
+	MetricOracleDeviationBps = "ingestor_oracle_deviation_bps"
 )
@@ -20,8 +20,16 @@ CREATE TABLE IF NOT EXISTS trades (
   reserves_base  Decimal(38, 0),
   reserves_quote Decimal(38, 0),
   fee_bps        UInt16,
+  lp_fee         Decimal(38, 0),
+  protocol_fee   Decimal(38, 0),
+  trader         String,
+  fee_payer      String,
+  mev_victim     UInt8,
   provisional    UInt8,
-  is_undo        UInt8
+  is_undo        UInt8,
+  price_usd      Float64,
+  volume_usd     Float64,
+  ts_estimated   UInt8
 ) ENGINE = MergeTree
 PARTITION BY toDate(ts)
 ORDER BY (chain_id, pool_id, slot, sig, idx);
@@ -57,6 +65,8 @@ CREATE TABLE IF NOT EXISTS ohlcv_1s (
   vol_base     Decimal(38, 0),
   vol_quote    Decimal(38, 0),
   trades       UInt32,
+  price_usd    Float64,
+  volume_usd   Float64,
   updated_at   DateTime('UTC') DEFAULT now('UTC')
 ) ENGINE = ReplacingMergeTree(updated_at)
 PARTITION BY toDate(window_start)
@@ -77,6 +87,8 @@ CREATE TABLE IF NOT EXISTS ohlcv_1m (
   vol_base     Decimal(38, 0),
   vol_quote    Decimal(38, 0),
   trades       UInt32,
+  price_usd    Float64,
+  volume_usd   Float64,
   updated_at   DateTime('UTC') DEFAULT now('UTC')
 ) ENGINE = ReplacingMergeTree(updated_at)
 PARTITION BY toDate(window_start)
@@ -97,6 +109,8 @@ CREATE TABLE IF NOT EXISTS ohlcv_5m (
   vol_base     Decimal(38, 0),
   vol_quote    Decimal(38, 0),
   trades       UInt32,
+  price_usd    Float64,
+  volume_usd   Float64,
   updated_at   DateTime('UTC') DEFAULT now('UTC')
 ) ENGINE = ReplacingMergeTree(updated_at)
 PARTITION BY toDate(window_start)
@@ -117,6 +131,8 @@ CREATE TABLE IF NOT EXISTS ohlcv_1h (
   vol_base     Decimal(38, 0),
   vol_quote    Decimal(38, 0),
   trades       UInt32,
+  price_usd    Float64,
+  volume_usd   Float64,
   updated_at   DateTime('UTC') DEFAULT now('UTC')
 ) ENGINE = ReplacingMergeTree(updated_at)
 PARTITION BY toDate(window_start)
@@ -137,6 +153,8 @@ CREATE TABLE IF NOT EXISTS ohlcv_1d (
   vol_base     Decimal(38, 0),
   vol_quote    Decimal(38, 0),
   trades       UInt32,
+  price_usd    Float64,
+  volume_usd   Float64,
   updated_at   DateTime('UTC') DEFAULT now('UTC')
 ) ENGINE = ReplacingMergeTree(updated_at)
 PARTITION BY toDate(window_start)
@@ -163,3 +181,23 @@ CREATE TABLE IF NOT EXISTS holders_estimate (
   updated_at   DateTime('UTC') DEFAULT now('UTC')
 ) ENGINE = ReplacingMergeTree(updated_at)
 ORDER BY (chain_id, mint, window_start);
+
+CREATE TABLE IF NOT EXISTS mev_events (
+  chain_id       UInt16,
+  slot           UInt64,
+  ts             DateTime64(3, 'UTC'),
+  kind           LowCardinality(String),
+  attacker       String,
+  pool_id        String,
+  front_sig      String,
+  front_idx      UInt32,
+  back_sig       String,
+  back_idx       UInt32,
+  victim_sigs    Array(String),
+  victim_traders Array(String),
+  profit_mint    String,
+  profit_amount  Int64,
+  ts_estimated   UInt8
+) ENGINE = ReplacingMergeTree
+PARTITION BY toDate(ts)
+ORDER BY (chain_id, slot, kind, back_sig, pool_id);
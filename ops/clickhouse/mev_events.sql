@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS mev_events (
+  chain_id       UInt16,
+  slot           UInt64,
+  ts             DateTime64(3, 'UTC'),
+  kind           LowCardinality(String),
+  attacker       String,
+  pool_id        String,
+  front_sig      String,
+  front_idx      UInt32,
+  back_sig       String,
+  back_idx       UInt32,
+  victim_sigs    Array(String),
+  victim_traders Array(String),
+  profit_mint    String,
+  profit_amount  Int64,
+  ts_estimated   UInt8
+) ENGINE = ReplacingMergeTree
+PARTITION BY toDate(ts)
+ORDER BY (chain_id, slot, kind, back_sig, pool_id);
@@ -13,6 +13,8 @@ CREATE TABLE IF NOT EXISTS ohlcv_1h (
   vol_base     Decimal(38, 0),
   vol_quote    Decimal(38, 0),
   trades       UInt32,
+  price_usd    Float64,
+  volume_usd   Float64,
   updated_at   DateTime('UTC') DEFAULT now('UTC')
 ) ENGINE = ReplacingMergeTree(updated_at)
 PARTITION BY toDate(window_start)
@@ -18,8 +18,16 @@ CREATE TABLE IF NOT EXISTS trades (
   reserves_base  Decimal(38, 0),
   reserves_quote Decimal(38, 0),
   fee_bps        UInt16,
+  lp_fee         Decimal(38, 0),
+  protocol_fee   Decimal(38, 0),
+  trader         String,
+  fee_payer      String,
+  mev_victim     UInt8,
   provisional    UInt8,
-  is_undo        UInt8
+  is_undo        UInt8,
+  price_usd      Float64,
+  volume_usd     Float64,
+  ts_estimated   UInt8
 ) ENGINE = MergeTree
 PARTITION BY toDate(ts)
 ORDER BY (chain_id, pool_id, slot, sig, idx);
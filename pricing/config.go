@@ -0,0 +1,64 @@
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultMaxAge       = 10 * time.Minute
+	envPriceMaxAge      = "PRICE_ORACLE_MAX_AGE_MS"
+	envPriceMinDepthUSD = "PRICE_ORACLE_MIN_DEPTH_USD"
+)
+
+// Config bounds which pool observations the oracle trusts.
+type Config struct {
+	// MaxAge is how long a pool's last price stays usable. Pools that have
+	// not traded within MaxAge of the valuation time are ignored.
+	MaxAge time.Duration
+	// MinDepthUSD drops pools shallower than this from the average so dust
+	// pools cannot move a price. Zero keeps every pool.
+	MinDepthUSD float64
+}
+
+// DefaultConfig returns a Config with defaults applied.
+func DefaultConfig() *Config {
+	return &Config{MaxAge: defaultMaxAge}
+}
+
+// Validate ensures the limits are sane.
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("config is nil")
+	}
+	if c.MaxAge <= 0 {
+		return fmt.Errorf("invalid MaxAge: %s", c.MaxAge)
+	}
+	if c.MinDepthUSD < 0 {
+		return fmt.Errorf("invalid MinDepthUSD: %g", c.MinDepthUSD)
+	}
+	return nil
+}
+
+// FromEnv builds a Config from the environment, starting from the defaults.
+func FromEnv() (*Config, error) {
+	cfg := DefaultConfig()
+	if v := os.Getenv(envPriceMaxAge); v != "" {
+		ms, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", envPriceMaxAge, err)
+		}
+		cfg.MaxAge = time.Duration(ms) * time.Millisecond
+	}
+	if v := os.Getenv(envPriceMinDepthUSD); v != "" {
+		depth, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", envPriceMinDepthUSD, err)
+		}
+		cfg.MinDepthUSD = depth
+	}
+	return cfg, cfg.Validate()
+}
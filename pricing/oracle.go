@@ -0,0 +1,316 @@
+// Package pricing values tokens in USD. The Oracle keeps the latest price
+// each pool traded at and routes every mint to USD through the deepest fresh
+// pools quoting it in USDC or USDT, or in SOL valued at its own USD price.
+// Fresh reference prices from an external oracle take precedence over pool
+// routing for the mints they cover.
+package pricing
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	dexcommon "github.com/rexbrahh/lp-indexer/decoder/common"
+	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+)
+
+// Mints the oracle routes through.
+const (
+	USDCMint = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+	USDTMint = "Es9vMFrzaCERmJfrF4H2FYD4KCoNkY11McCe8BenwNYB"
+	SOLMint  = "So11111111111111111111111111111111111111112"
+)
+
+const q32Factor = 4294967296.0
+
+// stablecoins are valued at par.
+var stablecoins = map[string]struct{}{
+	USDCMint: {},
+	USDTMint: {},
+}
+
+var routeDecimals = map[string]uint8{
+	USDCMint: 6,
+	USDTMint: 6,
+	SOLMint:  9,
+}
+
+// Quote is the latest price observed on one pool.
+type Quote struct {
+	PoolID string
+	Base   string
+	Quote  string
+	// Price is quote tokens per base token, decimal adjusted.
+	Price float64
+	// Depth is the pool's liquidity expressed in quote tokens.
+	Depth float64
+	At    time.Time
+}
+
+// Oracle tracks pool prices and derives USD prices from them. It is safe for
+// concurrent use.
+type Oracle struct {
+	cfg Config
+
+	mu         sync.RWMutex
+	pools      map[string]Quote
+	byMint     map[string]map[string]struct{}
+	decimals   map[string]uint8
+	references map[string]reference
+}
+
+type reference struct {
+	usd float64
+	at  time.Time
+}
+
+// New returns an empty oracle. A nil cfg uses DefaultConfig.
+func New(cfg *Config) *Oracle {
+	if cfg == nil {
+		cfg = DefaultConfig()
+	}
+	return &Oracle{
+		cfg:        *cfg,
+		pools:      make(map[string]Quote),
+		byMint:     make(map[string]map[string]struct{}),
+		decimals:   make(map[string]uint8),
+		references: make(map[string]reference),
+	}
+}
+
+// Observe records a pool price. Observations older than the pool's current
+// one and degenerate quotes are ignored.
+func (o *Oracle) Observe(q Quote) {
+	if q.PoolID == "" || q.Base == "" || q.Quote == "" || q.Base == q.Quote {
+		return
+	}
+	if !(q.Price > 0) || math.IsInf(q.Price, 0) || !(q.Depth >= 0) || math.IsInf(q.Depth, 0) {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if prev, ok := o.pools[q.PoolID]; ok {
+		if q.At.Before(prev.At) {
+			return
+		}
+		o.unindexLocked(prev)
+	}
+	o.pools[q.PoolID] = q
+	for _, mint := range []string{q.Base, q.Quote} {
+		set, ok := o.byMint[mint]
+		if !ok {
+			set = make(map[string]struct{})
+			o.byMint[mint] = set
+		}
+		set[q.PoolID] = struct{}{}
+	}
+}
+
+// ObserveSwap records the price a swap executed at. Depth comes from the
+// reported reserves; swaps without reserves fall back to their own quote
+// notional, which keeps such pools in the average at a small weight.
+func (o *Oracle) ObserveSwap(ev *dexv1.SwapEvent, at time.Time) {
+	if ev == nil || ev.GetIsUndo() {
+		return
+	}
+	o.learnDecimals(ev.GetMintBase(), ev.GetDecBase())
+	o.learnDecimals(ev.GetMintQuote(), ev.GetDecQuote())
+
+	price := swapPrice(ev)
+	reserveBase := scale(ev.GetReservesBase(), ev.GetDecBase())
+	reserveQuote := scale(ev.GetReservesQuote(), ev.GetDecQuote())
+	depth := reserveQuote + reserveBase*price
+	if depth == 0 {
+		depth = scale(ev.GetQuoteIn()+ev.GetQuoteOut(), ev.GetDecQuote())
+	}
+	o.Observe(Quote{
+		PoolID: ev.GetPoolId(),
+		Base:   ev.GetMintBase(),
+		Quote:  ev.GetMintQuote(),
+		Price:  price,
+		Depth:  depth,
+		At:     at,
+	})
+}
+
+// ObserveReference records an external USD price for mint, such as a Pyth
+// feed. Older observations than the current one are ignored.
+func (o *Oracle) ObserveReference(mint string, usd float64, at time.Time) {
+	if mint == "" || !(usd > 0) || math.IsInf(usd, 0) {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if prev, ok := o.references[mint]; ok && at.Before(prev.at) {
+		return
+	}
+	o.references[mint] = reference{usd: usd, at: at}
+}
+
+// PriceUSD returns the USD price of mint at now. Stablecoins are worth one
+// dollar and a fresh reference price wins; any other mint is the
+// depth-weighted average over fresh pools pairing it with a stablecoin, or
+// with SOL at SOL's own USD price.
+func (o *Oracle) PriceUSD(mint string, now time.Time) (float64, bool) {
+	return o.price(mint, now, true)
+}
+
+// DEXPriceUSD is PriceUSD from pool prices alone, ignoring reference
+// prices, so the two sources can be compared.
+func (o *Oracle) DEXPriceUSD(mint string, now time.Time) (float64, bool) {
+	return o.price(mint, now, false)
+}
+
+func (o *Oracle) price(mint string, now time.Time, useReferences bool) (float64, bool) {
+	if _, ok := stablecoins[mint]; ok {
+		return 1, true
+	}
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if useReferences {
+		if usd, ok := o.referenceLocked(mint, now); ok {
+			return usd, true
+		}
+	}
+	var (
+		solUSD float64
+		viaSOL bool
+	)
+	if mint != SOLMint {
+		if useReferences {
+			solUSD, viaSOL = o.referenceLocked(SOLMint, now)
+		}
+		if !viaSOL {
+			solUSD, viaSOL = o.routeLocked(SOLMint, 0, false, now)
+		}
+	}
+	return o.routeLocked(mint, solUSD, viaSOL, now)
+}
+
+func (o *Oracle) referenceLocked(mint string, now time.Time) (float64, bool) {
+	ref, ok := o.references[mint]
+	if !ok || now.Sub(ref.at) > o.cfg.MaxAge {
+		return 0, false
+	}
+	return ref.usd, true
+}
+
+// ValueSwap returns the USD execution price of the base token and the USD
+// volume of a swap. Quote-side values are preferred; the base side is used
+// when only the base mint can be priced.
+func (o *Oracle) ValueSwap(ev *dexv1.SwapEvent, now time.Time) (priceUSD, volumeUSD float64, ok bool) {
+	if ev == nil {
+		return 0, 0, false
+	}
+	if quoteUSD, ok := o.PriceUSD(ev.GetMintQuote(), now); ok {
+		priceUSD = swapPrice(ev) * quoteUSD
+		volumeUSD = scale(ev.GetQuoteIn()+ev.GetQuoteOut(), ev.GetDecQuote()) * quoteUSD
+		return priceUSD, volumeUSD, true
+	}
+	if baseUSD, ok := o.PriceUSD(ev.GetMintBase(), now); ok {
+		volumeUSD = scale(ev.GetBaseIn()+ev.GetBaseOut(), ev.GetDecBase()) * baseUSD
+		return baseUSD, volumeUSD, true
+	}
+	return 0, 0, false
+}
+
+// Decimals returns the decimals of mint as learned from swaps, the routing
+// mints or the loaded token list.
+func (o *Oracle) Decimals(mint string) (uint8, bool) {
+	if dec, ok := routeDecimals[mint]; ok {
+		return dec, true
+	}
+	o.mu.RLock()
+	dec, ok := o.decimals[mint]
+	o.mu.RUnlock()
+	if ok {
+		return dec, true
+	}
+	if info, ok := dexcommon.LookupToken(mint); ok && info.Decimals > 0 {
+		return info.Decimals, true
+	}
+	return 0, false
+}
+
+// routeLocked averages the USD price of mint implied by each fresh pool
+// against a stablecoin, or against SOL when viaSOL is set, weighting every
+// pool by its depth in USD.
+func (o *Oracle) routeLocked(mint string, solUSD float64, viaSOL bool, now time.Time) (float64, bool) {
+	var sum, weight float64
+	for poolID := range o.byMint[mint] {
+		q := o.pools[poolID]
+		if now.Sub(q.At) > o.cfg.MaxAge {
+			continue
+		}
+		other := q.Quote
+		if q.Quote == mint {
+			other = q.Base
+		}
+		var otherUSD float64
+		if _, ok := stablecoins[other]; ok {
+			otherUSD = 1
+		} else if other == SOLMint && viaSOL {
+			otherUSD = solUSD
+		} else {
+			continue
+		}
+
+		var mintUSD, depthUSD float64
+		if q.Base == mint {
+			mintUSD = q.Price * otherUSD
+			depthUSD = q.Depth * otherUSD
+		} else {
+			mintUSD = otherUSD / q.Price
+			depthUSD = q.Depth * mintUSD
+		}
+		if depthUSD <= 0 || depthUSD < o.cfg.MinDepthUSD {
+			continue
+		}
+		sum += mintUSD * depthUSD
+		weight += depthUSD
+	}
+	if weight == 0 {
+		return 0, false
+	}
+	return sum / weight, true
+}
+
+func (o *Oracle) unindexLocked(q Quote) {
+	for _, mint := range []string{q.Base, q.Quote} {
+		set := o.byMint[mint]
+		delete(set, q.PoolID)
+		if len(set) == 0 {
+			delete(o.byMint, mint)
+		}
+	}
+}
+
+func (o *Oracle) learnDecimals(mint string, decimals uint32) {
+	// Zero is indistinguishable from an unset field, so it is not recorded.
+	if mint == "" || decimals == 0 || decimals > math.MaxUint8 {
+		return
+	}
+	o.mu.Lock()
+	o.decimals[mint] = uint8(decimals)
+	o.mu.Unlock()
+}
+
+// swapPrice is the decimal-adjusted quote-per-base price a swap executed at,
+// taken from its amounts. PriceQ32 is only used when the amounts are missing,
+// or when neither decimals field is set and the amounts cannot be scaled.
+func swapPrice(ev *dexv1.SwapEvent) float64 {
+	baseAmount := ev.GetBaseIn() + ev.GetBaseOut()
+	quoteAmount := ev.GetQuoteIn() + ev.GetQuoteOut()
+	hasDecimals := ev.GetDecBase() != 0 || ev.GetDecQuote() != 0
+	if baseAmount > 0 && quoteAmount > 0 && (hasDecimals || ev.GetPriceQ32() == 0) {
+		return scale(quoteAmount, ev.GetDecQuote()) / scale(baseAmount, ev.GetDecBase())
+	}
+	if ev.GetPriceQ32() > 0 {
+		return float64(ev.GetPriceQ32()) / q32Factor
+	}
+	return 0
+}
+
+func scale(amount uint64, decimals uint32) float64 {
+	return float64(amount) / math.Pow10(int(decimals))
+}
@@ -0,0 +1,196 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+)
+
+const (
+	bonk = "DezXAZ8z7PnrnRJjz3wXBoRgixCa6xjnB7YaB1pPB263"
+	jup  = "JUPyiwrYJFskUPiHa7hkeR8VUtAeFoSYbKedZNsDvCN"
+)
+
+func approx(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-9*math.Max(1, math.Abs(want)) {
+		t.Fatalf("%s = %g, want %g", name, got, want)
+	}
+}
+
+func TestPriceUSDWeightsStablePoolsByDepth(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	o := New(nil)
+	o.Observe(Quote{PoolID: "sol-usdc", Base: SOLMint, Quote: USDCMint, Price: 150, Depth: 3_000_000, At: now})
+	o.Observe(Quote{PoolID: "sol-usdt", Base: SOLMint, Quote: USDTMint, Price: 154, Depth: 1_000_000, At: now})
+
+	price, ok := o.PriceUSD(SOLMint, now)
+	if !ok {
+		t.Fatal("SOL not priced")
+	}
+	approx(t, "SOL", price, (150*3+154*1)/4.0)
+
+	if price, ok := o.PriceUSD(USDCMint, now); !ok || price != 1 {
+		t.Fatalf("USDC = %g, %v", price, ok)
+	}
+	if _, ok := o.PriceUSD(bonk, now); ok {
+		t.Fatal("unrouted mint should not be priced")
+	}
+}
+
+func TestPriceUSDRoutesThroughSOL(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	o := New(nil)
+	o.Observe(Quote{PoolID: "sol-usdc", Base: SOLMint, Quote: USDCMint, Price: 100, Depth: 1_000_000, At: now})
+	// BONK/SOL at 2e-7 SOL is $0.00002; its own USDC pool says $0.00004.
+	o.Observe(Quote{PoolID: "bonk-sol", Base: bonk, Quote: SOLMint, Price: 2e-7, Depth: 3_000, At: now})
+	o.Observe(Quote{PoolID: "bonk-usdc", Base: bonk, Quote: USDCMint, Price: 4e-5, Depth: 100_000, At: now})
+
+	price, ok := o.PriceUSD(bonk, now)
+	if !ok {
+		t.Fatal("BONK not priced")
+	}
+	// $300k of depth via SOL against $100k direct.
+	approx(t, "BONK", price, (2e-5*300_000+4e-5*100_000)/400_000)
+
+	// A mint quoted against something other than a stable or SOL is not
+	// routed further.
+	o.Observe(Quote{PoolID: "jup-bonk", Base: jup, Quote: bonk, Price: 25_000, Depth: 1e12, At: now})
+	if _, ok := o.PriceUSD(jup, now); ok {
+		t.Fatal("two-hop route through BONK should not be used")
+	}
+}
+
+func TestPriceUSDHandlesMintOnQuoteSide(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	o := New(nil)
+	o.Observe(Quote{PoolID: "usdc-x", Base: USDCMint, Quote: jup, Price: 2, Depth: 500, At: now})
+	price, ok := o.PriceUSD(jup, now)
+	if !ok {
+		t.Fatal("JUP not priced")
+	}
+	approx(t, "JUP", price, 0.5)
+}
+
+func TestPriceUSDStalenessAndDepthLimits(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	o := New(&Config{MaxAge: time.Minute, MinDepthUSD: 1_000})
+	o.Observe(Quote{PoolID: "deep", Base: SOLMint, Quote: USDCMint, Price: 100, Depth: 50_000, At: start})
+	o.Observe(Quote{PoolID: "dust", Base: SOLMint, Quote: USDTMint, Price: 1_000, Depth: 10, At: start.Add(30 * time.Second)})
+
+	price, ok := o.PriceUSD(SOLMint, start.Add(30*time.Second))
+	if !ok || price != 100 {
+		t.Fatalf("SOL = %g, %v; want dust pool ignored", price, ok)
+	}
+	if _, ok := o.PriceUSD(SOLMint, start.Add(2*time.Minute)); ok {
+		t.Fatal("stale pools should not price SOL")
+	}
+
+	// An out-of-order observation does not replace a newer one.
+	o.Observe(Quote{PoolID: "deep", Base: SOLMint, Quote: USDCMint, Price: 1, Depth: 50_000, At: start.Add(-time.Second)})
+	if price, _ := o.PriceUSD(SOLMint, start); price != 100 {
+		t.Fatalf("SOL = %g after out-of-order quote", price)
+	}
+}
+
+func TestObserveAndValueSwap(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	o := New(nil)
+	o.ObserveSwap(&dexv1.SwapEvent{
+		PoolId:        "sol-usdc",
+		MintBase:      SOLMint,
+		MintQuote:     USDCMint,
+		DecBase:       9,
+		DecQuote:      6,
+		BaseIn:        2_000_000_000,
+		QuoteOut:      300_000_000,
+		PriceQ32:      150 << 32,
+		ReservesBase:  1_000_000_000_000,
+		ReservesQuote: 150_000_000_000,
+	}, now)
+
+	bonkSwap := &dexv1.SwapEvent{
+		PoolId:    "bonk-sol",
+		MintBase:  bonk,
+		MintQuote: SOLMint,
+		DecBase:   5,
+		DecQuote:  9,
+		BaseOut:   5_000_000_000, // 50,000 BONK
+		QuoteIn:   10_000_000,    // 0.01 SOL
+	}
+	o.ObserveSwap(bonkSwap, now)
+
+	priceUSD, volumeUSD, ok := o.ValueSwap(bonkSwap, now)
+	if !ok {
+		t.Fatal("swap not valued")
+	}
+	approx(t, "price_usd", priceUSD, 0.01/50_000*150)
+	approx(t, "volume_usd", volumeUSD, 1.5)
+
+	if dec, ok := o.Decimals(bonk); !ok || dec != 5 {
+		t.Fatalf("Decimals(bonk) = %d, %v", dec, ok)
+	}
+
+	// Undo events never move prices.
+	o.ObserveSwap(&dexv1.SwapEvent{PoolId: "sol-usdc", MintBase: SOLMint, MintQuote: USDCMint, PriceQ32: 1 << 32, IsUndo: true}, now.Add(time.Second))
+	if price, _ := o.PriceUSD(SOLMint, now); price != 150 {
+		t.Fatalf("SOL = %g after undo", price)
+	}
+}
+
+func TestSwapPricePrefersAmounts(t *testing.T) {
+	tests := []struct {
+		name string
+		ev   *dexv1.SwapEvent
+		want float64
+	}{
+		{
+			name: "amounts over pool price",
+			ev:   &dexv1.SwapEvent{DecBase: 9, DecQuote: 6, BaseIn: 2_000_000_000, QuoteOut: 300_000_000, PriceQ32: 160 << 32},
+			want: 150,
+		},
+		{
+			name: "pool price without amounts",
+			ev:   &dexv1.SwapEvent{DecBase: 9, DecQuote: 6, PriceQ32: 160 << 32},
+			want: 160,
+		},
+		{
+			name: "pool price without decimals",
+			ev:   &dexv1.SwapEvent{BaseIn: 2_000_000_000, QuoteOut: 300_000_000, PriceQ32: 160 << 32},
+			want: 160,
+		},
+		{
+			name: "amounts only",
+			ev:   &dexv1.SwapEvent{DecBase: 5, DecQuote: 9, BaseOut: 5_000_000_000, QuoteIn: 10_000_000},
+			want: 0.01 / 50_000,
+		},
+	}
+	for _, tt := range tests {
+		approx(t, tt.name, swapPrice(tt.ev), tt.want)
+	}
+}
+
+func TestReferencePriceTakesPrecedence(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	o := New(&Config{MaxAge: time.Minute})
+	o.Observe(Quote{PoolID: "sol-usdc", Base: SOLMint, Quote: USDCMint, Price: 150, Depth: 1_000_000, At: now})
+	o.Observe(Quote{PoolID: "bonk-sol", Base: bonk, Quote: SOLMint, Price: 1e-7, Depth: 1_000, At: now})
+	o.ObserveReference(SOLMint, 160, now)
+
+	if price, _ := o.PriceUSD(SOLMint, now); price != 160 {
+		t.Fatalf("SOL = %g, want reference 160", price)
+	}
+	if price, _ := o.DEXPriceUSD(SOLMint, now); price != 150 {
+		t.Fatalf("DEX SOL = %g, want 150", price)
+	}
+	price, _ := o.PriceUSD(bonk, now)
+	approx(t, "BONK via reference SOL", price, 1.6e-5)
+
+	// A stale reference falls back to pool routing.
+	o.Observe(Quote{PoolID: "sol-usdc", Base: SOLMint, Quote: USDCMint, Price: 151, Depth: 1_000_000, At: now.Add(2 * time.Minute)})
+	if price, _ := o.PriceUSD(SOLMint, now.Add(2*time.Minute)); price != 151 {
+		t.Fatalf("SOL = %g after reference went stale", price)
+	}
+}
@@ -0,0 +1,115 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultFlushInterval = 5 * time.Second
+	envRegistryDSN       = "REGISTRY_DSN"
+	envRegistryPath      = "REGISTRY_PATH"
+	envRegistryFlush     = "REGISTRY_FLUSH_MS"
+	envRegistryReload    = "REGISTRY_RELOAD_MS"
+)
+
+// Config selects the registry store. DSN takes precedence over Path.
+type Config struct {
+	// DSN is a Postgres connection string (postgres://...).
+	DSN string
+	// Path is a local JSON file used when no DSN is set.
+	Path string
+	// FlushInterval is how often changed pools are written to the store.
+	FlushInterval time.Duration
+	// ReloadInterval is how often the store is re-read so processes that do
+	// not observe pools themselves see new ones. Zero disables reloading.
+	ReloadInterval time.Duration
+}
+
+// DefaultConfig returns a Config with defaults applied. The store location
+// stays empty because it is deployment-specific.
+func DefaultConfig() *Config {
+	return &Config{FlushInterval: defaultFlushInterval}
+}
+
+// Validate ensures a store is configured and intervals are sane.
+func (c *Config) Validate() error {
+	if c == nil {
+		return errors.New("config is nil")
+	}
+	if c.DSN == "" && c.Path == "" {
+		return errors.New("registry DSN or path is required")
+	}
+	if c.FlushInterval <= 0 {
+		return fmt.Errorf("invalid FlushInterval: %s", c.FlushInterval)
+	}
+	if c.ReloadInterval < 0 {
+		return fmt.Errorf("invalid ReloadInterval: %s", c.ReloadInterval)
+	}
+	return nil
+}
+
+// FromEnv builds a Config from the environment. It returns nil when neither
+// REGISTRY_DSN nor REGISTRY_PATH is set.
+func FromEnv() (*Config, error) {
+	dsn := os.Getenv(envRegistryDSN)
+	path := os.Getenv(envRegistryPath)
+	if dsn == "" && path == "" {
+		return nil, nil
+	}
+	cfg := DefaultConfig()
+	cfg.DSN = dsn
+	cfg.Path = path
+	if v := os.Getenv(envRegistryFlush); v != "" {
+		ms, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", envRegistryFlush, err)
+		}
+		cfg.FlushInterval = time.Duration(ms) * time.Millisecond
+	}
+	if v := os.Getenv(envRegistryReload); v != "" {
+		ms, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", envRegistryReload, err)
+		}
+		cfg.ReloadInterval = time.Duration(ms) * time.Millisecond
+	}
+	return cfg, cfg.Validate()
+}
+
+// Open connects the configured store and loads the registry from it.
+func Open(ctx context.Context, cfg *Config) (*Registry, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	var (
+		store Store
+		err   error
+	)
+	if cfg.DSN != "" {
+		store, err = NewPostgresStore(ctx, cfg.DSN)
+	} else {
+		store, err = NewFileStore(cfg.Path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	reg := New(store)
+	if err := reg.Load(ctx); err != nil {
+		store.Close()
+		return nil, err
+	}
+	return reg, nil
+}
+
+// Close releases the underlying store.
+func (r *Registry) Close() error {
+	if r.store == nil {
+		return nil
+	}
+	return r.store.Close()
+}
@@ -0,0 +1,102 @@
+package registry
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed schema.sql
+var postgresSchema string
+
+const (
+	selectPoolsSQL = `SELECT address, program_id, dex, mint_a, mint_b, base_mint, quote_mint,
+	pair_id, fee_bps, first_slot, last_slot, updated_at FROM dex_pools`
+
+	upsertPoolSQL = `INSERT INTO dex_pools (address, program_id, dex, mint_a, mint_b, base_mint,
+	quote_mint, pair_id, fee_bps, first_slot, last_slot, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+ON CONFLICT (address) DO UPDATE SET
+	program_id = EXCLUDED.program_id,
+	dex = EXCLUDED.dex,
+	mint_a = EXCLUDED.mint_a,
+	mint_b = EXCLUDED.mint_b,
+	base_mint = EXCLUDED.base_mint,
+	quote_mint = EXCLUDED.quote_mint,
+	pair_id = EXCLUDED.pair_id,
+	fee_bps = EXCLUDED.fee_bps,
+	first_slot = COALESCE(LEAST(NULLIF(dex_pools.first_slot, 0), NULLIF(EXCLUDED.first_slot, 0)), 0),
+	last_slot = GREATEST(dex_pools.last_slot, EXCLUDED.last_slot),
+	updated_at = EXCLUDED.updated_at`
+)
+
+// PostgresStore keeps pools in the dex_pools table so every service shares
+// one registry.
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresStore connects to dsn and creates the dex_pools table when it is
+// missing.
+func NewPostgresStore(ctx context.Context, dsn string) (*PostgresStore, error) {
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("connect registry database: %w", err)
+	}
+	if _, err := pool.Exec(ctx, postgresSchema); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("create registry schema: %w", err)
+	}
+	return &PostgresStore{pool: pool}, nil
+}
+
+// Load reads every row of dex_pools.
+func (s *PostgresStore) Load(ctx context.Context) ([]Pool, error) {
+	rows, err := s.pool.Query(ctx, selectPoolsSQL)
+	if err != nil {
+		return nil, fmt.Errorf("query pools: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Pool
+	for rows.Next() {
+		var (
+			p                   Pool
+			feeBps              int32
+			firstSlot, lastSlot int64
+		)
+		if err := rows.Scan(&p.Address, &p.ProgramID, &p.DEX, &p.MintA, &p.MintB, &p.BaseMint,
+			&p.QuoteMint, &p.PairID, &feeBps, &firstSlot, &lastSlot, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan pool: %w", err)
+		}
+		p.FeeBps, p.FirstSlot, p.LastSlot = uint32(feeBps), uint64(firstSlot), uint64(lastSlot)
+		p.UpdatedAt = p.UpdatedAt.UTC()
+		out = append(out, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read pools: %w", err)
+	}
+	return out, nil
+}
+
+// Upsert writes pools in one batch. The stored slot range only widens.
+func (s *PostgresStore) Upsert(ctx context.Context, pools []Pool) error {
+	batch := &pgx.Batch{}
+	for _, p := range pools {
+		batch.Queue(upsertPoolSQL, p.Address, p.ProgramID, p.DEX, p.MintA, p.MintB, p.BaseMint,
+			p.QuoteMint, p.PairID, int32(p.FeeBps), int64(p.FirstSlot), int64(p.LastSlot), p.UpdatedAt)
+	}
+	if err := s.pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("upsert pools: %w", err)
+	}
+	return nil
+}
+
+// Close releases the connection pool.
+func (s *PostgresStore) Close() error {
+	s.pool.Close()
+	return nil
+}
@@ -0,0 +1,364 @@
+// Package registry is the source of truth for the pools the indexer has seen:
+// their DEX, mints, fee tier and canonical pair. It is fed by decoded pool
+// accounts and swap events and persisted through a Store.
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	dexcommon "github.com/rexbrahh/lp-indexer/decoder/common"
+	"github.com/rexbrahh/lp-indexer/decoder/meteora"
+	orcawhirlpool "github.com/rexbrahh/lp-indexer/decoder/orca_whirlpool"
+	ray "github.com/rexbrahh/lp-indexer/decoder/raydium"
+	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+)
+
+// ErrNotFound is returned by lookups for unknown pools.
+var ErrNotFound = errors.New("pool not found")
+
+// Pool describes one liquidity pool.
+type Pool struct {
+	Address   string `json:"address"`
+	ProgramID string `json:"program_id"`
+	DEX       string `json:"dex"`
+	// MintA and MintB are in the pool's own (raw) order.
+	MintA string `json:"mint_a"`
+	MintB string `json:"mint_b"`
+	// BaseMint, QuoteMint and PairID follow the canonical ResolvePair order.
+	BaseMint  string    `json:"base_mint"`
+	QuoteMint string    `json:"quote_mint"`
+	PairID    string    `json:"pair_id"`
+	FeeBps    uint32    `json:"fee_bps"`
+	FirstSlot uint64    `json:"first_slot"`
+	LastSlot  uint64    `json:"last_slot"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RawPairID is the pair identifier in the pool's own mint order, as used by
+// candles built from raw pool prices.
+func (p *Pool) RawPairID() string {
+	return dexcommon.PairID(p.MintA, p.MintB)
+}
+
+// DEXForProgram names the DEX behind a program ID, or "" when unknown.
+func DEXForProgram(programID string) string {
+	switch programID {
+	case ray.ProgramID:
+		return "raydium_clmm"
+	case orcawhirlpool.WhirlpoolProgramID:
+		return "orca_whirlpool"
+	}
+	if kind, ok := meteora.ProgramKindForID(programID); ok {
+		return "meteora_" + string(kind)
+	}
+	return ""
+}
+
+// Registry indexes pools by address, mint and canonical pair. It is safe for
+// concurrent use.
+type Registry struct {
+	store Store
+
+	mu     sync.RWMutex
+	pools  map[string]*Pool
+	byMint map[string]map[string]struct{}
+	byPair map[string]map[string]struct{}
+	dirty  map[string]struct{}
+}
+
+// New returns a registry persisted through store (nil keeps it in memory).
+func New(store Store) *Registry {
+	return &Registry{
+		store:  store,
+		pools:  make(map[string]*Pool),
+		byMint: make(map[string]map[string]struct{}),
+		byPair: make(map[string]map[string]struct{}),
+		dirty:  make(map[string]struct{}),
+	}
+}
+
+// Observe merges a pool description. Empty fields leave known values alone,
+// slots only widen the observed range, and the canonical pair is derived from
+// the mints.
+func (r *Registry) Observe(update Pool) {
+	if update.Address == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pool, ok := r.pools[update.Address]
+	if !ok {
+		pool = &Pool{Address: update.Address}
+		r.pools[update.Address] = pool
+	}
+	before := *pool
+
+	if update.ProgramID != "" {
+		pool.ProgramID = update.ProgramID
+		if dex := DEXForProgram(update.ProgramID); dex != "" {
+			pool.DEX = dex
+		}
+	}
+	if update.DEX != "" {
+		pool.DEX = update.DEX
+	}
+	if update.MintA != "" && update.MintB != "" {
+		pool.MintA, pool.MintB = update.MintA, update.MintB
+	}
+	if update.FeeBps != 0 {
+		pool.FeeBps = update.FeeBps
+	}
+	if update.FirstSlot != 0 && (pool.FirstSlot == 0 || update.FirstSlot < pool.FirstSlot) {
+		pool.FirstSlot = update.FirstSlot
+	}
+	if last := max(update.LastSlot, update.FirstSlot); last > pool.LastSlot {
+		pool.LastSlot = last
+	}
+	if pool.MintA != "" && pool.MintB != "" {
+		if pair, err := dexcommon.ResolvePair(pool.MintA, pool.MintB); err == nil {
+			pool.BaseMint, pool.QuoteMint, pool.PairID = pair.BaseMint, pair.QuoteMint, pair.ID()
+		}
+	}
+
+	if ok && samePool(&before, pool) {
+		return
+	}
+	pool.UpdatedAt = time.Now().UTC()
+	r.unindexLocked(&before)
+	r.indexLocked(pool)
+	r.dirty[pool.Address] = struct{}{}
+}
+
+// ObserveSwap records the pool a swap traded against. Swap mints are in the
+// canonical order the ingestor publishes, so they only fill in pools whose raw
+// mint order is still unknown.
+func (r *Registry) ObserveSwap(ev *dexv1.SwapEvent) {
+	if ev == nil || ev.GetPoolId() == "" {
+		return
+	}
+	update := Pool{
+		Address:   ev.GetPoolId(),
+		ProgramID: ev.GetProgramId(),
+		FeeBps:    ev.GetFeeBps(),
+		FirstSlot: ev.GetSlot(),
+		LastSlot:  ev.GetSlot(),
+	}
+	r.mu.RLock()
+	known, ok := r.pools[update.Address]
+	needMints := !ok || known.MintA == ""
+	r.mu.RUnlock()
+	if needMints {
+		update.MintA, update.MintB = ev.GetMintBase(), ev.GetMintQuote()
+	}
+	r.Observe(update)
+}
+
+// Pool returns the pool at address.
+func (r *Registry) Pool(address string) (Pool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	pool, ok := r.pools[address]
+	if !ok {
+		return Pool{}, ErrNotFound
+	}
+	return *pool, nil
+}
+
+// PoolsByMint returns the pools holding mint on either side, ordered by
+// address.
+func (r *Registry) PoolsByMint(mint string) []Pool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.collectLocked(r.byMint[mint])
+}
+
+// PoolsByPair returns the pools trading a pair. pairID may list the mints in
+// either order.
+func (r *Registry) PoolsByPair(pairID string) ([]Pool, error) {
+	pair, err := dexcommon.ResolvePairID(pairID)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.collectLocked(r.byPair[pair.ID()]), nil
+}
+
+// Pools returns every known pool ordered by address.
+func (r *Registry) Pools() []Pool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]Pool, 0, len(r.pools))
+	for _, pool := range r.pools {
+		out = append(out, *pool)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Address < out[j].Address })
+	return out
+}
+
+// Mints returns every mint held by a known pool, sorted.
+func (r *Registry) Mints() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]string, 0, len(r.byMint))
+	for mint := range r.byMint {
+		out = append(out, mint)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Load replaces the in-memory state with the store's contents. Pools changed
+// locally but not yet flushed are kept.
+func (r *Registry) Load(ctx context.Context) error {
+	if r.store == nil {
+		return nil
+	}
+	pools, err := r.store.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("load pool registry: %w", err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pending := make(map[string]*Pool, len(r.dirty))
+	for address := range r.dirty {
+		pending[address] = r.pools[address]
+	}
+	r.pools = make(map[string]*Pool, len(pools)+len(pending))
+	r.byMint = make(map[string]map[string]struct{})
+	r.byPair = make(map[string]map[string]struct{})
+	for i := range pools {
+		pool := pools[i]
+		if _, ok := pending[pool.Address]; ok {
+			continue
+		}
+		r.pools[pool.Address] = &pool
+		r.indexLocked(&pool)
+	}
+	for _, pool := range pending {
+		r.pools[pool.Address] = pool
+		r.indexLocked(pool)
+	}
+	return nil
+}
+
+// Flush writes pools changed since the last flush to the store.
+func (r *Registry) Flush(ctx context.Context) error {
+	if r.store == nil {
+		return nil
+	}
+	r.mu.Lock()
+	changed := make([]Pool, 0, len(r.dirty))
+	for address := range r.dirty {
+		changed = append(changed, *r.pools[address])
+	}
+	clear(r.dirty)
+	r.mu.Unlock()
+	if len(changed) == 0 {
+		return nil
+	}
+
+	sort.Slice(changed, func(i, j int) bool { return changed[i].Address < changed[j].Address })
+	if err := r.store.Upsert(ctx, changed); err != nil {
+		r.mu.Lock()
+		for _, pool := range changed {
+			r.dirty[pool.Address] = struct{}{}
+		}
+		r.mu.Unlock()
+		return fmt.Errorf("flush pool registry: %w", err)
+	}
+	return nil
+}
+
+// Run flushes every flushInterval and, when reloadInterval is positive,
+// reloads from the store so read-only replicas follow the writer. It flushes
+// once more when ctx is cancelled.
+func (r *Registry) Run(ctx context.Context, flushInterval, reloadInterval time.Duration) {
+	flush := time.NewTicker(flushInterval)
+	defer flush.Stop()
+	var reload <-chan time.Time
+	if reloadInterval > 0 {
+		ticker := time.NewTicker(reloadInterval)
+		defer ticker.Stop()
+		reload = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := r.Flush(flushCtx); err != nil {
+				log.Printf("pool registry: %v", err)
+			}
+			cancel()
+			return
+		case <-flush.C:
+			if err := r.Flush(ctx); err != nil {
+				log.Printf("pool registry: %v", err)
+			}
+		case <-reload:
+			if err := r.Load(ctx); err != nil {
+				log.Printf("pool registry: %v", err)
+			}
+		}
+	}
+}
+
+func (r *Registry) indexLocked(pool *Pool) {
+	for _, mint := range []string{pool.MintA, pool.MintB} {
+		if mint != "" {
+			addToSet(r.byMint, mint, pool.Address)
+		}
+	}
+	if pool.PairID != "" {
+		addToSet(r.byPair, pool.PairID, pool.Address)
+	}
+}
+
+func (r *Registry) unindexLocked(pool *Pool) {
+	for _, mint := range []string{pool.MintA, pool.MintB} {
+		removeFromSet(r.byMint, mint, pool.Address)
+	}
+	removeFromSet(r.byPair, pool.PairID, pool.Address)
+}
+
+func (r *Registry) collectLocked(addresses map[string]struct{}) []Pool {
+	out := make([]Pool, 0, len(addresses))
+	for address := range addresses {
+		out = append(out, *r.pools[address])
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Address < out[j].Address })
+	return out
+}
+
+func samePool(a, b *Pool) bool {
+	x, y := *a, *b
+	x.UpdatedAt, y.UpdatedAt = time.Time{}, time.Time{}
+	return x == y
+}
+
+func addToSet(index map[string]map[string]struct{}, key, address string) {
+	set, ok := index[key]
+	if !ok {
+		set = make(map[string]struct{})
+		index[key] = set
+	}
+	set[address] = struct{}{}
+}
+
+func removeFromSet(index map[string]map[string]struct{}, key, address string) {
+	set, ok := index[key]
+	if !ok {
+		return
+	}
+	delete(set, address)
+	if len(set) == 0 {
+		delete(index, key)
+	}
+}
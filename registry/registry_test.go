@@ -0,0 +1,120 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	orcawhirlpool "github.com/rexbrahh/lp-indexer/decoder/orca_whirlpool"
+	ray "github.com/rexbrahh/lp-indexer/decoder/raydium"
+	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+)
+
+const (
+	usdc = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+	sol  = "So11111111111111111111111111111111111111112"
+	bonk = "DezXAZ8z7PnrnRJjz3wXBoRgixCa6xjnB7YaB1pPB263"
+)
+
+func TestObserveIndexesCanonicalPair(t *testing.T) {
+	reg := New(nil)
+	reg.Observe(Pool{Address: "pool1", ProgramID: orcawhirlpool.WhirlpoolProgramID, MintA: usdc, MintB: sol, FeeBps: 4, FirstSlot: 20})
+	reg.Observe(Pool{Address: "pool2", ProgramID: ray.ProgramID, MintA: sol, MintB: usdc, FirstSlot: 10})
+	reg.Observe(Pool{Address: "pool3", ProgramID: ray.ProgramID, MintA: bonk, MintB: sol, FirstSlot: 30})
+
+	pool, err := reg.Pool("pool1")
+	if err != nil {
+		t.Fatalf("Pool: %v", err)
+	}
+	if pool.DEX != "orca_whirlpool" || pool.BaseMint != sol || pool.QuoteMint != usdc || pool.PairID != sol+"-"+usdc {
+		t.Fatalf("unexpected pool %+v", pool)
+	}
+	if pool.RawPairID() != usdc+"-"+sol {
+		t.Fatalf("RawPairID = %s", pool.RawPairID())
+	}
+
+	for _, id := range []string{sol + "-" + usdc, usdc + "-" + sol} {
+		pools, err := reg.PoolsByPair(id)
+		if err != nil {
+			t.Fatalf("PoolsByPair(%s): %v", id, err)
+		}
+		if len(pools) != 2 || pools[0].Address != "pool1" || pools[1].Address != "pool2" {
+			t.Fatalf("PoolsByPair(%s) = %+v", id, pools)
+		}
+	}
+	if pools := reg.PoolsByMint(sol); len(pools) != 3 {
+		t.Fatalf("PoolsByMint(sol) = %d pools, want 3", len(pools))
+	}
+	if pools := reg.PoolsByMint(bonk); len(pools) != 1 || pools[0].Address != "pool3" {
+		t.Fatalf("PoolsByMint(bonk) = %+v", pools)
+	}
+	if _, err := reg.Pool("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Pool(missing) err = %v", err)
+	}
+	if _, err := reg.PoolsByPair(sol); err == nil {
+		t.Fatal("expected malformed pair id error")
+	}
+}
+
+func TestObserveSwapKeepsRawOrder(t *testing.T) {
+	reg := New(nil)
+	reg.Observe(Pool{Address: "pool1", ProgramID: ray.ProgramID, MintA: usdc, MintB: sol, FirstSlot: 100})
+	reg.ObserveSwap(&dexv1.SwapEvent{PoolId: "pool1", ProgramId: ray.ProgramID, MintBase: sol, MintQuote: usdc, FeeBps: 25, Slot: 90})
+	reg.ObserveSwap(&dexv1.SwapEvent{PoolId: "pool1", ProgramId: ray.ProgramID, MintBase: sol, MintQuote: usdc, FeeBps: 25, Slot: 150})
+
+	pool, err := reg.Pool("pool1")
+	if err != nil {
+		t.Fatalf("Pool: %v", err)
+	}
+	if pool.MintA != usdc || pool.MintB != sol {
+		t.Fatalf("swap overwrote raw mint order: %+v", pool)
+	}
+	if pool.FeeBps != 25 || pool.FirstSlot != 90 || pool.LastSlot != 150 {
+		t.Fatalf("unexpected pool %+v", pool)
+	}
+
+	reg.ObserveSwap(&dexv1.SwapEvent{PoolId: "pool2", ProgramId: ray.ProgramID, MintBase: sol, MintQuote: usdc, Slot: 5})
+	if pool, err := reg.Pool("pool2"); err != nil || pool.PairID != sol+"-"+usdc {
+		t.Fatalf("swap-only pool = %+v, %v", pool, err)
+	}
+}
+
+func TestFlushAndLoadFileStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "pools.json"))
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+
+	writer := New(store)
+	writer.Observe(Pool{Address: "pool1", ProgramID: orcawhirlpool.WhirlpoolProgramID, MintA: usdc, MintB: sol, FeeBps: 4, FirstSlot: 20})
+	if err := writer.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	writer.Observe(Pool{Address: "pool2", ProgramID: ray.ProgramID, MintA: bonk, MintB: sol, FirstSlot: 30})
+	if err := writer.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	reader := New(store)
+	reader.Observe(Pool{Address: "pool3", MintA: bonk, MintB: usdc})
+	if err := reader.Load(ctx); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := len(reader.Pools()); got != 3 {
+		t.Fatalf("reader has %d pools, want 3 (2 stored + 1 unflushed)", got)
+	}
+	want, _ := writer.Pool("pool1")
+	got, err := reader.Pool("pool1")
+	if err != nil || !got.UpdatedAt.Equal(want.UpdatedAt) {
+		t.Fatalf("loaded pool = %+v, %v; want %+v", got, err, want)
+	}
+	got.UpdatedAt = want.UpdatedAt
+	if got != want {
+		t.Fatalf("loaded pool = %+v, want %+v", got, want)
+	}
+	if pools, _ := reader.PoolsByPair(sol + "-" + usdc); len(pools) != 1 {
+		t.Fatalf("loaded pair index = %+v", pools)
+	}
+}
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS dex_pools (
+    address     TEXT PRIMARY KEY,
+    program_id  TEXT NOT NULL DEFAULT '',
+    dex         TEXT NOT NULL DEFAULT '',
+    mint_a      TEXT NOT NULL DEFAULT '',
+    mint_b      TEXT NOT NULL DEFAULT '',
+    base_mint   TEXT NOT NULL DEFAULT '',
+    quote_mint  TEXT NOT NULL DEFAULT '',
+    pair_id     TEXT NOT NULL DEFAULT '',
+    fee_bps     INTEGER NOT NULL DEFAULT 0,
+    first_slot  BIGINT NOT NULL DEFAULT 0,
+    last_slot   BIGINT NOT NULL DEFAULT 0,
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS dex_pools_mint_a_idx ON dex_pools (mint_a);
+CREATE INDEX IF NOT EXISTS dex_pools_mint_b_idx ON dex_pools (mint_b);
+CREATE INDEX IF NOT EXISTS dex_pools_pair_id_idx ON dex_pools (pair_id);
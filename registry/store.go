@@ -0,0 +1,125 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Store persists registry pools.
+type Store interface {
+	// Load returns every stored pool.
+	Load(ctx context.Context) ([]Pool, error)
+	// Upsert inserts or replaces pools by address.
+	Upsert(ctx context.Context, pools []Pool) error
+	Close() error
+}
+
+const fileStoreVersion = 1
+
+type fileSnapshot struct {
+	Version int    `json:"version"`
+	Pools   []Pool `json:"pools"`
+}
+
+// FileStore keeps pools in a local JSON file, for single-process deployments
+// and development.
+type FileStore struct {
+	path string
+
+	mu sync.Mutex
+}
+
+// NewFileStore returns a store backed by the JSON file at path. The file is
+// created on the first Upsert.
+func NewFileStore(path string) (*FileStore, error) {
+	if path == "" {
+		return nil, errors.New("registry file path is required")
+	}
+	return &FileStore{path: path}, nil
+}
+
+// Load reads the file. A missing file yields no pools.
+func (s *FileStore) Load(context.Context) ([]Pool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readLocked()
+}
+
+// Upsert merges pools into the file and replaces it atomically.
+func (s *FileStore) Upsert(_ context.Context, pools []Pool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.readLocked()
+	if err != nil {
+		return err
+	}
+	merged := make(map[string]Pool, len(existing)+len(pools))
+	for _, pool := range existing {
+		merged[pool.Address] = pool
+	}
+	for _, pool := range pools {
+		merged[pool.Address] = pool
+	}
+	out := make([]Pool, 0, len(merged))
+	for _, pool := range merged {
+		out = append(out, pool)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Address < out[j].Address })
+	return s.writeLocked(out)
+}
+
+// Close is a no-op.
+func (s *FileStore) Close() error { return nil }
+
+func (s *FileStore) readLocked() ([]Pool, error) {
+	raw, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read registry file: %w", err)
+	}
+	var snap fileSnapshot
+	if err := json.Unmarshal(raw, &snap); err != nil {
+		return nil, fmt.Errorf("decode registry file %s: %w", s.path, err)
+	}
+	if snap.Version != fileStoreVersion {
+		return nil, fmt.Errorf("registry file %s: unsupported version %d", s.path, snap.Version)
+	}
+	return snap.Pools, nil
+}
+
+func (s *FileStore) writeLocked(pools []Pool) error {
+	raw, err := json.MarshalIndent(fileSnapshot{Version: fileStoreVersion, Pools: pools}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode registry file: %w", err)
+	}
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create registry dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create registry temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write registry file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close registry file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("replace registry file: %w", err)
+	}
+	return nil
+}
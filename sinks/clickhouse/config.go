@@ -15,11 +15,13 @@ const (
 	envSinkPullBatch       = "CH_SINK_PULL_BATCH"
 	envSinkPullTimeoutMS   = "CH_SINK_PULL_TIMEOUT_MS"
 	envSinkFlushIntervalMS = "CH_SINK_FLUSH_INTERVAL_MS"
+	envSinkSlotTimeGraceMS = "CH_SINK_SLOT_TIME_GRACE_MS"
 
 	envSinkDSN             = "CH_SINK_DSN"
 	envSinkDatabase        = "CH_SINK_DATABASE"
 	envSinkTradesTable     = "CH_SINK_TRADES_TABLE"
 	envSinkCandlesTable    = "CH_SINK_CANDLES_TABLE"
+	envSinkMevTable        = "CH_SINK_MEV_TABLE"
 	envSinkBatchSize       = "CH_SINK_BATCH_SIZE"
 	envSinkMaxRetries      = "CH_SINK_MAX_RETRIES"
 	envSinkRetryBackoffMS  = "CH_SINK_RETRY_BACKOFF_MS"
@@ -34,7 +36,10 @@ type ServiceConfig struct {
 	Consumer    string
 	PullBatch   int
 	PullTimeout time.Duration
-	Writer      Config
+	// SlotTimeGrace is how long swaps and MEV events wait for their slot's
+	// block head before being written with an estimated timestamp.
+	SlotTimeGrace time.Duration
+	Writer        Config
 }
 
 // Validate ensures required fields are populated.
@@ -57,18 +62,22 @@ func (c ServiceConfig) Validate() error {
 	if c.PullTimeout <= 0 {
 		return fmt.Errorf("pull timeout must be positive")
 	}
+	if c.SlotTimeGrace < 0 {
+		return fmt.Errorf("slot time grace must be non-negative")
+	}
 	return validateConfig(c.Writer)
 }
 
 // ServiceConfigFromEnv loads ServiceConfig from environment variables.
 func ServiceConfigFromEnv() (ServiceConfig, error) {
 	cfg := ServiceConfig{
-		NATSURL:     os.Getenv(envSinkNATSURL),
-		Stream:      os.Getenv(envSinkStream),
-		SubjectRoot: valueOrDefault(os.Getenv(envSinkSubjectRoot), "dex.sol"),
-		Consumer:    valueOrDefault(os.Getenv(envSinkConsumer), "clickhouse-sink"),
-		PullBatch:   256,
-		PullTimeout: 500 * time.Millisecond,
+		NATSURL:       os.Getenv(envSinkNATSURL),
+		Stream:        os.Getenv(envSinkStream),
+		SubjectRoot:   valueOrDefault(os.Getenv(envSinkSubjectRoot), "dex.sol"),
+		Consumer:      valueOrDefault(os.Getenv(envSinkConsumer), "clickhouse-sink"),
+		PullBatch:     256,
+		PullTimeout:   500 * time.Millisecond,
+		SlotTimeGrace: 2 * time.Second,
 		Writer: Config{
 			BatchSize:        512,
 			FlushInterval:    1 * time.Second,
@@ -100,6 +109,14 @@ func ServiceConfigFromEnv() (ServiceConfig, error) {
 		cfg.Writer.FlushInterval = time.Duration(ms) * time.Millisecond
 	}
 
+	if v := os.Getenv(envSinkSlotTimeGraceMS); v != "" {
+		ms, err := strconv.Atoi(v)
+		if err != nil || ms < 0 {
+			return ServiceConfig{}, fmt.Errorf("invalid %s: %q", envSinkSlotTimeGraceMS, v)
+		}
+		cfg.SlotTimeGrace = time.Duration(ms) * time.Millisecond
+	}
+
 	if v := os.Getenv(envSinkDSN); v != "" {
 		cfg.Writer.DSN = v
 	}
@@ -112,6 +129,9 @@ func ServiceConfigFromEnv() (ServiceConfig, error) {
 	if v := os.Getenv(envSinkCandlesTable); v != "" {
 		cfg.Writer.CandlesTable = v
 	}
+	if v := os.Getenv(envSinkMevTable); v != "" {
+		cfg.Writer.MevTable = v
+	}
 	if v := os.Getenv(envSinkBatchSize); v != "" {
 		batch, err := strconv.Atoi(v)
 		if err != nil || batch <= 0 {
@@ -0,0 +1,131 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/ch-go"
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+// MevEvent represents a detected sandwich, back-run or atomic arbitrage.
+type MevEvent struct {
+	ChainID       uint16
+	Slot          uint64
+	Timestamp     time.Time
+	Kind          string
+	Attacker      string
+	PoolID        string
+	FrontSig      string
+	FrontIndex    uint32
+	BackSig       string
+	BackIndex     uint32
+	VictimSigs    []string
+	VictimTraders []string
+	ProfitMint    string
+	ProfitAmount  int64
+	// TsEstimated marks a Timestamp estimated from neighbouring slots.
+	TsEstimated bool
+}
+
+type mevBatch struct {
+	chainIDs      proto.ColUInt16
+	slots         proto.ColUInt64
+	timestamps    proto.ColDateTime64
+	kinds         proto.ColStr
+	attackers     proto.ColStr
+	pools         proto.ColStr
+	frontSigs     proto.ColStr
+	frontIndices  proto.ColUInt32
+	backSigs      proto.ColStr
+	backIndices   proto.ColUInt32
+	victimSigs    *proto.ColArr[string]
+	victimTraders *proto.ColArr[string]
+	profitMints   proto.ColStr
+	profitAmounts proto.ColInt64
+	tsEstimated   proto.ColUInt8
+	count         int
+}
+
+func newMevBatch() *mevBatch {
+	timestamps := proto.ColDateTime64{}
+	timestamps.WithPrecision(proto.PrecisionMilli)
+	return &mevBatch{
+		timestamps:    timestamps,
+		victimSigs:    proto.NewArray[string](new(proto.ColStr)),
+		victimTraders: proto.NewArray[string](new(proto.ColStr)),
+	}
+}
+
+// WriteMevEvents adds MEV events to the batch and flushes if batch size is
+// reached. Events are dropped when no MEV table is configured.
+func (w *Writer) WriteMevEvents(ctx context.Context, events []MevEvent) error {
+	if w.config.MevTable == "" {
+		return nil
+	}
+	for _, ev := range events {
+		w.mevBatch.chainIDs.Append(ev.ChainID)
+		w.mevBatch.slots.Append(ev.Slot)
+		w.mevBatch.timestamps.Append(ev.Timestamp)
+		w.mevBatch.kinds.Append(ev.Kind)
+		w.mevBatch.attackers.Append(ev.Attacker)
+		w.mevBatch.pools.Append(ev.PoolID)
+		w.mevBatch.frontSigs.Append(ev.FrontSig)
+		w.mevBatch.frontIndices.Append(ev.FrontIndex)
+		w.mevBatch.backSigs.Append(ev.BackSig)
+		w.mevBatch.backIndices.Append(ev.BackIndex)
+		w.mevBatch.victimSigs.Append(ev.VictimSigs)
+		w.mevBatch.victimTraders.Append(ev.VictimTraders)
+		w.mevBatch.profitMints.Append(ev.ProfitMint)
+		w.mevBatch.profitAmounts.Append(ev.ProfitAmount)
+		if ev.TsEstimated {
+			w.mevBatch.tsEstimated.Append(1)
+		} else {
+			w.mevBatch.tsEstimated.Append(0)
+		}
+		w.mevBatch.count++
+
+		if w.mevBatch.count >= w.config.BatchSize {
+			if err := w.flushMev(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// flushMev writes the current MEV batch to ClickHouse
+func (w *Writer) flushMev(ctx context.Context) error {
+	if w.mevBatch.count == 0 {
+		return nil
+	}
+
+	input := proto.Input{
+		{Name: "chain_id", Data: w.mevBatch.chainIDs},
+		{Name: "slot", Data: w.mevBatch.slots},
+		{Name: "ts", Data: w.mevBatch.timestamps},
+		{Name: "kind", Data: w.mevBatch.kinds},
+		{Name: "attacker", Data: w.mevBatch.attackers},
+		{Name: "pool_id", Data: w.mevBatch.pools},
+		{Name: "front_sig", Data: w.mevBatch.frontSigs},
+		{Name: "front_idx", Data: w.mevBatch.frontIndices},
+		{Name: "back_sig", Data: w.mevBatch.backSigs},
+		{Name: "back_idx", Data: w.mevBatch.backIndices},
+		{Name: "victim_sigs", Data: w.mevBatch.victimSigs},
+		{Name: "victim_traders", Data: w.mevBatch.victimTraders},
+		{Name: "profit_mint", Data: w.mevBatch.profitMints},
+		{Name: "profit_amount", Data: w.mevBatch.profitAmounts},
+		{Name: "ts_estimated", Data: w.mevBatch.tsEstimated},
+	}
+
+	if err := w.client.Do(ctx, ch.Query{
+		Body:  fmt.Sprintf("INSERT INTO %s VALUES", w.config.MevTable),
+		Input: input,
+	}); err != nil {
+		return fmt.Errorf("failed to flush mev events: %w", err)
+	}
+
+	w.mevBatch = newMevBatch()
+	return nil
+}
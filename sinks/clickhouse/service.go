@@ -11,49 +11,61 @@ import (
 	proto "google.golang.org/protobuf/proto"
 
 	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+	"github.com/rexbrahh/lp-indexer/ingestor/common"
+	natsx "github.com/rexbrahh/lp-indexer/sinks/nats"
+	"github.com/rexbrahh/lp-indexer/sinks/nats/provision"
 )
 
 type tradeWriter interface {
 	WriteTrades(ctx context.Context, trades []Trade) error
+	WriteMevEvents(ctx context.Context, events []MevEvent) error
 	Flush(ctx context.Context) error
+	DeleteEstimated(ctx context.Context, chainID uint16, slot uint64) error
 }
 
 type processor struct {
 	writer    tradeWriter
-	slotTimes map[uint64]time.Time
+	slotTimes common.SlotTimeCache
+	// grace is how long rows wait for their slot's block time before being
+	// written with an estimated timestamp.
+	grace   time.Duration
+	pending map[uint64]*pendingSlot
+	now     func() time.Time
 }
 
-func newProcessor(writer tradeWriter) *processor {
+func newProcessor(writer tradeWriter, slotTimes common.SlotTimeCache, grace time.Duration) *processor {
 	return &processor{
 		writer:    writer,
-		slotTimes: make(map[uint64]time.Time),
+		slotTimes: slotTimes,
+		grace:     grace,
+		pending:   make(map[uint64]*pendingSlot),
+		now:       time.Now,
 	}
 }
 
-func (p *processor) handleBlockHead(head *dexv1.BlockHead) {
+func (p *processor) handleBlockHead(ctx context.Context, head *dexv1.BlockHead) error {
 	if head == nil {
-		return
+		return nil
 	}
-	ts := time.Unix(int64(head.GetTsSec()), 0).UTC()
-	if ts.IsZero() {
-		return
+	if strings.ToLower(head.GetStatus()) == "dead" {
+		p.slotTimes.Delete(head.GetSlot())
+		return p.dropSlot(ctx, head.GetSlot())
 	}
-	p.slotTimes[head.GetSlot()] = ts
-	status := strings.ToLower(head.GetStatus())
-	if status == "dead" {
-		delete(p.slotTimes, head.GetSlot())
+	if head.GetTsSec() == 0 {
+		return nil
 	}
+	ts := time.Unix(int64(head.GetTsSec()), 0).UTC()
+	p.slotTimes.Set(head.GetSlot(), ts)
+	return p.resolveSlot(ctx, head.GetSlot(), ts)
 }
 
 func (p *processor) handleSwap(ctx context.Context, event *dexv1.SwapEvent) error {
 	if event == nil {
 		return nil
 	}
-	ts := p.slotTimes[event.GetSlot()]
 	trade := Trade{
 		ChainID:       uint16(event.GetChainId()),
 		Slot:          event.GetSlot(),
-		Timestamp:     ts,
 		Signature:     event.GetSig(),
 		Index:         event.GetIndex(),
 		ProgramID:     event.GetProgramId(),
@@ -66,14 +78,43 @@ func (p *processor) handleSwap(ctx context.Context, event *dexv1.SwapEvent) erro
 		BaseOut:       event.GetBaseOut(),
 		QuoteIn:       event.GetQuoteIn(),
 		QuoteOut:      event.GetQuoteOut(),
-		PriceQ32:      0,
+		PriceQ32:      event.GetPriceQ32(),
 		ReservesBase:  event.GetReservesBase(),
 		ReservesQuote: event.GetReservesQuote(),
 		FeeBps:        uint16(event.GetFeeBps()),
+		LPFee:         event.GetLpFee(),
+		ProtocolFee:   event.GetProtocolFee(),
+		Trader:        event.GetTrader(),
+		FeePayer:      event.GetFeePayer(),
+		MevVictim:     event.GetMevVictim(),
 		Provisional:   event.GetProvisional(),
 		IsUndo:        event.GetIsUndo(),
+		PriceUSD:      event.GetPriceUsd(),
+		VolumeUSD:     event.GetVolumeUsd(),
+	}
+	return p.holdTrade(ctx, trade)
+}
+
+func (p *processor) handleMev(ctx context.Context, event *dexv1.MevEvent) error {
+	if event == nil {
+		return nil
+	}
+	ev := MevEvent{
+		ChainID:       uint16(event.GetChainId()),
+		Slot:          event.GetSlot(),
+		Kind:          event.GetKind(),
+		Attacker:      event.GetAttacker(),
+		PoolID:        event.GetPoolId(),
+		FrontSig:      event.GetFrontSig(),
+		FrontIndex:    event.GetFrontIndex(),
+		BackSig:       event.GetBackSig(),
+		BackIndex:     event.GetBackIndex(),
+		VictimSigs:    event.GetVictimSigs(),
+		VictimTraders: event.GetVictimTraders(),
+		ProfitMint:    event.GetProfitMint(),
+		ProfitAmount:  event.GetProfitAmount(),
 	}
-	return p.writer.WriteTrades(ctx, []Trade{trade})
+	return p.holdMev(ctx, ev)
 }
 
 type Service struct {
@@ -102,6 +143,11 @@ func NewService(ctx context.Context, cfg ServiceConfig) (*Service, error) {
 		return nil, fmt.Errorf("jetstream: %w", err)
 	}
 
+	if err := provision.VerifyFromEnv(js, cfg.Stream, cfg.Consumer); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
 	subject := cfg.SubjectRoot + ".>"
 	sub, err := js.PullSubscribe(subject, cfg.Consumer, nats.BindStream(cfg.Stream), nats.ManualAck())
 	if err != nil {
@@ -109,12 +155,18 @@ func NewService(ctx context.Context, cfg ServiceConfig) (*Service, error) {
 		return nil, fmt.Errorf("pull subscribe: %w", err)
 	}
 
+	slotTimes, err := common.SlotTimeCacheFromEnv(js)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("init slot cache: %w", err)
+	}
+
 	return &Service{
 		cfg:       cfg,
 		conn:      conn,
 		js:        js,
 		sub:       sub,
-		processor: newProcessor(writer),
+		processor: newProcessor(writer, slotTimes, cfg.SlotTimeGrace),
 		lastFlush: time.Now(),
 	}, nil
 }
@@ -124,12 +176,17 @@ func (s *Service) Run(ctx context.Context) error {
 	defer flushTicker.Stop()
 	defer s.conn.Drain()
 	defer s.processor.writer.Flush(context.Background())
+	// Rows still waiting for a block time are written with estimates.
+	defer s.processor.release(context.Background(), 0)
 
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-flushTicker.C:
+			if err := s.processor.release(ctx, s.processor.grace); err != nil {
+				return err
+			}
 			if err := s.processor.writer.Flush(ctx); err != nil {
 				return err
 			}
@@ -155,22 +212,26 @@ func (s *Service) Run(ctx context.Context) error {
 }
 
 func (s *Service) handleMessage(ctx context.Context, msg *nats.Msg) error {
-	subject := msg.Subject
-	switch {
-	case strings.HasSuffix(subject, ".swap"):
+	switch natsx.EventKind(msg) {
+	case natsx.KindSwap:
 		var event dexv1.SwapEvent
 		if err := proto.Unmarshal(msg.Data, &event); err != nil {
 			return fmt.Errorf("unmarshal swap: %w", err)
 		}
 		return s.processor.handleSwap(ctx, &event)
-	case strings.HasSuffix(subject, ".blocks.head"):
+	case natsx.KindMev:
+		var event dexv1.MevEvent
+		if err := proto.Unmarshal(msg.Data, &event); err != nil {
+			return fmt.Errorf("unmarshal mev event: %w", err)
+		}
+		return s.processor.handleMev(ctx, &event)
+	case natsx.KindBlockHead:
 		var head dexv1.BlockHead
 		if err := proto.Unmarshal(msg.Data, &head); err != nil {
 			return fmt.Errorf("unmarshal block head: %w", err)
 		}
-		s.processor.handleBlockHead(&head)
-		return nil
-	case strings.HasSuffix(subject, ".tx.meta"):
+		return s.processor.handleBlockHead(ctx, &head)
+	case natsx.KindTxMeta:
 		// Tx meta currently unused but must be acked.
 		return nil
 	default:
@@ -6,11 +6,14 @@ import (
 	"time"
 
 	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+	"github.com/rexbrahh/lp-indexer/ingestor/common"
 )
 
 type stubWriter struct {
-	trades []Trade
-	flush  int
+	trades  []Trade
+	mev     []MevEvent
+	flush   int
+	deleted []uint64
 }
 
 func (s *stubWriter) WriteTrades(_ context.Context, trades []Trade) error {
@@ -18,14 +21,31 @@ func (s *stubWriter) WriteTrades(_ context.Context, trades []Trade) error {
 	return nil
 }
 
+func (s *stubWriter) WriteMevEvents(_ context.Context, events []MevEvent) error {
+	s.mev = append(s.mev, events...)
+	return nil
+}
+
 func (s *stubWriter) Flush(_ context.Context) error {
 	s.flush++
 	return nil
 }
 
+func (s *stubWriter) DeleteEstimated(_ context.Context, _ uint16, slot uint64) error {
+	s.deleted = append(s.deleted, slot)
+	kept := s.trades[:0]
+	for _, trade := range s.trades {
+		if trade.Slot != slot || !trade.TsEstimated {
+			kept = append(kept, trade)
+		}
+	}
+	s.trades = kept
+	return nil
+}
+
 func TestProcessorHandlesBlockHeadAndSwap(t *testing.T) {
 	writer := &stubWriter{}
-	proc := newProcessor(writer)
+	proc := newProcessor(writer, common.NewMemorySlotTimeCache(), time.Second)
 
 	head := &dexv1.BlockHead{
 		ChainId: 501,
@@ -33,7 +53,9 @@ func TestProcessorHandlesBlockHeadAndSwap(t *testing.T) {
 		TsSec:   1_700_000_000,
 		Status:  "confirmed",
 	}
-	proc.handleBlockHead(head)
+	if err := proc.handleBlockHead(context.Background(), head); err != nil {
+		t.Fatalf("handleBlockHead error: %v", err)
+	}
 
 	swap := &dexv1.SwapEvent{
 		ChainId:       501,
@@ -49,9 +71,16 @@ func TestProcessorHandlesBlockHeadAndSwap(t *testing.T) {
 		BaseIn:        10,
 		QuoteOut:      20,
 		FeeBps:        30,
+		LpFee:         3,
+		ProtocolFee:   1,
+		Trader:        "wallet",
+		FeePayer:      "payer",
+		PriceQ32:      2 << 32,
 		ReservesBase:  1000,
 		ReservesQuote: 2000,
 		Provisional:   true,
+		PriceUsd:      2,
+		VolumeUsd:     0.00002,
 	}
 
 	if err := proc.handleSwap(context.Background(), swap); err != nil {
@@ -77,13 +106,27 @@ func TestProcessorHandlesBlockHeadAndSwap(t *testing.T) {
 	if trade.ReservesBase != 1000 || trade.ReservesQuote != 2000 {
 		t.Fatalf("unexpected reserves %+v", trade)
 	}
+	if trade.LPFee != 3 || trade.ProtocolFee != 1 {
+		t.Fatalf("unexpected fees lp=%d protocol=%d", trade.LPFee, trade.ProtocolFee)
+	}
+	if trade.Trader != "wallet" || trade.FeePayer != "payer" {
+		t.Fatalf("unexpected attribution trader=%s fee_payer=%s", trade.Trader, trade.FeePayer)
+	}
+	if trade.PriceQ32 != 2<<32 {
+		t.Fatalf("unexpected price_q32 %d", trade.PriceQ32)
+	}
+	if trade.PriceUSD != 2 || trade.VolumeUSD != 0.00002 {
+		t.Fatalf("unexpected usd valuation price=%g volume=%g", trade.PriceUSD, trade.VolumeUSD)
+	}
 }
 
 func TestProcessorHandlesUndo(t *testing.T) {
 	writer := &stubWriter{}
-	proc := newProcessor(writer)
+	proc := newProcessor(writer, common.NewMemorySlotTimeCache(), time.Second)
 
-	proc.handleBlockHead(&dexv1.BlockHead{Slot: 99, TsSec: 1})
+	if err := proc.handleBlockHead(context.Background(), &dexv1.BlockHead{Slot: 99, TsSec: 1}); err != nil {
+		t.Fatalf("handleBlockHead error: %v", err)
+	}
 
 	swap := &dexv1.SwapEvent{
 		ChainId:     501,
@@ -105,3 +148,127 @@ func TestProcessorHandlesUndo(t *testing.T) {
 		t.Fatal("expected undo trade")
 	}
 }
+
+func TestProcessorHandlesMev(t *testing.T) {
+	writer := &stubWriter{}
+	proc := newProcessor(writer, common.NewMemorySlotTimeCache(), time.Second)
+
+	if err := proc.handleBlockHead(context.Background(), &dexv1.BlockHead{Slot: 50, TsSec: 1_700_000_000}); err != nil {
+		t.Fatalf("handleBlockHead error: %v", err)
+	}
+
+	event := &dexv1.MevEvent{
+		ChainId:       501,
+		Slot:          50,
+		Kind:          "sandwich",
+		Attacker:      "attacker",
+		PoolId:        "pool",
+		FrontSig:      "front",
+		FrontIndex:    1,
+		BackSig:       "back",
+		BackIndex:     3,
+		VictimSigs:    []string{"victim"},
+		VictimTraders: []string{"wallet"},
+		ProfitMint:    "quote",
+		ProfitAmount:  -5,
+	}
+	if err := proc.handleMev(context.Background(), event); err != nil {
+		t.Fatalf("handleMev error: %v", err)
+	}
+	if len(writer.mev) != 1 {
+		t.Fatalf("expected 1 mev event, got %d", len(writer.mev))
+	}
+	got := writer.mev[0]
+	if got.Kind != "sandwich" || got.FrontSig != "front" || got.BackSig != "back" {
+		t.Fatalf("unexpected mev fields: %+v", got)
+	}
+	if got.Timestamp != time.Unix(1_700_000_000, 0).UTC() {
+		t.Fatalf("unexpected timestamp %v", got.Timestamp)
+	}
+	if len(got.VictimSigs) != 1 || got.VictimSigs[0] != "victim" || got.ProfitAmount != -5 {
+		t.Fatalf("unexpected victims/profit: %+v", got)
+	}
+
+	if err := proc.handleSwap(context.Background(), &dexv1.SwapEvent{Slot: 50, Sig: "victim", MevVictim: true}); err != nil {
+		t.Fatalf("handleSwap error: %v", err)
+	}
+	if !writer.trades[0].MevVictim {
+		t.Fatal("expected mev_victim flag on trade")
+	}
+}
+
+func TestProcessorEstimatesAndCorrectsMissingBlockTimes(t *testing.T) {
+	ctx := context.Background()
+	writer := &stubWriter{}
+	proc := newProcessor(writer, common.NewMemorySlotTimeCache(), time.Second)
+	now := time.Unix(1_700_000_100, 0)
+	proc.now = func() time.Time { return now }
+
+	for _, head := range []*dexv1.BlockHead{
+		{Slot: 100, TsSec: 1_700_000_000},
+		{Slot: 110, TsSec: 1_700_000_004},
+	} {
+		if err := proc.handleBlockHead(ctx, head); err != nil {
+			t.Fatalf("handleBlockHead error: %v", err)
+		}
+	}
+
+	// A swap whose block head arrives within the grace period is written
+	// once, with the real block time.
+	if err := proc.handleSwap(ctx, &dexv1.SwapEvent{ChainId: 501, Slot: 111, Sig: "on-time"}); err != nil {
+		t.Fatalf("handleSwap error: %v", err)
+	}
+	if len(writer.trades) != 0 {
+		t.Fatalf("expected swap to be held, got %+v", writer.trades)
+	}
+	if err := proc.handleBlockHead(ctx, &dexv1.BlockHead{Slot: 111, TsSec: 1_700_000_005}); err != nil {
+		t.Fatalf("handleBlockHead error: %v", err)
+	}
+	if len(writer.trades) != 1 || writer.trades[0].TsEstimated || writer.trades[0].Timestamp != time.Unix(1_700_000_005, 0).UTC() {
+		t.Fatalf("unexpected trades %+v", writer.trades)
+	}
+
+	// Past the grace period the swap is written with an estimate
+	// interpolated from the neighbouring slots.
+	if err := proc.handleSwap(ctx, &dexv1.SwapEvent{ChainId: 501, Slot: 105, Sig: "late"}); err != nil {
+		t.Fatalf("handleSwap error: %v", err)
+	}
+	now = now.Add(2 * time.Second)
+	if err := proc.release(ctx, proc.grace); err != nil {
+		t.Fatalf("release error: %v", err)
+	}
+	if len(writer.trades) != 2 {
+		t.Fatalf("expected estimated trade to be written, got %+v", writer.trades)
+	}
+	late := writer.trades[1]
+	if !late.TsEstimated || late.Timestamp != time.Unix(1_700_000_002, 0).UTC() {
+		t.Fatalf("unexpected estimated trade %+v", late)
+	}
+
+	// A further swap in the same slot is written straight away.
+	if err := proc.handleSwap(ctx, &dexv1.SwapEvent{ChainId: 501, Slot: 105, Sig: "later", Index: 1}); err != nil {
+		t.Fatalf("handleSwap error: %v", err)
+	}
+	if len(writer.trades) != 3 || !writer.trades[2].TsEstimated {
+		t.Fatalf("expected second estimated trade, got %+v", writer.trades)
+	}
+
+	// The real block time replaces both estimated rows.
+	if err := proc.handleBlockHead(ctx, &dexv1.BlockHead{Slot: 105, TsSec: 1_700_000_003}); err != nil {
+		t.Fatalf("handleBlockHead error: %v", err)
+	}
+	if len(writer.deleted) != 1 || writer.deleted[0] != 105 {
+		t.Fatalf("expected estimated rows for slot 105 to be deleted, got %v", writer.deleted)
+	}
+	if len(writer.trades) != 3 {
+		t.Fatalf("expected 3 trades after correction, got %+v", writer.trades)
+	}
+	for _, trade := range writer.trades[1:] {
+		if trade.TsEstimated || trade.Timestamp != time.Unix(1_700_000_003, 0).UTC() {
+			t.Fatalf("trade not corrected: %+v", trade)
+		}
+	}
+	if len(proc.pending) != 0 {
+		t.Fatalf("expected no pending slots, got %d", len(proc.pending))
+	}
+}
@@ -0,0 +1,141 @@
+package clickhouse
+
+import (
+	"context"
+	"time"
+)
+
+// maxCorrectionAge bounds how long rows written with an estimated timestamp
+// are kept in memory waiting for their slot's block time.
+const maxCorrectionAge = 10 * time.Minute
+
+// pendingSlot collects the rows of a slot whose block time was unknown when
+// they arrived. Rows are held for the grace period, then written with an
+// estimated timestamp and kept so they can be rewritten once the block time
+// arrives.
+type pendingSlot struct {
+	chainID uint16
+	since   time.Time
+	trades  []Trade
+	mev     []MevEvent
+	written bool
+}
+
+// holdTrade stamps trade with its slot's block time and writes it, or holds
+// it until the block time arrives.
+func (p *processor) holdTrade(ctx context.Context, trade Trade) error {
+	if ts, err := p.slotTimes.Get(trade.Slot); err == nil {
+		trade.Timestamp = ts
+		return p.writer.WriteTrades(ctx, []Trade{trade})
+	}
+	ps := p.pendingSlot(trade.ChainID, trade.Slot)
+	ps.trades = append(ps.trades, trade)
+	if !ps.written {
+		return nil
+	}
+	trade.Timestamp = p.estimate(trade.Slot, ps)
+	trade.TsEstimated = true
+	return p.writer.WriteTrades(ctx, []Trade{trade})
+}
+
+// holdMev is holdTrade for MEV events.
+func (p *processor) holdMev(ctx context.Context, ev MevEvent) error {
+	if ts, err := p.slotTimes.Get(ev.Slot); err == nil {
+		ev.Timestamp = ts
+		return p.writer.WriteMevEvents(ctx, []MevEvent{ev})
+	}
+	ps := p.pendingSlot(ev.ChainID, ev.Slot)
+	ps.mev = append(ps.mev, ev)
+	if !ps.written {
+		return nil
+	}
+	ev.Timestamp = p.estimate(ev.Slot, ps)
+	ev.TsEstimated = true
+	return p.writer.WriteMevEvents(ctx, []MevEvent{ev})
+}
+
+func (p *processor) pendingSlot(chainID uint16, slot uint64) *pendingSlot {
+	ps, ok := p.pending[slot]
+	if !ok {
+		ps = &pendingSlot{chainID: chainID, since: p.now()}
+		p.pending[slot] = ps
+	}
+	return ps
+}
+
+// resolveSlot writes the held rows of slot with its block time. Rows already
+// written with an estimate are deleted and rewritten.
+func (p *processor) resolveSlot(ctx context.Context, slot uint64, ts time.Time) error {
+	ps, ok := p.pending[slot]
+	if !ok {
+		return nil
+	}
+	delete(p.pending, slot)
+	if ps.written {
+		if err := p.writer.DeleteEstimated(ctx, ps.chainID, slot); err != nil {
+			return err
+		}
+	}
+	return p.writePending(ctx, ps, ts, false)
+}
+
+// release writes rows held for at least olderThan with estimated timestamps
+// and forgets estimated rows too old to correct.
+func (p *processor) release(ctx context.Context, olderThan time.Duration) error {
+	now := p.now()
+	for slot, ps := range p.pending {
+		age := now.Sub(ps.since)
+		if ps.written {
+			if age >= maxCorrectionAge {
+				delete(p.pending, slot)
+			}
+			continue
+		}
+		if age < olderThan {
+			continue
+		}
+		if err := p.writePending(ctx, ps, p.estimate(slot, ps), true); err != nil {
+			return err
+		}
+		ps.written = true
+	}
+	return nil
+}
+
+// dropSlot writes any rows still held for a dead slot with an estimated
+// timestamp, since no block time will arrive for it.
+func (p *processor) dropSlot(ctx context.Context, slot uint64) error {
+	ps, ok := p.pending[slot]
+	if !ok {
+		return nil
+	}
+	delete(p.pending, slot)
+	if ps.written {
+		return nil
+	}
+	return p.writePending(ctx, ps, p.estimate(slot, ps), true)
+}
+
+func (p *processor) writePending(ctx context.Context, ps *pendingSlot, ts time.Time, estimated bool) error {
+	for i := range ps.trades {
+		ps.trades[i].Timestamp = ts
+		ps.trades[i].TsEstimated = estimated
+	}
+	for i := range ps.mev {
+		ps.mev[i].Timestamp = ts
+		ps.mev[i].TsEstimated = estimated
+	}
+	if err := p.writer.WriteTrades(ctx, ps.trades); err != nil {
+		return err
+	}
+	return p.writer.WriteMevEvents(ctx, ps.mev)
+}
+
+// estimate returns the slot time estimated from neighbouring slots, falling
+// back to when the slot's first row arrived.
+func (p *processor) estimate(slot uint64, ps *pendingSlot) time.Time {
+	if ts, _, err := p.slotTimes.Estimate(slot); err == nil {
+		return ts
+	}
+	return ps.since.UTC().Truncate(time.Second)
+}
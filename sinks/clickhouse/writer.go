@@ -17,6 +17,7 @@ type Config struct {
 	Database         string
 	TradesTable      string
 	CandlesTable     string
+	MevTable         string
 	BatchSize        int
 	FlushInterval    time.Duration
 	MaxRetries       int
@@ -31,6 +32,7 @@ type Writer struct {
 
 	tradesBatch  *tradeBatch
 	candlesBatch *candleBatch
+	mevBatch     *mevBatch
 }
 
 type tradeBatch struct {
@@ -53,8 +55,16 @@ type tradeBatch struct {
 	reservesBase  proto.ColDecimal128
 	reservesQuote proto.ColDecimal128
 	feeBps        proto.ColUInt16
+	lpFee         proto.ColDecimal128
+	protocolFee   proto.ColDecimal128
+	traders       proto.ColStr
+	feePayers     proto.ColStr
+	mevVictims    proto.ColUInt8
 	provisional   proto.ColUInt8
 	isUndo        proto.ColUInt8
+	priceUSD      proto.ColFloat64
+	volumeUSD     proto.ColFloat64
+	tsEstimated   proto.ColUInt8
 	count         int
 }
 
@@ -66,6 +76,8 @@ type candleBatch struct {
 	lows       proto.ColFloat64
 	closes     proto.ColFloat64
 	volumes    proto.ColFloat64
+	priceUSD   proto.ColFloat64
+	volumeUSD  proto.ColFloat64
 	count      int
 }
 
@@ -109,8 +121,16 @@ func NewWithConfig(ctx context.Context, cfg Config) (*Writer, error) {
 			reservesBase:  proto.ColDecimal128{},
 			reservesQuote: proto.ColDecimal128{},
 			feeBps:        proto.ColUInt16{},
+			lpFee:         proto.ColDecimal128{},
+			protocolFee:   proto.ColDecimal128{},
+			traders:       proto.ColStr{},
+			feePayers:     proto.ColStr{},
+			mevVictims:    proto.ColUInt8{},
 			provisional:   proto.ColUInt8{},
 			isUndo:        proto.ColUInt8{},
+			priceUSD:      proto.ColFloat64{},
+			volumeUSD:     proto.ColFloat64{},
+			tsEstimated:   proto.ColUInt8{},
 		},
 		candlesBatch: &candleBatch{
 			timestamps: timestamps,
@@ -120,7 +140,10 @@ func NewWithConfig(ctx context.Context, cfg Config) (*Writer, error) {
 			lows:       proto.ColFloat64{},
 			closes:     proto.ColFloat64{},
 			volumes:    proto.ColFloat64{},
+			priceUSD:   proto.ColFloat64{},
+			volumeUSD:  proto.ColFloat64{},
 		},
+		mevBatch: newMevBatch(),
 	}
 
 	return w, nil
@@ -260,8 +283,18 @@ type Trade struct {
 	ReservesBase  uint64
 	ReservesQuote uint64
 	FeeBps        uint16
+	LPFee         uint64
+	ProtocolFee   uint64
+	Trader        string
+	FeePayer      string
+	MevVictim     bool
 	Provisional   bool
 	IsUndo        bool
+	PriceUSD      float64
+	VolumeUSD     float64
+	// TsEstimated marks a Timestamp estimated from neighbouring slots because
+	// the block time was not known when the trade was written.
+	TsEstimated bool
 }
 
 // WriteTrades adds trades to the batch and flushes if batch size is reached
@@ -286,6 +319,15 @@ func (w *Writer) WriteTrades(ctx context.Context, trades []Trade) error {
 		w.tradesBatch.reservesBase.Append(decimal128FromUint64(trade.ReservesBase))
 		w.tradesBatch.reservesQuote.Append(decimal128FromUint64(trade.ReservesQuote))
 		w.tradesBatch.feeBps.Append(trade.FeeBps)
+		w.tradesBatch.lpFee.Append(decimal128FromUint64(trade.LPFee))
+		w.tradesBatch.protocolFee.Append(decimal128FromUint64(trade.ProtocolFee))
+		w.tradesBatch.traders.Append(trade.Trader)
+		w.tradesBatch.feePayers.Append(trade.FeePayer)
+		if trade.MevVictim {
+			w.tradesBatch.mevVictims.Append(1)
+		} else {
+			w.tradesBatch.mevVictims.Append(0)
+		}
 		if trade.Provisional {
 			w.tradesBatch.provisional.Append(1)
 		} else {
@@ -296,6 +338,13 @@ func (w *Writer) WriteTrades(ctx context.Context, trades []Trade) error {
 		} else {
 			w.tradesBatch.isUndo.Append(0)
 		}
+		w.tradesBatch.priceUSD.Append(trade.PriceUSD)
+		w.tradesBatch.volumeUSD.Append(trade.VolumeUSD)
+		if trade.TsEstimated {
+			w.tradesBatch.tsEstimated.Append(1)
+		} else {
+			w.tradesBatch.tsEstimated.Append(0)
+		}
 		w.tradesBatch.count++
 
 		if w.tradesBatch.count >= w.config.BatchSize {
@@ -317,6 +366,8 @@ type Candle struct {
 	Low       float64
 	Close     float64
 	Volume    float64
+	PriceUSD  float64
+	VolumeUSD float64
 }
 
 // WriteCandles adds candles to the batch and flushes if batch size is reached
@@ -329,6 +380,8 @@ func (w *Writer) WriteCandles(ctx context.Context, candles []Candle) error {
 		w.candlesBatch.lows.Append(candle.Low)
 		w.candlesBatch.closes.Append(candle.Close)
 		w.candlesBatch.volumes.Append(candle.Volume)
+		w.candlesBatch.priceUSD.Append(candle.PriceUSD)
+		w.candlesBatch.volumeUSD.Append(candle.VolumeUSD)
 		w.candlesBatch.count++
 
 		if w.candlesBatch.count >= w.config.BatchSize {
@@ -367,8 +420,16 @@ func (w *Writer) flushTrades(ctx context.Context) error {
 		{Name: "reserves_base", Data: proto.Alias(&w.tradesBatch.reservesBase, proto.ColumnTypeDecimal.With("38", "0"))},
 		{Name: "reserves_quote", Data: proto.Alias(&w.tradesBatch.reservesQuote, proto.ColumnTypeDecimal.With("38", "0"))},
 		{Name: "fee_bps", Data: w.tradesBatch.feeBps},
+		{Name: "lp_fee", Data: proto.Alias(&w.tradesBatch.lpFee, proto.ColumnTypeDecimal.With("38", "0"))},
+		{Name: "protocol_fee", Data: proto.Alias(&w.tradesBatch.protocolFee, proto.ColumnTypeDecimal.With("38", "0"))},
+		{Name: "trader", Data: w.tradesBatch.traders},
+		{Name: "fee_payer", Data: w.tradesBatch.feePayers},
+		{Name: "mev_victim", Data: w.tradesBatch.mevVictims},
 		{Name: "provisional", Data: w.tradesBatch.provisional},
 		{Name: "is_undo", Data: w.tradesBatch.isUndo},
+		{Name: "price_usd", Data: w.tradesBatch.priceUSD},
+		{Name: "volume_usd", Data: w.tradesBatch.volumeUSD},
+		{Name: "ts_estimated", Data: w.tradesBatch.tsEstimated},
 	}
 
 	if err := w.client.Do(ctx, ch.Query{
@@ -400,8 +461,16 @@ func (w *Writer) flushTrades(ctx context.Context) error {
 	w.tradesBatch.reservesBase = proto.ColDecimal128{}
 	w.tradesBatch.reservesQuote = proto.ColDecimal128{}
 	w.tradesBatch.feeBps = proto.ColUInt16{}
+	w.tradesBatch.lpFee = proto.ColDecimal128{}
+	w.tradesBatch.protocolFee = proto.ColDecimal128{}
+	w.tradesBatch.traders = proto.ColStr{}
+	w.tradesBatch.feePayers = proto.ColStr{}
+	w.tradesBatch.mevVictims = proto.ColUInt8{}
 	w.tradesBatch.provisional = proto.ColUInt8{}
 	w.tradesBatch.isUndo = proto.ColUInt8{}
+	w.tradesBatch.priceUSD = proto.ColFloat64{}
+	w.tradesBatch.volumeUSD = proto.ColFloat64{}
+	w.tradesBatch.tsEstimated = proto.ColUInt8{}
 	w.tradesBatch.count = 0
 
 	return nil
@@ -421,6 +490,8 @@ func (w *Writer) flushCandles(ctx context.Context) error {
 		{Name: "low", Data: w.candlesBatch.lows},
 		{Name: "close", Data: w.candlesBatch.closes},
 		{Name: "volume", Data: w.candlesBatch.volumes},
+		{Name: "price_usd", Data: w.candlesBatch.priceUSD},
+		{Name: "volume_usd", Data: w.candlesBatch.volumeUSD},
 	}
 
 	if err := w.client.Do(ctx, ch.Query{
@@ -440,6 +511,8 @@ func (w *Writer) flushCandles(ctx context.Context) error {
 	w.candlesBatch.lows = proto.ColFloat64{}
 	w.candlesBatch.closes = proto.ColFloat64{}
 	w.candlesBatch.volumes = proto.ColFloat64{}
+	w.candlesBatch.priceUSD = proto.ColFloat64{}
+	w.candlesBatch.volumeUSD = proto.ColFloat64{}
 	w.candlesBatch.count = 0
 
 	return nil
@@ -450,9 +523,33 @@ func (w *Writer) Flush(ctx context.Context) error {
 	if err := w.flushTrades(ctx); err != nil {
 		return err
 	}
+	if err := w.flushMev(ctx); err != nil {
+		return err
+	}
 	return w.flushCandles(ctx)
 }
 
+// DeleteEstimated removes the trades and MEV events of a slot that were
+// written with an estimated timestamp, so they can be rewritten with the real
+// block time. Pending batches are flushed first.
+func (w *Writer) DeleteEstimated(ctx context.Context, chainID uint16, slot uint64) error {
+	if err := w.Flush(ctx); err != nil {
+		return err
+	}
+	tables := []string{w.config.TradesTable}
+	if w.config.MevTable != "" {
+		tables = append(tables, w.config.MevTable)
+	}
+	for _, table := range tables {
+		if err := w.client.Do(ctx, ch.Query{
+			Body: fmt.Sprintf("DELETE FROM %s WHERE chain_id = %d AND slot = %d AND ts_estimated = 1", table, chainID, slot),
+		}); err != nil {
+			return fmt.Errorf("delete estimated rows from %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
 func decimal128FromUint64(v uint64) proto.Decimal128 {
 	return proto.Decimal128(proto.Int128FromUInt64(v))
 }
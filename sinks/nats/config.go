@@ -14,6 +14,8 @@ const (
 	envNATSStream      = "NATS_STREAM"
 	envNATSSubjectRoot = "NATS_SUBJECT_ROOT"
 	envPublishTimeout  = "NATS_PUBLISH_TIMEOUT_MS"
+	envProgramsPath    = "PROGRAMS_YAML_PATH"
+	envStreamConfig    = "NATS_STREAM_CONFIG_PATH"
 )
 
 // Config captures the runtime parameters for the JetStream publisher.
@@ -22,6 +24,17 @@ type Config struct {
 	Stream         string
 	SubjectRoot    string
 	PublishTimeout time.Duration
+	// Programs maps program names from programs.yaml to program IDs; the
+	// name is the {name} subject token.
+	Programs map[string]string
+	// SubjectTemplates overrides the subject template of an event kind, for
+	// example {"swap": "{root}.{dex}.swap.{pair}"}.
+	SubjectTemplates map[string]string
+	// StreamConfigPath, when set, names a stream definition such as
+	// ops/jetstream/streams.dex.json that every template must be captured by.
+	StreamConfigPath string
+	// Outbox, when set, spools events to disk while JetStream is unreachable.
+	Outbox *OutboxConfig
 }
 
 // DefaultConfig initialises Config with defaults for optional fields.
@@ -46,9 +59,22 @@ func (c Config) Validate() error {
 	if c.PublishTimeout <= 0 {
 		return fmt.Errorf("publish timeout must be positive")
 	}
+	if _, err := c.Subjects(); err != nil {
+		return err
+	}
+	if c.Outbox != nil {
+		if err := c.Outbox.Validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// Subjects compiles the configured subject templates.
+func (c Config) Subjects() (*Subjects, error) {
+	return NewSubjects(c.SubjectRoot, c.Programs, c.SubjectTemplates)
+}
+
 // FromEnv constructs a Config from environment variables.
 func FromEnv() (Config, error) {
 	cfg := DefaultConfig()
@@ -68,5 +94,19 @@ func FromEnv() (Config, error) {
 		}
 		cfg.PublishTimeout = time.Duration(ms) * time.Millisecond
 	}
+	if v := os.Getenv(envProgramsPath); v != "" {
+		programs, templates, err := LoadProgramsFile(v)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.Programs = programs
+		cfg.SubjectTemplates = templates
+	}
+	cfg.StreamConfigPath = os.Getenv(envStreamConfig)
+	outbox, err := OutboxConfigFromEnv()
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.Outbox = outbox
 	return cfg, cfg.Validate()
 }
@@ -0,0 +1,805 @@
+package natsx
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	nats "github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/rexbrahh/lp-indexer/observability"
+)
+
+const (
+	defaultOutboxSegmentBytes = 64 << 20
+	defaultOutboxMaxBytes     = 1 << 30
+
+	envOutboxDir          = "NATS_OUTBOX_DIR"
+	envOutboxSegmentBytes = "NATS_OUTBOX_SEGMENT_BYTES"
+	envOutboxMaxBytes     = "NATS_OUTBOX_MAX_BYTES"
+	envOutboxFsync        = "NATS_OUTBOX_FSYNC"
+
+	outboxSegmentExt  = ".seg"
+	outboxCorruptExt  = ".corrupt"
+	outboxCursorFile  = "cursor"
+	outboxFrameHeader = 8 // payload length + CRC-32
+	// outboxMinPayload is the enqueue time and three empty length-prefixed
+	// strings.
+	outboxMinPayload = 8 + 3*2
+)
+
+// ErrOutboxFull is returned when an append would exceed OutboxConfig.MaxBytes.
+var ErrOutboxFull = errors.New("outbox full")
+
+// OutboxConfig configures the disk-backed outbox that holds events while
+// JetStream is unreachable.
+type OutboxConfig struct {
+	Dir string
+	// SegmentBytes is the size at which the log rolls over to a new segment
+	// file. Drained segments are deleted whole.
+	SegmentBytes int64
+	// MaxBytes caps the undrained log; appends beyond it fail.
+	MaxBytes int64
+	// Fsync syncs every append. Without it a process crash loses nothing but
+	// a host crash can lose the most recent appends.
+	Fsync bool
+}
+
+// DefaultOutboxConfig returns an OutboxConfig with default limits.
+func DefaultOutboxConfig() OutboxConfig {
+	return OutboxConfig{
+		SegmentBytes: defaultOutboxSegmentBytes,
+		MaxBytes:     defaultOutboxMaxBytes,
+	}
+}
+
+// Validate ensures the outbox has a directory and sane limits.
+func (c OutboxConfig) Validate() error {
+	if c.Dir == "" {
+		return fmt.Errorf("outbox dir is required")
+	}
+	if c.SegmentBytes <= outboxFrameHeader {
+		return fmt.Errorf("outbox segment bytes must be positive")
+	}
+	if c.MaxBytes < c.SegmentBytes {
+		return fmt.Errorf("outbox max bytes must be at least the segment size")
+	}
+	return nil
+}
+
+// OutboxConfigFromEnv reads the outbox settings. It returns nil when
+// NATS_OUTBOX_DIR is unset, leaving the outbox disabled.
+func OutboxConfigFromEnv() (*OutboxConfig, error) {
+	dir := os.Getenv(envOutboxDir)
+	if dir == "" {
+		return nil, nil
+	}
+	cfg := DefaultOutboxConfig()
+	cfg.Dir = dir
+	if v := os.Getenv(envOutboxSegmentBytes); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", envOutboxSegmentBytes, err)
+		}
+		cfg.SegmentBytes = n
+	}
+	if v := os.Getenv(envOutboxMaxBytes); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", envOutboxMaxBytes, err)
+		}
+		cfg.MaxBytes = n
+	}
+	if v := os.Getenv(envOutboxFsync); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", envOutboxFsync, err)
+		}
+		cfg.Fsync = enabled
+	}
+	return &cfg, cfg.Validate()
+}
+
+// outboxRecord is one spooled message.
+type outboxRecord struct {
+	Subject  string
+	MsgID    string
+	Kind     string
+	Data     []byte
+	Enqueued time.Time
+
+	size int64
+}
+
+func (r *outboxRecord) msg() *nats.Msg {
+	return newMsg(r.Kind, r.Subject, r.Data, r.MsgID)
+}
+
+// Outbox is an append-only log of messages split into segment files named
+// by sequence. A cursor file records how far the head segment has been
+// drained, so records survive restarts; a record may be sent twice around a
+// crash, which the stream's duplicate window absorbs through Nats-Msg-Id.
+type Outbox struct {
+	cfg OutboxConfig
+
+	mu       sync.Mutex
+	segments []uint64
+	lastSeq  uint64
+	head     *os.File
+	offset   int64
+	tail     *os.File
+	tailSize int64
+	cursor   *os.File
+	depth    int
+	bytes    int64
+	notify   chan struct{}
+
+	spooled, drained, dropped uint64
+}
+
+// OpenOutbox opens or creates the outbox in cfg.Dir and recovers its state,
+// truncating a record left half-written by a crash.
+func OpenOutbox(cfg OutboxConfig) (*Outbox, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create outbox dir: %w", err)
+	}
+	o := &Outbox{cfg: cfg, notify: make(chan struct{}, 1)}
+
+	entries, err := os.ReadDir(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("read outbox dir: %w", err)
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasSuffix(name, outboxSegmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, outboxSegmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		o.segments = append(o.segments, seq)
+	}
+	sort.Slice(o.segments, func(i, j int) bool { return o.segments[i] < o.segments[j] })
+
+	o.cursor, err = os.OpenFile(filepath.Join(cfg.Dir, outboxCursorFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open outbox cursor: %w", err)
+	}
+	if err := o.recover(); err != nil {
+		o.Close()
+		return nil, err
+	}
+	return o, nil
+}
+
+func (o *Outbox) segmentPath(seq uint64) string {
+	return filepath.Join(o.cfg.Dir, fmt.Sprintf("%020d%s", seq, outboxSegmentExt))
+}
+
+// recover drops segments the cursor has passed, counts the undrained records
+// and opens the head and tail segments.
+func (o *Outbox) recover() error {
+	var buf [16]byte
+	var cursorSeg uint64
+	if n, _ := o.cursor.ReadAt(buf[:], 0); n == len(buf) {
+		cursorSeg = binary.BigEndian.Uint64(buf[:8])
+		o.offset = int64(binary.BigEndian.Uint64(buf[8:]))
+	}
+	for len(o.segments) > 0 && o.segments[0] < cursorSeg {
+		if err := os.Remove(o.segmentPath(o.segments[0])); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove drained segment: %w", err)
+		}
+		o.segments = o.segments[1:]
+	}
+	if len(o.segments) == 0 || o.segments[0] != cursorSeg {
+		o.offset = 0
+	}
+	o.lastSeq = cursorSeg
+	if n := len(o.segments); n > 0 {
+		o.lastSeq = o.segments[n-1]
+	}
+
+	for i, seq := range o.segments {
+		f, err := os.OpenFile(o.segmentPath(seq), os.O_RDWR, 0o644)
+		if err != nil {
+			return fmt.Errorf("open outbox segment: %w", err)
+		}
+		start := int64(0)
+		if i == 0 {
+			start = o.offset
+		}
+		end, count, err := scanSegment(f, start)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("stat outbox segment: %w", err)
+		}
+		if end < info.Size() {
+			var kept int
+			f, end, kept, err = o.repairSegment(seq, f, end, info.Size(), i == len(o.segments)-1)
+			if err != nil {
+				return err
+			}
+			count += kept
+		}
+		o.depth += count
+		o.bytes += end - start
+		if i == 0 {
+			o.head = f
+		}
+		if i == len(o.segments)-1 {
+			o.tail = f
+			o.tailSize = end
+		} else if i != 0 {
+			f.Close()
+		}
+	}
+	if o.tail == nil {
+		return o.rollLocked()
+	}
+	return nil
+}
+
+// scanSegment walks the valid records from start and returns where they end
+// and how many there are.
+func scanSegment(f *os.File, start int64) (int64, int, error) {
+	offset, count := start, 0
+	for {
+		rec, err := readRecord(f, offset)
+		if err == io.EOF || errors.Is(err, errTornRecord) {
+			return offset, count, nil
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		offset += rec.size
+		count++
+	}
+}
+
+// repairSegment handles the bytes after the last intact record of a segment,
+// which start at end. In the last segment, bytes with no intact record after
+// them are a write torn by a crash and are truncated. Any other unreadable
+// span is quarantined and the records after it are kept. It returns the
+// segment's handle, its new end and the number of records kept; f is closed
+// on error.
+func (o *Outbox) repairSegment(seq uint64, f *os.File, end, size int64, last bool) (*os.File, int64, int, error) {
+	path := o.segmentPath(seq)
+	rest := make([]byte, size-end)
+	if _, err := f.ReadAt(rest, end); err != nil && err != io.EOF {
+		f.Close()
+		return nil, 0, 0, fmt.Errorf("read outbox segment: %w", err)
+	}
+	if last && nextFrame(rest, 0) < 0 {
+		log.Printf("outbox: truncating %s at %d (torn record)", path, end)
+		if err := f.Truncate(end); err != nil {
+			f.Close()
+			return nil, 0, 0, fmt.Errorf("truncate outbox segment: %w", err)
+		}
+		return f, end, 0, nil
+	}
+
+	var kept []byte
+	count := 0
+	for pos := 0; pos < len(rest); {
+		if n := frameLen(rest, pos); n > 0 {
+			kept = append(kept, rest[pos:pos+n]...)
+			count++
+			pos += n
+			continue
+		}
+		next := nextFrame(rest, pos+1)
+		if next < 0 {
+			if last {
+				log.Printf("outbox: dropping torn record at %s:%d", path, end+int64(pos))
+				break
+			}
+			next = len(rest)
+		}
+		if err := o.quarantine(seq, end+int64(pos), rest[pos:next]); err != nil {
+			f.Close()
+			return nil, 0, 0, err
+		}
+		o.dropped++
+		pos = next
+	}
+
+	// Rewrite the segment without the corrupt spans. The cursor offset lies
+	// before end, so it stays valid.
+	data := make([]byte, end, end+int64(len(kept)))
+	if _, err := f.ReadAt(data, 0); err != nil && err != io.EOF {
+		f.Close()
+		return nil, 0, 0, fmt.Errorf("read outbox segment: %w", err)
+	}
+	data = append(data, kept...)
+	f.Close()
+	tmp := path + ".tmp"
+	if err := writeFileSync(tmp, data); err != nil {
+		return nil, 0, 0, fmt.Errorf("rewrite outbox segment: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return nil, 0, 0, fmt.Errorf("rewrite outbox segment: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("open outbox segment: %w", err)
+	}
+	return f, int64(len(data)), count, nil
+}
+
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// rollLocked starts a new tail segment.
+func (o *Outbox) rollLocked() error {
+	seq := o.lastSeq + 1
+	f, err := os.OpenFile(o.segmentPath(seq), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("create outbox segment: %w", err)
+	}
+	if o.tail != nil && o.tail != o.head {
+		if err := o.tail.Sync(); err != nil {
+			log.Printf("outbox: sync segment: %v", err)
+		}
+		o.tail.Close()
+	}
+	o.segments = append(o.segments, seq)
+	o.lastSeq = seq
+	o.tail = f
+	o.tailSize = 0
+	if o.head == nil {
+		o.head = f
+		o.offset = 0
+		return o.writeCursorLocked()
+	}
+	return nil
+}
+
+// Append adds a message to the end of the log.
+func (o *Outbox) Append(msg *nats.Msg) error {
+	frame := encodeRecord(msg, time.Now())
+	size := int64(len(frame))
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.tail == nil {
+		return errors.New("outbox closed")
+	}
+	if o.bytes+size > o.cfg.MaxBytes {
+		return ErrOutboxFull
+	}
+	if o.tailSize > 0 && o.tailSize+size > o.cfg.SegmentBytes {
+		if err := o.rollLocked(); err != nil {
+			return err
+		}
+	}
+	if _, err := o.tail.WriteAt(frame, o.tailSize); err != nil {
+		return fmt.Errorf("append outbox record: %w", err)
+	}
+	if o.cfg.Fsync {
+		if err := o.tail.Sync(); err != nil {
+			return fmt.Errorf("sync outbox: %w", err)
+		}
+	}
+	o.tailSize += size
+	o.bytes += size
+	o.depth++
+	o.spooled++
+
+	select {
+	case o.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Len returns the number of undrained records.
+func (o *Outbox) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.depth
+}
+
+// Notify is signalled after each append.
+func (o *Outbox) Notify() <-chan struct{} {
+	return o.notify
+}
+
+// Peek returns the oldest undrained record without removing it.
+func (o *Outbox) Peek() (*outboxRecord, bool, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.peekLocked()
+}
+
+func (o *Outbox) peekLocked() (*outboxRecord, bool, error) {
+	if o.depth == 0 || o.head == nil {
+		return nil, false, nil
+	}
+	for {
+		rec, err := readRecord(o.head, o.offset)
+		if err == nil {
+			return rec, true, nil
+		}
+		if errors.Is(err, errTornRecord) {
+			if err := o.quarantineLocked(); err != nil {
+				return nil, false, err
+			}
+			if o.depth == 0 {
+				return nil, false, nil
+			}
+			continue
+		}
+		if err != io.EOF {
+			return nil, false, err
+		}
+		// The head segment is drained; move on to the next one.
+		if err := o.advanceSegmentLocked(); err != nil {
+			return nil, false, err
+		}
+	}
+}
+
+func (o *Outbox) advanceSegmentLocked() error {
+	if len(o.segments) < 2 {
+		return fmt.Errorf("outbox: %d records past the end of the log", o.depth)
+	}
+	drained := o.segments[0]
+	o.segments = o.segments[1:]
+	next := o.tail
+	if len(o.segments) > 1 {
+		f, err := os.OpenFile(o.segmentPath(o.segments[0]), os.O_RDWR, 0o644)
+		if err != nil {
+			return fmt.Errorf("open outbox segment: %w", err)
+		}
+		next = f
+	}
+	o.head.Close()
+	o.head = next
+	o.offset = 0
+	if err := o.writeCursorLocked(); err != nil {
+		return err
+	}
+	if err := os.Remove(o.segmentPath(drained)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove drained segment: %w", err)
+	}
+	return nil
+}
+
+// quarantineLocked moves a corrupt record at the head of the log into a
+// .corrupt file beside the segments and resumes at the next intact record, or
+// at the end of the segment when none follows. Without it the drain would
+// stall on the record forever.
+func (o *Outbox) quarantineLocked() error {
+	end := o.tailSize
+	if o.head != o.tail {
+		info, err := o.head.Stat()
+		if err != nil {
+			return fmt.Errorf("stat outbox segment: %w", err)
+		}
+		end = info.Size()
+	}
+	data := make([]byte, end-o.offset)
+	if _, err := o.head.ReadAt(data, o.offset); err != nil && err != io.EOF {
+		return fmt.Errorf("read corrupt outbox record: %w", err)
+	}
+	skip := len(data)
+	if next := nextFrame(data, 1); next >= 0 {
+		skip = next
+	}
+	if err := o.quarantine(o.segments[0], o.offset, data[:skip]); err != nil {
+		return err
+	}
+
+	o.offset += int64(skip)
+	o.bytes -= int64(skip)
+	o.dropped++
+	// The corrupt bytes may have held any number of records.
+	depth, err := o.countLocked()
+	if err != nil {
+		return err
+	}
+	o.depth = depth
+	return o.writeCursorLocked()
+}
+
+// quarantine writes corrupt bytes found at offset in segment seq to a
+// .corrupt file beside the segments.
+func (o *Outbox) quarantine(seq uint64, offset int64, data []byte) error {
+	path := filepath.Join(o.cfg.Dir, fmt.Sprintf("%020d-%d%s", seq, offset, outboxCorruptExt))
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("quarantine corrupt outbox record: %w", err)
+	}
+	log.Printf("outbox: moved %d corrupt bytes at %s:%d to %s", len(data), o.segmentPath(seq), offset, path)
+	return nil
+}
+
+// frameLen returns the size of the frame at data[i:] if its length fits and
+// its checksum matches, or 0.
+func frameLen(data []byte, i int) int {
+	if i+outboxFrameHeader > len(data) {
+		return 0
+	}
+	n := int(binary.BigEndian.Uint32(data[i : i+4]))
+	if n < outboxMinPayload || n > len(data)-i-outboxFrameHeader {
+		return 0
+	}
+	payload := data[i+outboxFrameHeader : i+outboxFrameHeader+n]
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(data[i+4:i+8]) {
+		return 0
+	}
+	return outboxFrameHeader + n
+}
+
+// nextFrame returns the offset of the first intact frame in data at or after
+// from, or -1.
+func nextFrame(data []byte, from int) int {
+	for i := from; i+outboxFrameHeader <= len(data); i++ {
+		if frameLen(data, i) > 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// countLocked counts the intact records left in the log.
+func (o *Outbox) countLocked() (int, error) {
+	total := 0
+	for i, seq := range o.segments {
+		f, start := o.head, o.offset
+		if i > 0 {
+			start = 0
+			f = o.tail
+			if i < len(o.segments)-1 {
+				var err error
+				if f, err = os.Open(o.segmentPath(seq)); err != nil {
+					return 0, fmt.Errorf("open outbox segment: %w", err)
+				}
+			}
+		}
+		_, n, err := scanSegment(f, start)
+		if f != o.head && f != o.tail {
+			f.Close()
+		}
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// Ack removes rec, which must be the record Peek returned, from the head of
+// the log after it has been published.
+func (o *Outbox) Ack(rec *outboxRecord) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.drained++
+	return o.removeHeadLocked(rec)
+}
+
+// Drop removes rec like Ack for a record that can never be published.
+func (o *Outbox) Drop(rec *outboxRecord) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.dropped++
+	return o.removeHeadLocked(rec)
+}
+
+func (o *Outbox) removeHeadLocked(rec *outboxRecord) error {
+	o.offset += rec.size
+	o.bytes -= rec.size
+	o.depth--
+	return o.writeCursorLocked()
+}
+
+func (o *Outbox) writeCursorLocked() error {
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], o.segments[0])
+	binary.BigEndian.PutUint64(buf[8:], uint64(o.offset))
+	if _, err := o.cursor.WriteAt(buf[:], 0); err != nil {
+		return fmt.Errorf("write outbox cursor: %w", err)
+	}
+	return nil
+}
+
+// OutboxStats is a snapshot of the outbox.
+type OutboxStats struct {
+	Depth                     int
+	Bytes                     int64
+	Oldest                    time.Time
+	Spooled, Drained, Dropped uint64
+}
+
+// Stats returns the current depth, size, enqueue time of the oldest record
+// and lifetime counters.
+func (o *Outbox) Stats() OutboxStats {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	stats := OutboxStats{
+		Depth:   o.depth,
+		Bytes:   o.bytes,
+		Spooled: o.spooled,
+		Drained: o.drained,
+		Dropped: o.dropped,
+	}
+	if rec, ok, err := o.peekLocked(); err == nil && ok {
+		stats.Oldest = rec.Enqueued
+	}
+	return stats
+}
+
+// Close syncs and closes the log. Undrained records stay on disk.
+func (o *Outbox) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var err error
+	if o.tail != nil {
+		err = o.tail.Sync()
+		o.tail.Close()
+	}
+	if o.head != nil && o.head != o.tail {
+		o.head.Close()
+	}
+	if o.cursor != nil {
+		o.cursor.Close()
+	}
+	o.head, o.tail, o.cursor = nil, nil, nil
+	return err
+}
+
+var errTornRecord = errors.New("torn outbox record")
+
+// encodeRecord frames a message as
+//
+//	u32 payload length | u32 CRC-32 of payload | payload
+//
+// where the payload is the enqueue time in Unix nanoseconds, the subject,
+// message ID and event kind as u16-length-prefixed strings, and the data.
+func encodeRecord(msg *nats.Msg, enqueued time.Time) []byte {
+	subject, msgID, kind := msg.Subject, msg.Header.Get(nats.MsgIdHdr), msg.Header.Get(HeaderEventKind)
+	payloadLen := 8 + 2 + len(subject) + 2 + len(msgID) + 2 + len(kind) + len(msg.Data)
+	frame := make([]byte, outboxFrameHeader, outboxFrameHeader+payloadLen)
+	frame = binary.BigEndian.AppendUint64(frame, uint64(enqueued.UnixNano()))
+	for _, s := range []string{subject, msgID, kind} {
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(s)))
+		frame = append(frame, s...)
+	}
+	frame = append(frame, msg.Data...)
+	binary.BigEndian.PutUint32(frame[0:4], uint32(payloadLen))
+	binary.BigEndian.PutUint32(frame[4:8], crc32.ChecksumIEEE(frame[outboxFrameHeader:]))
+	return frame
+}
+
+// readRecord decodes the record at offset. It returns io.EOF at the end of
+// the segment and errTornRecord for a truncated or corrupt record.
+func readRecord(f *os.File, offset int64) (*outboxRecord, error) {
+	var header [outboxFrameHeader]byte
+	n, err := f.ReadAt(header[:], offset)
+	if n == 0 && err == io.EOF {
+		return nil, io.EOF
+	}
+	if n < len(header) {
+		if err == io.EOF {
+			return nil, errTornRecord
+		}
+		return nil, fmt.Errorf("read outbox record: %w", err)
+	}
+	// Check the length against the segment before trusting it with an
+	// allocation.
+	length := int64(binary.BigEndian.Uint32(header[0:4]))
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat outbox segment: %w", err)
+	}
+	if length > info.Size()-offset-outboxFrameHeader {
+		return nil, errTornRecord
+	}
+	payload := make([]byte, length)
+	if n, err := f.ReadAt(payload, offset+outboxFrameHeader); n < len(payload) {
+		if err == io.EOF {
+			return nil, errTornRecord
+		}
+		return nil, fmt.Errorf("read outbox record: %w", err)
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, errTornRecord
+	}
+
+	rec := &outboxRecord{size: int64(outboxFrameHeader + len(payload))}
+	if len(payload) < 8 {
+		return nil, errTornRecord
+	}
+	rec.Enqueued = time.Unix(0, int64(binary.BigEndian.Uint64(payload[:8])))
+	rest := payload[8:]
+	fields := make([]string, 3)
+	for i := range fields {
+		if len(rest) < 2 {
+			return nil, errTornRecord
+		}
+		l := int(binary.BigEndian.Uint16(rest[:2]))
+		if len(rest) < 2+l {
+			return nil, errTornRecord
+		}
+		fields[i] = string(rest[2 : 2+l])
+		rest = rest[2+l:]
+	}
+	rec.Subject, rec.MsgID, rec.Kind = fields[0], fields[1], fields[2]
+	rec.Data = rest
+	return rec, nil
+}
+
+// outboxCollector reports outbox metrics at scrape time, so the age of the
+// oldest record keeps rising while nothing drains.
+type outboxCollector struct {
+	outbox                    *Outbox
+	depth, bytes, age         *prometheus.Desc
+	spooled, drained, dropped *prometheus.Desc
+}
+
+func newOutboxCollector(o *Outbox) *outboxCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName("dex", "", name), help, nil, nil)
+	}
+	return &outboxCollector{
+		outbox:  o,
+		depth:   desc(observability.MetricPublisherOutboxDepth, "Events waiting in the publisher outbox."),
+		bytes:   desc(observability.MetricPublisherOutboxBytes, "Bytes of undrained events in the publisher outbox."),
+		age:     desc(observability.MetricPublisherOutboxAge, "Age of the oldest event in the publisher outbox; 0 when empty."),
+		spooled: desc(observability.MetricPublisherOutboxSpooled, "Events written to the outbox instead of being published."),
+		drained: desc(observability.MetricPublisherOutboxDrained, "Outbox events published after JetStream recovered."),
+		dropped: desc(observability.MetricPublisherOutboxDropped, "Outbox events dropped because JetStream rejected them."),
+	}
+}
+
+func (c *outboxCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{c.depth, c.bytes, c.age, c.spooled, c.drained, c.dropped} {
+		ch <- d
+	}
+}
+
+func (c *outboxCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.outbox.Stats()
+	var age float64
+	if !stats.Oldest.IsZero() {
+		age = time.Since(stats.Oldest).Seconds()
+	}
+	ch <- prometheus.MustNewConstMetric(c.depth, prometheus.GaugeValue, float64(stats.Depth))
+	ch <- prometheus.MustNewConstMetric(c.bytes, prometheus.GaugeValue, float64(stats.Bytes))
+	ch <- prometheus.MustNewConstMetric(c.age, prometheus.GaugeValue, age)
+	ch <- prometheus.MustNewConstMetric(c.spooled, prometheus.CounterValue, float64(stats.Spooled))
+	ch <- prometheus.MustNewConstMetric(c.drained, prometheus.CounterValue, float64(stats.Drained))
+	ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(stats.Dropped))
+}
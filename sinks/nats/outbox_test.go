@@ -0,0 +1,438 @@
+package natsx
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	server "github.com/nats-io/nats-server/v2/server"
+	nats "github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus"
+
+	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+)
+
+func testOutboxConfig(dir string) OutboxConfig {
+	cfg := DefaultOutboxConfig()
+	cfg.Dir = dir
+	cfg.SegmentBytes = 256
+	cfg.MaxBytes = 1 << 20
+	return cfg
+}
+
+func drainN(t *testing.T, o *Outbox, n int) []string {
+	t.Helper()
+	var ids []string
+	for i := 0; i < n; i++ {
+		rec, ok, err := o.Peek()
+		if err != nil || !ok {
+			t.Fatalf("Peek() = %v, %v after %d records", ok, err, i)
+		}
+		if err := o.Ack(rec); err != nil {
+			t.Fatalf("Ack() error = %v", err)
+		}
+		ids = append(ids, rec.MsgID)
+	}
+	return ids
+}
+
+func TestOutboxOrderAcrossSegmentsAndRestarts(t *testing.T) {
+	dir := t.TempDir()
+	o, err := OpenOutbox(testOutboxConfig(dir))
+	if err != nil {
+		t.Fatalf("OpenOutbox() error = %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		msg := newMsg(KindSwap, "dex.sol.raydium_clmm.swap", []byte(fmt.Sprintf("payload-%d", i)), fmt.Sprintf("501:%d", i))
+		if err := o.Append(msg); err != nil {
+			t.Fatalf("Append(%d) error = %v", i, err)
+		}
+	}
+	segments, _ := filepath.Glob(filepath.Join(dir, "*"+outboxSegmentExt))
+	if len(segments) < 3 {
+		t.Fatalf("expected the log to roll over, got %d segments", len(segments))
+	}
+
+	if ids := drainN(t, o, 8); ids[0] != "501:0" || ids[7] != "501:7" {
+		t.Fatalf("drained %v", ids)
+	}
+	if err := o.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// A restart resumes at the cursor.
+	o, err = OpenOutbox(testOutboxConfig(dir))
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	if o.Len() != 12 {
+		t.Fatalf("Len() after restart = %d, want 12", o.Len())
+	}
+	rec, _, _ := o.Peek()
+	if rec.MsgID != "501:8" || rec.Kind != KindSwap || string(rec.Data) != "payload-8" {
+		t.Fatalf("head after restart = %+v", rec)
+	}
+	drainN(t, o, 12)
+	if _, ok, _ := o.Peek(); ok {
+		t.Fatal("outbox not empty")
+	}
+	if remaining, _ := filepath.Glob(filepath.Join(dir, "*"+outboxSegmentExt)); len(remaining) != 1 {
+		t.Fatalf("drained segments not removed: %v", remaining)
+	}
+	o.Close()
+}
+
+func TestOutboxTruncatesTornRecord(t *testing.T) {
+	dir := t.TempDir()
+	o, err := OpenOutbox(testOutboxConfig(dir))
+	if err != nil {
+		t.Fatalf("OpenOutbox() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := o.Append(newMsg(KindBlockHead, "dex.sol.blocks.head", []byte{byte(i)}, fmt.Sprintf("501:%d", i))); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	o.Close()
+
+	// Simulate a crash halfway through a third append.
+	segments, _ := filepath.Glob(filepath.Join(dir, "*"+outboxSegmentExt))
+	f, err := os.OpenFile(segments[len(segments)-1], os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := encodeRecord(newMsg(KindBlockHead, "dex.sol.blocks.head", []byte{2}, "501:2"), time.Now())
+	if _, err := f.Write(frame[:len(frame)-3]); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	o, err = OpenOutbox(testOutboxConfig(dir))
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	defer o.Close()
+	if o.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", o.Len())
+	}
+	if err := o.Append(newMsg(KindBlockHead, "dex.sol.blocks.head", []byte{3}, "501:3")); err != nil {
+		t.Fatalf("Append() after recovery error = %v", err)
+	}
+	if ids := drainN(t, o, 3); ids[2] != "501:3" {
+		t.Fatalf("drained %v", ids)
+	}
+}
+
+func TestOutboxQuarantinesCorruptRecord(t *testing.T) {
+	cases := []struct {
+		name string
+		at   int // byte of the second frame to flip
+	}{
+		{name: "payload", at: outboxFrameHeader + 3},
+		{name: "length", at: 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			o, err := OpenOutbox(testOutboxConfig(dir))
+			if err != nil {
+				t.Fatalf("OpenOutbox() error = %v", err)
+			}
+			defer o.Close()
+			var frameSize int
+			for i := 0; i < 3; i++ {
+				msg := newMsg(KindBlockHead, "dex.sol.blocks.head", []byte{byte(i)}, fmt.Sprintf("501:%d", i))
+				frameSize = len(encodeRecord(msg, time.Now()))
+				if err := o.Append(msg); err != nil {
+					t.Fatalf("Append() error = %v", err)
+				}
+			}
+			segments, _ := filepath.Glob(filepath.Join(dir, "*"+outboxSegmentExt))
+			if len(segments) != 1 {
+				t.Fatalf("expected one segment, got %v", segments)
+			}
+
+			f, err := os.OpenFile(segments[0], os.O_RDWR, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := make([]byte, 1)
+			offset := int64(frameSize + tc.at)
+			if _, err := f.ReadAt(b, offset); err != nil {
+				t.Fatal(err)
+			}
+			b[0] ^= 0xff
+			if _, err := f.WriteAt(b, offset); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+
+			if ids := drainN(t, o, 1); ids[0] != "501:0" {
+				t.Fatalf("drained %v", ids)
+			}
+			rec, ok, err := o.Peek()
+			if err != nil || !ok {
+				t.Fatalf("Peek() past corrupt record = %v, %v", ok, err)
+			}
+			if rec.MsgID != "501:2" {
+				t.Fatalf("head after corrupt record = %s, want 501:2", rec.MsgID)
+			}
+			if o.Len() != 1 {
+				t.Fatalf("Len() = %d, want 1", o.Len())
+			}
+			if got := o.Stats().Dropped; got != 1 {
+				t.Fatalf("Dropped = %d, want 1", got)
+			}
+			quarantined, _ := filepath.Glob(filepath.Join(dir, "*"+outboxCorruptExt))
+			if len(quarantined) != 1 {
+				t.Fatalf("quarantined files = %v", quarantined)
+			}
+			if info, err := os.Stat(quarantined[0]); err != nil || info.Size() != int64(frameSize) {
+				t.Fatalf("quarantined %v, %v; want %d bytes", info, err, frameSize)
+			}
+			drainN(t, o, 1)
+			if _, ok, _ := o.Peek(); ok {
+				t.Fatal("outbox not empty")
+			}
+		})
+	}
+}
+
+func TestOutboxQuarantinesCorruptRecordOnReopen(t *testing.T) {
+	cases := []struct {
+		name    string
+		corrupt func(frame []byte)
+	}{
+		{name: "payload", corrupt: func(frame []byte) { frame[outboxFrameHeader+3] ^= 0xff }},
+		// A length this large must be rejected before it is allocated.
+		{name: "length", corrupt: func(frame []byte) { copy(frame, []byte{0xff, 0xff, 0xff, 0xff}) }},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			o, err := OpenOutbox(testOutboxConfig(dir))
+			if err != nil {
+				t.Fatalf("OpenOutbox() error = %v", err)
+			}
+			var frameSize int
+			for i := 0; i < 3; i++ {
+				msg := newMsg(KindBlockHead, "dex.sol.blocks.head", []byte{byte(i)}, fmt.Sprintf("501:%d", i))
+				frameSize = len(encodeRecord(msg, time.Now()))
+				if err := o.Append(msg); err != nil {
+					t.Fatalf("Append() error = %v", err)
+				}
+			}
+			o.Close()
+
+			segments, _ := filepath.Glob(filepath.Join(dir, "*"+outboxSegmentExt))
+			if len(segments) != 1 {
+				t.Fatalf("expected one segment, got %v", segments)
+			}
+			data, err := os.ReadFile(segments[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc.corrupt(data[frameSize : 2*frameSize])
+			if err := os.WriteFile(segments[0], data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			o, err = OpenOutbox(testOutboxConfig(dir))
+			if err != nil {
+				t.Fatalf("reopen error = %v", err)
+			}
+			defer o.Close()
+			if o.Len() != 2 {
+				t.Fatalf("Len() = %d, want 2", o.Len())
+			}
+			stats := o.Stats()
+			if stats.Dropped != 1 || stats.Bytes != int64(2*frameSize) {
+				t.Fatalf("Dropped = %d, Bytes = %d; want 1, %d", stats.Dropped, stats.Bytes, 2*frameSize)
+			}
+			quarantined, _ := filepath.Glob(filepath.Join(dir, "*"+outboxCorruptExt))
+			if len(quarantined) != 1 {
+				t.Fatalf("quarantined files = %v", quarantined)
+			}
+			if info, err := os.Stat(segments[0]); err != nil || info.Size() != int64(2*frameSize) {
+				t.Fatalf("segment after repair: %v, %v; want %d bytes", info, err, 2*frameSize)
+			}
+			if ids := drainN(t, o, 2); ids[0] != "501:0" || ids[1] != "501:2" {
+				t.Fatalf("drained %v", ids)
+			}
+
+			// The rewritten segment keeps taking appends.
+			if err := o.Append(newMsg(KindBlockHead, "dex.sol.blocks.head", []byte{3}, "501:3")); err != nil {
+				t.Fatalf("Append() after repair error = %v", err)
+			}
+			if ids := drainN(t, o, 1); ids[0] != "501:3" {
+				t.Fatalf("drained %v", ids)
+			}
+		})
+	}
+}
+
+func TestOutboxQuarantinesCorruptLastRecord(t *testing.T) {
+	dir := t.TempDir()
+	o, err := OpenOutbox(testOutboxConfig(dir))
+	if err != nil {
+		t.Fatalf("OpenOutbox() error = %v", err)
+	}
+	defer o.Close()
+	if err := o.Append(newMsg(KindBlockHead, "dex.sol.blocks.head", []byte{0}, "501:0")); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	segments, _ := filepath.Glob(filepath.Join(dir, "*"+outboxSegmentExt))
+	f, err := os.OpenFile(segments[0], os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteAt([]byte{0xff}, outboxFrameHeader); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if _, ok, err := o.Peek(); ok || err != nil {
+		t.Fatalf("Peek() = %v, %v; want an empty outbox", ok, err)
+	}
+	if o.Len() != 0 || o.Stats().Bytes != 0 {
+		t.Fatalf("Len() = %d, Bytes = %d after quarantine", o.Len(), o.Stats().Bytes)
+	}
+	if err := o.Append(newMsg(KindBlockHead, "dex.sol.blocks.head", []byte{1}, "501:1")); err != nil {
+		t.Fatalf("Append() after quarantine error = %v", err)
+	}
+	if ids := drainN(t, o, 1); ids[0] != "501:1" {
+		t.Fatalf("drained %v", ids)
+	}
+}
+
+func TestOutboxFull(t *testing.T) {
+	cfg := testOutboxConfig(t.TempDir())
+	cfg.MaxBytes = cfg.SegmentBytes
+	o, err := OpenOutbox(cfg)
+	if err != nil {
+		t.Fatalf("OpenOutbox() error = %v", err)
+	}
+	defer o.Close()
+	big := make([]byte, 200)
+	if err := o.Append(newMsg(KindSwap, "s", big, "1")); err != nil {
+		t.Fatalf("first Append() error = %v", err)
+	}
+	if err := o.Append(newMsg(KindSwap, "s", big, "2")); err != ErrOutboxFull {
+		t.Fatalf("second Append() error = %v, want ErrOutboxFull", err)
+	}
+}
+
+func startServer(t *testing.T, port int, storeDir string) *server.Server {
+	t.Helper()
+	srv, err := server.NewServer(&server.Options{JetStream: true, Host: "127.0.0.1", Port: port, StoreDir: storeDir})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	go srv.Start()
+	if !srv.ReadyForConnections(10 * time.Second) {
+		srv.Shutdown()
+		t.Skip("nats-server not ready in sandbox")
+	}
+	return srv
+}
+
+func TestPublisherSpoolsDuringOutage(t *testing.T) {
+	storeDir := t.TempDir()
+	srv := startServer(t, -1, storeDir)
+	url := srv.ClientURL()
+	clientPort := srv.Addr().(*net.TCPAddr).Port
+	// File storage, so the stream survives the restart below.
+	if _, err := jetStreamContext(t, url).AddStream(&nats.StreamConfig{Name: "DEX", Subjects: []string{"dex.sol.>"}, Storage: nats.FileStorage}); err != nil {
+		t.Fatalf("add stream: %v", err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.URL = url
+	cfg.Stream = "DEX"
+	cfg.PublishTimeout = time.Second
+	outboxCfg := testOutboxConfig(t.TempDir())
+	cfg.Outbox = &outboxCfg
+
+	pub, err := NewPublisher(cfg)
+	if err != nil {
+		t.Fatalf("NewPublisher() error = %v", err)
+	}
+	defer pub.Close()
+	reg := prometheus.NewRegistry()
+	if err := pub.RegisterMetrics(reg); err != nil {
+		t.Fatalf("RegisterMetrics() error = %v", err)
+	}
+
+	ctx := context.Background()
+	head := func(slot uint64) *dexv1.BlockHead { return &dexv1.BlockHead{ChainId: 501, Slot: slot} }
+	if err := pub.PublishBlockHead(ctx, head(1)); err != nil {
+		t.Fatalf("publish before outage: %v", err)
+	}
+
+	srv.Shutdown()
+	srv.WaitForShutdown()
+
+	for slot := uint64(2); slot <= 6; slot++ {
+		if err := pub.PublishBlockHead(ctx, head(slot)); err != nil {
+			t.Fatalf("publish during outage: %v", err)
+		}
+	}
+	if depth := pub.outbox.Len(); depth != 5 {
+		t.Fatalf("outbox depth = %d, want 5", depth)
+	}
+	if got := gaugeValue(t, reg, "dex_publisher_outbox_depth"); got != 5 {
+		t.Fatalf("depth gauge = %v, want 5", got)
+	}
+	if got := gaugeValue(t, reg, "dex_publisher_outbox_oldest_age_seconds"); got <= 0 {
+		t.Fatalf("age gauge = %v, want > 0", got)
+	}
+
+	srv = startServer(t, clientPort, storeDir)
+	defer srv.Shutdown()
+
+	deadline := time.Now().Add(15 * time.Second)
+	for pub.outbox.Len() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("outbox not drained, depth %d", pub.outbox.Len())
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	// New events go straight to the stream once the outbox is empty.
+	if err := pub.PublishBlockHead(ctx, head(7)); err != nil {
+		t.Fatalf("publish after recovery: %v", err)
+	}
+
+	js := jetStreamContext(t, srv.ClientURL())
+	sub, err := js.SubscribeSync("dex.sol.blocks.head", nats.BindStream("DEX"), nats.OrderedConsumer(), nats.DeliverAll())
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	for want := uint64(1); want <= 7; want++ {
+		msg, err := sub.NextMsg(5 * time.Second)
+		if err != nil {
+			t.Fatalf("next message %d: %v", want, err)
+		}
+		if got := msg.Header.Get(nats.MsgIdHdr); got != fmt.Sprintf("501:%d", want) {
+			t.Fatalf("message %d has msg id %q", want, got)
+		}
+	}
+}
+
+func gaugeValue(t *testing.T, reg *prometheus.Registry, name string) float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	for _, mf := range families {
+		if mf.GetName() == name {
+			return mf.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	t.Fatalf("metric %s not registered", name)
+	return 0
+}
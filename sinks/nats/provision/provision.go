@@ -0,0 +1,356 @@
+// Package provision applies the JetStream stream and consumer definitions in
+// ops/jetstream through the JetStream management API and reports where the
+// live configuration has drifted from them.
+//
+// Only the fields a definition file declares are compared, so server-side
+// defaults for everything else never count as drift.
+package provision
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	nats "github.com/nats-io/nats.go"
+)
+
+// StreamDef is a stream definition file.
+type StreamDef struct {
+	Path   string
+	Config nats.StreamConfig
+	fields []string
+}
+
+// ConsumerDef is a consumer definition file. Consumers are durable; a file
+// that only sets "name" uses it as the durable name.
+type ConsumerDef struct {
+	Path   string
+	Stream string
+	Config nats.ConsumerConfig
+	fields []string
+}
+
+// Definitions holds every stream and consumer definition in a directory.
+type Definitions struct {
+	Streams   []StreamDef
+	Consumers []ConsumerDef
+}
+
+// LoadDir reads the *.json definitions in dir. Files with a "stream_name" key
+// are consumers; all others are streams. Unknown keys are rejected so a typo
+// cannot silently fall back to a server default.
+func LoadDir(dir string) (*Definitions, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("list definitions: %w", err)
+	}
+	sort.Strings(paths)
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no definitions in %s", dir)
+	}
+
+	defs := &Definitions{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		if _, ok := raw["stream_name"]; ok {
+			def, err := parseConsumer(path, data, raw)
+			if err != nil {
+				return nil, err
+			}
+			defs.Consumers = append(defs.Consumers, def)
+			continue
+		}
+		def, err := parseStream(path, data, raw)
+		if err != nil {
+			return nil, err
+		}
+		defs.Streams = append(defs.Streams, def)
+	}
+	return defs, defs.validate()
+}
+
+func parseStream(path string, data []byte, raw map[string]json.RawMessage) (StreamDef, error) {
+	def := StreamDef{Path: path, fields: keys(raw)}
+	if err := decodeStrict(data, &def.Config); err != nil {
+		return StreamDef{}, fmt.Errorf("parse stream %s: %w", path, err)
+	}
+	if def.Config.Name == "" {
+		return StreamDef{}, fmt.Errorf("stream %s: name is required", path)
+	}
+	return def, nil
+}
+
+func parseConsumer(path string, data []byte, raw map[string]json.RawMessage) (ConsumerDef, error) {
+	var file struct {
+		StreamName string `json:"stream_name"`
+		nats.ConsumerConfig
+	}
+	if err := decodeStrict(data, &file); err != nil {
+		return ConsumerDef{}, fmt.Errorf("parse consumer %s: %w", path, err)
+	}
+	def := ConsumerDef{Path: path, Stream: file.StreamName, Config: file.ConsumerConfig}
+	delete(raw, "stream_name")
+	def.fields = keys(raw)
+	if def.Config.Durable == "" {
+		def.Config.Durable = def.Config.Name
+	}
+	if def.Stream == "" || def.Config.Durable == "" {
+		return ConsumerDef{}, fmt.Errorf("consumer %s: stream_name and name are required", path)
+	}
+	return def, nil
+}
+
+func decodeStrict(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+func keys(raw map[string]json.RawMessage) []string {
+	out := make([]string, 0, len(raw))
+	for k := range raw {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (d *Definitions) validate() error {
+	streams := make(map[string]struct{}, len(d.Streams))
+	for _, s := range d.Streams {
+		if _, dup := streams[s.Config.Name]; dup {
+			return fmt.Errorf("stream %s defined twice", s.Config.Name)
+		}
+		streams[s.Config.Name] = struct{}{}
+	}
+	consumers := make(map[string]struct{}, len(d.Consumers))
+	for _, c := range d.Consumers {
+		if _, ok := streams[c.Stream]; !ok {
+			return fmt.Errorf("consumer %s: stream %s is not defined", c.Path, c.Stream)
+		}
+		key := c.Stream + "/" + c.Config.Durable
+		if _, dup := consumers[key]; dup {
+			return fmt.Errorf("consumer %s defined twice", key)
+		}
+		consumers[key] = struct{}{}
+	}
+	return nil
+}
+
+// Stream returns the definition of the named stream.
+func (d *Definitions) Stream(name string) (StreamDef, bool) {
+	for _, s := range d.Streams {
+		if s.Config.Name == name {
+			return s, true
+		}
+	}
+	return StreamDef{}, false
+}
+
+// Consumer returns the definition of a durable consumer on stream.
+func (d *Definitions) Consumer(stream, name string) (ConsumerDef, bool) {
+	for _, c := range d.Consumers {
+		if c.Stream == stream && c.Config.Durable == name {
+			return c, true
+		}
+	}
+	return ConsumerDef{}, false
+}
+
+// FieldDrift is one declared field whose live value differs.
+type FieldDrift struct {
+	Field string
+	Want  string
+	Got   string
+}
+
+// Drift describes a stream or consumer that is missing or differs from its
+// definition.
+type Drift struct {
+	Kind    string // "stream" or "consumer"
+	Stream  string
+	Name    string
+	Missing bool
+	Fields  []FieldDrift
+}
+
+// ID names the stream, or the consumer as stream/name.
+func (d Drift) ID() string {
+	if d.Kind == "consumer" {
+		return d.Stream + "/" + d.Name
+	}
+	return d.Stream
+}
+
+func (d Drift) String() string {
+	name := d.ID()
+	if d.Missing {
+		return fmt.Sprintf("%s %s: missing", d.Kind, name)
+	}
+	parts := make([]string, len(d.Fields))
+	for i, f := range d.Fields {
+		parts[i] = fmt.Sprintf("%s want %s got %s", f.Field, f.Want, f.Got)
+	}
+	return fmt.Sprintf("%s %s: %s", d.Kind, name, strings.Join(parts, "; "))
+}
+
+// Diff compares every definition with the live configuration and returns the
+// streams and consumers that are missing or drifted.
+func Diff(js nats.JetStreamManager, defs *Definitions) ([]Drift, error) {
+	var drifts []Drift
+	for _, s := range defs.Streams {
+		drift, err := diffStream(js, s)
+		if err != nil {
+			return nil, err
+		}
+		if drift != nil {
+			drifts = append(drifts, *drift)
+		}
+	}
+	for _, c := range defs.Consumers {
+		drift, err := diffConsumer(js, c)
+		if err != nil {
+			return nil, err
+		}
+		if drift != nil {
+			drifts = append(drifts, *drift)
+		}
+	}
+	return drifts, nil
+}
+
+func diffStream(js nats.JetStreamManager, def StreamDef) (*Drift, error) {
+	drift := &Drift{Kind: "stream", Stream: def.Config.Name}
+	info, err := js.StreamInfo(def.Config.Name)
+	if errors.Is(err, nats.ErrStreamNotFound) {
+		drift.Missing = true
+		return drift, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("stream info %s: %w", def.Config.Name, err)
+	}
+	drift.Fields, err = compareFields(def.fields, def.Config, info.Config)
+	if err != nil || len(drift.Fields) == 0 {
+		return nil, err
+	}
+	return drift, nil
+}
+
+func diffConsumer(js nats.JetStreamManager, def ConsumerDef) (*Drift, error) {
+	drift := &Drift{Kind: "consumer", Stream: def.Stream, Name: def.Config.Durable}
+	info, err := js.ConsumerInfo(def.Stream, def.Config.Durable)
+	if errors.Is(err, nats.ErrConsumerNotFound) || errors.Is(err, nats.ErrStreamNotFound) {
+		drift.Missing = true
+		return drift, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("consumer info %s/%s: %w", def.Stream, def.Config.Durable, err)
+	}
+	drift.Fields, err = compareFields(def.fields, def.Config, info.Config)
+	if err != nil || len(drift.Fields) == 0 {
+		return nil, err
+	}
+	return drift, nil
+}
+
+// compareFields compares the JSON encoding of the declared fields, which
+// normalises enums and durations the same way on both sides.
+func compareFields(fields []string, want, got any) ([]FieldDrift, error) {
+	wantFields, err := toFields(want)
+	if err != nil {
+		return nil, err
+	}
+	gotFields, err := toFields(got)
+	if err != nil {
+		return nil, err
+	}
+	var drifts []FieldDrift
+	for _, field := range fields {
+		w, g := wantFields[field], gotFields[field]
+		if reflect.DeepEqual(w, g) {
+			continue
+		}
+		drifts = append(drifts, FieldDrift{Field: field, Want: render(w), Got: render(g)})
+	}
+	return drifts, nil
+}
+
+func toFields(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("encode config: %w", err)
+	}
+	var out map[string]any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("decode config: %w", err)
+	}
+	return out, nil
+}
+
+func render(v any) string {
+	if v == nil {
+		return "<unset>"
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// Apply creates missing streams and consumers and updates drifted ones, then
+// returns what it changed. Streams are applied before consumers. Updating a
+// field the server treats as immutable, such as storage, fails with the
+// server's error.
+func Apply(js nats.JetStreamManager, defs *Definitions) ([]Drift, error) {
+	var applied []Drift
+	for _, s := range defs.Streams {
+		drift, err := diffStream(js, s)
+		if err != nil {
+			return applied, err
+		}
+		if drift == nil {
+			continue
+		}
+		cfg := s.Config
+		if drift.Missing {
+			_, err = js.AddStream(&cfg)
+		} else {
+			_, err = js.UpdateStream(&cfg)
+		}
+		if err != nil {
+			return applied, fmt.Errorf("apply stream %s: %w", s.Config.Name, err)
+		}
+		applied = append(applied, *drift)
+	}
+	for _, c := range defs.Consumers {
+		drift, err := diffConsumer(js, c)
+		if err != nil {
+			return applied, err
+		}
+		if drift == nil {
+			continue
+		}
+		cfg := c.Config
+		if drift.Missing {
+			_, err = js.AddConsumer(c.Stream, &cfg)
+		} else {
+			_, err = js.UpdateConsumer(c.Stream, &cfg)
+		}
+		if err != nil {
+			return applied, fmt.Errorf("apply consumer %s/%s: %w", c.Stream, c.Config.Durable, err)
+		}
+		applied = append(applied, *drift)
+	}
+	return applied, nil
+}
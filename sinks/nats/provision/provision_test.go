@@ -0,0 +1,159 @@
+package provision
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	nats "github.com/nats-io/nats.go"
+)
+
+const opsDir = "../../../ops/jetstream"
+
+func runJetStream(t *testing.T) nats.JetStreamContext {
+	t.Helper()
+	opts := &server.Options{JetStream: true, Host: "127.0.0.1", Port: -1, StoreDir: t.TempDir()}
+	srv, err := server.NewServer(opts)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	go srv.Start()
+	if !srv.ReadyForConnections(10 * time.Second) {
+		srv.Shutdown()
+		t.Skip("nats-server not ready in sandbox")
+	}
+	t.Cleanup(srv.Shutdown)
+
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(nc.Close)
+	js, err := nc.JetStream()
+	if err != nil {
+		t.Fatalf("jetstream: %v", err)
+	}
+	return js
+}
+
+func TestApplyOpsDefinitions(t *testing.T) {
+	js := runJetStream(t)
+	defs, err := LoadDir(opsDir)
+	if err != nil {
+		t.Fatalf("LoadDir() error = %v", err)
+	}
+
+	drifts, err := Diff(js, defs)
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if len(drifts) != len(defs.Streams)+len(defs.Consumers) {
+		t.Fatalf("expected everything missing, got %v", drifts)
+	}
+	if err := Verify(js, defs, "DEX", "SWAP_FIREHOSE"); err == nil {
+		t.Fatal("Verify() passed before provisioning")
+	}
+
+	if _, err := Apply(js, defs); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	// Applying again is a no-op.
+	applied, err := Apply(js, defs)
+	if err != nil || len(applied) != 0 {
+		t.Fatalf("second Apply() = %v, %v", applied, err)
+	}
+	if err := Verify(js, defs, "DEX", "SWAP_FIREHOSE"); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	info, err := js.ConsumerInfo("DEX", "SWAP_FIREHOSE")
+	if err != nil {
+		t.Fatalf("consumer info: %v", err)
+	}
+	if info.Config.Durable != "SWAP_FIREHOSE" || info.Config.MaxAckPending != 50000 {
+		t.Fatalf("unexpected consumer config %+v", info.Config)
+	}
+}
+
+func TestDiffReportsDriftAndApplyRepairs(t *testing.T) {
+	js := runJetStream(t)
+	defs, err := LoadDir(opsDir)
+	if err != nil {
+		t.Fatalf("LoadDir() error = %v", err)
+	}
+	if _, err := Apply(js, defs); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	stream, err := js.StreamInfo("DEX")
+	if err != nil {
+		t.Fatalf("stream info: %v", err)
+	}
+	cfg := stream.Config
+	cfg.Subjects = cfg.Subjects[:len(cfg.Subjects)-1]
+	cfg.MaxMsgs = 10 // undeclared, so not drift
+	if _, err := js.UpdateStream(&cfg); err != nil {
+		t.Fatalf("update stream: %v", err)
+	}
+	consumer, err := js.ConsumerInfo("DEX", "SWAP_FIREHOSE")
+	if err != nil {
+		t.Fatalf("consumer info: %v", err)
+	}
+	ccfg := consumer.Config
+	ccfg.MaxAckPending = 10
+	if _, err := js.UpdateConsumer("DEX", &ccfg); err != nil {
+		t.Fatalf("update consumer: %v", err)
+	}
+
+	drifts, err := Diff(js, defs)
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if len(drifts) != 2 {
+		t.Fatalf("expected 2 drifts, got %v", drifts)
+	}
+	if drifts[0].Kind != "stream" || len(drifts[0].Fields) != 1 || drifts[0].Fields[0].Field != "subjects" {
+		t.Fatalf("unexpected stream drift %v", drifts[0])
+	}
+	if got := drifts[1].String(); got != "consumer DEX/SWAP_FIREHOSE: max_ack_pending want 50000 got 10" {
+		t.Fatalf("unexpected consumer drift %q", got)
+	}
+	err = Verify(js, defs, "DEX", "SWAP_FIREHOSE")
+	if err == nil || !strings.Contains(err.Error(), "max_ack_pending") {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	applied, err := Apply(js, defs)
+	if err != nil || len(applied) != 2 {
+		t.Fatalf("Apply() = %v, %v", applied, err)
+	}
+	if drifts, err := Diff(js, defs); err != nil || len(drifts) != 0 {
+		t.Fatalf("Diff() after Apply = %v, %v", drifts, err)
+	}
+}
+
+func TestLoadDirRejectsBadDefinitions(t *testing.T) {
+	cases := map[string]string{
+		"unknown key":    `{"name": "DEX", "subjects": ["a.>"], "replicas": 3}`,
+		"bad duration":   `{"name": "DEX", "subjects": ["a.>"], "duplicate_window": "2m"}`,
+		"orphan":         `{"stream_name": "OTHER", "name": "c"}`,
+		"unnamed stream": `{"subjects": ["a.>"]}`,
+	}
+	for name, body := range cases {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "def.json"), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if name == "orphan" {
+			stream := `{"name": "DEX", "subjects": ["a.>"]}`
+			if err := os.WriteFile(filepath.Join(dir, "stream.json"), []byte(stream), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := LoadDir(dir); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
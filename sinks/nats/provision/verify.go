@@ -0,0 +1,63 @@
+package provision
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	nats "github.com/nats-io/nats.go"
+)
+
+const envVerifyDir = "JETSTREAM_VERIFY_DIR"
+
+// Verify checks that stream, and consumer when non-empty, are defined in
+// defs, exist, and match their definitions.
+func Verify(js nats.JetStreamManager, defs *Definitions, stream, consumer string) error {
+	var drifts []Drift
+	s, ok := defs.Stream(stream)
+	if !ok {
+		return fmt.Errorf("stream %s has no definition", stream)
+	}
+	drift, err := diffStream(js, s)
+	if err != nil {
+		return err
+	}
+	if drift != nil {
+		drifts = append(drifts, *drift)
+	}
+	if consumer != "" {
+		c, ok := defs.Consumer(stream, consumer)
+		if !ok {
+			return fmt.Errorf("consumer %s/%s has no definition", stream, consumer)
+		}
+		drift, err := diffConsumer(js, c)
+		if err != nil {
+			return err
+		}
+		if drift != nil {
+			drifts = append(drifts, *drift)
+		}
+	}
+	if len(drifts) == 0 {
+		return nil
+	}
+	parts := make([]string, len(drifts))
+	for i, d := range drifts {
+		parts[i] = d.String()
+	}
+	return fmt.Errorf("jetstream drift (run jsprovision to apply): %s", strings.Join(parts, ", "))
+}
+
+// VerifyFromEnv runs Verify against the definitions in JETSTREAM_VERIFY_DIR.
+// It does nothing when the variable is unset.
+func VerifyFromEnv(js nats.JetStreamManager, stream, consumer string) error {
+	dir := os.Getenv(envVerifyDir)
+	if dir == "" {
+		return nil
+	}
+	defs, err := LoadDir(dir)
+	if err != nil {
+		return fmt.Errorf("load jetstream definitions: %w", err)
+	}
+	return Verify(js, defs, stream, consumer)
+}
@@ -4,28 +4,67 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strings"
+	"log"
+	"time"
 
 	nats "github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/protobuf/proto"
 
 	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+	"github.com/rexbrahh/lp-indexer/sinks/nats/provision"
 )
 
 // Publisher wraps a JetStream connection for emitting canonical protobuf events.
 type Publisher struct {
-	cfg  Config
-	conn *nats.Conn
-	js   nats.JetStreamContext
+	cfg      Config
+	subjects *Subjects
+	conn     *nats.Conn
+	js       nats.JetStreamContext
+
+	outbox *Outbox
+	stop   chan struct{}
+	done   chan struct{}
 }
 
-// NewPublisher dials JetStream using the provided configuration.
+// Backoff between attempts to drain the outbox while JetStream is down.
+const (
+	outboxRetryMin = 250 * time.Millisecond
+	outboxRetryMax = 5 * time.Second
+)
+
+var errUnexpectedStream = errors.New("unexpected stream ack")
+
+// NewPublisher dials JetStream using the provided configuration. When
+// StreamConfigPath is set, the subject templates are checked against the
+// stream's subjects first so a mismatch fails at startup rather than on the
+// first publish. With an outbox configured, the connection reconnects
+// forever and events that cannot be published are spooled to disk instead of
+// failing.
 func NewPublisher(cfg Config) (*Publisher, error) {
 	if err := cfg.Validate(); err != nil {
 		return nil, err
 	}
+	subjects, err := cfg.Subjects()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.StreamConfigPath != "" {
+		streamSubjects, err := LoadStreamSubjects(cfg.StreamConfigPath)
+		if err != nil {
+			return nil, err
+		}
+		if err := subjects.Validate(streamSubjects); err != nil {
+			return nil, fmt.Errorf("stream %s: %w", cfg.StreamConfigPath, err)
+		}
+	}
 
 	opts := []nats.Option{nats.Name("solana-liquidity-indexer")}
+	if cfg.Outbox != nil {
+		// Fail publishes immediately while disconnected rather than
+		// buffering them in memory; the outbox takes them instead.
+		opts = append(opts, nats.MaxReconnects(-1), nats.ReconnectBufSize(-1))
+	}
 	conn, err := nats.Connect(cfg.URL, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("connect to nats: %w", err)
@@ -36,12 +75,50 @@ func NewPublisher(cfg Config) (*Publisher, error) {
 		conn.Close()
 		return nil, fmt.Errorf("jetstream context: %w", err)
 	}
+	if err := provision.VerifyFromEnv(js, cfg.Stream, ""); err != nil {
+		conn.Close()
+		return nil, err
+	}
 
-	return &Publisher{cfg: cfg, conn: conn, js: js}, nil
+	p := &Publisher{cfg: cfg, subjects: subjects, conn: conn, js: js}
+	if cfg.Outbox != nil {
+		p.outbox, err = OpenOutbox(*cfg.Outbox)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		p.stop = make(chan struct{})
+		p.done = make(chan struct{})
+		go p.drainOutbox()
+	}
+	return p, nil
 }
 
-// Close drains and closes the underlying NATS connection.
+// RegisterMetrics registers the outbox depth, size, age and throughput
+// metrics with reg. It is a no-op without an outbox.
+func (p *Publisher) RegisterMetrics(reg prometheus.Registerer) error {
+	if p.outbox == nil || reg == nil {
+		return nil
+	}
+	return reg.Register(newOutboxCollector(p.outbox))
+}
+
+// JetStream returns the publisher's JetStream context so other components,
+// such as the shared slot cache, can reuse its connection.
+func (p *Publisher) JetStream() nats.JetStreamContext {
+	return p.js
+}
+
+// Close drains and closes the underlying NATS connection. Events still in
+// the outbox stay on disk and are sent by the next publisher to open it.
 func (p *Publisher) Close() {
+	if p.outbox != nil {
+		close(p.stop)
+		<-p.done
+		if err := p.outbox.Close(); err != nil {
+			log.Printf("close outbox: %v", err)
+		}
+	}
 	if p.conn == nil {
 		return
 	}
@@ -54,9 +131,75 @@ func (p *Publisher) PublishSwap(ctx context.Context, event *dexv1.SwapEvent) err
 	if event == nil {
 		return errors.New("swap event is nil")
 	}
-	subject := fmt.Sprintf("%s.%s.swap", p.cfg.SubjectRoot, programSegment(event.GetProgramId()))
-	msgID := fmt.Sprintf("501:%d:%s:%d", event.GetSlot(), event.GetSig(), event.GetIndex())
-	return p.publish(ctx, subject, event, msgID)
+	msgID := fmt.Sprintf("501:%d:%s:%d:%s:%s", event.GetSlot(), event.GetSig(), event.GetIndex(), swapPosition(event), settlement(event.GetProvisional(), event.GetIsUndo()))
+	return p.publish(ctx, KindSwap, p.subjects.Swap(event), event, msgID)
+}
+
+// swapPosition locates a swap leg within its transaction: the outer
+// instruction, the CPI within it and the hop of a multi-pool instruction.
+func swapPosition(event *dexv1.SwapEvent) string {
+	pos := fmt.Sprint(event.GetInstructionIndex())
+	if event.GetInner() {
+		pos += fmt.Sprintf(".%d", event.GetInnerInstructionIndex())
+	}
+	if event.GetHop() > 0 {
+		pos += fmt.Sprintf("/%d", event.GetHop())
+	}
+	return pos
+}
+
+// settlement names the commitment an event is published at, so the finalized
+// and undo republications are not deduplicated against the provisional one.
+func settlement(provisional, isUndo bool) string {
+	switch {
+	case isUndo:
+		return "undo"
+	case provisional:
+		return "provisional"
+	default:
+		return "final"
+	}
+}
+
+// PublishRoute publishes a Route grouping the swap legs of one transaction.
+func (p *Publisher) PublishRoute(ctx context.Context, route *dexv1.Route) error {
+	if route == nil {
+		return errors.New("route is nil")
+	}
+	msgID := fmt.Sprintf("501:%d:%s:route:%s", route.GetSlot(), route.GetSig(), settlement(route.GetProvisional(), route.GetIsUndo()))
+	return p.publish(ctx, KindRoute, p.subjects.Fixed(KindRoute), route, msgID)
+}
+
+// PublishMev publishes an MevEvent detected in a confirmed slot.
+func (p *Publisher) PublishMev(ctx context.Context, event *dexv1.MevEvent) error {
+	if event == nil {
+		return errors.New("mev event is nil")
+	}
+	msgID := fmt.Sprintf("501:%d:%s:%s:%s", event.GetSlot(), event.GetKind(), event.GetBackSig(), event.GetPoolId())
+	return p.publish(ctx, KindMev, p.subjects.Mev(event), event, msgID)
+}
+
+// PublishFailedSwap publishes a FailedSwapAttempt recorded from a reverted
+// transaction.
+func (p *Publisher) PublishFailedSwap(ctx context.Context, attempt *dexv1.FailedSwapAttempt) error {
+	if attempt == nil {
+		return errors.New("failed swap attempt is nil")
+	}
+	msgID := fmt.Sprintf("501:%d:%s:%d:failed", attempt.GetSlot(), attempt.GetSig(), attempt.GetInstructionIndex())
+	if attempt.GetInner() {
+		msgID = fmt.Sprintf("501:%d:%s:%d.%d:failed", attempt.GetSlot(), attempt.GetSig(), attempt.GetInstructionIndex(), attempt.GetInnerInstructionIndex())
+	}
+	return p.publish(ctx, KindSwapFailed, p.subjects.SwapFailed(attempt), attempt, msgID)
+}
+
+// PublishDecodeFailure publishes a transaction the decoder rejected to the
+// decode dead-letter subject so it can be replayed by a newer decoder build.
+func (p *Publisher) PublishDecodeFailure(ctx context.Context, failure *dexv1.DecodeFailure) error {
+	if failure == nil {
+		return errors.New("decode failure is nil")
+	}
+	msgID := fmt.Sprintf("501:%d:%s:dlq", failure.GetSlot(), failure.GetSig())
+	return p.publish(ctx, KindDecodeFailure, p.subjects.Fixed(KindDecodeFailure), failure, msgID)
 }
 
 // PublishBlockHead publishes a BlockHead update to JetStream.
@@ -64,9 +207,8 @@ func (p *Publisher) PublishBlockHead(ctx context.Context, head *dexv1.BlockHead)
 	if head == nil {
 		return errors.New("block head is nil")
 	}
-	subject := fmt.Sprintf("%s.blocks.head", p.cfg.SubjectRoot)
 	msgID := fmt.Sprintf("501:%d", head.GetSlot())
-	return p.publish(ctx, subject, head, msgID)
+	return p.publish(ctx, KindBlockHead, p.subjects.Fixed(KindBlockHead), head, msgID)
 }
 
 // PublishTxMeta publishes a TxMeta update to JetStream.
@@ -74,9 +216,19 @@ func (p *Publisher) PublishTxMeta(ctx context.Context, meta *dexv1.TxMeta) error
 	if meta == nil {
 		return errors.New("tx meta is nil")
 	}
-	subject := fmt.Sprintf("%s.tx.meta", p.cfg.SubjectRoot)
 	msgID := fmt.Sprintf("501:%d:%s", meta.GetSlot(), meta.GetSig())
-	return p.publish(ctx, subject, meta, msgID)
+	return p.publish(ctx, KindTxMeta, p.subjects.Fixed(KindTxMeta), meta, msgID)
+}
+
+// PublishReferencePrice publishes an oracle reference price. A price account
+// can be written more than once per slot, so the publish time is part of the
+// message ID.
+func (p *Publisher) PublishReferencePrice(ctx context.Context, price *dexv1.ReferencePrice) error {
+	if price == nil {
+		return errors.New("reference price is nil")
+	}
+	msgID := fmt.Sprintf("501:%d:%s:%d", price.GetSlot(), price.GetAccount(), price.GetPublishTime())
+	return p.publish(ctx, KindReferencePrice, p.subjects.ReferencePrice(price), price, msgID)
 }
 
 // PublishPoolSnapshot publishes a PoolSnapshot update.
@@ -84,9 +236,8 @@ func (p *Publisher) PublishPoolSnapshot(ctx context.Context, snap *dexv1.PoolSna
 	if snap == nil {
 		return errors.New("pool snapshot is nil")
 	}
-	subject := fmt.Sprintf("%s.pool.snapshot", p.cfg.SubjectRoot)
 	msgID := fmt.Sprintf("501:%d:%s", snap.GetSlot(), snap.GetPoolId())
-	return p.publish(ctx, subject, snap, msgID)
+	return p.publish(ctx, KindPoolSnapshot, p.subjects.PoolSnapshot(snap), snap, msgID)
 }
 
 // PublishCandle publishes a Candle update (pool or pair scope).
@@ -94,16 +245,12 @@ func (p *Publisher) PublishCandle(ctx context.Context, candle *dexv1.Candle) err
 	if candle == nil {
 		return errors.New("candle is nil")
 	}
-	scope := "pair"
-	if candle.GetPoolId() != "" {
-		scope = "pool"
-	}
-	subject := fmt.Sprintf("%s.candle.%s.%s", p.cfg.SubjectRoot, scope, candle.GetTimeframe())
+	subject, kind := p.subjects.Candle(candle)
 	msgID := fmt.Sprintf("501:%s:%s:%d:%t", candle.GetPairId(), candle.GetPoolId(), candle.GetWindowStart(), candle.GetProvisional())
-	return p.publish(ctx, subject, candle, msgID)
+	return p.publish(ctx, kind, subject, candle, msgID)
 }
 
-func (p *Publisher) publish(parent context.Context, subject string, message proto.Message, msgID string) error {
+func (p *Publisher) publish(parent context.Context, kind, subject string, message proto.Message, msgID string) error {
 	if message == nil {
 		return errors.New("message is nil")
 	}
@@ -112,27 +259,127 @@ func (p *Publisher) publish(parent context.Context, subject string, message prot
 	if err != nil {
 		return fmt.Errorf("marshal payload: %w", err)
 	}
+	msg := newMsg(kind, subject, data, msgID)
+
+	// Queue behind spooled events so the stream sees them in order.
+	if p.outbox != nil && p.outbox.Len() > 0 {
+		return p.spool(msg, nil)
+	}
+
+	ctx, cancel := p.ensureTimeout(parent)
+	defer cancel()
+	err = p.send(ctx, msg)
+	if err != nil && p.outbox != nil && retryable(err) {
+		return p.spool(msg, err)
+	}
+	return err
+}
 
+func newMsg(kind, subject string, data []byte, msgID string) *nats.Msg {
 	msg := &nats.Msg{Subject: subject, Data: data}
 	msg.Header = nats.Header{}
 	if msgID != "" {
-		msg.Header.Set("Nats-Msg-Id", msgID)
+		msg.Header.Set(nats.MsgIdHdr, msgID)
 	}
 	msg.Header.Set("Content-Type", "application/protobuf")
+	msg.Header.Set(HeaderEventKind, kind)
+	return msg
+}
 
-	ctx, cancel := p.ensureTimeout(parent)
-	defer cancel()
-
+func (p *Publisher) send(ctx context.Context, msg *nats.Msg) error {
 	ack, err := p.js.PublishMsg(msg, nats.Context(ctx), nats.ExpectStream(p.cfg.Stream))
 	if err != nil {
-		return fmt.Errorf("publish %s: %w", subject, err)
+		return fmt.Errorf("publish %s: %w", msg.Subject, err)
 	}
 	if ack != nil && ack.Stream != "" && ack.Stream != p.cfg.Stream {
-		return fmt.Errorf("unexpected stream ack %q (expected %q)", ack.Stream, p.cfg.Stream)
+		return fmt.Errorf("%w %q (expected %q)", errUnexpectedStream, ack.Stream, p.cfg.Stream)
 	}
 	return nil
 }
 
+func (p *Publisher) spool(msg *nats.Msg, cause error) error {
+	if err := p.outbox.Append(msg); err != nil {
+		if cause != nil {
+			return fmt.Errorf("%w (outbox: %v)", cause, err)
+		}
+		return fmt.Errorf("spool %s: %w", msg.Subject, err)
+	}
+	return nil
+}
+
+// permanentPublishErrors are the JetStream rejections retrying cannot fix.
+// nats.go only names the stream-not-found code; the others are the server's
+// JSStreamNotMatchErr, JSStreamMessageExceedsMaximumErr,
+// JSStreamHeaderExceedsMaximumErr and JSStreamSealedErr.
+var permanentPublishErrors = map[nats.ErrorCode]struct{}{
+	nats.JSErrCodeStreamNotFound: {},
+	10060:                        {}, // expected stream does not match
+	10054:                        {}, // message size exceeds maximum allowed
+	10097:                        {}, // header size exceeds maximum allowed
+	10109:                        {}, // invalid operation on sealed stream
+}
+
+// retryable reports whether a failed publish may succeed later. Only the
+// known-permanent stream rejections are final; other API errors, such as a
+// 503 while JetStream is temporarily unavailable, are retried.
+func retryable(err error) bool {
+	if errors.Is(err, errUnexpectedStream) {
+		return false
+	}
+	var apiErr *nats.APIError
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+	_, permanent := permanentPublishErrors[apiErr.ErrorCode]
+	return !permanent
+}
+
+// drainOutbox publishes spooled events in order, backing off while
+// JetStream stays unreachable.
+func (p *Publisher) drainOutbox() {
+	defer close(p.done)
+	backoff := outboxRetryMin
+	for {
+		rec, ok, err := p.outbox.Peek()
+		if err != nil {
+			log.Printf("outbox: read: %v", err)
+		}
+		if err == nil && !ok {
+			select {
+			case <-p.outbox.Notify():
+				continue
+			case <-p.stop:
+				return
+			}
+		}
+		if err == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), p.cfg.PublishTimeout)
+			err = p.send(ctx, rec.msg())
+			cancel()
+			switch {
+			case err == nil:
+				err = p.outbox.Ack(rec)
+			case !retryable(err):
+				log.Printf("outbox: dropping %s (msg id %s): %v", rec.Subject, rec.MsgID, err)
+				err = p.outbox.Drop(rec)
+			}
+		}
+		if err == nil {
+			backoff = outboxRetryMin
+			continue
+		}
+		select {
+		case <-time.After(backoff):
+		case <-p.stop:
+			return
+		}
+		backoff *= 2
+		if backoff > outboxRetryMax {
+			backoff = outboxRetryMax
+		}
+	}
+}
+
 func (p *Publisher) ensureTimeout(parent context.Context) (context.Context, context.CancelFunc) {
 	if parent == nil {
 		parent = context.Background()
@@ -152,29 +399,3 @@ func (p *Publisher) Config() Config {
 func (p *Publisher) WithTimeout(parent context.Context) (context.Context, context.CancelFunc) {
 	return p.ensureTimeout(parent)
 }
-
-var programSubjectAliases = map[string]string{
-	"CAMMCzo5YL8w4VFF8KVHrK22GGUsp5VTaW7grrKgrWqK":  "raydium",
-	"whirLbMiicVdio4qvUfM5KAg6Ct8VwpYzGff3uctyCc":   "orca",
-	"METoRa111111111111111111111111111111111111111": "meteora",
-}
-
-func programSegment(programID string) string {
-	if programID == "" {
-		return "unknown"
-	}
-	if alias, ok := programSubjectAliases[programID]; ok {
-		return alias
-	}
-	cleaned := strings.Map(func(r rune) rune {
-		switch r {
-		case '.', ' ', '*', '>':
-			return -1
-		}
-		return r
-	}, programID)
-	if len(cleaned) > 16 {
-		cleaned = cleaned[:16]
-	}
-	return strings.ToLower(cleaned)
-}
@@ -25,6 +25,7 @@ func TestPublisherPublishesMessages(t *testing.T) {
 	cfg.Stream = "DEX"
 	cfg.SubjectRoot = "dex.sol"
 	cfg.PublishTimeout = 2 * time.Second
+	cfg.Programs = map[string]string{"raydium_clmm": "CAMMCzo5YL8w4VFF8KVHrK22GGUsp5VTaW7grrKgrWqK"}
 
 	pub, err := NewPublisher(cfg)
 	if err != nil {
@@ -48,9 +49,12 @@ func TestPublisherPublishesMessages(t *testing.T) {
 
 	js := jetStreamContext(t, url)
 	msg := getLastMsg(t, js, "DEX", "dex.sol.raydium.swap")
-	if got := msg.Header.Get("Nats-Msg-Id"); got != "501:123:sig123:1" {
+	if got := msg.Header.Get("Nats-Msg-Id"); got != "501:123:sig123:1:0:final" {
 		t.Fatalf("unexpected msg id %q", got)
 	}
+	if got := msg.Header.Get(HeaderEventKind); got != KindSwap {
+		t.Fatalf("unexpected event kind %q", got)
+	}
 	var decodedSwap dexv1.SwapEvent
 	if err := proto.Unmarshal(msg.Data, &decodedSwap); err != nil {
 		t.Fatalf("unmarshal swap: %v", err)
@@ -129,6 +133,20 @@ func TestPublisherPublishesMessages(t *testing.T) {
 		t.Fatalf("unexpected tx meta msg id %q", got)
 	}
 
+	ref := &dexv1.ReferencePrice{
+		ChainId:     501,
+		Slot:        999,
+		Account:     "pythSOL",
+		PublishTime: 1_700_000_000,
+	}
+	if err := pub.PublishReferencePrice(ctx, ref); err != nil {
+		t.Fatalf("PublishReferencePrice() error = %v", err)
+	}
+	msg = getLastMsg(t, js, "DEX", "dex.sol.oracle.price")
+	if got := msg.Header.Get("Nats-Msg-Id"); got != "501:999:pythSOL:1700000000" {
+		t.Fatalf("unexpected reference price msg id %q", got)
+	}
+
 	ctxTimeout, cancel := pub.WithTimeout(context.Background())
 	defer cancel()
 	if _, ok := ctxTimeout.Deadline(); !ok {
@@ -136,6 +154,73 @@ func TestPublisherPublishesMessages(t *testing.T) {
 	}
 }
 
+func TestPublisherKeepsEveryLegAndSettlement(t *testing.T) {
+	srv, url := runJetStream(t)
+	defer srv.Shutdown()
+
+	ensureStream(t, url, "DEX", []string{"dex.sol.>"})
+
+	cfg := DefaultConfig()
+	cfg.URL = url
+	cfg.Stream = "DEX"
+	cfg.SubjectRoot = "dex.sol"
+	cfg.PublishTimeout = 2 * time.Second
+
+	pub, err := NewPublisher(cfg)
+	if err != nil {
+		t.Fatalf("NewPublisher() error = %v", err)
+	}
+	defer pub.Close()
+
+	ctx := context.Background()
+
+	// Two legs of one aggregator transaction: same slot, signature and
+	// transaction index, reached through different CPIs of the same outer
+	// instruction.
+	legs := []*dexv1.SwapEvent{
+		{ChainId: 501, Slot: 200, Sig: "route-sig", Index: 4, PoolId: "pool-a", InstructionIndex: 2, Inner: true, InnerInstructionIndex: 1, Provisional: true},
+		{ChainId: 501, Slot: 200, Sig: "route-sig", Index: 4, PoolId: "pool-b", InstructionIndex: 2, Inner: true, InnerInstructionIndex: 5, Provisional: true},
+	}
+	for _, leg := range legs {
+		if err := pub.PublishSwap(ctx, leg); err != nil {
+			t.Fatalf("PublishSwap(%s) error = %v", leg.GetPoolId(), err)
+		}
+	}
+
+	route := &dexv1.Route{ChainId: 501, Slot: 200, Sig: "route-sig", Index: 4, Legs: legs, Provisional: true}
+	if err := pub.PublishRoute(ctx, route); err != nil {
+		t.Fatalf("PublishRoute() error = %v", err)
+	}
+
+	// Finalization republishes the legs and the route with settled flags.
+	for _, leg := range legs {
+		final := proto.Clone(leg).(*dexv1.SwapEvent)
+		final.Provisional = false
+		if err := pub.PublishSwap(ctx, final); err != nil {
+			t.Fatalf("PublishSwap(final %s) error = %v", leg.GetPoolId(), err)
+		}
+	}
+	settled := proto.Clone(route).(*dexv1.Route)
+	settled.Provisional = false
+	if err := pub.PublishRoute(ctx, settled); err != nil {
+		t.Fatalf("PublishRoute(final) error = %v", err)
+	}
+
+	js := jetStreamContext(t, url)
+	info, err := js.StreamInfo("DEX")
+	if err != nil {
+		t.Fatalf("StreamInfo: %v", err)
+	}
+	if info.State.Msgs != 6 {
+		t.Fatalf("expected 4 swaps and 2 routes on the stream, got %d messages", info.State.Msgs)
+	}
+
+	msg := getLastMsg(t, js, "DEX", "dex.sol.route")
+	if got := msg.Header.Get(nats.MsgIdHdr); got != "501:200:route-sig:route:final" {
+		t.Fatalf("unexpected route msg id %q", got)
+	}
+}
+
 func runJetStream(t *testing.T) (*server.Server, string) {
 	t.Helper()
 	opts := &server.Options{JetStream: true, Host: "127.0.0.1", Port: -1, StoreDir: t.TempDir()}
@@ -209,3 +294,25 @@ func getLastMsg(t *testing.T, js nats.JetStreamContext, stream, subject string)
 	}
 	return msg
 }
+
+func TestRetryable(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "stream not found", err: &nats.APIError{Code: 503, ErrorCode: nats.JSErrCodeStreamNotFound}, want: false},
+		{name: "message too large", err: &nats.APIError{Code: 400, ErrorCode: 10054}, want: false},
+		{name: "wrapped permanent", err: fmt.Errorf("publish: %w", &nats.APIError{Code: 400, ErrorCode: 10109}), want: false},
+		{name: "unexpected stream", err: errUnexpectedStream, want: false},
+		{name: "jetstream unavailable", err: &nats.APIError{Code: 503, ErrorCode: 10008}, want: true},
+		{name: "store failed", err: fmt.Errorf("publish: %w", &nats.APIError{Code: 503, ErrorCode: 10077}), want: true},
+		{name: "timeout", err: nats.ErrTimeout, want: true},
+		{name: "no responders", err: nats.ErrNoResponders, want: true},
+	}
+	for _, tc := range cases {
+		if got := retryable(tc.err); got != tc.want {
+			t.Errorf("%s: retryable(%v) = %v, want %v", tc.name, tc.err, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,401 @@
+package natsx
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	nats "github.com/nats-io/nats.go"
+	"gopkg.in/yaml.v3"
+
+	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+)
+
+// Event kinds. Each kind has its own subject template and is sent in the
+// HeaderEventKind header, so consumers need not parse subjects.
+const (
+	KindSwap           = "swap"
+	KindSwapFailed     = "swap_failed"
+	KindRoute          = "route"
+	KindMev            = "mev"
+	KindDecodeFailure  = "dlq_decode"
+	KindBlockHead      = "block_head"
+	KindTxMeta         = "tx_meta"
+	KindReferencePrice = "oracle_price"
+	KindPoolSnapshot   = "pool_snapshot"
+	KindCandlePool     = "candle_pool"
+	KindCandlePair     = "candle_pair"
+)
+
+// HeaderEventKind carries the event kind of every published message.
+const HeaderEventKind = "Dex-Event-Kind"
+
+// defaultSubjectTemplates reproduce the subjects in
+// ops/jetstream/streams.dex.json.
+var defaultSubjectTemplates = map[string]string{
+	KindSwap:           "{root}.{dex}.swap",
+	KindSwapFailed:     "{root}.{dex}.swap.failed",
+	KindRoute:          "{root}.route",
+	KindMev:            "{root}.mev",
+	KindDecodeFailure:  "{root}.dlq.decode",
+	KindBlockHead:      "{root}.blocks.head",
+	KindTxMeta:         "{root}.tx.meta",
+	KindReferencePrice: "{root}.oracle.price",
+	KindPoolSnapshot:   "{root}.pool.snapshot",
+	KindCandlePool:     "{root}.candle.pool.{timeframe}",
+	KindCandlePair:     "{root}.candle.pair.{timeframe}",
+}
+
+// subjectPlaceholders lists the placeholders each kind may use besides
+// {root}.
+var subjectPlaceholders = map[string][]string{
+	KindSwap:           {"dex", "name", "program", "pool", "pair", "base", "quote"},
+	KindSwapFailed:     {"dex", "name", "program", "pool"},
+	KindMev:            {"pool"},
+	KindReferencePrice: {"feed", "mint"},
+	KindPoolSnapshot:   {"pool", "base", "quote"},
+	KindCandlePool:     {"pool", "pair", "timeframe"},
+	KindCandlePair:     {"pair", "timeframe"},
+}
+
+// Subjects renders event subjects from templates such as
+// "{root}.{dex}.swap.{pair}". A placeholder fills a whole subject token; its
+// value is sanitized to letters, digits, '_' and '-', so IDs can never add
+// tokens or wildcards. {dex} is the legacy venue token (raydium, orca, ...,
+// or the shortened program ID); {name} is the program's name in
+// programs.yaml, falling back to {dex}.
+type Subjects struct {
+	templates map[string][]subjectToken
+	names     map[string]string
+	venues    map[string]string
+}
+
+type subjectToken struct {
+	literal     string
+	placeholder string
+}
+
+// NewSubjects compiles the default templates, with any overrides, under
+// root. programs maps programs.yaml names to program IDs.
+func NewSubjects(root string, programs, overrides map[string]string) (*Subjects, error) {
+	for kind := range overrides {
+		if _, ok := defaultSubjectTemplates[kind]; !ok {
+			return nil, fmt.Errorf("unknown subject kind %q", kind)
+		}
+	}
+	s := &Subjects{
+		templates: make(map[string][]subjectToken, len(defaultSubjectTemplates)),
+		names:     make(map[string]string, len(programs)),
+		venues:    make(map[string]string, len(programs)),
+	}
+	for kind, tmpl := range defaultSubjectTemplates {
+		if override, ok := overrides[kind]; ok {
+			tmpl = override
+		}
+		tokens, err := compileSubject(kind, strings.ReplaceAll(tmpl, "{root}", root))
+		if err != nil {
+			return nil, fmt.Errorf("subject template %s %q: %w", kind, tmpl, err)
+		}
+		s.templates[kind] = tokens
+	}
+	for name, programID := range programs {
+		if prev, ok := s.names[programID]; ok && prev < name {
+			continue
+		}
+		s.names[programID] = name
+	}
+	for programID, name := range s.names {
+		venue, _, _ := strings.Cut(name, "_")
+		s.venues[programID] = venue
+	}
+	return s, nil
+}
+
+func compileSubject(kind, tmpl string) ([]subjectToken, error) {
+	allowed := make(map[string]struct{})
+	for _, name := range subjectPlaceholders[kind] {
+		allowed[name] = struct{}{}
+	}
+	parts := strings.Split(tmpl, ".")
+	tokens := make([]subjectToken, 0, len(parts))
+	for _, part := range parts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			name := part[1 : len(part)-1]
+			if _, ok := allowed[name]; !ok {
+				return nil, fmt.Errorf("placeholder {%s} is not available", name)
+			}
+			tokens = append(tokens, subjectToken{placeholder: name})
+			continue
+		}
+		if part == "" {
+			return nil, fmt.Errorf("empty subject token")
+		}
+		if strings.ContainsAny(part, "{}*> \t") {
+			return nil, fmt.Errorf("invalid subject token %q", part)
+		}
+		tokens = append(tokens, subjectToken{literal: part})
+	}
+	return tokens, nil
+}
+
+func (s *Subjects) render(kind string, values map[string]string) string {
+	var b strings.Builder
+	for i, tok := range s.templates[kind] {
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		if tok.placeholder == "" {
+			b.WriteString(tok.literal)
+			continue
+		}
+		b.WriteString(sanitizeToken(values[tok.placeholder]))
+	}
+	return b.String()
+}
+
+// sanitizeToken maps value onto a single safe subject token.
+func sanitizeToken(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		}
+		return '_'
+	}, value)
+}
+
+// legacyDexTokens are the venue tokens of the programs in ops/programs.yaml.
+// Stream and consumer filters depend on them, so they hold even when no
+// programs file is loaded.
+var legacyDexTokens = map[string]string{
+	"675kPX9MHTjS2zt1qfr1NYHuzeLXfQM9H24wFSUt1Mp8": "raydium",
+	"5quBtoiQqxF9Jv6KYKctB59NT3gtJD2Y65kdnB1Uev3h": "raydium",
+	"CAMMCzo5YL8w4VFF8KVHrK22GGUsp5VTaW7grrKgrWqK": "raydium",
+	"whirLbMiicVdio4qvUfM5KAg6Ct8VwpYzGff3uctyCc":  "orca",
+	"9W959DqEETiGZocYWCQPaJ6sBmUzgfxXfqGeTEdp3aQP": "orca",
+	"LBUZKhRxPF3XUpBCjp4YzTKgLccjZhTSDM9YuVaPwxo":  "meteora",
+	"Eo7WjKq67rjJQSZxS6z3YkapzY3eMj6Xy8X5EQVn5UaB": "meteora",
+	"cpamdpZCGKUy5JxQXB4dcpGPiikHawvSWAd6mEn1sGG":  "meteora",
+}
+
+// dex returns the legacy venue token of a program: its alias, the part of
+// its programs.yaml name before the first '_', or the lowercased program ID
+// cut to 16 characters.
+func (s *Subjects) dex(programID string) string {
+	if programID == "" {
+		return ""
+	}
+	if alias, ok := legacyDexTokens[programID]; ok {
+		return alias
+	}
+	if venue, ok := s.venues[programID]; ok {
+		return venue
+	}
+	cleaned := strings.Map(func(r rune) rune {
+		switch r {
+		case '.', ' ', '*', '>':
+			return -1
+		}
+		return r
+	}, programID)
+	if len(cleaned) > 16 {
+		cleaned = cleaned[:16]
+	}
+	return strings.ToLower(cleaned)
+}
+
+func (s *Subjects) name(programID string) string {
+	if name, ok := s.names[programID]; ok {
+		return name
+	}
+	return s.dex(programID)
+}
+
+// Swap returns the subject of a swap event.
+func (s *Subjects) Swap(ev *dexv1.SwapEvent) string {
+	return s.render(KindSwap, map[string]string{
+		"dex":     s.dex(ev.GetProgramId()),
+		"name":    s.name(ev.GetProgramId()),
+		"program": ev.GetProgramId(),
+		"pool":    ev.GetPoolId(),
+		"pair":    ev.GetPairId(),
+		"base":    ev.GetMintBase(),
+		"quote":   ev.GetMintQuote(),
+	})
+}
+
+// SwapFailed returns the subject of a failed swap attempt.
+func (s *Subjects) SwapFailed(attempt *dexv1.FailedSwapAttempt) string {
+	return s.render(KindSwapFailed, map[string]string{
+		"dex":     s.dex(attempt.GetProgramId()),
+		"name":    s.name(attempt.GetProgramId()),
+		"program": attempt.GetProgramId(),
+		"pool":    attempt.GetPoolId(),
+	})
+}
+
+// Mev returns the subject of an MEV event.
+func (s *Subjects) Mev(ev *dexv1.MevEvent) string {
+	return s.render(KindMev, map[string]string{"pool": ev.GetPoolId()})
+}
+
+// ReferencePrice returns the subject of an oracle reference price.
+func (s *Subjects) ReferencePrice(price *dexv1.ReferencePrice) string {
+	return s.render(KindReferencePrice, map[string]string{
+		"feed": price.GetFeed(),
+		"mint": price.GetMint(),
+	})
+}
+
+// PoolSnapshot returns the subject of a pool snapshot.
+func (s *Subjects) PoolSnapshot(snap *dexv1.PoolSnapshot) string {
+	return s.render(KindPoolSnapshot, map[string]string{
+		"pool":  snap.GetPoolId(),
+		"base":  snap.GetMintBase(),
+		"quote": snap.GetMintQuote(),
+	})
+}
+
+// Candle returns the subject and kind of a pool or pair candle.
+func (s *Subjects) Candle(candle *dexv1.Candle) (subject, kind string) {
+	kind = KindCandlePair
+	if candle.GetPoolId() != "" {
+		kind = KindCandlePool
+	}
+	return s.render(kind, map[string]string{
+		"pool":      candle.GetPoolId(),
+		"pair":      candle.GetPairId(),
+		"timeframe": candle.GetTimeframe(),
+	}), kind
+}
+
+// Fixed returns the subject of a kind without placeholders, such as
+// KindBlockHead.
+func (s *Subjects) Fixed(kind string) string {
+	return s.render(kind, nil)
+}
+
+// Validate checks that every subject a template can produce is captured by
+// one of the stream's subject filters.
+func (s *Subjects) Validate(streamSubjects []string) error {
+	kinds := make([]string, 0, len(s.templates))
+	for kind := range s.templates {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	var problems []string
+	for _, kind := range kinds {
+		pattern := make([]string, len(s.templates[kind]))
+		for i, tok := range s.templates[kind] {
+			pattern[i] = tok.literal
+			if tok.placeholder != "" {
+				pattern[i] = "*"
+			}
+		}
+		covered := false
+		for _, filter := range streamSubjects {
+			if subjectCovers(strings.Split(filter, "."), pattern) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			problems = append(problems, fmt.Sprintf("%s (%s)", kind, strings.Join(pattern, ".")))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("subjects not captured by the stream: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// subjectCovers reports whether every subject matching pattern, where "*"
+// stands for any single token, also matches filter.
+func subjectCovers(filter, pattern []string) bool {
+	for i, f := range filter {
+		if f == ">" {
+			return len(pattern) > i
+		}
+		if i >= len(pattern) {
+			return false
+		}
+		if f != "*" && f != pattern[i] {
+			return false
+		}
+	}
+	return len(filter) == len(pattern)
+}
+
+// LoadStreamSubjects reads the subject filters of a stream definition such
+// as ops/jetstream/streams.dex.json.
+func LoadStreamSubjects(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read stream config: %w", err)
+	}
+	var stream struct {
+		Subjects []string `json:"subjects"`
+	}
+	if err := json.Unmarshal(data, &stream); err != nil {
+		return nil, fmt.Errorf("parse stream config %s: %w", path, err)
+	}
+	return stream.Subjects, nil
+}
+
+// LoadProgramsFile reads the program names and subject template overrides
+// from programs.yaml.
+func LoadProgramsFile(path string) (programs, subjects map[string]string, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read programs file: %w", err)
+	}
+	var file struct {
+		Programs map[string]string `yaml:"programs"`
+		Subjects map[string]string `yaml:"subjects"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, nil, fmt.Errorf("parse programs file %s: %w", path, err)
+	}
+	return file.Programs, file.Subjects, nil
+}
+
+// EventKind returns the kind of a consumed message from its HeaderEventKind
+// header, falling back to the default subject layout for messages published
+// without one.
+func EventKind(msg *nats.Msg) string {
+	if kind := msg.Header.Get(HeaderEventKind); kind != "" {
+		return kind
+	}
+	subject := msg.Subject
+	switch {
+	case strings.HasSuffix(subject, ".swap.failed"):
+		return KindSwapFailed
+	case strings.HasSuffix(subject, ".swap"):
+		return KindSwap
+	case strings.HasSuffix(subject, ".route"):
+		return KindRoute
+	case strings.HasSuffix(subject, ".mev"):
+		return KindMev
+	case strings.HasSuffix(subject, ".dlq.decode"):
+		return KindDecodeFailure
+	case strings.HasSuffix(subject, ".blocks.head"):
+		return KindBlockHead
+	case strings.HasSuffix(subject, ".tx.meta"):
+		return KindTxMeta
+	case strings.HasSuffix(subject, ".oracle.price"):
+		return KindReferencePrice
+	case strings.HasSuffix(subject, ".pool.snapshot"):
+		return KindPoolSnapshot
+	case strings.Contains(subject, ".candle.pool."):
+		return KindCandlePool
+	case strings.Contains(subject, ".candle.pair."):
+		return KindCandlePair
+	}
+	return ""
+}
@@ -0,0 +1,198 @@
+package natsx
+
+import (
+	"strings"
+	"testing"
+
+	nats "github.com/nats-io/nats.go"
+
+	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+)
+
+const streamConfigPath = "../../ops/jetstream/streams.dex.json"
+
+func TestSubjectsRender(t *testing.T) {
+	programs := map[string]string{
+		"orca_whirlpool": "whirLbMiicVdio4qvUfM5KAg6Ct8VwpYzGff3uctyCc",
+		"phoenix_v1":     "PhoeNiXZ8ByJGLkxNfZRnkUfjvmuYqLR89jjFHGqdXY",
+	}
+	defaults, err := NewSubjects("dex.sol", programs, nil)
+	if err != nil {
+		t.Fatalf("NewSubjects() error = %v", err)
+	}
+	swap := &dexv1.SwapEvent{ProgramId: "whirLbMiicVdio4qvUfM5KAg6Ct8VwpYzGff3uctyCc", PairId: "SOL/USDC"}
+	// Program names do not change the legacy default subjects.
+	if got := defaults.Swap(swap); got != "dex.sol.orca.swap" {
+		t.Fatalf("default Swap() = %q", got)
+	}
+	meteora := &dexv1.SwapEvent{ProgramId: "LBUZKhRxPF3XUpBCjp4YzTKgLccjZhTSDM9YuVaPwxo"}
+	if got := defaults.Swap(meteora); got != "dex.sol.meteora.swap" {
+		t.Fatalf("default Swap(meteora) = %q", got)
+	}
+	// Other listed programs take the venue from their name.
+	phoenix := &dexv1.SwapEvent{ProgramId: "PhoeNiXZ8ByJGLkxNfZRnkUfjvmuYqLR89jjFHGqdXY"}
+	if got := defaults.Swap(phoenix); got != "dex.sol.phoenix.swap" {
+		t.Fatalf("default Swap(listed) = %q", got)
+	}
+	unlisted := &dexv1.SwapEvent{ProgramId: "SSwpkEEcbUqx4vtoEByFjSkhKdCT862DNVb52nZg1UZ"}
+	if got := defaults.Swap(unlisted); got != "dex.sol.sswpkeecbuqx4vto.swap" {
+		t.Fatalf("default Swap(unlisted) = %q", got)
+	}
+
+	subjects, err := NewSubjects("dex.sol", programs, map[string]string{
+		KindSwap:       "{root}.{dex}.swap.{pair}",
+		KindSwapFailed: "{root}.{name}.swap.failed",
+		KindMev:        "{root}.mev.{pool}",
+	})
+	if err != nil {
+		t.Fatalf("NewSubjects() error = %v", err)
+	}
+
+	if got := subjects.Swap(swap); got != "dex.sol.orca.swap.SOL_USDC" {
+		t.Fatalf("Swap() = %q", got)
+	}
+	failed := &dexv1.FailedSwapAttempt{ProgramId: "whirLbMiicVdio4qvUfM5KAg6Ct8VwpYzGff3uctyCc"}
+	if got := subjects.SwapFailed(failed); got != "dex.sol.orca_whirlpool.swap.failed" {
+		t.Fatalf("SwapFailed() = %q", got)
+	}
+	// Dots and wildcards in IDs cannot leak into the subject.
+	swap = &dexv1.SwapEvent{ProgramId: "Some.Program>*", PairId: "A.B>"}
+	if got := subjects.Swap(swap); got != "dex.sol.someprogram.swap.A_B_" {
+		t.Fatalf("Swap(unknown) = %q", got)
+	}
+	if got := subjects.Swap(&dexv1.SwapEvent{}); got != "dex.sol.unknown.swap.unknown" {
+		t.Fatalf("Swap(empty) = %q", got)
+	}
+	if got := subjects.Mev(&dexv1.MevEvent{PoolId: "pool 1"}); got != "dex.sol.mev.pool_1" {
+		t.Fatalf("Mev() = %q", got)
+	}
+	candle := &dexv1.Candle{PoolId: "pool1", Timeframe: "1m"}
+	if got, kind := subjects.Candle(candle); got != "dex.sol.candle.pool.1m" || kind != KindCandlePool {
+		t.Fatalf("Candle() = %q, %q", got, kind)
+	}
+	if got := subjects.Fixed(KindBlockHead); got != "dex.sol.blocks.head" {
+		t.Fatalf("Fixed(block_head) = %q", got)
+	}
+}
+
+func TestSubjectsRejectInvalidTemplates(t *testing.T) {
+	cases := map[string]map[string]string{
+		"unknown kind":           {"swaps": "{root}.swap"},
+		"unavailable":            {KindRoute: "{root}.route.{pool}"},
+		"partial token":          {KindSwap: "{root}.dex-{dex}.swap"},
+		"wildcard":               {KindSwap: "{root}.*.swap"},
+		"empty token":            {KindSwap: "{root}..swap"},
+		"unknown placeholder":    {KindSwap: "{root}.{dex}.swap.{venue}"},
+		"whitespace in template": {KindMev: "{root}.m ev"},
+	}
+	for name, overrides := range cases {
+		if _, err := NewSubjects("dex.sol", nil, overrides); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestSubjectsValidateAgainstStream(t *testing.T) {
+	streamSubjects, err := LoadStreamSubjects(streamConfigPath)
+	if err != nil {
+		t.Fatalf("LoadStreamSubjects() error = %v", err)
+	}
+
+	defaults, err := NewSubjects("dex.sol", nil, nil)
+	if err != nil {
+		t.Fatalf("NewSubjects() error = %v", err)
+	}
+	if err := defaults.Validate(streamSubjects); err != nil {
+		t.Fatalf("default templates not captured by stream: %v", err)
+	}
+
+	pair, err := NewSubjects("dex.sol", nil, map[string]string{KindSwap: "{root}.{dex}.swap.{pair}"})
+	if err != nil {
+		t.Fatalf("NewSubjects(pair) error = %v", err)
+	}
+	if err := pair.Validate(streamSubjects); err != nil {
+		t.Fatalf("pair template not captured by stream: %v", err)
+	}
+
+	trades, err := NewSubjects("dex.sol", nil, map[string]string{KindSwap: "{root}.trades.{dex}"})
+	if err != nil {
+		t.Fatalf("NewSubjects(trades) error = %v", err)
+	}
+	err = trades.Validate(streamSubjects)
+	if err == nil || !strings.Contains(err.Error(), "dex.sol.trades.*") {
+		t.Fatalf("expected trades template to be rejected, got %v", err)
+	}
+	if err := trades.Validate([]string{"dex.sol.>"}); err != nil {
+		t.Fatalf("trades template not captured by dex.sol.>: %v", err)
+	}
+
+	rooted, err := NewSubjects("dex.eth", nil, nil)
+	if err != nil {
+		t.Fatalf("NewSubjects(dex.eth) error = %v", err)
+	}
+	if err := rooted.Validate(streamSubjects); err == nil {
+		t.Fatal("expected a different root to be rejected")
+	}
+}
+
+func TestNewPublisherChecksStreamConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.URL = "nats://127.0.0.1:1"
+	cfg.Stream = "DEX"
+	cfg.StreamConfigPath = streamConfigPath
+	cfg.SubjectTemplates = map[string]string{KindSwap: "{root}.trades.{dex}"}
+
+	_, err := NewPublisher(cfg)
+	if err == nil || !strings.Contains(err.Error(), "not captured") {
+		t.Fatalf("NewPublisher() error = %v, want stream mismatch", err)
+	}
+}
+
+func TestEventKind(t *testing.T) {
+	msg := &nats.Msg{Subject: "dex.sol.custom.subject", Header: nats.Header{}}
+	msg.Header.Set(HeaderEventKind, KindSwap)
+	if got := EventKind(msg); got != KindSwap {
+		t.Fatalf("EventKind(header) = %q", got)
+	}
+
+	legacy := map[string]string{
+		"dex.sol.raydium.swap":        KindSwap,
+		"dex.sol.raydium.swap.failed": KindSwapFailed,
+		"dex.sol.blocks.head":         KindBlockHead,
+		"dex.sol.candle.pair.1m":      KindCandlePair,
+		"dex.sol.something.else":      "",
+	}
+	for subject, want := range legacy {
+		if got := EventKind(&nats.Msg{Subject: subject}); got != want {
+			t.Errorf("EventKind(%q) = %q, want %q", subject, got, want)
+		}
+	}
+}
+
+func TestLoadProgramsFile(t *testing.T) {
+	programs, templates, err := LoadProgramsFile("../../ops/programs.yaml")
+	if err != nil {
+		t.Fatalf("LoadProgramsFile() error = %v", err)
+	}
+	if programs["raydium_clmm"] != "CAMMCzo5YL8w4VFF8KVHrK22GGUsp5VTaW7grrKgrWqK" {
+		t.Fatalf("raydium_clmm = %q", programs["raydium_clmm"])
+	}
+	subjects, err := NewSubjects("dex.sol", programs, templates)
+	if err != nil {
+		t.Fatalf("NewSubjects(programs.yaml) error = %v", err)
+	}
+	// Every configured program gets a readable venue token.
+	for name, programID := range programs {
+		venue, _, _ := strings.Cut(name, "_")
+		if got := subjects.Swap(&dexv1.SwapEvent{ProgramId: programID}); got != "dex.sol."+venue+".swap" {
+			t.Errorf("Swap(%s) = %q", name, got)
+		}
+	}
+	streamSubjects, err := LoadStreamSubjects(streamConfigPath)
+	if err != nil {
+		t.Fatalf("LoadStreamSubjects() error = %v", err)
+	}
+	if err := subjects.Validate(streamSubjects); err != nil {
+		t.Fatalf("programs.yaml templates not captured by stream: %v", err)
+	}
+}
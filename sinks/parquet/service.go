@@ -6,13 +6,15 @@ import (
 	"fmt"
 	"os"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/nats-io/nats.go"
 	proto "google.golang.org/protobuf/proto"
 
 	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+	"github.com/rexbrahh/lp-indexer/ingestor/common"
+	natsx "github.com/rexbrahh/lp-indexer/sinks/nats"
+	"github.com/rexbrahh/lp-indexer/sinks/nats/provision"
 )
 
 type ServiceConfig struct {
@@ -22,7 +24,10 @@ type ServiceConfig struct {
 	Consumer    string
 	PullBatch   int
 	PullTimeout time.Duration
-	Writer      Config
+	// SlotTimeGrace is how long swaps wait for their slot's block head before
+	// being written with an estimated timestamp.
+	SlotTimeGrace time.Duration
+	Writer        Config
 }
 
 func (c ServiceConfig) Validate() error {
@@ -44,18 +49,22 @@ func (c ServiceConfig) Validate() error {
 	if c.PullTimeout <= 0 {
 		return fmt.Errorf("pull timeout must be positive")
 	}
+	if c.SlotTimeGrace < 0 {
+		return fmt.Errorf("slot time grace must be non-negative")
+	}
 	return c.Writer.Validate()
 }
 
 func ServiceConfigFromEnv() (ServiceConfig, error) {
 	cfg := ServiceConfig{
-		NATSURL:     os.Getenv("PARQUET_NATS_URL"),
-		Stream:      os.Getenv("PARQUET_NATS_STREAM"),
-		SubjectRoot: valueOrDefault(os.Getenv("PARQUET_SUBJECT_ROOT"), "dex.sol"),
-		Consumer:    valueOrDefault(os.Getenv("PARQUET_CONSUMER"), "parquet-sink"),
-		PullBatch:   256,
-		PullTimeout: 500 * time.Millisecond,
-		Writer:      DefaultConfig(),
+		NATSURL:       os.Getenv("PARQUET_NATS_URL"),
+		Stream:        os.Getenv("PARQUET_NATS_STREAM"),
+		SubjectRoot:   valueOrDefault(os.Getenv("PARQUET_SUBJECT_ROOT"), "dex.sol"),
+		Consumer:      valueOrDefault(os.Getenv("PARQUET_CONSUMER"), "parquet-sink"),
+		PullBatch:     256,
+		PullTimeout:   500 * time.Millisecond,
+		SlotTimeGrace: 2 * time.Second,
+		Writer:        DefaultConfig(),
 	}
 
 	if v := os.Getenv("PARQUET_PULL_BATCH"); v != "" {
@@ -72,6 +81,13 @@ func ServiceConfigFromEnv() (ServiceConfig, error) {
 		}
 		cfg.PullTimeout = time.Duration(ms) * time.Millisecond
 	}
+	if v := os.Getenv("PARQUET_SLOT_TIME_GRACE_MS"); v != "" {
+		ms, err := strconv.Atoi(v)
+		if err != nil || ms < 0 {
+			return ServiceConfig{}, fmt.Errorf("invalid PARQUET_SLOT_TIME_GRACE_MS: %q", v)
+		}
+		cfg.SlotTimeGrace = time.Duration(ms) * time.Millisecond
+	}
 
 	writerCfg, err := FromEnv()
 	if err != nil {
@@ -87,6 +103,7 @@ type Service struct {
 	js        nats.JetStreamContext
 	sub       *nats.Subscription
 	writer    *Writer
+	swaps     *stamper
 	flushTick *time.Ticker
 }
 
@@ -107,19 +124,31 @@ func NewService(ctx context.Context, cfg ServiceConfig) (*Service, error) {
 		return nil, fmt.Errorf("jetstream: %w", err)
 	}
 
-	subject := cfg.SubjectRoot + ".candle.>"
+	if err := provision.VerifyFromEnv(js, cfg.Stream, cfg.Consumer); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	subject := cfg.SubjectRoot + ".>"
 	sub, err := js.PullSubscribe(subject, cfg.Consumer, nats.BindStream(cfg.Stream), nats.ManualAck())
 	if err != nil {
 		conn.Close()
 		return nil, fmt.Errorf("pull subscribe: %w", err)
 	}
 
+	slotTimes, err := common.SlotTimeCacheFromEnv(js)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("init slot cache: %w", err)
+	}
+
 	return &Service{
 		cfg:       cfg,
 		conn:      conn,
 		js:        js,
 		sub:       sub,
 		writer:    writer,
+		swaps:     newStamper(writer, slotTimes, cfg.SlotTimeGrace),
 		flushTick: time.NewTicker(cfg.Writer.FlushInterval),
 	}, nil
 }
@@ -128,6 +157,8 @@ func (s *Service) Run(ctx context.Context) error {
 	defer s.flushTick.Stop()
 	defer s.conn.Drain()
 	defer s.writer.Close()
+	// Swaps still waiting for a block time are written with estimates.
+	defer s.swaps.release(context.Background(), 0)
 
 	for {
 		select {
@@ -139,6 +170,9 @@ func (s *Service) Run(ctx context.Context) error {
 			}
 		default:
 		}
+		if err := s.swaps.release(ctx, s.cfg.SlotTimeGrace); err != nil {
+			return err
+		}
 
 		msgs, err := s.sub.Fetch(s.cfg.PullBatch, nats.MaxWait(s.cfg.PullTimeout))
 		if errors.Is(err, nats.ErrTimeout) {
@@ -159,16 +193,28 @@ func (s *Service) Run(ctx context.Context) error {
 }
 
 func (s *Service) handleMessage(ctx context.Context, msg *nats.Msg) error {
-	subject := msg.Subject
-	if !strings.Contains(subject, ".candle.") {
+	switch natsx.EventKind(msg) {
+	case natsx.KindSwap:
+		var event dexv1.SwapEvent
+		if err := proto.Unmarshal(msg.Data, &event); err != nil {
+			return fmt.Errorf("unmarshal swap: %w", err)
+		}
+		return s.swaps.handleSwap(ctx, &event)
+	case natsx.KindBlockHead:
+		var head dexv1.BlockHead
+		if err := proto.Unmarshal(msg.Data, &head); err != nil {
+			return fmt.Errorf("unmarshal block head: %w", err)
+		}
+		return s.swaps.handleBlockHead(ctx, &head)
+	case natsx.KindCandlePool, natsx.KindCandlePair:
+		var candle dexv1.Candle
+		if err := proto.Unmarshal(msg.Data, &candle); err != nil {
+			return fmt.Errorf("unmarshal candle: %w", err)
+		}
+		return s.writer.AppendCandle(ctx, &candle)
+	default:
 		return nil
 	}
-
-	var candle dexv1.Candle
-	if err := proto.Unmarshal(msg.Data, &candle); err != nil {
-		return fmt.Errorf("unmarshal candle: %w", err)
-	}
-	return s.writer.AppendCandle(ctx, &candle)
 }
 
 func valueOrDefault(value, fallback string) string {
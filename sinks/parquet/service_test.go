@@ -0,0 +1,81 @@
+package parquet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+	"github.com/rexbrahh/lp-indexer/ingestor/common"
+)
+
+type stubSwapWriter struct {
+	swaps []*dexv1.SwapEvent
+	times []time.Time
+}
+
+func (w *stubSwapWriter) AppendSwap(_ context.Context, event *dexv1.SwapEvent, ts time.Time) error {
+	w.swaps = append(w.swaps, event)
+	w.times = append(w.times, ts)
+	return nil
+}
+
+func TestStamperHoldsSwapsUntilBlockHead(t *testing.T) {
+	ctx := context.Background()
+	writer := &stubSwapWriter{}
+	s := newStamper(writer, common.NewMemorySlotTimeCache(), time.Second)
+
+	if err := s.handleSwap(ctx, &dexv1.SwapEvent{Slot: 10, Sig: "a"}); err != nil {
+		t.Fatalf("handleSwap: %v", err)
+	}
+	if len(writer.swaps) != 0 {
+		t.Fatalf("swap written before its block time: %+v", writer.swaps)
+	}
+
+	blockTime := time.Unix(1_700_000_000, 0).UTC()
+	if err := s.handleBlockHead(ctx, &dexv1.BlockHead{Slot: 10, TsSec: uint64(blockTime.Unix())}); err != nil {
+		t.Fatalf("handleBlockHead: %v", err)
+	}
+	if err := s.handleSwap(ctx, &dexv1.SwapEvent{Slot: 10, Sig: "b"}); err != nil {
+		t.Fatalf("handleSwap: %v", err)
+	}
+	if len(writer.swaps) != 2 {
+		t.Fatalf("expected 2 swaps, got %d", len(writer.swaps))
+	}
+	for i, ts := range writer.times {
+		if !ts.Equal(blockTime) {
+			t.Fatalf("swap %d ts = %v, want %v", i, ts, blockTime)
+		}
+	}
+}
+
+func TestStamperEstimatesAfterGrace(t *testing.T) {
+	ctx := context.Background()
+	writer := &stubSwapWriter{}
+	slotTimes := common.NewMemorySlotTimeCache()
+	slotTimes.Set(9, time.Unix(1_700_000_000, 0).UTC())
+	s := newStamper(writer, slotTimes, time.Second)
+	now := time.Unix(1_700_000_100, 0)
+	s.now = func() time.Time { return now }
+
+	if err := s.handleSwap(ctx, &dexv1.SwapEvent{Slot: 10}); err != nil {
+		t.Fatalf("handleSwap: %v", err)
+	}
+	if err := s.release(ctx, time.Second); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if len(writer.swaps) != 0 {
+		t.Fatal("swap released before grace expired")
+	}
+
+	now = now.Add(2 * time.Second)
+	if err := s.release(ctx, time.Second); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if len(writer.swaps) != 1 || writer.times[0].Unix() < 1_700_000_000 {
+		t.Fatalf("expected one swap with an estimated ts, got %v", writer.times)
+	}
+	if len(s.pending) != 0 {
+		t.Fatalf("pending not cleared: %d", len(s.pending))
+	}
+}
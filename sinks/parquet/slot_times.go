@@ -0,0 +1,112 @@
+package parquet
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+	"github.com/rexbrahh/lp-indexer/ingestor/common"
+)
+
+type swapWriter interface {
+	AppendSwap(ctx context.Context, event *dexv1.SwapEvent, ts time.Time) error
+}
+
+// stamper stamps swaps with their slot's block time. Parquet objects cannot be
+// rewritten, so swaps whose block head has not arrived are held for the grace
+// period and then written once with an estimated timestamp.
+type stamper struct {
+	writer    swapWriter
+	slotTimes common.SlotTimeCache
+	grace     time.Duration
+	pending   map[uint64]*pendingSwaps
+	now       func() time.Time
+}
+
+type pendingSwaps struct {
+	since  time.Time
+	events []*dexv1.SwapEvent
+}
+
+func newStamper(writer swapWriter, slotTimes common.SlotTimeCache, grace time.Duration) *stamper {
+	return &stamper{
+		writer:    writer,
+		slotTimes: slotTimes,
+		grace:     grace,
+		pending:   make(map[uint64]*pendingSwaps),
+		now:       time.Now,
+	}
+}
+
+func (s *stamper) handleBlockHead(ctx context.Context, head *dexv1.BlockHead) error {
+	if head == nil {
+		return nil
+	}
+	if strings.ToLower(head.GetStatus()) == "dead" {
+		s.slotTimes.Delete(head.GetSlot())
+		return s.writeSlot(ctx, head.GetSlot(), s.estimate(head.GetSlot()))
+	}
+	if head.GetTsSec() == 0 {
+		return nil
+	}
+	ts := time.Unix(int64(head.GetTsSec()), 0).UTC()
+	s.slotTimes.Set(head.GetSlot(), ts)
+	return s.writeSlot(ctx, head.GetSlot(), ts)
+}
+
+func (s *stamper) handleSwap(ctx context.Context, event *dexv1.SwapEvent) error {
+	if event == nil {
+		return nil
+	}
+	if ts, err := s.slotTimes.Get(event.GetSlot()); err == nil {
+		return s.writer.AppendSwap(ctx, event, ts)
+	}
+	ps, ok := s.pending[event.GetSlot()]
+	if !ok {
+		ps = &pendingSwaps{since: s.now()}
+		s.pending[event.GetSlot()] = ps
+	}
+	ps.events = append(ps.events, event)
+	return nil
+}
+
+// release writes swaps held for at least olderThan with estimated timestamps.
+func (s *stamper) release(ctx context.Context, olderThan time.Duration) error {
+	now := s.now()
+	for slot, ps := range s.pending {
+		if now.Sub(ps.since) < olderThan {
+			continue
+		}
+		if err := s.writeSlot(ctx, slot, s.estimate(slot)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *stamper) writeSlot(ctx context.Context, slot uint64, ts time.Time) error {
+	ps, ok := s.pending[slot]
+	if !ok {
+		return nil
+	}
+	delete(s.pending, slot)
+	for _, event := range ps.events {
+		if err := s.writer.AppendSwap(ctx, event, ts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// estimate returns the slot time estimated from neighbouring slots, falling
+// back to when the slot's first swap arrived.
+func (s *stamper) estimate(slot uint64) time.Time {
+	if ts, _, err := s.slotTimes.Estimate(slot); err == nil {
+		return ts
+	}
+	if ps, ok := s.pending[slot]; ok {
+		return ps.since.UTC().Truncate(time.Second)
+	}
+	return s.now().UTC().Truncate(time.Second)
+}
@@ -22,34 +22,67 @@ import (
 
 var ErrWriterDisabled = errors.New("parquet writer disabled: missing configuration")
 
-// Writer buffers candles and periodically uploads Parquet files to S3-compatible storage.
+// Writer buffers candles and trades and periodically uploads Parquet files to
+// S3-compatible storage.
 type Writer struct {
 	cfg Config
 
 	mu        sync.Mutex
 	buckets   map[string][]candleRow
+	trades    []tradeRow
 	uploader  *s3manager.Uploader
 	lastFlush time.Time
 }
 
+type tradeRow struct {
+	ChainID     int32   `parquet:"name=chain_id,type=INT32"`
+	Slot        uint64  `parquet:"name=slot,type=INT64"`
+	Timestamp   int64   `parquet:"name=ts,type=INT64,logicaltype=TIMESTAMP(isAdjustedToUTC=true,unit=SECONDS)"`
+	Signature   string  `parquet:"name=sig,type=BYTE_ARRAY,convertedtype=UTF8"`
+	Index       uint32  `parquet:"name=idx,type=INT32"`
+	ProgramID   string  `parquet:"name=program_id,type=BYTE_ARRAY,convertedtype=UTF8"`
+	PoolID      string  `parquet:"name=pool_id,type=BYTE_ARRAY,convertedtype=UTF8"`
+	PairID      string  `parquet:"name=pair_id,type=BYTE_ARRAY,convertedtype=UTF8"`
+	MintBase    string  `parquet:"name=mint_base,type=BYTE_ARRAY,convertedtype=UTF8"`
+	MintQuote   string  `parquet:"name=mint_quote,type=BYTE_ARRAY,convertedtype=UTF8"`
+	DecBase     int32   `parquet:"name=dec_base,type=INT32"`
+	DecQuote    int32   `parquet:"name=dec_quote,type=INT32"`
+	BaseIn      uint64  `parquet:"name=base_in,type=INT64"`
+	BaseOut     uint64  `parquet:"name=base_out,type=INT64"`
+	QuoteIn     uint64  `parquet:"name=quote_in,type=INT64"`
+	QuoteOut    uint64  `parquet:"name=quote_out,type=INT64"`
+	PriceQ32    int64   `parquet:"name=price_q32,type=INT64"`
+	FeeBps      int32   `parquet:"name=fee_bps,type=INT32"`
+	LPFee       uint64  `parquet:"name=lp_fee,type=INT64"`
+	ProtocolFee uint64  `parquet:"name=protocol_fee,type=INT64"`
+	Trader      string  `parquet:"name=trader,type=BYTE_ARRAY,convertedtype=UTF8"`
+	FeePayer    string  `parquet:"name=fee_payer,type=BYTE_ARRAY,convertedtype=UTF8"`
+	Provisional bool    `parquet:"name=provisional,type=BOOLEAN"`
+	IsUndo      bool    `parquet:"name=is_undo,type=BOOLEAN"`
+	PriceUSD    float64 `parquet:"name=price_usd,type=DOUBLE"`
+	VolumeUSD   float64 `parquet:"name=volume_usd,type=DOUBLE"`
+}
+
 type candleRow struct {
-	ChainID      int32  `parquet:"name=chain_id,type=INT32"`
-	PairID       string `parquet:"name=pair_id,type=BYTE_ARRAY,convertedtype=UTF8"`
-	PoolID       string `parquet:"name=pool_id,type=BYTE_ARRAY,convertedtype=UTF8"`
-	Scope        string `parquet:"name=scope,type=BYTE_ARRAY,convertedtype=UTF8"`
-	Timeframe    string `parquet:"name=timeframe,type=BYTE_ARRAY,convertedtype=UTF8"`
-	WindowStart  int64  `parquet:"name=window_start,type=INT64,logicaltype=TIMESTAMP(isAdjustedToUTC=true,unit=SECONDS)"`
-	Provisional  bool   `parquet:"name=provisional,type=BOOLEAN"`
-	IsCorrection bool   `parquet:"name=is_correction,type=BOOLEAN"`
-	OpenPxQ32    int64  `parquet:"name=open_px_q32,type=INT64"`
-	HighPxQ32    int64  `parquet:"name=high_px_q32,type=INT64"`
-	LowPxQ32     int64  `parquet:"name=low_px_q32,type=INT64"`
-	ClosePxQ32   int64  `parquet:"name=close_px_q32,type=INT64"`
-	VolBaseHi    uint64 `parquet:"name=vol_base_hi,type=INT64"`
-	VolBaseLo    uint64 `parquet:"name=vol_base_lo,type=INT64"`
-	VolQuoteHi   uint64 `parquet:"name=vol_quote_hi,type=INT64"`
-	VolQuoteLo   uint64 `parquet:"name=vol_quote_lo,type=INT64"`
-	Trades       int32  `parquet:"name=trades,type=INT32"`
+	ChainID      int32   `parquet:"name=chain_id,type=INT32"`
+	PairID       string  `parquet:"name=pair_id,type=BYTE_ARRAY,convertedtype=UTF8"`
+	PoolID       string  `parquet:"name=pool_id,type=BYTE_ARRAY,convertedtype=UTF8"`
+	Scope        string  `parquet:"name=scope,type=BYTE_ARRAY,convertedtype=UTF8"`
+	Timeframe    string  `parquet:"name=timeframe,type=BYTE_ARRAY,convertedtype=UTF8"`
+	WindowStart  int64   `parquet:"name=window_start,type=INT64,logicaltype=TIMESTAMP(isAdjustedToUTC=true,unit=SECONDS)"`
+	Provisional  bool    `parquet:"name=provisional,type=BOOLEAN"`
+	IsCorrection bool    `parquet:"name=is_correction,type=BOOLEAN"`
+	OpenPxQ32    int64   `parquet:"name=open_px_q32,type=INT64"`
+	HighPxQ32    int64   `parquet:"name=high_px_q32,type=INT64"`
+	LowPxQ32     int64   `parquet:"name=low_px_q32,type=INT64"`
+	ClosePxQ32   int64   `parquet:"name=close_px_q32,type=INT64"`
+	VolBaseHi    uint64  `parquet:"name=vol_base_hi,type=INT64"`
+	VolBaseLo    uint64  `parquet:"name=vol_base_lo,type=INT64"`
+	VolQuoteHi   uint64  `parquet:"name=vol_quote_hi,type=INT64"`
+	VolQuoteLo   uint64  `parquet:"name=vol_quote_lo,type=INT64"`
+	Trades       int32   `parquet:"name=trades,type=INT32"`
+	PriceUSD     float64 `parquet:"name=price_usd,type=DOUBLE"`
+	VolumeUSD    float64 `parquet:"name=volume_usd,type=DOUBLE"`
 }
 
 // NewWriter validates configuration and prepares a Writer.
@@ -81,12 +114,54 @@ func NewWriter(cfg Config) (*Writer, error) {
 	}, nil
 }
 
-func (w *Writer) AppendSwap(ctx context.Context, event *dexv1.SwapEvent) error {
-	_ = ctx
-	_ = event
+// AppendSwap buffers a swap stamped with its slot's block time.
+func (w *Writer) AppendSwap(ctx context.Context, event *dexv1.SwapEvent, ts time.Time) error {
+	if event == nil {
+		return errors.New("nil swap event")
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.trades = append(w.trades, newTradeRow(event, ts))
+
+	if len(w.trades) >= w.cfg.BatchRows || time.Since(w.lastFlush) >= w.cfg.FlushInterval {
+		return w.flushLocked(ctx)
+	}
 	return nil
 }
 
+func newTradeRow(event *dexv1.SwapEvent, ts time.Time) tradeRow {
+	return tradeRow{
+		ChainID:     int32(event.GetChainId()),
+		Slot:        event.GetSlot(),
+		Timestamp:   ts.Unix(),
+		Signature:   event.GetSig(),
+		Index:       event.GetIndex(),
+		ProgramID:   event.GetProgramId(),
+		PoolID:      event.GetPoolId(),
+		PairID:      event.GetPairId(),
+		MintBase:    event.GetMintBase(),
+		MintQuote:   event.GetMintQuote(),
+		DecBase:     int32(event.GetDecBase()),
+		DecQuote:    int32(event.GetDecQuote()),
+		BaseIn:      event.GetBaseIn(),
+		BaseOut:     event.GetBaseOut(),
+		QuoteIn:     event.GetQuoteIn(),
+		QuoteOut:    event.GetQuoteOut(),
+		PriceQ32:    event.GetPriceQ32(),
+		FeeBps:      int32(event.GetFeeBps()),
+		LPFee:       event.GetLpFee(),
+		ProtocolFee: event.GetProtocolFee(),
+		Trader:      event.GetTrader(),
+		FeePayer:    event.GetFeePayer(),
+		Provisional: event.GetProvisional(),
+		IsUndo:      event.GetIsUndo(),
+		PriceUSD:    event.GetPriceUsd(),
+		VolumeUSD:   event.GetVolumeUsd(),
+	}
+}
+
 func (w *Writer) AppendCandle(ctx context.Context, candle *dexv1.Candle) error {
 	if candle == nil {
 		return errors.New("nil candle")
@@ -118,6 +193,8 @@ func (w *Writer) AppendCandle(ctx context.Context, candle *dexv1.Candle) error {
 		LowPxQ32:     candle.GetLowPxQ32(),
 		ClosePxQ32:   candle.GetClosePxQ32(),
 		Trades:       int32(candle.GetTrades()),
+		PriceUSD:     candle.GetPriceUsd(),
+		VolumeUSD:    candle.GetVolumeUsd(),
 	}
 
 	if vb := candle.GetVolBase(); vb != nil {
@@ -150,10 +227,6 @@ func (w *Writer) Close() error {
 }
 
 func (w *Writer) flushLocked(ctx context.Context) error {
-	if len(w.buckets) == 0 {
-		return nil
-	}
-
 	for key, rows := range w.buckets {
 		if len(rows) == 0 {
 			continue
@@ -164,27 +237,50 @@ func (w *Writer) flushLocked(ctx context.Context) error {
 		}
 		w.buckets[key] = w.buckets[key][:0]
 	}
+	if len(w.trades) > 0 {
+		if err := w.writeTrades(ctx, w.trades); err != nil {
+			return err
+		}
+		w.trades = w.trades[:0]
+	}
 	w.lastFlush = time.Now()
 	return nil
 }
 
 func (w *Writer) writeBucket(ctx context.Context, timeframe, scope string, rows []candleRow) error {
+	body, err := encodeRows(rows)
+	if err != nil {
+		return err
+	}
+	return w.upload(ctx, w.objectKey(timeframe, scope), body)
+}
+
+func (w *Writer) writeTrades(ctx context.Context, rows []tradeRow) error {
+	body, err := encodeRows(rows)
+	if err != nil {
+		return err
+	}
+	return w.upload(ctx, w.tradesObjectKey(), body)
+}
+
+func encodeRows[T any](rows []T) ([]byte, error) {
 	buf := bytes.NewBuffer(nil)
 
-	writer := parquet.NewGenericWriter[candleRow](buf, parquet.Compression(&snappy.Codec{}))
+	writer := parquet.NewGenericWriter[T](buf, parquet.Compression(&snappy.Codec{}))
 	if _, err := writer.Write(rows); err != nil {
-		return fmt.Errorf("write parquet rows: %w", err)
+		return nil, fmt.Errorf("write parquet rows: %w", err)
 	}
 	if err := writer.Close(); err != nil {
-		return fmt.Errorf("close parquet writer: %w", err)
+		return nil, fmt.Errorf("close parquet writer: %w", err)
 	}
+	return buf.Bytes(), nil
+}
 
-	key := w.objectKey(timeframe, scope)
-
+func (w *Writer) upload(ctx context.Context, key string, body []byte) error {
 	_, err := w.uploader.UploadWithContext(ctx, &s3manager.UploadInput{
 		Bucket:      aws.String(w.cfg.Bucket),
 		Key:         aws.String(key),
-		Body:        bytes.NewReader(buf.Bytes()),
+		Body:        bytes.NewReader(body),
 		ContentType: aws.String("application/octet-stream"),
 	})
 	if err != nil {
@@ -200,6 +296,13 @@ func (w *Writer) objectKey(timeframe, scope string) string {
 	return filepath.Join(prefix, fmt.Sprintf("timeframe=%s", timeframe), fmt.Sprintf("scope=%s", scope), fmt.Sprintf("date=%s", date), filename)
 }
 
+func (w *Writer) tradesObjectKey() string {
+	prefix := strings.TrimSuffix(w.cfg.Prefix, "/")
+	date := time.Now().UTC().Format("2006-01-02")
+	filename := fmt.Sprintf("trades-%d.parquet", time.Now().UnixNano())
+	return filepath.Join(prefix, "trades", fmt.Sprintf("date=%s", date), filename)
+}
+
 func bucketKey(timeframe, scope string) string {
 	return timeframe + "|" + scope
 }
@@ -1,6 +1,14 @@
 package parquet
 
-import "testing"
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	dexv1 "github.com/rexbrahh/lp-indexer/gen/go/dex/sol/v1"
+)
 
 func TestWriterValidation(t *testing.T) {
 	cfg := DefaultConfig()
@@ -14,3 +22,38 @@ func TestWriterValidation(t *testing.T) {
 		t.Fatalf("expected ErrWriterDisabled, got %v", err)
 	}
 }
+
+func TestEncodeTradeRows(t *testing.T) {
+	blockTime := time.Unix(1_700_000_000, 0).UTC()
+	row := newTradeRow(&dexv1.SwapEvent{
+		ChainId:  501,
+		Slot:     42,
+		Sig:      "sig",
+		PoolId:   "pool",
+		PairId:   "base-quote",
+		BaseIn:   10,
+		QuoteOut: 20,
+		LpFee:    3,
+		Trader:   "wallet",
+		FeePayer: "payer",
+	}, blockTime)
+
+	body, err := encodeRows([]tradeRow{row})
+	if err != nil {
+		t.Fatalf("encodeRows: %v", err)
+	}
+
+	rows, err := parquet.Read[tradeRow](bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("read parquet: %v", err)
+	}
+	if len(rows) != 1 || rows[0] != row {
+		t.Fatalf("unexpected rows %+v", rows)
+	}
+	if rows[0].Timestamp != blockTime.Unix() {
+		t.Fatalf("ts = %d, want %d", rows[0].Timestamp, blockTime.Unix())
+	}
+	if rows[0].Trader != "wallet" || rows[0].FeePayer != "payer" {
+		t.Fatalf("trader attribution lost: %+v", rows[0])
+	}
+}